@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/ollama"
+	"github.com/olusolaa/goforai/foundation/prompts"
+	"github.com/olusolaa/goforai/foundation/retrieval"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"github.com/olusolaa/goforai/foundation/usage"
+	"github.com/spf13/cobra"
+)
+
+// askQuestion is a question of a batch file and the line it is on.
+type askQuestion struct {
+	line int
+	text string
+}
+
+// askAnswer is one line of `goforai ask` output.
+type askAnswer struct {
+	Line      int                `json:"line"` // Of the question in the batch file.
+	Question  string             `json:"question"`
+	Answer    string             `json:"answer,omitempty"` // Empty when no document matched.
+	Citations []chromemdb.Source `json:"citations"`        // The documents the answer cites.
+	Retrieved int                `json:"retrieved"`        // Documents above RAG_MIN_SCORE given to the model.
+	Usage     events.Usage       `json:"usage"`
+	LatencyMS int64              `json:"latency_ms"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// asker answers questions from the knowledge base, without tools or history.
+type asker struct {
+	kb       retriever.Retriever
+	model    model.BaseChatModel
+	prompt   *prompts.Template
+	minScore float64
+	topK     int // 0 keeps the knowledge base's default.
+}
+
+func newAskCmd() *cobra.Command {
+	var (
+		batch       string
+		outPath     string
+		concurrency int
+		topK        int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "ask [question]",
+		Short: "Answer questions from the knowledge base, one or a batch, as JSONL with citations",
+		Long: "Answers each question with the knowledge base alone: the documents most similar to it\n" +
+			"(above RAG_MIN_SCORE) go to the chat model, which answers citing them by number or says\n" +
+			"it does not know. Each answer is written as a JSON line with the documents it cites and\n" +
+			"the tokens it used, in the order of the questions, so a batch shows which questions the\n" +
+			"knowledge base covers. --batch reads one question per line; blank lines and lines\n" +
+			"starting with # are skipped.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (batch == "") == (len(args) == 0) {
+				return errors.New("give either a question or --batch")
+			}
+			if concurrency < 1 {
+				return fmt.Errorf("--concurrency must be at least 1, got %d", concurrency)
+			}
+			var questions []askQuestion
+			if batch != "" {
+				var err error
+				if questions, err = loadQuestions(batch); err != nil {
+					return err
+				}
+			} else {
+				questions = []askQuestion{{line: 1, text: args[0]}}
+			}
+
+			ctx := cmd.Context()
+			if !tools.Offline(ctx) {
+				if err := requireGeminiKey(ctx); err != nil {
+					return err
+				}
+			}
+			a, err := newAsker(ctx, topK)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if outPath != "" && outPath != "-" {
+				f, err := os.Create(outPath)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", outPath, err)
+				}
+				defer f.Close()
+				out = f
+			}
+			stats, err := a.answerAll(ctx, questions, concurrency, out, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "%d questions: %d answered with citations, %d not covered, %d failed; %d tokens\n",
+				len(questions), stats.cited, len(questions)-stats.cited-stats.failed, stats.failed, stats.tokens)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&batch, "batch", "", "file of questions, one per line")
+	cmd.Flags().StringVar(&outPath, "out", "", "JSONL file to write the answers to (default: standard output)")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "questions answered at the same time")
+	cmd.Flags().IntVar(&topK, "top-k", 0, "documents retrieved per question (default: the knowledge base tool's)")
+	return cmd
+}
+
+// loadQuestions reads a batch file.
+func loadQuestions(path string) ([]askQuestion, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open batch file: %w", err)
+	}
+	defer f.Close()
+
+	var questions []askQuestion
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		questions = append(questions, askQuestion{line: line, text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %w", err)
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("%s holds no questions", path)
+	}
+	return questions, nil
+}
+
+// newAsker opens the knowledge base and the chat model: Gemini, or the local
+// Ollama model in offline mode.
+func newAsker(ctx context.Context, topK int) (*asker, error) {
+	kb, err := tools.OpenKnowledgeBase(ctx)
+	if errors.Is(err, chromemdb.ErrDBNotFound) {
+		return nil, fmt.Errorf("%w; build it with 'goforai index'", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := prompts.Get("ask.rag")
+	if err != nil {
+		return nil, err
+	}
+	if err := prompt.Expect("context", "question"); err != nil {
+		return nil, err
+	}
+	minScore, err := config.RAGMinScore()
+	if err != nil {
+		return nil, err
+	}
+	expansion, err := config.LoadExpansion()
+	if err != nil {
+		return nil, err
+	}
+
+	var chatModel model.BaseChatModel
+	if tools.Offline(ctx) {
+		cfg, err := config.LoadOffline()
+		if err != nil {
+			return nil, err
+		}
+		offlineModel, err := ollama.NewChatModel(ctx, cfg.ChatModel, cfg.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create offline chat model: %w", err)
+		}
+		chatModel = usage.ChatModel(ollama.Provider, cfg.ChatModel, offlineModel)
+	} else if chatModel, err = gemini.NewChatModel(ctx); err != nil {
+		return nil, err
+	}
+	return &asker{kb: retrieval.Expand(kb, expansion), model: chatModel, prompt: prompt, minScore: minScore, topK: topK}, nil
+}
+
+// askStats sums up a batch.
+type askStats struct {
+	cited  int // Answers citing at least one document.
+	failed int
+	tokens int
+}
+
+// answerAll answers the questions, concurrency at a time, and writes the
+// answers to out in the order of the questions as soon as each is known.
+// Progress goes to progress. A failed question is written with its error and
+// does not stop the batch.
+func (a *asker) answerAll(ctx context.Context, questions []askQuestion, concurrency int, out, progress io.Writer) (askStats, error) {
+	answers := make([]chan askAnswer, len(questions))
+	for i := range answers {
+		answers[i] = make(chan askAnswer, 1)
+	}
+	// Questions are started in order, so with a concurrency of 1 the batch
+	// runs as written.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sem := make(chan struct{}, concurrency)
+		for i, q := range questions {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				answers[i] <- askAnswer{Line: q.line, Question: q.text, Citations: []chromemdb.Source{}, Error: ctx.Err().Error()}
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				answers[i] <- a.answer(ctx, q)
+			}()
+		}
+	}()
+	defer wg.Wait()
+
+	var stats askStats
+	enc := json.NewEncoder(out)
+	for i, ch := range answers {
+		answer := <-ch
+		if err := enc.Encode(answer); err != nil {
+			return stats, fmt.Errorf("failed to write answer: %w", err)
+		}
+		stats.tokens += answer.Usage.TotalTokens
+		switch {
+		case answer.Error != "":
+			stats.failed++
+		case len(answer.Citations) > 0:
+			stats.cited++
+		}
+		if len(questions) > 1 {
+			fmt.Fprintf(progress, "\ranswered %d/%d", i+1, len(questions))
+		}
+	}
+	if len(questions) > 1 {
+		fmt.Fprintln(progress)
+	}
+	return stats, ctx.Err()
+}
+
+// answer runs one question through the retrieval and the model.
+func (a *asker) answer(ctx context.Context, q askQuestion) (answer askAnswer) {
+	start := time.Now()
+	answer = askAnswer{Line: q.line, Question: q.text, Citations: []chromemdb.Source{}}
+	defer func() { answer.LatencyMS = time.Since(start).Milliseconds() }()
+	fail := func(err error) askAnswer {
+		answer.Error = err.Error()
+		return answer
+	}
+
+	var opts []retriever.Option
+	if a.topK > 0 {
+		opts = append(opts, retriever.WithTopK(a.topK))
+	}
+	docs, err := a.kb.Retrieve(ctx, q.text, opts...)
+	if err != nil {
+		return fail(fmt.Errorf("failed to search the knowledge base: %w", err))
+	}
+	relevant := docs[:0]
+	for _, doc := range docs {
+		if doc.Score() >= a.minScore {
+			relevant = append(relevant, doc)
+		}
+	}
+	// Without a document the knowledge base does not cover the question,
+	// and the model is not asked.
+	if len(relevant) == 0 {
+		return answer
+	}
+	result := chromemdb.NewResult(relevant)
+	answer.Retrieved = len(result.Sources)
+
+	text, err := a.prompt.Render(map[string]any{"context": result.Answer, "question": q.text})
+	if err != nil {
+		return fail(err)
+	}
+	msg, err := a.model.Generate(ctx, []*schema.Message{schema.UserMessage(text)})
+	if err != nil {
+		return fail(err)
+	}
+	answer.Answer = strings.TrimSpace(msg.Content)
+	if msg.ResponseMeta != nil && msg.ResponseMeta.Usage != nil {
+		u := msg.ResponseMeta.Usage
+		answer.Usage = events.Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+	}
+	for _, n := range citedSources(answer.Answer, len(result.Sources)) {
+		answer.Citations = append(answer.Citations, result.Sources[n-1])
+	}
+	return answer
+}
+
+// citation matches a reference such as [2] in an answer.
+var citation = regexp.MustCompile(`\[(\d+)\]`)
+
+// citedSources returns the numbers, from 1 to n, that answer cites, in the
+// order of their first citation.
+func citedSources(answer string, n int) []int {
+	var cited []int
+	seen := make(map[int]bool)
+	for _, m := range citation.FindAllStringSubmatch(answer, -1) {
+		i, err := strconv.Atoi(m[1])
+		if err != nil || i < 1 || i > n || seen[i] {
+			continue
+		}
+		seen[i] = true
+		cited = append(cited, i)
+	}
+	return cited
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/olusolaa/goforai/example01/step5/ui"
+	"github.com/spf13/cobra"
+)
+
+func newChatCmd() *cobra.Command {
+	var (
+		transcriptPath string
+		verbose        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "chat",
+		Short: "Start an interactive session with the coding agent",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireGeminiKey(cmd.Context()); err != nil {
+				return err
+			}
+
+			var opts []agent.Option
+			if transcriptPath != "" {
+				f, err := os.OpenFile(transcriptPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+				if err != nil {
+					return fmt.Errorf("failed to open transcript: %w", err)
+				}
+				defer f.Close()
+				fmt.Fprintf(f, "=== goforai chat, %s ===\n", time.Now().Format(time.RFC3339))
+				opts = append(opts, agent.WithTranscript(f))
+			}
+
+			terminal := ui.New()
+			terminal.SetVerbose(verbose)
+			gopherAgent, err := agent.New(cmd.Context(), terminal, opts...)
+			if err != nil {
+				return err
+			}
+			defer gopherAgent.Close(context.Background())
+			return gopherAgent.Run(cmd.Context())
+		},
+	}
+
+	cmd.Flags().StringVar(&transcriptPath, "log-transcript", "", "append the conversation and a line per tool call to this file as it happens")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show what each tool returns (toggle with /verbose on|off)")
+	return cmd
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/indexing"
+	"github.com/olusolaa/goforai/foundation/ollama"
+	"github.com/olusolaa/goforai/foundation/secrets"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"github.com/spf13/cobra"
+)
+
+// checkTimeout bounds each check of `goforai doctor`.
+const checkTimeout = 20 * time.Second
+
+// warning marks a problem that leaves goforai usable, such as a missing
+// optional key or knowledge base.
+type warning struct{ error }
+
+func (w warning) Unwrap() error { return w.error }
+
+// checkup is one check of `goforai doctor`. run returns what it found, or
+// the problem; fix is the advice for problems the error taxonomy has none for.
+type checkup struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+	fix  string
+}
+
+func newDoctorCmd() *cobra.Command {
+	var dbPath string
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check API keys, models, the knowledge base and tool prerequisites, and say how to fix problems",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			failed := diagnose(cmd.Context(), cmd.OutOrStdout(), doctorChecks(cmd.Context(), dbPath))
+			if failed > 0 {
+				return fmt.Errorf("%d check(s) failed", failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&dbPath, "db", indexing.DefaultDBPath, "path of the exported knowledge base")
+	return cmd
+}
+
+// diagnose runs checks in order, prints a line per check with the fix for
+// each problem, and returns how many failed. Warnings do not count.
+func diagnose(ctx context.Context, out io.Writer, checks []checkup) int {
+	var failed int
+	for _, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		detail, err := c.run(checkCtx)
+		cancel()
+
+		var warn warning
+		switch {
+		case err == nil:
+			fmt.Fprintf(out, "✅ %s: %s\n", c.name, detail)
+			continue
+		case errors.As(err, &warn):
+			fmt.Fprintf(out, "⚠️  %s: %v\n", c.name, err)
+		default:
+			failed++
+			fmt.Fprintf(out, "❌ %s: %v\n", c.name, err)
+		}
+		if fix := fixFor(err, c.fix); fix != "" {
+			fmt.Fprintf(out, "   → %s\n", fix)
+		}
+	}
+	return failed
+}
+
+// fixFor returns the advice for err by its place in the error taxonomy, or
+// fallback for errors outside it.
+func fixFor(err error, fallback string) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, secrets.ErrNotFound):
+		return "Run 'goforai init', or store the key with 'goforai secrets set <NAME>'."
+	case errors.Is(err, gemini.ErrInvalidAPIKey):
+		return "Create a key at https://aistudio.google.com/app/apikey and store it with 'goforai secrets set GEMINI_API_KEY'."
+	case errors.Is(err, ollama.ErrUnavailable):
+		return "Start Ollama with 'ollama serve', or point OLLAMA_BASE_URL at the server."
+	case errors.Is(err, gemini.ErrRateLimited):
+		return "Wait a minute and try again, or check the quota of the key's project."
+	case errors.Is(err, chromemdb.ErrDBNotFound):
+		return "Build it with 'goforai index' (or 'make setup'); until then the agent runs without it."
+	case errors.Is(err, chromemdb.ErrIndexMismatch):
+		return "Re-run 'goforai index', or set EMBEDDING_PROVIDER and EMBEDDING_MODEL back to the ones the index was built with."
+	case errors.Is(err, exec.ErrNotFound):
+		return "Install it and make sure it is on PATH."
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return "Check the network connection, and HTTPS_PROXY if you are behind a proxy."
+	}
+	return fallback
+}
+
+// doctorChecks lists the checks in the order they run. The knowledge base
+// check compares the index with the vectors the embedder check got back.
+// Offline, the local chat model is checked instead of Gemini, and the
+// network is not.
+func doctorChecks(ctx context.Context, dbPath string) []checkup {
+	var embedModel string
+	var dimension int
+
+	checks := []checkup{{
+		name: "Gemini API key",
+		run: func(context.Context) (string, error) {
+			_, source, err := secrets.Resolve(secrets.GeminiAPIKey)
+			if err != nil {
+				return "", err
+			}
+			return "set (" + source + ")", nil
+		},
+	}, {
+		name: "Chat model",
+		run: func(ctx context.Context) (string, error) {
+			if err := gemini.Ping(ctx); err != nil {
+				if errors.Is(err, gemini.ErrRateLimited) {
+					return "", warning{err}
+				}
+				return "", err
+			}
+			return gemini.ChatModel() + " is available", nil
+		},
+		fix: "Check that CHAT_MODEL names a model your key can use; 'goforai init' lists them.",
+	}}
+	if tools.Offline(ctx) {
+		checks = []checkup{{
+			name: "Offline chat model",
+			run: func(ctx context.Context) (string, error) {
+				cfg, err := config.LoadOffline()
+				if err != nil {
+					return "", err
+				}
+				ping, err := modelCheck(ctx)
+				if err != nil {
+					return "", err
+				}
+				if err := ping(ctx); err != nil {
+					return "", err
+				}
+				return cfg.ChatModel + " is available in Ollama", nil
+			},
+			fix: "Pull the model named by OFFLINE_CHAT_MODEL with 'ollama pull', or point OLLAMA_BASE_URL at the server.",
+		}}
+	}
+
+	checks = append(checks, []checkup{
+		{
+			name: "Embedder",
+			run: func(ctx context.Context) (string, error) {
+				cfg, err := config.LoadEmbeddings()
+				if err != nil {
+					return "", err
+				}
+				embedModel, dimension, err = probeEmbedder(ctx, cfg)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s returns %d-dimensional vectors", embedModel, dimension), nil
+			},
+			fix: "Check EMBEDDING_PROVIDER, EMBEDDING_MODEL and EMBEDDING_BASE_URL; for Ollama run 'ollama serve' and 'ollama pull <model>'.",
+		},
+		{
+			name: "Knowledge base",
+			run: func(ctx context.Context) (string, error) {
+				stats, err := chromemdb.Verify(dbPath, embedModel, dimension)
+				if errors.Is(err, chromemdb.ErrDBNotFound) {
+					return "", warning{err}
+				}
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s holds %d documents with %d-dimensional vectors", dbPath, stats.Documents, stats.Dimension), nil
+			},
+			fix: "The file is damaged; delete it and rebuild it with 'goforai index'.",
+		},
+		{
+			name: "git",
+			run:  lookPath("git"),
+			fix:  "The clone and pull tools need git.",
+		},
+		{
+			name: "go",
+			run: func(ctx context.Context) (string, error) {
+				path, err := lookPath("go")(ctx)
+				if err != nil {
+					return "", warning{fmt.Errorf("%w; the rename and environment tools need it", err)}
+				}
+				return path, nil
+			},
+		},
+	}...)
+	if tools.Offline(ctx) {
+		return checks
+	}
+	return append(checks, []checkup{
+		{
+			name: "GitHub",
+			run:  reachable("https://github.com"),
+			fix:  "The clone tool needs to reach github.com.",
+		},
+		{
+			name: "Web search",
+			run: func(ctx context.Context) (string, error) {
+				cfg, err := config.LoadSearch()
+				if err != nil {
+					return "", err
+				}
+				switch _, keyErr := secrets.Get(secrets.TavilyAPIKey); {
+				case cfg.Provider == config.SearchGemini:
+					// The chat model check covers the API.
+					return "Gemini search grounding with " + gemini.ChatModel(), nil
+				case keyErr == nil && cfg.Provider != config.SearchDuckDuckGo:
+					return reachable(config.TavilyBaseURL())(ctx)
+				case cfg.Provider == config.SearchTavily:
+					return "", keyErr
+				}
+				detail, err := reachable(config.DuckDuckGoBaseURL())(ctx)
+				if err != nil {
+					return "", warning{err}
+				}
+				if cfg.Provider == config.SearchDuckDuckGo {
+					return detail + " (DuckDuckGo)", nil
+				}
+				return detail + " (DuckDuckGo; set TAVILY_API_KEY for better results)", nil
+			},
+		},
+	}...)
+}
+
+// lookPath checks that the program is installed.
+func lookPath(program string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		path, err := exec.LookPath(program)
+		if err != nil {
+			return "", fmt.Errorf("%s is not installed: %w", program, err)
+		}
+		return path, nil
+	}
+}
+
+// reachable checks that url answers HTTP requests, whatever the status.
+func reachable(url string) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("%s is unreachable: %w", url, err)
+		}
+		resp.Body.Close()
+		return url + " is reachable", nil
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/olusolaa/goforai/example01/step5/ui"
+	"github.com/spf13/cobra"
+)
+
+// evalCase is one line of an eval file: a question and the phrases a correct
+// answer must contain (case-insensitive).
+type evalCase struct {
+	Question string   `json:"question"`
+	Expect   []string `json:"expect"`
+}
+
+func newEvalCmd() *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "eval",
+		Short: "Run a JSONL file of questions through the agent and score the answers",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireGeminiKey(cmd.Context()); err != nil {
+				return err
+			}
+
+			cases, err := loadEvalCases(file)
+			if err != nil {
+				return err
+			}
+
+			gopherAgent, err := agent.New(cmd.Context(), ui.New())
+			if err != nil {
+				return err
+			}
+			defer gopherAgent.Close(context.Background())
+
+			out := cmd.OutOrStdout()
+			passed := 0
+			for i, c := range cases {
+				gopherAgent.Reset()
+				answer, err := gopherAgent.Ask(cmd.Context(), c.Question)
+				if err != nil {
+					fmt.Fprintf(out, "✗ [%d] %s\n    error: %v\n", i+1, c.Question, err)
+					continue
+				}
+
+				missing := missingPhrases(answer.Content, c.Expect)
+				if len(missing) == 0 {
+					passed++
+					fmt.Fprintf(out, "✓ [%d] %s\n", i+1, c.Question)
+					continue
+				}
+				fmt.Fprintf(out, "✗ [%d] %s\n    missing: %s\n", i+1, c.Question, strings.Join(missing, ", "))
+			}
+
+			fmt.Fprintf(out, "\n%d/%d passed\n", passed, len(cases))
+			if passed < len(cases) {
+				return fmt.Errorf("%d eval case(s) failed", len(cases)-passed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "eval/gophercon.jsonl", "JSONL file of eval cases")
+	return cmd
+}
+
+func loadEvalCases(path string) ([]evalCase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open eval file: %w", err)
+	}
+	defer f.Close()
+
+	var cases []evalCase
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var c evalCase
+		if err := json.Unmarshal([]byte(text), &c); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid eval case: %w", path, line, err)
+		}
+		cases = append(cases, c)
+	}
+	return cases, scanner.Err()
+}
+
+func missingPhrases(answer string, expect []string) []string {
+	lower := strings.ToLower(answer)
+	var missing []string
+	for _, phrase := range expect {
+		if !strings.Contains(lower, strings.ToLower(phrase)) {
+			missing = append(missing, phrase)
+		}
+	}
+	return missing
+}
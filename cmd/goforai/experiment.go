@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/olusolaa/goforai/example01/step5/ui"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/prompts"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"github.com/olusolaa/goforai/foundation/truncate"
+	"github.com/spf13/cobra"
+)
+
+// variant is one side of an experiment: settings that override the
+// environment while its agent is built and asked the eval cases.
+type variant struct {
+	Name     string            `json:"name"`
+	Settings map[string]string `json:"settings"`
+}
+
+// trial is one answer of a variant to an eval case.
+type trial struct {
+	score   float64 // Share of the expected phrases the answer contains.
+	latency time.Duration
+	tokens  int
+	cost    float64 // USD; zero for models without a known price.
+	err     error
+}
+
+// caseResult compares the variants on one eval case over every run.
+type caseResult struct {
+	Question string     `json:"question"`
+	Score    [2]float64 `json:"mean_score"`
+	Wins     [2]int     `json:"wins"`
+	Ties     int        `json:"ties"`
+	Errors   [2]int     `json:"errors"`
+}
+
+// variantResult sums up one variant over every case and run.
+type variantResult struct {
+	variant
+	Model     string  `json:"model"`
+	Trials    int     `json:"trials"`
+	Passed    int     `json:"passed"`
+	Wins      int     `json:"wins"`
+	WinRate   float64 `json:"win_rate"`
+	LatencyMS int64   `json:"mean_latency_ms"`
+	Tokens    int     `json:"tokens"`
+	Cost      float64 `json:"cost_usd"`
+}
+
+// experimentReport is what `goforai experiment --report` writes.
+type experimentReport struct {
+	Runs     int              `json:"runs"`
+	Variants [2]variantResult `json:"variants"`
+	Cases    []caseResult     `json:"cases"`
+}
+
+func newExperimentCmd() *cobra.Command {
+	var (
+		file       string
+		a, b       []string
+		runs       int
+		reportPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "experiment",
+		Short: "Run the eval suite against two prompt or config variants and compare them",
+		Long: "Asks every eval case of --file with variant A and with variant B, --runs times each, and\n" +
+			"reports per case how often each variant gave the better answer (more of the expected\n" +
+			"phrases), with their pass rates, mean latency, tokens and cost. A variant is a list of\n" +
+			"KEY=VALUE settings that override the environment while it runs, such as\n" +
+			"--a PROMPT_VERSIONS=step5.system=1 --b PROMPT_VERSIONS=step5.system=2, or a\n" +
+			"CHAT_MODEL, PROMPTS_DIR or RAG_TOP_K of its own.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if runs < 1 {
+				return fmt.Errorf("--runs must be at least 1, got %d", runs)
+			}
+			cases, err := loadEvalCases(file)
+			if err != nil {
+				return err
+			}
+			variants := [2]variant{}
+			for i, settings := range [][]string{a, b} {
+				if variants[i], err = parseVariant(string(rune('A'+i)), settings); err != nil {
+					return err
+				}
+			}
+
+			out := cmd.OutOrStdout()
+			var trials [2][][]trial
+			var modelNames [2]string
+			for i, v := range variants {
+				fmt.Fprintf(out, "Running variant %s (%s)...\n", v.Name, v.describe())
+				if trials[i], modelNames[i], err = runVariant(cmd.Context(), v, cases, runs); err != nil {
+					return fmt.Errorf("variant %s: %w", v.Name, err)
+				}
+			}
+
+			report := compareVariants(variants, modelNames, cases, trials, runs)
+			printReport(out, report)
+			if reportPath != "" {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(reportPath, append(data, '\n'), 0o644); err != nil {
+					return fmt.Errorf("failed to write report: %w", err)
+				}
+				fmt.Fprintf(out, "\nWrote the report to %s\n", reportPath)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "eval/gophercon.jsonl", "JSONL file of eval cases")
+	cmd.Flags().StringArrayVar(&a, "a", nil, "KEY=VALUE setting of variant A (repeatable)")
+	cmd.Flags().StringArrayVar(&b, "b", nil, "KEY=VALUE setting of variant B (repeatable)")
+	cmd.Flags().IntVar(&runs, "runs", 3, "times each variant answers each case")
+	cmd.Flags().StringVar(&reportPath, "report", "", "also write the report as JSON to this file")
+	return cmd
+}
+
+// parseVariant reads KEY=VALUE settings.
+func parseVariant(name string, settings []string) (variant, error) {
+	v := variant{Name: name, Settings: make(map[string]string, len(settings))}
+	for _, s := range settings {
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return variant{}, fmt.Errorf("variant %s: invalid setting %q; want KEY=VALUE", name, s)
+		}
+		v.Settings[strings.TrimSpace(key)] = value
+	}
+	return v, nil
+}
+
+// describe lists the variant's settings in a stable order.
+func (v variant) describe() string {
+	if len(v.Settings) == 0 {
+		return "current settings"
+	}
+	keys := make([]string, 0, len(v.Settings))
+	for key := range v.Settings {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for i, key := range keys {
+		keys[i] = key + "=" + v.Settings[key]
+	}
+	return strings.Join(keys, " ")
+}
+
+// apply sets the variant's settings in the environment and returns the
+// function that restores it.
+func (v variant) apply() func() {
+	type saved struct {
+		value string
+		set   bool
+	}
+	previous := make(map[string]saved, len(v.Settings))
+	for key, value := range v.Settings {
+		old, set := os.LookupEnv(key)
+		previous[key] = saved{old, set}
+		os.Setenv(key, value)
+	}
+	return func() {
+		for key, old := range previous {
+			if old.set {
+				os.Setenv(key, old.value)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	}
+}
+
+// runVariant builds the agent with the variant's settings and prompts, and
+// asks it every case runs times, each time without history. It returns the
+// trials by case and the name of the model that answered.
+func runVariant(ctx context.Context, v variant, cases []evalCase, runs int) ([][]trial, string, error) {
+	restore := v.apply()
+	defer restore()
+
+	library, err := prompts.FromConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	ctx = prompts.WithLibrary(ctx, library)
+	offline, err := config.LoadOffline()
+	if err != nil {
+		return nil, "", err
+	}
+	if offline.Enabled {
+		ctx = tools.WithOffline(ctx)
+	}
+	if err := requireGeminiKey(ctx); err != nil {
+		return nil, "", err
+	}
+
+	gopherAgent, err := agent.New(ctx, ui.New())
+	if err != nil {
+		return nil, "", err
+	}
+	defer gopherAgent.Close(context.Background())
+	caps, _ := models.Lookup(gopherAgent.Model())
+
+	trials := make([][]trial, len(cases))
+	for i, c := range cases {
+		for range runs {
+			gopherAgent.Reset()
+			// A nil dispatcher counts the turn's tokens without sending events.
+			turn := (*events.Dispatcher)(nil).StartTurn(events.Event{Prompt: c.Question})
+			start := time.Now()
+			answer, err := gopherAgent.Ask(ctx, c.Question, compose.WithCallbacks(turn.Handler()))
+			t := trial{latency: time.Since(start), err: err}
+			usage := turn.Usage()
+			t.tokens = usage.TotalTokens
+			t.cost = caps.Cost(usage.PromptTokens, usage.CompletionTokens)
+			if err == nil {
+				t.score = scoreAnswer(answer.Content, c.Expect)
+			}
+			trials[i] = append(trials[i], t)
+		}
+	}
+	return trials, gopherAgent.Model(), nil
+}
+
+// scoreAnswer returns the share of the expected phrases answer contains.
+func scoreAnswer(answer string, expect []string) float64 {
+	if len(expect) == 0 {
+		return 1
+	}
+	return 1 - float64(len(missingPhrases(answer, expect)))/float64(len(expect))
+}
+
+// compareVariants pairs the variants' trials run by run: the higher score
+// wins, and equal scores tie. A failed turn scores zero.
+func compareVariants(variants [2]variant, modelNames [2]string, cases []evalCase, trials [2][][]trial, runs int) experimentReport {
+	report := experimentReport{Runs: runs}
+	var latency [2]time.Duration
+	for i := range variants {
+		report.Variants[i] = variantResult{variant: variants[i], Model: modelNames[i]}
+	}
+	for c, ec := range cases {
+		result := caseResult{Question: ec.Question}
+		for r := range runs {
+			pair := [2]trial{trials[0][c][r], trials[1][c][r]}
+			for i, t := range pair {
+				v := &report.Variants[i]
+				v.Trials++
+				v.Tokens += t.tokens
+				v.Cost += t.cost
+				latency[i] += t.latency
+				result.Score[i] += t.score / float64(runs)
+				if t.err != nil {
+					result.Errors[i]++
+				} else if t.score == 1 {
+					v.Passed++
+				}
+			}
+			switch {
+			case pair[0].score > pair[1].score:
+				result.Wins[0]++
+			case pair[1].score > pair[0].score:
+				result.Wins[1]++
+			default:
+				result.Ties++
+			}
+		}
+		for i := range variants {
+			report.Variants[i].Wins += result.Wins[i]
+		}
+		report.Cases = append(report.Cases, result)
+	}
+	for i := range variants {
+		v := &report.Variants[i]
+		if v.Trials > 0 {
+			v.WinRate = float64(v.Wins) / float64(v.Trials)
+			v.LatencyMS = (latency[i] / time.Duration(v.Trials)).Milliseconds()
+		}
+	}
+	return report
+}
+
+// printReport writes the per-case comparison and the totals of each variant.
+func printReport(out io.Writer, report experimentReport) {
+	a, b := report.Variants[0], report.Variants[1]
+	fmt.Fprintf(out, "\nA: %s\nB: %s\n\n", a.describe(), b.describe())
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CASE\tSCORE A\tSCORE B\tA WINS\tB WINS\tTIES\tERRORS A/B")
+	for i, c := range report.Cases {
+		fmt.Fprintf(w, "[%d] %s\t%.2f\t%.2f\t%d/%d\t%d/%d\t%d\t%d/%d\n", i+1, truncate.Runes(c.Question, 50),
+			c.Score[0], c.Score[1], c.Wins[0], report.Runs, c.Wins[1], report.Runs, c.Ties, c.Errors[0], c.Errors[1])
+	}
+	w.Flush()
+
+	fmt.Fprintln(out)
+	w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\tA\tB")
+	fmt.Fprintf(w, "model\t%s\t%s\n", a.Model, b.Model)
+	fmt.Fprintf(w, "passed\t%d/%d\t%d/%d\n", a.Passed, a.Trials, b.Passed, b.Trials)
+	fmt.Fprintf(w, "win rate\t%.0f%%\t%.0f%%\n", 100*a.WinRate, 100*b.WinRate)
+	fmt.Fprintf(w, "mean latency\t%s\t%s\n", time.Duration(a.LatencyMS)*time.Millisecond, time.Duration(b.LatencyMS)*time.Millisecond)
+	fmt.Fprintf(w, "tokens\t%d\t%d\n", a.Tokens, b.Tokens)
+	fmt.Fprintf(w, "cost\t$%.4f\t$%.4f\n", a.Cost, b.Cost)
+	w.Flush()
+}
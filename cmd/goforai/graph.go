@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/spf13/cobra"
+)
+
+func newGraphCmd() *cobra.Command {
+	var format, output string
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Print the agent's Eino graph as Mermaid or Graphviz DOT",
+		Long: "Builds the agent's graph without running it and prints its nodes, edges and the\n" +
+			"types between them, with the ReAct agent's model and tools loop nested inside.\n" +
+			"Render DOT with `dot -Tsvg`; Mermaid renders in GitHub Markdown.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "mermaid" && format != "dot" {
+				return fmt.Errorf("unknown format %q: use mermaid or dot", format)
+			}
+			if err := requireGeminiKey(cmd.Context()); err != nil {
+				return err
+			}
+
+			graph, err := agent.DescribeGraph(cmd.Context())
+			if err != nil {
+				return err
+			}
+			text := graph.Mermaid()
+			if format == "dot" {
+				text = graph.DOT()
+			}
+			if output == "" {
+				_, err := fmt.Fprint(cmd.OutOrStdout(), text)
+				return err
+			}
+			return os.WriteFile(output, []byte(text), 0o644)
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "mermaid", "output format: mermaid or dot")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write to this file instead of stdout")
+	return cmd
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/indexing"
+	"github.com/spf13/cobra"
+)
+
+func newIndexCmd() *cobra.Command {
+	opts := indexing.Options{}
+
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Build the GopherCon knowledge base used by the RAG tool",
+		Long: "Builds the knowledge base and saves a snapshot of it in the snapshots directory next\n" +
+			"to the database. `index delta` then exports what a later build changed since a\n" +
+			"snapshot, and `index apply` applies it to another copy of the database, so a knowledge\n" +
+			"base built in CI reaches production as a small patch.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The embedder checks its own API key: the index may be built
+			// with OpenAI or a local Ollama model instead of Gemini.
+			opts.Out = cmd.OutOrStdout()
+			return indexing.Run(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.DocsDir, "docs", indexing.DefaultDocsDir, "directory of markdown documents to index")
+	cmd.Flags().StringVar(&opts.DBPath, "db", indexing.DefaultDBPath, "path of the exported database")
+	cmd.Flags().StringVar(&opts.AccessFile, "access", "", "JSON file of per-path access rules (default: access.json in --docs)")
+	cmd.Flags().StringVar(&opts.GraphPath, "graph", config.KnowledgeGraphPath(), "also save a knowledge graph of the documents' relations here, for graph_query (also KNOWLEDGE_GRAPH)")
+	cmd.AddCommand(newIndexSnapshotCmd(), newIndexDeltaCmd(), newIndexApplyCmd())
+	return cmd
+}
+
+func newIndexSnapshotCmd() *cobra.Command {
+	var dbPath string
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Print the snapshot ID of the knowledge base, saving the snapshot",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			snapshot, err := chromemdb.TakeSnapshot(dbPath)
+			if err != nil {
+				return err
+			}
+			if err := chromemdb.SaveSnapshot(chromemdb.SnapshotDir(dbPath), snapshot); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), snapshot.ID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dbPath, "db", indexing.DefaultDBPath, "path of the exported database")
+	return cmd
+}
+
+func newIndexDeltaCmd() *cobra.Command {
+	var dbPath, since, out string
+	cmd := &cobra.Command{
+		Use:   "delta",
+		Short: "Export the documents added, changed and removed since a snapshot",
+		Long: "Compares the knowledge base with a snapshot saved by an earlier `goforai index` (or\n" +
+			"`index snapshot`) and writes the differences, with their embeddings, to --out. Without\n" +
+			"--since the delta holds the whole knowledge base, for a database that does not exist yet.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var base *chromemdb.Snapshot
+			if since != "" {
+				var err error
+				if base, err = chromemdb.LoadSnapshot(chromemdb.SnapshotDir(dbPath), since); err != nil {
+					return err
+				}
+			}
+			delta, err := chromemdb.ExportDelta(dbPath, base, out)
+			if err != nil {
+				return err
+			}
+			upserts, removed := delta.Counts()
+			fmt.Fprintf(cmd.OutOrStdout(), "📦 Wrote %s: %s → %s, %d documents added or changed, %d removed\n",
+				out, deltaBase(delta.Base, since), delta.Target, upserts, removed)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dbPath, "db", indexing.DefaultDBPath, "path of the exported database")
+	cmd.Flags().StringVar(&since, "since", "", "ID of the snapshot the delta starts from (default: an empty knowledge base)")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the delta to")
+	cmd.MarkFlagRequired("out")
+	return cmd
+}
+
+func newIndexApplyCmd() *cobra.Command {
+	var dbPath string
+	cmd := &cobra.Command{
+		Use:   "apply DELTA",
+		Short: "Apply a delta exported by `index delta` to the knowledge base",
+		Long: "The knowledge base must be at the snapshot the delta starts from; one already at the\n" +
+			"snapshot it leads to is left as it is. Restart running agents to load the result.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			delta, changed, err := chromemdb.ApplyDelta(dbPath, args[0])
+			if errors.Is(err, chromemdb.ErrDeltaBase) {
+				return fmt.Errorf("%w; export a delta from this database's snapshot (goforai index snapshot prints it)", err)
+			}
+			if err != nil {
+				return err
+			}
+			if !changed {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s is already at snapshot %s\n", dbPath, delta.Target)
+				return nil
+			}
+			upserts, removed := delta.Counts()
+			fmt.Fprintf(cmd.OutOrStdout(), "✅ Updated %s to snapshot %s: %d documents added or changed, %d removed\n",
+				dbPath, delta.Target, upserts, removed)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dbPath, "db", indexing.DefaultDBPath, "path of the exported database")
+	return cmd
+}
+
+// deltaBase names the snapshot a delta starts from.
+func deltaBase(id, since string) string {
+	if since == "" {
+		return "empty"
+	}
+	return id
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/embeddings"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/secrets"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// selfTestTimeout bounds each connectivity check of `goforai init`.
+const selfTestTimeout = 30 * time.Second
+
+func newInitCmd() *cobra.Command {
+	var (
+		envFile  string
+		skipTest bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Set up goforai interactively: providers, API keys, workspace and model",
+		Long: "Asks for the embedding provider, the API keys it needs (stored in the OS keyring), the\n" +
+			"directory the file tools work in and the chat model, writes the answers to the .env file\n" +
+			"every command reads, and checks that the model and the embedder answer.\n" +
+			"Answers can also be piped in, one per line.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := &wizard{cmd: cmd, in: bufio.NewReader(cmd.InOrStdin()), out: cmd.ErrOrStderr(), values: make(map[string]string)}
+			if err := w.run(); err != nil {
+				return err
+			}
+			if err := config.UpdateEnvFile(envFile, w.values); err != nil {
+				return err
+			}
+			fmt.Fprintf(w.out, "\n📝 Wrote %d settings to %s\n", len(w.values), envFile)
+			for key, value := range w.values {
+				os.Setenv(key, value)
+			}
+			if skipTest {
+				return nil
+			}
+			return selfTest(cmd.Context(), w.out)
+		},
+	}
+
+	cmd.Flags().StringVar(&envFile, "env-file", config.EnvFile, "file to write the settings to")
+	cmd.Flags().BoolVar(&skipTest, "skip-test", false, "do not check that the model and embedder answer")
+	return cmd
+}
+
+// wizard asks the setup questions and collects the settings to write.
+type wizard struct {
+	cmd    *cobra.Command
+	in     *bufio.Reader
+	out    io.Writer
+	values map[string]string // Settings for the .env file.
+}
+
+func (w *wizard) run() error {
+	fmt.Fprintln(w.out, "🧭 goforai setup. Press Enter to accept the [default].")
+
+	embedCfg, err := config.LoadEmbeddings()
+	if err != nil {
+		return err
+	}
+	provider, err := w.choose("Embedding provider for the knowledge base", []string{config.EmbeddingGemini, config.EmbeddingOpenAI, config.EmbeddingOllama}, embedCfg.Provider)
+	if err != nil {
+		return err
+	}
+	w.values["EMBEDDING_PROVIDER"] = provider
+
+	fmt.Fprintln(w.out, "\nThe agent chats with Gemini, so it needs a Gemini API key (https://aistudio.google.com/app/apikey).")
+	if err := w.secret(secrets.GeminiAPIKey, true); err != nil {
+		return err
+	}
+	switch provider {
+	case config.EmbeddingOpenAI:
+		if err := w.secret(secrets.OpenAIAPIKey, true); err != nil {
+			return err
+		}
+	case config.EmbeddingOllama:
+		url, err := w.ask("Ollama URL", firstSet(embedCfg.BaseURL, embeddings.DefaultOllamaBaseURL))
+		if err != nil {
+			return err
+		}
+		w.values["EMBEDDING_BASE_URL"] = url
+	}
+	fmt.Fprintln(w.out, "\nWeb search uses Tavily with a key and DuckDuckGo without one (https://tavily.com).")
+	if err := w.secret(secrets.TavilyAPIKey, false); err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	for {
+		dir, err := w.ask("\nWorkspace the file tools may read and edit", firstSet(config.WorkspaceDir(), cwd))
+		if err != nil {
+			return err
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			fmt.Fprintf(w.out, "❌ %s is not a directory\n", abs)
+			continue
+		}
+		w.values["WORKSPACE_DIR"] = abs
+		break
+	}
+
+	var chatModels []string
+	for _, name := range models.Names() {
+		if caps, _ := models.Lookup(name); strings.HasPrefix(name, "gemini-") && caps.Tools {
+			chatModels = append(chatModels, name)
+		}
+	}
+	model, err := w.choose("Chat model", chatModels, gemini.ChatModel())
+	if err != nil {
+		return err
+	}
+	w.values["CHAT_MODEL"] = model
+	return nil
+}
+
+// ask prints question with its default and returns the answer, or def for
+// an empty one.
+func (w *wizard) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	line, err := w.in.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		if errors.Is(err, io.EOF) {
+			return "", errors.New("setup ended before every question was answered")
+		}
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// choose asks for one of options until it gets one.
+func (w *wizard) choose(question string, options []string, def string) (string, error) {
+	if !slices.Contains(options, def) {
+		def = options[0]
+	}
+	for {
+		answer, err := w.ask(fmt.Sprintf("%s (%s)", question, strings.Join(options, ", ")), def)
+		if err != nil {
+			return "", err
+		}
+		if slices.Contains(options, answer) {
+			return answer, nil
+		}
+		fmt.Fprintf(w.out, "❌ Choose one of %s\n", strings.Join(options, ", "))
+	}
+}
+
+// confirm asks a yes/no question.
+func (w *wizard) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := w.ask(question+" ("+hint+")", "")
+	if err != nil || answer == "" {
+		return def, err
+	}
+	return strings.HasPrefix(strings.ToLower(answer), "y"), nil
+}
+
+// secret asks for the API key name, unless one is already set and the user
+// keeps it, and stores it in the OS keyring. Where the keyring is
+// unavailable, as on a headless server, it offers to write the key to the
+// .env file instead. An optional key may be left empty.
+func (w *wizard) secret(name string, required bool) error {
+	if _, source, err := secrets.Resolve(name); err == nil {
+		keep, err := w.confirm(fmt.Sprintf("%s is already set (%s). Keep it?", name, source), true)
+		if err != nil || keep {
+			return err
+		}
+		if source == "env" {
+			fmt.Fprintf(w.out, "⚠️  The %s environment variable overrides the keyring; unset it after setup.\n", name)
+		}
+	}
+
+	var value string
+	for {
+		var err error
+		if value, err = w.readSecret(name, required); err != nil {
+			return err
+		}
+		if value != "" || !required {
+			break
+		}
+		fmt.Fprintf(w.out, "❌ %s is required\n", name)
+	}
+	if value == "" {
+		return nil
+	}
+	err := secrets.Set(name, value)
+	if err == nil {
+		fmt.Fprintf(w.out, "🔐 Stored %s in the OS keyring\n", name)
+		return nil
+	}
+	fmt.Fprintf(w.out, "⚠️  %v\n", err)
+	plain, err := w.confirm(fmt.Sprintf("Write %s to the .env file instead?", name), true)
+	if err != nil {
+		return err
+	}
+	if !plain {
+		return fmt.Errorf("%s was not stored; set it with 'goforai secrets set %s' or the %s environment variable", name, name, name)
+	}
+	w.values[name] = value
+	return nil
+}
+
+// readSecret reads a key without echo on a terminal, or as a line otherwise.
+func (w *wizard) readSecret(name string, required bool) (string, error) {
+	prompt := "Enter " + name
+	if !required {
+		prompt += " (Enter to skip)"
+	}
+	if f, ok := w.cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprintf(w.out, "%s: ", prompt)
+		raw, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(w.out)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return strings.TrimSpace(string(raw)), nil
+	}
+	return w.ask(prompt, "")
+}
+
+// selfTest checks that the configured chat model and embedder answer, and
+// says how to fix what does not.
+func selfTest(ctx context.Context, out io.Writer) error {
+	fmt.Fprintln(out, "\n🔌 Checking connectivity...")
+	var failed bool
+
+	pingCtx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	err := gemini.Ping(pingCtx)
+	cancel()
+	switch {
+	case err == nil:
+		fmt.Fprintf(out, "✅ Gemini answers with %s\n", gemini.ChatModel())
+	case errors.Is(err, gemini.ErrRateLimited):
+		fmt.Fprintf(out, "⚠️  Gemini is reachable but rate limited; try again in a minute: %v\n", err)
+	default:
+		failed = true
+		fmt.Fprintf(out, "❌ Gemini: %v\n   Check GEMINI_API_KEY and CHAT_MODEL, and that https://generativelanguage.googleapis.com is reachable.\n", err)
+	}
+
+	cfg, err := config.LoadEmbeddings()
+	if err == nil {
+		embedCtx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+		_, _, err = probeEmbedder(embedCtx, cfg)
+		cancel()
+	}
+	if err != nil {
+		failed = true
+		fmt.Fprintf(out, "❌ Embeddings (%s): %v\n", cfg.Provider, err)
+		if cfg.Provider == config.EmbeddingOllama {
+			fmt.Fprintln(out, "   Start Ollama with 'ollama serve' and pull the model with 'ollama pull nomic-embed-text'.")
+		}
+	} else {
+		fmt.Fprintf(out, "✅ Embeddings answer (%s)\n", cfg.Provider)
+	}
+
+	if failed {
+		return errors.New("setup was saved, but the self-test failed; fix the problems above and run 'goforai init' again")
+	}
+	fmt.Fprintln(out, "\n🎉 Ready. Run 'make setup' to index the knowledge base, then 'goforai chat'.")
+	return nil
+}
+
+// probeEmbedder embeds one word with the configured embedder and returns
+// the model ID it records in the index and the size of its vectors.
+func probeEmbedder(ctx context.Context, cfg config.Embeddings) (string, int, error) {
+	embedder, model, err := embeddings.New(ctx, cfg)
+	if err != nil {
+		return "", 0, err
+	}
+	vectors, err := embedder.EmbedStrings(ctx, []string{"gopher"})
+	if err != nil {
+		return "", 0, err
+	}
+	if len(vectors) != 1 || len(vectors[0]) == 0 {
+		return "", 0, errors.New("the embedder returned no vector")
+	}
+	return model, len(vectors[0]), nil
+}
+
+// firstSet returns the first non-empty value.
+func firstSet(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
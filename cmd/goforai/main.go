@@ -0,0 +1,134 @@
+// Command goforai is the single entry point for the coding agent and its
+// supporting tasks: chatting, indexing the knowledge base, evaluating answers,
+// and inspecting the toolbox and the graph that runs it.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/secrets"
+	"github.com/olusolaa/goforai/foundation/telemetry"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"github.com/olusolaa/goforai/foundation/usage"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// execute runs the root command and then closes the log and flushes the
+// traces, whether or not the command failed.
+func execute() error {
+	root, cleanup := newRootCmd()
+	defer cleanup()
+	return root.Execute()
+}
+
+// newRootCmd wires the shared logger and tracing into every subcommand. The
+// returned function closes them; call it once the command has run. Cobra
+// skips the post-run hooks of a command that fails, and the traces of a
+// failed run are the ones most needed.
+func newRootCmd() (*cobra.Command, func()) {
+	var cleanups []func()
+	var readOnly, offline bool
+
+	root := &cobra.Command{
+		Use:           "goforai",
+		Short:         "Expert Go coding agent powered by Eino",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.LoadEnvFile(config.EnvFile); err != nil {
+				return err
+			}
+
+			appLogger, closeLog, err := logger.New(config.LoadLogging())
+			if err != nil {
+				return err
+			}
+			slog.SetDefault(appLogger)
+			cleanups = append(cleanups, func() { closeLog() })
+
+			ctx := logger.WithContext(cmd.Context(), appLogger)
+
+			shutdownTracing, err := telemetry.Setup(ctx, "goforai")
+			if err != nil {
+				return err
+			}
+			cleanups = append(cleanups, func() { shutdownTracing(context.Background()) })
+
+			if err := usage.Setup(); err != nil {
+				return err
+			}
+
+			if readOnly {
+				ctx = tools.WithReadOnly(ctx)
+			}
+			offlineCfg, err := config.LoadOffline()
+			if err != nil {
+				return err
+			}
+			if offline || offlineCfg.Enabled {
+				ctx = tools.WithOffline(ctx)
+			}
+			if dir := config.WorkspaceDir(); dir != "" {
+				abs, err := filepath.Abs(dir)
+				if err != nil {
+					return fmt.Errorf("invalid WORKSPACE_DIR %q: %w", dir, err)
+				}
+				ctx = tools.WithWorkspace(ctx, abs)
+			}
+			cmd.SetContext(ctx)
+			return nil
+		},
+	}
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+		cleanups = nil
+	}
+
+	root.PersistentFlags().BoolVar(&readOnly, "read-only", false, "never modify files: leave out edit_go_file, refuse git pulls, and tell the model")
+	root.PersistentFlags().BoolVar(&offline, "offline", false, "work without the internet: chat with a local Ollama model and leave out web search and cloning (also OFFLINE=true)")
+
+	root.AddCommand(
+		newInitCmd(),
+		newDoctorCmd(),
+		newChatCmd(),
+		newIndexCmd(),
+		newEvalCmd(),
+		newExperimentCmd(),
+		newAskCmd(),
+		newToolsCmd(),
+		newGraphCmd(),
+		newMCPCmd(),
+		newServeCmd(),
+		newSessionsCmd(),
+		newReplayCmd(),
+		newScheduleCmd(),
+		newSecretsCmd(),
+		newUsageCmd(),
+	)
+	return root, cleanup
+}
+
+// requireGeminiKey fails fast with a helpful message when the API key is
+// missing. Offline, the agent does not call Gemini and needs no key.
+func requireGeminiKey(ctx context.Context) error {
+	if tools.Offline(ctx) {
+		return nil
+	}
+	_, err := secrets.Get(secrets.GeminiAPIKey)
+	return err
+}
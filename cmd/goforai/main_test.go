@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/zalando/go-keyring"
+)
+
+// run executes the root command with args and returns what it wrote to
+// stdout. Token usage goes to a ledger of the test's own unless it set one.
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	if os.Getenv("USAGE_PATH") == "" {
+		t.Setenv("USAGE_PATH", filepath.Join(t.TempDir(), "usage.json"))
+	}
+	var stdout, stderr bytes.Buffer
+	root, cleanup := newRootCmd()
+	defer cleanup()
+	root.SetArgs(args)
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	err := root.ExecuteContext(context.Background())
+	return stdout.String(), err
+}
+
+func TestRootRegistersSubcommands(t *testing.T) {
+	names := map[string]bool{}
+	root, _ := newRootCmd()
+	for _, c := range root.Commands() {
+		names[c.Name()] = true
+	}
+	for _, want := range []string{"chat", "index", "eval", "experiment", "ask", "tools", "graph", "mcp", "serve", "sessions", "replay", "schedule", "secrets", "init", "doctor", "usage"} {
+		if !names[want] {
+			t.Errorf("subcommand %q is not registered", want)
+		}
+	}
+}
+
+// seedSessions points the session config at a fresh SQLite store and saves
+// one session in it.
+func seedSessions(t *testing.T) (*session.Session, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("SESSION_STORE", "sqlite")
+	t.Setenv("SESSION_SQLITE_PATH", filepath.Join(dir, "sessions.db"))
+	t.Setenv("SESSION_WORKSPACE_DIR", filepath.Join(dir, "workspaces"))
+	t.Setenv("SESSION_IDLE_TIMEOUT", "1h")
+
+	store, err := session.NewSQLiteStore(context.Background(), filepath.Join(dir, "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	now := time.Now()
+	sess := &session.Session{
+		ID:        "6f1c2b1e-3a5d-4c1e-9f7a-2b8d9e0c4a11",
+		Messages:  []*schema.Message{schema.UserMessage("What is Eino?"), schema.AssistantMessage("A Go LLM framework.", nil)},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := store.Save(context.Background(), sess); err != nil {
+		t.Fatal(err)
+	}
+	workspace := filepath.Join(dir, "workspaces", sess.ID)
+	if err := os.MkdirAll(workspace, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return sess, workspace
+}
+
+func TestSessionsListShowDelete(t *testing.T) {
+	sess, workspace := seedSessions(t)
+
+	out, err := run(t, "sessions", "list")
+	if err != nil {
+		t.Fatalf("sessions list: %v", err)
+	}
+	if !strings.Contains(out, sess.ID) || !strings.Contains(out, "2") {
+		t.Errorf("sessions list output lacks the session:\n%s", out)
+	}
+
+	out, err = run(t, "sessions", "show", sess.ID)
+	if err != nil {
+		t.Fatalf("sessions show: %v", err)
+	}
+	if !strings.Contains(out, "[user]\nWhat is Eino?") || !strings.Contains(out, "[assistant]\nA Go LLM framework.") {
+		t.Errorf("sessions show output lacks the conversation:\n%s", out)
+	}
+
+	if _, err := run(t, "sessions", "delete", sess.ID); err != nil {
+		t.Fatalf("sessions delete: %v", err)
+	}
+	if _, err := os.Stat(workspace); !os.IsNotExist(err) {
+		t.Errorf("workspace survived the delete: %v", err)
+	}
+	if _, err := run(t, "sessions", "show", sess.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("show after delete = %v, want not found", err)
+	}
+}
+
+func TestSessionsRejectMemoryStore(t *testing.T) {
+	t.Setenv("SESSION_STORE", "memory")
+	if _, err := run(t, "sessions", "list"); err == nil || !strings.Contains(err.Error(), "memory") {
+		t.Fatalf("sessions list with the memory store = %v, want an explanation", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	sess, _ := seedSessions(t)
+	store, err := session.NewSQLiteStore(context.Background(), os.Getenv("SESSION_SQLITE_PATH"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess.Turns = []*session.Turn{{
+		Message:    0,
+		StartedAt:  sess.CreatedAt,
+		DurationMS: 1200,
+		Retrievals: []session.Retrieval{{Query: "Eino", Sources: []string{"eino.md"}}},
+		ToolCalls:  []session.ToolCall{{Name: "fetch_url", Arguments: `{"url":"https://example.com"}`, Error: "timeout"}},
+	}}
+	if err := store.Save(context.Background(), sess); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	out, err := run(t, "replay", sess.ID)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	for _, want := range []string{"Turn 1/1", "What is Eino?", `Searched "Eino": 1 found`, "- eino.md", "fetch_url", "timeout", "A Go LLM framework."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("replay lacks %q:\n%s", want, out)
+		}
+	}
+	if _, err := run(t, "replay", sess.ID, "--turn", "2"); err == nil {
+		t.Error("a turn past the end was accepted")
+	}
+
+	fakeGemini := fakeapi.NewGemini(t)
+	fakeGemini.Use(t)
+	fakeapi.NewTavily(t).Use(t)
+	t.Setenv("MCP_CONFIG", filepath.Join(t.TempDir(), "mcp.json"))
+	t.Setenv("RECALL_DB_PATH", filepath.Join(t.TempDir(), "conversations.gob"))
+	fakeGemini.ReplyText("Eino is CloudWeGo's LLM framework.")
+	out, err = run(t, "replay", sess.ID, "--turn", "1", "--rerun", "--prompt", "What is Eino, briefly?", "--model", "gemini-2.5-pro")
+	if err != nil {
+		t.Fatalf("replay --rerun: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Eino is CloudWeGo's LLM framework.") {
+		t.Errorf("re-run answer missing:\n%s", out)
+	}
+	reqs := fakeGemini.Requests()
+	if len(reqs) != 1 || reqs[0].Model != "gemini-2.5-pro" {
+		t.Fatalf("want one call to gemini-2.5-pro, got %+v", reqs)
+	}
+	last := reqs[0].Contents[len(reqs[0].Contents)-1]
+	if len(last.Parts) == 0 || !strings.Contains(last.Parts[0].Text, "What is Eino, briefly?") {
+		t.Errorf("re-run did not send the new prompt: %+v", last)
+	}
+}
+
+func TestGraphRejectsUnknownFormat(t *testing.T) {
+	if _, err := run(t, "graph", "--format", "png"); err == nil || !strings.Contains(err.Error(), "png") {
+		t.Fatalf("graph --format png = %v, want an error naming the format", err)
+	}
+}
+
+func TestLoadEvalCases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cases.jsonl")
+	content := "# comment\n{\"question\":\"What is Eino?\",\"expect\":[\"framework\"]}\n\n{\"question\":\"Who spoke?\",\"expect\":[]}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cases, err := loadEvalCases(path)
+	if err != nil {
+		t.Fatalf("loadEvalCases: %v", err)
+	}
+	if len(cases) != 2 || cases[0].Question != "What is Eino?" || cases[0].Expect[0] != "framework" {
+		t.Errorf("unexpected cases %+v", cases)
+	}
+
+	if err := os.WriteFile(path, []byte("{\"question\":\"ok\"}\nnot json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadEvalCases(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("invalid line = %v, want an error naming line 2", err)
+	}
+}
+
+func TestExperiment(t *testing.T) {
+	fakeGemini := fakeapi.NewGemini(t)
+	fakeGemini.Use(t)
+	fakeapi.NewTavily(t).Use(t)
+	t.Setenv("MCP_CONFIG", filepath.Join(t.TempDir(), "mcp.json"))
+	t.Setenv("PROMPT_VERSIONS", "")
+	dir := t.TempDir()
+	t.Setenv("PROMPTS_DIR", dir)
+	if err := os.WriteFile(filepath.Join(dir, "system-v2.md"), []byte("---\nname: step5.system\nversion: 2\nvariables: [date]\n---\nAnswer in one word. Date: {date}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := filepath.Join(dir, "cases.jsonl")
+	if err := os.WriteFile(cases, []byte(`{"question":"Where is GopherCon Africa?","expect":["Nairobi","Kenya"]}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Variant A answers first, then B.
+	fakeGemini.ReplyText("Nairobi, Kenya.")
+	fakeGemini.ReplyText("Nairobi, Kenya.")
+	fakeGemini.ReplyText("Nairobi.")
+	fakeGemini.ReplyText("Nairobi, Kenya.")
+
+	report := filepath.Join(dir, "report.json")
+	out, err := run(t, "experiment", "--file", cases, "--runs", "2", "--a", "PROMPT_VERSIONS=step5.system=1", "--report", report)
+	if err != nil {
+		t.Fatalf("experiment: %v\n%s", err, out)
+	}
+	if words := strings.Join(strings.Fields(out), " "); !strings.Contains(words, "passed 2/2 1/2") || !strings.Contains(words, "win rate 50% 0%") {
+		t.Errorf("report:\n%s", out)
+	}
+
+	reqs := fakeGemini.Requests()
+	if len(reqs) != 4 {
+		t.Fatalf("expected 4 model calls, got %d", len(reqs))
+	}
+	if system := reqs[0].System; strings.Contains(system, "one word") {
+		t.Errorf("variant A did not use the pinned prompt: %q", system)
+	}
+	if system := reqs[2].System; !strings.Contains(system, "one word") {
+		t.Errorf("variant B did not use the latest prompt: %q", system)
+	}
+	if os.Getenv("PROMPT_VERSIONS") != "" {
+		t.Error("variant A's settings were not restored")
+	}
+
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved experimentReport
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if c := saved.Cases[0]; c.Wins != [2]int{1, 0} || c.Ties != 1 || c.Score != [2]float64{1, 0.75} {
+		t.Errorf("case result = %+v", c)
+	}
+	if v := saved.Variants[0]; v.Settings["PROMPT_VERSIONS"] != "step5.system=1" || v.Tokens != 30 || v.Cost == 0 {
+		t.Errorf("variant A = %+v", v)
+	}
+}
+
+func TestParseVariant(t *testing.T) {
+	v, err := parseVariant("A", []string{"CHAT_MODEL=gemini-2.5-pro", "PROMPT_VERSIONS=step5.system=1,step5.compact=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.describe(); got != "CHAT_MODEL=gemini-2.5-pro PROMPT_VERSIONS=step5.system=1,step5.compact=1" {
+		t.Errorf("describe = %q", got)
+	}
+	if _, err := parseVariant("B", []string{"gemini-2.5-pro"}); err == nil {
+		t.Error("a setting without = was accepted")
+	}
+}
+
+func TestMissingPhrases(t *testing.T) {
+	got := missingPhrases("Eino is a Go FRAMEWORK for LLM apps.", []string{"framework", "go", "python"})
+	if len(got) != 1 || got[0] != "python" {
+		t.Errorf("missingPhrases = %v, want [python]", got)
+	}
+}
+
+func TestKnowledgeBaseCheck(t *testing.T) {
+	dir := t.TempDir()
+	if err := knowledgeBaseCheck(filepath.Join(dir, "missing.gob"))(context.Background()); err != nil {
+		t.Errorf("missing index = %v, want ready", err)
+	}
+	// The header announces 255 bytes of manifest but the file ends first.
+	truncated := filepath.Join(dir, "index.gob")
+	if err := os.WriteFile(truncated, []byte("GOFORAI-INDEX\n\x00\x00\x00\xff{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := knowledgeBaseCheck(truncated)(context.Background()); err == nil {
+		t.Error("unreadable index reported ready")
+	}
+}
+
+func TestSecretsCheck(t *testing.T) {
+	t.Setenv("GOFORAI_TEST_SECRET", "value")
+	out, err := run(t, "secrets", "check", "GOFORAI_TEST_SECRET")
+	if err != nil || !strings.Contains(out, "GOFORAI_TEST_SECRET is set (env)") {
+		t.Errorf("secrets check = %q, %v", out, err)
+	}
+}
+
+func TestInitWritesEnvFile(t *testing.T) {
+	keyring.MockInit()
+	for _, key := range []string{"GEMINI_API_KEY", "OPENAI_API_KEY", "TAVILY_API_KEY", "EMBEDDING_PROVIDER", "WORKSPACE_DIR", "CHAT_MODEL"} {
+		t.Setenv(key, "")
+	}
+	workspace := t.TempDir()
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte("# Keep me\nLOG_LEVEL=debug\n# EMBEDDING_PROVIDER=gemini\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	answers := strings.Join([]string{
+		"voyage", // Not a provider: asked again.
+		"openai",
+		"gemini-key",
+		"openai-key",
+		"",                                  // No Tavily key.
+		filepath.Join(workspace, "missing"), // Not a directory: asked again.
+		workspace,
+		"", // The default chat model.
+	}, "\n") + "\n"
+	var stderr bytes.Buffer
+	root, cleanup := newRootCmd()
+	defer cleanup()
+	root.SetArgs([]string{"init", "--env-file", envFile, "--skip-test"})
+	root.SetIn(strings.NewReader(answers))
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&stderr)
+	if err := root.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("init: %v\n%s", err, stderr.String())
+	}
+
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Keep me\nLOG_LEVEL=debug\nEMBEDDING_PROVIDER=openai\nCHAT_MODEL=gemini-2.5-flash\nWORKSPACE_DIR=" + workspace + "\n"
+	if string(data) != want {
+		t.Errorf(".env =\n%s\nwant\n%s", data, want)
+	}
+	if info, _ := os.Stat(envFile); info.Mode().Perm() != 0o600 {
+		t.Errorf(".env mode = %v, want 0600", info.Mode().Perm())
+	}
+	for key, want := range map[string]string{"GEMINI_API_KEY": "gemini-key", "OPENAI_API_KEY": "openai-key"} {
+		if got, err := keyring.Get("goforai", key); err != nil || got != want {
+			t.Errorf("keyring %s = %q, %v", key, got, err)
+		}
+	}
+	if _, err := keyring.Get("goforai", "TAVILY_API_KEY"); err == nil {
+		t.Error("a skipped key was stored")
+	}
+}
+
+func TestInitStopsAtEndOfInput(t *testing.T) {
+	keyring.MockInit()
+	root, cleanup := newRootCmd()
+	defer cleanup()
+	root.SetArgs([]string{"init", "--env-file", filepath.Join(t.TempDir(), ".env"), "--skip-test"})
+	root.SetIn(strings.NewReader("gemini\n"))
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	if err := root.ExecuteContext(context.Background()); err == nil || !strings.Contains(err.Error(), "setup ended") {
+		t.Errorf("init with too few answers = %v", err)
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	fakeapi.NewGemini(t).Use(t)
+	t.Setenv("EMBEDDING_PROVIDER", "gemini")
+	t.Setenv("EMBEDDING_MODEL", "")
+	docs := t.TempDir()
+	if err := os.WriteFile(filepath.Join(docs, "goroutines.md"), []byte("# Goroutines\n\nGoroutines are cheap threads.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db := filepath.Join(t.TempDir(), "chromem.gob")
+	if _, err := run(t, "index", "--docs", docs, "--db", db); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	// The other checks need the network.
+	checks := doctorChecks(context.Background(), db)[:4]
+
+	var out bytes.Buffer
+	if failed := diagnose(context.Background(), &out, checks); failed != 0 {
+		t.Fatalf("%d checks failed:\n%s", failed, out.String())
+	}
+	if !strings.Contains(out.String(), "✅ Knowledge base: "+db+" holds 1 documents") {
+		t.Errorf("report:\n%s", out.String())
+	}
+
+	t.Setenv("EMBEDDING_MODEL", "text-embedding-999")
+	out.Reset()
+	if failed := diagnose(context.Background(), &out, checks); failed != 1 ||
+		!strings.Contains(out.String(), "❌ Knowledge base") || !strings.Contains(out.String(), "Re-run 'goforai index'") {
+		t.Errorf("a mismatched index was not reported with its fix:\n%s", out.String())
+	}
+
+	out.Reset()
+	if failed := diagnose(context.Background(), &out, doctorChecks(context.Background(), filepath.Join(t.TempDir(), "missing.gob"))[3:4]); failed != 0 ||
+		!strings.Contains(out.String(), "⚠️  Knowledge base") {
+		t.Errorf("a missing index was not a warning:\n%s", out.String())
+	}
+}
+
+func TestAskBatch(t *testing.T) {
+	fakeGemini := fakeapi.NewGemini(t)
+	fakeGemini.Use(t)
+	t.Setenv("EMBEDDING_PROVIDER", "gemini")
+	t.Setenv("EMBEDDING_MODEL", "")
+	t.Setenv("RAG_MIN_SCORE", "0.5")
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.MkdirAll("docs", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("docs", "goroutines.md"), []byte("# Goroutines\n\nGoroutines are cheap threads managed by the Go runtime.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(t, "index", "--docs", "docs", "--db", filepath.Join("data", "chromem.gob")); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	batch := "# coverage check\nAre goroutines cheap threads?\n\nWifi password?\nAre goroutines threads managed by the Go runtime?\n"
+	if err := os.WriteFile("questions.txt", []byte(batch), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fakeGemini.ReplyText("Goroutines are cheap threads [1].")
+	fakeGemini.ReplyText("I don't know.")
+
+	if _, err := run(t, "ask", "--batch", "questions.txt", "--out", "answers.jsonl", "-c", "1"); err != nil {
+		t.Fatalf("ask: %v", err)
+	}
+	data, err := os.ReadFile("answers.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var answers []askAnswer
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var a askAnswer
+		if err := json.Unmarshal([]byte(line), &a); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		answers = append(answers, a)
+	}
+	if len(answers) != 3 {
+		t.Fatalf("got %d answers, want 3:\n%s", len(answers), data)
+	}
+	if a := answers[0]; a.Line != 2 || len(a.Citations) != 1 || a.Citations[0].Origin != "goroutines.md" || a.Usage.TotalTokens == 0 {
+		t.Errorf("first answer = %+v, want one citation of goroutines.md with usage", a)
+	}
+	if a := answers[1]; a.Line != 4 || a.Answer != "" || a.Retrieved != 0 || len(a.Citations) != 0 {
+		t.Errorf("uncovered question = %+v, want no answer", a)
+	}
+	if a := answers[2]; a.Line != 5 || a.Retrieved != 1 || len(a.Citations) != 0 {
+		t.Errorf("third answer = %+v, want a retrieved document and no citation", a)
+	}
+	if reqs := fakeGemini.Requests(); len(reqs) != 2 || !strings.Contains(reqs[0].Contents[0].Parts[0].Text, "(goroutines.md, as of") {
+		t.Errorf("model calls = %+v, want two with numbered documents", reqs)
+	}
+}
+
+func TestCitedSources(t *testing.T) {
+	got := citedSources("Cheap [2], managed by the runtime [1][2]; see also [7] and [0].", 3)
+	if fmt.Sprint(got) != "[2 1]" {
+		t.Errorf("citedSources = %v, want [2 1]", got)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	t.Setenv("USAGE_PATH", path)
+	t.Setenv("USAGE_QUOTA_DAILY", "gemini:1000")
+	today := time.Now().UTC()
+	ledger := fmt.Sprintf(`{"records": [
+		{"date": "2025-01-02", "provider": "gemini", "model": "gemini-2.5-flash", "calls": 3, "total_tokens": 900, "cost_usd": 0.5},
+		{"date": %q, "provider": "gemini", "model": "gemini-2.5-flash", "calls": 2, "prompt_tokens": 200, "completion_tokens": 50, "total_tokens": 250, "cost_usd": 0.1},
+		{"date": %q, "provider": "ollama", "model": "qwen3", "calls": 1, "total_tokens": 40}
+	]}`, today.Format(time.DateOnly), today.Format(time.DateOnly))
+	if err := os.WriteFile(path, []byte(ledger), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := run(t, "usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "2025-01-02") || !strings.Contains(out, "qwen3") {
+		t.Errorf("the last 30 days were not reported:\n%s", out)
+	}
+	if !regexp.MustCompile(`gemini\s+per day\s+250\s+1000\s+25%`).MatchString(out) {
+		t.Errorf("the daily quota was not reported:\n%s", out)
+	}
+
+	out, err = run(t, "usage", "--monthly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`2025-01\s+gemini\s+3\s+900\s+\$0.5000`).MatchString(out) {
+		t.Errorf("the history was not summed per month:\n%s", out)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/mcpserver"
+	"github.com/spf13/cobra"
+)
+
+func newMCPCmd() *cobra.Command {
+	var (
+		transport string
+		addr      string
+		token     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve the agent's tools to MCP clients (Claude Desktop, Cursor, ...)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			if transport == "stdio" {
+				// stdout carries the JSON-RPC stream; a single log line would corrupt it.
+				var err error
+				if ctx, err = logToStderr(ctx); err != nil {
+					return err
+				}
+			}
+
+			toolsList, closeTools, err := agent.SetupTools(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeTools()
+
+			s, err := mcpserver.New(ctx, toolsList)
+			if err != nil {
+				return err
+			}
+
+			switch transport {
+			case "stdio":
+				return mcpserver.ServeStdio(ctx, s)
+			case "sse":
+				fmt.Fprintf(cmd.ErrOrStderr(), "🔌 MCP server listening on %s (SSE)\n", addr)
+				return mcpserver.ServeSSE(ctx, s, addr, token)
+			default:
+				return fmt.Errorf("unknown transport %q (use stdio or sse)", transport)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&transport, "transport", "stdio", "transport to serve: stdio or sse")
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8081", "listen address for the sse transport")
+	cmd.Flags().StringVar(&token, "token", os.Getenv("MCP_AUTH_TOKEN"),
+		"bearer token sse clients must send (default $MCP_AUTH_TOKEN); required for non-loopback addresses")
+	return cmd
+}
+
+// logToStderr replaces the logger with one writing to stderr when LOG_OUTPUT
+// is stdout. Other outputs are left alone.
+func logToStderr(ctx context.Context) (context.Context, error) {
+	cfg := config.LoadLogging()
+	if !strings.EqualFold(cfg.Output, "stdout") {
+		return ctx, nil
+	}
+	cfg.Output = "stderr"
+	appLogger, _, err := logger.New(cfg)
+	if err != nil {
+		return ctx, err
+	}
+	slog.SetDefault(appLogger)
+	appLogger.Warn("LOG_OUTPUT=stdout would corrupt the MCP stdio stream; logging to stderr instead")
+	return logger.WithContext(ctx, appLogger), nil
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// maxReplayedResult bounds how much of each tool result replay prints.
+const maxReplayedResult = 600
+
+// replayTurn is one user message of a stored session with its answer and,
+// for sessions recorded with traces, how it was answered.
+type replayTurn struct {
+	message int // Index of the user message in the session.
+	prompt  string
+	answer  string
+	trace   *session.Turn // Nil when the session holds no trace for the turn.
+}
+
+func newReplayCmd() *cobra.Command {
+	var (
+		turn   int
+		rerun  bool
+		prompt string
+		model  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay ID",
+		Short: "Step through a stored session turn by turn and re-run turns to debug them",
+		Long: "Prints each turn of a server session: the prompt, what the knowledge base was searched\n" +
+			"for and found, the tools called with their results, and the answer. In a terminal it\n" +
+			"pauses after every turn: Enter goes on, 'r' re-runs the turn, 'r PROMPT' re-runs it with\n" +
+			"another prompt, 'm MODEL' picks the model re-runs chat with, and 'q' quits. Otherwise\n" +
+			"it prints every turn, and --rerun re-runs the turn chosen with --turn.\n\n" +
+			"A re-run sends the conversation up to the turn to the agent as it is configured now,\n" +
+			"read-only and in the session's workspace, and prints the new answer; the session is\n" +
+			"not changed. Traces are recorded by `goforai serve`; older sessions show only the\n" +
+			"messages.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rerun && turn == 0 {
+				return errors.New("--rerun needs the turn to re-run (--turn)")
+			}
+			return withSessions(cmd, func(m *session.Manager) error {
+				s, err := m.Lookup(cmd.Context(), args[0])
+				if err != nil {
+					return sessionError(args[0], err)
+				}
+				turns := replayTurns(s)
+				if len(turns) == 0 {
+					return fmt.Errorf("session %s has no turns", s.ID)
+				}
+				if turn < 0 || turn > len(turns) {
+					return fmt.Errorf("--turn must be between 1 and %d, got %d", len(turns), turn)
+				}
+				r := &replayer{cmd: cmd, session: s, workspace: m.Workspace(s.ID), model: model}
+
+				out := cmd.OutOrStdout()
+				fmt.Fprintf(out, "Session %s, %d turns (created %s)\n", s.ID, len(turns), s.CreatedAt.Format(time.DateTime))
+				if turn > 0 {
+					t := turns[turn-1]
+					printReplayTurn(out, turn, len(turns), t)
+					if rerun {
+						if prompt == "" {
+							prompt = t.prompt
+						}
+						return r.rerun(t, prompt)
+					}
+					return nil
+				}
+
+				f, ok := cmd.InOrStdin().(*os.File)
+				if !ok || !term.IsTerminal(int(f.Fd())) {
+					for i, t := range turns {
+						printReplayTurn(out, i+1, len(turns), t)
+					}
+					return nil
+				}
+				return r.step(turns, bufio.NewScanner(f))
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&turn, "turn", 0, "show only this turn, counting from 1")
+	cmd.Flags().BoolVar(&rerun, "rerun", false, "re-run the turn chosen with --turn")
+	cmd.Flags().StringVar(&prompt, "prompt", "", "with --rerun, the prompt to re-run the turn with instead of the original")
+	cmd.Flags().StringVar(&model, "model", "", "the chat model re-runs use instead of CHAT_MODEL")
+	return cmd
+}
+
+// replayTurns pairs the user messages of s with their answers and traces.
+func replayTurns(s *session.Session) []replayTurn {
+	traces := make(map[int]*session.Turn, len(s.Turns))
+	for _, t := range s.Turns {
+		traces[t.Message] = t
+	}
+	var turns []replayTurn
+	for i, msg := range s.Messages {
+		if msg.Role != schema.User {
+			continue
+		}
+		t := replayTurn{message: i, prompt: msg.Content, trace: traces[i]}
+		if i+1 < len(s.Messages) && s.Messages[i+1].Role == schema.Assistant {
+			t.answer = s.Messages[i+1].Content
+		}
+		turns = append(turns, t)
+	}
+	return turns
+}
+
+// printReplayTurn prints turn n of total.
+func printReplayTurn(out io.Writer, n, total int, t replayTurn) {
+	fmt.Fprintf(out, "\n━━━ Turn %d/%d", n, total)
+	if t.trace != nil {
+		fmt.Fprintf(out, " (%s, %s)", t.trace.StartedAt.Local().Format(time.DateTime), time.Duration(t.trace.DurationMS)*time.Millisecond)
+	}
+	fmt.Fprintf(out, " ━━━\n\n👤 Prompt:\n%s\n", t.prompt)
+	if t.trace == nil {
+		fmt.Fprintln(out, "\n(no trace recorded for this turn)")
+	} else {
+		for _, r := range t.trace.Retrievals {
+			fmt.Fprintf(out, "\n🔎 Searched %q: %d found\n", r.Query, len(r.Sources))
+			for _, source := range r.Sources {
+				fmt.Fprintf(out, "   - %s\n", source)
+			}
+		}
+		for _, call := range t.trace.ToolCalls {
+			printReplayedCall(out, call)
+		}
+	}
+	answer := t.answer
+	if answer == "" {
+		answer = "(no answer)"
+	}
+	fmt.Fprintf(out, "\n🤖 Answer:\n%s\n", answer)
+}
+
+func printReplayedCall(out io.Writer, call session.ToolCall) {
+	fmt.Fprintf(out, "\n🔧 %s %s\n", call.Name, call.Arguments)
+	if call.Error != "" {
+		fmt.Fprintf(out, "   ❌ %s\n", call.Error)
+		return
+	}
+	fmt.Fprintf(out, "   → %s\n", indentResult(shorten(call.Result, maxReplayedResult)))
+}
+
+func indentResult(s string) string {
+	return strings.ReplaceAll(s, "\n", "\n     ")
+}
+
+func shorten(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
+
+// replayer re-runs turns of a session.
+type replayer struct {
+	cmd       *cobra.Command
+	session   *session.Session
+	workspace string
+	model     string // Chat model for re-runs; empty for the configured one.
+}
+
+// step prints the turns one at a time, reading what to do after each from
+// in.
+func (r *replayer) step(turns []replayTurn, in *bufio.Scanner) error {
+	out := r.cmd.OutOrStdout()
+	for i := 0; i < len(turns); {
+		t := turns[i]
+		printReplayTurn(out, i+1, len(turns), t)
+		for {
+			fmt.Fprint(out, "\n[Enter] next, r [PROMPT] re-run, m MODEL set model, q quit: ")
+			if !in.Scan() {
+				fmt.Fprintln(out)
+				return in.Err()
+			}
+			line := strings.TrimSpace(in.Text())
+			command, arg, _ := strings.Cut(line, " ")
+			arg = strings.TrimSpace(arg)
+			switch command {
+			case "":
+			case "q":
+				return nil
+			case "m":
+				if arg == "" {
+					fmt.Fprintln(out, "Give the model, such as: m gemini-2.5-pro")
+					continue
+				}
+				r.model = arg
+				fmt.Fprintf(out, "Re-runs now chat with %s\n", r.model)
+				continue
+			case "r":
+				prompt := t.prompt
+				if arg != "" {
+					prompt = arg
+				}
+				if err := r.rerun(t, prompt); err != nil {
+					fmt.Fprintf(r.cmd.ErrOrStderr(), "❌ Re-run failed: %v\n", err)
+				}
+				continue
+			default:
+				fmt.Fprintf(out, "Unknown command %q\n", command)
+				continue
+			}
+			break
+		}
+		i++
+	}
+	fmt.Fprintln(out, "\nEnd of session.")
+	return nil
+}
+
+// rerun sends the conversation before t with prompt in place of its prompt
+// to a fresh agent and prints the answer. The agent runs read-only, so a
+// re-run cannot change the session's workspace.
+func (r *replayer) rerun(t replayTurn, prompt string) error {
+	ctx := tools.WithReadOnly(r.cmd.Context())
+	if err := requireGeminiKey(ctx); err != nil {
+		return err
+	}
+	if info, err := os.Stat(r.workspace); err == nil && info.IsDir() {
+		ctx = tools.WithWorkspace(ctx, r.workspace)
+	}
+	if r.model != "" {
+		ctx = agent.WithChatModel(ctx, r.model)
+	}
+
+	runner, err := agent.NewRunner(ctx)
+	if err != nil {
+		return err
+	}
+	defer runner.Close(context.Background())
+
+	out := r.cmd.OutOrStdout()
+	model := r.model
+	if model == "" {
+		model = "the configured model"
+	}
+	fmt.Fprintf(out, "\n🔁 Re-running with %s:\n%s\n", model, prompt)
+	messages := append(append([]*schema.Message{}, r.session.Messages[:t.message]...), schema.UserMessage(prompt))
+	start := time.Now()
+	answer, err := runner.Generate(ctx, messages, compose.WithCallbacks(replayToolHandler(out)))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\n🤖 New answer (%s):\n%s\n", time.Since(start).Round(time.Millisecond), answer.Content)
+	if answer.Content == t.answer {
+		fmt.Fprintln(out, "(same as the original answer)")
+	}
+	return nil
+}
+
+// replayToolHandler prints the tool calls of a re-run as they finish.
+func replayToolHandler(out io.Writer) callbacks.Handler {
+	type argsKey struct{}
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if in := tool.ConvCallbackInput(input); in != nil && info.Component == components.ComponentOfTool {
+				return context.WithValue(ctx, argsKey{}, in.ArgumentsInJSON)
+			}
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if o := tool.ConvCallbackOutput(output); o != nil && info.Component == components.ComponentOfTool {
+				args, _ := ctx.Value(argsKey{}).(string)
+				printReplayedCall(out, session.ToolCall{Name: info.Name, Arguments: args, Result: o.Response})
+			}
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			if info.Component == components.ComponentOfTool {
+				args, _ := ctx.Value(argsKey{}).(string)
+				printReplayedCall(out, session.ToolCall{Name: info.Name, Arguments: args, Error: err.Error()})
+			}
+			return ctx
+		}).
+		Build()
+}
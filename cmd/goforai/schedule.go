@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/olusolaa/goforai/foundation/scheduler"
+	"github.com/olusolaa/goforai/foundation/snapshot"
+	"github.com/olusolaa/goforai/foundation/truncate"
+	"github.com/spf13/cobra"
+)
+
+func newScheduleCmd() *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run agent prompts on cron schedules",
+	}
+	cmd.PersistentFlags().StringVar(&file, "file", "", "schedules file (default $SCHEDULE_CONFIG or schedules.json)")
+
+	load := func() (*scheduler.Config, error) {
+		if file == "" {
+			file = scheduler.ConfigPath()
+		}
+		cfg, err := scheduler.LoadConfig(file)
+		if err != nil {
+			return nil, err
+		}
+		if len(cfg.Tasks) == 0 {
+			return nil, fmt.Errorf("no tasks found in %s (see schedules.example.json)", file)
+		}
+		return cfg, nil
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List scheduled tasks and when they run next",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cfg, err := load()
+				if err != nil {
+					return err
+				}
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "NAME\tSCHEDULE\tNEXT RUN\tPROMPT")
+				for _, t := range scheduler.New(nil, cfg.Tasks).Tasks() {
+					next := t.Next(time.Now()).Format(time.DateTime)
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Name, t.Schedule, next, truncate.Runes(t.Prompt, 60))
+				}
+				return w.Flush()
+			},
+		},
+		&cobra.Command{
+			Use:   "run",
+			Short: "Run the scheduler until interrupted",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if err := requireGeminiKey(cmd.Context()); err != nil {
+					return err
+				}
+				cfg, err := load()
+				if err != nil {
+					return err
+				}
+
+				ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+				defer stop()
+
+				runner, err := agent.NewRunner(ctx)
+				if err != nil {
+					return err
+				}
+				defer runner.Close(context.Background())
+				fmt.Fprintf(cmd.ErrOrStderr(), "⏰ Running %d scheduled task(s) from %s\n", len(cfg.Tasks), file)
+				return scheduler.New(runner, cfg.Tasks).Run(ctx)
+			},
+		},
+		newScheduleTriggerCmd(load),
+	)
+	return cmd
+}
+
+// newScheduleTriggerCmd runs one task in the foreground, which is how CI
+// jobs use the agent: the answer goes to stdout and the report of the files
+// it changed to stderr and, with --report, to a file kept as an artifact.
+func newScheduleTriggerCmd(load func() (*scheduler.Config, error)) *cobra.Command {
+	var reportPath string
+
+	cmd := &cobra.Command{
+		Use:   "trigger NAME",
+		Short: "Run one task now and deliver its result",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireGeminiKey(cmd.Context()); err != nil {
+				return err
+			}
+			cfg, err := load()
+			if err != nil {
+				return err
+			}
+
+			runner, err := agent.NewRunner(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer runner.Close(context.Background())
+			result, err := scheduler.New(runner, cfg.Tasks).Trigger(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			report := snapshot.Report(result.Changes)
+			fmt.Fprint(cmd.ErrOrStderr(), report)
+			if reportPath != "" {
+				if err := os.WriteFile(reportPath, []byte(report), 0644); err != nil {
+					return fmt.Errorf("failed to write report: %w", err)
+				}
+			}
+			if result.Error != "" {
+				return fmt.Errorf("task %s failed: %s", result.Task, result.Error)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), result.Output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&reportPath, "report", "", "write the files the task changed, with diffs, to this file (git apply accepts it)")
+	return cmd
+}
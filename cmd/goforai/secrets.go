@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/olusolaa/goforai/foundation/secrets"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func newSecretsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage API keys in the OS keyring",
+		Long: "API keys are read from the environment, from the file named by <NAME>_FILE, or from the OS keyring,\n" +
+			"in that order. Storing them in the keyring keeps them out of shell history and dotfiles.",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "set NAME",
+			Short: "Store a secret in the OS keyring (reads the value from the terminal or stdin)",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				value, err := readSecret(cmd, args[0])
+				if err != nil {
+					return err
+				}
+				if err := secrets.Set(args[0], value); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "🔐 Stored %s in the OS keyring\n", args[0])
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "delete NAME",
+			Short: "Remove a secret from the OS keyring",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return secrets.Delete(args[0])
+			},
+		},
+		&cobra.Command{
+			Use:   "check NAME...",
+			Short: "Fail unless every named secret can be read from one of its sources",
+			Args:  cobra.MinimumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				for _, name := range args {
+					_, source, err := secrets.Resolve(name)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "%s is set (%s)\n", name, source)
+				}
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show where each known secret is read from",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+				fmt.Fprintln(w, "NAME\tSOURCE")
+				for _, name := range secrets.Known {
+					_, source, err := secrets.Resolve(name)
+					switch {
+					case errors.Is(err, secrets.ErrNotFound):
+						source = "not set"
+					case err != nil:
+						source = "error: " + err.Error()
+					}
+					fmt.Fprintf(w, "%s\t%s\n", name, source)
+				}
+				return w.Flush()
+			},
+		},
+	)
+	return cmd
+}
+
+// readSecret prompts without echo on a terminal, or reads one line from a pipe.
+func readSecret(cmd *cobra.Command, name string) (string, error) {
+	var value string
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Enter %s: ", name)
+		raw, err := term.ReadPassword(fd)
+		fmt.Fprintln(cmd.ErrOrStderr())
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret: %w", err)
+		}
+		value = string(raw)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read secret from stdin: %w", err)
+		}
+		value = line
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("secret %s cannot be empty", name)
+	}
+	return value, nil
+}
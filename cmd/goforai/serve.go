@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/olusolaa/goforai/foundation/answercache"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/indexing"
+	"github.com/olusolaa/goforai/foundation/ollama"
+	"github.com/olusolaa/goforai/foundation/server"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"github.com/spf13/cobra"
+)
+
+func newServeCmd() *cobra.Command {
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the agent as a web UI and an OpenAI-compatible HTTP API",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireGeminiKey(cmd.Context()); err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			pingModel, err := modelCheck(ctx)
+			if err != nil {
+				return err
+			}
+			serverCfg, err := config.LoadServer()
+			if err != nil {
+				return err
+			}
+
+			// Documents written through the API go into the knowledge base
+			// the agent searches, so they are found from the next turn on.
+			var ingester *indexing.Ingester
+			if serverCfg.DocumentWriters != "" {
+				kb, err := tools.OpenWritableKnowledgeBase(ctx)
+				if err != nil {
+					return err
+				}
+				if ingester, err = indexing.NewIngester(kb, indexing.DefaultDBPath); err != nil {
+					return err
+				}
+				ctx = tools.WithKnowledgeBase(ctx, kb)
+			}
+
+			runner, err := agent.NewRunner(ctx)
+			if err != nil {
+				return err
+			}
+			defer runner.Close(context.Background())
+
+			streamingCfg, err := config.LoadStreaming()
+			if err != nil {
+				return err
+			}
+			heartbeatCfg, err := config.LoadHeartbeat()
+			if err != nil {
+				return err
+			}
+
+			sessionCfg, err := config.LoadSessions()
+			if err != nil {
+				return err
+			}
+			store, err := session.NewStore(ctx, sessionCfg)
+			if err != nil {
+				return fmt.Errorf("failed to open session store: %w", err)
+			}
+			defer store.Close()
+
+			jobsCfg, err := config.LoadJobs()
+			if err != nil {
+				return err
+			}
+
+			answers, err := answercache.NewFromEnv(ctx, indexing.DefaultDBPath)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "🌐 Serving %s on %s (web UI at /, API at POST /v1/chat/completions, %s sessions)\n",
+				server.ModelName, addr, sessionCfg.Store)
+			opts := []server.Option{
+				server.WithAddr(addr),
+				server.WithSessions(session.NewManager(store, sessionCfg)),
+				server.WithAPIKeys(serverCfg.APIKeys),
+				server.WithGroups(serverCfg.Groups),
+				server.WithRateLimit(serverCfg.RequestsPerMinute),
+				server.WithTokenBudget(serverCfg.DailyTokenBudget),
+				server.WithBudgetAlertThreshold(serverCfg.BudgetAlert),
+				server.WithEvents(events.NewDispatcherFromEnv()),
+				server.WithMaxRequestBytes(serverCfg.MaxRequestBytes),
+				server.WithStreaming(streamingCfg),
+				server.WithHeartbeat(heartbeatCfg),
+				server.WithJobs(jobsCfg),
+				server.WithShutdownTimeout(serverCfg.ShutdownTimeout),
+				server.WithReadinessCheck("model", pingModel),
+				server.WithReadinessCheck("knowledge_base", knowledgeBaseCheck(indexing.DefaultDBPath)),
+			}
+			if answers != nil {
+				opts = append(opts, server.WithAnswerCache(answers))
+			}
+			if ingester != nil {
+				opts = append(opts, server.WithDocumentIngestion(ingester, serverCfg.DocumentWriters))
+			}
+			return server.New(runner, opts...).Run(ctx)
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8080", "listen address; non-loopback addresses require SERVER_API_KEYS")
+	return cmd
+}
+
+// modelCheck returns the readiness check of the chat model the agent uses:
+// Gemini, or the local Ollama model in offline mode.
+func modelCheck(ctx context.Context) (server.Check, error) {
+	if !tools.Offline(ctx) {
+		return gemini.Ping, nil
+	}
+	cfg, err := config.LoadOffline()
+	if err != nil {
+		return nil, err
+	}
+	chatModel, err := ollama.NewChatModel(ctx, cfg.ChatModel, cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return chatModel.Ping, nil
+}
+
+// knowledgeBaseCheck fails when the index at path is unreadable. A missing
+// index is healthy: the agent runs without its knowledge base tool then.
+func knowledgeBaseCheck(path string) server.Check {
+	return func(ctx context.Context) error {
+		_, err := chromemdb.ReadManifest(path)
+		if errors.Is(err, chromemdb.ErrDBNotFound) {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/recall"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/spf13/cobra"
+)
+
+func newSessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Inspect and remove the server's stored conversations",
+		Long: "Works on the store selected by SESSION_STORE. The memory store lives inside the\n" +
+			"server process, so these commands need SESSION_STORE=sqlite or redis.",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List live sessions, most recently active first",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withSessions(cmd, func(m *session.Manager) error {
+					sessions, err := m.List(cmd.Context())
+					if err != nil {
+						return err
+					}
+					w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+					fmt.Fprintln(w, "ID\tOWNER\tMESSAGES\tCREATED\tLAST ACTIVE")
+					for _, s := range sessions {
+						owner := s.Owner
+						if owner == "" {
+							owner = "-"
+						}
+						fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", s.ID, owner, len(s.Messages),
+							s.CreatedAt.Format(time.DateTime), s.UpdatedAt.Format(time.DateTime))
+					}
+					return w.Flush()
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "show ID",
+			Short: "Print a session's conversation, even after it closed",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withSessions(cmd, func(m *session.Manager) error {
+					s, err := m.Lookup(cmd.Context(), args[0])
+					if err != nil {
+						return sessionError(args[0], err)
+					}
+					out := cmd.OutOrStdout()
+					var closed string
+					if s.ClosedAt != nil {
+						closed = fmt.Sprintf(", closed %s (%s)", s.ClosedAt.Format(time.DateTime), s.CloseReason)
+					}
+					fmt.Fprintf(out, "Session %s (created %s, last active %s%s)\n",
+						s.ID, s.CreatedAt.Format(time.DateTime), s.UpdatedAt.Format(time.DateTime), closed)
+					for _, msg := range s.Messages {
+						fmt.Fprintf(out, "\n[%s]\n%s\n", msg.Role, msg.Content)
+					}
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "delete ID",
+			Short: "Delete a session and its workspace",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withSessions(cmd, func(m *session.Manager) error {
+					if _, err := m.Lookup(cmd.Context(), args[0]); err != nil {
+						return sessionError(args[0], err)
+					}
+					if err := m.Delete(cmd.Context(), args[0]); err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.ErrOrStderr(), "🗑️  Deleted session %s\n", args[0])
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "index",
+			Short: "Index the stored conversations so the agent can search them (/recall, search_past_conversations)",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withSessions(cmd, func(m *session.Manager) error {
+					index, err := recall.NewFromEnv(cmd.Context())
+					if err != nil {
+						return err
+					}
+					stats, err := index.Sync(cmd.Context(), m)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.ErrOrStderr(), "🗂️  Indexed %d exchanges of %d sessions in %s (%d newly embedded)\n",
+						stats.Exchanges, stats.Sessions, index.Path(), stats.Embedded)
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "search QUERY",
+			Short: "Search the indexed conversations",
+			Args:  cobra.MinimumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				index, err := recall.NewFromEnv(cmd.Context())
+				if err != nil {
+					return err
+				}
+				docs, err := index.Retrieve(cmd.Context(), strings.Join(args, " "))
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), recall.Format(docs))
+				return nil
+			},
+		},
+	)
+	return cmd
+}
+
+// withSessions opens the configured session store for the duration of fn.
+func withSessions(cmd *cobra.Command, fn func(*session.Manager) error) error {
+	cfg, err := config.LoadSessions()
+	if err != nil {
+		return err
+	}
+	if cfg.Store == config.SessionStoreMemory {
+		return errors.New("SESSION_STORE is memory; sessions only exist inside the running server (use sqlite or redis)")
+	}
+	store, err := session.NewStore(cmd.Context(), cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open session store: %w", err)
+	}
+	defer store.Close()
+	return fn(session.NewManager(store, cfg))
+}
+
+func sessionError(id string, err error) error {
+	if errors.Is(err, session.ErrNotFound) {
+		return fmt.Errorf("session %s not found or expired", id)
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/olusolaa/goforai/foundation/truncate"
+	"github.com/spf13/cobra"
+)
+
+func newToolsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tools",
+		Short: "Inspect the agent's toolbox",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the tools available to the agent",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireGeminiKey(cmd.Context()); err != nil {
+				return err
+			}
+
+			toolsList, closeTools, err := agent.SetupTools(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeTools()
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tDESCRIPTION")
+			for _, t := range toolsList {
+				info, err := t.Info(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("failed to describe tool: %w", err)
+				}
+				fmt.Fprintf(w, "%s\t%s\n", info.Name, truncate.Runes(info.Desc, 90))
+			}
+			return w.Flush()
+		},
+	})
+	return cmd
+}
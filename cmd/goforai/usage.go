@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/usage"
+	"github.com/spf13/cobra"
+)
+
+func newUsageCmd() *cobra.Command {
+	var days int
+	var monthly bool
+
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report the tokens spent with each model provider and how much of the quotas is left",
+		Long: "Every chat model call is recorded in USAGE_PATH (data/usage.json) per UTC day, provider and model.\n" +
+			"USAGE_QUOTA_DAILY and USAGE_QUOTA_MONTHLY (provider:tokens pairs) cap the spend: a warning is\n" +
+			"logged at USAGE_ALERT_THRESHOLD (80%) of a quota and calls are refused once it is spent.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ledger := usage.Current()
+			if ledger == nil {
+				return fmt.Errorf("usage tracking is not set up")
+			}
+			since := time.Now().UTC().AddDate(0, 0, -(days - 1))
+			if monthly {
+				since = time.Time{}
+			}
+			records, err := ledger.Records(since)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if len(records) == 0 {
+				fmt.Fprintf(out, "No usage recorded in %s yet.\n", ledger.Path())
+			} else if monthly {
+				err = printMonthlyUsage(out, records)
+			} else {
+				err = printDailyUsage(out, records)
+			}
+			if err != nil {
+				return err
+			}
+
+			quotas, err := ledger.Quotas()
+			if err != nil || len(quotas) == 0 {
+				return err
+			}
+			fmt.Fprintln(out)
+			w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "PROVIDER\tQUOTA\tUSED\tLIMIT\tSPENT")
+			for _, q := range quotas {
+				fmt.Fprintf(w, "%s\tper %s\t%d\t%d\t%.0f%%\n", q.Provider, q.Period, q.Used, q.Limit, 100*float64(q.Used)/float64(q.Limit))
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().IntVar(&days, "days", 30, "days of history to report, today included")
+	cmd.Flags().BoolVar(&monthly, "monthly", false, "sum the whole history per month and provider instead")
+	return cmd
+}
+
+func printDailyUsage(out io.Writer, records []usage.Record) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tPROVIDER\tMODEL\tCALLS\tPROMPT\tCOMPLETION\tTOTAL\tCOST")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t$%.4f\n",
+			r.Date, r.Provider, r.Model, r.Calls, r.PromptTokens, r.CompletionTokens, r.TotalTokens, r.Cost)
+	}
+	return w.Flush()
+}
+
+func printMonthlyUsage(out io.Writer, records []usage.Record) error {
+	type key struct{ month, provider string }
+	var order []key
+	sums := make(map[key]*usage.Record)
+	for _, r := range records {
+		k := key{r.Date[:len("2006-01")], r.Provider}
+		sum, ok := sums[k]
+		if !ok {
+			sum = &usage.Record{}
+			sums[k] = sum
+			order = append(order, k)
+		}
+		sum.Calls += r.Calls
+		sum.TotalTokens += r.TotalTokens
+		sum.Cost += r.Cost
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].month != order[j].month {
+			return order[i].month < order[j].month
+		}
+		return order[i].provider < order[j].provider
+	})
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MONTH\tPROVIDER\tCALLS\tTOKENS\tCOST")
+	for _, k := range order {
+		sum := sums[k]
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t$%.4f\n", k.month, k.provider, sum.Calls, sum.TotalTokens, sum.Cost)
+	}
+	return w.Flush()
+}
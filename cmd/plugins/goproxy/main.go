@@ -0,0 +1,103 @@
+// Command goproxy is an example tool plugin. It looks up the latest version
+// of a Go module on the module proxy, so the agent can check whether a
+// dependency is current without network access of its own.
+//
+// Build it into the agent's plugin directory:
+//
+//	go build -o plugins/goproxy ./cmd/plugins/goproxy
+//
+// It uses https://proxy.golang.org unless GOPROXY_URL says otherwise; like
+// every variable starting with the plugin's name, the agent passes that on.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/olusolaa/goforai/foundation/plugin"
+)
+
+type LatestVersionRequest struct {
+	Module string `json:"module" jsonschema:"description=Module path, such as github.com/cloudwego/eino"`
+}
+
+type LatestVersionResponse struct {
+	Module  string `json:"module"`
+	Version string `json:"version,omitempty"`
+	Time    string `json:"time,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func main() {
+	proxy := strings.TrimSuffix(os.Getenv("GOPROXY_URL"), "/")
+	if proxy == "" {
+		proxy = "https://proxy.golang.org"
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	latest, err := utils.InferTool("latest_version", "Look up the latest released version of a Go module on the module proxy.",
+		func(ctx context.Context, req LatestVersionRequest) (*LatestVersionResponse, error) {
+			return latestVersion(ctx, client, proxy, req.Module), nil
+		})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	plugin.Serve(latest)
+}
+
+func latestVersion(ctx context.Context, client *http.Client, proxy, module string) *LatestVersionResponse {
+	resp := &LatestVersionResponse{Module: module}
+	if module == "" {
+		resp.Error = "module cannot be empty"
+		return resp
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, proxy+"/"+escapePath(module)+"/@latest", nil)
+	if err != nil {
+		resp.Error = fmt.Sprintf("invalid module path: %v", err)
+		return resp
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		resp.Error = fmt.Sprintf("module proxy unavailable: %v", err)
+		return resp
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		resp.Error = fmt.Sprintf("module not found (status %d): %s", res.StatusCode, strings.TrimSpace(string(body)))
+		return resp
+	}
+	var info struct {
+		Version string
+		Time    string
+	}
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		resp.Error = fmt.Sprintf("invalid proxy response: %v", err)
+		return resp
+	}
+	resp.Version, resp.Time = info.Version, info.Time
+	return resp
+}
+
+// escapePath applies the module proxy's case encoding: every upper-case
+// letter becomes '!' and its lower-case form.
+func escapePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/prompts"
 )
 
 // ---
@@ -55,7 +56,20 @@ const (
 )
 
 // ************** NEW: The system prompt for behavioral control. **************
-const systemPrompt = `You are a helpful coding assistant for Go. Never provide code examples in Python. Also never be verboose be very concise.`
+// It is the step2.system template of the prompt library.
+func systemPrompt() (string, error) {
+	t, err := prompts.Get("step2.system")
+	if err != nil {
+		return "", err
+	}
+	return t.Text, nil
+}
+
+// ******** NEW: A partial answer is kept when the stream fails, and /retry continues it. ********
+const (
+	interruptedMarker = "\n\n[This answer was cut off by an error.]"
+	continuePrompt    = "Your previous answer was cut off by an error. Continue it from exactly where it stopped, without repeating anything."
+)
 
 type Agent struct {
 	model   model.ToolCallingChatModel
@@ -71,7 +85,12 @@ func NewAgent(m model.ToolCallingChatModel, in io.Reader, out io.Writer) *Agent
 // sophisticated, user-friendly application.
 func (a *Agent) Run(ctx context.Context) error {
 	// *********** CHANGED: We now initialize state with a system prompt. ***********
-	conversation := []*schema.Message{schema.SystemMessage(systemPrompt)}
+	system, err := systemPrompt()
+	if err != nil {
+		return err
+	}
+	conversation := []*schema.Message{schema.SystemMessage(system)}
+	canRetry := false // Whether the last answer was cut off.
 	fmt.Fprintf(a.out, "\nChat with %s (use 'ctrl-c' to quit)\n", chatModelName)
 	for {
 		fmt.Fprintf(a.out, "%s\nYou%s: ", colorBlue, colorReset)
@@ -82,6 +101,15 @@ func (a *Agent) Run(ctx context.Context) error {
 		if userInput == "" {
 			continue
 		}
+		retrying := userInput == "/retry"
+		if retrying && !canRetry {
+			fmt.Fprintf(a.out, "Nothing to retry: the last answer was not cut off.\n")
+			continue
+		}
+		canRetry = false
+		if retrying {
+			userInput = continuePrompt
+		}
 		conversation = append(conversation, schema.UserMessage(userInput))
 		fmt.Fprintf(a.out, "%s\n%s%s: ", colorYellow, chatModelName, colorReset)
 		// ************ CHANGED: We've switched to a streaming model call. *************
@@ -97,6 +125,7 @@ func (a *Agent) Run(ctx context.Context) error {
 
 		// ************** NEW: The idiomatic Go stream consumption loop. ***************
 		var chunks []*schema.Message
+		var streamErr error
 		firstChunk := true
 		for {
 			chunk, err := streamReader.Recv()
@@ -108,6 +137,7 @@ func (a *Agent) Run(ctx context.Context) error {
 					break
 				}
 				fmt.Fprintf(a.out, "\n\n%sERROR: %s%s\n\n", colorRed, err, colorReset)
+				streamErr = err
 				break
 			}
 			if firstChunk {
@@ -122,6 +152,12 @@ func (a *Agent) Run(ctx context.Context) error {
 
 		if len(chunks) > 0 {
 			fullMsg, _ := schema.ConcatMessages(chunks)
+			if streamErr != nil {
+				// Keep what arrived, marked so the model knows it is incomplete.
+				fullMsg.Content += interruptedMarker
+				canRetry = true
+				fmt.Fprintf(a.out, "Type /retry to continue the answer.\n")
+			}
 			conversation = append(conversation, fullMsg)
 		}
 	}
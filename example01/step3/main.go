@@ -32,6 +32,12 @@ import (
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/schema"
 	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/prompts"
+	"github.com/olusolaa/goforai/foundation/retrieval"
+	"github.com/olusolaa/goforai/foundation/router"
+	"github.com/olusolaa/goforai/foundation/tokens"
 )
 
 // ---
@@ -63,8 +69,11 @@ func run(ctx context.Context) error {
 		return err
 	}
 
+	// ****** NEW: A router that skips retrieval for "hello" and other chatter. ******
+	ragRouter := newRouter(clients.embedder)
+
 	// ************ CHANGED: Create an agent with the new RAG components. **********
-	agent := NewAgent(clients.chatModel, ragRetriever, ragTemplate, os.Stdin, os.Stdout)
+	agent := NewAgent(clients.chatModel, ragRetriever, ragTemplate, ragRouter, os.Stdin, os.Stdout)
 	return agent.Run(ctx)
 }
 
@@ -73,7 +82,19 @@ func run(ctx context.Context) error {
 // ---
 
 // ******** CHANGED: System prompt is now specific to our RAG knowledge base. *******
-const systemPrompt = `You are an assistant with access to a knowledge base about GopherCon Africa 2024. Use the provided context to answer questions accurately.`
+// It is the step3.system template of the prompt library.
+func systemPrompt() (string, error) {
+	t, err := prompts.Get("step3.system")
+	if err != nil {
+		return "", err
+	}
+	return t.Text, nil
+}
+
+const (
+	interruptedMarker = "\n\n[This answer was cut off by an error.]"
+	continuePrompt    = "Your previous answer was cut off by an error. Continue it from exactly where it stopped, without repeating anything."
+)
 
 // ****** CHANGED: Our Agent struct now includes retriever and template dependencies. ******
 
@@ -83,17 +104,19 @@ type Agent struct {
 	model     model.ToolCallingChatModel
 	retriever retriever.Retriever // Finds relevant documents. chromem-go, go native (745 stars)
 	template  prompt.ChatTemplate // Formats prompts with context.
+	router    *router.Router      // Decides whether a message needs retrieval at all.
 	scanner   *bufio.Scanner
 	out       io.Writer
 }
 
 // ********* CHANGED: The constructor now accepts the new RAG components. **********
 
-func NewAgent(m model.ToolCallingChatModel, r retriever.Retriever, t prompt.ChatTemplate, in io.Reader, out io.Writer) *Agent {
+func NewAgent(m model.ToolCallingChatModel, r retriever.Retriever, t prompt.ChatTemplate, rt *router.Router, in io.Reader, out io.Writer) *Agent {
 	return &Agent{
 		model:     m,
 		retriever: r,
 		template:  t,
+		router:    rt,
 		scanner:   bufio.NewScanner(in),
 		out:       out,
 	}
@@ -101,7 +124,12 @@ func NewAgent(m model.ToolCallingChatModel, r retriever.Retriever, t prompt.Chat
 
 // Run is a direct evolution of Step 2, but now performs RAG retrieval before each query.
 func (a *Agent) Run(ctx context.Context) error {
-	conversation := []*schema.Message{schema.SystemMessage(systemPrompt)}
+	system, err := systemPrompt()
+	if err != nil {
+		return err
+	}
+	conversation := []*schema.Message{schema.SystemMessage(system)}
+	canRetry := false // Whether the last answer was cut off.
 	fmt.Fprintf(a.out, "\nChat with a RAG-powered agent (use 'ctrl-c' to quit)\n")
 
 	for {
@@ -113,34 +141,23 @@ func (a *Agent) Run(ctx context.Context) error {
 		if userInput == "" {
 			continue
 		}
-
-		// ********* NEW: Retrieve relevant documents for the user's question. *********
-		// doing heavy vector math. In Go, this is fast, compiled code running without a GIL, and
-		// can be easily parallelized.
-		docs, err := a.retriever.Retrieve(ctx, userInput)
-		if err != nil {
-			fmt.Fprintf(a.out, "\n\n%sERROR: %s%s\n\n", colorRed, err, colorReset)
+		retrying := userInput == "/retry"
+		if retrying && !canRetry {
+			fmt.Fprintf(a.out, "Nothing to retry: the last answer was not cut off.\n")
 			continue
 		}
+		canRetry = false
 
-		// ****** NEW: Format the prompt with retrieved context using a ChatTemplate. ******
-		var sb strings.Builder
-		for i, doc := range docs {
-			if i > 0 {
-				sb.WriteString("\n\n")
+		userMessage := schema.UserMessage(continuePrompt)
+		if !retrying {
+			var err error
+			promptCtx := retrieval.WithPromptTokens(ctx, tokens.EstimateMessages(conversation))
+			if userMessage, err = a.userMessage(promptCtx, userInput); err != nil {
+				fmt.Fprintf(a.out, "\n\n%sERROR: %s%s\n\n", colorRed, err, colorReset)
+				continue
 			}
-			sb.WriteString(fmt.Sprintf("Document %d:\n%s", i+1, doc.Content))
 		}
-
-		messages, err := a.template.Format(ctx, map[string]any{"context": sb.String(), "question": userInput})
-		if err != nil {
-			fmt.Fprintf(a.out, "\n\n%sERROR: %s%s\n\n", colorRed, err, colorReset)
-			continue
-		}
-
-		// Extract the user message with context from the template (skip system message)
-		userMessageWithContext := messages[len(messages)-1]
-		conversation = append(conversation, userMessageWithContext)
+		conversation = append(conversation, userMessage)
 		fmt.Fprintf(a.out, "%s\n%s%s: ", colorYellow, chatModelName, colorReset)
 
 		streamReader, err := a.model.Stream(ctx, conversation)
@@ -153,6 +170,7 @@ func (a *Agent) Run(ctx context.Context) error {
 		go a.showSpinner(done)
 
 		var chunks []*schema.Message
+		var streamErr error
 		firstChunk := true
 		for {
 			chunk, err := streamReader.Recv()
@@ -164,6 +182,7 @@ func (a *Agent) Run(ctx context.Context) error {
 					break
 				}
 				fmt.Fprintf(a.out, "\n\n%sERROR: %s%s\n\n", colorRed, err, colorReset)
+				streamErr = err
 				break
 			}
 			if firstChunk {
@@ -178,12 +197,53 @@ func (a *Agent) Run(ctx context.Context) error {
 
 		if len(chunks) > 0 {
 			fullMsg, _ := schema.ConcatMessages(chunks)
+			if streamErr != nil {
+				// Keep what arrived, marked so the model knows it is incomplete.
+				fullMsg.Content += interruptedMarker
+				canRetry = true
+				fmt.Fprintf(a.out, "Type /retry to continue the answer.\n")
+			}
 			conversation = append(conversation, fullMsg)
 		}
 	}
 	return a.scanner.Err()
 }
 
+// userMessage turns the user's input into the message sent to the model. When the
+// router decides the input needs the knowledge base, the message carries the
+// retrieved documents; otherwise, as for "hello", it is the input alone and no
+// embedding call is made.
+func (a *Agent) userMessage(ctx context.Context, userInput string) (*schema.Message, error) {
+	if !a.router.Route(ctx, userInput).Retrieve {
+		return schema.UserMessage(userInput), nil
+	}
+
+	// ********* NEW: Retrieve relevant documents for the user's question. *********
+	// doing heavy vector math. In Go, this is fast, compiled code running without a GIL, and
+	// can be easily parallelized.
+	docs, err := a.retriever.Retrieve(ctx, userInput)
+	if err != nil {
+		return nil, err
+	}
+
+	// ****** NEW: Format the prompt with retrieved context using a ChatTemplate. ******
+	var sb strings.Builder
+	for i, doc := range docs {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("Document %d:\n%s", i+1, doc.Content))
+	}
+
+	messages, err := a.template.Format(ctx, prompts.EscapeVars(map[string]any{"context": sb.String(), "question": userInput}))
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract the user message with context from the template (skip system message)
+	return messages[len(messages)-1], nil
+}
+
 // *** Helper method for the concurrent spinner (Unchanged from Step 2). ***
 func (a *Agent) showSpinner(done <-chan struct{}) {
 	spinner := `|/-\`
@@ -247,22 +307,59 @@ func newAIClients(ctx context.Context) (*aiClients, error) {
 
 // ********* NEW: A factory specifically for our vector database retriever. *********
 func newRetriever(ctx context.Context, embedder embedding.Embedder) (retriever.Retriever, error) {
-	return chromemdb.New(ctx, "gophercon-knowledge", embedder,
+	kb, err := chromemdb.New(ctx, "gophercon-knowledge", embedder,
 		chromemdb.WithDBPath(dbPath),
 		chromemdb.WithTopK(3),
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	// How many documents a search returns, and how much of each, follows the
+	// context the conversation leaves free instead of the fixed 3.
+	caps, ok := models.Lookup(chatModelName)
+	if !ok {
+		return kb, nil
+	}
+	retrievalConfig, err := config.LoadRetrieval()
+	if err != nil {
+		return nil, err
+	}
+	chunking, err := config.LoadChunking()
+	if err != nil {
+		return nil, err
+	}
+	return retrieval.Wrap(kb, retrieval.NewSizer(caps, retrievalConfig, chunking.Size)), nil
+}
+
+// ************ NEW: A factory for the router that gates retrieval. *************
+// The centroid of the knowledge base lets it recognize on-topic questions the
+// keyword rules miss; without one it retrieves whenever in doubt.
+func newRouter(embedder embedding.Embedder) *router.Router {
+	centroid, err := chromemdb.ReadCentroid(dbPath, "gophercon-knowledge")
+	if err != nil {
+		log.Printf("retrieval router runs without a centroid: %v", err)
+		return router.New()
+	}
+	return router.New(router.WithCentroid(embedder, centroid, 0))
 }
 
 // **************** NEW: A factory for the RAG chat template. ******************
 func newRAGTemplate() (prompt.ChatTemplate, error) {
+	system, err := systemPrompt()
+	if err != nil {
+		return nil, err
+	}
+	rag, err := prompts.Get("step3.rag")
+	if err != nil {
+		return nil, err
+	}
+	if err := rag.Expect("context", "question"); err != nil {
+		return nil, err
+	}
 	return prompt.FromMessages(
 		schema.FString,
-		schema.SystemMessage(systemPrompt),
-		schema.UserMessage(`Based ONLY on the following context, answer the question.
-
-Context:
-{context}
-
-Question: {question}`),
+		schema.SystemMessage(system),
+		schema.UserMessage(rag.Text),
 	), nil
 }
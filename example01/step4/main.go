@@ -38,6 +38,12 @@ import (
 	"github.com/cloudwego/eino/flow/agent/react"
 	"github.com/cloudwego/eino/schema"
 	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/prompts"
+	"github.com/olusolaa/goforai/foundation/retrieval"
+	"github.com/olusolaa/goforai/foundation/router"
+	"github.com/olusolaa/goforai/foundation/tokens"
 )
 
 // ---
@@ -64,6 +70,7 @@ func run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	ragRouter := newRouter(clients.embedder)
 
 	// ************** NEW: Build the tool registry for our new tools. **************
 	toolRegistry, err := newToolRegistry(ctx)
@@ -72,7 +79,7 @@ func run(ctx context.Context) error {
 	}
 
 	// ******** CHANGED: Build the final, most powerful agent with all components. ********
-	agent := NewAgent(clients.chatModel, ragRetriever, ragTemplate, toolRegistry, os.Stdin, os.Stdout)
+	agent := NewAgent(clients.chatModel, ragRetriever, ragTemplate, ragRouter, toolRegistry, os.Stdin, os.Stdout)
 	return agent.Run(ctx)
 }
 
@@ -81,21 +88,35 @@ func run(ctx context.Context) error {
 // ---
 
 // ******* CHANGED: System prompt now reflects the agent's full capabilities. *******
-const systemPrompt = `You are an assistant with access to a knowledge base and internet search. Use the knowledge base for GopherCon Africa questions. Use internet search for all other topics.`
+// It is the step4.system template of the prompt library.
+func systemPrompt() (string, error) {
+	t, err := prompts.Get("step4.system")
+	if err != nil {
+		return "", err
+	}
+	return t.Text, nil
+}
+
+const (
+	interruptedMarker = "\n\n[This answer was cut off by an error.]"
+	continuePrompt    = "Your previous answer was cut off by an error. Continue it from exactly where it stopped, without repeating anything."
+)
 
 // ******* CHANGED: The agent now holds the powerful `react.Agent` as its brain. *******
 
 type Agent struct {
 	reactAgent *react.Agent        // The decision-making brain.
 	retriever  retriever.Retriever // The knowledge base.
+	webSearch  tool.InvokableTool  // The fallback when the knowledge base has no answer.
 	template   prompt.ChatTemplate // Formats prompts with knowledge.
+	router     *router.Router      // Decides whether a message needs the fallback chain at all.
 	scanner    *bufio.Scanner
 	out        io.Writer
 }
 
 // ************ We build the `react.Agent` here, giving it the tools. **************
 
-func NewAgent(m model.ToolCallingChatModel, r retriever.Retriever, t prompt.ChatTemplate, toolRegistry map[string]tool.BaseTool, in io.Reader, out io.Writer) *Agent {
+func NewAgent(m model.ToolCallingChatModel, r retriever.Retriever, t prompt.ChatTemplate, rt *router.Router, toolRegistry map[string]tool.BaseTool, in io.Reader, out io.Writer) *Agent {
 	toolsList := make([]tool.BaseTool, 0, len(toolRegistry))
 	for _, tool := range toolRegistry {
 		toolsList = append(toolsList, tool)
@@ -103,18 +124,26 @@ func NewAgent(m model.ToolCallingChatModel, r retriever.Retriever, t prompt.Chat
 	config := &react.AgentConfig{MaxStep: 10, ToolCallingModel: m}
 	config.ToolsConfig.Tools = toolsList
 	reactAgent, _ := react.NewAgent(context.Background(), config)
+	webSearch, _ := toolRegistry["search_internet"].(tool.InvokableTool)
 
 	return &Agent{
 		reactAgent: reactAgent,
 		retriever:  r,
+		webSearch:  webSearch,
 		template:   t,
+		router:     rt,
 		scanner:    bufio.NewScanner(in),
 		out:        out,
 	}
 }
 
 func (a *Agent) Run(ctx context.Context) error {
-	conversation := []*schema.Message{schema.SystemMessage(systemPrompt)}
+	system, err := systemPrompt()
+	if err != nil {
+		return err
+	}
+	conversation := []*schema.Message{schema.SystemMessage(system)}
+	canRetry := false // Whether the last answer was cut off.
 	fmt.Fprintf(a.out, "\nChat with a RAG + Tool-powered agent (use 'ctrl-c' to quit)\n")
 
 	for {
@@ -126,32 +155,23 @@ func (a *Agent) Run(ctx context.Context) error {
 		if userInput == "" {
 			continue
 		}
-
-		// *** SAME: We still perform the RAG step first to gather context. ***
-		docs, err := a.retriever.Retrieve(ctx, userInput)
-		if err != nil {
-			fmt.Fprintf(a.out, "\n\n%sERROR: %s%s\n\n", colorRed, err, colorReset)
+		retrying := userInput == "/retry"
+		if retrying && !canRetry {
+			fmt.Fprintf(a.out, "Nothing to retry: the last answer was not cut off.\n")
 			continue
 		}
+		canRetry = false
 
-		// Build context string from retrieved documents
-		var sb strings.Builder
-		for i, doc := range docs {
-			if i > 0 {
-				sb.WriteString("\n---\n")
+		userMessage := schema.UserMessage(continuePrompt)
+		if !retrying {
+			var err error
+			promptCtx := retrieval.WithPromptTokens(ctx, tokens.EstimateMessages(conversation))
+			if userMessage, err = a.userMessage(promptCtx, userInput); err != nil {
+				fmt.Fprintf(a.out, "\n\n%sERROR: %s%s\n\n", colorRed, err, colorReset)
+				continue
 			}
-			sb.WriteString(doc.Content)
-		}
-
-		messages, err := a.template.Format(ctx, map[string]any{"context": sb.String(), "question": userInput})
-		if err != nil {
-			fmt.Fprintf(a.out, "\n\n%sERROR: %s%s\n\n", colorRed, err, colorReset)
-			continue
 		}
-
-		// Extract the user message with context from the template (skip system message)
-		userMessageWithContext := messages[len(messages)-1]
-		conversation = append(conversation, userMessageWithContext)
+		conversation = append(conversation, userMessage)
 		fmt.Fprintf(a.out, "%s\n%s%s: ", colorYellow, chatModelName, colorReset)
 
 		// ******** CHANGED: We hand off to the `react.Agent` for the final decision. ********
@@ -166,6 +186,7 @@ func (a *Agent) Run(ctx context.Context) error {
 		go a.showSpinner(done)
 
 		var chunks []*schema.Message
+		var streamErr error
 		firstChunk := true
 		for {
 			chunk, err := streamReader.Recv()
@@ -177,6 +198,7 @@ func (a *Agent) Run(ctx context.Context) error {
 					break
 				}
 				fmt.Fprintf(a.out, "\n\n%sERROR: %s%s\n\n", colorRed, err, colorReset)
+				streamErr = err
 				break
 			}
 			if firstChunk {
@@ -191,12 +213,98 @@ func (a *Agent) Run(ctx context.Context) error {
 
 		if len(chunks) > 0 {
 			fullMsg, _ := schema.ConcatMessages(chunks)
+			if streamErr != nil {
+				// Keep what arrived, marked so the model knows it is incomplete.
+				fullMsg.Content += interruptedMarker
+				canRetry = true
+				fmt.Fprintf(a.out, "Type /retry to continue the answer.\n")
+			}
 			conversation = append(conversation, fullMsg)
 		}
 	}
 	return a.scanner.Err()
 }
 
+// userMessage turns the user's input into the message sent to the agent. When
+// the router decides the input needs outside knowledge, the message carries
+// what the fallback chain found; otherwise, as for "hello", it is the input
+// alone and the agent can still reach for its tools if it must.
+func (a *Agent) userMessage(ctx context.Context, userInput string) (*schema.Message, error) {
+	if !a.router.Route(ctx, userInput).Retrieve {
+		return schema.UserMessage(userInput), nil
+	}
+
+	// ******** CHANGED: Context comes from a fallback chain instead of always from RAG. ********
+	knowledge, err := a.gatherContext(ctx, userInput)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := a.template.Format(ctx, prompts.EscapeVars(map[string]any{"context": knowledge, "question": userInput}))
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract the user message with context from the template (skip system message)
+	return messages[len(messages)-1], nil
+}
+
+// ******** NEW: The retrieval fallback chain: knowledge base → web → "I don't know". ********
+
+// minKBScore is the similarity below which a knowledge base document is
+// considered unrelated to the question.
+const minKBScore = 0.5
+
+// noContext tells the model that neither source had anything, so it admits it
+// does not know rather than inventing an answer.
+const noContext = "(Nothing relevant was found in the knowledge base or on the web.)"
+
+// gatherContext tries the knowledge base first and keeps only documents that
+// match the question closely. When none does, it searches the web; when that
+// finds nothing either, it returns noContext.
+func (a *Agent) gatherContext(ctx context.Context, question string) (string, error) {
+	docs, err := a.retriever.Retrieve(ctx, question)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, doc := range docs {
+		if doc.Score() < minKBScore {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n---\n")
+		}
+		sb.WriteString(doc.Content)
+	}
+	if sb.Len() > 0 {
+		return "From the GopherCon knowledge base:\n" + sb.String(), nil
+	}
+
+	if a.webSearch == nil {
+		return noContext, nil
+	}
+	args, _ := json.Marshal(TavilySearchRequest{Query: question})
+	out, err := a.webSearch.InvokableRun(ctx, string(args))
+	if err != nil {
+		// A failed search is no reason to fail the turn; the model can
+		// still say it doesn't know.
+		return noContext, nil
+	}
+	var result TavilySearchResponse
+	if err := json.Unmarshal([]byte(out), &result); err != nil || (result.Answer == "" && len(result.Results) == 0) {
+		return noContext, nil
+	}
+	sb.WriteString("From a web search:\n")
+	if result.Answer != "" {
+		sb.WriteString(result.Answer + "\n")
+	}
+	for _, r := range result.Results {
+		fmt.Fprintf(&sb, "---\n%s\n%s\n", r.URL, r.Content)
+	}
+	return sb.String(), nil
+}
+
 // *** Helper method for the concurrent spinner (Unchanged from Step 2). ***
 func (a *Agent) showSpinner(done <-chan struct{}) {
 	spinner := `|/-\`
@@ -254,22 +362,58 @@ func newAIClients(ctx context.Context) (*aiClients, error) {
 }
 
 func newRetriever(ctx context.Context, embedder embedding.Embedder) (retriever.Retriever, error) {
-	return chromemdb.New(ctx, "gophercon-knowledge", embedder,
+	kb, err := chromemdb.New(ctx, "gophercon-knowledge", embedder,
 		chromemdb.WithDBPath(dbPath),
 		chromemdb.WithTopK(3),
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	// How many documents a search returns, and how much of each, follows the
+	// context the conversation leaves free instead of the fixed 3.
+	caps, ok := models.Lookup(chatModelName)
+	if !ok {
+		return kb, nil
+	}
+	retrievalConfig, err := config.LoadRetrieval()
+	if err != nil {
+		return nil, err
+	}
+	chunking, err := config.LoadChunking()
+	if err != nil {
+		return nil, err
+	}
+	return retrieval.Wrap(kb, retrieval.NewSizer(caps, retrievalConfig, chunking.Size)), nil
+}
+
+// newRouter builds the router that gates retrieval, using the centroid of the
+// knowledge base when the database has one.
+func newRouter(embedder embedding.Embedder) *router.Router {
+	centroid, err := chromemdb.ReadCentroid(dbPath, "gophercon-knowledge")
+	if err != nil {
+		log.Printf("retrieval router runs without a centroid: %v", err)
+		return router.New()
+	}
+	return router.New(router.WithCentroid(embedder, centroid, 0))
 }
 
 func newRAGTemplate() (prompt.ChatTemplate, error) {
+	system, err := systemPrompt()
+	if err != nil {
+		return nil, err
+	}
+	rag, err := prompts.Get("step4.rag")
+	if err != nil {
+		return nil, err
+	}
+	if err := rag.Expect("context", "question"); err != nil {
+		return nil, err
+	}
 	return prompt.FromMessages(
 		schema.FString,
-		schema.SystemMessage(systemPrompt),
-		schema.UserMessage(`Based on the following context if it is relevant, answer the question.
-
-Context:
-{context}
-
-Question: {question}`),
+		schema.SystemMessage(system),
+		schema.UserMessage(rag.Text),
 	), nil
 }
 
@@ -3,21 +3,71 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"os/signal"
+	"slices"
 	"strings"
 
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"github.com/olusolaa/goforai/example01/step5/ui"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/completion"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/language"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/pacing"
+	"github.com/olusolaa/goforai/foundation/phases"
+	"github.com/olusolaa/goforai/foundation/postprocess"
+	"github.com/olusolaa/goforai/foundation/recall"
+	"github.com/olusolaa/goforai/foundation/retrieval"
+	"github.com/olusolaa/goforai/foundation/speech"
+	"github.com/olusolaa/goforai/foundation/telemetry"
+	"github.com/olusolaa/goforai/foundation/tokens"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // Agent orchestrates the Eino graph and manages the conversation state.
 // It is decoupled from the UI, which is provided as a dependency.
 type Agent struct {
 	graph        compose.Runnable[*UserMessage, *schema.Message]
+	toolbox      []tool.BaseTool // Every tool set up at start; the graph calls those not disabled.
+	disabled     map[string]bool // Tools turned off with /tools disable.
+	closeTools   func() error
 	ui           *ui.TerminalUI
 	conversation []*schema.Message
+	attachments  []*attachment       // Files added with /attach, sent before the conversation.
+	pins         *tools.Pins         // Facts pinned with /pin or pin_fact, sent before the attachments.
+	summarizer   model.BaseChatModel // Writes the summary for /compact.
+	tokens       tokens.Counter      // Counts prompts with the model's tokenizer; nil estimates them.
+	recorder     *telemetry.Recorder // Optional; ships turn traces to Langfuse or a webhook.
+	events       *events.Dispatcher  // Optional; posts turn and tool failure events to webhooks.
+	streaming    config.Streaming    // How the answer is paced onto the terminal.
+	heartbeat    config.Heartbeat    // When a quiet turn says it is still working, and when it is stopped.
+	retry        string              // What /retry sends after a failed turn; empty after one that succeeded.
+	speaker      speech.Speaker      // Set by /voice on; reads each answer aloud.
+	cancelSpeech context.CancelFunc  // Stops the answer being read aloud.
+	pushToTalk   bool                // Set by /talk on; an empty input line records a question.
+	microphone   *speech.Recorder    // Created by the first /talk.
+	transcriber  speech.Transcriber
+	model        string                  // Name of the chat model; /model changes it.
+	recall       *recall.Index           // Searched by /recall; created on first use.
+	transcript   *transcript             // Set by WithTranscript; tees the session to a file.
+	retrievals   *chromemdb.RetrievalLog // Searches of the last answer; voted on by /good and /bad.
+	watch        *watcher                // Set with WATCH_WORKSPACE; notices files the user changes between turns.
+	postprocess  postprocess.Pipeline    // Rewrites each finished answer before it is kept.
+	completer    *completion.Completer   // Completes the input line from commands, paths and recent symbols.
 }
 
 // UserMessage defines the input structure for the agent's graph.
@@ -29,16 +79,221 @@ type UserMessage struct {
 
 // New creates and initializes a new Agent.
 // It builds the Eino graph and sets up the initial state.
-func New(ctx context.Context, ui *ui.TerminalUI) (*Agent, error) {
-	graph, err := buildEinoGraph(ctx)
+func New(ctx context.Context, ui *ui.TerminalUI, opts ...Option) (*Agent, error) {
+	streaming, err := config.LoadStreaming()
 	if err != nil {
+		return nil, err
+	}
+	heartbeat, err := config.LoadHeartbeat()
+	if err != nil {
+		return nil, err
+	}
+	postprocessing, err := config.LoadPostprocess()
+	if err != nil {
+		return nil, err
+	}
+	if err := requireToolCalling(ctx); err != nil {
 		return nil, fmt.Errorf("failed to build agent graph: %w", err)
 	}
+	toolbox, closeTools, err := SetupTools(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build agent graph: failed to set up tools: %w", err)
+	}
 
-	return &Agent{
-		graph:        graph,
+	a := &Agent{
+		toolbox:      toolbox,
+		disabled:     make(map[string]bool),
+		closeTools:   closeTools,
 		ui:           ui,
 		conversation: make([]*schema.Message, 0),
+		pins:         tools.NewPins(),
+		events:       events.NewDispatcherFromEnv(),
+		streaming:    streaming,
+		heartbeat:    heartbeat,
+		model:        chatModelName(ctx),
+		completer:    completion.New(tools.Workspace(ctx), commandNames()...),
+	}
+	ui.SetCompleter(a.completer)
+	if err := a.rebuild(ctx); err != nil {
+		closeTools()
+		return nil, err
+	}
+	a.recorder = newRecorderFromEnv(a.model)
+	// Answers are translated with the model the session started with.
+	if a.postprocess, err = postprocess.New(postprocessing, language.NewTranslator(a.summarizer)); err != nil {
+		closeTools()
+		return nil, err
+	}
+	if config.WatchWorkspace() {
+		root := tools.Workspace(ctx)
+		if root == "" {
+			root = "."
+		}
+		if a.watch, err = newWatcher(root); err != nil {
+			closeTools()
+			return nil, err
+		}
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a, nil
+}
+
+// Model returns the name of the model the agent chats with.
+func (a *Agent) Model() string {
+	return a.model
+}
+
+// newRecorderFromEnv returns a trace recorder for turns answered by
+// modelName when an exporter is configured, or nil.
+func newRecorderFromEnv(modelName string) *telemetry.Recorder {
+	if exporter := telemetry.NewExporterFromEnv(); exporter != nil {
+		return telemetry.NewRecorder(exporter, modelName)
+	}
+	return nil
+}
+
+// Close shuts down the tools' external MCP servers and waits for pending
+// trace exports, or for ctx to be done.
+func (a *Agent) Close(ctx context.Context) error {
+	return closeAll(ctx, a.closeTools, a.recorder)
+}
+
+func closeAll(ctx context.Context, closeTools func() error, recorder *telemetry.Recorder) error {
+	err := closeTools()
+	if recorder != nil {
+		err = errors.Join(err, recorder.Flush(ctx))
+	}
+	return err
+}
+
+// Runner executes the agent graph for callers that manage conversation
+// history themselves, such as the HTTP server. It is safe for concurrent use.
+type Runner struct {
+	graph      compose.Runnable[*UserMessage, *schema.Message]
+	closeTools func() error
+	recorder   *telemetry.Recorder // Optional; ships turn traces to Langfuse or a webhook.
+}
+
+// NewRunner builds the agent graph without any terminal UI attached.
+func NewRunner(ctx context.Context) (*Runner, error) {
+	graph, closeTools, err := buildEinoGraph(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build agent graph: %w", err)
+	}
+	return &Runner{graph: graph, closeTools: closeTools, recorder: newRecorderFromEnv(chatModelName(ctx))}, nil
+}
+
+// Close shuts down the tools' external MCP servers and waits for pending
+// trace exports, or for ctx to be done.
+func (r *Runner) Close(ctx context.Context) error {
+	return closeAll(ctx, r.closeTools, r.recorder)
+}
+
+// Generate answers the last user message in messages, treating the earlier
+// messages as conversation history.
+func (r *Runner) Generate(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (msg *schema.Message, err error) {
+	input, err := toUserMessage(messages)
+	if err != nil {
+		return nil, err
+	}
+	ctx = turnContext(ctx, input)
+	if r.recorder != nil {
+		ctx = r.recorder.StartTurn(ctx, "server.turn", "", input.Query)
+		defer func() { r.recorder.EndTurn(ctx, answerOf(msg), err) }()
+	}
+	return r.graph.Invoke(ctx, input, append(r.defaultOptions(), opts...)...)
+}
+
+// Stream is the streaming counterpart of Generate. With a trace recorder the
+// turn ends when the caller has drained or closed the returned stream.
+func (r *Runner) Stream(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (*schema.StreamReader[*schema.Message], error) {
+	input, err := toUserMessage(messages)
+	if err != nil {
+		return nil, err
+	}
+	ctx = turnContext(ctx, input)
+	if r.recorder == nil {
+		return r.graph.Stream(ctx, input, append(r.defaultOptions(), opts...)...)
+	}
+
+	ctx = r.recorder.StartTurn(ctx, "server.turn", "", input.Query)
+	reader, err := r.graph.Stream(ctx, input, append(r.defaultOptions(), opts...)...)
+	if err != nil {
+		r.recorder.EndTurn(ctx, nil, err)
+		return nil, err
+	}
+
+	out, w := schema.Pipe[*schema.Message](0)
+	go func() {
+		defer reader.Close()
+		defer w.Close()
+		var chunks []*schema.Message
+		var streamErr error
+		for {
+			chunk, err := reader.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				streamErr = err
+				w.Send(nil, err)
+				break
+			}
+			chunks = append(chunks, chunk)
+			if closed := w.Send(chunk, nil); closed {
+				streamErr = errors.New("stream closed by the caller")
+				break
+			}
+		}
+		var answer *schema.Message
+		if streamErr == nil && len(chunks) > 0 {
+			answer, _ = schema.ConcatMessages(chunks)
+		}
+		r.recorder.EndTurn(ctx, answerOf(answer), streamErr)
+	}()
+	return out, nil
+}
+
+func (r *Runner) defaultOptions() []compose.Option {
+	handlers := []callbacks.Handler{telemetry.NewHandler(), logger.NewCallbackHandler()}
+	if r.recorder != nil {
+		handlers = append(handlers, r.recorder.Handler())
+	}
+	return []compose.Option{compose.WithCallbacks(handlers...)}
+}
+
+// answerOf is the trace output for a turn's reply.
+func answerOf(msg *schema.Message) any {
+	if msg == nil {
+		return nil
+	}
+	return msg.Content
+}
+
+// turnContext prepares ctx for a turn answering input: it records how much
+// of the context input takes up, so that knowledge base searches fit in what
+// is left, and starts the turn's count of failed tool calls and its tool
+// prefetches.
+func turnContext(ctx context.Context, input *UserMessage) context.Context {
+	ctx = tools.WithFailureTally(ctx)
+	ctx = tools.WithSpeculation(ctx)
+	return retrieval.WithPromptTokens(ctx, tokens.EstimateMessages(input.History)+tokens.Estimate(input.Query))
+}
+
+// toUserMessage splits a message list into the graph's input contract.
+func toUserMessage(messages []*schema.Message) (*UserMessage, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("at least one message is required")
+	}
+	last := messages[len(messages)-1]
+	if last.Role != schema.User {
+		return nil, fmt.Errorf("the last message must be from the user, got role '%s'", last.Role)
+	}
+	return &UserMessage{
+		Query:   last.Content,
+		History: messages[:len(messages)-1],
 	}, nil
 }
 
@@ -49,76 +304,314 @@ func (a *Agent) Run(ctx context.Context) error {
 	for {
 		userInput, ok := a.ui.GetUserInput()
 		if !ok || strings.ToLower(userInput) == "exit" || strings.ToLower(userInput) == "quit" {
+			a.stopSpeaking()
 			fmt.Println("\n👋 Goodbye!")
 			return nil
 		}
-		if userInput == "" {
+		switch {
+		case userInput == "" && a.pushToTalk:
+			userInput = a.listen(ctx)
+		case userInput == "/talk" || strings.HasPrefix(userInput, "/talk "):
+			userInput = a.setTalk(ctx, strings.TrimSpace(strings.TrimPrefix(userInput, "/talk")))
+		case userInput == "/retry":
+			if userInput = a.retry; userInput == "" {
+				a.ui.DisplayNotice("Nothing to retry: the last turn succeeded.")
+			}
+		}
+		if userInput == "" || a.runCommand(ctx, userInput) {
 			continue
 		}
+		a.noteWorkspaceChanges()
+		if caps, ok := models.Lookup(a.model); ok {
+			a.fitContext(ctx, caps, userInput)
+		}
 
-		// Execute the agent's logic for a single turn.
-		if err := a.executeTurn(ctx, userInput); err != nil {
+		// Execute the agent's logic for a single turn. Ctrl-C cancels the
+		// turn, tools included, instead of exiting.
+		a.stopSpeaking()
+		a.retry = ""
+		turnCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		err := a.executeTurn(turnCtx, userInput)
+		interrupted := turnCtx.Err() != nil && ctx.Err() == nil
+		stop()
+		a.forgetWorkspaceChanges()
+		switch {
+		case interrupted:
+			a.ui.DisplayNotice("\nTurn cancelled.")
+		case err != nil:
 			a.ui.DisplayError(err)
+			a.recover(err)
+		default:
+			a.speak(ctx)
+		}
+		if err != nil || interrupted {
+			a.offerRetry(userInput)
 		}
 	}
 }
 
+// recover tells the user how to get past errors the next turn can fix.
+func (a *Agent) recover(err error) {
+	switch {
+	case errors.Is(err, gemini.ErrContextTooLong):
+		dropped := a.dropOldestTurns()
+		a.ui.DisplayNotice(fmt.Sprintf("The conversation no longer fits the model's context; dropped the oldest %d messages. Please ask again.", dropped))
+	case errors.Is(err, gemini.ErrRateLimited):
+		a.ui.DisplayNotice("The Gemini API is rate limiting requests; wait a moment before asking again.")
+	}
+}
+
+// interruptedMarker ends an answer kept in the conversation after its stream
+// failed, so the model knows it is incomplete.
+const interruptedMarker = "\n\n[This answer was cut off by an error.]"
+
+// continuePrompt is what /retry sends after an answer was cut off.
+const continuePrompt = "Your previous answer was cut off by an error. Continue it from exactly where it stopped, without repeating anything."
+
+// offerRetry tells the user what /retry will do after the failed turn that
+// userInput started: continue the answer if part of it was kept, or else
+// send userInput again.
+func (a *Agent) offerRetry(userInput string) {
+	if a.retry == continuePrompt {
+		a.ui.DisplayNotice("The partial answer is kept; /retry continues it.")
+		return
+	}
+	a.retry = userInput
+	a.ui.DisplayNotice("/retry asks again.")
+}
+
+// contextWarning is the share of the context window above which fitContext
+// suggests /compact.
+const contextWarning = 0.8
+
+// fitContext keeps the next turn within the model's context window. Past the
+// window it drops the oldest turns before the API rejects the request; past
+// contextWarning of it, it suggests /compact. The local estimate decides
+// while the prompt is well within the window; past half of it the model's
+// tokenizer counts.
+func (a *Agent) fitContext(ctx context.Context, caps models.Capabilities, userInput string) {
+	used := func() int {
+		history := a.history()
+		n := tokens.EstimateMessages(history) + tokens.Estimate(userInput)
+		if a.tokens == nil || n <= caps.ContextWindow/2 {
+			return n
+		}
+		return tokens.Count(ctx, a.tokens, append(slices.Clip(history), schema.UserMessage(userInput)))
+	}
+	dropped := 0
+	for used() > caps.ContextWindow {
+		n := a.dropOldestTurns()
+		if n == 0 {
+			break
+		}
+		dropped += n
+	}
+	switch {
+	case dropped > 0:
+		a.ui.DisplayNotice(fmt.Sprintf("The conversation no longer fits the model's context; dropped the oldest %d messages.", dropped))
+	case float64(used()) > contextWarning*float64(caps.ContextWindow):
+		a.ui.DisplayNotice(fmt.Sprintf("The conversation fills ~%d%% of the model's context window; /compact makes room.", used()*100/caps.ContextWindow))
+	}
+}
+
+// dropOldestTurns removes the older half of the conversation, keeping
+// user/assistant pairs together, and returns the number of messages removed.
+func (a *Agent) dropOldestTurns() int {
+	n := len(a.conversation) / 2
+	n += n % 2
+	if n == 0 {
+		return 0
+	}
+	a.conversation = append(a.conversation[:0], a.conversation[n:]...)
+	return n
+}
+
+// Ask runs a single non-interactive turn and returns the full response.
+// The exchange is appended to the conversation like an interactive turn.
+// opts are passed to the graph, such as callbacks that measure the turn.
+func (a *Agent) Ask(ctx context.Context, question string, opts ...compose.Option) (response *schema.Message, err error) {
+	ctx, _ = logger.WithRequestID(ctx)
+	input := &UserMessage{
+		Query:   question,
+		History: a.history(),
+	}
+	ctx = tools.WithPins(turnContext(ctx, input), a.pins)
+
+	handlers := []callbacks.Handler{telemetry.NewHandler(), logger.NewCallbackHandler()}
+	if a.recorder != nil {
+		ctx = a.recorder.StartTurn(ctx, "agent.ask", "", question)
+		handlers = append(handlers, a.recorder.Handler())
+		defer func() { a.recorder.EndTurn(ctx, answerOf(response), err) }()
+	}
+
+	response, err = a.graph.Invoke(ctx, input, append([]compose.Option{compose.WithCallbacks(handlers...)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("graph execution failed: %w", err)
+	}
+	a.updateConversationHistory(question, response)
+	return response, nil
+}
+
+// Reset clears the conversation history, attachments and pinned facts.
+func (a *Agent) Reset() {
+	a.conversation = a.conversation[:0]
+	a.attachments = nil
+	a.pins.Clear()
+}
+
 // executeTurn handles a single user query, from graph execution to response streaming.
-func (a *Agent) executeTurn(ctx context.Context, userInput string) error {
+func (a *Agent) executeTurn(ctx context.Context, userInput string) (err error) {
+	// A span per turn groups every node, model call, and tool call beneath it.
+	ctx, span := telemetry.Tracer().Start(ctx, "agent.turn")
+	ctx, requestID := logger.WithRequestID(ctx)
+	span.SetAttributes(
+		attribute.String("agent.request_id", requestID),
+		attribute.Int("agent.query_size", len(userInput)),
+		attribute.Int("agent.history_messages", len(a.conversation)),
+	)
+	logger.FromContext(ctx).Info("turn started", "history_messages", len(a.conversation))
+	a.transcript.user(userInput)
+	defer func() {
+		a.transcript.endTurn()
+		if err != nil {
+			a.transcript.line("Error: %v", oneLine(err.Error()))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			logger.FromContext(ctx).Error("turn failed", "error", err)
+		}
+		span.End()
+	}()
+
 	input := &UserMessage{
 		Query:   userInput,
-		History: a.conversation,
+		History: a.history(),
 	}
+	ctx = tools.WithPins(turnContext(ctx, input), a.pins)
+	retrievals := &chromemdb.RetrievalLog{}
+	ctx = chromemdb.WithRetrievalLog(ctx, retrievals)
 
 	a.ui.DisplayBotPrompt()
 
 	// The UI itself is the callback handler, cleanly connecting agent events to the UI.
-	cbHandler := a.ui.Build()
+	phaseEvents := newTurnPhases()
+	handlers := []callbacks.Handler{a.ui.Build(), phaseEvents.handler, telemetry.NewHandler(), logger.NewCallbackHandler()}
+
+	turn := a.events.StartTurn(events.Event{RequestID: requestID, Prompt: userInput})
+	handlers = append(handlers, turn.Handler(), a.completer.Handler())
+	if a.transcript != nil {
+		handlers = append(handlers, a.transcript.handler())
+	}
+	defer func() {
+		var answer string
+		if err == nil && len(a.conversation) > 0 {
+			answer = a.conversation[len(a.conversation)-1].Content
+		}
+		a.completer.Observe(answer)
+		turn.End(ctx, answer, err)
+	}()
+
+	if a.recorder != nil {
+		ctx = a.recorder.StartTurn(ctx, "agent.turn", "", userInput)
+		handlers = append(handlers, a.recorder.Handler())
+		defer func() {
+			var output any
+			if err == nil && len(a.conversation) > 0 {
+				output = a.conversation[len(a.conversation)-1].Content
+			}
+			a.recorder.EndTurn(ctx, output, err)
+		}()
+	}
 
-	streamReader, err := a.graph.Stream(ctx, input, compose.WithCallbacks(cbHandler))
+	// A long autonomous turn says it is still working whenever it has been
+	// quiet for a heartbeat, and is stopped if it stalls.
+	runCtx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+	turn.OnProgress(func(p events.Progress) {
+		if p.Idle >= a.heartbeat.Interval.Seconds() {
+			a.ui.DisplayNotice("⏳ Still working: " + p.String())
+		}
+	})
+	turn.Watch(runCtx, events.Heartbeat{Interval: a.heartbeat.Interval, Stall: a.heartbeat.Stall, Abort: abort})
+	defer func() {
+		if cause := context.Cause(runCtx); err != nil && errors.Is(cause, events.ErrStalled) && !errors.Is(err, cause) {
+			err = fmt.Errorf("%w: %w", cause, err)
+		}
+	}()
+
+	streamReader, err := a.graph.Stream(runCtx, input, compose.WithCallbacks(handlers...))
 	if err != nil {
 		return fmt.Errorf("graph execution failed: %w", err)
 	}
 	defer streamReader.Close()
 
 	// Process the streaming response, updating the UI and conversation history concurrently.
-	return a.processStream(streamReader, userInput)
+	if err := a.processStream(runCtx, streamReader, userInput, phaseEvents); err != nil {
+		return err
+	}
+	a.retrievals = retrievals
+	return nil
 }
 
 // processStream handles the reading of the response stream.
 // It collects chunks for history while updating the UI in real-time.
-func (a *Agent) processStream(streamReader interface {
+// The answer is paced as configured by a.streaming, so fast models print in
+// steady pieces rather than a flicker of tokens. The reasoning is set apart
+// on lines of its own from the reasoning_started event in phaseEvents to the
+// answer_started event, as the model call reports them.
+func (a *Agent) processStream(ctx context.Context, streamReader interface {
 	Recv() (*schema.Message, error)
-}, userInput string) error {
+}, userInput string, phaseEvents *turnPhases) error {
 	var chunks []*schema.Message
-	var thinkingMode bool
+	// The reasoning arrives in ReasoningContent; the splitter also takes it
+	// out of <think> tags in the content, for models that have no field of
+	// their own for it.
+	var split phases.Splitter
+	var phase phases.Phase    // The phase shown: none yet, reasoning, or answer.
+	var shown strings.Builder // The answer as displayed, without the thinking.
+	answer := pacing.New(ctx, a.streaming, func(text string) error {
+		a.ui.DisplayStreamChunk(text)
+		return nil
+	})
+	enter := func(next phases.Phase) {
+		phaseEvents.await(ctx, next)
+		if next == phases.ReasoningStarted || phase == phases.ReasoningStarted {
+			a.ui.DisplayThinking("\n")
+		}
+		phase = next
+	}
+	// show shows the reasoning and the answer text of a chunk.
+	show := func(reasoning, text string) {
+		if reasoning != "" {
+			answer.Flush()
+			if phase == "" && strings.TrimSpace(reasoning) != "" {
+				enter(phases.ReasoningStarted)
+			}
+			a.ui.DisplayThinking(reasoning)
+		}
+		if text != "" {
+			if phase != phases.AnswerStarted && strings.TrimSpace(text) != "" {
+				enter(phases.AnswerStarted)
+			}
+			answer.Write(text)
+			a.transcript.answer(text)
+			shown.WriteString(text)
+		}
+	}
 
 	for {
 		chunk, err := streamReader.Recv()
 		if err != nil {
 			if err == io.EOF {
+				show(split.Flush())
+				answer.Flush()
 				break // End of stream
 			}
+			answer.Flush()
+			a.keepPartialAnswer(userInput, chunks)
 			return fmt.Errorf("stream receive error: %w", err)
 		}
 
-		content := chunk.Content
-		if strings.Contains(content, "<think>") {
-			thinkingMode = true
-			content = strings.ReplaceAll(content, "<think>", "\n")
-		}
-		if strings.Contains(content, "</think>") {
-			thinkingMode = false
-			content = strings.ReplaceAll(content, "</think>", "\n")
-		}
-
-		if content != "" {
-			if thinkingMode {
-				a.ui.DisplayThinking(content)
-			} else {
-				a.ui.DisplayStreamChunk(content)
-			}
-		}
+		show(split.Split(chunk))
 
 		chunks = append(chunks, chunk)
 	}
@@ -128,12 +621,63 @@ func (a *Agent) processStream(streamReader interface {
 	if len(chunks) > 0 {
 		fullResponse, _ = schema.ConcatMessages(chunks)
 	}
+	if fullResponse != nil && len(a.postprocess) > 0 {
+		a.postprocessAnswer(ctx, userInput, fullResponse, shown.String())
+	}
 	a.updateConversationHistory(userInput, fullResponse)
 
 	fmt.Println()
 	return nil
 }
 
+// postprocessAnswer runs the post-processors on the finished answer msg
+// before it is kept, and shows what they changed of shown, the answer as
+// streamed: only the addition when they appended to it, or else the whole
+// processed answer. When a post-processor fails the answer is kept as the
+// ones before it left it.
+func (a *Agent) postprocessAnswer(ctx context.Context, userInput string, msg *schema.Message, shown string) {
+	processed := &postprocess.Answer{Question: userInput, Text: msg.Content}
+	if log := chromemdb.RetrievalLogFrom(ctx); log != nil {
+		for _, r := range log.Retrievals() {
+			processed.Sources = append(processed.Sources, r.Sources...)
+		}
+	}
+	if err := a.postprocess.Process(ctx, processed); err != nil {
+		a.ui.DisplayError(err)
+	}
+	msg.Content = processed.Text
+
+	shown = strings.TrimSpace(shown)
+	switch {
+	case processed.Text == shown:
+	case strings.HasPrefix(processed.Text, shown):
+		added := processed.Text[len(shown):]
+		a.ui.DisplayStreamChunk(added)
+		a.transcript.answer(added)
+	default:
+		a.ui.DisplayNotice("\n\nPost-processed answer:")
+		a.ui.DisplayStreamChunk(processed.Text)
+		a.transcript.line("Post-processed answer: %s", processed.Text)
+	}
+}
+
+// keepPartialAnswer adds the exchange to the conversation when the stream
+// failed after part of the answer arrived, marking the answer as cut off so
+// that /retry can ask the model to continue it.
+func (a *Agent) keepPartialAnswer(userInput string, chunks []*schema.Message) {
+	if len(chunks) == 0 {
+		return
+	}
+	partial, err := schema.ConcatMessages(chunks)
+	if err != nil || strings.TrimSpace(partial.Content) == "" {
+		return
+	}
+	partial.Content += interruptedMarker
+	partial.ToolCalls = nil
+	a.updateConversationHistory(userInput, partial)
+	a.retry = continuePrompt
+}
+
 // updateConversationHistory appends the last user message and the full AI response
 // to the conversation log for future context.
 func (a *Agent) updateConversationHistory(userInput string, botResponse *schema.Message) {
@@ -0,0 +1,509 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/example01/step5/ui"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/prompts"
+	"github.com/olusolaa/goforai/foundation/retrieval"
+	"github.com/olusolaa/goforai/foundation/telemetry"
+	"github.com/olusolaa/goforai/foundation/tokens"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// newFakeRunner builds the real agent graph against fake Gemini and Tavily
+// servers. There is no knowledge base in the test directory, so the RAG tool
+// is left out.
+func newFakeRunner(t *testing.T) (*Runner, *fakeapi.Gemini, *fakeapi.Tavily) {
+	t.Helper()
+	fakeGemini := fakeapi.NewGemini(t)
+	fakeGemini.Use(t)
+	fakeTavily := fakeapi.NewTavily(t)
+	fakeTavily.Use(t)
+	t.Setenv("MCP_CONFIG", t.TempDir()+"/mcp.json")
+
+	r, err := NewRunner(context.Background())
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	return r, fakeGemini, fakeTavily
+}
+
+func TestRunnerUsesToolResults(t *testing.T) {
+	r, fakeGemini, fakeTavily := newFakeRunner(t)
+	fakeGemini.ReplyToolCall("search_internet", map[string]any{"query": "eino latest release"})
+	fakeGemini.ReplyText("Eino v0.4.7 is the latest release.")
+	fakeTavily.Reply(fakeapi.TavilyReply{
+		Answer:  "v0.4.7",
+		Results: []fakeapi.TavilyResult{{Title: "Releases", URL: "https://github.com/cloudwego/eino/releases", Content: "v0.4.7"}},
+	})
+
+	msg, err := r.Generate(context.Background(), []*schema.Message{schema.UserMessage("What is the latest Eino release?")})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if msg.Content != "Eino v0.4.7 is the latest release." {
+		t.Errorf("unexpected answer %q", msg.Content)
+	}
+
+	searches := fakeTavily.Requests()
+	if len(searches) != 1 || searches[0].Query != "eino latest release" {
+		t.Fatalf("expected one search for the model's query, got %+v", searches)
+	}
+
+	reqs := fakeGemini.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 model calls, got %d", len(reqs))
+	}
+	if !contains(reqs[0].Tools, "search_internet") {
+		t.Errorf("search_internet was not offered to the model: %v", reqs[0].Tools)
+	}
+	if !hasFunctionResponse(reqs[1], "Releases") {
+		t.Errorf("the second model call did not carry the search result: %+v", reqs[1].Contents)
+	}
+	if fakeGemini.Pending() != 0 {
+		t.Errorf("%d scripted replies were not used", fakeGemini.Pending())
+	}
+}
+
+func TestRunnerStreams(t *testing.T) {
+	r, fakeGemini, _ := newFakeRunner(t)
+	fakeGemini.ReplyText("Goroutines are cheap threads managed by the Go runtime.")
+
+	stream, err := r.Stream(context.Background(), []*schema.Message{
+		schema.UserMessage("Hi"),
+		schema.AssistantMessage("Hello! Ask me about Go.", nil),
+		schema.UserMessage("What is a goroutine?"),
+	})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	defer stream.Close()
+
+	var got strings.Builder
+	chunks := 0
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		got.WriteString(chunk.Content)
+		chunks++
+	}
+	if got.String() != "Goroutines are cheap threads managed by the Go runtime." {
+		t.Errorf("unexpected answer %q", got.String())
+	}
+	if chunks < 2 {
+		t.Errorf("expected the answer in several chunks, got %d", chunks)
+	}
+
+	reqs := fakeGemini.Requests()
+	if len(reqs) != 1 || !reqs[0].Stream {
+		t.Fatalf("expected one streaming model call, got %+v", reqs)
+	}
+	if n := len(reqs[0].Contents); n != 3 {
+		t.Errorf("expected the history and the question (3 messages), got %d", n)
+	}
+}
+
+func TestRunnerKeepsPastedCode(t *testing.T) {
+	r, fakeGemini, _ := newFakeRunner(t)
+	fakeGemini.ReplyText("Add the missing return.")
+
+	question := "Why does this fail? {date}\n\nfunc f() map[string]int {\n\treturn map[string]int{}\n"
+	if _, err := r.Generate(context.Background(), []*schema.Message{schema.UserMessage(question)}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	reqs := fakeGemini.Requests()
+	if len(reqs) != 1 || len(reqs[0].Contents) != 1 {
+		t.Fatalf("expected one model call with the question, got %+v", reqs)
+	}
+	if got := reqs[0].Contents[0].Parts[0].Text; got != question {
+		t.Errorf("the model was sent %q, want the question as pasted", got)
+	}
+}
+
+func TestRunnerReadOnly(t *testing.T) {
+	fakeGemini := fakeapi.NewGemini(t)
+	fakeGemini.Use(t)
+	fakeapi.NewTavily(t).Use(t)
+	t.Setenv("MCP_CONFIG", t.TempDir()+"/mcp.json")
+	fakeGemini.ReplyText("I can only read files here.")
+
+	r, err := NewRunner(tools.WithReadOnly(context.Background()))
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	if _, err := r.Generate(context.Background(), []*schema.Message{schema.UserMessage("Fix the bug")}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	reqs := fakeGemini.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 model call, got %d", len(reqs))
+	}
+	if contains(reqs[0].Tools, "edit_go_file") || contains(reqs[0].Tools, "apply_changeset") || contains(reqs[0].Tools, "rename_symbol") || contains(reqs[0].Tools, "scaffold_project") || contains(reqs[0].Tools, "fix_dependencies") || !contains(reqs[0].Tools, "read_file") {
+		t.Errorf("read-only agent was offered %v", reqs[0].Tools)
+	}
+
+	library, err := prompts.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chatTemplate, err := createChatTemplate(library, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := chatTemplate.Format(context.Background(), map[string]any{"content": "hi", "date": "2025-10-06"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msgs[0].Content, "Read-Only Mode") {
+		t.Errorf("system prompt does not mention read-only mode:\n%s", msgs[0].Content)
+	}
+}
+
+func TestRunnerOffline(t *testing.T) {
+	var requests []map[string]any
+	fakeOllama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		requests = append(requests, body)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Use a buffered channel."},"done":true}` + "\n"))
+	}))
+	defer fakeOllama.Close()
+	t.Setenv("OLLAMA_BASE_URL", fakeOllama.URL)
+	t.Setenv("OFFLINE_CHAT_MODEL", "qwen2.5-coder:7b")
+	t.Setenv("GEMINI_BASE_URL", "http://127.0.0.1:1") // Any call to Gemini fails.
+	t.Setenv("GEMINI_API_KEY", "fake-gemini-key")
+	t.Setenv("MCP_CONFIG", t.TempDir()+"/mcp.json")
+
+	r, err := NewRunner(tools.WithOffline(context.Background()))
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	msg, err := r.Generate(context.Background(), []*schema.Message{schema.UserMessage("How do I bound concurrency?")})
+	if err != nil || msg.Content != "Use a buffered channel." {
+		t.Fatalf("Generate = %v, %v", msg, err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 call to Ollama, got %d", len(requests))
+	}
+	var offered []string
+	for _, tool := range requests[0]["tools"].([]any) {
+		offered = append(offered, tool.(map[string]any)["function"].(map[string]any)["name"].(string))
+	}
+	if contains(offered, "search_internet") || contains(offered, "gitclone") || !contains(offered, "read_file") {
+		t.Errorf("offline agent was offered %v", offered)
+	}
+	system := requests[0]["messages"].([]any)[0].(map[string]any)["content"].(string)
+	if !strings.Contains(system, "Offline Mode") {
+		t.Errorf("system prompt does not mention offline mode:\n%s", system)
+	}
+}
+
+func TestRunnerReportsRateLimits(t *testing.T) {
+	r, fakeGemini, _ := newFakeRunner(t)
+	fakeGemini.ReplyError(http.StatusTooManyRequests, "Resource has been exhausted (e.g. check quota).")
+
+	_, err := r.Generate(context.Background(), []*schema.Message{schema.UserMessage("Hello")})
+	if !errors.Is(err, gemini.ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestRunnerExportsStreamedTurns(t *testing.T) {
+	traces := make(chan telemetry.Trace, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var trace telemetry.Trace
+		if err := json.NewDecoder(req.Body).Decode(&trace); err != nil {
+			t.Errorf("invalid trace: %v", err)
+		}
+		traces <- trace
+	}))
+	defer webhook.Close()
+	t.Setenv("LANGFUSE_PUBLIC_KEY", "")
+	t.Setenv("TRACE_WEBHOOK_URL", webhook.URL)
+
+	r, fakeGemini, _ := newFakeRunner(t)
+	fakeGemini.ReplyText("Channels connect goroutines.")
+
+	stream, err := r.Stream(context.Background(), []*schema.Message{schema.UserMessage("What is a channel?")})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Recv: %v", err)
+			}
+			break
+		}
+	}
+	stream.Close()
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	select {
+	case trace := <-traces:
+		if trace.Name != "server.turn" || trace.Input != "What is a channel?" || trace.Output != "Channels connect goroutines." {
+			t.Errorf("unexpected trace %+v", trace)
+		}
+		if len(trace.Observations) == 0 {
+			t.Error("trace has no observations")
+		}
+	default:
+		t.Fatal("no trace was exported")
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFunctionResponse(req fakeapi.GeminiRequest, substr string) bool {
+	for _, c := range req.Contents {
+		for _, p := range c.Parts {
+			if len(p.FunctionResponse) > 0 && strings.Contains(string(p.FunctionResponse), substr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestDescribeGraph(t *testing.T) {
+	fakeapi.NewGemini(t).Use(t)
+	fakeapi.NewTavily(t).Use(t)
+	t.Setenv("MCP_CONFIG", t.TempDir()+"/mcp.json")
+
+	g, err := DescribeGraph(context.Background())
+	if err != nil {
+		t.Fatalf("DescribeGraph: %v", err)
+	}
+	var keys []string
+	for _, n := range g.Nodes {
+		keys = append(keys, n.Key)
+	}
+	if g.Name != graphName || strings.Join(keys, ",") != "InputToHistory,ChatTemplate,ReactAgent" {
+		t.Fatalf("graph %s has nodes %v", g.Name, keys)
+	}
+	// The ReAct loop is nested under its lambda: the model branches to the
+	// tools, which feed back into the model.
+	loop := g.Nodes[2].Subgraph
+	if loop == nil {
+		t.Fatal("the ReAct agent's graph was not described")
+	}
+	mermaid := g.Mermaid()
+	for _, want := range []string{"ChatModel", "Tools", "-. branch .->"} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid is missing %q:\n%s", want, mermaid)
+		}
+	}
+}
+
+// brokenStream yields its chunks and then fails.
+type brokenStream struct {
+	chunks []*schema.Message
+	err    error
+}
+
+func (s *brokenStream) Recv() (*schema.Message, error) {
+	if len(s.chunks) == 0 {
+		return nil, s.err
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func TestPartialAnswerIsKept(t *testing.T) {
+	a := &Agent{ui: ui.New()}
+	stream := &brokenStream{
+		chunks: []*schema.Message{schema.AssistantMessage("Goroutines are ", nil), schema.AssistantMessage("cheap", nil)},
+		err:    errors.New("connection reset"),
+	}
+	if err := a.processStream(context.Background(), stream, "Why use goroutines?", nil); err == nil {
+		t.Fatal("the stream error was not reported")
+	}
+	if len(a.conversation) != 2 || a.conversation[0].Content != "Why use goroutines?" ||
+		a.conversation[1].Content != "Goroutines are cheap"+interruptedMarker {
+		t.Fatalf("conversation = %v", a.conversation)
+	}
+	if a.retry != continuePrompt {
+		t.Errorf("retry = %q, want the continue prompt", a.retry)
+	}
+
+	// A stream that fails before any text leaves the conversation alone.
+	a = &Agent{ui: ui.New()}
+	if err := a.processStream(context.Background(), &brokenStream{err: errors.New("connection reset")}, "Why?", nil); err == nil {
+		t.Fatal("the stream error was not reported")
+	}
+	if len(a.conversation) != 0 || a.retry != "" {
+		t.Errorf("conversation = %v, retry = %q", a.conversation, a.retry)
+	}
+}
+
+func TestReasoningIsSetApartByPhaseEvents(t *testing.T) {
+	chunks := []*schema.Message{
+		{Role: schema.Assistant, ReasoningContent: "Checking."},
+		{Role: schema.Assistant, Content: "Yes."},
+	}
+	phaseEvents := newTurnPhases()
+	ctx := context.Background()
+	info := &callbacks.RunInfo{Component: components.ComponentOfChatModel}
+	phaseEvents.handler.OnStart(ctx, info, &model.CallbackInput{})
+
+	a := &Agent{ui: ui.New()}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan error)
+	go func() { done <- a.processStream(ctx, &brokenStream{chunks: chunks, err: io.EOF}, "Sure?", phaseEvents) }()
+
+	// The reasoning waits for the model call's events.
+	select {
+	case err := <-done:
+		t.Fatalf("processStream ended before the events: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	var outputs []callbacks.CallbackOutput
+	for _, chunk := range chunks {
+		outputs = append(outputs, &model.CallbackOutput{Message: chunk})
+	}
+	phaseEvents.handler.OnEndWithStreamOutput(ctx, info, schema.StreamReaderFromArray(outputs))
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	printed, _ := io.ReadAll(r)
+	if got := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(string(printed), ""); got != "\nChecking.\nYes.\n" {
+		t.Errorf("printed %q", got)
+	}
+}
+
+// newFakeAgent is the interactive counterpart of newFakeRunner.
+func newFakeAgent(t *testing.T, opts ...Option) (*Agent, *fakeapi.Gemini, *fakeapi.Tavily) {
+	t.Helper()
+	fakeGemini := fakeapi.NewGemini(t)
+	fakeGemini.Use(t)
+	fakeTavily := fakeapi.NewTavily(t)
+	fakeTavily.Use(t)
+	t.Setenv("MCP_CONFIG", t.TempDir()+"/mcp.json")
+
+	a, err := New(context.Background(), ui.New(), opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { a.Close(context.Background()) })
+	return a, fakeGemini, fakeTavily
+}
+
+func TestTranscript(t *testing.T) {
+	var out strings.Builder
+	a, fakeGemini, fakeTavily := newFakeAgent(t, WithTranscript(&out))
+	fakeGemini.ReplyToolCall("search_internet", map[string]any{"query": "eino latest release"})
+	fakeGemini.ReplyText("Eino v0.4.7 is the latest release.")
+	fakeTavily.Reply(fakeapi.TavilyReply{Answer: "v0.4.7"})
+	a.transcript.now = func() time.Time { return time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC) }
+
+	if err := a.executeTurn(context.Background(), "What is the latest Eino release?"); err != nil {
+		t.Fatalf("executeTurn: %v", err)
+	}
+	want := "\n[10:00:00] User: What is the latest Eino release?\n" +
+		"[10:00:00] tool search_internet (0s) ok\n" +
+		"[10:00:00] Assistant: Eino v0.4.7 is the latest release.\n"
+	if out.String() != want {
+		t.Errorf("transcript = %q, want %q", out.String(), want)
+	}
+}
+
+func TestSwapModelAndTools(t *testing.T) {
+	a, fakeGemini, _ := newFakeAgent(t)
+	fakeGemini.ReplyText("Eino is a Go framework for LLM apps.")
+	fakeGemini.ReplyText("It is maintained by CloudWeGo.")
+	ctx := context.Background()
+
+	if err := a.executeTurn(ctx, "What is Eino?"); err != nil {
+		t.Fatalf("first turn: %v", err)
+	}
+	a.runCommand(ctx, "/model gemini-2.5-pro")
+	a.runCommand(ctx, "/tools disable search_internet")
+	a.runCommand(ctx, "/tools disable no_such_tool")
+	if err := a.executeTurn(ctx, "Who maintains it?"); err != nil {
+		t.Fatalf("second turn: %v", err)
+	}
+
+	reqs := fakeGemini.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 model calls, got %d", len(reqs))
+	}
+	if !strings.HasSuffix(reqs[0].Model, gemini.ChatModel()) || !strings.HasSuffix(reqs[1].Model, "gemini-2.5-pro") {
+		t.Errorf("models = %q, %q; want the configured one, then gemini-2.5-pro", reqs[0].Model, reqs[1].Model)
+	}
+	if !contains(reqs[0].Tools, "search_internet") || contains(reqs[1].Tools, "search_internet") {
+		t.Errorf("search_internet offered in %v, then %v; want it dropped", reqs[0].Tools, reqs[1].Tools)
+	}
+	if len(reqs[1].Contents) != 3 {
+		t.Errorf("the second call carried %d messages, want the first exchange and the question", len(reqs[1].Contents))
+	}
+	if a.Model() != "gemini-2.5-pro" || len(a.conversation) != 4 {
+		t.Errorf("model = %s with %d messages, want gemini-2.5-pro with 4", a.Model(), len(a.conversation))
+	}
+}
+
+func TestAskSizesRetrievalToThePrompt(t *testing.T) {
+	var promptTokens int
+	graph, err := compose.NewChain[*UserMessage, *schema.Message]().
+		AppendLambda(compose.InvokableLambda(func(ctx context.Context, in *UserMessage) (*schema.Message, error) {
+			promptTokens = retrieval.PromptTokens(ctx)
+			return schema.AssistantMessage("Yes.", nil), nil
+		})).
+		Compile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Agent{graph: graph, pins: tools.NewPins(), conversation: []*schema.Message{
+		schema.UserMessage("What is Eino?"),
+		schema.AssistantMessage("A Go framework for LLM apps.", nil),
+	}}
+
+	question := "Does it support streaming?"
+	if _, err := a.Ask(context.Background(), question); err != nil {
+		t.Fatal(err)
+	}
+	if want := tokens.EstimateMessages(a.conversation[:2]) + tokens.Estimate(question); promptTokens != want {
+		t.Errorf("retrieval sized for %d prompt tokens, want %d", promptTokens, want)
+	}
+}
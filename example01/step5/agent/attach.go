@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chunking"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// Attachment limits. Files above attachmentPartSize are split into several
+// messages so that no single message dominates the context.
+const (
+	maxAttachmentBytes = 256 << 10
+	attachmentPartSize = 16 << 10
+)
+
+// attachment is a file the user attached to the conversation with /attach.
+type attachment struct {
+	path     string
+	size     int
+	messages []*schema.Message
+}
+
+// loadAttachment reads the file at path, resolved like the file tools
+// resolve paths, and turns it into context messages. Each message is wrapped
+// in an <attachment> marker naming the file and part, so the model can cite
+// where the text came from.
+func loadAttachment(ctx context.Context, path string) (*attachment, error) {
+	resolved, err := tools.ResolvePath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("cannot attach '%s': %w", path, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("cannot attach '%s': it is a directory", path)
+	}
+	if info.Size() > maxAttachmentBytes {
+		return nil, fmt.Errorf("cannot attach '%s': %d bytes is over the %d byte limit; ask the agent to read the parts you need instead", path, info.Size(), maxAttachmentBytes)
+	}
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("cannot attach '%s': %w", path, err)
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		return nil, fmt.Errorf("cannot attach '%s': it is not a text file", path)
+	}
+
+	parts := []string{string(data)}
+	if len(data) > attachmentPartSize {
+		splitter, err := chunking.New(config.Chunking{Size: attachmentPartSize, Overlap: 0, Strategy: config.StrategyParagraph})
+		if err != nil {
+			return nil, err
+		}
+		parts = splitter.Split(string(data))
+	}
+
+	att := &attachment{path: path, size: len(data)}
+	for i, part := range parts {
+		att.messages = append(att.messages, schema.UserMessage(fmt.Sprintf(
+			"<attachment path=%q part=\"%d/%d\">\n%s\n</attachment>", path, i+1, len(parts), part)))
+	}
+	return att, nil
+}
+
+// attach adds the file at path to the context of every following turn,
+// replacing an earlier attachment of the same path.
+func (a *Agent) attach(ctx context.Context, path string) (*attachment, error) {
+	att, err := loadAttachment(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	a.detach(path)
+	a.attachments = append(a.attachments, att)
+	return att, nil
+}
+
+// detach removes the attachment of path, or every attachment when path is
+// empty, and returns how many were removed.
+func (a *Agent) detach(path string) int {
+	kept := a.attachments[:0]
+	for _, att := range a.attachments {
+		if path != "" && att.path != path {
+			kept = append(kept, att)
+		}
+	}
+	removed := len(a.attachments) - len(kept)
+	a.attachments = kept
+	return removed
+}
+
+// history is the conversation as the model sees it: pinned facts first,
+// then attachments, then the exchanged messages. Only the exchanged messages
+// are compacted or dropped to fit the context window.
+func (a *Agent) history() []*schema.Message {
+	pinned := a.pins.Prompt()
+	if len(a.attachments) == 0 && pinned == "" {
+		return a.conversation
+	}
+	var history []*schema.Message
+	if pinned != "" {
+		history = append(history, schema.UserMessage(pinned))
+	}
+	for _, att := range a.attachments {
+		history = append(history, att.messages...)
+	}
+	return append(history, a.conversation...)
+}
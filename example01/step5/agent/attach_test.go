@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+func TestAttach(t *testing.T) {
+	dir := t.TempDir()
+	ctx := tools.WithWorkspace(context.Background(), dir)
+	paragraph := strings.Repeat("word ", 1000) + "\n\n"
+	files := map[string]string{
+		"main.go":  "package main\n",
+		"big.md":   strings.Repeat(paragraph, 10),
+		"huge.txt": strings.Repeat("x", maxAttachmentBytes+1),
+		"bin.dat":  "ELF\x00\x01",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := &Agent{conversation: []*schema.Message{schema.UserMessage("hi")}}
+	att, err := a.attach(ctx, "main.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(att.messages) != 1 || att.messages[0].Content != "<attachment path=\"main.go\" part=\"1/1\">\npackage main\n\n</attachment>" {
+		t.Errorf("unexpected attachment %q", att.messages)
+	}
+
+	big, err := a.attach(ctx, "big.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(big.messages); n < 3 {
+		t.Errorf("big.md was split into %d parts, want at least 3", n)
+	}
+	for _, m := range big.messages {
+		if len(m.Content) > attachmentPartSize+100 || !strings.Contains(m.Content, `path="big.md" part=`) {
+			t.Errorf("part without provenance or over the size limit (%d bytes)", len(m.Content))
+		}
+	}
+	if h := a.history(); len(h) != 1+len(big.messages)+1 || h[len(h)-1].Content != "hi" {
+		t.Errorf("attachments are not sent before the conversation: %d messages", len(h))
+	}
+
+	for _, path := range []string{"huge.txt", "bin.dat", "missing.go", "."} {
+		if _, err := a.attach(ctx, path); err == nil {
+			t.Errorf("attached %s", path)
+		}
+	}
+	if _, err := a.attach(ctx, "/etc/passwd"); !errors.Is(err, tools.ErrWorkspaceViolation) {
+		t.Errorf("attach outside the workspace = %v, want ErrWorkspaceViolation", err)
+	}
+
+	if n := a.detach("main.go"); n != 1 || len(a.attachments) != 1 {
+		t.Errorf("detach(main.go) removed %d, left %d", n, len(a.attachments))
+	}
+	if n := a.detach(""); n != 1 || len(a.history()) != 1 {
+		t.Errorf("detach() removed %d, history has %d messages", n, len(a.history()))
+	}
+}
+
+func TestPinnedFactsComeFirst(t *testing.T) {
+	a := &Agent{pins: tools.NewPins(), conversation: []*schema.Message{
+		schema.UserMessage("first"), schema.AssistantMessage("one", nil),
+		schema.UserMessage("second"), schema.AssistantMessage("two", nil),
+	}}
+	a.attachments = []*attachment{{path: "notes.md", messages: []*schema.Message{schema.UserMessage("<attachment>")}}}
+	if _, err := a.pins.Add("Never modify files under /legacy."); err != nil {
+		t.Fatal(err)
+	}
+
+	a.dropOldestTurns()
+	h := a.history()
+	if len(h) != 4 || !strings.Contains(h[0].Content, "1. Never modify files under /legacy.") || h[1].Content != "<attachment>" || h[2].Content != "second" {
+		t.Fatalf("history = %v, want the pinned facts, the attachment, then the last turn", h)
+	}
+
+	a.pins.Remove(1)
+	if h := a.history(); len(h) != 3 {
+		t.Errorf("history after unpinning has %d messages, want 3", len(h))
+	}
+}
@@ -0,0 +1,233 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// commandHelp lists the slash commands of the interactive loop.
+const commandHelp = `/attach <path>   add a file to the context of the following turns
+/detach [path]   remove one attachment, or all of them
+/pin [fact]      pin a fact to every following turn, or list the pinned facts
+/unpin <n>|all   remove a pinned fact, or all of them
+/compact [turns] summarize the conversation, keeping the last turns (default 2) verbatim
+/voice on|off    read answers aloud (TTS_PROVIDER picks the engine)
+/talk [on|off]   record a question from the microphone; "on" makes an empty line start recording
+/good, /bad      rate the last answer; the knowledge base ranks what it was given higher or lower for similar questions
+/retry           continue an answer cut off by an error, or send a failed question again
+/commit [hint]   write a commit message for the uncommitted changes and commit them once approved
+/recall <query>  search the conversations of earlier sessions and add the matches to this one
+/model [name]    show the chat model, or switch to another one keeping the conversation
+/tools [enable|disable <name>]  list the tools, or turn one on or off
+/verbose on|off  show what each tool returned`
+
+// commandNames returns the slash commands listed in commandHelp.
+func commandNames() []string {
+	var names []string
+	for _, line := range strings.Split(commandHelp, "\n") {
+		for _, field := range strings.Fields(line) {
+			if !strings.HasPrefix(field, "/") {
+				break
+			}
+			names = append(names, strings.TrimSuffix(field, ","))
+		}
+	}
+	return names
+}
+
+// runCommand handles a slash command typed in the interactive loop. It reports
+// false when input is not a command and should be sent to the model.
+func (a *Agent) runCommand(ctx context.Context, input string) bool {
+	if !strings.HasPrefix(input, "/") {
+		return false
+	}
+	name, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/attach":
+		if arg == "" {
+			a.ui.DisplayNotice("Usage: /attach <path>")
+			return true
+		}
+		att, err := a.attach(ctx, arg)
+		if err != nil {
+			a.ui.DisplayError(err)
+			return true
+		}
+		a.ui.DisplayNotice(fmt.Sprintf("Attached %s (%d bytes, %d part(s)); it stays in context until /detach.", att.path, att.size, len(att.messages)))
+	case "/detach":
+		switch n := a.detach(arg); {
+		case n == 0 && arg != "":
+			a.ui.DisplayNotice(fmt.Sprintf("%s is not attached.", arg))
+		default:
+			a.ui.DisplayNotice(fmt.Sprintf("Detached %d file(s).", n))
+		}
+	case "/pin":
+		a.pin(arg)
+	case "/unpin":
+		a.unpin(arg)
+	case "/compact":
+		keep := defaultCompactKeep
+		if arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 {
+				a.ui.DisplayNotice("Usage: /compact [turns to keep]")
+				return true
+			}
+			keep = n
+		}
+		a.compact(ctx, keep)
+	case "/good":
+		a.rate(1)
+	case "/bad":
+		a.rate(-1)
+	case "/commit":
+		a.commit(ctx, arg)
+	case "/recall":
+		if arg == "" {
+			a.ui.DisplayNotice("Usage: /recall <query>")
+			return true
+		}
+		a.recallPast(ctx, arg)
+	case "/voice":
+		a.setVoice(ctx, arg)
+	case "/model":
+		a.setModel(ctx, arg)
+	case "/tools":
+		a.setTools(ctx, arg)
+	case "/verbose":
+		switch arg {
+		case "on":
+			a.ui.SetVerbose(true)
+			a.ui.DisplayNotice("Tool results will be shown below each tool; /verbose off hides them.")
+		case "off":
+			a.ui.SetVerbose(false)
+			a.ui.DisplayNotice("Tool results will no longer be shown.")
+		default:
+			a.ui.DisplayNotice("Usage: /verbose on|off")
+		}
+	default:
+		a.ui.DisplayNotice("Unknown command " + name + ". Commands:\n" + commandHelp)
+	}
+	return true
+}
+
+// commit handles /commit: suggest_commit_message writes a message for the
+// uncommitted changes, with hint as the author's note, and commits them once
+// the user approves. In read-only mode the message is only shown.
+func (a *Agent) commit(ctx context.Context, hint string) {
+	var committer tool.InvokableTool
+	for _, t := range a.toolbox {
+		if it, ok := t.(tool.InvokableTool); ok && toolName(ctx, t) == "suggest_commit_message" {
+			committer = it
+		}
+	}
+	if committer == nil {
+		a.ui.DisplayNotice("Commit messages are written by the chat model, which could not be set up for tools.")
+		return
+	}
+	readOnly := tools.ReadOnly(ctx)
+	args, err := json.Marshal(tools.CommitMessageRequest{Hint: hint, Commit: !readOnly})
+	if err != nil {
+		a.ui.DisplayError(err)
+		return
+	}
+	a.ui.DisplayNotice("Writing a commit message for the uncommitted changes...")
+	out, err := committer.InvokableRun(tools.WithApprover(ctx, a.ui), string(args))
+	if errors.Is(err, tools.ErrNotApproved) {
+		a.ui.DisplayNotice("Nothing was committed.")
+		return
+	}
+	if err != nil {
+		a.ui.DisplayError(err)
+		return
+	}
+	var resp tools.CommitMessageResponse
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		a.ui.DisplayError(err)
+		return
+	}
+	if resp.Error != "" {
+		a.ui.DisplayNotice(resp.Error)
+		return
+	}
+	var b strings.Builder
+	switch {
+	case resp.Commit != "":
+		fmt.Fprintf(&b, "Committed %s (%s):\n\n%s", resp.Commit[:7], resp.Summary, resp.Message)
+	case readOnly:
+		fmt.Fprintf(&b, "Read-only mode: commit %s yourself with this message:\n\n%s", resp.Summary, resp.Message)
+	}
+	if resp.Changelog != "" {
+		fmt.Fprintf(&b, "\n\nChangelog: %s", resp.Changelog)
+	}
+	a.ui.DisplayNotice(b.String())
+}
+
+// pin pins fact, or lists the pinned facts when it is empty.
+func (a *Agent) pin(fact string) {
+	if fact == "" {
+		facts := a.pins.Facts()
+		if len(facts) == 0 {
+			a.ui.DisplayNotice("No facts are pinned. /pin <fact> keeps one in context for the rest of the session.")
+			return
+		}
+		var b strings.Builder
+		b.WriteString("Pinned facts:")
+		for i, f := range facts {
+			fmt.Fprintf(&b, "\n  %d. %s", i+1, f)
+		}
+		a.ui.DisplayNotice(b.String())
+		return
+	}
+	n, err := a.pins.Add(fact)
+	if err != nil {
+		a.ui.DisplayError(err)
+		return
+	}
+	a.ui.DisplayNotice(fmt.Sprintf("Pinned as fact %d; it stays in context, through /compact, until /unpin %d.", n, n))
+}
+
+// unpin removes the pinned fact numbered arg, or all of them.
+func (a *Agent) unpin(arg string) {
+	if arg == "all" {
+		a.ui.DisplayNotice(fmt.Sprintf("Unpinned %d fact(s).", a.pins.Clear()))
+		return
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		a.ui.DisplayNotice("Usage: /unpin <n>|all; /pin lists the pinned facts.")
+		return
+	}
+	if !a.pins.Remove(n) {
+		a.ui.DisplayNotice(fmt.Sprintf("There is no pinned fact %d; /pin lists them.", n))
+		return
+	}
+	a.ui.DisplayNotice(fmt.Sprintf("Unpinned fact %d.", n))
+}
+
+// compact summarizes the conversation, keeping the last keep turns, and
+// reports the estimated savings.
+func (a *Agent) compact(ctx context.Context, keep int) {
+	a.ui.DisplayNotice("Summarizing the conversation...")
+	compacted, c, err := compactMessages(ctx, a.summarizer, a.tokens, a.conversation, keep)
+	if err != nil {
+		a.ui.DisplayError(err)
+		return
+	}
+	if c.summarized == 0 {
+		a.ui.DisplayNotice(fmt.Sprintf("Nothing to compact: the conversation has no more than %d turns.", keep))
+		return
+	}
+	a.conversation = compacted
+	a.ui.DisplayNotice(fmt.Sprintf("Compacted %d messages into a summary: ~%d → ~%d tokens of history (~%d saved per turn).",
+		c.summarized, c.tokensBefore, c.tokensAfter, c.tokensBefore-c.tokensAfter))
+}
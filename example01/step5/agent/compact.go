@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/prompts"
+	"github.com/olusolaa/goforai/foundation/tokens"
+)
+
+// defaultCompactKeep is how many recent turns /compact keeps verbatim.
+const defaultCompactKeep = 2
+
+// compaction is the outcome of compacting the conversation.
+type compaction struct {
+	summarized   int // Messages replaced by the summary.
+	tokensBefore int
+	tokensAfter  int
+}
+
+// compactMessages replaces all but the last keep turns of conversation with
+// a summary written by summarizer. The summary is stored as a user/assistant
+// pair so the conversation keeps its turn structure. The savings are counted
+// with counter, or estimated when it is nil.
+func compactMessages(ctx context.Context, summarizer model.BaseChatModel, counter tokens.Counter, conversation []*schema.Message, keep int) ([]*schema.Message, compaction, error) {
+	cut := max(len(conversation)-2*keep, 0)
+	cut -= cut % 2
+	if cut == 0 {
+		return conversation, compaction{}, nil
+	}
+
+	library, err := prompts.FromContext(ctx)
+	if err != nil {
+		return nil, compaction{}, err
+	}
+	compactPrompt, err := library.Get("step5.compact")
+	if err != nil {
+		return nil, compaction{}, err
+	}
+	var transcript strings.Builder
+	for _, m := range conversation[:cut] {
+		fmt.Fprintf(&transcript, "[%s]\n%s\n\n", m.Role, m.Content)
+	}
+	summary, err := summarizer.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(compactPrompt.Text),
+		schema.UserMessage(transcript.String()),
+	})
+	if err != nil {
+		return nil, compaction{}, fmt.Errorf("failed to summarize the conversation: %w", err)
+	}
+	if strings.TrimSpace(summary.Content) == "" {
+		return nil, compaction{}, fmt.Errorf("failed to summarize the conversation: the model returned an empty summary")
+	}
+
+	compacted := []*schema.Message{
+		schema.UserMessage("<conversation-summary>\n" + strings.TrimSpace(summary.Content) + "\n</conversation-summary>\nThis summarizes our earlier conversation."),
+		schema.AssistantMessage("Understood. I will continue from this summary.", nil),
+	}
+	compacted = append(compacted, conversation[cut:]...)
+	return compacted, compaction{
+		summarized:   cut,
+		tokensBefore: tokens.Count(ctx, counter, conversation),
+		tokensAfter:  tokens.Count(ctx, counter, compacted),
+	}, nil
+}
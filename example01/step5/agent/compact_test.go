@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/example01/step5/ui"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/models"
+)
+
+func TestCompactMessages(t *testing.T) {
+	fakeGemini := fakeapi.NewGemini(t)
+	fakeGemini.Use(t)
+	summarizer, err := gemini.NewChatModel(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var conversation []*schema.Message
+	for i := range 5 {
+		conversation = append(conversation,
+			schema.UserMessage(fmt.Sprintf("question %d %s", i, strings.Repeat("context ", 200))),
+			schema.AssistantMessage(fmt.Sprintf("answer %d %s", i, strings.Repeat("detail ", 200)), nil))
+	}
+
+	fakeGemini.ReplyText("- The user asked five questions about widgets.")
+	compacted, c, err := compactMessages(context.Background(), summarizer, nil, conversation, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compacted) != 6 || c.summarized != 6 {
+		t.Fatalf("compacted to %d messages, summarizing %d; want 6 and 6", len(compacted), c.summarized)
+	}
+	if !strings.Contains(compacted[0].Content, "five questions about widgets") || compacted[1].Role != schema.Assistant {
+		t.Errorf("summary pair is %q, %q", compacted[0].Content, compacted[1].Content)
+	}
+	if compacted[2] != conversation[6] || compacted[5] != conversation[9] {
+		t.Error("the last two turns were not kept verbatim")
+	}
+	if c.tokensAfter >= c.tokensBefore {
+		t.Errorf("tokens went from %d to %d", c.tokensBefore, c.tokensAfter)
+	}
+	requests := fakeGemini.Requests()
+	if len(requests) != 1 || !strings.Contains(fmt.Sprint(requests[0]), "question 2") || strings.Contains(fmt.Sprint(requests[0]), "question 3") {
+		t.Errorf("the summarizer did not get exactly the older turns: %+v", requests)
+	}
+
+	if same, c, err := compactMessages(context.Background(), summarizer, nil, conversation[:4], 2); err != nil || c.summarized != 0 || len(same) != 4 {
+		t.Errorf("compacting a short conversation = %d messages, %+v, %v", len(same), c, err)
+	}
+}
+
+func TestFitContext(t *testing.T) {
+	a := &Agent{ui: ui.New()}
+	for i := range 10 {
+		a.conversation = append(a.conversation,
+			schema.UserMessage(fmt.Sprintf("question %d %s", i, strings.Repeat("x", 396))),
+			schema.AssistantMessage(strings.Repeat("y", 400), nil))
+	}
+	// 20 messages of ~70 tokens each.
+	a.fitContext(context.Background(), models.Capabilities{ContextWindow: 2500}, "next")
+	if len(a.conversation) != 20 {
+		t.Errorf("dropped turns from a conversation that fits: %d messages left", len(a.conversation))
+	}
+	a.fitContext(context.Background(), models.Capabilities{ContextWindow: 1200}, "next")
+	if len(a.conversation) != 10 || !strings.HasPrefix(a.conversation[0].Content, "question 5 ") {
+		t.Errorf("want the last 5 turns kept, got %d messages starting with %.12q", len(a.conversation), a.conversation[0].Content)
+	}
+}
@@ -0,0 +1,27 @@
+package agent
+
+import "fmt"
+
+// rate votes value, +1 for /good or -1 for /bad, on the knowledge base
+// documents the last answer was given, so that they rank higher or lower for
+// similar questions. An answer is rated once.
+func (a *Agent) rate(value int) {
+	if a.retrievals == nil {
+		a.ui.DisplayNotice("Nothing to rate: the last answer did not search the knowledge base.")
+		return
+	}
+	n, err := a.retrievals.Vote(value)
+	if err != nil {
+		a.ui.DisplayError(fmt.Errorf("failed to record feedback: %w", err))
+		return
+	}
+	a.retrievals = nil
+	switch {
+	case n == 0:
+		a.ui.DisplayNotice("Nothing to rate: the last answer did not search the knowledge base, or RAG_FEEDBACK_WEIGHT is 0.")
+	case value > 0:
+		a.ui.DisplayNotice(fmt.Sprintf("Thanks! The %d document(s) behind the answer will rank higher for similar questions.", n))
+	default:
+		a.ui.DisplayNotice(fmt.Sprintf("Noted. The %d document(s) behind the answer will rank lower for similar questions.", n))
+	}
+}
@@ -12,95 +12,193 @@ import (
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/flow/agent/react"
 	"github.com/cloudwego/eino/schema"
-	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/prompts"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// Using constants for node names is a best practice for clarity and maintainability.
+const (
+	graphName          = "GopherConAgent"
+	nodeInputToHistory = "InputToHistory"
+	nodeChatTemplate   = "ChatTemplate"
+	nodeReactAgent     = "ReactAgent"
 )
 
 // buildEinoGraph encapsulates the declarative orchestration logic. It defines
 // the flow of data between components using Eino's type-safe graph primitives.
-func buildEinoGraph(ctx context.Context) (compose.Runnable[*UserMessage, *schema.Message], error) {
-	// Using constants for node names is a best practice for clarity and maintainability.
-	const (
-		NodeInputToHistory = "InputToHistory"
-		NodeChatTemplate   = "ChatTemplate"
-		NodeReactAgent     = "ReactAgent"
-	)
+// The returned function releases the tools' external resources.
+func buildEinoGraph(ctx context.Context) (compose.Runnable[*UserMessage, *schema.Message], func() error, error) {
+	g, _, closeTools, err := newEinoGraph(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	// Compile the graph into an executable Runnable. This validates the
+	// graph's structure (e.g., checking for cycles) and optimizes it.
+	graph, err := g.Compile(ctx, compose.WithGraphName(graphName))
+	if err != nil {
+		closeTools()
+		return nil, nil, err
+	}
+	return graph, closeTools, nil
+}
+
+// newEinoGraph defines the agent's graph without compiling it, returning the
+// ReAct agent its last node runs alongside it.
+func newEinoGraph(ctx context.Context) (*compose.Graph[*UserMessage, *schema.Message], *react.Agent, func() error, error) {
+	reactAgent, closeTools, err := createReactAgent(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	g, err := assembleGraph(ctx, reactAgent)
+	if err != nil {
+		closeTools()
+		return nil, nil, nil, err
+	}
+	return g, reactAgent, closeTools, nil
+}
+
+// compileGraph compiles the agent's graph around reactAgent. The agent calls
+// it again with a new ReAct agent when /model or /tools changes it.
+func compileGraph(ctx context.Context, reactAgent *react.Agent) (compose.Runnable[*UserMessage, *schema.Message], error) {
+	g, err := assembleGraph(ctx, reactAgent)
+	if err != nil {
+		return nil, err
+	}
+	return g.Compile(ctx, compose.WithGraphName(graphName))
+}
+
+// assembleGraph defines the agent's graph, whose last node runs reactAgent.
+func assembleGraph(ctx context.Context, reactAgent *react.Agent) (*compose.Graph[*UserMessage, *schema.Message], error) {
 	// The graph is statically typed with its input and output structs.
 	// This prevents entire classes of runtime errors.
 	g := compose.NewGraph[*UserMessage, *schema.Message]()
 
 	// Node 1: A simple lambda to format the input for the prompt template.
-	g.AddLambdaNode(NodeInputToHistory, compose.InvokableLambda(extractVariables))
+	g.AddLambdaNode(nodeInputToHistory, compose.InvokableLambda(extractVariables))
 
 	// Node 2: The prompt template that structures the input for the LLM.
-	chatTemplate := createChatTemplate()
-	g.AddChatTemplateNode(NodeChatTemplate, chatTemplate)
+	library, err := prompts.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	chatTemplate, err := createChatTemplate(library, tools.ReadOnly(ctx), tools.Offline(ctx))
+	if err != nil {
+		return nil, err
+	}
+	g.AddChatTemplateNode(nodeChatTemplate, chatTemplate)
 
 	// Node 3: The core ReAct agent, which handles the tool-use loop.
-	reactAgentNode, err := createReactAgentNode(ctx)
+	// Wrap the agent's methods in a generic Lambda to make it compatible with the graph.
+	reactAgentNode, err := compose.AnyLambda(reactAgent.Generate, reactAgent.Stream, nil, nil)
 	if err != nil {
 		return nil, err
 	}
-	g.AddLambdaNode(NodeReactAgent, reactAgentNode)
+	g.AddLambdaNode(nodeReactAgent, reactAgentNode)
 
 	// Define the data flow through the graph by connecting the nodes.
 	// The compiler validates that the output type of a node matches the
 	// input type of the next, ensuring type safety.
-	g.AddEdge(compose.START, NodeInputToHistory)
-	g.AddEdge(NodeInputToHistory, NodeChatTemplate)
-	g.AddEdge(NodeChatTemplate, NodeReactAgent)
-	g.AddEdge(NodeReactAgent, compose.END)
-
-	// Compile the graph into an executable Runnable. This validates the
-	// graph's structure (e.g., checking for cycles) and optimizes it.
-	return g.Compile(ctx, compose.WithGraphName("GopherConAgent"))
+	g.AddEdge(compose.START, nodeInputToHistory)
+	g.AddEdge(nodeInputToHistory, nodeChatTemplate)
+	g.AddEdge(nodeChatTemplate, nodeReactAgent)
+	g.AddEdge(nodeReactAgent, compose.END)
+	return g, nil
 }
 
 // extractVariables is a pure function that transforms the agent input
-// into the map required by the chat template.
+// into the map required by the chat template. The query is escaped, so
+// code the user pastes reaches the model with its braces intact.
 func extractVariables(_ context.Context, input *UserMessage) (map[string]any, error) {
-	return map[string]any{
+	return prompts.EscapeVars(map[string]any{
 		"content": input.Query,
 		"history": input.History,
 		"date":    time.Now().Format("2006-01-02"),
-	}, nil
+	}), nil
 }
 
-// createChatTemplate defines the system prompt and message structure.
-func createChatTemplate() prompt.ChatTemplate {
-	systemPrompt := `You are an expert Go coding assistant. You are concise, proactive, and use your tools to answer questions.
-- Use tools to find information instead of asking the user.
-- **Analyze Errors:** If a tool fails, read the error, adapt, and try again.
-- **Code Editing Strategy:** When a small, targeted code replacement fails with a syntax error, it means the tool requires 
-	a larger, complete declaration. The correct recovery is to ESCALATE your scope: replace the entire parent function containing the bug.
-- Current Date: {date}`
+// createChatTemplate defines the system prompt and message structure. The
+// system prompt is the step5.system template of library, followed in read-only mode by
+// step5.read_only, so the model explains what it would change instead of
+// trying to change it, and offline by step5.offline, so it neither promises
+// web results nor guesses at what it cannot look up.
+func createChatTemplate(library *prompts.Library, readOnly, offline bool) (prompt.ChatTemplate, error) {
+	system, err := library.Get("step5.system")
+	if err != nil {
+		return nil, err
+	}
+	if err := system.Expect("date"); err != nil {
+		return nil, err
+	}
+	systemPrompt := system.Text
+	for _, mode := range []struct {
+		on   bool
+		name string
+	}{{readOnly, "step5.read_only"}, {offline, "step5.offline"}} {
+		if !mode.on {
+			continue
+		}
+		t, err := library.Get(mode.name)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Expect(); err != nil {
+			return nil, err
+		}
+		systemPrompt += "\n" + t.Text
+	}
 
 	return prompt.FromMessages(
 		schema.FString,
 		schema.SystemMessage(systemPrompt),
 		schema.MessagesPlaceholder("history", true),
 		schema.UserMessage("{content}"),
-	)
+	), nil
 }
 
-// createReactAgentNode builds the ReAct agent component, which includes
+// createReactAgent builds the ReAct agent component, which includes
 // the LLM, the list of available tools, and its configuration.
-func createReactAgentNode(ctx context.Context) (*compose.Lambda, error) {
-	chatModel, err := gemini.NewChatModel(ctx)
+func createReactAgent(ctx context.Context) (*react.Agent, func() error, error) {
+	if err := requireToolCalling(ctx); err != nil {
+		return nil, nil, err
+	}
+	toolsList, closeTools, err := SetupTools(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create chat model: %w", err)
+		return nil, nil, fmt.Errorf("failed to set up tools: %w", err)
 	}
 
-	toolsList, err := setupTools(ctx)
+	reactAgent, err := newReactAgent(ctx, toolsList)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set up tools: %w", err)
+		closeTools()
+		return nil, nil, err
 	}
+	return reactAgent, closeTools, nil
+}
 
+// newReactAgent builds a ReAct agent that chats with the model of ctx
+// (chatModelName) and calls toolsList.
+func newReactAgent(ctx context.Context, toolsList []tool.BaseTool) (*react.Agent, error) {
+	if err := requireToolCalling(ctx); err != nil {
+		return nil, err
+	}
+	chatModel, err := newChatModel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat model: %w", err)
+	}
 	return buildReactAgent(ctx, chatModel, toolsList)
 }
 
+// requireToolCalling rejects chat models known not to call tools.
+func requireToolCalling(ctx context.Context) error {
+	if caps, ok := models.Lookup(chatModelName(ctx)); ok && !caps.Tools {
+		return fmt.Errorf("chat model %s does not support tool calling", chatModelName(ctx))
+	}
+	return nil
+}
+
 // buildReactAgent configures and constructs the Eino ReAct agent.
-func buildReactAgent(ctx context.Context, chatModel model.ToolCallingChatModel, toolsList []tool.BaseTool) (*compose.Lambda, error) {
+func buildReactAgent(ctx context.Context, chatModel model.ToolCallingChatModel, toolsList []tool.BaseTool) (*react.Agent, error) {
 	config := &react.AgentConfig{
 		MaxStep:          20,
 		ToolCallingModel: chatModel,
@@ -111,7 +209,5 @@ func buildReactAgent(ctx context.Context, chatModel model.ToolCallingChatModel,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create react agent: %w", err)
 	}
-
-	// Wrap the agent's methods in a generic Lambda to make it compatible with the graph.
-	return compose.AnyLambda(reactAgent.Generate, reactAgent.Stream, nil, nil)
+	return reactAgent, nil
 }
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/olusolaa/goforai/foundation/chaos"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/ollama"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"github.com/olusolaa/goforai/foundation/usage"
+)
+
+type chatModelKey struct{}
+
+// WithChatModel returns a context in which agents built by New and NewRunner
+// chat with the named model instead of the configured one, as after /model.
+func WithChatModel(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, chatModelKey{}, name)
+}
+
+// newChatModel returns the model the agent chats with: Gemini, or in offline
+// mode (tools.WithOffline) the local Ollama model. With CHAOS_MODEL_429_RATE
+// set, some of its calls fail as rate limited.
+func newChatModel(ctx context.Context) (model.ToolCallingChatModel, error) {
+	chaosConfig, err := config.LoadChaos()
+	if err != nil {
+		return nil, err
+	}
+	m, err := newProviderChatModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return chaos.New(chaosConfig).ChatModel(chatModelName(ctx), m), nil
+}
+
+// newProviderChatModel returns the model newChatModel wraps.
+func newProviderChatModel(ctx context.Context) (model.ToolCallingChatModel, error) {
+	if !tools.Offline(ctx) {
+		return gemini.NewNamedChatModel(ctx, chatModelName(ctx))
+	}
+	cfg, err := config.LoadOffline()
+	if err != nil {
+		return nil, err
+	}
+	chatModel, err := ollama.NewChatModel(ctx, chatModelName(ctx), cfg.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create offline chat model: %w", err)
+	}
+	return usage.ChatModel(ollama.Provider, chatModel.Model(), chatModel), nil
+}
+
+// chatModelName returns the name of the model newChatModel returns.
+func chatModelName(ctx context.Context) string {
+	if name, ok := ctx.Value(chatModelKey{}).(string); ok {
+		return name
+	}
+	if !tools.Offline(ctx) {
+		return gemini.ChatModel()
+	}
+	cfg, err := config.LoadOffline()
+	if err != nil {
+		return config.DefaultOfflineChatModel
+	}
+	return cfg.ChatModel
+}
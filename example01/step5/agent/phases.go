@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/olusolaa/goforai/foundation/phases"
+)
+
+// turnPhases records the phase events of a turn so that processStream can
+// set the reasoning apart from the answer when the events say each began,
+// rather than when the text looks like it did. The events are sent from the
+// goroutines that read the model's streams, which may fall behind the
+// agent's output stream, so processStream waits for the event of the text
+// it is about to show. The methods of a nil turnPhases wait for nothing.
+type turnPhases struct {
+	handler *phases.Handler
+
+	mu      sync.Mutex
+	seen    map[phaseOf]bool
+	changed chan struct{} // Closed and replaced when an event is recorded.
+}
+
+// phaseOf identifies the event of a phase of one model call.
+type phaseOf struct {
+	step  int
+	phase phases.Phase
+}
+
+func newTurnPhases() *turnPhases {
+	p := &turnPhases{seen: make(map[phaseOf]bool), changed: make(chan struct{})}
+	p.handler = phases.NewHandler(func(_ context.Context, e phases.Event) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.seen[phaseOf{e.Step, e.Phase}] = true
+		close(p.changed)
+		p.changed = make(chan struct{})
+	})
+	return p
+}
+
+// await waits until the model call whose output is being shown has entered
+// phase, or ctx is done. The agent's output stream carries only the turn's
+// last model call, which has started by the time its output arrives.
+func (p *turnPhases) await(ctx context.Context, phase phases.Phase) {
+	if p == nil {
+		return
+	}
+	key := phaseOf{p.handler.Step(), phase}
+	for {
+		p.mu.Lock()
+		seen, changed := p.seen[key], p.changed
+		p.mu.Unlock()
+		if seen {
+			return
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/recall"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// newRecallIndex returns the index of past conversations. Offline, it is
+// only available when conversations are embedded locally.
+func newRecallIndex(ctx context.Context) (*recall.Index, error) {
+	embeddingConfig, err := config.LoadEmbeddings()
+	if err != nil {
+		return nil, err
+	}
+	if tools.Offline(ctx) && embeddingConfig.Provider != config.EmbeddingOllama {
+		return nil, fmt.Errorf("past conversations search %w: they are embedded with %s", tools.ErrOffline, embeddingConfig.Provider)
+	}
+	return recall.NewFromEnv(ctx)
+}
+
+// setupRecallTool returns the search_past_conversations tool, or nil when no
+// conversation has been indexed. With a persistent session store, the
+// sessions stored since the last start are indexed first.
+func setupRecallTool(ctx context.Context) (tool.BaseTool, error) {
+	log := logger.FromContext(ctx)
+	sessionConfig, err := config.LoadSessions()
+	if err != nil {
+		return nil, err
+	}
+	_, statErr := os.Stat(config.RecallDBPath())
+	if sessionConfig.Store == config.SessionStoreMemory && statErr != nil {
+		return nil, nil
+	}
+
+	index, err := newRecallIndex(ctx)
+	if err != nil {
+		log.Warn("past conversations unavailable", "error", err)
+		return nil, nil
+	}
+	if sessionConfig.Store != config.SessionStoreMemory {
+		if stats, err := syncRecall(ctx, index, sessionConfig); err != nil {
+			log.Warn("failed to index past conversations", "error", err)
+		} else {
+			log.Info("indexed past conversations", "sessions", stats.Sessions, "exchanges", stats.Exchanges, "embedded", stats.Embedded)
+		}
+	}
+	if _, err := os.Stat(index.Path()); err != nil {
+		return nil, nil
+	}
+	return tools.NewPastConversationsTool(ctx, index)
+}
+
+// syncRecall indexes the sessions of the configured store.
+func syncRecall(ctx context.Context, index *recall.Index, cfg config.Sessions) (recall.Stats, error) {
+	store, err := session.NewStore(ctx, cfg)
+	if err != nil {
+		return recall.Stats{}, fmt.Errorf("failed to open session store: %w", err)
+	}
+	defer store.Close()
+	return index.Sync(ctx, store)
+}
+
+// recallPast shows the exchanges of earlier sessions that match query and
+// adds them to the conversation, so the following turns can build on them.
+func (a *Agent) recallPast(ctx context.Context, query string) {
+	if a.recall == nil {
+		index, err := newRecallIndex(ctx)
+		if err != nil {
+			a.ui.DisplayError(err)
+			return
+		}
+		a.recall = index
+	}
+	docs, err := a.recall.Retrieve(ctx, query)
+	if errors.Is(err, chromemdb.ErrDBNotFound) {
+		a.ui.DisplayNotice("No past conversations are indexed yet. With SESSION_STORE=sqlite or redis, run 'goforai sessions index'.")
+		return
+	}
+	if err != nil {
+		a.ui.DisplayError(err)
+		return
+	}
+	found := recall.Format(docs)
+	a.ui.DisplayNotice(found)
+	if len(docs) == 0 {
+		return
+	}
+	a.conversation = append(a.conversation,
+		schema.UserMessage("<past-conversations query=\""+query+"\">\n"+found+"\n</past-conversations>\nThese are excerpts of earlier sessions."),
+		schema.AssistantMessage("Understood. I will take these earlier conversations into account.", nil),
+	)
+}
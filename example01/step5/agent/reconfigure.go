@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/tokens"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// rebuild compiles the graph around a new ReAct agent that chats with
+// a.model and calls the tools of the toolbox that are not disabled. The
+// conversation, attachments and tools themselves are kept, so /model and
+// /tools take effect from the next turn without a restart. On error the
+// agent keeps its previous graph.
+func (a *Agent) rebuild(ctx context.Context) error {
+	ctx = WithChatModel(ctx, a.model)
+	reactAgent, err := newReactAgent(ctx, a.enabledTools(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to build agent graph: %w", err)
+	}
+	graph, err := compileGraph(ctx, reactAgent)
+	if err != nil {
+		return fmt.Errorf("failed to build agent graph: %w", err)
+	}
+	summarizer, err := newChatModel(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create chat model: %w", err)
+	}
+
+	a.graph, a.summarizer, a.tokens = graph, summarizer, nil
+	// Offline, tokens are estimated instead of counted by the Gemini API.
+	if client, err := gemini.Client(ctx); err == nil && !tools.Offline(ctx) {
+		a.tokens = tokens.NewGemini(client, a.model)
+	}
+	return nil
+}
+
+// enabledTools returns the tools of the toolbox that are not disabled.
+func (a *Agent) enabledTools(ctx context.Context) []tool.BaseTool {
+	enabled := make([]tool.BaseTool, 0, len(a.toolbox))
+	for _, t := range a.toolbox {
+		if !a.disabled[toolName(ctx, t)] {
+			enabled = append(enabled, t)
+		}
+	}
+	return enabled
+}
+
+func toolName(ctx context.Context, t tool.BaseTool) string {
+	if info, err := t.Info(ctx); err == nil {
+		return info.Name
+	}
+	return ""
+}
+
+// setModel handles /model: without a name it shows the current model,
+// otherwise it switches the agent to the named one.
+func (a *Agent) setModel(ctx context.Context, name string) {
+	if name == "" {
+		a.ui.DisplayNotice(fmt.Sprintf("Chatting with %s. Switch with /model <name>; known models: %s.", a.model, strings.Join(models.Names(), ", ")))
+		return
+	}
+	if name == a.model {
+		a.ui.DisplayNotice("Already chatting with " + name + ".")
+		return
+	}
+	previous := a.model
+	a.model = name
+	if err := a.rebuild(ctx); err != nil {
+		a.model = previous
+		a.ui.DisplayError(err)
+		return
+	}
+	msg := fmt.Sprintf("Switched from %s to %s; the conversation is kept.", previous, name)
+	if _, ok := models.Lookup(name); !ok {
+		msg += " The model is not in the registry, so its context window and price are unknown."
+	}
+	a.ui.DisplayNotice(msg)
+}
+
+// setTools handles /tools: without arguments it lists the tools, and
+// "enable <name>" or "disable <name>" turns one on or off.
+func (a *Agent) setTools(ctx context.Context, arg string) {
+	action, name, _ := strings.Cut(arg, " ")
+	name = strings.TrimSpace(name)
+	switch {
+	case arg == "" || arg == "list":
+		a.ui.DisplayNotice(a.describeTools(ctx))
+		return
+	case (action != "enable" && action != "disable") || name == "":
+		a.ui.DisplayNotice("Usage: /tools [enable|disable <name>]")
+		return
+	}
+
+	names := make([]string, len(a.toolbox))
+	for i, t := range a.toolbox {
+		names[i] = toolName(ctx, t)
+	}
+	if !slices.Contains(names, name) {
+		a.ui.DisplayNotice(fmt.Sprintf("There is no tool named %s; /tools lists them.", name))
+		return
+	}
+	disable := action == "disable"
+	if a.disabled[name] == disable {
+		a.ui.DisplayNotice(fmt.Sprintf("%s is already %sd.", name, action))
+		return
+	}
+	a.disabled[name] = disable
+	if err := a.rebuild(ctx); err != nil {
+		a.disabled[name] = !disable
+		a.ui.DisplayError(err)
+		return
+	}
+	a.ui.DisplayNotice(fmt.Sprintf("%s %sd from the next turn.", name, action))
+}
+
+// describeTools lists the tools of the toolbox and whether each is enabled.
+func (a *Agent) describeTools(ctx context.Context) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Tools (%d of %d enabled):", len(a.enabledTools(ctx)), len(a.toolbox))
+	for _, t := range a.toolbox {
+		name := toolName(ctx, t)
+		mark := "✓"
+		if a.disabled[name] {
+			mark = "✗"
+		}
+		fmt.Fprintf(&b, "\n  %s %s", mark, name)
+	}
+	return b.String()
+}
@@ -2,68 +2,452 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 
+	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/audit"
+	"github.com/olusolaa/goforai/foundation/chaos"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/knowledgegraph"
+	"github.com/olusolaa/goforai/foundation/language"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/mcpclient"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/plugin"
+	"github.com/olusolaa/goforai/foundation/policy"
+	"github.com/olusolaa/goforai/foundation/retrieval"
 	"github.com/olusolaa/goforai/foundation/tools"
 )
 
-// setupTools initializes and returns the list of tools for the agent.
+// SetupTools initializes and returns the list of tools for the agent.
 // It includes logic for falling back to alternative tools if primaries fail.
-func setupTools(ctx context.Context) ([]tool.BaseTool, error) {
-	ragTool, err := tools.NewRAGTool(ctx)
+// The returned function shuts down the external MCP servers and plugins
+// behind some of the tools and closes the audit log; call it when the tools
+// are no longer needed.
+func SetupTools(ctx context.Context) ([]tool.BaseTool, func() error, error) {
+	// Translation lets attendees ask in their own language; without a chat
+	// model the knowledge base is searched with the question as asked, and
+	// analyze_repos and suggest_commit_message, which use the same model, are
+	// left out.
+	var (
+		toolModel  model.BaseChatModel
+		translator *language.Translator
+	)
+	if m, err := newChatModel(ctx); err != nil {
+		logger.FromContext(ctx).Warn("translation unavailable", "error", err)
+	} else {
+		toolModel = m
+		translator = language.NewTranslator(m)
+	}
+	maxAge, err := config.KBMaxAge()
+	if err != nil {
+		return nil, nil, err
+	}
+	minScore, err := config.RAGMinScore()
+	if err != nil {
+		return nil, nil, err
+	}
+	sizer, err := newRetrievalSizer(chatModelName(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	sources, err := config.RAGSources()
+	if err != nil {
+		return nil, nil, err
+	}
+	expansion, err := config.LoadExpansion()
+	if err != nil {
+		return nil, nil, err
+	}
+	// Without an index the agent still works, just without the knowledge base.
+	ragTool, err := tools.NewRAGTool(ctx, &tools.RAGToolConfig{
+		Structured: config.RAGStructured(),
+		Translator: translator,
+		MinScore:   minScore,
+		MaxAge:     maxAge,
+		Sizer:      sizer,
+		Sources:    sources,
+		Expansion:  expansion,
+	})
+	switch {
+	case errors.Is(err, chromemdb.ErrDBNotFound):
+		logger.FromContext(ctx).Warn("knowledge base not indexed, run 'make setup' to enable it", "error", err)
+	case errors.Is(err, tools.ErrOffline):
+		logger.FromContext(ctx).Warn("knowledge base unavailable offline", "error", err)
+	case err != nil:
+		return nil, nil, fmt.Errorf("failed to create RAG tool: %w", err)
+	}
+	// The knowledge graph is optional; `goforai index` builds it when
+	// KNOWLEDGE_GRAPH names its file.
+	var graphTool tool.BaseTool
+	if path := config.KnowledgeGraphPath(); path != "" {
+		graphTool, err = tools.NewGraphQueryTool(ctx, &tools.GraphQueryConfig{Path: path})
+		switch {
+		case errors.Is(err, knowledgegraph.ErrNotFound):
+			logger.FromContext(ctx).Warn("knowledge graph not built, run 'goforai index' to enable graph_query", "error", err)
+		case err != nil:
+			return nil, nil, fmt.Errorf("failed to create graph query tool: %w", err)
+		}
+	}
+	// Without indexed sessions the agent cannot search past conversations.
+	recallTool, err := setupRecallTool(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create RAG tool: %w", err)
+		return nil, nil, fmt.Errorf("failed to create past conversations tool: %w", err)
 	}
 	readFileTool, err := tools.NewReadFileTool(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create read file tool: %w", err)
+		return nil, nil, fmt.Errorf("failed to create read file tool: %w", err)
 	}
 	searchFilesTool, err := tools.NewSearchFilesTool(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create search files tool: %w", err)
+		return nil, nil, fmt.Errorf("failed to create search files tool: %w", err)
+	}
+	gitCloneTool, err := tools.NewGitCloneTool(ctx, &tools.GitCloneConfig{Overview: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create git clone tool: %w", err)
+	}
+	repoOverviewTool, err := tools.NewRepoOverviewTool(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create repo overview tool: %w", err)
+	}
+	reviewChangesTool, err := tools.NewReviewChangesTool(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create review changes tool: %w", err)
+	}
+	summarizeModuleTool, err := tools.NewSummarizeModuleTool(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create summarize module tool: %w", err)
+	}
+	envInfoTool, err := tools.NewEnvInfoTool(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create env info tool: %w", err)
+	}
+	runGoTool, err := tools.NewRunGoTool(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create run go tool: %w", err)
 	}
-	editFileTool, err := tools.NewEditFileTool(ctx)
+	profileTool, err := tools.NewProfileProgramTool(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create edit file tool: %w", err)
+		return nil, nil, fmt.Errorf("failed to create profile program tool: %w", err)
 	}
-	gitCloneTool, err := tools.NewGitCloneTool(ctx, nil)
+	testRegexTool, err := tools.NewTestRegexTool(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create git clone tool: %w", err)
+		return nil, nil, fmt.Errorf("failed to create test regex tool: %w", err)
+	}
+	queryFilesTool, err := tools.NewQueryFilesTool(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create query files tool: %w", err)
 	}
 
-	searchTool := setupSearchTool(ctx)
+	searchConfig, err := config.LoadSearch()
+	if err != nil {
+		return nil, nil, err
+	}
+	// Offline mode (tools.WithOffline) leaves out the tools that need the
+	// internet: web search, cloning, vulnerability scans and email.
+	var searchTool tool.BaseTool
+	if !tools.Offline(ctx) {
+		searchTool = setupSearchTool(ctx, searchConfig.Provider, &tools.SearchConfig{
+			FetchPages:    searchConfig.FetchPages,
+			PageMaxTokens: searchConfig.PageMaxTokens,
+		})
+	}
 
 	toolsList := []tool.BaseTool{
 		searchFilesTool,
 		readFileTool,
-		editFileTool,
-		gitCloneTool,
-		ragTool,
+	}
+	// Read-only mode (tools.WithReadOnly) leaves the editing tools out.
+	if !tools.ReadOnly(ctx) {
+		editFileTool, err := tools.NewEditFileTool(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create edit file tool: %w", err)
+		}
+		changesetTool, err := tools.NewChangesetTool(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create changeset tool: %w", err)
+		}
+		renameSymbolTool, err := tools.NewRenameSymbolTool(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create rename symbol tool: %w", err)
+		}
+		scaffoldTool, err := tools.NewScaffoldProjectTool(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create scaffold project tool: %w", err)
+		}
+		fixDependenciesTool, err := tools.NewFixDependenciesTool(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create fix dependencies tool: %w", err)
+		}
+		resolveConflictTool, err := tools.NewResolveConflictTool(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create resolve conflict tool: %w", err)
+		}
+		toolsList = append(toolsList, editFileTool, changesetTool, renameSymbolTool, scaffoldTool, fixDependenciesTool, resolveConflictTool)
+	}
+	if !tools.Offline(ctx) {
+		scanVulnerabilitiesTool, err := tools.NewScanVulnerabilitiesTool(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create scan vulnerabilities tool: %w", err)
+		}
+		toolsList = append(toolsList, gitCloneTool, scanVulnerabilitiesTool)
+		if toolModel != nil {
+			analyzeReposTool, err := tools.NewAnalyzeReposTool(ctx, &tools.AnalyzeReposConfig{Model: toolModel})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to create analyze repos tool: %w", err)
+			}
+			toolsList = append(toolsList, analyzeReposTool)
+		}
+	}
+	listConflictsTool, err := tools.NewListConflictsTool(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create list conflicts tool: %w", err)
+	}
+	licenseAuditTool, err := tools.NewLicenseAuditTool(ctx, &tools.LicenseAuditConfig{Disallowed: config.DisallowedLicenses()})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create license audit tool: %w", err)
+	}
+	toolsList = append(toolsList, repoOverviewTool, reviewChangesTool, summarizeModuleTool, envInfoTool, runGoTool, profileTool, testRegexTool, queryFilesTool, listConflictsTool, licenseAuditTool)
+	if toolModel != nil {
+		commitMessageTool, err := tools.NewCommitMessageTool(ctx, &tools.CommitMessageConfig{Model: toolModel})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create commit message tool: %w", err)
+		}
+		toolsList = append(toolsList, commitMessageTool)
+	}
+	if ragTool != nil {
+		toolsList = append(toolsList, ragTool)
+	}
+	if graphTool != nil {
+		toolsList = append(toolsList, graphTool)
+	}
+	if recallTool != nil {
+		toolsList = append(toolsList, recallTool)
+	}
+	pinFactTool, err := tools.NewPinFactTool(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create pin fact tool: %w", err)
+	}
+	toolsList = append(toolsList, pinFactTool)
+	if translator != nil {
+		translateTool, err := tools.NewTranslateTool(ctx, translator)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create translate tool: %w", err)
+		}
+		toolsList = append(toolsList, translateTool)
+	}
+	// Email is opt-in: EMAIL_PROVIDER names the provider to send through.
+	emailConfig, err := config.LoadEmail()
+	if err != nil {
+		return nil, nil, err
+	}
+	if emailConfig.Provider != "" && !tools.Offline(ctx) {
+		sendEmailTool, err := tools.NewSendEmailTool(ctx, emailConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create send email tool: %w", err)
+		}
+		toolsList = append(toolsList, sendEmailTool)
 	}
 	if searchTool != nil {
 		toolsList = append(toolsList, searchTool)
 	}
 
-	return toolsList, nil
+	// Tools from external MCP servers listed in mcp.json (or $MCP_CONFIG).
+	// Read-only mode starts only the servers marked read_only.
+	mcpTools, closeMCP, err := mcpclient.LoadTools(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load MCP tools: %w", err)
+	}
+	toolsList = append(toolsList, mcpTools...)
+
+	// The tools declared outside the built-in set are annotated by what they
+	// declare rather than by name.
+	external := make(map[string]tools.Concurrency)
+	annotate := func(ts []tool.BaseTool) {
+		for _, t := range ts {
+			if info, err := t.Info(ctx); err == nil {
+				external[info.Name] = tools.ExternalConcurrency(t)
+			}
+		}
+	}
+	annotate(mcpTools)
+
+	// Command and HTTP tools declared in tools.yaml (or $TOOLS_CONFIG).
+	customTools, err := tools.LoadCustomTools(ctx, config.CustomToolsPath())
+	if err != nil {
+		closeMCP()
+		return nil, nil, err
+	}
+	names := make(map[string]bool, len(toolsList))
+	for _, t := range toolsList {
+		if info, err := t.Info(ctx); err == nil {
+			names[info.Name] = true
+		}
+	}
+	for _, t := range customTools {
+		if info, err := t.Info(ctx); err == nil && names[info.Name] {
+			closeMCP()
+			return nil, nil, fmt.Errorf("custom tool %q has the name of a built-in or MCP tool", info.Name)
+		}
+	}
+	for _, t := range customTools {
+		if info, err := t.Info(ctx); err == nil {
+			names[info.Name] = true
+		}
+	}
+	toolsList = append(toolsList, customTools...)
+	annotate(customTools)
+
+	// Tools from plugin binaries in plugins/ (or $PLUGIN_DIR), each run in a
+	// process of its own. Plugins cannot declare their tools read-only, so
+	// read-only mode starts none.
+	closePlugins := func() error { return nil }
+	if !tools.ReadOnly(ctx) {
+		var pluginTools []tool.BaseTool
+		pluginTools, closePlugins, err = plugin.Load(ctx, config.PluginDir())
+		if err != nil {
+			closeMCP()
+			return nil, nil, err
+		}
+		for _, t := range pluginTools {
+			if info, err := t.Info(ctx); err == nil && names[info.Name] {
+				closeMCP()
+				closePlugins()
+				return nil, nil, fmt.Errorf("plugin tool %q has the name of a built-in, MCP or custom tool; rename the plugin", info.Name)
+			}
+		}
+		toolsList = append(toolsList, pluginTools...)
+		annotate(pluginTools)
+	}
+	closeExternal := func() error {
+		return errors.Join(closeMCP(), closePlugins())
+	}
+
+	// Every result, local or remote, is held to the same size budget.
+	maxTokens, err := config.ToolOutputMaxTokens()
+	if err != nil {
+		closeExternal()
+		return nil, nil, err
+	}
+	maxRetries, err := config.ToolMaxRetries()
+	if err != nil {
+		closeExternal()
+		return nil, nil, err
+	}
+	speculation, err := config.LoadSpeculation()
+	if err != nil {
+		closeExternal()
+		return nil, nil, err
+	}
+	toolPolicy, err := policy.Load(config.PolicyPath())
+	if err != nil {
+		closeExternal()
+		return nil, nil, err
+	}
+	chaosConfig, err := config.LoadChaos()
+	if err != nil {
+		closeExternal()
+		return nil, nil, err
+	}
+	if chaosConfig.Enabled() {
+		logger.FromContext(ctx).Warn("chaos testing is injecting failures",
+			"model_429_rate", chaosConfig.ModelRateLimit, "tool_timeout_rate", chaosConfig.ToolTimeout,
+			"tool_malformed_rate", chaosConfig.MalformedOutput, "tools", chaosConfig.Tools, "seed", chaosConfig.Seed)
+	}
+	auditLog, err := audit.Open(config.AuditLogPath())
+	if err != nil {
+		closeExternal()
+		return nil, nil, err
+	}
+	// The policy is checked before every call, and the audit log records
+	// policy denials and the results as the model receives them. The tool
+	// calls of one response run in parallel, so calls that write the same
+	// file wait for each other, and external tools not declared read-only run
+	// alone; denied calls never wait. Failed results
+	// carry a hint for the model, and a tool that keeps failing is not run
+	// again in the same turn. With SPECULATIVE_PREFETCH, the files a search
+	// finds are read while the model decides what to read. Failures injected
+	// for chaos testing (CHAOS_*) pass through all of this, as real ones do.
+	injector := chaos.New(chaosConfig)
+	limiter := tools.NewLimiter()
+	guide := tools.RetryGuide{MaxRetries: maxRetries}
+	speculator := tools.NewSpeculator(speculation)
+	for i, t := range toolsList {
+		var name string
+		if info, err := t.Info(ctx); err == nil {
+			name = info.Name
+		}
+		c, ok := external[name]
+		if !ok {
+			c = tools.ConcurrencyOf(name)
+		}
+		readOnly := c.Paths == nil && !c.Exclusive
+		guided := guide.Wrap(tools.LimitOutput(speculator.Wrap(injector.Tool(name, t), name, readOnly), tools.OutputPolicy{MaxTokens: maxTokens}), name)
+		toolsList[i] = auditLog.Wrap(toolPolicy.Wrap(limiter.Wrap(guided, c)))
+	}
+
+	closeTools := func() error {
+		return errors.Join(closeExternal(), auditLog.Close())
+	}
+	return toolsList, closeTools, nil
 }
 
-// setupSearchTool attempts to create the primary search tool (Tavily)
-// and falls back to a secondary one (DuckDuckGo) if it fails.
-func setupSearchTool(ctx context.Context) tool.BaseTool {
-	tavilyTool, err := tools.NewTavilySearchTool(ctx)
-	if err == nil {
-		log.Println("✅ Using Tavily for web search")
-		return tavilyTool
+// newRetrievalSizer sizes knowledge base searches for the chat model. For a
+// model the registry does not know it returns nil, and searches keep the
+// retriever's fixed size.
+func newRetrievalSizer(chatModel string) (*retrieval.Sizer, error) {
+	caps, ok := models.Lookup(chatModel)
+	if !ok {
+		return nil, nil
+	}
+	cfg, err := config.LoadRetrieval()
+	if err != nil {
+		return nil, err
+	}
+	chunking, err := config.LoadChunking()
+	if err != nil {
+		return nil, err
+	}
+	return retrieval.NewSizer(caps, cfg, chunking.Size), nil
+}
+
+// setupSearchTool creates the search tool of provider (SEARCH_PROVIDER).
+// Without one it attempts to create the primary search tool (Tavily) and
+// falls back to a secondary one (DuckDuckGo) if it fails.
+func setupSearchTool(ctx context.Context, provider string, searchConfig *tools.SearchConfig) tool.BaseTool {
+	log := logger.FromContext(ctx)
+
+	if provider == config.SearchGemini {
+		groundedTool, err := tools.NewGroundedSearchTool(ctx)
+		if err != nil {
+			log.Warn("could not initialize Gemini search grounding", "error", err)
+			return nil
+		}
+		log.Info("using Gemini search grounding for web search")
+		return groundedTool
+	}
+
+	if provider != config.SearchDuckDuckGo {
+		tavilyTool, err := tools.NewTavilySearchTool(ctx, searchConfig)
+		if err == nil {
+			log.Info("using Tavily for web search")
+			return tavilyTool
+		}
+		if provider == config.SearchTavily {
+			log.Warn("could not initialize Tavily search", "error", err)
+			return nil
+		}
+		log.Info("Tavily search not available, falling back to DuckDuckGo", "reason", err)
 	}
-	log.Printf("ℹ️ Tavily search not available (%v), falling back to DuckDuckGo", err)
 
-	ddgTool, err := tools.NewDuckDuckGoSearchTool(ctx)
+	ddgTool, err := tools.NewDuckDuckGoSearchTool(ctx, searchConfig)
 	if err == nil {
-		log.Println("✅ Using DuckDuckGo for web search")
+		log.Info("using DuckDuckGo for web search")
 		return ddgTool
 	}
-	log.Printf("⚠️ Could not initialize any web search tool (%v)", err)
+	log.Warn("could not initialize any web search tool", "error", err)
 	return nil
 }
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/topology"
+)
+
+// DescribeGraph builds the agent's graph without running it and returns its
+// topology, for `goforai graph`. The ReAct agent runs as a lambda node, which
+// would hide its model and tools loop, so its own graph is described
+// separately and nested under that node.
+func DescribeGraph(ctx context.Context) (*topology.Graph, error) {
+	g, reactAgent, closeTools, err := newEinoGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeTools()
+
+	var rec topology.Recorder
+	if _, err := g.Compile(ctx, compose.WithGraphName(graphName), compose.WithGraphCompileCallbacks(&rec)); err != nil {
+		return nil, fmt.Errorf("failed to compile agent graph: %w", err)
+	}
+	graph := rec.Graph()
+
+	loop, err := describeReactAgent(ctx, reactAgent)
+	if err != nil {
+		return nil, err
+	}
+	for i := range graph.Nodes {
+		if graph.Nodes[i].Key == nodeReactAgent {
+			graph.Nodes[i].Subgraph = loop
+		}
+	}
+	return graph, nil
+}
+
+// describeReactAgent returns the topology of the graph inside reactAgent.
+// Eino only describes the subgraphs of the graph being compiled, so the
+// agent's graph is compiled as the sole node of a throwaway parent.
+func describeReactAgent(ctx context.Context, reactAgent *react.Agent) (*topology.Graph, error) {
+	inner, opts := reactAgent.ExportGraph()
+	parent := compose.NewGraph[[]*schema.Message, *schema.Message]()
+	if err := parent.AddGraphNode(nodeReactAgent, inner, opts...); err != nil {
+		return nil, fmt.Errorf("failed to describe react agent: %w", err)
+	}
+	parent.AddEdge(compose.START, nodeReactAgent)
+	parent.AddEdge(nodeReactAgent, compose.END)
+
+	var rec topology.Recorder
+	if _, err := parent.Compile(ctx, compose.WithGraphCompileCallbacks(&rec)); err != nil {
+		return nil, fmt.Errorf("failed to describe react agent: %w", err)
+	}
+	return rec.Graph().Nodes[0].Subgraph, nil
+}
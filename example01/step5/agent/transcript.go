@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/events"
+)
+
+// Option configures an Agent.
+type Option func(*Agent)
+
+// WithTranscript tees the session to w as it happens: each question, the
+// answer as it streams, one line per tool call and turn errors. Unlike the
+// terminal, it holds no colors, spinners or tool output, so it can be
+// followed with tail -f while recording a demo, or read afterwards.
+func WithTranscript(w io.Writer) Option {
+	return func(a *Agent) {
+		a.transcript = &transcript{w: w, now: time.Now}
+	}
+}
+
+// transcript writes the session to a file. Its methods do nothing on a nil
+// transcript. Write errors are ignored: a full disk must not end the session.
+type transcript struct {
+	mu        sync.Mutex
+	w         io.Writer
+	now       func() time.Time
+	answering bool // An answer is being written; the next line starts a new one.
+}
+
+// user writes the start of a turn.
+func (t *transcript) user(question string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endAnswer()
+	fmt.Fprintf(t.w, "\n[%s] User: %s\n", t.stamp(), question)
+}
+
+// answer writes a piece of the streamed answer.
+func (t *transcript) answer(text string) {
+	if t == nil || text == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.answering {
+		fmt.Fprintf(t.w, "[%s] Assistant: ", t.stamp())
+		t.answering = true
+	}
+	io.WriteString(t.w, text)
+}
+
+// line writes a line of its own, such as a tool call or an error.
+func (t *transcript) line(format string, args ...any) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endAnswer()
+	fmt.Fprintf(t.w, "[%s] %s\n", t.stamp(), fmt.Sprintf(format, args...))
+}
+
+// endTurn finishes the turn's answer, if any. The caller holds no lock.
+func (t *transcript) endTurn() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endAnswer()
+}
+
+// endAnswer ends the line of an answer in progress. The caller holds mu.
+func (t *transcript) endAnswer() {
+	if t.answering {
+		io.WriteString(t.w, "\n")
+		t.answering = false
+	}
+}
+
+func (t *transcript) stamp() string {
+	return t.now().Format(time.TimeOnly)
+}
+
+type toolStartKey struct{}
+
+// handler writes a summary line for every tool call: its name, how long it
+// took and whether it failed.
+func (t *transcript) handler() callbacks.Handler {
+	summary := func(ctx context.Context, name string) string {
+		s := "tool " + name
+		if start, ok := ctx.Value(toolStartKey{}).(time.Time); ok {
+			s += fmt.Sprintf(" (%s)", t.now().Sub(start).Round(time.Millisecond))
+		}
+		return s
+	}
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if info.Component != components.ComponentOfTool {
+				return ctx
+			}
+			return context.WithValue(ctx, toolStartKey{}, t.now())
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if info.Component != components.ComponentOfTool {
+				return ctx
+			}
+			if out := tool.ConvCallbackOutput(output); out != nil {
+				if msg := events.ToolError(out.Response); msg != "" {
+					t.line("%s failed: %s", summary(ctx, info.Name), oneLine(msg))
+					return ctx
+				}
+			}
+			t.line("%s ok", summary(ctx, info.Name))
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			if info.Component == components.ComponentOfTool {
+				t.line("%s failed: %s", summary(ctx, info.Name), oneLine(err.Error()))
+			}
+			return ctx
+		}).
+		Build()
+}
+
+// oneLine keeps a message on the transcript line it belongs to.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/speech"
+)
+
+// setVoice handles /voice: "on" starts speaking answers with the engine
+// TTS_PROVIDER selects, "off" stops.
+func (a *Agent) setVoice(ctx context.Context, arg string) {
+	switch arg {
+	case "on":
+		cfg, err := config.LoadSpeech()
+		if err != nil {
+			a.ui.DisplayError(err)
+			return
+		}
+		speaker, err := speech.New(ctx, cfg)
+		if err != nil {
+			a.ui.DisplayError(err)
+			return
+		}
+		a.speaker = speaker
+		a.ui.DisplayNotice(fmt.Sprintf("Answers will be read aloud (%s); /voice off stops.", cfg.TTSProvider))
+	case "off":
+		a.stopSpeaking()
+		a.speaker = nil
+		a.ui.DisplayNotice("Answers will no longer be read aloud.")
+	default:
+		a.ui.DisplayNotice("Usage: /voice on|off")
+	}
+}
+
+// speak reads the last answer aloud in the background, so the user can type
+// the next question meanwhile; the next turn or /voice off cuts it short.
+func (a *Agent) speak(ctx context.Context) {
+	if a.speaker == nil || len(a.conversation) == 0 {
+		return
+	}
+	answer := a.conversation[len(a.conversation)-1]
+	if answer.Role != schema.Assistant || answer.Content == "" {
+		return
+	}
+	a.stopSpeaking()
+	ctx, cancel := context.WithCancel(ctx)
+	a.cancelSpeech = cancel
+	speaker := a.speaker
+	go func() {
+		defer cancel()
+		// Errors are logged rather than shown: the prompt for the next
+		// question is already on screen.
+		if err := speaker.Speak(ctx, answer.Content); err != nil {
+			logger.FromContext(ctx).Warn("speaking the answer failed", "error", err)
+		}
+	}()
+}
+
+// stopSpeaking cuts short the answer being read aloud, if any.
+func (a *Agent) stopSpeaking() {
+	if a.cancelSpeech != nil {
+		a.cancelSpeech()
+		a.cancelSpeech = nil
+	}
+}
+
+// setTalk handles /talk: without an argument it records one question, "on"
+// makes an empty input line start recording (push to talk), and "off" stops
+// that. It returns the transcribed question to send, or "".
+func (a *Agent) setTalk(ctx context.Context, arg string) string {
+	switch arg {
+	case "":
+		return a.listen(ctx)
+	case "on":
+		a.pushToTalk = true
+		a.ui.DisplayNotice("Press Enter on an empty line to record a question; /talk off stops.")
+	case "off":
+		a.pushToTalk = false
+		a.ui.DisplayNotice("Push to talk is off.")
+	default:
+		a.ui.DisplayNotice("Usage: /talk [on|off]")
+	}
+	return ""
+}
+
+// listen records a question until the user presses Enter and returns its
+// transcript, or "" when nothing usable was heard.
+func (a *Agent) listen(ctx context.Context) string {
+	if a.microphone == nil {
+		cfg, err := config.LoadSpeech()
+		if err != nil {
+			a.ui.DisplayError(err)
+			return ""
+		}
+		recorder, err := speech.NewRecorder()
+		if err != nil {
+			a.ui.DisplayError(err)
+			return ""
+		}
+		transcriber, err := speech.NewTranscriber(ctx, cfg)
+		if err != nil {
+			a.ui.DisplayError(err)
+			return ""
+		}
+		a.microphone, a.transcriber = recorder, transcriber
+	}
+	a.stopSpeaking()
+
+	recordCtx, stop := context.WithCancel(ctx)
+	type recording struct {
+		audio []byte
+		err   error
+	}
+	done := make(chan recording, 1)
+	go func() {
+		audio, err := a.microphone.Record(recordCtx)
+		done <- recording{audio, err}
+	}()
+	a.ui.WaitForEnter("🎙️  Recording... press Enter to stop. ")
+	stop()
+	rec := <-done
+	if rec.err != nil {
+		a.ui.DisplayError(rec.err)
+		return ""
+	}
+
+	a.ui.DisplayNotice("Transcribing...")
+	text, err := a.transcriber.Transcribe(ctx, rec.audio)
+	if err != nil {
+		a.ui.DisplayError(err)
+		return ""
+	}
+	if text == "" {
+		a.ui.DisplayNotice("No speech was heard.")
+		return ""
+	}
+	a.ui.DisplayTranscript(text)
+	return text
+}
@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/example01/step5/ui"
+)
+
+// fakeSpeaker records what it is asked to say and blocks until stopped.
+type fakeSpeaker struct {
+	spoken chan string
+}
+
+func (s *fakeSpeaker) Speak(ctx context.Context, text string) error {
+	s.spoken <- text
+	<-ctx.Done()
+	return nil
+}
+
+func TestSpeak(t *testing.T) {
+	speaker := &fakeSpeaker{spoken: make(chan string, 1)}
+	a := &Agent{ui: ui.New(), speaker: speaker}
+	a.updateConversationHistory("What is Eino?", schema.AssistantMessage("A **framework**.", nil))
+
+	a.speak(context.Background())
+	select {
+	case got := <-speaker.spoken:
+		if got != "A **framework**." {
+			t.Errorf("spoke %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the answer was not spoken")
+	}
+	if a.cancelSpeech == nil {
+		t.Fatal("speech cannot be stopped")
+	}
+	a.stopSpeaking()
+	if a.cancelSpeech != nil {
+		t.Error("stopSpeaking left the speech running")
+	}
+
+	// Without an answer to read, nothing is spoken.
+	a.updateConversationHistory("Thanks", nil)
+	a.speak(context.Background())
+	if a.cancelSpeech != nil {
+		t.Error("a user message was spoken")
+	}
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/snapshot"
+)
+
+// maxWatchedChanges bounds the files named in one workspace change notice.
+const maxWatchedChanges = 20
+
+// watcher notices the files the user changes in the workspace between turns.
+// It snapshots the workspace after each turn, so the agent's own edits are
+// never reported; edits the user makes while a turn runs are missed the same
+// way.
+type watcher struct {
+	root string
+	last *snapshot.Snapshot
+}
+
+// newWatcher starts watching the files under root.
+func newWatcher(root string) (*watcher, error) {
+	last, err := snapshot.Take(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch the workspace: %w", err)
+	}
+	return &watcher{root: root, last: last}, nil
+}
+
+// changes returns what changed since the last call, or since the watcher
+// started, and starts over from now.
+func (w *watcher) changes() ([]snapshot.Change, error) {
+	now, err := snapshot.Take(w.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch the workspace: %w", err)
+	}
+	changes := snapshot.Compare(w.last, now)
+	w.last = now
+	return changes, nil
+}
+
+// noteWorkspaceChanges tells the model, and the user, which files the user
+// changed since the last turn, so the model re-reads them instead of
+// trusting what it saw before. It does nothing without a watcher.
+func (a *Agent) noteWorkspaceChanges() {
+	if a.watch == nil {
+		return
+	}
+	changes, err := a.watch.changes()
+	if err != nil {
+		a.ui.DisplayError(err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+	notice := workspaceNotice(changes)
+	a.ui.DisplayNotice(notice)
+	a.conversation = append(a.conversation,
+		schema.UserMessage("<workspace-changes>\n"+notice+"\n</workspace-changes>\nRe-read these files before relying on what you saw of them earlier."),
+		schema.AssistantMessage("Understood. I will re-read those files before relying on them.", nil),
+	)
+}
+
+// forgetWorkspaceChanges absorbs the changes made during a turn, the agent's
+// own edits included, so the next turn reports only the user's.
+func (a *Agent) forgetWorkspaceChanges() {
+	if a.watch == nil {
+		return
+	}
+	if _, err := a.watch.changes(); err != nil {
+		a.ui.DisplayError(err)
+	}
+}
+
+// workspaceNotice lists the kind and path of each change, naming at most
+// maxWatchedChanges files.
+func workspaceNotice(changes []snapshot.Change) string {
+	var b strings.Builder
+	b.WriteString("Since the last turn the user changed these files:")
+	for i, c := range changes {
+		if i == maxWatchedChanges {
+			fmt.Fprintf(&b, "\n- and %d more", len(changes)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n- %s %s", c.Kind, c.Path)
+	}
+	return b.String()
+}
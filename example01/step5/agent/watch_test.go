@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olusolaa/goforai/example01/step5/ui"
+	"github.com/olusolaa/goforai/foundation/snapshot"
+)
+
+func TestWatchWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main\n")
+	write("old.go", "package main\n")
+
+	w, err := newWatcher(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Agent{ui: ui.New(), watch: w}
+	a.noteWorkspaceChanges()
+	if len(a.conversation) != 0 {
+		t.Fatalf("an untouched workspace was reported: %v", a.conversation)
+	}
+
+	// The agent's own edits during a turn are not the user's.
+	write("agent.go", "package main\n")
+	a.forgetWorkspaceChanges()
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("new.go", "package main\n")
+	if err := os.Remove(filepath.Join(dir, "old.go")); err != nil {
+		t.Fatal(err)
+	}
+	a.noteWorkspaceChanges()
+	if len(a.conversation) != 2 {
+		t.Fatalf("want a notice and its acknowledgement, got %d messages", len(a.conversation))
+	}
+	notice := a.conversation[0].Content
+	for _, want := range []string{"- modified main.go", "- created new.go", "- deleted old.go"} {
+		if !strings.Contains(notice, want) {
+			t.Errorf("notice lacks %q:\n%s", want, notice)
+		}
+	}
+	if strings.Contains(notice, "agent.go") {
+		t.Errorf("notice reports the agent's edit:\n%s", notice)
+	}
+
+	a.noteWorkspaceChanges()
+	if len(a.conversation) != 2 {
+		t.Errorf("changes were reported twice: %d messages", len(a.conversation))
+	}
+}
+
+func TestWorkspaceNoticeIsBounded(t *testing.T) {
+	var changes []snapshot.Change
+	for i := range maxWatchedChanges + 5 {
+		changes = append(changes, snapshot.Change{Path: fmt.Sprintf("f%02d.go", i), Kind: snapshot.Modified})
+	}
+	notice := workspaceNotice(changes)
+	if strings.Count(notice, "\n- modified ") != maxWatchedChanges || !strings.HasSuffix(notice, "\n- and 5 more") {
+		t.Errorf("unexpected notice:\n%s", notice)
+	}
+}
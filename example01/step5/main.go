@@ -4,10 +4,14 @@ package main
 import (
 	"context"
 	"log"
-	"os"
+	"log/slog"
 
 	"github.com/olusolaa/goforai/example01/step5/agent"
 	"github.com/olusolaa/goforai/example01/step5/ui"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/secrets"
+	"github.com/olusolaa/goforai/foundation/telemetry"
 )
 
 func main() {
@@ -22,11 +26,26 @@ func main() {
 // run encapsulates the application's startup and execution logic.
 func run() error {
 	// Ensure the required API key is set, failing early if it's not.
-	if os.Getenv("GEMINI_API_KEY") == "" {
-		log.Fatal("GEMINI_API_KEY environment variable must be set")
+	if _, err := secrets.Get(secrets.GeminiAPIKey); err != nil {
+		log.Fatal(err)
 	}
 
-	ctx := context.Background()
+	// Logs go to stderr by default so they don't interleave with the chat on stdout.
+	appLogger, closeLog, err := logger.New(config.LoadLogging())
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	slog.SetDefault(appLogger)
+
+	ctx := logger.WithContext(context.Background(), appLogger)
+
+	// Tracing is a no-op unless OTEL_EXPORTER_OTLP_ENDPOINT is set.
+	shutdownTracing, err := telemetry.Setup(ctx, "goforai-agent")
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
 
 	// 1. Initialize the UI component. It's a dependency for the agent.
 	terminalUI := ui.New()
@@ -37,6 +56,8 @@ func run() error {
 	if err != nil {
 		return err // Error is already well-contextualized by agent.New
 	}
+	// Trace exports run in the background; let the last ones finish.
+	defer gopherAgent.Close(context.Background())
 
 	// 3. Start the agent's main loop.
 	return gopherAgent.Run(ctx)
@@ -0,0 +1,252 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// In verbose mode (SetVerbose) every tool result is shown below the tool's
+// line. Tools with a renderer are shown from their response type; the others
+// as indented JSON, cut to maxResultLines.
+
+// maxResultLines bounds what a tool result takes up on the terminal.
+const maxResultLines = 20
+
+// renderer turns a tool's JSON response into the lines shown for it. ok is
+// false when the response does not decode, such as one cut short by the
+// output limit, and the JSON is shown instead.
+type renderer func(t *TerminalUI, response string) (lines []string, ok bool)
+
+// renderers are keyed by tool name.
+var renderers = map[string]renderer{
+	"search_files": typed(renderSearchFiles),
+	"calculator":   typed(renderCalculate),
+	"gitclone":     typed(renderGitClone),
+	"read_file":    typed(renderReadFile),
+	"run_go":       typed(renderRunGo),
+	"edit_go_file": typed(renderEditFile),
+}
+
+// typed adapts a renderer of the response type R.
+func typed[R any](render func(t *TerminalUI, resp *R) []string) renderer {
+	return func(t *TerminalUI, response string) ([]string, bool) {
+		var resp R
+		if err := json.Unmarshal([]byte(response), &resp); err != nil {
+			return nil, false
+		}
+		return render(t, &resp), true
+	}
+}
+
+// SetVerbose turns the display of tool results on or off.
+func (t *TerminalUI) SetVerbose(on bool) {
+	t.activeToolMutex.Lock()
+	defer t.activeToolMutex.Unlock()
+	t.verbose = on
+}
+
+// Verbose reports whether tool results are displayed.
+func (t *TerminalUI) Verbose() bool {
+	t.activeToolMutex.Lock()
+	defer t.activeToolMutex.Unlock()
+	return t.verbose
+}
+
+// renderResult returns the display of a tool's response, indented below the
+// tool's line. Failures reported in the response's "error" field are shown
+// the same way for every tool.
+func (t *TerminalUI) renderResult(name, response string) string {
+	var lines []string
+	if msg := events.ToolError(response); msg != "" {
+		lines = []string{t.colorError("error: ") + msg}
+	} else if render, found := renderers[name]; found {
+		lines, _ = render(t, response)
+	}
+	if lines == nil {
+		lines = jsonLines(response)
+	}
+	if len(lines) > maxResultLines {
+		more := len(lines) - maxResultLines + 1
+		lines = append(lines[:maxResultLines-1], t.colorMuted(fmt.Sprintf("… %d more lines", more)))
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&b, "   %s\n", line)
+	}
+	return b.String()
+}
+
+// jsonLines indents a JSON response, or splits a response that is not JSON
+// into its lines.
+func jsonLines(response string) []string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(response), "", "  "); err == nil {
+		response = buf.String()
+	}
+	return strings.Split(strings.TrimRight(response, "\n"), "\n")
+}
+
+// renderSearchFiles shows the matched files as a tree of their directories,
+// with the lines that matched.
+func renderSearchFiles(t *TerminalUI, resp *tools.SearchFilesResponse) []string {
+	if len(resp.Matches) == 0 {
+		return []string{t.colorMuted("no matches")}
+	}
+	lines := []string{t.colorMuted(fmt.Sprintf("%d files", len(resp.Matches)))}
+	detail := make(map[string]string, len(resp.Matches))
+	paths := make([]string, len(resp.Matches))
+	for i, m := range resp.Matches {
+		paths[i] = path.Clean(strings.ReplaceAll(m.File, `\`, "/"))
+		if len(m.Lines) > 0 {
+			nums := make([]string, len(m.Lines))
+			for j, n := range m.Lines {
+				nums[j] = fmt.Sprint(n)
+			}
+			d := "lines " + strings.Join(nums, ", ")
+			if m.Truncated {
+				d += ", …"
+			}
+			detail[paths[i]] = t.colorMuted(d)
+		}
+	}
+	for _, entry := range fileTree(paths) {
+		line := entry.prefix + entry.name
+		if d := detail[entry.path]; d != "" {
+			line += "  " + d
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// treeEntry is a line of fileTree: a directory or a file.
+type treeEntry struct {
+	prefix string // Box-drawing characters that place the entry in the tree.
+	name   string // Base name; directory names end in "/".
+	path   string // Full path of a file, or "" for a directory.
+}
+
+// treeNode is a directory, or a file when path is set.
+type treeNode struct {
+	children map[string]*treeNode
+	path     string
+}
+
+func (n *treeNode) child(name string) *treeNode {
+	c, ok := n.children[name]
+	if !ok {
+		c = &treeNode{children: map[string]*treeNode{}}
+		n.children[name] = c
+	}
+	return c
+}
+
+// fileTree lays paths out as a tree below their common directory, which is
+// the first entry. A directory whose only entry is a directory shares its
+// line, as in "internal/store/".
+func fileTree(paths []string) []treeEntry {
+	paths = slices.Clone(paths)
+	slices.Sort(paths)
+	common := path.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for common != "." && common != "/" && !strings.HasPrefix(p, common+"/") {
+			common = path.Dir(common)
+		}
+	}
+	root := &treeNode{children: map[string]*treeNode{}}
+	for _, p := range paths {
+		rel := p
+		if common != "." {
+			rel = strings.TrimPrefix(strings.TrimPrefix(p, common), "/")
+		}
+		n := root
+		for _, part := range strings.Split(rel, "/") {
+			n = n.child(part)
+		}
+		n.path = p
+	}
+
+	entries := []treeEntry{{name: strings.TrimSuffix(common, "/") + "/"}}
+	var walk func(n *treeNode, indent string)
+	walk = func(n *treeNode, indent string) {
+		names := slices.Sorted(maps.Keys(n.children))
+		for i, name := range names {
+			c := n.children[name]
+			branch, next := "├─ ", "│  "
+			if i == len(names)-1 {
+				branch, next = "└─ ", "   "
+			}
+			if len(c.children) == 0 {
+				entries = append(entries, treeEntry{prefix: indent + branch, name: name, path: c.path})
+				continue
+			}
+			for len(c.children) == 1 && c.path == "" {
+				only := slices.Collect(maps.Keys(c.children))[0]
+				if len(c.children[only].children) == 0 {
+					break
+				}
+				name, c = name+"/"+only, c.children[only]
+			}
+			entries = append(entries, treeEntry{prefix: indent + branch, name: name + "/"})
+			walk(c, indent+next)
+		}
+	}
+	walk(root, "")
+	return entries
+}
+
+func renderCalculate(t *TerminalUI, resp *tools.CalculateResponse) []string {
+	return []string{"= " + t.colorHighlight(fmt.Sprint(resp.Result))}
+}
+
+// renderGitClone shows the clone as a card with the path to use next.
+func renderGitClone(t *TerminalUI, resp *tools.GitCloneResponse) []string {
+	lines := []string{"┌ " + t.colorHighlight(resp.Path)}
+	if resp.Message != "" {
+		lines = append(lines, "│ "+resp.Message)
+	}
+	if resp.NextSteps != "" {
+		lines = append(lines, "│ "+t.colorMuted(firstLine(resp.NextSteps)))
+	}
+	return append(lines, "└")
+}
+
+func renderReadFile(t *TerminalUI, resp *tools.ReadFileResponse) []string {
+	return []string{t.colorMuted(fmt.Sprintf("lines %d–%d of %d (%d bytes)", resp.StartLine, resp.EndLine, resp.TotalLines, resp.FileSize))}
+}
+
+// renderRunGo shows whether the command passed and, when it failed, the end
+// of its output, where Go reports the failure.
+func renderRunGo(t *TerminalUI, resp *tools.RunGoResponse) []string {
+	if resp.Passed {
+		return []string{t.colorSuccess("passed: ") + resp.Command}
+	}
+	lines := []string{t.colorError("failed: ") + resp.Command}
+	output := strings.Split(strings.TrimRight(resp.Output, "\n"), "\n")
+	if len(output) > 8 {
+		output = output[len(output)-8:]
+	}
+	for _, line := range output {
+		if line != "" {
+			lines = append(lines, t.colorMuted(line))
+		}
+	}
+	return lines
+}
+
+func renderEditFile(t *TerminalUI, resp *tools.EditFileResponse) []string {
+	return []string{resp.Message}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
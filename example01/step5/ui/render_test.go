@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var ansi = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func TestRenderSearchFiles(t *testing.T) {
+	response := `{"matches":[
+		{"file":"repos/eino/compose/graph.go","lines":[42,97]},
+		{"file":"repos/eino/compose/chain.go"},
+		{"file":"repos/eino/internal/gmap/gmap.go","lines":[3],"truncated":true},
+		{"file":"repos/eino/schema/message.go"}]}`
+	got := ansi.ReplaceAllString(New().renderResult("search_files", response), "")
+	want := `   4 files
+   repos/eino/
+   ├─ compose/
+   │  ├─ chain.go
+   │  └─ graph.go  lines 42, 97
+   ├─ internal/gmap/
+   │  └─ gmap.go  lines 3, …
+   └─ schema/
+      └─ message.go
+`
+	if got != want {
+		t.Errorf("search_files rendered as\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderResult(t *testing.T) {
+	ui := New()
+	for _, tc := range []struct {
+		name, tool, response, want string
+	}{
+		{"calculator", "calculator", `{"result":42}`, "   = 42\n"},
+		{"clone card", "gitclone", `{"message":"Cloned eino","path":"repos/cloudwego/eino","next_steps":"Run repo_overview.\nThen search."}`,
+			"   ┌ repos/cloudwego/eino\n   │ Cloned eino\n   │ Run repo_overview.\n   └\n"},
+		{"error field", "search_files", `{"matches":null,"error":"path escapes the workspace"}`, "   error: path escapes the workspace\n"},
+		{"unknown tool", "env_info", `{"go":"1.25"}`, "   {\n     \"go\": \"1.25\"\n   }\n"},
+		{"cut short", "search_files", `{"matches":[{"file":"a.go"`, "   {\"matches\":[{\"file\":\"a.go\"\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ansi.ReplaceAllString(ui.renderResult(tc.tool, tc.response), ""); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	long := strings.Repeat("line\n", 50)
+	if lines := strings.Count(ui.renderResult("env_info", long), "\n"); lines != maxResultLines {
+		t.Errorf("a long result takes %d lines, want %d", lines, maxResultLines)
+	}
+}
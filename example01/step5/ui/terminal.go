@@ -3,20 +3,33 @@ package ui
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/completion"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"golang.org/x/term"
 )
 
 // TerminalUI handles all rendering and user interaction in the terminal.
 // It implements the callbacks.Handler interface to react to agent events.
 type TerminalUI struct {
 	scanner         *bufio.Scanner
+	completer       *completion.Completer
+	line            *term.Terminal // Line editor for interactive input, made on first use.
 	spinner         *Spinner
 	colorUser       func(a ...interface{}) string
 	colorBot        func(a ...interface{}) string
@@ -28,6 +41,14 @@ type TerminalUI struct {
 	colorHighlight  func(a ...interface{}) string
 	activeToolMutex sync.Mutex
 	activeToolName  string
+	activePanel     *outputPanel // Output of the active tool's commands.
+	verbose         bool         // Show tool results; guarded by activeToolMutex.
+
+	// Tool calls are previewed while the model is still generating them.
+	previewMutex sync.Mutex
+	previewing   bool
+	previewIndex int
+	previewDone  chan struct{} // Closed once the last model stream is previewed.
 }
 
 // Color helper functions using ANSI codes
@@ -83,19 +104,96 @@ func (t *TerminalUI) DisplayWelcome() {
 	fmt.Println(t.colorHighlight("╔" + border + "╗"))
 	fmt.Println(t.colorHighlight("║") + "       🤖 Expert Go Coding Agent - Powered by Eino          " + t.colorHighlight("║"))
 	fmt.Println(t.colorHighlight("╚" + border + "╝"))
-	fmt.Println(t.colorMuted("\nTools: File Search/Read/Edit, Web Search, Git Clone, RAG | Type 'exit' to quit."))
+	fmt.Println(t.colorMuted("\nTools: File Search/Read/Edit, Web Search, Git Clone, RAG | /attach <path> adds a file | Type 'exit' to quit."))
 	fmt.Println(t.colorMuted(strings.Repeat("─", 62)))
 }
 
-// GetUserInput prompts the user and returns their input.
+// SetCompleter turns on Tab completion of the input from c when the input
+// is a terminal.
+func (t *TerminalUI) SetCompleter(c *completion.Completer) {
+	t.completer = c
+}
+
+// GetUserInput prompts the user and returns their input. With a completer
+// and a terminal, the line can be edited, Tab completes the word before the
+// cursor, and the arrow keys recall earlier input.
 func (t *TerminalUI) GetUserInput() (string, bool) {
-	fmt.Printf("\n%s ", t.colorUser("You:"))
+	fmt.Println()
+	prompt := t.colorUser("You:") + " "
+	if fd := int(os.Stdin.Fd()); t.completer != nil && term.IsTerminal(fd) {
+		if state, err := term.MakeRaw(fd); err == nil {
+			defer term.Restore(fd, state)
+			if t.line == nil {
+				t.line = term.NewTerminal(struct {
+					io.Reader
+					io.Writer
+				}{os.Stdin, os.Stdout}, prompt)
+				t.line.AutoCompleteCallback = t.complete
+			}
+			input, err := t.line.ReadLine()
+			if err != nil && !errors.Is(err, term.ErrPasteIndicator) {
+				return "", false
+			}
+			return strings.TrimSpace(input), true
+		}
+	}
+	fmt.Print(prompt)
 	if !t.scanner.Scan() {
 		return "", false
 	}
 	return strings.TrimSpace(t.scanner.Text()), true
 }
 
+// complete completes the input line on Tab, listing the candidates when
+// they share nothing more than what was typed.
+func (t *TerminalUI) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	newLine, newPos, candidates := t.completer.Complete(line, pos)
+	if newLine == line && len(candidates) > 1 {
+		fmt.Fprintln(t.line, t.colorMuted(strings.Join(candidates, "  ")))
+	}
+	return newLine, newPos, true
+}
+
+// WaitForEnter shows prompt and blocks until the user presses Enter. It
+// reports false when input has ended.
+func (t *TerminalUI) WaitForEnter(prompt string) bool {
+	fmt.Print(t.colorMuted(prompt))
+	return t.scanner.Scan()
+}
+
+// Approve shows an action a tool is about to take and asks the user to
+// confirm it. It implements tools.Approver.
+func (t *TerminalUI) Approve(ctx context.Context, action, details string) (bool, error) {
+	if t.spinner.Active() {
+		message := t.spinner.Message()
+		t.spinner.Stop("\n")
+		defer t.spinner.Start(message)
+	}
+
+	fmt.Println(t.colorHighlight("   The agent wants to " + action + ":"))
+	for _, line := range strings.Split(details, "\n") {
+		fmt.Println(t.colorMuted("   │ ") + line)
+	}
+	fmt.Print(t.colorUser("   Approve? [y/N] "))
+	if !t.scanner.Scan() {
+		return false, errors.New("input ended before the action was approved")
+	}
+	switch strings.ToLower(strings.TrimSpace(t.scanner.Text())) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// DisplayTranscript shows what was heard of a spoken question, in place of
+// the typed input.
+func (t *TerminalUI) DisplayTranscript(text string) {
+	fmt.Printf("%s %s\n", t.colorUser("You (voice):"), text)
+}
+
 // DisplayBotPrompt shows the bot's name before it starts streaming.
 func (t *TerminalUI) DisplayBotPrompt() {
 	fmt.Printf("\n%s ", t.colorBot("Bot:"))
@@ -111,21 +209,75 @@ func (t *TerminalUI) DisplayStreamChunk(chunk string) {
 	fmt.Print(chunk)
 }
 
+// DisplayToolCallDelta previews tool calls as the model streams them: the
+// tool name once a call starts, then its arguments as they arrive, muted.
+func (t *TerminalUI) DisplayToolCallDelta(calls []schema.ToolCall) {
+	t.previewMutex.Lock()
+	defer t.previewMutex.Unlock()
+
+	for _, call := range calls {
+		index := 0
+		if call.Index != nil {
+			index = *call.Index
+		}
+		if call.Function.Name != "" && (!t.previewing || index != t.previewIndex) {
+			if t.previewing {
+				fmt.Print(t.colorMuted(")"))
+			}
+			fmt.Printf("\n %s %s", getToolIcon(call.Function.Name), t.colorMuted(call.Function.Name+"("))
+			t.previewing, t.previewIndex = true, index
+		}
+		if t.previewing && call.Function.Arguments != "" {
+			fmt.Print(t.colorMuted(call.Function.Arguments))
+		}
+	}
+}
+
+// EndToolCallPreview closes the line of a tool call preview, if one is open,
+// so the tool's spinner starts on a line of its own.
+func (t *TerminalUI) EndToolCallPreview() {
+	t.previewMutex.Lock()
+	defer t.previewMutex.Unlock()
+
+	if t.previewing {
+		fmt.Println(t.colorMuted(")"))
+		t.previewing = false
+	}
+}
+
 // DisplayError prints a formatted error message.
 func (t *TerminalUI) DisplayError(err error) {
 	fmt.Printf("\n%s %v\n", t.colorError("Error:"), err)
 }
 
+// DisplayNotice prints a muted hint, such as how to recover from an error.
+func (t *TerminalUI) DisplayNotice(msg string) {
+	fmt.Println(t.colorMuted(msg))
+}
+
 // OnStartFn is called when a component (like a tool) starts.
 func (t *TerminalUI) OnStartFn(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
 	if info.Component == "Tool" {
+		// Let the preview of the call finish printing first.
+		t.previewMutex.Lock()
+		done := t.previewDone
+		t.previewMutex.Unlock()
+		if done != nil {
+			<-done
+		}
+
 		t.activeToolMutex.Lock()
 		defer t.activeToolMutex.Unlock()
 
 		t.activeToolName = info.Name
+		t.activePanel = &outputPanel{ui: t}
 		icon := getToolIcon(info.Name)
 		msg := fmt.Sprintf(" %s %s", icon, t.colorTool(info.Name))
 		go t.spinner.Start(msg)
+
+		// Commands the tool runs stream their output into the panel, and
+		// actions such as sending an email are put to the user first.
+		return tools.WithApprover(tools.WithOutput(ctx, t.activePanel), t)
 	}
 	return ctx
 }
@@ -138,8 +290,12 @@ func (t *TerminalUI) OnEndFn(ctx context.Context, info *callbacks.RunInfo, outpu
 
 		if info.Name == t.activeToolName {
 			t.spinner.Stop(t.colorSuccess("✓\n"))
+			t.closePanel()
 			t.activeToolName = ""
 		}
+		if out := tool.ConvCallbackOutput(output); t.verbose && out != nil {
+			fmt.Print(t.renderResult(info.Name, out.Response))
+		}
 	}
 	return ctx
 }
@@ -152,11 +308,122 @@ func (t *TerminalUI) OnErrorFn(ctx context.Context, info *callbacks.RunInfo, err
 
 		if info.Name == t.activeToolName {
 			t.spinner.Stop(t.colorError("✗\n"))
+			t.closePanel()
 			t.activeToolName = ""
-			// Optionally print the specific error for debugging
-			// fmt.Printf("%s\n", t.colorMuted(err.Error()))
 		}
+		if t.verbose {
+			fmt.Printf("   %s%v\n", t.colorError("error: "), err)
+		}
+	}
+	return ctx
+}
+
+// closePanel collapses the active tool's output panel to a line count. The
+// caller holds activeToolMutex.
+func (t *TerminalUI) closePanel() {
+	if t.activePanel == nil {
+		return
+	}
+	if lines := t.activePanel.lineCount(); lines > 0 {
+		fmt.Println(t.colorMuted(fmt.Sprintf("   └ %d lines of output", lines)))
+	}
+	t.activePanel = nil
+}
+
+// maxPanelWidth is the number of characters of output shown beside the
+// spinner.
+const maxPanelWidth = 60
+
+// outputPanel receives the output of the commands a tool runs (see
+// tools.WithOutput) and shows it collapsed to its latest line beside the
+// tool's spinner, so long builds and test runs show progress.
+type outputPanel struct {
+	ui      *TerminalUI
+	mu      sync.Mutex
+	partial []byte // Output after the last newline.
+	lines   int
+}
+
+func (p *outputPanel) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.partial = append(p.partial, b...)
+	var last string
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if line := panelLine(string(p.partial[:i])); line != "" {
+			last = line
+		}
+		p.partial = p.partial[i+1:]
+		p.lines++
+	}
+	if last != "" && p.active() {
+		p.ui.spinner.SetDetail(p.ui.colorMuted(last))
+	}
+	return len(b), nil
+}
+
+func (p *outputPanel) active() bool {
+	p.ui.activeToolMutex.Lock()
+	defer p.ui.activeToolMutex.Unlock()
+	return p.ui.activePanel == p
+}
+
+func (p *outputPanel) lineCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lines
+}
+
+// panelLine makes a line of command output fit on the spinner's line: control
+// characters are dropped and long lines cut short.
+func panelLine(line string) string {
+	line = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return ' '
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, line))
+	if r := []rune(line); len(r) > maxPanelWidth {
+		line = string(r[:maxPanelWidth-1]) + "…"
+	}
+	return line
+}
+
+// OnEndWithStreamOutputFn previews the tool calls in a streamed model
+// response. The ReAct agent routes those responses to its tools, so they
+// never reach the agent's output stream; this is the only place they are
+// previewed.
+func (t *TerminalUI) OnEndWithStreamOutputFn(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+	if info.Component != components.ComponentOfChatModel {
+		output.Close()
+		return ctx
 	}
+	done := make(chan struct{})
+	t.previewMutex.Lock()
+	t.previewDone = done
+	t.previewMutex.Unlock()
+	go func() {
+		defer close(done)
+		defer output.Close()
+		for {
+			chunk, err := output.Recv()
+			if err != nil {
+				break
+			}
+			if out := model.ConvCallbackOutput(chunk); out != nil && out.Message != nil && len(out.Message.ToolCalls) > 0 {
+				t.DisplayToolCallDelta(out.Message.ToolCalls)
+			}
+		}
+		t.EndToolCallPreview()
+	}()
 	return ctx
 }
 
@@ -166,6 +433,7 @@ func (t *TerminalUI) Build() callbacks.Handler {
 	builder.OnStartFn(t.OnStartFn)
 	builder.OnEndFn(t.OnEndFn)
 	builder.OnErrorFn(t.OnErrorFn)
+	builder.OnEndWithStreamOutputFn(t.OnEndWithStreamOutputFn)
 	return builder.Build()
 }
 
@@ -175,14 +443,54 @@ func getToolIcon(toolName string) string {
 		return "🔍"
 	case "read_file":
 		return "📖"
-	case "edit_go_file":
+	case "edit_go_file", "apply_changeset", "rename_symbol", "fix_dependencies":
 		return "✏️"
+	case "scaffold_project":
+		return "🏗️"
+	case "run_go":
+		return "▶️"
+	case "profile_program":
+		return "⏱️"
+	case "scan_vulnerabilities":
+		return "🛡️"
+	case "license_audit":
+		return "⚖️"
+	case "suggest_commit_message":
+		return "📝"
+	case "list_conflicts":
+		return "🔀"
+	case "resolve_conflict":
+		return "🩹"
+	case "graph_query":
+		return "🕸️"
+	case "test_regex":
+		return "🧪"
+	case "query_files":
+		return "🧮"
 	case "search_internet", "tavily_search_results_json":
 		return "🌐"
 	case "gitclone":
 		return "📥"
+	case "analyze_repos":
+		return "⚖️"
+	case "repo_overview":
+		return "🗺️"
+	case "review_changes":
+		return "🔎"
+	case "summarize_module":
+		return "🧭"
+	case "env_info":
+		return "🖥️"
+	case "pin_fact":
+		return "📌"
+	case "translate":
+		return "🌍"
+	case "send_email":
+		return "✉️"
 	case "rag_tool":
 		return "📚"
+	case "search_past_conversations":
+		return "🗂️"
 	default:
 		return "🛠️"
 	}
@@ -195,7 +503,13 @@ type Spinner struct {
 	ticker   *time.Ticker
 	stopChan chan bool
 	isActive bool
+	message  string
 	mu       sync.Mutex
+
+	// The detail shown after the spinner has a lock of its own, since Stop
+	// holds mu until the spinning goroutine returns.
+	detailMu sync.Mutex
+	detail   string
 }
 
 func NewSpinner(d time.Duration) *Spinner {
@@ -212,6 +526,7 @@ func (s *Spinner) Start(message string) {
 		return
 	}
 	s.isActive = true
+	s.message = message
 	s.mu.Unlock()
 
 	go func() {
@@ -222,7 +537,11 @@ func (s *Spinner) Start(message string) {
 			case <-s.stopChan:
 				return
 			case <-s.ticker.C:
-				fmt.Printf("\r%s%s ", message, string(frames[i%len(frames)]))
+				if detail := s.getDetail(); detail != "" {
+					fmt.Printf("\r%s%s %s\033[K", message, string(frames[i%len(frames)]), detail)
+				} else {
+					fmt.Printf("\r%s%s ", message, string(frames[i%len(frames)]))
+				}
 				i++
 			}
 		}
@@ -238,5 +557,37 @@ func (s *Spinner) Stop(finalMessage string) {
 	s.stopChan <- true
 	s.isActive = false
 	s.mu.Unlock()
+	if s.getDetail() != "" {
+		// Clear the detail before the final message overwrites the line.
+		fmt.Printf("\r\033[K%s", s.message)
+		s.SetDetail("")
+	}
 	fmt.Printf("\r%s", finalMessage)
 }
+
+// Active reports whether the spinner is running.
+func (s *Spinner) Active() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isActive
+}
+
+// Message returns the message the spinner was last started with.
+func (s *Spinner) Message() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.message
+}
+
+// SetDetail shows detail after the spinner until the next call or Stop.
+func (s *Spinner) SetDetail(detail string) {
+	s.detailMu.Lock()
+	defer s.detailMu.Unlock()
+	s.detail = detail
+}
+
+func (s *Spinner) getDetail() string {
+	s.detailMu.Lock()
+	defer s.detailMu.Unlock()
+	return s.detail
+}
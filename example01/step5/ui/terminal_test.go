@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// captureStdout returns what f prints to standard output.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	f()
+	w.Close()
+	return <-out
+}
+
+func TestToolCallPreview(t *testing.T) {
+	ui := New()
+	ui.colorMuted = func(a ...interface{}) string { return fmt.Sprint(a...) }
+	first, second := 0, 1
+	deltas := []schema.ToolCall{
+		{Index: &first, Function: schema.FunctionCall{Name: "read_file"}},
+		{Index: &first, Function: schema.FunctionCall{Arguments: `{"path":`}},
+		{Index: &first, Function: schema.FunctionCall{Arguments: `"go.mod"}`}},
+		{Index: &second, Function: schema.FunctionCall{Name: "search_files", Arguments: `{"query":"main"}`}},
+	}
+	var chunks []callbacks.CallbackOutput
+	for _, d := range deltas {
+		chunks = append(chunks, &model.CallbackOutput{Message: &schema.Message{Role: schema.Assistant, ToolCalls: []schema.ToolCall{d}}})
+	}
+
+	got := captureStdout(t, func() {
+		info := &callbacks.RunInfo{Component: components.ComponentOfChatModel}
+		ui.OnEndWithStreamOutputFn(context.Background(), info, schema.StreamReaderFromArray(chunks))
+		// A tool starting waits for the preview of its call to be printed.
+		ui.previewMutex.Lock()
+		done := ui.previewDone
+		ui.previewMutex.Unlock()
+		<-done
+	})
+	want := "\n " + getToolIcon("read_file") + ` read_file({"path":"go.mod"})` +
+		"\n " + getToolIcon("search_files") + ` search_files({"query":"main"})` + "\n"
+	if got != want {
+		t.Errorf("preview = %q, want %q", got, want)
+	}
+}
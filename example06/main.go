@@ -0,0 +1,172 @@
+// Command example06 deploys the agent as a production service. Where
+// example01 stops at an interactive terminal, this program wires the pieces a
+// shared deployment needs: configuration from the environment and a mounted
+// config file, the model registry, a tool policy, a persistent session store,
+// token quotas, logs and traces, and the HTTP server with its health checks.
+//
+// Build and run it with the Dockerfile next to this file:
+//
+//	docker build -f example06/Dockerfile -t goforai-server .
+//	docker run -p 8080:8080 -v goforai-data:/app/data \
+//		-v $PWD/example06/production.env:/app/config.env goforai-server
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os/signal"
+	"syscall"
+
+	"github.com/olusolaa/goforai/example01/step5/agent"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/indexing"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/policy"
+	"github.com/olusolaa/goforai/foundation/secrets"
+	"github.com/olusolaa/goforai/foundation/server"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/olusolaa/goforai/foundation/telemetry"
+	"github.com/olusolaa/goforai/foundation/usage"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatalf("❌ Server failed: %v", err)
+	}
+}
+
+// run wires the service together. Every setting comes from the environment,
+// the way container platforms pass it, with the config file filling in what
+// the environment leaves unset.
+func run() error {
+	configFile := flag.String("config", "config.env", "file of KEY=value settings; the environment overrides it")
+	addr := flag.String("addr", "0.0.0.0:8080", "listen address; non-loopback addresses require SERVER_API_KEYS")
+	flag.Parse()
+
+	// 1. Configuration. Load every section up front so a typo in the
+	// deployment fails the rollout instead of the first request.
+	if err := config.LoadEnvFile(*configFile); err != nil {
+		return err
+	}
+	serverCfg, err := config.LoadServer()
+	if err != nil {
+		return err
+	}
+	sessionCfg, err := config.LoadSessions()
+	if err != nil {
+		return err
+	}
+	streamingCfg, err := config.LoadStreaming()
+	if err != nil {
+		return err
+	}
+	heartbeatCfg, err := config.LoadHeartbeat()
+	if err != nil {
+		return err
+	}
+	if _, err := secrets.Get(secrets.GeminiAPIKey); err != nil {
+		return err
+	}
+
+	// 2. Observability. Logs are JSON on stderr when LOG_FORMAT=json, and
+	// traces go to OTEL_EXPORTER_OTLP_ENDPOINT when it is set.
+	appLogger, closeLog, err := logger.New(config.LoadLogging())
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	slog.SetDefault(appLogger)
+	ctx := logger.WithContext(context.Background(), appLogger)
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := telemetry.Setup(ctx, "goforai-server")
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	// 3. Model registry. Costs and context limits come from it; a model it
+	// does not know still works, but its spend is recorded without a price.
+	chatModel := gemini.ChatModel()
+	if caps, ok := models.Lookup(chatModel); ok {
+		appLogger.Info("chat model", "model", chatModel, "context_window", caps.ContextWindow,
+			"input_price", caps.InputPrice, "output_price", caps.OutputPrice)
+	} else {
+		appLogger.Warn("chat model is not in the model registry; its cost is not tracked", "model", chatModel, "known", models.Names())
+	}
+
+	// 4. Token quotas. Every model call is recorded in USAGE_PATH, and calls
+	// are refused once USAGE_QUOTA_DAILY or USAGE_QUOTA_MONTHLY is spent.
+	if err := usage.Setup(); err != nil {
+		return err
+	}
+
+	// 5. Tool policy. A shared deployment must say what the agent's tools may
+	// touch, so the service refuses to start without a rules file. The agent
+	// loads the same file for every tool call.
+	toolPolicy, err := policy.Load(config.PolicyPath())
+	if err != nil {
+		return err
+	}
+	if toolPolicy == nil {
+		return fmt.Errorf("no tool policy at %s: set POLICY_FILE (see example06/policy.json)", config.PolicyPath())
+	}
+	appLogger.Info("tool policy loaded", "path", config.PolicyPath(), "rules", len(toolPolicy.Rules), "default", toolPolicy.Default)
+
+	// 6. The agent and the session store its conversations are kept in.
+	runner, err := agent.NewRunner(ctx)
+	if err != nil {
+		return err
+	}
+	defer runner.Close(context.Background())
+
+	if sessionCfg.Store == config.SessionStoreMemory {
+		appLogger.Warn("sessions are kept in memory and lost on restart; set SESSION_STORE=sqlite or redis")
+	}
+	store, err := session.NewStore(ctx, sessionCfg)
+	if err != nil {
+		return fmt.Errorf("failed to open session store: %w", err)
+	}
+	defer store.Close()
+
+	// 7. The server. /healthz reports it is up; /readyz also checks the
+	// model and the knowledge base, for the load balancer to route on.
+	appLogger.Info("serving", "addr", *addr, "sessions", sessionCfg.Store)
+	return server.New(runner,
+		server.WithAddr(*addr),
+		server.WithSessions(session.NewManager(store, sessionCfg)),
+		server.WithAPIKeys(serverCfg.APIKeys),
+		server.WithGroups(serverCfg.Groups),
+		server.WithRateLimit(serverCfg.RequestsPerMinute),
+		server.WithTokenBudget(serverCfg.DailyTokenBudget),
+		server.WithBudgetAlertThreshold(serverCfg.BudgetAlert),
+		server.WithEvents(events.NewDispatcherFromEnv()),
+		server.WithMaxRequestBytes(serverCfg.MaxRequestBytes),
+		server.WithStreaming(streamingCfg),
+		server.WithHeartbeat(heartbeatCfg),
+		server.WithShutdownTimeout(serverCfg.ShutdownTimeout),
+		server.WithReadinessCheck("model", gemini.Ping),
+		server.WithReadinessCheck("knowledge_base", knowledgeBaseCheck(indexing.DefaultDBPath)),
+	).Run(ctx)
+}
+
+// knowledgeBaseCheck fails when the index at path is unreadable. A missing
+// index is healthy: the agent runs without its knowledge base tool then.
+func knowledgeBaseCheck(path string) server.Check {
+	return func(ctx context.Context) error {
+		_, err := chromemdb.ReadManifest(path)
+		if errors.Is(err, chromemdb.ErrDBNotFound) {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,320 @@
+// Package answercache keeps the answers the agent served and serves them
+// again for near-identical questions, so an FAQ-style deployment pays for
+// each question once rather than on every asking.
+//
+// Questions are matched by the cosine similarity of their embeddings, and
+// only against answers given from the same version of the knowledge base:
+// once the knowledge base is indexed again, its cached answers no longer
+// match and are dropped. Answers are kept in a JSON file so they survive
+// restarts.
+package answercache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/embeddings"
+)
+
+// Entry is a cached answer.
+type Entry struct {
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	Scope     string    `json:"scope,omitempty"` // Who the answer may be served to; "" is everyone.
+	KBVersion string    `json:"kb_version"`      // Of the knowledge base the answer was given from.
+	Vector    []float32 `json:"vector"`          // Normalized embedding of the question.
+	CreatedAt time.Time `json:"created_at"`
+	Hits      int       `json:"hits"` // Times the answer was served again.
+}
+
+// Hit is a cached answer to a question.
+type Hit struct {
+	Entry
+	Score float64 // Similarity of the question to the cached one.
+}
+
+// Query is a question prepared for Lookup and Store.
+type Query struct {
+	Question  string
+	Scope     string
+	kbVersion string
+	vector    []float32
+}
+
+// file is the layout of the cache file.
+type file struct {
+	EmbeddingModel string   `json:"embedding_model"`
+	Entries        []*Entry `json:"entries"`
+}
+
+// Cache is a semantic cache of answers. It is safe for concurrent use.
+type Cache struct {
+	path           string
+	embedder       embedding.Embedder
+	embeddingModel string
+	kbPath         string
+	minScore       float64
+	maxEntries     int
+
+	mu      sync.Mutex
+	entries []*Entry
+	loaded  bool
+}
+
+// Option configures a Cache.
+type Option func(*Cache)
+
+// WithKnowledgeBase sets the exported knowledge base whose version answers
+// are tied to. Without it every answer is kept until it is evicted.
+func WithKnowledgeBase(path string) Option {
+	return func(c *Cache) {
+		c.kbPath = path
+	}
+}
+
+// WithMinScore sets the similarity a question needs to a cached one.
+// Defaults to config.DefaultSemanticCacheMinScore.
+func WithMinScore(score float64) Option {
+	return func(c *Cache) {
+		c.minScore = score
+	}
+}
+
+// WithMaxEntries caps the answers kept; the oldest are dropped first.
+// Defaults to 1000.
+func WithMaxEntries(n int) Option {
+	return func(c *Cache) {
+		c.maxEntries = n
+	}
+}
+
+// New returns the cache stored at path, whose questions are embedded by
+// embedder, a model named embeddingModel. Answers embedded by another model
+// are discarded on load.
+func New(path string, embedder embedding.Embedder, embeddingModel string, opts ...Option) *Cache {
+	c := &Cache{
+		path:           path,
+		embedder:       embedder,
+		embeddingModel: embeddingModel,
+		minScore:       config.DefaultSemanticCacheMinScore,
+		maxEntries:     1000,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewFromEnv returns the cache config.LoadSemanticCache describes, tied to
+// the knowledge base exported to kbPath and embedded with the configured
+// embedder, or nil when SEMANTIC_CACHE is off.
+func NewFromEnv(ctx context.Context, kbPath string) (*Cache, error) {
+	cfg, err := config.LoadSemanticCache()
+	if err != nil || !cfg.Enabled {
+		return nil, err
+	}
+	embedCfg, err := config.LoadEmbeddings()
+	if err != nil {
+		return nil, err
+	}
+	embedder, model, err := embeddings.New(ctx, embedCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+	return New(cfg.Path, embedder, model,
+		WithKnowledgeBase(kbPath),
+		WithMinScore(cfg.MinScore),
+		WithMaxEntries(cfg.MaxEntries),
+	), nil
+}
+
+// Prepare embeds question, asked by someone allowed to see the answers of
+// scope, and notes the knowledge base version it is answered from.
+func (c *Cache) Prepare(ctx context.Context, question, scope string) (*Query, error) {
+	question = strings.Join(strings.Fields(question), " ")
+	if question == "" {
+		return nil, errors.New("question cannot be empty")
+	}
+	version, err := c.kbVersion()
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := c.embedder.EmbedStrings(ctx, []string{question})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed question: %w", err)
+	}
+	if len(vectors) != 1 || len(vectors[0]) == 0 {
+		return nil, errors.New("failed to embed question: the embedder returned no vector")
+	}
+	return &Query{Question: question, Scope: scope, kbVersion: version, vector: normalize(vectors[0])}, nil
+}
+
+// Lookup returns the cached answer to the question most similar to q's, or
+// nil when none is similar enough.
+func (c *Cache) Lookup(q *Query) (*Hit, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	var best *Entry
+	var bestScore float64
+	for _, e := range c.entries {
+		if e.Scope != q.Scope || e.KBVersion != q.kbVersion || len(e.Vector) != len(q.vector) {
+			continue
+		}
+		if score := dot(e.Vector, q.vector); score >= c.minScore && (best == nil || score > bestScore) {
+			best, bestScore = e, score
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	// Hits are saved with the next Store.
+	best.Hits++
+	return &Hit{Entry: *best, Score: bestScore}, nil
+}
+
+// Store caches answer to q. Answers given from an older version of the
+// knowledge base are dropped, and the oldest answers beyond the cap.
+func (c *Cache) Store(q *Query, answer string) error {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.load(); err != nil {
+		return err
+	}
+	kept := c.entries[:0]
+	for _, e := range c.entries {
+		if e.KBVersion == q.kbVersion && (e.Question != q.Question || e.Scope != q.Scope) {
+			kept = append(kept, e)
+		}
+	}
+	kept = append(kept, &Entry{
+		Question:  q.Question,
+		Answer:    answer,
+		Scope:     q.Scope,
+		KBVersion: q.kbVersion,
+		Vector:    q.vector,
+		CreatedAt: time.Now().UTC(),
+	})
+	if over := len(kept) - c.maxEntries; over > 0 {
+		kept = kept[over:]
+	}
+	c.entries = kept
+	return c.save()
+}
+
+// load reads the cache file the first time it is needed.
+func (c *Cache) load() error {
+	if c.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(c.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("failed to read answer cache: %w", err)
+	default:
+		var f file
+		if err := json.Unmarshal(data, &f); err != nil {
+			return fmt.Errorf("failed to read answer cache %s: %w", c.path, err)
+		}
+		if f.EmbeddingModel == c.embeddingModel {
+			c.entries = f.Entries
+		}
+	}
+	c.loaded = true
+	return nil
+}
+
+// save writes the cache file, replacing it whole.
+func (c *Cache) save() error {
+	data, err := json.Marshal(file{EmbeddingModel: c.embeddingModel, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to save answer cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".answer-cache-*")
+	if err != nil {
+		return fmt.Errorf("failed to save answer cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save answer cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save answer cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to save answer cache: %w", err)
+	}
+	return nil
+}
+
+// kbVersion identifies the exported knowledge base by when it was built, or
+// returns "" when there is none.
+func (c *Cache) kbVersion() (string, error) {
+	if c.kbPath == "" {
+		return "", nil
+	}
+	manifest, err := chromemdb.ReadManifest(c.kbPath)
+	if errors.Is(err, chromemdb.ErrDBNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if manifest != nil {
+		return manifest.CreatedAt.UTC().Format(time.RFC3339Nano), nil
+	}
+	// Legacy exports have no manifest; their modification time tells
+	// versions apart.
+	info, err := os.Stat(c.kbPath)
+	if err != nil {
+		return "", err
+	}
+	return info.ModTime().UTC().Format(time.RFC3339Nano), nil
+}
+
+// normalize returns v scaled to unit length, so that the dot product of two
+// normalized vectors is their cosine similarity.
+func normalize(v []float64) []float32 {
+	var norm float64
+	for _, f := range v {
+		norm += f * f
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float32, len(v))
+	for i, f := range v {
+		if norm > 0 {
+			out[i] = float32(f / norm)
+		}
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
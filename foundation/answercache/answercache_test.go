@@ -0,0 +1,114 @@
+package answercache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+	"github.com/philippgille/chromem-go"
+)
+
+// wordEmbedder embeds texts as the fake Gemini API does: a bag of words, so
+// questions differing only in case and spacing embed alike.
+type wordEmbedder struct{}
+
+func (wordEmbedder) EmbedStrings(_ context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = fakeapi.Embed(text)
+	}
+	return vectors, nil
+}
+
+func exportKB(t *testing.T, path string, createdAt time.Time) {
+	t.Helper()
+	if err := chromemdb.ExportDB(chromem.NewDB(), path, &chromemdb.Manifest{SchemaVersion: chromemdb.SchemaVersion, CreatedAt: createdAt}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lookup(t *testing.T, c *Cache, question, scope string) *Hit {
+	t.Helper()
+	q, err := c.Prepare(context.Background(), question, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hit, err := c.Lookup(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hit
+}
+
+func store(t *testing.T, c *Cache, question, scope, answer string) {
+	t.Helper()
+	q, err := c.Prepare(context.Background(), question, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Store(q, answer); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	kb, path := filepath.Join(dir, "kb.gob"), filepath.Join(dir, "answers.json")
+	exportKB(t, kb, time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC))
+	c := New(path, wordEmbedder{}, "fake", WithKnowledgeBase(kb))
+
+	if hit := lookup(t, c, "When is the keynote?", ""); hit != nil {
+		t.Fatalf("empty cache answered %+v", hit)
+	}
+	store(t, c, "When is the keynote?", "", "At 9am.")
+
+	hit := lookup(t, c, "  when is the   KEYNOTE? ", "")
+	if hit == nil || hit.Answer != "At 9am." || hit.Score < 0.99 {
+		t.Fatalf("near-identical question got %+v, want the cached answer", hit)
+	}
+	if hit := lookup(t, c, "Who gives the keynote?", ""); hit != nil {
+		t.Errorf("different question answered %+v", hit)
+	}
+	if hit := lookup(t, c, "When is the keynote?", "alice"); hit != nil {
+		t.Errorf("question from another scope answered %+v", hit)
+	}
+
+	// Answers survive restarts, but not a different embedding model.
+	if hit := lookup(t, New(path, wordEmbedder{}, "fake", WithKnowledgeBase(kb)), "When is the keynote?", ""); hit == nil {
+		t.Error("reopened cache lost its answer")
+	}
+	if hit := lookup(t, New(path, wordEmbedder{}, "other", WithKnowledgeBase(kb)), "When is the keynote?", ""); hit != nil {
+		t.Errorf("cache of another embedding model answered %+v", hit)
+	}
+
+	// Indexing the knowledge base again retires its answers.
+	exportKB(t, kb, time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC))
+	if hit := lookup(t, c, "When is the keynote?", ""); hit != nil {
+		t.Errorf("answer from an older knowledge base served %+v", hit)
+	}
+	store(t, c, "Where is the venue?", "", "In Berlin.")
+	c = New(path, wordEmbedder{}, "fake", WithKnowledgeBase(kb))
+	if err := c.load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.entries) != 1 || c.entries[0].Question != "Where is the venue?" {
+		t.Errorf("entries = %+v, want only the answer from the current knowledge base", c.entries)
+	}
+}
+
+func TestCacheEvictsOldest(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "answers.json"), wordEmbedder{}, "fake", WithMaxEntries(2))
+	store(t, c, "first question", "", "1")
+	store(t, c, "second question", "", "2")
+	store(t, c, "third question", "", "3")
+	if hit := lookup(t, c, "first question", ""); hit != nil {
+		t.Errorf("evicted answer served %+v", hit)
+	}
+	if hit := lookup(t, c, "third question", ""); hit == nil || hit.Answer != "3" {
+		t.Errorf("newest answer = %+v, want 3", hit)
+	}
+}
@@ -0,0 +1,227 @@
+// Package audit keeps an append-only JSON Lines record of every tool call the
+// agent makes: when, in which session, with which (redacted) arguments, what
+// came back, how long it took and whether the call was allowed, so operators
+// can review exactly what an autonomous run touched.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/forward"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// Decisions recorded for a tool call.
+const (
+	Allowed  = "allowed"  // The tool ran, successfully or not.
+	Denied   = "denied"   // A tool or the policy refused the call.
+	Canceled = "canceled" // The turn was interrupted while the tool ran.
+)
+
+// Limits on what an entry keeps of a call.
+const (
+	maxArgumentBytes = 256
+	maxResultBytes   = 200
+)
+
+// Entry is one line of the audit log.
+type Entry struct {
+	Time       time.Time       `json:"time"`
+	SessionID  string          `json:"session_id,omitempty"`
+	Tool       string          `json:"tool"`
+	Arguments  json.RawMessage `json:"arguments"`
+	Result     string          `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMS int64           `json:"duration_ms"`
+	Decision   string          `json:"decision"`
+}
+
+// Log appends entries to a file. A nil *Log is valid and records nothing, so
+// callers need no nil checks.
+type Log struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// Open opens the audit log at path for appending, creating it if needed. An
+// empty path disables auditing and returns a nil *Log.
+func Open(path string) (*Log, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	return &Log{f: f}, nil
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+// Record appends e to the log as one line.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+type sessionKey struct{}
+
+// WithSession attributes the tool calls made with the returned context to the
+// session id.
+func WithSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+func sessionID(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
+
+// Wrap returns t with every invocation recorded in l. Tools that are not
+// invokable, and every tool when l is nil, are returned unchanged.
+func (l *Log) Wrap(t tool.BaseTool) tool.BaseTool {
+	inner, ok := t.(tool.InvokableTool)
+	if l == nil || !ok {
+		return t
+	}
+	return &auditedTool{Tool: forward.Tool{InvokableTool: inner}, log: l}
+}
+
+type auditedTool struct {
+	forward.Tool
+	log *Log
+}
+
+func (t *auditedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	start := time.Now()
+	out, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+
+	entry := Entry{
+		Time:       start.UTC(),
+		SessionID:  sessionID(ctx),
+		Arguments:  redact(argumentsInJSON),
+		Result:     summarize(out),
+		DurationMS: time.Since(start).Milliseconds(),
+		Decision:   Allowed,
+	}
+	if info, infoErr := t.Info(ctx); infoErr == nil {
+		entry.Tool = info.Name
+	}
+	switch {
+	case errors.Is(err, tools.ErrToolDenied):
+		entry.Decision = Denied
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		entry.Decision = Canceled
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else if msg, denied := resultError(out); denied {
+		entry.Error, entry.Decision = msg, Denied
+	} else {
+		entry.Error = msg
+	}
+	if recErr := t.log.Record(entry); recErr != nil {
+		logger.FromContext(ctx).Warn("failed to record tool call", "tool", entry.Tool, "error", recErr)
+	}
+	return out, err
+}
+
+// sensitiveKey matches argument names whose values are never logged.
+var sensitiveKey = regexp.MustCompile(`(?i)pass(word)?|secret|token|api_?key|auth|credential`)
+
+// redact returns the arguments of a call with sensitive values replaced and
+// long strings, such as file contents, shortened. Arguments that are not a
+// JSON value are kept as a shortened string.
+func redact(argumentsInJSON string) json.RawMessage {
+	var args any
+	if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+		data, _ := json.Marshal(shorten(argumentsInJSON, maxArgumentBytes))
+		return data
+	}
+	data, err := json.Marshal(redactValue(args))
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return data
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if sensitiveKey.MatchString(key) {
+				v[key] = "[REDACTED]"
+			} else {
+				v[key] = redactValue(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redactValue(value)
+		}
+	case string:
+		return shorten(v, maxArgumentBytes)
+	}
+	return v
+}
+
+// summarize returns the start of a tool result on one line.
+func summarize(out string) string {
+	return shorten(strings.Join(strings.Fields(out), " "), maxResultBytes)
+}
+
+// resultError returns the message of a failure foundation tools report in
+// their "error" field, and whether the result is the policy package's denial
+// of the call.
+func resultError(out string) (msg string, denied bool) {
+	var result struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal([]byte(out), &result) != nil {
+		return "", false
+	}
+	var failure tools.ToolError
+	denied = json.Unmarshal(result.Error, &failure) == nil && failure.Code == tools.FailureDenied
+	return events.ToolError(out), denied
+}
+
+// shorten cuts s to about limit bytes on a rune boundary and notes its length.
+func shorten(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s… (%d bytes)", s[:cut], len(s))
+}
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+type fetchRequest struct {
+	URL    string `json:"url"`
+	APIKey string `json:"api_key"`
+	Body   string `json:"body"`
+}
+
+type fetchResponse struct {
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func TestWrap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := utils.InferTool("fetch", "Fetch a URL.", func(ctx context.Context, req *fetchRequest) (*fetchResponse, error) {
+		switch req.URL {
+		case "denied":
+			return nil, tools.ErrWorkspaceViolation
+		case "canceled":
+			return nil, fmt.Errorf("tool call canceled: %w", context.Canceled)
+		case "missing":
+			return &fetchResponse{Error: "not found"}, nil
+		}
+		return &fetchResponse{Status: "ok"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped := log.Wrap(inner).(tool.InvokableTool)
+
+	ctx := WithSession(context.Background(), "s1")
+	long := strings.Repeat("x", 1000)
+	for _, url := range []string{"https://go.dev", "missing", "denied", "canceled"} {
+		args, _ := json.Marshal(fetchRequest{URL: url, APIKey: "sk-secret", Body: long})
+		wrapped.InvokableRun(ctx, string(args))
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-secret") {
+		t.Error("the audit log contains a secret")
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("%d entries, want 4", len(entries))
+	}
+
+	ok := entries[0]
+	if ok.Tool != "fetch" || ok.SessionID != "s1" || ok.Decision != Allowed || ok.Error != "" || ok.Result != `{"status":"ok"}` || ok.Time.IsZero() {
+		t.Errorf("entry = %+v", ok)
+	}
+	var args map[string]string
+	if err := json.Unmarshal(ok.Arguments, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args["url"] != "https://go.dev" || args["api_key"] != "[REDACTED]" || len(args["body"]) > maxArgumentBytes+20 {
+		t.Errorf("arguments = %v", args)
+	}
+	for i, want := range []struct{ decision, err string }{
+		{Allowed, "not found"},
+		{Denied, "outside the session workspace"},
+		{Canceled, "canceled"},
+	} {
+		if e := entries[i+1]; e.Decision != want.decision || !strings.Contains(e.Error, want.err) {
+			t.Errorf("entry %d = %s %q, want %s %q", i+1, e.Decision, e.Error, want.decision, want.err)
+		}
+	}
+}
+
+func TestResultError(t *testing.T) {
+	if msg, denied := resultError(`{"error":{"code":"denied","message":"not allowed","retryable":false},"hint":{}}`); msg != "not allowed" || !denied {
+		t.Errorf("policy denial = %q, %v", msg, denied)
+	}
+	if msg, denied := resultError(`{"error":"not found"}`); msg != "not found" || denied {
+		t.Errorf("tool failure = %q, %v", msg, denied)
+	}
+	if msg, denied := resultError(`{"error":{"code":"not_found","message":"not found","retryable":false},"hint":{}}`); msg != "not found" || denied {
+		t.Errorf("guided tool failure = %q, %v", msg, denied)
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	log, err := Open("")
+	if log != nil || err != nil {
+		t.Fatalf("Open(\"\") = %v, %v", log, err)
+	}
+	inner, _ := utils.InferTool("noop", "No-op.", func(ctx context.Context, req *fetchRequest) (*fetchResponse, error) {
+		return &fetchResponse{}, nil
+	})
+	if log.Wrap(inner) != inner || log.Close() != nil {
+		t.Error("a nil log wraps tools")
+	}
+}
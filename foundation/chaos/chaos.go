@@ -0,0 +1,148 @@
+// Package chaos injects failures into model and tool calls, for testing how
+// the agent recovers from them before they happen in production: models
+// that answer 429, tools that time out, and tool results cut off mid-way.
+// It is configured with the CHAOS_* settings (see config.LoadChaos) and
+// injects nothing unless a rate is set.
+//
+// Every model and tool draws from a random stream of its own, seeded from
+// the configured seed and its name, so the calls of one tool fail the same
+// way on every run with the same seed, however calls of different tools
+// interleave.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"slices"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/forward"
+	"github.com/olusolaa/goforai/foundation/gemini"
+)
+
+// ErrInjected marks every failure the package injects.
+var ErrInjected = errors.New("injected by chaos testing")
+
+// Injector decides which calls fail. It is safe for concurrent use.
+type Injector struct {
+	cfg config.Chaos
+
+	mu      sync.Mutex
+	streams map[string]*rand.Rand
+}
+
+// New returns an Injector for cfg, or nil when cfg injects nothing. The
+// methods of a nil Injector return what they are given unchanged.
+func New(cfg config.Chaos) *Injector {
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &Injector{cfg: cfg, streams: make(map[string]*rand.Rand)}
+}
+
+// roll reports whether the next call of name fails, at rate.
+func (i *Injector) roll(name string, rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	r, ok := i.streams[name]
+	if !ok {
+		h := fnv.New64a()
+		h.Write([]byte(name))
+		r = rand.New(rand.NewSource(i.cfg.Seed ^ int64(h.Sum64())))
+		i.streams[name] = r
+	}
+	return r.Float64() < rate
+}
+
+// ChatModel returns m with a share of its Generate and Stream calls
+// rejected as rate limited, as the provider would on 429, before they
+// reach it. The errors wrap gemini.ErrRateLimited.
+func (i *Injector) ChatModel(name string, m model.ToolCallingChatModel) model.ToolCallingChatModel {
+	if i == nil || i.cfg.ModelRateLimit <= 0 {
+		return m
+	}
+	return &chaosModel{ChatModel: forward.ChatModel{ToolCallingChatModel: m}, injector: i, name: name}
+}
+
+type chaosModel struct {
+	forward.ChatModel
+	injector *Injector
+	name     string
+}
+
+func (m *chaosModel) fail() error {
+	if !m.injector.roll("model:"+m.name, m.injector.cfg.ModelRateLimit) {
+		return nil
+	}
+	return fmt.Errorf("%w: Error 429, RESOURCE_EXHAUSTED (%w)", gemini.ErrRateLimited, ErrInjected)
+}
+
+func (m *chaosModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if err := m.fail(); err != nil {
+		return nil, err
+	}
+	return m.ToolCallingChatModel.Generate(ctx, input, opts...)
+}
+
+func (m *chaosModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if err := m.fail(); err != nil {
+		return nil, err
+	}
+	return m.ToolCallingChatModel.Stream(ctx, input, opts...)
+}
+
+func (m *chaosModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	inner, err := m.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &chaosModel{ChatModel: forward.ChatModel{ToolCallingChatModel: inner}, injector: m.injector, name: m.name}, nil
+}
+
+// Tool returns the tool called name with a share of its calls timing out
+// without running and a share of its results cut off mid-way. Tools not
+// listed in CHAOS_TOOLS, when it is set, and tools that are not invokable
+// are returned unchanged.
+func (i *Injector) Tool(name string, t tool.BaseTool) tool.BaseTool {
+	if i == nil || (i.cfg.ToolTimeout <= 0 && i.cfg.MalformedOutput <= 0) {
+		return t
+	}
+	if len(i.cfg.Tools) > 0 && !slices.Contains(i.cfg.Tools, name) {
+		return t
+	}
+	inner, ok := t.(tool.InvokableTool)
+	if !ok {
+		return t
+	}
+	return &chaosTool{Tool: forward.Tool{InvokableTool: inner}, injector: i, name: name}
+}
+
+type chaosTool struct {
+	forward.Tool
+	injector *Injector
+	name     string
+}
+
+func (t *chaosTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	cfg := t.injector.cfg
+	if t.injector.roll("timeout:"+t.name, cfg.ToolTimeout) {
+		return "", fmt.Errorf("%s timed out: %w (%w)", t.name, context.DeadlineExceeded, ErrInjected)
+	}
+	out, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	if err != nil || !t.injector.roll("malformed:"+t.name, cfg.MalformedOutput) {
+		return out, err
+	}
+	// Half a JSON result does not parse, as when a connection drops or a
+	// tool crashes while writing.
+	return out[:len(out)/2], nil
+}
@@ -0,0 +1,123 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/gemini"
+)
+
+type okModel struct{ calls int }
+
+func (m *okModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.calls++
+	return schema.AssistantMessage("ok", nil), nil
+}
+
+func (m *okModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.calls++
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("ok", nil)}), nil
+}
+
+func (m *okModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+type okTool struct{ calls int }
+
+func (t *okTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "read_file"}, nil
+}
+
+func (t *okTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	t.calls++
+	return `{"content":"package main","error":""}`, nil
+}
+
+func TestDisabled(t *testing.T) {
+	inner := &okModel{}
+	if m := New(config.Chaos{Seed: 1}).ChatModel("gemini", inner); m != inner {
+		t.Error("model wrapped without any rate set")
+	}
+	bt := New(config.Chaos{ModelRateLimit: 1}).Tool("read_file", &okTool{})
+	if _, ok := bt.(*okTool); !ok {
+		t.Error("tool wrapped without a tool rate set")
+	}
+	bt = New(config.Chaos{ToolTimeout: 1, Tools: []string{"run_go"}}).Tool("read_file", &okTool{})
+	if _, ok := bt.(*okTool); !ok {
+		t.Error("tool wrapped that is not listed in Tools")
+	}
+}
+
+func TestModelRateLimit(t *testing.T) {
+	inner := &okModel{}
+	m := New(config.Chaos{ModelRateLimit: 1}).ChatModel("gemini", inner)
+	m, err := m.WithTools(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Generate(context.Background(), nil); !errors.Is(err, gemini.ErrRateLimited) || !errors.Is(err, ErrInjected) {
+		t.Errorf("Generate = %v, want an injected rate limit", err)
+	}
+	if _, err := m.Stream(context.Background(), nil); !errors.Is(err, gemini.ErrRateLimited) {
+		t.Errorf("Stream = %v, want an injected rate limit", err)
+	}
+	if inner.calls != 0 {
+		t.Errorf("the model was called %d times", inner.calls)
+	}
+}
+
+func TestToolFailures(t *testing.T) {
+	inner := &okTool{}
+	bt := New(config.Chaos{ToolTimeout: 1}).Tool("read_file", inner)
+	_, err := bt.(tool.InvokableTool).InvokableRun(context.Background(), "{}")
+	if !errors.Is(err, context.DeadlineExceeded) || inner.calls != 0 {
+		t.Errorf("err = %v after %d calls, want a timeout before any call", err, inner.calls)
+	}
+
+	bt = New(config.Chaos{MalformedOutput: 1}).Tool("read_file", inner)
+	out, err := bt.(tool.InvokableTool).InvokableRun(context.Background(), "{}")
+	if err != nil || inner.calls != 1 || json.Valid([]byte(out)) {
+		t.Errorf("out = %q, %v after %d calls, want invalid JSON from one call", out, err, inner.calls)
+	}
+}
+
+func TestSameSeedSameFailures(t *testing.T) {
+	// Calls of the model interleaved differently do not change which tool
+	// calls fail.
+	run := func(seed int64, every int) []bool {
+		cfg := config.Chaos{ModelRateLimit: 0.5, ToolTimeout: 0.5, Seed: seed}
+		i := New(cfg)
+		m, bt := i.ChatModel("gemini", &okModel{}), i.Tool("read_file", &okTool{})
+		var failed []bool
+		for n := range 20 {
+			if n%every == 0 {
+				m.Generate(context.Background(), nil)
+			}
+			_, err := bt.(tool.InvokableTool).InvokableRun(context.Background(), "{}")
+			failed = append(failed, err != nil)
+		}
+		return failed
+	}
+	a, b := run(7, 2), run(7, 3)
+	for n := range a {
+		if a[n] != b[n] {
+			t.Fatalf("call %d failed in one run but not the other: %v, %v", n, a, b)
+		}
+	}
+	var failures int
+	for _, f := range a {
+		if f {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(a) {
+		t.Errorf("%d of %d calls failed at rate 0.5", failures, len(a))
+	}
+}
@@ -0,0 +1,64 @@
+package chromemdb
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// MetaAccess is the document metadata key listing who may retrieve the
+// document: a comma-separated list of client names and "group:<name>"
+// entries. Documents without it are public.
+const MetaAccess = "access"
+
+// Identity is who a retrieval is made for.
+type Identity struct {
+	Name   string
+	Groups []string
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context whose retrievals only return the documents
+// identity may read. Retrievals without an identity, such as those of the
+// terminal agent, see every document.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFrom returns the identity set by WithIdentity.
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// CanRead reports whether id is granted by access, a MetaAccess value. An
+// empty access list grants everyone.
+func (id Identity) CanRead(access string) bool {
+	if strings.TrimSpace(access) == "" {
+		return true
+	}
+	for _, entry := range strings.Split(access, ",") {
+		entry = strings.TrimSpace(entry)
+		if group, ok := strings.CutPrefix(entry, "group:"); ok {
+			if slices.Contains(id.Groups, group) {
+				return true
+			}
+		} else if entry != "" && entry == id.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// readable returns the filter ctx's identity puts on stored documents, or
+// nil when every document may be returned.
+func readable(ctx context.Context) func(metadata map[string]string) bool {
+	identity, ok := IdentityFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return func(metadata map[string]string) bool {
+		return identity.CanRead(metadata[MetaAccess])
+	}
+}
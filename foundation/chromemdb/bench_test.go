@@ -0,0 +1,151 @@
+package chromemdb
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/schema"
+	"github.com/philippgille/chromem-go"
+)
+
+// Run with memory profiles, one package at a time:
+//
+//	go test ./foundation/chromemdb -run '^$' -bench . -benchmem -memprofile mem.pprof
+//	go tool pprof -sample_index=alloc_space mem.pprof
+//
+// The 100k-document cases are skipped with -short.
+
+// benchDimension matches text-embedding-004.
+const benchDimension = 768
+
+var benchSizes = []int{1_000, 10_000, 100_000}
+
+// hashEmbedder returns a deterministic unit vector per text, so benchmarks
+// measure the store rather than an embedding API.
+type hashEmbedder struct{}
+
+func (hashEmbedder) EmbedStrings(_ context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	out := make([][]float64, len(texts))
+	for i, text := range texts {
+		h := fnv.New64a()
+		h.Write([]byte(text))
+		rng := rand.New(rand.NewSource(int64(h.Sum64())))
+		v := make([]float64, benchDimension)
+		var norm float64
+		for j := range v {
+			v[j] = rng.NormFloat64()
+			norm += v[j] * v[j]
+		}
+		norm = math.Sqrt(norm)
+		for j := range v {
+			v[j] /= norm
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func benchDocs(n int) []*schema.Document {
+	docs := make([]*schema.Document, n)
+	for i := range docs {
+		docs[i] = &schema.Document{
+			ID:       fmt.Sprintf("doc-%d", i),
+			Content:  fmt.Sprintf("Talk %d: building reliable Go services, session %d of track %d.", i, i%40, i%7),
+			MetaData: map[string]any{"source": fmt.Sprintf("talks/%d.md", i%500)},
+		}
+	}
+	return docs
+}
+
+var benchQuantizations = []Quantization{QuantizeNone, QuantizeInt8, QuantizeFloat16}
+
+func newBenchDB(b *testing.B, q Quantization) *ChromemDB {
+	b.Helper()
+	db, err := New(context.Background(), "bench", hashEmbedder{},
+		WithDB(chromem.NewDB()),
+		WithQuantization(q),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+func skipLarge(b *testing.B, n int) {
+	if testing.Short() && n > 10_000 {
+		b.Skipf("skipping %d documents in -short mode", n)
+	}
+}
+
+// BenchmarkStore measures embedding and inserting a whole corpus; one op is
+// one corpus of n documents.
+func BenchmarkStore(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("docs=%d", n), func(b *testing.B) {
+			skipLarge(b, n)
+			docs := benchDocs(n)
+			ctx := context.Background()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				db := newBenchDB(b, QuantizeNone)
+				b.StartTimer()
+				if _, err := db.Store(ctx, docs); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(n*b.N)/b.Elapsed().Seconds(), "docs/s")
+		})
+	}
+}
+
+// BenchmarkRetrieve measures one top-k query against a populated collection,
+// for each quantization. heap-MB is the live heap after storing the corpus.
+func BenchmarkRetrieve(b *testing.B) {
+	for _, n := range benchSizes {
+		for _, q := range benchQuantizations {
+			b.Run(fmt.Sprintf("docs=%d/quant=%s", n, q), func(b *testing.B) {
+				skipLarge(b, n)
+				benchRetrieve(b, n, q)
+			})
+		}
+	}
+}
+
+func benchRetrieve(b *testing.B, n int, q Quantization) {
+	ctx := context.Background()
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	db := newBenchDB(b, q)
+	if _, err := db.Store(ctx, benchDocs(n)); err != nil {
+		b.Fatal(err)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		docs, err := db.Retrieve(ctx, fmt.Sprintf("reliable Go services %d", i%100))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(docs) == 0 {
+			b.Fatal("no documents retrieved")
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/(1<<20), "heap-MB")
+	runtime.KeepAlive(db)
+}
@@ -4,9 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/indexer"
@@ -28,14 +36,37 @@ type ChromemDB struct {
 	db         *chromem.DB
 	embedder   embedding.Embedder
 	topK       int
+
+	// configMismatch describes how the index-time configuration differs from
+	// the query-time one. It is reported once, on the first retrieval.
+	configMismatch string
+	warnOnce       sync.Once
+
+	logger         *slog.Logger
+	embeddingModel string
+	metadata       map[string]string
+	manifest       *Manifest    // Header of the loaded export, nil for legacy files.
+	dimension      atomic.Int64 // Vector size observed from the embedder.
+
+	// quant holds the documents instead of the chromem collection when
+	// quantization is enabled; the collection is then left empty.
+	quant *quantIndex
+
+	feedback *Feedback // Votes on answers that re-rank results; nil without.
 }
 
 // config holds the optional configuration for creating a new ChromemDB instance.
 // It is unexported as it's an implementation detail of the constructor.
 type config struct {
-	db     *chromem.DB
-	dbPath string
-	topK   int
+	db             *chromem.DB
+	dbPath         string
+	topK           int
+	metadata       map[string]string
+	embeddingModel string
+	autoMigrate    bool
+	quantization   Quantization
+	feedback       *Feedback
+	logger         *slog.Logger
 }
 
 // Option defines the functional option type for configuring ChromemDB.
@@ -64,6 +95,58 @@ func WithTopK(topK int) Option {
 	}
 }
 
+// WithCollectionMetadata records metadata (such as chunking parameters) on the
+// collection when it is created. Existing collections keep their metadata;
+// loaded indexes whose values differ are reported as a configuration mismatch.
+func WithCollectionMetadata(metadata map[string]string) Option {
+	return func(c *config) {
+		c.metadata = metadata
+	}
+}
+
+// WithEmbeddingModel names the model behind the embedder. It is recorded in the
+// collection metadata at index time and compared against it at query time.
+func WithEmbeddingModel(model string) Option {
+	return func(c *config) {
+		c.embeddingModel = model
+	}
+}
+
+// WithLogger sets the logger used for lifecycle and warning messages.
+// Defaults to slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithAutoMigrate re-embeds a loaded index whose manifest does not match the
+// configured embedding model instead of refusing to load it.
+func WithAutoMigrate() Option {
+	return func(c *config) {
+		c.autoMigrate = true
+	}
+}
+
+// WithQuantization keeps embeddings in memory as int8 or float16 instead of
+// float32, cutting the footprint of large collections by 4x or 2x. Vectors
+// loaded WithDBPath are converted on load; queries stay in float32.
+// Quantized documents live outside the chromem.DB, so ExportDB does not see
+// them: index without quantization and enable it where the index is served.
+func WithQuantization(q Quantization) Option {
+	return func(c *config) {
+		c.quantization = q
+	}
+}
+
+// WithFeedback re-ranks results by the votes cast on earlier answers to
+// similar queries, and lets retrievals logged WithRetrievalLog be voted on.
+func WithFeedback(f *Feedback) Option {
+	return func(c *config) {
+		c.feedback = f
+	}
+}
+
 func New(ctx context.Context, collectionName string, embedder embedding.Embedder, opts ...Option) (*ChromemDB, error) {
 	// --- 1. Validate Required Arguments (Fail Fast) ---
 	if collectionName == "" {
@@ -74,52 +157,280 @@ func New(ctx context.Context, collectionName string, embedder embedding.Embedder
 	}
 
 	cfg := &config{
-		topK: defaultTopK,
+		topK:   defaultTopK,
+		logger: slog.Default(),
 	}
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
+	c := &ChromemDB{
+		logger:         cfg.logger.With("collection", collectionName),
+		embedder:       embedder,
+		topK:           cfg.topK,
+		embeddingModel: cfg.embeddingModel,
+		feedback:       cfg.feedback,
+	}
+
+	embeddingFunc := func(ctx context.Context, text string) ([]float32, error) {
+		embeddings, err := embedder.EmbedStrings(ctx, []string{text})
+		if err != nil {
+			return nil, fmt.Errorf("embedding failed: %w", err)
+		}
+		if len(embeddings) == 0 || len(embeddings[0]) == 0 {
+			return nil, errors.New("embedder returned no embeddings")
+		}
+		c.dimension.Store(int64(len(embeddings[0])))
+		return convertToFloat32(embeddings[0]), nil
+	}
+
 	var db *chromem.DB
 	switch {
 	case cfg.db != nil:
 		db = cfg.db
 	case cfg.dbPath != "":
-		db = chromem.NewDB()
 		if _, err := os.Stat(cfg.dbPath); errors.Is(err, os.ErrNotExist) {
-			return nil, fmt.Errorf("database not found at %s: run indexing and export first", cfg.dbPath)
+			return nil, fmt.Errorf("%w at %s: run indexing and export first", ErrDBNotFound, cfg.dbPath)
+		}
+		var err error
+		db, c.manifest, err = importDB(cfg.dbPath)
+		if err != nil {
+			return nil, err
 		}
-		if err := db.ImportFromFile(cfg.dbPath, ""); err != nil {
-			return nil, fmt.Errorf("failed to import database from %s: %w", cfg.dbPath, err)
+		if c.manifest != nil {
+			if reason := c.manifest.mismatch(cfg.embeddingModel, 0); reason != "" {
+				if !cfg.autoMigrate {
+					return nil, fmt.Errorf("%w: %s: re-run indexing or enable WithAutoMigrate() to re-embed %s", ErrIndexMismatch, reason, cfg.dbPath)
+				}
+				c.logger.Info("migrating index", "path", cfg.dbPath, "reason", reason)
+				db, c.manifest, err = migrate(ctx, c.logger, cfg.dbPath, collectionName, cfg.embeddingModel, embeddingFunc)
+				if err != nil {
+					return nil, fmt.Errorf("failed to migrate %s: %w", cfg.dbPath, err)
+				}
+			}
 		}
 	default:
 		return nil, errors.New("configuration requires one of WithDB() or WithDBPath()")
 	}
 
-	embeddingFunc := func(ctx context.Context, text string) ([]float32, error) {
-		embeddings, err := embedder.EmbedStrings(ctx, []string{text})
+	metadata := make(map[string]string, len(cfg.metadata)+1)
+	for k, v := range cfg.metadata {
+		metadata[k] = v
+	}
+	if cfg.embeddingModel != "" {
+		metadata[MetaEmbeddingModel] = cfg.embeddingModel
+	}
+	c.metadata = metadata
+
+	collection, err := db.GetOrCreateCollection(collectionName, metadata, embeddingFunc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create collection '%s': %w", collectionName, err)
+	}
+
+	// Compare the index-time configuration with the query-time one. The
+	// manifest carries it; legacy exports only have the collection metadata.
+	if cfg.dbPath != "" {
+		var stored map[string]string
+		if c.manifest != nil {
+			stored = c.manifest.Metadata
+			c.dimension.Store(int64(c.manifest.Dimension))
+		} else {
+			collections, err := readPersisted(cfg.dbPath)
+			if err != nil {
+				return nil, err
+			}
+			if col, ok := collections[collectionName]; ok {
+				stored = col.Metadata
+				for _, doc := range col.Documents {
+					c.dimension.Store(int64(len(doc.Embedding)))
+					break
+				}
+			}
+		}
+		if drift := metadataDrift(stored, metadata); len(drift) > 0 {
+			c.configMismatch = fmt.Sprintf("collection '%s' was indexed with %s; results may be poor or meaningless, re-run indexing",
+				collectionName, strings.Join(drift, ", "))
+		}
+	}
+
+	if cfg.quantization != QuantizeNone {
+		collection, err = c.quantize(ctx, db, collection, embeddingFunc, cfg.quantization)
 		if err != nil {
-			return nil, fmt.Errorf("embedding failed: %w", err)
+			return nil, err
 		}
-		if len(embeddings) == 0 || len(embeddings[0]) == 0 {
-			return nil, errors.New("embedder returned no embeddings")
+	}
+
+	c.collection = collection
+	c.db = db
+	c.logger.Info("initialized ChromemDB", "documents", c.count(), "quantization", cfg.quantization)
+	return c, nil
+}
+
+// quantize moves the documents of a loaded export into a quantized index and
+// replaces the collection with an empty one, so the float32 vectors can be
+// garbage collected.
+func (c *ChromemDB) quantize(ctx context.Context, db *chromem.DB, collection *chromem.Collection, embeddingFunc chromem.EmbeddingFunc, q Quantization) (*chromem.Collection, error) {
+	c.quant = newQuantIndex(q)
+	n := collection.Count()
+	if n == 0 {
+		return collection, nil
+	}
+	dim := int(c.dimension.Load())
+	if dim == 0 {
+		return nil, fmt.Errorf("collection '%s' already holds %d documents: quantization applies to documents loaded WithDBPath() or added with Store", collection.Name, n)
+	}
+
+	// chromem-go cannot list a collection, but a query for all of its
+	// documents returns each one with its embedding.
+	probe := make([]float32, dim)
+	probe[0] = 1
+	docs, err := collection.QueryEmbedding(ctx, probe, n, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection '%s': %w", collection.Name, err)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID }) // Stable order, so ties rank the same on every load.
+	for _, doc := range docs {
+		if err := c.quant.add(quantDoc{ID: doc.ID, Content: doc.Content, Metadata: doc.Metadata}, doc.Embedding); err != nil {
+			return nil, fmt.Errorf("failed to quantize collection '%s': %w", collection.Name, err)
 		}
-		return convertToFloat32(embeddings[0]), nil
 	}
+	c.dimension.Store(int64(c.quant.dim))
 
-	collection, err := db.GetOrCreateCollection(collectionName, nil, embeddingFunc)
+	if err := db.DeleteCollection(collection.Name); err != nil {
+		return nil, fmt.Errorf("failed to release collection '%s': %w", collection.Name, err)
+	}
+	name := collection.Name
+	collection, err = db.CreateCollection(name, c.metadata, embeddingFunc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get or create collection '%s': %w", collectionName, err)
+		return nil, fmt.Errorf("failed to recreate collection '%s': %w", name, err)
+	}
+	return collection, nil
+}
+
+// metadataDrift lists the configured values, such as the embedding model or
+// chunk size, that differ from the ones recorded at index time. Keys missing
+// on either side are not compared.
+func metadataDrift(stored, configured map[string]string) []string {
+	keys := make([]string, 0, len(configured))
+	for k := range configured {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var drift []string
+	for _, k := range keys {
+		indexed, ok := stored[k]
+		if !ok || indexed == "" || configured[k] == "" || indexed == configured[k] {
+			continue
+		}
+		drift = append(drift, fmt.Sprintf("%s %q but is queried with %q", k, indexed, configured[k]))
+	}
+	return drift
+}
+
+// count returns the number of stored documents.
+func (c *ChromemDB) count() int {
+	if c.quant != nil {
+		return c.quant.count()
+	}
+	return c.collection.Count()
+}
+
+// Manifest describes the index as built by this instance. Pass it to ExportDB
+// so that later loads can detect configuration drift.
+func (c *ChromemDB) Manifest() *Manifest {
+	return &Manifest{
+		SchemaVersion:  SchemaVersion,
+		EmbeddingModel: c.embeddingModel,
+		Dimension:      int(c.dimension.Load()),
+		Collection:     c.collection.Name,
+		Metadata:       c.metadata,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+// migrate re-embeds every document of a collection with the current embedder
+// and rewrites the export in place, keeping a .bak copy of the original.
+func migrate(ctx context.Context, logger *slog.Logger, path, collectionName, embeddingModel string, embeddingFunc chromem.EmbeddingFunc) (*chromem.DB, *Manifest, error) {
+	collections, err := readPersisted(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	old, ok := collections[collectionName]
+	if !ok {
+		return nil, nil, fmt.Errorf("collection '%s' not found in %s", collectionName, path)
+	}
+
+	metadata := make(map[string]string, len(old.Metadata)+1)
+	for k, v := range old.Metadata {
+		metadata[k] = v
+	}
+	metadata[MetaEmbeddingModel] = embeddingModel
+
+	db := chromem.NewDB()
+	collection, err := db.CreateCollection(collectionName, metadata, embeddingFunc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create collection '%s': %w", collectionName, err)
 	}
 
-	fmt.Printf("✅ Initialized ChromemDB with %d documents in collection '%s'.\n", collection.Count(), collectionName)
+	docs := make([]chromem.Document, 0, len(old.Documents))
+	dimension := 0
+	for _, doc := range old.Documents {
+		docs = append(docs, chromem.Document{ID: doc.ID, Content: doc.Content, Metadata: doc.Metadata})
+	}
+	if err := collection.AddDocuments(ctx, docs, runtime.NumCPU()); err != nil {
+		return nil, nil, fmt.Errorf("failed to re-embed documents: %w", err)
+	}
+	if len(docs) > 0 {
+		if embedded, err := collection.GetByID(ctx, docs[0].ID); err == nil {
+			dimension = len(embedded.Embedding)
+		}
+	}
+
+	manifest := &Manifest{
+		SchemaVersion:  SchemaVersion,
+		EmbeddingModel: embeddingModel,
+		Dimension:      dimension,
+		Collection:     collectionName,
+		Metadata:       metadata,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	// Keep the original as .bak, then replace it atomically: if the export
+	// fails, the original index is still in place.
+	if err := backup(path, path+".bak"); err != nil {
+		return nil, nil, fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := exportDB(db, path, manifest); err != nil {
+		return nil, nil, err
+	}
+	logger.Info("re-embedded index", "documents", len(docs), "embedding_model", embeddingModel, "backup", path+".bak")
+	return db, manifest, nil
+}
 
-	return &ChromemDB{
-		collection: collection,
-		db:         db,
-		embedder:   embedder,
-		topK:       cfg.topK,
-	}, nil
+// backup hard-links path to bak, falling back to a copy where links are not
+// supported. An existing bak is replaced.
+func backup(path, bak string) error {
+	if err := os.Remove(bak); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Link(path, bak); err == nil {
+		return nil
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(bak)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
 }
 
 func (c *ChromemDB) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) ([]string, error) {
@@ -151,6 +462,13 @@ func (c *ChromemDB) Store(ctx context.Context, docs []*schema.Document, opts ...
 		}
 	}
 
+	if c.quant != nil {
+		if err := c.storeQuantized(ctx, chromemDocs); err != nil {
+			return nil, err
+		}
+		return ids, nil
+	}
+
 	if err := c.collection.AddDocuments(ctx, chromemDocs, runtime.NumCPU()); err != nil {
 		return nil, fmt.Errorf("failed to batch add documents: %w", err)
 	}
@@ -158,8 +476,106 @@ func (c *ChromemDB) Store(ctx context.Context, docs []*schema.Document, opts ...
 	return ids, nil
 }
 
-// Retrieve finds relevant documents for a given query.
+// MetaDocumentID is the metadata key linking a chunk to the document it was
+// split from, so that Delete removes the chunks along with the document.
+const MetaDocumentID = "document_id"
+
+// Delete removes the documents with the given IDs and the chunks split from
+// them, and returns how many of the IDs named a stored document or chunks of
+// one. Unknown IDs are ignored.
+func (c *ChromemDB) Delete(ctx context.Context, ids ...string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if c.quant != nil {
+		return 0, errors.New("documents cannot be deleted from a quantized index")
+	}
+	removed := 0
+	for _, id := range ids {
+		before := c.collection.Count()
+		if err := c.collection.Delete(ctx, nil, nil, id); err != nil {
+			return removed, fmt.Errorf("failed to delete %s: %w", id, err)
+		}
+		if err := c.collection.Delete(ctx, map[string]string{MetaDocumentID: id}, nil); err != nil {
+			return removed, fmt.Errorf("failed to delete the chunks of %s: %w", id, err)
+		}
+		if c.collection.Count() < before {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// HasCollection reports whether c's database holds a collection called name.
+func (c *ChromemDB) HasCollection(name string) bool {
+	return c.db.GetCollection(name, nil) != nil
+}
+
+// Collection returns the named collection of c's database, embedded by the
+// same embedder, creating it when it does not exist. c's own name returns c.
+func (c *ChromemDB) Collection(ctx context.Context, name string) (*ChromemDB, error) {
+	if name == c.collection.Name {
+		return c, nil
+	}
+	return New(ctx, name, c.embedder,
+		WithDB(c.db),
+		WithEmbeddingModel(c.embeddingModel),
+		WithTopK(c.topK))
+}
+
+// Export writes c's database, every collection included, to path with c's
+// manifest. Quantized indexes keep their documents outside the database and
+// cannot be exported.
+func (c *ChromemDB) Export(path string) error {
+	if c.quant != nil {
+		return errors.New("a quantized index cannot be exported")
+	}
+	return ExportDB(c.db, path, c.Manifest())
+}
+
+// embedBatchSize is the most texts sent to the embedder in one call; the
+// Gemini batch endpoint accepts up to 100.
+const embedBatchSize = 100
+
+// storeQuantized embeds docs in batches and adds them to the quantized index.
+func (c *ChromemDB) storeQuantized(ctx context.Context, docs []chromem.Document) error {
+	for start := 0; start < len(docs); start += embedBatchSize {
+		batch := docs[start:min(start+embedBatchSize, len(docs))]
+		texts := make([]string, len(batch))
+		for i, doc := range batch {
+			texts[i] = doc.Content
+		}
+		embeddings, err := c.embedder.EmbedStrings(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to batch add documents: embedding failed: %w", err)
+		}
+		if len(embeddings) != len(batch) {
+			return fmt.Errorf("failed to batch add documents: embedder returned %d embeddings for %d texts", len(embeddings), len(batch))
+		}
+		for i, doc := range batch {
+			if len(embeddings[i]) == 0 {
+				return errors.New("failed to batch add documents: embedder returned an empty embedding")
+			}
+			c.dimension.Store(int64(len(embeddings[i])))
+			if err := c.quant.add(quantDoc{ID: doc.ID, Content: doc.Content, Metadata: doc.Metadata}, convertToFloat32(embeddings[i])); err != nil {
+				return fmt.Errorf("failed to batch add documents: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Retrieve finds relevant documents for a given query. retriever.WithTopK
+// overrides the number of documents set by WithTopK for this call. With
+// WithFeedback, scores include the votes' boost or penalty.
 func (c *ChromemDB) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	topK := *retriever.GetCommonOptions(&retriever.Options{TopK: &c.topK}, opts...).TopK
+	if c.configMismatch != "" {
+		c.warnOnce.Do(func() {
+			c.logger.Warn("index configuration mismatch", "detail", c.configMismatch)
+		})
+	}
+
 	embeddings, err := c.embedder.EmbedStrings(ctx, []string{query})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate embedding for query: %w", err)
@@ -168,50 +584,213 @@ func (c *ChromemDB) Retrieve(ctx context.Context, query string, opts ...retrieve
 		return nil, errors.New("embedder generated an empty embedding for the query")
 	}
 
+	if c.manifest != nil && c.manifest.Dimension != 0 && c.manifest.Dimension != len(embeddings[0]) {
+		return nil, fmt.Errorf("%w: %s", ErrIndexMismatch, c.manifest.mismatch("", len(embeddings[0])))
+	}
+
 	embedding32 := convertToFloat32(embeddings[0])
+	keep := readable(ctx)
+
+	// Votes can lift a document from below the top ones, so more are ranked.
+	candidates := topK
+	reranked := c.feedback != nil && c.feedback.hasVotes()
+	if reranked {
+		candidates = feedbackPool * topK
+	}
+
+	var outDocs []*schema.Document
+	if c.quant != nil {
+		results, err := c.quant.query(embedding32, candidates, keep)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query collection: %w", err)
+		}
+		outDocs = make([]*schema.Document, len(results))
+		for i, result := range results {
+			outDocs[i] = toDocument(result.ID, result.Content, result.Metadata, result.Similarity)
+		}
+	} else {
+		results, err := c.queryCollection(ctx, embedding32, candidates, keep)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query collection: %w", err)
+		}
+		outDocs = make([]*schema.Document, len(results))
+		for i, result := range results {
+			outDocs[i] = toDocument(result.ID, result.Content, result.Metadata, result.Similarity)
+		}
+	}
 
+	if reranked {
+		outDocs = c.feedback.rerank(embedding32, outDocs, topK)
+	}
+	logRetrieval(ctx, query, embedding32, outDocs, c.feedback)
+	return outDocs, nil
+}
+
+// queryCollection returns the topK documents of the collection most similar
+// to embedding among those keep accepts; a nil keep accepts all. chromem-go
+// only filters on exact metadata values, so documents are fetched in
+// growing batches and filtered here until topK are found or none are left.
+func (c *ChromemDB) queryCollection(ctx context.Context, embedding []float32, topK int, keep func(map[string]string) bool) ([]chromem.Result, error) {
 	numDocs := c.collection.Count()
-	topK := c.topK
 	if topK > numDocs {
 		topK = numDocs
 	}
-
-	results, err := c.collection.QueryEmbedding(ctx, embedding32, topK, nil, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query collection: %w", err)
+	if topK <= 0 {
+		return nil, nil
 	}
-
-	outDocs := make([]*schema.Document, len(results))
-	for i, result := range results {
-		metadata := make(map[string]any, len(result.Metadata))
-		for k, v := range result.Metadata {
-			metadata[k] = v
+	if keep == nil {
+		return c.collection.QueryEmbedding(ctx, embedding, topK, nil, nil)
+	}
+	for n := topK; ; n = min(4*n, numDocs) {
+		results, err := c.collection.QueryEmbedding(ctx, embedding, n, nil, nil)
+		if err != nil {
+			return nil, err
 		}
-
-		doc := &schema.Document{
-			ID:       result.ID,
-			Content:  result.Content,
-			MetaData: metadata,
+		kept := make([]chromem.Result, 0, topK)
+		for _, result := range results {
+			if keep(result.Metadata) {
+				kept = append(kept, result)
+				if len(kept) == topK {
+					break
+				}
+			}
+		}
+		if len(kept) == topK || n == numDocs {
+			return kept, nil
 		}
-		doc.WithScore(float64(result.Similarity))
-		outDocs[i] = doc
 	}
+}
 
-	return outDocs, nil
+func toDocument(id, content string, meta map[string]string, similarity float32) *schema.Document {
+	metadata := make(map[string]any, len(meta))
+	for k, v := range meta {
+		metadata[k] = v
+	}
+
+	doc := &schema.Document{
+		ID:       id,
+		Content:  content,
+		MetaData: metadata,
+	}
+	doc.WithScore(float64(similarity))
+	return doc
 }
 
-func ExportDB(db *chromem.DB, path string) error {
+// exportDB is the export used by migrate; tests replace it to simulate a
+// failed write.
+var exportDB = ExportDB
+
+// ExportDB writes the database to path, preceded by the manifest header when
+// one is given. The file is replaced atomically.
+func ExportDB(db *chromem.DB, path string, manifest *Manifest) error {
+	return writeIndex(path, manifest, func(w io.Writer) error {
+		if err := db.ExportToWriter(w, false, ""); err != nil {
+			return fmt.Errorf("failed to export database to %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// writeIndex replaces the database at path with the manifest header, when
+// one is given, followed by what payload writes.
+func writeIndex(path string, manifest *Manifest, payload func(io.Writer) error) (err error) {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory %s: %w", dir, err)
 	}
 
-	if err := db.ExportToFile(path, false, ""); err != nil {
-		return fmt.Errorf("failed to export database to %s: %w", path, err)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if manifest != nil {
+		if err := writeHeader(tmp, manifest); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write index header to %s: %w", path, err)
+		}
+	}
+	if err := payload(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
 	}
 	return nil
 }
 
+// MetaEmbeddingModel is the collection metadata key holding the index-time embedding model.
+const MetaEmbeddingModel = "embedding_model"
+
+// ReadCollectionMetadata returns the metadata recorded on a collection in an
+// exported database file. chromem-go does not expose collection metadata, so
+// the file is decoded directly.
+func ReadCollectionMetadata(path, collectionName string) (map[string]string, error) {
+	collections, err := readPersisted(path)
+	if err != nil {
+		return nil, err
+	}
+	if col, ok := collections[collectionName]; ok && col.Metadata != nil {
+		return col.Metadata, nil
+	}
+	return map[string]string{}, nil
+}
+
+// ReadCentroid returns the normalized mean of the normalized embeddings of a
+// collection in an exported database file: a single vector that stands for
+// what the collection is about, so a query's similarity to it tells whether
+// the collection is likely to hold an answer.
+func ReadCentroid(path, collectionName string) ([]float64, error) {
+	collections, err := readPersisted(path)
+	if err != nil {
+		return nil, err
+	}
+	col, ok := collections[collectionName]
+	if !ok || len(col.Documents) == 0 {
+		return nil, fmt.Errorf("collection '%s' in %s has no documents", collectionName, path)
+	}
+	var centroid []float64
+	for _, doc := range col.Documents {
+		if centroid == nil {
+			centroid = make([]float64, len(doc.Embedding))
+		}
+		if len(doc.Embedding) != len(centroid) {
+			return nil, fmt.Errorf("document %s has %d dimensions, want %d", doc.ID, len(doc.Embedding), len(centroid))
+		}
+		norm := vectorNorm(doc.Embedding)
+		if norm == 0 {
+			continue
+		}
+		for i, v := range doc.Embedding {
+			centroid[i] += float64(v) / norm
+		}
+	}
+	if norm := vectorNorm(centroid); norm > 0 {
+		for i := range centroid {
+			centroid[i] /= norm
+		}
+	}
+	return centroid, nil
+}
+
+// vectorNorm returns the Euclidean length of v.
+func vectorNorm[T float32 | float64](v []T) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
 func convertToFloat32(embeddings []float64) []float32 {
 	embedding32 := make([]float32, len(embeddings))
 	for i, v := range embeddings {
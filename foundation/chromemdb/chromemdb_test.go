@@ -0,0 +1,284 @@
+package chromemdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chunking"
+	"github.com/philippgille/chromem-go"
+)
+
+var quietLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// exportTestIndex stores docs in a fresh collection and exports it with its
+// manifest, as the indexing pipeline does.
+func exportTestIndex(t *testing.T, docs int, opts ...Option) string {
+	t.Helper()
+	ctx := context.Background()
+	db := chromem.NewDB()
+	opts = append([]Option{WithDB(db), WithLogger(quietLogger)}, opts...)
+	idx, err := New(ctx, "test", hashEmbedder{}, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := idx.Store(ctx, benchDocs(docs)); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "chromem.gob")
+	if err := ExportDB(db, path, idx.Manifest()); err != nil {
+		t.Fatalf("ExportDB: %v", err)
+	}
+	return path
+}
+
+func TestLoadReportsChunkingDrift(t *testing.T) {
+	path := exportTestIndex(t, 10,
+		WithEmbeddingModel("model-a"),
+		WithCollectionMetadata(map[string]string{"chunk_size": "1000", "chunk_overlap": "200"}))
+
+	idx, err := New(context.Background(), "test", hashEmbedder{},
+		WithDBPath(path), WithLogger(quietLogger),
+		WithEmbeddingModel("model-a"),
+		WithCollectionMetadata(map[string]string{"chunk_size": "500", "chunk_overlap": "200"}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !strings.Contains(idx.configMismatch, `chunk_size "1000" but is queried with "500"`) {
+		t.Errorf("expected a chunk_size mismatch, got %q", idx.configMismatch)
+	}
+	if strings.Contains(idx.configMismatch, "chunk_overlap") {
+		t.Errorf("unchanged chunk_overlap reported as drift: %q", idx.configMismatch)
+	}
+
+	same, err := New(context.Background(), "test", hashEmbedder{},
+		WithDBPath(path), WithLogger(quietLogger),
+		WithEmbeddingModel("model-a"),
+		WithCollectionMetadata(map[string]string{"chunk_size": "1000"}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if same.configMismatch != "" {
+		t.Errorf("matching configuration reported as drift: %q", same.configMismatch)
+	}
+}
+
+func TestRetrieveResult(t *testing.T) {
+	ctx := context.Background()
+	idx, err := New(ctx, "test", hashEmbedder{}, WithDB(chromem.NewDB()), WithLogger(quietLogger), WithTopK(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := benchDocs(20)
+	docs[7].Content = "Talk 7: " + strings.Repeat("concurrency patterns \n", 50)
+	if _, err := idx.Store(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := idx.RetrieveResult(ctx, docs[7].Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Sources) != 3 {
+		t.Fatalf("got %d sources, want 3", len(result.Sources))
+	}
+	top := result.Sources[0]
+	if top.ID != "doc-7" || top.Score < result.Sources[1].Score || top.Score < result.Sources[2].Score {
+		t.Errorf("sources are not ordered by similarity: %+v", result.Sources)
+	}
+	if !strings.HasPrefix(top.Snippet, "Talk 7: concurrency patterns concurrency") || !strings.HasSuffix(top.Snippet, "…") || len([]rune(top.Snippet)) > snippetLen+1 {
+		t.Errorf("unexpected snippet %q", top.Snippet)
+	}
+	if top.Origin != "talks/7.md" {
+		t.Errorf("origin = %q", top.Origin)
+	}
+	if !strings.HasPrefix(result.Answer, "[1] doc-7 (talks/7.md)\nTalk 7: ") || !strings.Contains(result.Answer, "\n\n[3] "+result.Sources[2].ID+" (") {
+		t.Errorf("answer does not number its sources:\n%s", result.Answer)
+	}
+
+	// The number of documents can be chosen per call.
+	for _, k := range []int{1, 5} {
+		got, err := idx.Retrieve(ctx, docs[7].Content, retriever.WithTopK(k))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != k || got[0].ID != "doc-7" {
+			t.Errorf("WithTopK(%d) returned %d documents, first %s", k, len(got), got[0].ID)
+		}
+	}
+}
+
+func TestReadCentroid(t *testing.T) {
+	path := exportTestIndex(t, 10)
+
+	centroid, err := ReadCentroid(path, "test")
+	if err != nil {
+		t.Fatalf("ReadCentroid: %v", err)
+	}
+	if len(centroid) != benchDimension {
+		t.Fatalf("centroid has %d dimensions, want %d", len(centroid), benchDimension)
+	}
+	if norm := vectorNorm(centroid); math.Abs(norm-1) > 1e-6 {
+		t.Errorf("centroid norm = %f, want 1", norm)
+	}
+	// Every document leans towards the mean of them all.
+	vectors, _ := hashEmbedder{}.EmbedStrings(context.Background(), []string{benchDocs(1)[0].Content})
+	var dot float64
+	for i, v := range vectors[0] {
+		dot += v * centroid[i]
+	}
+	if dot <= 0 {
+		t.Errorf("a document's similarity to the centroid is %f", dot)
+	}
+
+	if _, err := ReadCentroid(path, "missing"); err == nil {
+		t.Error("ReadCentroid of a missing collection succeeded")
+	}
+}
+
+func TestRetrieveHonorsAccess(t *testing.T) {
+	ctx := context.Background()
+	db := chromem.NewDB()
+	idx, err := New(ctx, "test", hashEmbedder{}, WithDB(db), WithLogger(quietLogger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := benchDocs(30)
+	for i, doc := range docs {
+		switch i % 3 {
+		case 0:
+			doc.MetaData[MetaAccess] = "group:platform"
+		case 1:
+			doc.MetaData[MetaAccess] = "ada, grace"
+		}
+	}
+	if _, err := idx.Store(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "chromem.gob")
+	if err := ExportDB(db, path, idx.Manifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, q := range []Quantization{QuantizeNone, QuantizeInt8} {
+		idx, err := New(ctx, "test", hashEmbedder{}, WithDBPath(path), WithQuantization(q), WithTopK(10), WithLogger(quietLogger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		retrieve := func(ctx context.Context) []*schema.Document {
+			t.Helper()
+			got, err := idx.Retrieve(ctx, docs[0].Content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return got
+		}
+
+		// Without an identity every document may be returned.
+		if got := retrieve(ctx); len(got) != 10 || got[0].ID != "doc-0" {
+			t.Errorf("%s: no identity got %d documents, first %s", q, len(got), got[0].ID)
+		}
+		// Others only see the public documents, however far down they rank.
+		got := retrieve(WithIdentity(ctx, Identity{Name: "bob"}))
+		if len(got) != 10 {
+			t.Errorf("%s: bob got %d documents, want the 10 public ones", q, len(got))
+		}
+		for _, doc := range got {
+			if doc.MetaData[MetaAccess] != nil {
+				t.Errorf("%s: bob got %s, restricted to %v", q, doc.ID, doc.MetaData[MetaAccess])
+			}
+		}
+		if got := retrieve(WithIdentity(ctx, Identity{Name: "grace", Groups: []string{"platform"}})); len(got) != 10 || got[0].ID != "doc-0" {
+			t.Errorf("%s: a member of platform got %d documents, first %s", q, len(got), got[0].ID)
+		}
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	ctx := context.Background()
+	db := chromem.NewDB()
+	idx, err := New(ctx, "test", hashEmbedder{}, WithDB(db), WithLogger(quietLogger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []*schema.Document
+	for _, source := range []string{"talks.md", "venue.md"} {
+		for i := range 4 {
+			docs = append(docs, &schema.Document{
+				ID:       fmt.Sprintf("%s#%d", source, i),
+				Content:  fmt.Sprintf("%s chunk %d", source, i),
+				MetaData: map[string]any{MetaSource: source, chunking.MetaChunkIndex: i},
+			})
+		}
+	}
+	docs[2].MetaData[MetaAccess] = "group:staff"
+	docs = append(docs, &schema.Document{ID: "legacy", Content: "indexed without chunk indexes"})
+	if _, err := idx.Store(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "chromem.gob")
+	if err := ExportDB(db, path, idx.Manifest()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, q := range []Quantization{QuantizeNone, QuantizeInt8} {
+		idx, err := New(ctx, "test", hashEmbedder{}, WithDBPath(path), WithQuantization(q), WithLogger(quietLogger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hits, err := idx.Retrieve(ctx, "talks.md chunk 1", retriever.WithTopK(1))
+		if err != nil || len(hits) != 1 || hits[0].ID != "talks.md#1" {
+			t.Fatalf("%s: retrieve = %v, %v", q, hits, err)
+		}
+		ids := func(docs []*schema.Document) string {
+			var ids []string
+			for _, doc := range docs {
+				ids = append(ids, doc.ID)
+			}
+			return strings.Join(ids, " ")
+		}
+
+		got, err := idx.Neighbors(ctx, hits[0], 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "talks.md#0 talks.md#2 talks.md#3"; ids(got) != want {
+			t.Errorf("%s: neighbors = %s, want %s", q, ids(got), want)
+		}
+		got, err = idx.Neighbors(WithIdentity(ctx, Identity{Name: "bob"}), hits[0], 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "talks.md#0"; ids(got) != want {
+			t.Errorf("%s: bob's neighbors = %s, want %s", q, ids(got), want)
+		}
+		if got, err := idx.Neighbors(ctx, &schema.Document{ID: "legacy"}, 1); err != nil || len(got) != 0 {
+			t.Errorf("%s: a chunk without an index has neighbors %v, %v", q, got, err)
+		}
+	}
+}
+
+func TestIdentityCanRead(t *testing.T) {
+	ada := Identity{Name: "ada", Groups: []string{"platform"}}
+	for access, want := range map[string]bool{
+		"":                     true,
+		"ada":                  true,
+		"grace, ada":           true,
+		"group:platform":       true,
+		"grace,group:security": false,
+		"group:ada":            false,
+		"platform":             false,
+		"adam":                 false,
+	} {
+		if got := ada.CanRead(access); got != want {
+			t.Errorf("CanRead(%q) = %v, want %v", access, got, want)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package chromemdb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/philippgille/chromem-go"
+)
+
+// Snapshot identifies the contents of an exported database, so that a later
+// build can ship only what changed since it as a Delta. Documents are
+// compared by ID, content and metadata; their indexed_at stamp and their
+// embeddings are left out, since every build renews the first and the second
+// follows from the content and the embedding model, which the snapshot
+// records.
+type Snapshot struct {
+	ID             string                        `json:"id"`
+	CreatedAt      time.Time                     `json:"created_at"`
+	EmbeddingModel string                        `json:"embedding_model,omitempty"`
+	Collections    map[string]SnapshotCollection `json:"collections"`
+}
+
+// SnapshotCollection holds the hashes of a collection's metadata and of each
+// of its documents, by ID.
+type SnapshotCollection struct {
+	Metadata  string            `json:"metadata"`
+	Documents map[string]string `json:"documents"`
+}
+
+// ErrDeltaBase is returned when a delta is applied to a database other than
+// the one it was made against.
+var ErrDeltaBase = errors.New("delta does not apply to this database")
+
+// deltaMagic prefixes every exported delta.
+var deltaMagic = []byte("GOFORAI-DELTA\n")
+
+// Delta holds the changes that turn a database at the Base snapshot into one
+// at the Target snapshot.
+type Delta struct {
+	Base, Target string
+	CreatedAt    time.Time
+	Manifest     *Manifest // Header of the target database; nil for a legacy export.
+
+	Collections        map[string]*CollectionDelta // Collections with changes, by name.
+	RemovedCollections []string
+}
+
+// CollectionDelta holds the changes to one collection: the documents added
+// or changed, with their embeddings, and the IDs of those removed.
+type CollectionDelta struct {
+	Metadata map[string]string
+	Upserts  map[string]*chromem.Document
+	Removed  []string
+}
+
+// Counts returns how many documents the delta adds or changes and removes.
+func (d *Delta) Counts() (upserts, removed int) {
+	for _, c := range d.Collections {
+		upserts += len(c.Upserts)
+		removed += len(c.Removed)
+	}
+	return upserts, removed
+}
+
+// SnapshotDir is where the snapshots of the database at path are saved.
+func SnapshotDir(path string) string {
+	return filepath.Join(filepath.Dir(path), "snapshots")
+}
+
+// TakeSnapshot returns the snapshot of the exported database at path. A
+// missing database wraps ErrDBNotFound.
+func TakeSnapshot(path string) (*Snapshot, error) {
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	collections, err := readPersisted(path)
+	if err != nil {
+		return nil, err
+	}
+	return snapshotOf(manifest, collections), nil
+}
+
+func snapshotOf(manifest *Manifest, collections map[string]*persistedCollection) *Snapshot {
+	s := &Snapshot{CreatedAt: time.Now().UTC(), Collections: make(map[string]SnapshotCollection, len(collections))}
+	if manifest != nil {
+		s.EmbeddingModel = manifest.EmbeddingModel
+	}
+	id := sha256.New()
+	fmt.Fprintf(id, "%q\n", s.EmbeddingModel)
+	for _, name := range slices.Sorted(maps.Keys(collections)) {
+		col := collections[name]
+		sc := SnapshotCollection{Metadata: hashFields(col.Metadata), Documents: make(map[string]string, len(col.Documents))}
+		fmt.Fprintf(id, "collection %q %s\n", name, sc.Metadata)
+		for _, docID := range slices.Sorted(maps.Keys(col.Documents)) {
+			sc.Documents[docID] = hashDocument(col.Documents[docID])
+			fmt.Fprintf(id, "%q %s\n", docID, sc.Documents[docID])
+		}
+		s.Collections[name] = sc
+	}
+	s.ID = hex.EncodeToString(id.Sum(nil))[:16]
+	return s
+}
+
+func hashDocument(doc *chromem.Document) string {
+	metadata := maps.Clone(doc.Metadata)
+	delete(metadata, MetaIndexedAt)
+	h := sha256.New()
+	fmt.Fprintf(h, "%q\n%s\n%q", doc.ID, hashFields(metadata), doc.Content)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func hashFields(fields map[string]string) string {
+	h := sha256.New()
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		fmt.Fprintf(h, "%q=%q\n", k, fields[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// SaveSnapshot writes s to dir as <id>.json.
+func SaveSnapshot(dir string, s *Snapshot) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory %s: %w", dir, err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	path := filepath.Join(dir, s.ID+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save snapshot %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadSnapshot reads the snapshot id saved in dir.
+func LoadSnapshot(dir, id string) (*Snapshot, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid snapshot id %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("snapshot %s not found in %s", id, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", id, err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot %s: %w", id, err)
+	}
+	return &s, nil
+}
+
+// ExportDelta writes to out the changes made to the database at path since
+// base was taken, and returns them. A nil base stands for an empty database,
+// so the delta holds every document.
+func ExportDelta(path string, base *Snapshot, out string) (*Delta, error) {
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	collections, err := readPersisted(path)
+	if err != nil {
+		return nil, err
+	}
+	target := snapshotOf(manifest, collections)
+	if base == nil {
+		base = snapshotOf(nil, nil)
+	}
+	if len(base.Collections) > 0 && base.EmbeddingModel != target.EmbeddingModel {
+		return nil, fmt.Errorf("snapshot %s was embedded with %q but %s with %q: ship the whole database",
+			base.ID, base.EmbeddingModel, path, target.EmbeddingModel)
+	}
+
+	d := &Delta{Base: base.ID, Target: target.ID, CreatedAt: time.Now().UTC(), Manifest: manifest, Collections: make(map[string]*CollectionDelta)}
+	for name, col := range collections {
+		was, existed := base.Collections[name]
+		now := target.Collections[name]
+		c := &CollectionDelta{Metadata: col.Metadata, Upserts: make(map[string]*chromem.Document)}
+		for id, hash := range now.Documents {
+			if was.Documents[id] != hash {
+				c.Upserts[id] = col.Documents[id]
+			}
+		}
+		for id := range was.Documents {
+			if _, ok := now.Documents[id]; !ok {
+				c.Removed = append(c.Removed, id)
+			}
+		}
+		slices.Sort(c.Removed)
+		if !existed || was.Metadata != now.Metadata || len(c.Upserts) > 0 || len(c.Removed) > 0 {
+			d.Collections[name] = c
+		}
+	}
+	for name := range base.Collections {
+		if _, ok := collections[name]; !ok {
+			d.RemovedCollections = append(d.RemovedCollections, name)
+		}
+	}
+	slices.Sort(d.RemovedCollections)
+
+	err = writeIndex(out, nil, func(w io.Writer) error {
+		if _, err := w.Write(deltaMagic); err != nil {
+			return err
+		}
+		zw := gzip.NewWriter(w)
+		if err := gob.NewEncoder(zw).Encode(d); err != nil {
+			return fmt.Errorf("failed to encode delta: %w", err)
+		}
+		return zw.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ReadDelta reads a delta written by ExportDelta.
+func ReadDelta(path string) (*Delta, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open delta %s: %w", path, err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	if prefix, err := br.Peek(len(deltaMagic)); err != nil || !bytes.Equal(prefix, deltaMagic) {
+		return nil, fmt.Errorf("%s is not a knowledge base delta", path)
+	}
+	br.Discard(len(deltaMagic))
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delta %s: %w", path, err)
+	}
+	var d Delta
+	if err := gob.NewDecoder(zr).Decode(&d); err != nil {
+		return nil, fmt.Errorf("failed to decode delta %s: %w", path, err)
+	}
+	return &d, nil
+}
+
+// ApplyDelta applies the delta at deltaPath to the database at path, which
+// must be at the delta's base snapshot; a missing database counts as an
+// empty one. A database already at the target snapshot is left as it is,
+// so a delta can be applied again safely. The database is replaced
+// atomically, and only once the result is checked to be at the target.
+// ApplyDelta reports whether the database changed.
+func ApplyDelta(path, deltaPath string) (*Delta, bool, error) {
+	d, err := ReadDelta(deltaPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	manifest, err := ReadManifest(path)
+	collections := make(map[string]*persistedCollection)
+	switch {
+	case errors.Is(err, ErrDBNotFound):
+	case err != nil:
+		return nil, false, err
+	default:
+		if collections, err = readPersisted(path); err != nil {
+			return nil, false, err
+		}
+	}
+
+	current := snapshotOf(manifest, collections).ID
+	if current == d.Target {
+		return d, false, nil
+	}
+	if current != d.Base {
+		return nil, false, fmt.Errorf("%w: it was made against snapshot %s but %s is at %s", ErrDeltaBase, d.Base, path, current)
+	}
+
+	for _, name := range d.RemovedCollections {
+		delete(collections, name)
+	}
+	for name, c := range d.Collections {
+		col := collections[name]
+		if col == nil {
+			col = &persistedCollection{Name: name, Documents: make(map[string]*chromem.Document)}
+			collections[name] = col
+		}
+		col.Metadata = c.Metadata
+		for _, id := range c.Removed {
+			delete(col.Documents, id)
+		}
+		maps.Copy(col.Documents, c.Upserts)
+	}
+	if got := snapshotOf(d.Manifest, collections).ID; got != d.Target {
+		return nil, false, fmt.Errorf("applying the delta to %s gave snapshot %s, want %s", path, got, d.Target)
+	}
+
+	err = writeIndex(path, d.Manifest, func(w io.Writer) error {
+		persisted := struct {
+			Collections map[string]*persistedCollection
+		}{collections}
+		if err := gob.NewEncoder(w).Encode(persisted); err != nil {
+			return fmt.Errorf("failed to write database %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return d, true, nil
+}
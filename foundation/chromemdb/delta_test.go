@@ -0,0 +1,102 @@
+package chromemdb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/philippgille/chromem-go"
+)
+
+// exportDocs exports docs as the only collection of a database at path.
+func exportDocs(t *testing.T, path string, docs []*schema.Document) {
+	t.Helper()
+	ctx := context.Background()
+	db := chromem.NewDB()
+	idx, err := New(ctx, "test", hashEmbedder{}, WithDB(db), WithLogger(quietLogger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.Store(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportDB(db, path, idx.Manifest()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	dir := t.TempDir()
+	ci, prod := filepath.Join(dir, "ci.gob"), filepath.Join(dir, "prod.gob")
+
+	docs := benchDocs(5)
+	for _, doc := range docs {
+		doc.MetaData[MetaIndexedAt] = "2026-01-01T00:00:00Z"
+	}
+	exportDocs(t, ci, docs)
+	base, err := TakeSnapshot(ci)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Production starts from nothing and gets everything.
+	if _, err := ExportDelta(ci, nil, filepath.Join(dir, "full.delta")); err != nil {
+		t.Fatal(err)
+	}
+	if _, changed, err := ApplyDelta(prod, filepath.Join(dir, "full.delta")); err != nil || !changed {
+		t.Fatalf("apply the full delta: changed %v, %v", changed, err)
+	}
+
+	// The next build re-stamps every document, changes one, drops one and
+	// adds one.
+	docs = benchDocs(6)
+	for _, doc := range docs {
+		doc.MetaData[MetaIndexedAt] = "2026-02-01T00:00:00Z"
+	}
+	docs[1].Content = "Talk 1: rewritten."
+	docs = append(docs[:3], docs[4:]...)
+	exportDocs(t, ci, docs)
+
+	deltaPath := filepath.Join(dir, "kb.delta")
+	delta, err := ExportDelta(ci, base, deltaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upserts, removed := delta.Counts(); upserts != 2 || removed != 1 {
+		t.Errorf("delta holds %d upserts and %d removals, want 2 and 1", upserts, removed)
+	}
+
+	if _, changed, err := ApplyDelta(prod, deltaPath); err != nil || !changed {
+		t.Fatalf("apply: changed %v, %v", changed, err)
+	}
+	got, err := TakeSnapshot(prod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != delta.Target {
+		t.Errorf("production is at %s, want %s", got.ID, delta.Target)
+	}
+	if _, err := Verify(prod, "", 0); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if db, _, err := ImportDB(prod); err != nil || db.GetCollection("test", nil).Count() != 5 {
+		t.Errorf("ImportDB: %v", err)
+	}
+	if _, changed, err := ApplyDelta(prod, deltaPath); err != nil || changed {
+		t.Errorf("applying again: changed %v, %v", changed, err)
+	}
+
+	// A database that drifted from the base is left alone.
+	other := filepath.Join(dir, "other.gob")
+	exportDocs(t, other, benchDocs(2))
+	before, _ := os.ReadFile(other)
+	if _, _, err := ApplyDelta(other, deltaPath); !errors.Is(err, ErrDeltaBase) {
+		t.Errorf("apply to another database: %v", err)
+	}
+	if after, _ := os.ReadFile(other); string(after) != string(before) {
+		t.Error("a delta that does not apply changed the database")
+	}
+}
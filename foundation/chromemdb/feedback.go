@@ -0,0 +1,273 @@
+package chromemdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// feedbackPool is how many times topK candidates Retrieve ranks when
+// feedback may move documents into the top ones.
+const feedbackPool = 3
+
+// Vote is a user's judgement of an answer, recorded against one of the
+// documents retrieved for it.
+type Vote struct {
+	Query  string    `json:"query"`
+	Vector []float32 `json:"vector"` // Normalized embedding of the query.
+	DocID  string    `json:"doc_id"`
+	Value  int       `json:"value"` // +1 the answer helped, -1 it did not.
+	At     time.Time `json:"at"`
+}
+
+// feedbackFile is the layout of the feedback file.
+type feedbackFile struct {
+	Votes []*Vote `json:"votes"`
+}
+
+// Feedback keeps the votes users cast on answers and turns them into a
+// re-ranking term: a document voted up for queries like the one being
+// answered ranks higher, one voted down lower. Votes are kept in a JSON file
+// so they accumulate across sessions. It is safe for concurrent use.
+type Feedback struct {
+	path          string
+	weight        float64
+	minSimilarity float64
+	now           func() time.Time
+
+	mu    sync.Mutex
+	votes []*Vote
+}
+
+// OpenFeedback loads the votes kept in path, which need not exist yet.
+// weight is the most a document's score moves for the votes on it;
+// minSimilarity is the cosine similarity a query needs to a voted one for
+// that vote to count.
+func OpenFeedback(path string, weight, minSimilarity float64) (*Feedback, error) {
+	f := &Feedback{path: path, weight: weight, minSimilarity: minSimilarity, now: time.Now}
+	votes, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	f.votes = votes
+	return f, nil
+}
+
+// Path returns the file the votes are kept in.
+func (f *Feedback) Path() string {
+	return f.path
+}
+
+// Record casts value, +1 or -1, on every document r returned.
+func (f *Feedback) Record(r Retrieval, value int) error {
+	if value != 1 && value != -1 {
+		return fmt.Errorf("invalid vote %d: want +1 or -1", value)
+	}
+	if len(r.DocIDs) == 0 {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Other processes may have voted since the file was loaded.
+	votes, err := f.read()
+	if err != nil {
+		return err
+	}
+	at := f.now().UTC()
+	for _, id := range r.DocIDs {
+		votes = append(votes, &Vote{Query: r.Query, Vector: r.vector, DocID: id, Value: value, At: at})
+	}
+	if err := f.write(votes); err != nil {
+		return err
+	}
+	f.votes = votes
+	return nil
+}
+
+// adjustment returns what the votes on docID add to its score for a query
+// whose normalized embedding is vector. Each vote counts by how similar its query is to this
+// one; the sum is damped so that the first votes move a document less than a
+// consistent history of them, and never by more than the weight.
+func (f *Feedback) adjustment(vector []float32, docID string) float64 {
+	var net, mass float64
+	for _, v := range f.votes {
+		if v.DocID != docID {
+			continue
+		}
+		similarity := dot(vector, v.Vector)
+		if similarity < f.minSimilarity {
+			continue
+		}
+		net += similarity * float64(v.Value)
+		mass += similarity
+	}
+	if mass == 0 {
+		return 0
+	}
+	return f.weight * net / (mass + 1)
+}
+
+// rerank adds the votes' adjustment to the score of docs, retrieved for a
+// query embedded as vector, and returns the topK best by the new score.
+func (f *Feedback) rerank(vector []float32, docs []*schema.Document, topK int) []*schema.Document {
+	unit := normalize(vector)
+	f.mu.Lock()
+	for _, doc := range docs {
+		if adj := f.adjustment(unit, doc.ID); adj != 0 {
+			doc.WithScore(doc.Score() + adj)
+		}
+	}
+	f.mu.Unlock()
+	sort.SliceStable(docs, func(i, j int) bool { return docs[i].Score() > docs[j].Score() })
+	if len(docs) > topK {
+		docs = docs[:topK]
+	}
+	return docs
+}
+
+// hasVotes reports whether any vote was cast, so that retrievals without
+// any skip ranking the extra candidates.
+func (f *Feedback) hasVotes() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.votes) > 0
+}
+
+func (f *Feedback) read() ([]*Vote, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feedback: %w", err)
+	}
+	var file feedbackFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to read feedback %s: %w", f.path, err)
+	}
+	return file.Votes, nil
+}
+
+// write replaces the feedback file atomically.
+func (f *Feedback) write(votes []*Vote) error {
+	data, err := json.MarshalIndent(feedbackFile{Votes: votes}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".feedback-*")
+	if err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	return nil
+}
+
+// Retrieval is a query Retrieve answered and the documents it returned.
+type Retrieval struct {
+	Query   string
+	DocIDs  []string
+	Sources []string // Where each document came from (see ProvenanceOf), or its ID when that is unknown.
+
+	vector   []float32 // Normalized embedding of Query.
+	feedback *Feedback // Of the knowledge base searched; nil without one.
+}
+
+// RetrievalLog collects the retrievals made with a context returned by
+// WithRetrievalLog, so that votes on an answer reach the documents it was
+// given. It is safe for concurrent use.
+type RetrievalLog struct {
+	mu         sync.Mutex
+	retrievals []Retrieval
+}
+
+type retrievalLogKey struct{}
+
+// WithRetrievalLog returns a context whose retrievals are added to log.
+func WithRetrievalLog(ctx context.Context, log *RetrievalLog) context.Context {
+	return context.WithValue(ctx, retrievalLogKey{}, log)
+}
+
+// RetrievalLogFrom returns the log of ctx set by WithRetrievalLog, or nil.
+func RetrievalLogFrom(ctx context.Context) *RetrievalLog {
+	log, _ := ctx.Value(retrievalLogKey{}).(*RetrievalLog)
+	return log
+}
+
+// Retrievals returns the retrievals logged so far.
+func (l *RetrievalLog) Retrievals() []Retrieval {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Retrieval(nil), l.retrievals...)
+}
+
+// Vote casts value on every document logged from a knowledge base with
+// feedback, and returns how many that was.
+func (l *RetrievalLog) Vote(value int) (int, error) {
+	var n int
+	for _, r := range l.Retrievals() {
+		if r.feedback == nil {
+			continue
+		}
+		if err := r.feedback.Record(r, value); err != nil {
+			return n, err
+		}
+		n += len(r.DocIDs)
+	}
+	return n, nil
+}
+
+// logRetrieval adds the documents retrieved for query to ctx's log, if any.
+func logRetrieval(ctx context.Context, query string, vector []float32, docs []*schema.Document, feedback *Feedback) {
+	log, ok := ctx.Value(retrievalLogKey{}).(*RetrievalLog)
+	if !ok || len(docs) == 0 {
+		return
+	}
+	r := Retrieval{Query: query, vector: normalize(vector), feedback: feedback}
+	for _, doc := range docs {
+		r.DocIDs = append(r.DocIDs, doc.ID)
+		source := ProvenanceOf(doc).Source
+		if source == "" {
+			source = doc.ID
+		}
+		r.Sources = append(r.Sources, source)
+	}
+	log.mu.Lock()
+	log.retrievals = append(log.retrievals, r)
+	log.mu.Unlock()
+}
+
+// dot returns the cosine similarity of normalized a and b, or -Inf when
+// they were embedded by different models.
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return math.Inf(-1)
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
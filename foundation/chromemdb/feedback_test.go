@@ -0,0 +1,100 @@
+package chromemdb
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/philippgille/chromem-go"
+)
+
+func TestFeedbackReranks(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "feedback.json")
+	docs := benchDocs(20)
+	open := func() *ChromemDB {
+		t.Helper()
+		feedback, err := OpenFeedback(path, 2, 0.9)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idx, err := New(ctx, "test", hashEmbedder{}, WithDB(chromem.NewDB()), WithTopK(3), WithFeedback(feedback), WithLogger(quietLogger))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := idx.Store(ctx, docs); err != nil {
+			t.Fatal(err)
+		}
+		return idx
+	}
+	ids := func(docs []*schema.Document) []string {
+		var out []string
+		for _, doc := range docs {
+			out = append(out, doc.ID)
+		}
+		return out
+	}
+
+	idx := open()
+	var log RetrievalLog
+	got, err := idx.Retrieve(WithRetrievalLog(ctx, &log), docs[0].Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].ID != "doc-0" || math.Abs(got[0].Score()-1) > 1e-4 {
+		t.Fatalf("before any vote got %v, first scored %.3f", ids(got), got[0].Score())
+	}
+	if r := log.Retrievals(); len(r) != 1 || r[0].Query != docs[0].Content || !slices.Equal(r[0].DocIDs, ids(got)) {
+		t.Fatalf("logged %+v, want the retrieval of %v", r, ids(got))
+	}
+
+	for range 3 {
+		if n, err := log.Vote(-1); err != nil || n != 3 {
+			t.Fatalf("Vote = %d, %v; want 3 documents", n, err)
+		}
+	}
+	// The votes survive a restart and push the documents out of the results
+	// for the query they were cast on.
+	idx = open()
+	got, err = idx.Retrieve(ctx, docs[0].Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || slices.Contains(ids(got), "doc-0") {
+		t.Errorf("after voting doc-0 down got %v", ids(got))
+	}
+	// Unrelated queries are not affected.
+	got, err = idx.Retrieve(ctx, docs[5].Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].ID != "doc-5" || math.Abs(got[0].Score()-1) > 1e-4 {
+		t.Errorf("unrelated query got %v, first scored %.3f", ids(got), got[0].Score())
+	}
+}
+
+func TestFeedbackAdjustment(t *testing.T) {
+	f := &Feedback{weight: 0.1, minSimilarity: 0.8}
+	query := normalize([]float32{1, 0})
+	near := normalize([]float32{1, 0.1})
+	far := normalize([]float32{0, 1})
+	if adj := f.adjustment(query, "a"); adj != 0 {
+		t.Errorf("adjustment without votes = %v", adj)
+	}
+
+	f.votes = []*Vote{{Vector: near, DocID: "a", Value: 1}, {Vector: far, DocID: "a", Value: -1}}
+	one := f.adjustment(query, "a")
+	if one <= 0 || one >= 0.1 {
+		t.Errorf("adjustment for one vote up = %v, want between 0 and the weight", one)
+	}
+	f.votes = append(f.votes, &Vote{Vector: query, DocID: "a", Value: 1}, &Vote{Vector: query, DocID: "a", Value: 1})
+	if more := f.adjustment(query, "a"); more <= one || more >= 0.1 {
+		t.Errorf("adjustment for three votes up = %v, want more than %v and less than the weight", more, one)
+	}
+	if adj := f.adjustment(query, "b"); adj != 0 {
+		t.Errorf("adjustment of a document without votes = %v", adj)
+	}
+}
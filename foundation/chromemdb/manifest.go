@@ -0,0 +1,238 @@
+package chromemdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/philippgille/chromem-go"
+)
+
+// SchemaVersion is the current version of the exported index format. Bump it
+// whenever the header or the way documents are embedded changes.
+const SchemaVersion = 1
+
+// indexMagic prefixes every versioned export. Files without it are legacy
+// exports produced by chromem-go directly.
+var indexMagic = []byte("GOFORAI-INDEX\n")
+
+// Manifest is the header stored in front of an exported database. It records
+// everything needed to decide whether the index can be queried as-is.
+type Manifest struct {
+	SchemaVersion  int               `json:"schema_version"`
+	EmbeddingModel string            `json:"embedding_model,omitempty"`
+	Dimension      int               `json:"dimension,omitempty"`
+	Collection     string            `json:"collection,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// ErrDBNotFound is returned when the exported database file does not exist,
+// usually because indexing has not been run yet.
+var ErrDBNotFound = errors.New("database not found")
+
+// ErrIndexMismatch is returned when the exported index was built with a
+// different embedding configuration than the one used to query it.
+var ErrIndexMismatch = errors.New("index was built with a different embedding configuration")
+
+// mismatch describes why a manifest cannot be used with the expected model.
+// An empty string means the index is compatible.
+func (m *Manifest) mismatch(embeddingModel string, dimension int) string {
+	switch {
+	case m.SchemaVersion > SchemaVersion:
+		return fmt.Sprintf("index schema version %d is newer than supported version %d", m.SchemaVersion, SchemaVersion)
+	case embeddingModel != "" && m.EmbeddingModel != "" && m.EmbeddingModel != embeddingModel:
+		return fmt.Sprintf("index was embedded with %q but the configured embedder is %q", m.EmbeddingModel, embeddingModel)
+	case dimension != 0 && m.Dimension != 0 && m.Dimension != dimension:
+		return fmt.Sprintf("index has %d-dimensional vectors but the embedder produces %d", m.Dimension, dimension)
+	}
+	return ""
+}
+
+// ReadManifest returns the header of an exported database. Legacy exports
+// without a header return a nil manifest and no error.
+func ReadManifest(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w at %s: run indexing and export first", ErrDBNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	defer f.Close()
+
+	manifest, _, err := readHeader(f)
+	return manifest, err
+}
+
+// IndexStats describes an exported database checked by Verify.
+type IndexStats struct {
+	Manifest    *Manifest // Nil for legacy exports.
+	Collections int
+	Documents   int
+	Dimension   int // Size of every stored embedding; 0 when there are none.
+}
+
+// Verify decodes the whole exported database at path, checks that every
+// document carries an embedding of one size that agrees with the manifest,
+// and that the index suits an embedder recording embeddingModel and
+// producing vectors of dimension. Empty values skip those comparisons. A
+// missing file wraps ErrDBNotFound and an unsuitable index ErrIndexMismatch;
+// other errors mean the file is damaged.
+func Verify(path, embeddingModel string, dimension int) (*IndexStats, error) {
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	collections, err := readPersisted(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &IndexStats{Manifest: manifest, Collections: len(collections)}
+	for name, col := range collections {
+		for id, doc := range col.Documents {
+			if len(doc.Embedding) == 0 {
+				return stats, fmt.Errorf("document %s in collection '%s' of %s has no embedding", id, name, path)
+			}
+			if stats.Dimension == 0 {
+				stats.Dimension = len(doc.Embedding)
+			}
+			if len(doc.Embedding) != stats.Dimension {
+				return stats, fmt.Errorf("document %s in collection '%s' of %s has %d dimensions, want %d",
+					id, name, path, len(doc.Embedding), stats.Dimension)
+			}
+			stats.Documents++
+		}
+	}
+	if manifest != nil && manifest.Dimension != 0 && stats.Dimension != 0 && manifest.Dimension != stats.Dimension {
+		return stats, fmt.Errorf("%s records %d dimensions but stores %d-dimensional vectors", path, manifest.Dimension, stats.Dimension)
+	}
+
+	indexed := &Manifest{SchemaVersion: SchemaVersion, Dimension: stats.Dimension}
+	if manifest != nil {
+		indexed.SchemaVersion, indexed.EmbeddingModel = manifest.SchemaVersion, manifest.EmbeddingModel
+	}
+	if reason := indexed.mismatch(embeddingModel, dimension); reason != "" {
+		return stats, fmt.Errorf("%w: %s: re-run indexing", ErrIndexMismatch, reason)
+	}
+	return stats, nil
+}
+
+// readHeader parses the manifest at the start of r and returns the offset
+// where the chromem-go payload begins.
+func readHeader(r io.Reader) (*Manifest, int64, error) {
+	br := bufio.NewReader(r)
+	prefix, err := br.Peek(len(indexMagic))
+	if err != nil || !bytes.Equal(prefix, indexMagic) {
+		return nil, 0, nil
+	}
+	if _, err := br.Discard(len(indexMagic)); err != nil {
+		return nil, 0, fmt.Errorf("failed to read index header: %w", err)
+	}
+
+	var size uint32
+	if err := binary.Read(br, binary.BigEndian, &size); err != nil {
+		return nil, 0, fmt.Errorf("failed to read index header size: %w", err)
+	}
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(br, raw); err != nil {
+		return nil, 0, fmt.Errorf("failed to read index header: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode index header: %w", err)
+	}
+	return &manifest, int64(len(indexMagic)) + 4 + int64(size), nil
+}
+
+func writeHeader(w io.Writer, manifest *Manifest) error {
+	raw, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode index header: %w", err)
+	}
+	if _, err := w.Write(indexMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(len(raw))); err != nil {
+		return err
+	}
+	_, err = w.Write(raw)
+	return err
+}
+
+// ImportDB loads an exported database with its manifest, which is nil for
+// legacy exports, so that its documents can be copied or added to before it
+// is exported again.
+func ImportDB(path string) (*chromem.DB, *Manifest, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("%w at %s", ErrDBNotFound, path)
+	}
+	return importDB(path)
+}
+
+// importDB loads an exported database, skipping the manifest if present.
+func importDB(path string) (*chromem.DB, *Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	defer f.Close()
+
+	manifest, offset, err := readHeader(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat database %s: %w", path, err)
+	}
+
+	db := chromem.NewDB()
+	payload := io.NewSectionReader(f, offset, info.Size()-offset)
+	if err := db.ImportFromReader(payload, ""); err != nil {
+		return nil, nil, fmt.Errorf("failed to import database from %s: %w", path, err)
+	}
+	return db, manifest, nil
+}
+
+// persistedCollection mirrors the gob layout chromem-go uses for exports.
+type persistedCollection struct {
+	Name      string
+	Metadata  map[string]string
+	Documents map[string]*chromem.Document
+}
+
+// readPersisted decodes the raw collections of an exported database. It is
+// used where chromem-go does not expose what we need (metadata, listing).
+func readPersisted(path string) (map[string]*persistedCollection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	defer f.Close()
+
+	_, offset, err := readHeader(f)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek database %s: %w", path, err)
+	}
+
+	var persisted struct {
+		Collections map[string]*persistedCollection
+	}
+	if err := gob.NewDecoder(f).Decode(&persisted); err != nil {
+		return nil, fmt.Errorf("failed to decode database %s: %w", path, err)
+	}
+	return persisted.Collections, nil
+}
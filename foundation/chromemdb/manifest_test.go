@@ -0,0 +1,198 @@
+package chromemdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/philippgille/chromem-go"
+)
+
+func TestHeaderRoundTrip(t *testing.T) {
+	want := &Manifest{
+		SchemaVersion:  SchemaVersion,
+		EmbeddingModel: "text-embedding-004",
+		Dimension:      768,
+		Collection:     "docs",
+		Metadata:       map[string]string{"chunk_size": "1000"},
+		CreatedAt:      time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+	var buf bytes.Buffer
+	if err := writeHeader(&buf, want); err != nil {
+		t.Fatalf("writeHeader: %v", err)
+	}
+	headerLen := int64(buf.Len())
+	buf.WriteString("payload")
+
+	got, offset, err := readHeader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("readHeader: %v", err)
+	}
+	if offset != headerLen {
+		t.Errorf("payload offset = %d, want %d", offset, headerLen)
+	}
+	if got.EmbeddingModel != want.EmbeddingModel || got.Dimension != want.Dimension ||
+		got.Collection != want.Collection || got.Metadata["chunk_size"] != "1000" || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("readHeader = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadHeaderLegacyFile(t *testing.T) {
+	got, offset, err := readHeader(bytes.NewReader([]byte("\x0f\xff\x81 gob data")))
+	if err != nil || got != nil || offset != 0 {
+		t.Fatalf("readHeader on a legacy file = %v, %d, %v; want nil, 0, nil", got, offset, err)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	if _, err := ReadManifest(filepath.Join(t.TempDir(), "missing.gob")); !errors.Is(err, ErrDBNotFound) {
+		t.Errorf("missing file: got %v, want ErrDBNotFound", err)
+	}
+
+	path := exportTestIndex(t, 5, WithEmbeddingModel("model-a"))
+	m, err := ReadManifest(path)
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+	if m.EmbeddingModel != "model-a" || m.Dimension != benchDimension || m.Collection != "test" {
+		t.Errorf("unexpected manifest %+v", m)
+	}
+
+	legacy := filepath.Join(t.TempDir(), "legacy.gob")
+	db := chromem.NewDB()
+	if _, err := db.CreateCollection("test", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportDB(db, legacy, nil); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := ReadManifest(legacy); err != nil || m != nil {
+		t.Errorf("legacy export: got %v, %v; want nil, nil", m, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	path := exportTestIndex(t, 5, WithEmbeddingModel("model-a"))
+	stats, err := Verify(path, "model-a", benchDimension)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if stats.Collections != 1 || stats.Documents != 5 || stats.Dimension != benchDimension || stats.Manifest == nil {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	if _, err := Verify(path, "model-b", 0); !errors.Is(err, ErrIndexMismatch) {
+		t.Errorf("other model: got %v, want ErrIndexMismatch", err)
+	}
+	if _, err := Verify(path, "", benchDimension*2); !errors.Is(err, ErrIndexMismatch) {
+		t.Errorf("other dimension: got %v, want ErrIndexMismatch", err)
+	}
+	if _, err := Verify(filepath.Join(t.TempDir(), "missing.gob"), "", 0); !errors.Is(err, ErrDBNotFound) {
+		t.Errorf("missing file: got %v, want ErrDBNotFound", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := filepath.Join(t.TempDir(), "truncated.gob")
+	if err := os.WriteFile(truncated, data[:len(data)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(truncated, "", 0); err == nil || errors.Is(err, ErrIndexMismatch) {
+		t.Errorf("truncated file: got %v, want a decoding error", err)
+	}
+}
+
+func TestLoadSetsDimensionFromManifest(t *testing.T) {
+	path := exportTestIndex(t, 5, WithEmbeddingModel("model-a"))
+	idx, err := New(context.Background(), "test", hashEmbedder{},
+		WithDBPath(path), WithEmbeddingModel("model-a"), WithLogger(quietLogger))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := idx.Manifest().Dimension; got != benchDimension {
+		t.Errorf("Manifest().Dimension = %d before any embedding, want %d", got, benchDimension)
+	}
+}
+
+func TestLoadRejectsOtherEmbeddingModel(t *testing.T) {
+	path := exportTestIndex(t, 5, WithEmbeddingModel("model-a"))
+	_, err := New(context.Background(), "test", hashEmbedder{},
+		WithDBPath(path), WithEmbeddingModel("model-b"), WithLogger(quietLogger))
+	if !errors.Is(err, ErrIndexMismatch) {
+		t.Fatalf("got %v, want ErrIndexMismatch", err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	path := exportTestIndex(t, 20, WithEmbeddingModel("model-a"),
+		WithCollectionMetadata(map[string]string{"chunk_size": "1000"}))
+
+	idx, err := New(context.Background(), "test", hashEmbedder{},
+		WithDBPath(path), WithEmbeddingModel("model-b"), WithAutoMigrate(), WithLogger(quietLogger))
+	if err != nil {
+		t.Fatalf("New with auto-migrate: %v", err)
+	}
+	if n := idx.count(); n != 20 {
+		t.Errorf("migrated index holds %d documents, want 20", n)
+	}
+
+	m, err := ReadManifest(path)
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+	if m.EmbeddingModel != "model-b" || m.Dimension != benchDimension || m.Metadata["chunk_size"] != "1000" {
+		t.Errorf("rewritten manifest = %+v", m)
+	}
+	old, err := ReadManifest(path + ".bak")
+	if err != nil || old.EmbeddingModel != "model-a" {
+		t.Errorf("backup manifest = %+v, %v; want the original", old, err)
+	}
+}
+
+// failingEmbedder fails every call, as an unreachable embedding API would.
+type failingEmbedder struct{}
+
+func (failingEmbedder) EmbedStrings(context.Context, []string, ...embedding.Option) ([][]float64, error) {
+	return nil, errors.New("embedding API unavailable")
+}
+
+func TestFailedMigrationKeepsOriginal(t *testing.T) {
+	failExport := func(*chromem.DB, string, *Manifest) error { return errors.New("disk full") }
+	for name, tc := range map[string]struct {
+		embedder embedding.Embedder
+		export   func(*chromem.DB, string, *Manifest) error
+	}{
+		"embedding fails": {failingEmbedder{}, ExportDB},
+		"export fails":    {hashEmbedder{}, failExport},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := exportTestIndex(t, 5, WithEmbeddingModel("model-a"))
+			before, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exportDB = tc.export
+			t.Cleanup(func() { exportDB = ExportDB })
+			_, err = New(context.Background(), "test", tc.embedder,
+				WithDBPath(path), WithEmbeddingModel("model-b"), WithAutoMigrate(), WithLogger(quietLogger))
+			if err == nil {
+				t.Fatal("expected the migration to fail")
+			}
+			after, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("original index is gone after a failed migration: %v", err)
+			}
+			if !bytes.Equal(before, after) {
+				t.Error("original index changed after a failed migration")
+			}
+		})
+	}
+}
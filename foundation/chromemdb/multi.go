@@ -0,0 +1,145 @@
+package chromemdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+)
+
+// MetaKnowledgeSource is the metadata key MultiRetriever sets on each
+// document, holding the name of the source it came from, or the
+// comma-separated names of the sources that all returned it.
+const MetaKnowledgeSource = "knowledge_source"
+
+// MetaFusedScore is the metadata key holding a document's reciprocal-rank
+// fusion score, by which MultiRetriever orders its results.
+const MetaFusedScore = "fused_score"
+
+// rrfK dampens the weight of the top ranks in reciprocal-rank fusion; 60 is
+// the constant of the original paper and works well across corpora.
+const rrfK = 60
+
+// KnowledgeSource is a retriever searched by a MultiRetriever, such as a collection
+// of the knowledge base or the index of past conversations.
+type KnowledgeSource struct {
+	Name      string
+	Retriever retriever.Retriever
+}
+
+// MultiRetriever searches several sources at once and fuses their results
+// with reciprocal-rank fusion: a document scores the sum of 1/(60+rank) over
+// the sources that returned it, so results are ranked without comparing
+// similarities that different collections compute differently, and a
+// document several sources hold under the same ID ranks above one only a
+// single source returned at the same rank. Documents keep their similarity
+// as their score, the best of the sources'; the fused score is in
+// MetaFusedScore.
+type MultiRetriever struct {
+	sources []KnowledgeSource
+	topK    int
+	logger  *slog.Logger
+}
+
+// NewMultiRetriever returns a retriever over sources returning topK
+// documents, unless retriever.WithTopK asks for another number.
+func NewMultiRetriever(topK int, sources ...KnowledgeSource) (*MultiRetriever, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("at least one source is required")
+	}
+	seen := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		if s.Name == "" || s.Retriever == nil {
+			return nil, errors.New("every source needs a name and a retriever")
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("source %q is listed twice", s.Name)
+		}
+		seen[s.Name] = true
+	}
+	if topK <= 0 {
+		topK = defaultTopK
+	}
+	return &MultiRetriever{sources: sources, topK: topK, logger: slog.Default()}, nil
+}
+
+// Retrieve queries every source concurrently for the topK documents and
+// returns the topK best of them by fused rank. A source that fails is
+// logged and left out; Retrieve only fails when every source does.
+func (m *MultiRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	topK := *retriever.GetCommonOptions(&retriever.Options{TopK: &m.topK}, opts...).TopK
+
+	results := make([][]*schema.Document, len(m.sources))
+	errs := make([]error, len(m.sources))
+	var wg sync.WaitGroup
+	for i, s := range m.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = s.Retriever.Retrieve(ctx, query, append(opts, retriever.WithTopK(topK))...)
+		}()
+	}
+	wg.Wait()
+
+	type fused struct {
+		doc    *schema.Document
+		source string
+		score  float64
+	}
+	var ranked []*fused
+	byKey := make(map[string]*fused)
+	failed := 0
+	for i, s := range m.sources {
+		if errs[i] != nil {
+			failed++
+			m.logger.Warn("knowledge source search failed", "source", s.Name, "error", errs[i])
+			continue
+		}
+		for rank, doc := range results[i] {
+			f, ok := byKey[doc.ID]
+			if !ok {
+				f = &fused{doc: doc, source: s.Name}
+				byKey[doc.ID] = f
+				ranked = append(ranked, f)
+			} else {
+				if doc.Score() > f.doc.Score() {
+					f.doc = doc
+				}
+				f.source += "," + s.Name
+			}
+			f.score += 1 / float64(rrfK+rank+1)
+		}
+	}
+	if failed == len(m.sources) {
+		return nil, fmt.Errorf("every knowledge source failed: %w", errors.Join(errs...))
+	}
+
+	// Ties, such as the first documents of two sources, go to the more
+	// similar document.
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].doc.Score() > ranked[j].doc.Score()
+	})
+	if len(ranked) > topK {
+		ranked = ranked[:topK]
+	}
+	docs := make([]*schema.Document, len(ranked))
+	for i, f := range ranked {
+		if f.doc.MetaData == nil {
+			f.doc.MetaData = make(map[string]any)
+		}
+		f.doc.MetaData[MetaKnowledgeSource] = f.source
+		f.doc.MetaData[MetaFusedScore] = f.score
+		docs[i] = f.doc
+	}
+	return docs, nil
+}
+
+var _ retriever.Retriever = (*MultiRetriever)(nil)
@@ -0,0 +1,100 @@
+package chromemdb
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fixedRetriever returns its documents, scored by their order, up to the
+// requested number.
+type fixedRetriever struct {
+	ids []string
+	err error
+}
+
+func (f fixedRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	topK := *retriever.GetCommonOptions(&retriever.Options{TopK: new(int)}, opts...).TopK
+	var docs []*schema.Document
+	for i, id := range f.ids[:min(topK, len(f.ids))] {
+		doc := &schema.Document{ID: id, Content: id}
+		docs = append(docs, doc.WithScore(1-float64(i)/10))
+	}
+	return docs, nil
+}
+
+func TestMultiRetriever(t *testing.T) {
+	ctx := context.Background()
+	ids := func(docs []*schema.Document) []string {
+		var out []string
+		for _, doc := range docs {
+			out = append(out, doc.ID)
+		}
+		return out
+	}
+	multi, err := NewMultiRetriever(4,
+		KnowledgeSource{Name: "docs", Retriever: fixedRetriever{ids: []string{"a", "b", "c", "d"}}},
+		KnowledgeSource{Name: "code", Retriever: fixedRetriever{ids: []string{"x", "c", "y", "z"}}},
+		KnowledgeSource{Name: "memory", Retriever: fixedRetriever{err: errors.New("offline")}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multi.logger = quietLogger
+
+	got, err := multi.Retrieve(ctx, "q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// c, returned by both sources, outranks the first document of either;
+	// of a and x, tied on rank, a is the more similar.
+	if want := []string{"c", "a", "x", "b"}; !slices.Equal(ids(got), want) {
+		t.Fatalf("got %v, want %v", ids(got), want)
+	}
+	if src := got[0].MetaData[MetaKnowledgeSource]; src != "docs,code" {
+		t.Errorf("c came from %v, want docs,code", src)
+	}
+	if src := got[2].MetaData[MetaKnowledgeSource]; src != "code" {
+		t.Errorf("x came from %v, want code", src)
+	}
+	if fused, _ := got[0].MetaData[MetaFusedScore].(float64); fused != 1.0/63+1.0/62 {
+		t.Errorf("fused score of c = %v", fused)
+	}
+	if got[0].Score() != 0.9 {
+		t.Errorf("score of c = %v, want its best similarity 0.9", got[0].Score())
+	}
+
+	got, err = multi.Retrieve(ctx, "q", retriever.WithTopK(2))
+	if err != nil || len(got) != 2 {
+		t.Errorf("WithTopK(2) got %v, %v", ids(got), err)
+	}
+}
+
+func TestMultiRetrieverErrors(t *testing.T) {
+	failing := fixedRetriever{err: errors.New("offline")}
+	multi, err := NewMultiRetriever(0,
+		KnowledgeSource{Name: "docs", Retriever: failing},
+		KnowledgeSource{Name: "code", Retriever: failing},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multi.logger = quietLogger
+	if _, err := multi.Retrieve(context.Background(), "q"); err == nil {
+		t.Error("Retrieve succeeded with every source failing")
+	}
+
+	if _, err := NewMultiRetriever(3); err == nil {
+		t.Error("NewMultiRetriever accepted no sources")
+	}
+	if _, err := NewMultiRetriever(3, KnowledgeSource{Name: "docs", Retriever: failing}, KnowledgeSource{Name: "docs", Retriever: failing}); err == nil {
+		t.Error("NewMultiRetriever accepted a duplicate source")
+	}
+}
@@ -0,0 +1,81 @@
+package chromemdb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chunking"
+)
+
+// Neighbors returns the chunks split from the same file as doc whose chunk
+// index (chunking.MetaChunkIndex) is at most window away from doc's, in the
+// order of the file, doc itself left out. Documents indexed without a source
+// or chunk index have no neighbors, and chunks the identity in ctx may not
+// read are left out. The neighbors are not scored.
+func (c *ChromemDB) Neighbors(ctx context.Context, doc *schema.Document, window int) ([]*schema.Document, error) {
+	source, _ := doc.MetaData[MetaSource].(string)
+	index, ok := ChunkIndex(doc)
+	if source == "" || !ok || window <= 0 {
+		return nil, nil
+	}
+	identity, restricted := IdentityFrom(ctx)
+
+	var find func(where map[string]string) (*schema.Document, error)
+	if c.quant != nil {
+		find = func(where map[string]string) (*schema.Document, error) {
+			d, ok := c.quant.find(where)
+			if !ok {
+				return nil, nil
+			}
+			return toDocument(d.ID, d.Content, d.Metadata, 0), nil
+		}
+	} else {
+		// chromem-go only filters within a similarity search; doc's own
+		// vector serves as the query.
+		stored, err := c.collection.GetByID(ctx, doc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk %s: %w", doc.ID, err)
+		}
+		find = func(where map[string]string) (*schema.Document, error) {
+			results, err := c.collection.QueryEmbedding(ctx, stored.Embedding, 1, where, nil)
+			if err != nil || len(results) == 0 {
+				return nil, err
+			}
+			return toDocument(results[0].ID, results[0].Content, results[0].Metadata, 0), nil
+		}
+	}
+
+	var neighbors []*schema.Document
+	for i := max(index-window, 0); i <= index+window; i++ {
+		if i == index {
+			continue
+		}
+		neighbor, err := find(map[string]string{MetaSource: source, chunking.MetaChunkIndex: strconv.Itoa(i)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk %d of %s: %w", i, source, err)
+		}
+		if neighbor == nil {
+			continue
+		}
+		if access, _ := neighbor.MetaData[MetaAccess].(string); restricted && !identity.CanRead(access) {
+			continue
+		}
+		neighbors = append(neighbors, neighbor)
+	}
+	return neighbors, nil
+}
+
+// ChunkIndex returns the position of a chunk in the file it was split from,
+// as recorded by the splitter.
+func ChunkIndex(doc *schema.Document) (int, bool) {
+	switch v := doc.MetaData[chunking.MetaChunkIndex].(type) {
+	case int:
+		return v, true
+	case string:
+		i, err := strconv.Atoi(v)
+		return i, err == nil
+	}
+	return 0, false
+}
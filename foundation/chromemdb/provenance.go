@@ -0,0 +1,54 @@
+package chromemdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Metadata keys recording where an indexed document came from and when it
+// was indexed.
+const (
+	MetaSource    = "source"     // Path of the file, relative to the indexed directory, or its URL.
+	MetaIndexedAt = "indexed_at" // RFC 3339 time of the indexing run.
+)
+
+// Provenance is where a retrieved document came from and when it was
+// indexed. Documents indexed before provenance was recorded have neither.
+type Provenance struct {
+	Source    string
+	IndexedAt time.Time
+}
+
+// ProvenanceOf reads the provenance recorded on doc.
+func ProvenanceOf(doc *schema.Document) Provenance {
+	var p Provenance
+	p.Source, _ = doc.MetaData[MetaSource].(string)
+	if v, ok := doc.MetaData[MetaIndexedAt].(string); ok {
+		p.IndexedAt, _ = time.Parse(time.RFC3339, v)
+	}
+	return p
+}
+
+// AsOf returns the indexing date as "2006-01-02", or "" when it is unknown.
+func (p Provenance) AsOf() string {
+	if p.IndexedAt.IsZero() {
+		return ""
+	}
+	return p.IndexedAt.Format(time.DateOnly)
+}
+
+// String describes the provenance as "talks.md, as of 2025-10-01", leaving
+// out what is unknown.
+func (p Provenance) String() string {
+	switch {
+	case p.Source != "" && !p.IndexedAt.IsZero():
+		return fmt.Sprintf("%s, as of %s", p.Source, p.AsOf())
+	case p.Source != "":
+		return p.Source
+	case !p.IndexedAt.IsZero():
+		return "as of " + p.AsOf()
+	}
+	return ""
+}
@@ -0,0 +1,347 @@
+package chromemdb
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Quantization selects how embeddings are held in memory.
+type Quantization int
+
+const (
+	// QuantizeNone keeps full float32 vectors inside chromem-go.
+	QuantizeNone Quantization = iota
+	// QuantizeInt8 stores one signed byte per dimension plus a per-vector
+	// scale, about a quarter of the float32 footprint.
+	QuantizeInt8
+	// QuantizeFloat16 stores IEEE half-precision values, half the footprint
+	// and practically lossless for unit vectors.
+	QuantizeFloat16
+)
+
+func (q Quantization) String() string {
+	switch q {
+	case QuantizeInt8:
+		return "int8"
+	case QuantizeFloat16:
+		return "float16"
+	default:
+		return "none"
+	}
+}
+
+// ParseQuantization parses "none", "int8" or "float16". An empty string means none.
+func ParseQuantization(s string) (Quantization, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none", "float32":
+		return QuantizeNone, nil
+	case "int8":
+		return QuantizeInt8, nil
+	case "float16", "fp16":
+		return QuantizeFloat16, nil
+	}
+	return QuantizeNone, fmt.Errorf("unknown quantization %q (want none, int8 or float16)", s)
+}
+
+// quantDoc is everything but the vector of a stored document.
+type quantDoc struct {
+	ID       string
+	Content  string
+	Metadata map[string]string
+}
+
+// quantResult is one hit of a quantized search.
+type quantResult struct {
+	quantDoc
+	Similarity float32
+}
+
+// quantIndex is a flat, exhaustive cosine index over quantized unit vectors.
+// Vectors are laid out back to back so a scan touches contiguous memory.
+type quantIndex struct {
+	kind Quantization
+
+	mu     sync.RWMutex
+	dim    int
+	docs   []quantDoc
+	byID   map[string]int
+	int8s  []int8    // QuantizeInt8: dim codes per document.
+	scales []float32 // QuantizeInt8: one scale per document.
+	halves []uint16  // QuantizeFloat16: dim values per document.
+}
+
+func newQuantIndex(kind Quantization) *quantIndex {
+	return &quantIndex{kind: kind, byID: make(map[string]int)}
+}
+
+func (x *quantIndex) count() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// add normalizes and quantizes vec. A document with an existing ID is replaced.
+func (x *quantIndex) add(doc quantDoc, vec []float32) error {
+	unit := normalize(vec)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.dim == 0 {
+		x.dim = len(unit)
+	}
+	if len(unit) != x.dim {
+		return fmt.Errorf("document '%s' has %d dimensions, the index has %d", doc.ID, len(unit), x.dim)
+	}
+
+	i, exists := x.byID[doc.ID]
+	if !exists {
+		i = len(x.docs)
+		x.byID[doc.ID] = i
+		x.docs = append(x.docs, doc)
+		switch x.kind {
+		case QuantizeInt8:
+			x.int8s = append(x.int8s, make([]int8, x.dim)...)
+			x.scales = append(x.scales, 0)
+		case QuantizeFloat16:
+			x.halves = append(x.halves, make([]uint16, x.dim)...)
+		}
+	}
+	x.docs[i] = doc
+
+	switch x.kind {
+	case QuantizeInt8:
+		x.scales[i] = quantizeInt8(unit, x.int8s[i*x.dim:(i+1)*x.dim])
+	case QuantizeFloat16:
+		codes := x.halves[i*x.dim : (i+1)*x.dim]
+		for j, v := range unit {
+			codes[j] = float32ToHalf(v)
+		}
+	}
+	return nil
+}
+
+// find returns the first document whose metadata holds every value of where.
+func (x *quantIndex) find(where map[string]string) (quantDoc, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+next:
+	for _, doc := range x.docs {
+		for k, v := range where {
+			if doc.Metadata[k] != v {
+				continue next
+			}
+		}
+		return doc, true
+	}
+	return quantDoc{}, false
+}
+
+// query returns the topK documents most similar to vec, best first, among
+// those whose metadata keep accepts; a nil keep accepts all. The query stays
+// in float32; only the stored side is quantized.
+func (x *quantIndex) query(vec []float32, topK int, keep func(metadata map[string]string) bool) ([]quantResult, error) {
+	q := normalize(vec)
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	n := len(x.docs)
+	if topK > n {
+		topK = n
+	}
+	if topK <= 0 {
+		return nil, nil
+	}
+	if len(q) != x.dim {
+		return nil, fmt.Errorf("query has %d dimensions, the index has %d", len(q), x.dim)
+	}
+
+	// Split the scan across CPUs; each worker keeps its own top k.
+	workers := runtime.NumCPU()
+	if per := 1024; n < workers*per {
+		workers = (n + per - 1) / per
+	}
+	partial := make([][]scored, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			top := topN{k: topK}
+			for i := w * n / workers; i < (w+1)*n/workers; i++ {
+				if keep != nil && !keep(x.docs[i].Metadata) {
+					continue
+				}
+				top.push(scored{i, x.similarity(q, i)})
+			}
+			partial[w] = top.items
+		}(w)
+	}
+	wg.Wait()
+
+	top := topN{k: topK}
+	for _, items := range partial {
+		for _, s := range items {
+			top.push(s)
+		}
+	}
+	results := make([]quantResult, len(top.items))
+	for i, s := range top.items {
+		results[i] = quantResult{quantDoc: x.docs[s.index], Similarity: s.score}
+	}
+	return results, nil
+}
+
+func (x *quantIndex) similarity(q []float32, i int) float32 {
+	var dot float32
+	switch x.kind {
+	case QuantizeInt8:
+		codes := x.int8s[i*x.dim : (i+1)*x.dim]
+		for j, c := range codes {
+			dot += q[j] * float32(c)
+		}
+		dot *= x.scales[i]
+	case QuantizeFloat16:
+		table := halfTable()
+		codes := x.halves[i*x.dim : (i+1)*x.dim]
+		for j, c := range codes {
+			dot += q[j] * table[c]
+		}
+	}
+	return dot
+}
+
+type scored struct {
+	index int
+	score float32
+}
+
+// topN keeps the k highest scores in descending order. k is small (the
+// retriever's topK), so insertion into a sorted slice beats a heap.
+type topN struct {
+	k     int
+	items []scored
+}
+
+func (t *topN) push(s scored) {
+	if len(t.items) == t.k && s.score <= t.items[len(t.items)-1].score {
+		return
+	}
+	pos := sort.Search(len(t.items), func(i int) bool { return t.items[i].score < s.score })
+	if len(t.items) < t.k {
+		t.items = append(t.items, scored{})
+	}
+	copy(t.items[pos+1:], t.items[pos:])
+	t.items[pos] = s
+}
+
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, f := range v {
+		norm += float64(f) * float64(f)
+	}
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	inv := float32(1 / math.Sqrt(norm))
+	for i, f := range v {
+		out[i] = f * inv
+	}
+	return out
+}
+
+// quantizeInt8 writes symmetric int8 codes for v into codes and returns the
+// scale that maps them back: v[i] ≈ codes[i] * scale.
+func quantizeInt8(v []float32, codes []int8) float32 {
+	var maxAbs float32
+	for _, f := range v {
+		if a := float32(math.Abs(float64(f))); a > maxAbs {
+			maxAbs = a
+		}
+	}
+	if maxAbs == 0 {
+		clear(codes)
+		return 0
+	}
+	scale := maxAbs / 127
+	for i, f := range v {
+		codes[i] = int8(math.Round(float64(f / scale)))
+	}
+	return scale
+}
+
+// float32ToHalf converts f to IEEE 754 binary16, rounding to nearest even.
+func float32ToHalf(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int32(bits>>23&0xff) - 127 + 15
+	mant := bits & 0x7fffff
+
+	switch {
+	case bits&0x7fffffff == 0:
+		return sign
+	case exp >= 0x1f: // Overflow, infinity and NaN. Unit vectors never get here.
+		if bits&0x7f800000 == 0x7f800000 && mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp <= 0: // Subnormal half or zero.
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint32(14 - exp)
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		mid := uint32(1) << (shift - 1)
+		if rem > mid || (rem == mid && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	}
+
+	half := uint32(exp)<<10 | mant>>13
+	rem := mant & 0x1fff
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++ // May carry into the exponent, which is still correct.
+	}
+	return sign | uint16(half)
+}
+
+// halfToFloat32 converts an IEEE 754 binary16 value to float32.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign)
+	case exp == 0: // Subnormal: renormalize.
+		e := uint32(127 - 15 + 1)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		return math.Float32frombits(sign | e<<23 | (mant&0x3ff)<<13)
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp-15+127)<<23 | mant<<13)
+}
+
+// halfTable maps every binary16 bit pattern to its float32 value, so the
+// scan dequantizes with a single load.
+var halfTable = sync.OnceValue(func() *[1 << 16]float32 {
+	var t [1 << 16]float32
+	for i := range t {
+		t[i] = halfToFloat32(uint16(i))
+	}
+	return &t
+})
@@ -0,0 +1,157 @@
+package chromemdb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestFloat32ToHalf(t *testing.T) {
+	for _, tc := range []struct {
+		in   float32
+		want uint16
+	}{
+		{0, 0x0000},
+		{float32(math.Copysign(0, -1)), 0x8000},
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{65504, 0x7bff},                // largest finite half
+		{65520, 0x7c00},                // rounds up to infinity
+		{float32(math.Inf(1)), 0x7c00}, // infinities keep their sign
+		{float32(math.Inf(-1)), 0xfc00},
+		{0x1p-14, 0x0400},                               // smallest normal
+		{0x3ffp-24, 0x03ff},                             // largest subnormal
+		{0x1p-24, 0x0001},                               // smallest subnormal
+		{0x1p-25, 0x0000},                               // halfway to 0x0001, ties to even
+		{0x3p-26, 0x0001},                               // above halfway
+		{0x1p-26, 0x0000},                               // underflow
+		{1 + 0x1p-11, 0x3c00},                           // halfway, ties to even
+		{1 + 0x3p-11, 0x3c02},                           // halfway, ties to even
+		{1 + 0x1p-11 + 0x1p-20, 0x3c01},                 // above halfway
+		{0x7ffp-11 + 0x1p-12, 0x3c00},                   // carries into the exponent
+		{float32(math.Nextafter32(0x1p-14, 0)), 0x0400}, // rounds up into the normals
+	} {
+		if got := float32ToHalf(tc.in); got != tc.want {
+			t.Errorf("float32ToHalf(%g) = %#04x, want %#04x", tc.in, got, tc.want)
+		}
+	}
+
+	nan := float32ToHalf(float32(math.NaN()))
+	if nan&0x7c00 != 0x7c00 || nan&0x3ff == 0 {
+		t.Errorf("float32ToHalf(NaN) = %#04x, want a NaN", nan)
+	}
+}
+
+func TestHalfRoundTrip(t *testing.T) {
+	for i := 0; i < 1<<16; i++ {
+		h := uint16(i)
+		f := halfToFloat32(h)
+		if h&0x7c00 == 0x7c00 && h&0x3ff != 0 {
+			if !math.IsNaN(float64(f)) {
+				t.Errorf("halfToFloat32(%#04x) = %g, want NaN", h, f)
+			}
+			continue
+		}
+		if got := float32ToHalf(f); got != h {
+			t.Errorf("float32ToHalf(halfToFloat32(%#04x)) = %#04x (via %g)", h, got, f)
+		}
+		if halfTable()[h] != f && !math.IsNaN(float64(f)) {
+			t.Errorf("halfTable()[%#04x] = %g, want %g", h, halfTable()[h], f)
+		}
+	}
+}
+
+func TestQuantizeInt8(t *testing.T) {
+	v := []float32{0.5, -0.25, 0, 1e-9, -0.5, 0.499}
+	codes := make([]int8, len(v))
+	scale := quantizeInt8(v, codes)
+	if want := float32(0.5) / 127; scale != want {
+		t.Errorf("scale = %g, want %g", scale, want)
+	}
+	want := []int8{127, -64, 0, 0, -127, 127}
+	for i := range v {
+		if codes[i] != want[i] {
+			t.Errorf("codes[%d] = %d for %g, want %d", i, codes[i], v[i], want[i])
+		}
+		if err := math.Abs(float64(v[i] - float32(codes[i])*scale)); err > float64(scale)/2+1e-9 {
+			t.Errorf("v[%d] = %g dequantizes to %g", i, v[i], float32(codes[i])*scale)
+		}
+	}
+
+	codes = []int8{1, 2, 3}
+	if scale := quantizeInt8(make([]float32, 3), codes); scale != 0 || codes[0] != 0 || codes[1] != 0 || codes[2] != 0 {
+		t.Errorf("zero vector quantized to %v with scale %g, want zero codes and scale", codes, scale)
+	}
+}
+
+// TestQuantizedRecall compares the top k of each quantization, loaded from an
+// exported index, against an exact float64 search of the same corpus.
+func TestQuantizedRecall(t *testing.T) {
+	const (
+		corpus  = 2000
+		queries = 50
+		k       = 10
+	)
+	path := exportTestIndex(t, corpus)
+	ctx := context.Background()
+
+	docs := benchDocs(corpus)
+	texts := make([]string, corpus)
+	for i, doc := range docs {
+		texts[i] = doc.Content
+	}
+	vectors, _ := hashEmbedder{}.EmbedStrings(ctx, texts)
+	exact := make([]map[string]bool, queries)
+	for qi := range exact {
+		q, _ := hashEmbedder{}.EmbedStrings(ctx, []string{recallQuery(qi)})
+		ids := make([]int, corpus)
+		scores := make([]float64, corpus)
+		for i, v := range vectors {
+			ids[i] = i
+			for j := range v {
+				scores[i] += v[j] * q[0][j]
+			}
+		}
+		sort.Slice(ids, func(a, b int) bool { return scores[ids[a]] > scores[ids[b]] })
+		exact[qi] = make(map[string]bool, k)
+		for _, i := range ids[:k] {
+			exact[qi][docs[i].ID] = true
+		}
+	}
+
+	minRecall := map[Quantization]float64{QuantizeNone: 1, QuantizeFloat16: 0.99, QuantizeInt8: 0.9}
+	for _, q := range benchQuantizations {
+		t.Run(q.String(), func(t *testing.T) {
+			idx, err := New(ctx, "test", hashEmbedder{}, WithDBPath(path), WithTopK(k), WithQuantization(q), WithLogger(quietLogger))
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if n := idx.count(); n != corpus {
+				t.Fatalf("loaded %d documents, want %d", n, corpus)
+			}
+			hits := 0
+			for qi := range exact {
+				got, err := idx.Retrieve(ctx, recallQuery(qi))
+				if err != nil {
+					t.Fatalf("Retrieve: %v", err)
+				}
+				for _, doc := range got {
+					if exact[qi][doc.ID] {
+						hits++
+					}
+				}
+			}
+			recall := float64(hits) / float64(queries*k)
+			t.Logf("recall@%d = %.3f", k, recall)
+			if recall < minRecall[q] {
+				t.Errorf("recall@%d = %.3f, want at least %.2f", k, recall, minRecall[q])
+			}
+		})
+	}
+}
+
+func recallQuery(i int) string {
+	return fmt.Sprintf("reliable Go services %d", i)
+}
@@ -0,0 +1,76 @@
+package chromemdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// snippetLen is the length, in runes, of a source snippet.
+const snippetLen = 200
+
+// Source is a retrieved document as a citation.
+type Source struct {
+	ID      string  `json:"id" jsonschema:"description=ID of the document in the knowledge base."`
+	Snippet string  `json:"snippet" jsonschema:"description=Start of the document."`
+	Score   float64 `json:"score" jsonschema:"description=Similarity of the document to the query, from -1 to 1."`
+	Origin  string  `json:"origin,omitempty" jsonschema:"description=File or URL the document was indexed from."`
+	AsOf    string  `json:"as_of,omitempty" jsonschema:"description=Date the document was indexed; it may have changed since."`
+}
+
+// Result is the outcome of a retrieval in a form that can be rendered with
+// citations: Answer holds the documents' text, each introduced by its number
+// in Sources and its provenance, e.g. "[1] talk-42 (talks.md, as of
+// 2025-10-01)".
+type Result struct {
+	Answer  string   `json:"answer" jsonschema:"description=Text of the retrieved documents, each headed by its source number."`
+	Sources []Source `json:"sources" jsonschema:"description=The retrieved documents, most similar first."`
+}
+
+// NewResult builds a Result from retrieved documents, keeping their order.
+func NewResult(docs []*schema.Document) *Result {
+	result := &Result{Sources: make([]Source, 0, len(docs))}
+	var answer strings.Builder
+	for i, doc := range docs {
+		if i > 0 {
+			answer.WriteString("\n\n")
+		}
+		provenance := ProvenanceOf(doc)
+		fmt.Fprintf(&answer, "[%d] %s", i+1, doc.ID)
+		if p := provenance.String(); p != "" {
+			fmt.Fprintf(&answer, " (%s)", p)
+		}
+		fmt.Fprintf(&answer, "\n%s", doc.Content)
+		result.Sources = append(result.Sources, Source{
+			ID:      doc.ID,
+			Snippet: snippet(doc.Content),
+			Score:   doc.Score(),
+			Origin:  provenance.Source,
+			AsOf:    provenance.AsOf(),
+		})
+	}
+	result.Answer = answer.String()
+	return result
+}
+
+// RetrieveResult is Retrieve returning a Result.
+func (c *ChromemDB) RetrieveResult(ctx context.Context, query string) (*Result, error) {
+	docs, err := c.Retrieve(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return NewResult(docs), nil
+}
+
+// snippet returns the first snippetLen runes of content on one line.
+func snippet(content string) string {
+	content = strings.Join(strings.Fields(content), " ")
+	if utf8.RuneCountInString(content) <= snippetLen {
+		return content
+	}
+	runes := []rune(content)
+	return strings.TrimSpace(string(runes[:snippetLen])) + "…"
+}
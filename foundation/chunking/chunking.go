@@ -0,0 +1,141 @@
+// Package chunking splits loaded documents into overlapping chunks so that
+// each embedding covers a focused piece of text.
+package chunking
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// MetaChunkIndex is the metadata key holding a chunk's position in its source document.
+const MetaChunkIndex = "chunk_index"
+
+// Splitter is a recursive character splitter. It tries the coarsest separator
+// first and only falls back to finer ones for pieces that are still too large.
+type Splitter struct {
+	cfg config.Chunking
+}
+
+// New creates a Splitter from a validated chunking configuration.
+func New(cfg config.Chunking) (*Splitter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid chunking config: %w", err)
+	}
+	return &Splitter{cfg: cfg}, nil
+}
+
+// Transform splits every source document into chunks, copying its metadata
+// and recording the chunk index on each one.
+func (s *Splitter) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	var out []*schema.Document
+	for _, doc := range src {
+		chunks := s.Split(doc.Content)
+		for i, chunk := range chunks {
+			metadata := make(map[string]any, len(doc.MetaData)+1)
+			for k, v := range doc.MetaData {
+				metadata[k] = v
+			}
+			metadata[MetaChunkIndex] = i
+
+			id := ""
+			if doc.ID != "" {
+				id = fmt.Sprintf("%s#%d", doc.ID, i)
+			}
+			out = append(out, &schema.Document{ID: id, Content: chunk, MetaData: metadata})
+		}
+	}
+	return out, nil
+}
+
+// Split breaks text into chunks no longer than the configured size.
+func (s *Splitter) Split(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return s.split(text, s.cfg.Separators())
+}
+
+func (s *Splitter) split(text string, separators []string) []string {
+	// Pick the first separator that actually occurs in the text.
+	sep, rest := "", []string(nil)
+	for i, candidate := range separators {
+		if candidate == "" || strings.Contains(text, candidate) {
+			sep, rest = candidate, separators[i+1:]
+			break
+		}
+	}
+
+	var chunks, pending []string
+	for _, piece := range splitKeepingSeparator(text, sep) {
+		if len(piece) <= s.cfg.Size {
+			pending = append(pending, piece)
+			continue
+		}
+		// The piece is too large on its own: flush what we have and recurse.
+		chunks = append(chunks, s.merge(pending)...)
+		pending = nil
+		if len(rest) == 0 {
+			chunks = append(chunks, piece)
+		} else {
+			chunks = append(chunks, s.split(piece, rest)...)
+		}
+	}
+	return append(chunks, s.merge(pending)...)
+}
+
+// merge packs small pieces into chunks, carrying the tail of each chunk into
+// the next one so that consecutive chunks share roughly Overlap characters.
+func (s *Splitter) merge(pieces []string) []string {
+	var chunks, window []string
+	total := 0
+
+	for _, piece := range pieces {
+		if total+len(piece) > s.cfg.Size && len(window) > 0 {
+			if chunk := strings.TrimSpace(strings.Join(window, "")); chunk != "" {
+				chunks = append(chunks, chunk)
+			}
+			for total > s.cfg.Overlap || (total+len(piece) > s.cfg.Size && total > 0) {
+				total -= len(window[0])
+				window = window[1:]
+			}
+		}
+		window = append(window, piece)
+		total += len(piece)
+	}
+
+	if chunk := strings.TrimSpace(strings.Join(window, "")); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// splitKeepingSeparator splits text on sep and keeps the separator. Heading
+// markers start the following piece, so headings stay with their sections;
+// any other separator, such as a sentence's ". ", ends the preceding one.
+func splitKeepingSeparator(text, sep string) []string {
+	if sep == "" {
+		return strings.Split(text, "")
+	}
+	leading := strings.HasPrefix(strings.TrimLeft(sep, "\n"), "#")
+	parts := strings.Split(text, sep)
+	pieces := make([]string, 0, len(parts))
+	for i, part := range parts {
+		switch {
+		case leading && i > 0:
+			part = sep + part
+		case !leading && i < len(parts)-1:
+			part += sep
+		}
+		if part != "" {
+			pieces = append(pieces, part)
+		}
+	}
+	return pieces
+}
+
+var _ document.Transformer = (*Splitter)(nil)
@@ -0,0 +1,142 @@
+package chunking
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+func newSplitter(t *testing.T, size, overlap int, strategy string) *Splitter {
+	t.Helper()
+	s, err := New(config.Chunking{Size: size, Overlap: overlap, Strategy: strategy})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	for _, cfg := range []config.Chunking{
+		{Size: 0, Overlap: 0, Strategy: config.StrategyMarkdown},
+		{Size: 100, Overlap: 100, Strategy: config.StrategyMarkdown},
+		{Size: 100, Overlap: -1, Strategy: config.StrategyMarkdown},
+		{Size: 100, Overlap: 10, Strategy: "words"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
+
+func TestSplitKeepingSeparator(t *testing.T) {
+	tests := []struct {
+		text, sep string
+		want      []string
+	}{
+		{"One. Two. Three.", ". ", []string{"One. ", "Two. ", "Three."}},
+		{"a\n\nb\n\n", "\n\n", []string{"a\n\n", "b\n\n"}},
+		{"intro\n## A\nx\n## B\ny", "\n## ", []string{"intro", "\n## A\nx", "\n## B\ny"}},
+		{"abc", "", []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		got := splitKeepingSeparator(tt.text, tt.sep)
+		if strings.Join(got, "") != tt.text {
+			t.Errorf("splitKeepingSeparator(%q, %q) lost text: %q", tt.text, tt.sep, got)
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitKeepingSeparator(%q, %q) = %q, want %q", tt.text, tt.sep, got, tt.want)
+		}
+	}
+}
+
+func TestSplitSentencesEndChunks(t *testing.T) {
+	s := newSplitter(t, 30, 0, config.StrategySentence)
+	chunks := s.Split("The first sentence is here. The second one follows. A third closes it.")
+	want := []string{"The first sentence is here.", "The second one follows.", "A third closes it."}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", chunks, want)
+	}
+}
+
+func TestSplitMarkdownKeepsHeadingsWithSections(t *testing.T) {
+	s := newSplitter(t, 40, 0, config.StrategyMarkdown)
+	chunks := s.Split("# Title\n## Speakers\nAda and Grace.\n## Schedule\nTalks start at nine.")
+	want := []string{"# Title\n## Speakers\nAda and Grace.", "## Schedule\nTalks start at nine."}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", chunks, want)
+	}
+}
+
+func TestSplitRespectsSize(t *testing.T) {
+	text := strings.Repeat("Go makes concurrency approachable. ", 40) + "\n\n" + strings.Repeat("x", 250)
+	for _, strategy := range []string{config.StrategyMarkdown, config.StrategyParagraph, config.StrategySentence} {
+		s := newSplitter(t, 100, 20, strategy)
+		chunks := s.Split(text)
+		if len(chunks) < 2 {
+			t.Fatalf("%s: expected several chunks, got %d", strategy, len(chunks))
+		}
+		for _, c := range chunks {
+			if len(c) > 100 {
+				t.Errorf("%s: chunk of %d characters exceeds the size: %q", strategy, len(c), c)
+			}
+			if c == "" || c != strings.TrimSpace(c) {
+				t.Errorf("%s: chunk is empty or untrimmed: %q", strategy, c)
+			}
+		}
+	}
+}
+
+func TestMergeOverlap(t *testing.T) {
+	s := newSplitter(t, 20, 8, config.StrategyParagraph)
+	pieces := []string{"aaaa ", "bbbb ", "cccc ", "dddd ", "eeee ", "ffff "}
+	chunks := s.merge(pieces)
+	want := []string{"aaaa bbbb cccc dddd", "dddd eeee ffff"}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", chunks, want)
+	}
+
+	s = newSplitter(t, 20, 0, config.StrategyParagraph)
+	chunks = s.merge(pieces)
+	want = []string{"aaaa bbbb cccc dddd", "eeee ffff"}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("without overlap got %q, want %q", chunks, want)
+	}
+}
+
+func TestSplitEmpty(t *testing.T) {
+	s := newSplitter(t, 100, 10, config.StrategyMarkdown)
+	if chunks := s.Split(" \n\n "); chunks != nil {
+		t.Fatalf("expected no chunks for blank text, got %q", chunks)
+	}
+}
+
+func TestTransformCopiesMetadata(t *testing.T) {
+	s := newSplitter(t, 20, 0, config.StrategySentence)
+	src := []*schema.Document{{
+		ID:       "talks.md",
+		Content:  "First short one. Second short one.",
+		MetaData: map[string]any{"source": "talks.md"},
+	}}
+	out, err := s.Transform(context.Background(), src)
+	if err != nil {
+		t.Fatalf("Transform: %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(out))
+	}
+	for i, doc := range out {
+		if doc.MetaData["source"] != "talks.md" || doc.MetaData[MetaChunkIndex] != i {
+			t.Errorf("chunk %d has metadata %v", i, doc.MetaData)
+		}
+		if want := fmt.Sprintf("talks.md#%d", i); doc.ID != want {
+			t.Errorf("chunk %d has ID %q, want %q", i, doc.ID, want)
+		}
+	}
+	if src[0].MetaData[MetaChunkIndex] != nil {
+		t.Error("Transform modified the source document's metadata")
+	}
+}
@@ -0,0 +1,243 @@
+// Package completion suggests completions for the word being typed in the
+// chat input: slash commands, paths of files in the workspace, and symbols
+// that recently came up in the conversation. Symbols are learnt from what
+// the tools return, through the callback handler of a Completer, and from
+// any other text given to Observe.
+package completion
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Limits on what a Completer keeps and offers.
+const (
+	maxSymbols     = 1000
+	maxCandidates  = 50
+	maxObserveSize = 256 << 10 // Only the start of larger texts is scanned.
+)
+
+// pathCommands take a path as their argument.
+var pathCommands = []string{"/attach", "/detach"}
+
+// identifier matches identifiers, qualified or not, such as os.ReadFile.
+var identifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*`)
+
+// Completer completes words of the chat input. It is safe for concurrent
+// use.
+type Completer struct {
+	root     string
+	commands []string
+
+	mu      sync.Mutex
+	seq     int
+	symbols map[string]int // Symbol to when it was last seen.
+}
+
+// New returns a Completer for the workspace at root ("" for the current
+// directory) that completes the given slash commands.
+func New(root string, commands ...string) *Completer {
+	return &Completer{root: root, commands: slices.Sorted(slices.Values(commands)), symbols: make(map[string]int)}
+}
+
+// Observe remembers the symbols mentioned in text, such as the identifiers
+// in a file a tool read or in the model's answer.
+func (c *Completer) Observe(text string) {
+	if len(text) > maxObserveSize {
+		text = text[:maxObserveSize]
+	}
+	found := identifier.FindAllString(text, -1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range found {
+		if !isSymbol(s) {
+			continue
+		}
+		c.seq++
+		c.symbols[s] = c.seq
+		if i := strings.LastIndexByte(s, '.'); i >= 0 && isSymbol(s[i+1:]) {
+			c.symbols[s[i+1:]] = c.seq
+		}
+	}
+	if len(c.symbols) > 2*maxSymbols {
+		c.forgetOldest()
+	}
+}
+
+// isSymbol reports whether an identifier looks like a name in code rather
+// than a word of prose: it is qualified, has an underscore or has capitals
+// after its first letter, as in ReadFile, maxSymbols or JOB_WORKERS.
+func isSymbol(s string) bool {
+	if len(s) < 3 || strings.HasSuffix(s, ".") {
+		return false
+	}
+	if i := strings.LastIndexByte(s, '.'); i > 0 {
+		last := s[i+1:]
+		return last != "" && unicode.IsUpper(rune(last[0]))
+	}
+	return strings.Contains(strings.Trim(s, "_"), "_") || strings.IndexFunc(s[1:], unicode.IsUpper) >= 0
+}
+
+// forgetOldest keeps the maxSymbols symbols seen last.
+func (c *Completer) forgetOldest() {
+	seen := make([]int, 0, len(c.symbols))
+	for _, seq := range c.symbols {
+		seen = append(seen, seq)
+	}
+	slices.Sort(seen)
+	cutoff := seen[len(seen)-maxSymbols]
+	for s, seq := range c.symbols {
+		if seq < cutoff {
+			delete(c.symbols, s)
+		}
+	}
+}
+
+// Handler returns a callback handler that observes the arguments and
+// results of every tool call.
+func (c *Completer) Handler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if in := tool.ConvCallbackInput(input); info.Component == components.ComponentOfTool && in != nil {
+				c.Observe(in.ArgumentsInJSON)
+			}
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if out := tool.ConvCallbackOutput(output); info.Component == components.ComponentOfTool && out != nil {
+				c.Observe(out.Response)
+			}
+			return ctx
+		}).
+		Build()
+}
+
+// Candidates returns the completions of the word that ends at pos in line,
+// with the byte offset where the word starts. Slash commands are completed
+// at the start of the line; the arguments of /attach and /detach, and words
+// with a slash, are completed as paths; other words as recent symbols, most
+// recent first, and then as paths.
+func (c *Completer) Candidates(line string, pos int) (start int, candidates []string) {
+	start = strings.LastIndexAny(line[:pos], " \t") + 1
+	word := line[start:pos]
+	command, _, _ := strings.Cut(line, " ")
+	switch {
+	case start == 0 && strings.HasPrefix(word, "/") && !strings.Contains(word[1:], "/"):
+		candidates = withPrefix(c.commands, word)
+	case slices.Contains(pathCommands, command) || strings.Contains(word, "/"):
+		candidates = c.paths(word)
+	case word != "":
+		candidates = append(c.recent(word), c.paths(word)...)
+	}
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+	return start, candidates
+}
+
+// Complete completes the word that ends at pos in line as far as its
+// candidates agree, and returns the new line and cursor position. A word
+// with one candidate is completed in full and the cursor put after a space,
+// unless it is a directory. The candidates are returned too, so that a caller can
+// list them when the line did not change.
+func (c *Completer) Complete(line string, pos int) (newLine string, newPos int, candidates []string) {
+	start, candidates := c.Candidates(line, pos)
+	if len(candidates) == 0 {
+		return line, pos, nil
+	}
+	word := candidates[0]
+	for _, cand := range candidates[1:] {
+		word = commonPrefix(word, cand)
+	}
+	if len(word) < pos-start {
+		return line, pos, candidates
+	}
+	rest, skip := line[pos:], 0
+	if len(candidates) == 1 && !strings.HasSuffix(word, "/") {
+		if strings.HasPrefix(rest, " ") {
+			skip = 1
+		} else {
+			word += " "
+		}
+	}
+	return line[:start] + word + rest, start + len(word) + skip, candidates
+}
+
+func (c *Completer) recent(prefix string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var found []string
+	for s := range c.symbols {
+		if strings.HasPrefix(s, prefix) && s != prefix {
+			found = append(found, s)
+		}
+	}
+	slices.SortFunc(found, func(a, b string) int { return c.symbols[b] - c.symbols[a] })
+	return found
+}
+
+// paths completes word as a path, relative to the workspace unless it is
+// absolute. Hidden files are only offered once the word names them.
+func (c *Completer) paths(word string) []string {
+	dir, base := "", word
+	if i := strings.LastIndexByte(word, '/'); i >= 0 {
+		dir, base = word[:i+1], word[i+1:]
+	}
+	path := dir
+	if !filepath.IsAbs(dir) {
+		path = filepath.Join(c.root, dir)
+	}
+	if path == "" {
+		path = "."
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	var found []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if e.IsDir() {
+			name += "/"
+		}
+		found = append(found, dir+name)
+	}
+	return found
+}
+
+func withPrefix(words []string, prefix string) []string {
+	var found []string
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			found = append(found, w)
+		}
+	}
+	return found
+}
+
+// commonPrefix returns the longest common prefix of a and b that does not
+// split a UTF-8 sequence.
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	for n > 0 && n < len(a) && !utf8.RuneStart(a[n]) {
+		n--
+	}
+	return a[:n]
+}
@@ -0,0 +1,68 @@
+package completion
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func TestComplete(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"agent/agent.go", "agent/attach.go", "README.md", ".env"} {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := New(root, "/attach", "/detach", "/compact", "/commit")
+
+	// Symbols come from tool results, the latest first.
+	h := c.Handler()
+	info := &callbacks.RunInfo{Component: components.ComponentOfTool}
+	h.OnEnd(context.Background(), info, &tool.CallbackOutput{Response: `func processStream() calls pacing.New and the Flush method`})
+	c.Observe("Then processChunk runs; the answer mentions JOB_WORKERS.")
+
+	for _, tc := range []struct {
+		line, want string
+		candidates []string
+	}{
+		{"/co", "/com", []string{"/commit", "/compact"}},
+		{"/det", "/detach ", []string{"/detach"}},
+		{"/attach ag", "/attach agent/", []string{"agent/"}},
+		{"/attach agent/a", "/attach agent/a", []string{"agent/agent.go", "agent/attach.go"}},
+		{"/attach agent/ag", "/attach agent/agent.go ", []string{"agent/agent.go"}},
+		{"why is proc", "why is process", []string{"processChunk", "processStream"}},
+		{"does pacing.N", "does pacing.New ", []string{"pacing.New"}},
+		{"set JOB", "set JOB_WORKERS ", []string{"JOB_WORKERS"}},
+		{"explain RE", "explain README.md ", []string{"README.md"}},
+		{"see .e", "see .env ", []string{".env"}},
+		{"the answer", "the answer", nil},
+	} {
+		line, pos, candidates := c.Complete(tc.line, len(tc.line))
+		if line != tc.want || pos != len(tc.want) || !slices.Equal(candidates, tc.candidates) {
+			t.Errorf("Complete(%q) = %q, %d, %q; want %q, %q", tc.line, line, pos, candidates, tc.want, tc.candidates)
+		}
+	}
+
+	// Text after the cursor stays where it is.
+	if line, pos, _ := c.Complete("/attach READ and more", len("/attach READ")); line != "/attach README.md and more" || pos != len("/attach README.md ") {
+		t.Errorf("mid-line completion = %q, %d", line, pos)
+	}
+}
+
+func TestForgetOldest(t *testing.T) {
+	c := New("")
+	for i := range 2*maxSymbols + 1 {
+		c.Observe("sym_" + string(rune('a'+i%26)) + "_" + string(rune('A'+i/26%26)) + string(rune('a'+i/676)))
+	}
+	if n := len(c.symbols); n > maxSymbols {
+		t.Errorf("kept %d symbols, want at most %d", n, maxSymbols)
+	}
+}
@@ -0,0 +1,1293 @@
+// Package config centralizes tunable settings that are shared between the
+// indexing pipeline and the agents that query the knowledge base.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default chunking values. They are sized for the small markdown documents in
+// the GopherCon knowledge base and fit comfortably in an embedding request.
+const (
+	DefaultChunkSize     = 1000
+	DefaultChunkOverlap  = 200
+	DefaultChunkStrategy = StrategyMarkdown
+)
+
+// Supported separator strategies for splitting documents into chunks.
+const (
+	StrategyMarkdown  = "markdown"
+	StrategyParagraph = "paragraph"
+	StrategySentence  = "sentence"
+)
+
+// Metadata keys used to record the index-time configuration on a collection.
+const (
+	MetaChunkSize     = "chunk_size"
+	MetaChunkOverlap  = "chunk_overlap"
+	MetaChunkStrategy = "chunk_strategy"
+)
+
+// Chunking describes how documents are split before they are embedded.
+type Chunking struct {
+	Size     int    // Maximum chunk length in characters.
+	Overlap  int    // Characters shared between consecutive chunks.
+	Strategy string // One of the Strategy* constants.
+}
+
+// DefaultChunking returns the chunking configuration used when nothing is set.
+func DefaultChunking() Chunking {
+	return Chunking{
+		Size:     DefaultChunkSize,
+		Overlap:  DefaultChunkOverlap,
+		Strategy: DefaultChunkStrategy,
+	}
+}
+
+// LoadChunking reads CHUNK_SIZE, CHUNK_OVERLAP and CHUNK_STRATEGY from the
+// environment, falling back to the defaults for anything that is unset.
+func LoadChunking() (Chunking, error) {
+	cfg := DefaultChunking()
+
+	if v := os.Getenv("CHUNK_SIZE"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return Chunking{}, fmt.Errorf("invalid CHUNK_SIZE %q: %w", v, err)
+		}
+		cfg.Size = size
+	}
+	if v := os.Getenv("CHUNK_OVERLAP"); v != "" {
+		overlap, err := strconv.Atoi(v)
+		if err != nil {
+			return Chunking{}, fmt.Errorf("invalid CHUNK_OVERLAP %q: %w", v, err)
+		}
+		cfg.Overlap = overlap
+	}
+	if v := os.Getenv("CHUNK_STRATEGY"); v != "" {
+		cfg.Strategy = strings.ToLower(strings.TrimSpace(v))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Chunking{}, err
+	}
+	return cfg, nil
+}
+
+// Validate reports whether the configuration can produce sensible chunks.
+func (c Chunking) Validate() error {
+	if c.Size <= 0 {
+		return fmt.Errorf("chunk size must be positive, got %d", c.Size)
+	}
+	if c.Overlap < 0 || c.Overlap >= c.Size {
+		return fmt.Errorf("chunk overlap must be in [0, %d), got %d", c.Size, c.Overlap)
+	}
+	if _, ok := separators[c.Strategy]; !ok {
+		return fmt.Errorf("unknown chunk strategy %q (use markdown, paragraph or sentence)", c.Strategy)
+	}
+	return nil
+}
+
+// Separators returns the ordered list of separators for the strategy, from the
+// coarsest to the finest. The empty string means "split anywhere".
+func (c Chunking) Separators() []string {
+	return separators[c.Strategy]
+}
+
+// Metadata renders the chunking parameters as collection metadata.
+func (c Chunking) Metadata() map[string]string {
+	return map[string]string{
+		MetaChunkSize:     strconv.Itoa(c.Size),
+		MetaChunkOverlap:  strconv.Itoa(c.Overlap),
+		MetaChunkStrategy: c.Strategy,
+	}
+}
+
+var separators = map[string][]string{
+	StrategyMarkdown:  {"\n## ", "\n### ", "\n\n", "\n", " ", ""},
+	StrategyParagraph: {"\n\n", "\n", " ", ""},
+	StrategySentence:  {"\n\n", ". ", "\n", " ", ""},
+}
+
+// IndexAutoMigrate reports whether INDEX_AUTO_MIGRATE asks for an index built
+// with a different embedding model to be re-embedded on load.
+func IndexAutoMigrate() bool {
+	v, _ := strconv.ParseBool(os.Getenv("INDEX_AUTO_MIGRATE"))
+	return v
+}
+
+// RAGStructured reports whether RAG_STRUCTURED asks the knowledge base tool
+// to return an answer with a list of sources instead of one block of text.
+func RAGStructured() bool {
+	v, _ := strconv.ParseBool(os.Getenv("RAG_STRUCTURED"))
+	return v
+}
+
+// Knowledge sources the knowledge base tool can search besides the
+// collections of the knowledge base file.
+const (
+	RAGSourceKnowledge = "knowledge" // The conference knowledge base.
+	RAGSourceMemory    = "memory"    // Past conversations, as /recall searches them.
+)
+
+// RAGSources returns RAG_SOURCES, the comma-separated sources the knowledge
+// base tool searches at once: knowledge, memory, or the name of another
+// collection in the knowledge base file, such as one written through POST
+// /v1/documents. It defaults to knowledge alone.
+func RAGSources() ([]string, error) {
+	v := os.Getenv("RAG_SOURCES")
+	if strings.TrimSpace(v) == "" {
+		return []string{RAGSourceKnowledge}, nil
+	}
+	var sources []string
+	for _, s := range strings.Split(v, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" || slices.Contains(sources, s) {
+			return nil, fmt.Errorf("invalid RAG_SOURCES %q", v)
+		}
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
+
+// DefaultRAGMinScore is the similarity a knowledge base document needs to be
+// used in an answer.
+const DefaultRAGMinScore = 0.5
+
+// RAGMinScore reads RAG_MIN_SCORE, the similarity (from 0 to 1) below which
+// knowledge base documents are treated as unrelated to the question, so the
+// agent falls back to a web search. It defaults to DefaultRAGMinScore; 0
+// keeps every document.
+func RAGMinScore() (float64, error) {
+	v := os.Getenv("RAG_MIN_SCORE")
+	if v == "" {
+		return DefaultRAGMinScore, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f > 1 {
+		return 0, fmt.Errorf("invalid RAG_MIN_SCORE %q", v)
+	}
+	return f, nil
+}
+
+// Retrieval bounds what knowledge base documents may add to a turn's prompt.
+type Retrieval struct {
+	ContextShare float64 // Share of the context window the conversation leaves free that documents may fill.
+	MaxTopK      int     // Most documents one search returns.
+	MaxTurnCost  float64 // USD the turn's prompt may cost; 0 sets no ceiling.
+}
+
+// LoadRetrieval reads RAG_CONTEXT_SHARE, RAG_MAX_TOP_K and RAG_MAX_TURN_COST
+// from the environment. By default documents may fill 5% of the free context,
+// up to 8 of them, whatever they cost.
+func LoadRetrieval() (Retrieval, error) {
+	cfg := Retrieval{ContextShare: 0.05, MaxTopK: 8}
+	if v := os.Getenv("RAG_CONTEXT_SHARE"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			return Retrieval{}, fmt.Errorf("invalid RAG_CONTEXT_SHARE %q", v)
+		}
+		cfg.ContextShare = f
+	}
+	if v := os.Getenv("RAG_MAX_TOP_K"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Retrieval{}, fmt.Errorf("invalid RAG_MAX_TOP_K %q", v)
+		}
+		cfg.MaxTopK = n
+	}
+	if v := os.Getenv("RAG_MAX_TURN_COST"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			return Retrieval{}, fmt.Errorf("invalid RAG_MAX_TURN_COST %q", v)
+		}
+		cfg.MaxTurnCost = f
+	}
+	return cfg, nil
+}
+
+// Expansion configures how a knowledge base search widens its best matches
+// with the chunks around them, so that a section split across chunks reaches
+// the prompt whole.
+type Expansion struct {
+	MinScore float64 // Similarity a chunk needs for its neighbors to be merged into it.
+	Window   int     // Chunks merged on each side; 0 turns expansion off.
+}
+
+// LoadExpansion reads RAG_EXPAND_MIN_SCORE and RAG_EXPAND_WINDOW from the
+// environment. By default the chunks just before and after a chunk 0.75
+// similar to the query are merged into it.
+func LoadExpansion() (Expansion, error) {
+	cfg := Expansion{MinScore: 0.75, Window: 1}
+	if v := os.Getenv("RAG_EXPAND_MIN_SCORE"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return Expansion{}, fmt.Errorf("invalid RAG_EXPAND_MIN_SCORE %q", v)
+		}
+		cfg.MinScore = f
+	}
+	if v := os.Getenv("RAG_EXPAND_WINDOW"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Expansion{}, fmt.Errorf("invalid RAG_EXPAND_WINDOW %q", v)
+		}
+		cfg.Window = n
+	}
+	return cfg, nil
+}
+
+// Feedback configures how the votes cast with /good and /bad re-rank
+// knowledge base results.
+type Feedback struct {
+	Path          string  // File the votes are kept in.
+	Weight        float64 // Most the votes move a document's score; 0 turns feedback off.
+	MinSimilarity float64 // Cosine similarity a query needs to a voted one for the vote to count.
+}
+
+// LoadFeedback reads RAG_FEEDBACK_PATH, RAG_FEEDBACK_WEIGHT and
+// RAG_FEEDBACK_MIN_SIMILARITY from the environment. By default votes are kept
+// in data/feedback.json, move scores by up to 0.1 and count for queries
+// 0.85 similar to the voted one.
+func LoadFeedback() (Feedback, error) {
+	cfg := Feedback{Path: "data/feedback.json", Weight: 0.1, MinSimilarity: 0.85}
+	if v := os.Getenv("RAG_FEEDBACK_PATH"); v != "" {
+		cfg.Path = v
+	}
+	if v := os.Getenv("RAG_FEEDBACK_WEIGHT"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return Feedback{}, fmt.Errorf("invalid RAG_FEEDBACK_WEIGHT %q", v)
+		}
+		cfg.Weight = f
+	}
+	if v := os.Getenv("RAG_FEEDBACK_MIN_SIMILARITY"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			return Feedback{}, fmt.Errorf("invalid RAG_FEEDBACK_MIN_SIMILARITY %q", v)
+		}
+		cfg.MinSimilarity = f
+	}
+	return cfg, nil
+}
+
+// KnowledgeGraphPath returns KNOWLEDGE_GRAPH, the file `goforai index`
+// saves the knowledge graph of the documents to and graph_query reads, or ""
+// when no graph is built.
+func KnowledgeGraphPath() string {
+	return os.Getenv("KNOWLEDGE_GRAPH")
+}
+
+// CodeIndex reports whether CODE_INDEX asks search_files to build a trigram
+// index of each cloned repository and consult it for content searches.
+func CodeIndex() bool {
+	v, _ := strconv.ParseBool(os.Getenv("CODE_INDEX"))
+	return v
+}
+
+// IndexQuantization returns INDEX_QUANTIZATION, the in-memory precision of
+// the knowledge base embeddings: none (default), int8 or float16.
+func IndexQuantization() string {
+	return os.Getenv("INDEX_QUANTIZATION")
+}
+
+// DefaultKBMaxAge is how old knowledge base content may be before the agent
+// is warned that it may be out of date.
+const DefaultKBMaxAge = 30 * 24 * time.Hour
+
+// KBMaxAge reads KB_MAX_AGE, a duration such as "720h": retrieved documents
+// indexed longer ago than that come with a warning that they may be out of
+// date. It defaults to DefaultKBMaxAge; 0 disables the warning.
+func KBMaxAge() (time.Duration, error) {
+	v := os.Getenv("KB_MAX_AGE")
+	if v == "" {
+		return DefaultKBMaxAge, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid KB_MAX_AGE %q", v)
+	}
+	return d, nil
+}
+
+// Embedding providers for the knowledge base.
+const (
+	EmbeddingGemini = "gemini"
+	EmbeddingOpenAI = "openai"
+	EmbeddingOllama = "ollama"
+)
+
+// Embeddings selects the model that embeds the knowledge base and the
+// queries against it. Both sides must use the same one.
+type Embeddings struct {
+	Provider string // One of the Embedding* constants.
+	Model    string // Model name; empty uses the provider's default.
+	BaseURL  string // API endpoint; empty uses the provider's default.
+}
+
+// LoadEmbeddings reads EMBEDDING_PROVIDER, EMBEDDING_MODEL and
+// EMBEDDING_BASE_URL from the environment. By default the knowledge base is
+// embedded with Gemini.
+func LoadEmbeddings() (Embeddings, error) {
+	cfg := Embeddings{
+		Provider: EmbeddingGemini,
+		Model:    os.Getenv("EMBEDDING_MODEL"),
+		BaseURL:  strings.TrimSuffix(os.Getenv("EMBEDDING_BASE_URL"), "/"),
+	}
+	if v := os.Getenv("EMBEDDING_PROVIDER"); v != "" {
+		cfg.Provider = strings.ToLower(strings.TrimSpace(v))
+	}
+	switch cfg.Provider {
+	case EmbeddingGemini, EmbeddingOpenAI, EmbeddingOllama:
+	default:
+		return Embeddings{}, fmt.Errorf("unknown EMBEDDING_PROVIDER %q (use gemini, openai or ollama)", cfg.Provider)
+	}
+	return cfg, nil
+}
+
+// DefaultOfflineChatModel is the Ollama model offline mode chats with.
+const DefaultOfflineChatModel = "qwen2.5-coder:7b"
+
+// Offline configures offline mode, in which the agent chats with a local
+// Ollama model and leaves out the tools that need the internet.
+type Offline struct {
+	Enabled   bool
+	ChatModel string // Ollama model; must support tool calling.
+	BaseURL   string // Ollama endpoint; empty uses its default.
+}
+
+// LoadOffline reads OFFLINE, OFFLINE_CHAT_MODEL and OLLAMA_BASE_URL from the
+// environment. Offline mode is off by default; the --offline flag also turns
+// it on.
+func LoadOffline() (Offline, error) {
+	cfg := Offline{
+		ChatModel: DefaultOfflineChatModel,
+		BaseURL:   strings.TrimSuffix(os.Getenv("OLLAMA_BASE_URL"), "/"),
+	}
+	if v := os.Getenv("OFFLINE"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return Offline{}, fmt.Errorf("invalid OFFLINE %q", v)
+		}
+		cfg.Enabled = enabled
+	}
+	if v := strings.TrimSpace(os.Getenv("OFFLINE_CHAT_MODEL")); v != "" {
+		cfg.ChatModel = v
+	}
+	return cfg, nil
+}
+
+// DefaultTavilyBaseURL is the public Tavily API.
+const DefaultTavilyBaseURL = "https://api.tavily.com"
+
+// GeminiBaseURL returns GEMINI_BASE_URL, which points the Gemini client at a
+// proxy or a fake server such as foundation/fakeapi. Empty means the public API.
+func GeminiBaseURL() string {
+	return os.Getenv("GEMINI_BASE_URL")
+}
+
+// TavilyBaseURL returns TAVILY_BASE_URL, or the public API when it is unset.
+func TavilyBaseURL() string {
+	if v := os.Getenv("TAVILY_BASE_URL"); v != "" {
+		return strings.TrimSuffix(v, "/")
+	}
+	return DefaultTavilyBaseURL
+}
+
+// Search providers selectable with SEARCH_PROVIDER.
+const (
+	SearchTavily     = "tavily"
+	SearchDuckDuckGo = "duckduckgo"
+	SearchGemini     = "gemini" // Gemini's own Google Search grounding.
+)
+
+// Search configures the search_internet tool.
+type Search struct {
+	Provider      string // One of the Search constants; empty tries Tavily, then DuckDuckGo.
+	FetchPages    int    // Top result pages to download and extract; 0 disables it.
+	PageMaxTokens int    // Approximate text budget per fetched page; 0 is the tool default.
+}
+
+// LoadSearch reads SEARCH_PROVIDER, SEARCH_FETCH_PAGES and
+// SEARCH_PAGE_MAX_TOKENS from the environment. By default the tool returns
+// links and snippets only.
+func LoadSearch() (Search, error) {
+	var cfg Search
+	switch v := strings.ToLower(os.Getenv("SEARCH_PROVIDER")); v {
+	case "", SearchTavily, SearchDuckDuckGo, SearchGemini:
+		cfg.Provider = v
+	default:
+		return Search{}, fmt.Errorf("invalid SEARCH_PROVIDER %q: want tavily, duckduckgo or gemini", v)
+	}
+	if v := os.Getenv("SEARCH_FETCH_PAGES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Search{}, fmt.Errorf("invalid SEARCH_FETCH_PAGES %q", v)
+		}
+		cfg.FetchPages = n
+	}
+	if v := os.Getenv("SEARCH_PAGE_MAX_TOKENS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return Search{}, fmt.Errorf("invalid SEARCH_PAGE_MAX_TOKENS %q", v)
+		}
+		cfg.PageMaxTokens = n
+	}
+	return cfg, nil
+}
+
+// DefaultDuckDuckGoBaseURL is the public DuckDuckGo HTML search.
+const DefaultDuckDuckGoBaseURL = "https://html.duckduckgo.com"
+
+// DuckDuckGoBaseURL returns DDG_BASE_URL, or the public site when it is unset.
+func DuckDuckGoBaseURL() string {
+	if v := os.Getenv("DDG_BASE_URL"); v != "" {
+		return strings.TrimSuffix(v, "/")
+	}
+	return DefaultDuckDuckGoBaseURL
+}
+
+// Logging controls the structured logger shared by the foundation packages
+// and the agent.
+type Logging struct {
+	Level  string // debug, info, warn or error.
+	Format string // text or json.
+	Output string // stderr, stdout, or a file path.
+}
+
+// LoadLogging reads LOG_LEVEL, LOG_FORMAT and LOG_OUTPUT from the environment.
+// The defaults (info, text, stderr) keep the terminal output readable.
+func LoadLogging() Logging {
+	cfg := Logging{Level: "info", Format: "text", Output: "stderr"}
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		cfg.Level = strings.ToLower(v)
+	}
+	if v := os.Getenv("LOG_FORMAT"); v != "" {
+		cfg.Format = strings.ToLower(v)
+	}
+	if v := os.Getenv("LOG_OUTPUT"); v != "" {
+		cfg.Output = v
+	}
+	return cfg
+}
+
+// ChatModel returns CHAT_MODEL, the Gemini model the agent chats with, or ""
+// for the default.
+func ChatModel() string {
+	return strings.TrimSpace(os.Getenv("CHAT_MODEL"))
+}
+
+// WorkspaceDir returns WORKSPACE_DIR, the directory the CLI's file tools are
+// confined to, or "" to let them use the current directory.
+func WorkspaceDir() string {
+	return os.Getenv("WORKSPACE_DIR")
+}
+
+// WatchWorkspace reports whether WATCH_WORKSPACE asks the chat agent to
+// notice files the user changes outside the session between turns and tell
+// the model about them.
+func WatchWorkspace() bool {
+	v, _ := strconv.ParseBool(os.Getenv("WATCH_WORKSPACE"))
+	return v
+}
+
+// PolicyPath returns POLICY_FILE, the rules file that allows or denies tool
+// calls, or policy.json when it is not set.
+func PolicyPath() string {
+	if v := os.Getenv("POLICY_FILE"); v != "" {
+		return v
+	}
+	return "policy.json"
+}
+
+// DisallowedLicenses returns LICENSE_DISALLOWED, the comma-separated SPDX
+// IDs of the licenses license_audit flags in dependencies, such as
+// "AGPL-3.0,GPL-3.0"; "unknown" flags licenses it cannot identify. None are
+// disallowed by default.
+func DisallowedLicenses() []string {
+	var ids []string
+	for _, id := range strings.Split(os.Getenv("LICENSE_DISALLOWED"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// CustomToolsPath returns TOOLS_CONFIG, the YAML file declaring custom
+// command and HTTP tools, defaulting to tools.yaml.
+func CustomToolsPath() string {
+	if v := os.Getenv("TOOLS_CONFIG"); v != "" {
+		return v
+	}
+	return "tools.yaml"
+}
+
+// PromptsDir returns PROMPTS_DIR, the directory whose prompt templates add
+// to or replace the built-in ones, defaulting to prompts.
+func PromptsDir() string {
+	if v := os.Getenv("PROMPTS_DIR"); v != "" {
+		return v
+	}
+	return "prompts"
+}
+
+// PromptVersions reads PROMPT_VERSIONS, a comma-separated list of
+// name=version pairs such as "step5.system=1", which pins those prompt
+// templates to an older version instead of the latest.
+func PromptVersions() (map[string]int, error) {
+	pins := make(map[string]int)
+	for _, pair := range strings.Split(os.Getenv("PROMPT_VERSIONS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, v, ok := strings.Cut(pair, "=")
+		version, err := strconv.Atoi(strings.TrimSpace(v))
+		if !ok || err != nil || version < 1 || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid PROMPT_VERSIONS entry %q", pair)
+		}
+		pins[strings.TrimSpace(name)] = version
+	}
+	return pins, nil
+}
+
+// RecallDBPath returns RECALL_DB_PATH, the file holding the index of past
+// conversations, defaulting to data/conversations.gob.
+func RecallDBPath() string {
+	if v := os.Getenv("RECALL_DB_PATH"); v != "" {
+		return v
+	}
+	return "data/conversations.gob"
+}
+
+// DefaultSemanticCacheMinScore is the similarity a question needs to a cached
+// one to get its answer: high enough that rephrasings match but related
+// questions do not.
+const DefaultSemanticCacheMinScore = 0.95
+
+// SemanticCache configures the cache of answers goforai serve reuses for
+// near-identical questions.
+type SemanticCache struct {
+	Enabled    bool
+	Path       string  // File the answers are kept in.
+	MinScore   float64 // Cosine similarity a question needs to a cached one.
+	MaxEntries int     // The oldest answers are dropped beyond this many.
+}
+
+// LoadSemanticCache reads SEMANTIC_CACHE, SEMANTIC_CACHE_PATH,
+// SEMANTIC_CACHE_MIN_SCORE and SEMANTIC_CACHE_MAX_ENTRIES from the
+// environment. The cache is off by default; when on, it keeps up to 1000
+// answers in data/answer-cache.json.
+func LoadSemanticCache() (SemanticCache, error) {
+	cfg := SemanticCache{Path: "data/answer-cache.json", MinScore: DefaultSemanticCacheMinScore, MaxEntries: 1000}
+	if v := os.Getenv("SEMANTIC_CACHE"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return SemanticCache{}, fmt.Errorf("invalid SEMANTIC_CACHE %q", v)
+		}
+		cfg.Enabled = enabled
+	}
+	if v := os.Getenv("SEMANTIC_CACHE_PATH"); v != "" {
+		cfg.Path = v
+	}
+	if v := os.Getenv("SEMANTIC_CACHE_MIN_SCORE"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			return SemanticCache{}, fmt.Errorf("invalid SEMANTIC_CACHE_MIN_SCORE %q", v)
+		}
+		cfg.MinScore = f
+	}
+	if v := os.Getenv("SEMANTIC_CACHE_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return SemanticCache{}, fmt.Errorf("invalid SEMANTIC_CACHE_MAX_ENTRIES %q", v)
+		}
+		cfg.MaxEntries = n
+	}
+	return cfg, nil
+}
+
+// PluginDir returns PLUGIN_DIR, the directory whose executables are started
+// as tool plugins, defaulting to plugins.
+func PluginDir() string {
+	if v := os.Getenv("PLUGIN_DIR"); v != "" {
+		return v
+	}
+	return "plugins"
+}
+
+// AuditLogPath returns AUDIT_LOG, the JSON Lines file every tool call is
+// appended to. Empty disables the audit log.
+func AuditLogPath() string {
+	return os.Getenv("AUDIT_LOG")
+}
+
+// ToolOutputMaxTokens reads TOOL_OUTPUT_MAX_TOKENS, the approximate size
+// above which tool results are truncated before they reach the model. It
+// defaults to 8000; 0 disables truncation.
+func ToolOutputMaxTokens() (int, error) {
+	v := os.Getenv("TOOL_OUTPUT_MAX_TOKENS")
+	if v == "" {
+		return 8000, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid TOOL_OUTPUT_MAX_TOKENS %q", v)
+	}
+	return n, nil
+}
+
+// ToolMaxRetries reads TOOL_MAX_RETRIES, how many times in a row one tool may
+// fail in a turn before the agent stops running it until the next turn. It
+// defaults to 3; 0 disables the limit.
+func ToolMaxRetries() (int, error) {
+	v := os.Getenv("TOOL_MAX_RETRIES")
+	if v == "" {
+		return 3, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid TOOL_MAX_RETRIES %q", v)
+	}
+	return n, nil
+}
+
+// Speculation configures the prefetching of tool calls the model is likely
+// to make next.
+type Speculation struct {
+	Enabled    bool
+	MaxCalls   int     // Most calls prefetched after one tool result.
+	MinHitRate float64 // Share of a kind of prefetch the model must go on to use for it to continue.
+}
+
+// LoadSpeculation reads SPECULATIVE_PREFETCH, SPECULATIVE_PREFETCH_MAX_CALLS
+// and SPECULATIVE_PREFETCH_MIN_HIT_RATE from the environment. Prefetching is
+// off by default; when on, up to 2 calls are prefetched after a result as
+// long as at least a quarter of them are used.
+func LoadSpeculation() (Speculation, error) {
+	cfg := Speculation{MaxCalls: 2, MinHitRate: 0.25}
+	cfg.Enabled, _ = strconv.ParseBool(os.Getenv("SPECULATIVE_PREFETCH"))
+	if v := os.Getenv("SPECULATIVE_PREFETCH_MAX_CALLS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Speculation{}, fmt.Errorf("invalid SPECULATIVE_PREFETCH_MAX_CALLS %q", v)
+		}
+		cfg.MaxCalls = n
+	}
+	if v := os.Getenv("SPECULATIVE_PREFETCH_MIN_HIT_RATE"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return Speculation{}, fmt.Errorf("invalid SPECULATIVE_PREFETCH_MIN_HIT_RATE %q", v)
+		}
+		cfg.MinHitRate = f
+	}
+	return cfg, nil
+}
+
+// WireLog configures the opt-in log of the HTTP requests sent to model and
+// embedding providers.
+type WireLog struct {
+	Dir          string // Directory of the per-provider log files; empty disables the log.
+	MaxBodyBytes int    // Request and response bodies are cut to this size.
+	MaxFileBytes int64  // A log file is rotated once it would grow past this size.
+	MaxFiles     int    // Rotated files kept per provider, besides the current one.
+}
+
+// LoadWireLog reads WIRE_LOG_DIR, WIRE_LOG_MAX_BODY_BYTES,
+// WIRE_LOG_MAX_FILE_BYTES and WIRE_LOG_MAX_FILES from the environment. The
+// log is off unless WIRE_LOG_DIR is set; bodies are cut at 64 KiB and each
+// provider keeps up to five rotated 10 MiB files.
+func LoadWireLog() (WireLog, error) {
+	cfg := WireLog{
+		Dir:          os.Getenv("WIRE_LOG_DIR"),
+		MaxBodyBytes: 64 << 10,
+		MaxFileBytes: 10 << 20,
+		MaxFiles:     5,
+	}
+	for _, setting := range []struct {
+		name string
+		dst  func(int64)
+	}{
+		{"WIRE_LOG_MAX_BODY_BYTES", func(n int64) { cfg.MaxBodyBytes = int(n) }},
+		{"WIRE_LOG_MAX_FILE_BYTES", func(n int64) { cfg.MaxFileBytes = n }},
+		{"WIRE_LOG_MAX_FILES", func(n int64) { cfg.MaxFiles = int(n) }},
+	} {
+		v := os.Getenv(setting.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || n <= 0 {
+			return WireLog{}, fmt.Errorf("invalid %s %q (use a positive number)", setting.name, v)
+		}
+		setting.dst(n)
+	}
+	return cfg, nil
+}
+
+// ModelRateLimits reads MODEL_RATE_LIMITS, the requests per minute the whole
+// process may send to each model provider, as comma-separated provider:rpm
+// pairs such as "gemini:60,openai:500". Providers that are not listed are
+// not limited.
+func ModelRateLimits() (map[string]int, error) {
+	limits := make(map[string]int)
+	v := os.Getenv("MODEL_RATE_LIMITS")
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		provider, value, ok := strings.Cut(entry, ":")
+		rpm, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || rpm <= 0 || strings.TrimSpace(provider) == "" {
+			return nil, fmt.Errorf("invalid MODEL_RATE_LIMITS entry %q (use provider:requests_per_minute)", entry)
+		}
+		limits[strings.ToLower(strings.TrimSpace(provider))] = rpm
+	}
+	return limits, nil
+}
+
+// Usage configures the ledger of tokens spent with each model provider and
+// the quotas enforced on it.
+type Usage struct {
+	Path           string           // File the ledger is kept in.
+	DailyQuotas    map[string]int64 // Tokens per UTC day, by provider.
+	MonthlyQuotas  map[string]int64 // Tokens per UTC month, by provider.
+	AlertThreshold float64          // Fraction of a quota at which a warning is logged.
+}
+
+// LoadUsage reads USAGE_PATH, USAGE_QUOTA_DAILY, USAGE_QUOTA_MONTHLY and
+// USAGE_ALERT_THRESHOLD from the environment. Quotas are comma-separated
+// provider:tokens pairs such as "gemini:2000000"; providers that are not
+// listed are only tracked. The ledger defaults to data/usage.json and the
+// warning to 80% of a quota.
+func LoadUsage() (Usage, error) {
+	cfg := Usage{Path: "data/usage.json", AlertThreshold: 0.8}
+	if v := os.Getenv("USAGE_PATH"); v != "" {
+		cfg.Path = v
+	}
+	for _, quota := range []struct {
+		name string
+		dst  *map[string]int64
+	}{
+		{"USAGE_QUOTA_DAILY", &cfg.DailyQuotas},
+		{"USAGE_QUOTA_MONTHLY", &cfg.MonthlyQuotas},
+	} {
+		quotas := make(map[string]int64)
+		for _, entry := range strings.Split(os.Getenv(quota.name), ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			provider, value, ok := strings.Cut(entry, ":")
+			tokens, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if !ok || err != nil || tokens <= 0 || strings.TrimSpace(provider) == "" {
+				return Usage{}, fmt.Errorf("invalid %s entry %q (use provider:tokens)", quota.name, entry)
+			}
+			quotas[strings.ToLower(strings.TrimSpace(provider))] = tokens
+		}
+		*quota.dst = quotas
+	}
+	if v := os.Getenv("USAGE_ALERT_THRESHOLD"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			return Usage{}, fmt.Errorf("invalid USAGE_ALERT_THRESHOLD %q (use a fraction such as 0.8)", v)
+		}
+		cfg.AlertThreshold = f
+	}
+	return cfg, nil
+}
+
+// Session store backends.
+const (
+	SessionStoreMemory = "memory"
+	SessionStoreSQLite = "sqlite"
+	SessionStoreRedis  = "redis"
+)
+
+// Sessions configures how serve mode keeps per-user conversations.
+type Sessions struct {
+	Store        string        // One of the SessionStore* constants.
+	SQLitePath   string        // Database file for the sqlite store.
+	RedisURL     string        // Connection URL for the redis store.
+	IdleTimeout  time.Duration // Sessions untouched for this long are closed.
+	MaxDuration  time.Duration // Sessions are closed this long after they start; 0 is no limit.
+	Retention    time.Duration // Closed sessions' conversations are kept this long past the idle timeout.
+	WorkspaceDir string        // Parent directory of the per-session tool workspaces.
+}
+
+// LoadSessions reads SESSION_STORE, SESSION_SQLITE_PATH, REDIS_URL,
+// SESSION_IDLE_TIMEOUT, SESSION_MAX_DURATION, SESSION_RETENTION and
+// SESSION_WORKSPACE_DIR from the environment. By default sessions live in
+// memory, close after 30 minutes of inactivity with no limit on their total
+// length, and their conversations are kept for a day after that.
+func LoadSessions() (Sessions, error) {
+	cfg := Sessions{
+		Store:        SessionStoreMemory,
+		SQLitePath:   "data/sessions.db",
+		RedisURL:     "redis://localhost:6379/0",
+		IdleTimeout:  30 * time.Minute,
+		Retention:    24 * time.Hour,
+		WorkspaceDir: "data/workspaces",
+	}
+	if v := os.Getenv("SESSION_STORE"); v != "" {
+		cfg.Store = strings.ToLower(strings.TrimSpace(v))
+	}
+	if v := os.Getenv("SESSION_SQLITE_PATH"); v != "" {
+		cfg.SQLitePath = v
+	}
+	if v := os.Getenv("REDIS_URL"); v != "" {
+		cfg.RedisURL = v
+	}
+	if v := os.Getenv("SESSION_IDLE_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return Sessions{}, fmt.Errorf("invalid SESSION_IDLE_TIMEOUT %q: %w", v, err)
+		}
+		cfg.IdleTimeout = d
+	}
+	if v := os.Getenv("SESSION_MAX_DURATION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return Sessions{}, fmt.Errorf("invalid SESSION_MAX_DURATION %q (use a duration such as 8h, or 0 for no limit)", v)
+		}
+		cfg.MaxDuration = d
+	}
+	if v := os.Getenv("SESSION_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return Sessions{}, fmt.Errorf("invalid SESSION_RETENTION %q (use a duration such as 24h, or 0 to delete sessions when they close)", v)
+		}
+		cfg.Retention = d
+	}
+	if v := os.Getenv("SESSION_WORKSPACE_DIR"); v != "" {
+		cfg.WorkspaceDir = v
+	}
+
+	switch cfg.Store {
+	case SessionStoreMemory, SessionStoreSQLite, SessionStoreRedis:
+	default:
+		return Sessions{}, fmt.Errorf("unknown SESSION_STORE %q (use memory, sqlite or redis)", cfg.Store)
+	}
+	if cfg.IdleTimeout <= 0 {
+		return Sessions{}, fmt.Errorf("SESSION_IDLE_TIMEOUT must be positive, got %s", cfg.IdleTimeout)
+	}
+	return cfg, nil
+}
+
+// Server holds the access controls for `goforai serve`.
+type Server struct {
+	APIKeys           map[string]string   // API key -> client name. Empty disables auth.
+	Groups            map[string][]string // Client name -> the groups it belongs to, for knowledge base access.
+	RequestsPerMinute int                 // Per-client request rate; 0 disables the limit.
+	DailyTokenBudget  int64               // Per-client model tokens per UTC day; 0 is unlimited.
+	MaxRequestBytes   int                 // Largest accepted request body.
+	ShutdownTimeout   time.Duration       // How long to drain in-flight turns on shutdown.
+	BudgetAlert       float64             // Fraction of the budget that triggers a budget.threshold event.
+	DocumentWriters   string              // Clients and group:<name> entries allowed to POST /v1/documents; "*" is everyone.
+}
+
+// LoadServer reads SERVER_API_KEYS, SERVER_GROUPS, RATE_LIMIT_RPM,
+// TOKEN_BUDGET_DAILY, BUDGET_ALERT_THRESHOLD, MAX_REQUEST_BYTES,
+// SHUTDOWN_TIMEOUT and SERVER_DOCUMENT_WRITERS from the environment. SERVER_API_KEYS is a comma-separated
+// list of name:key pairs; a bare key is named after its position.
+// SERVER_GROUPS is a comma-separated list of group:member|member entries.
+func LoadServer() (Server, error) {
+	cfg := Server{
+		APIKeys:           make(map[string]string),
+		Groups:            make(map[string][]string),
+		RequestsPerMinute: 60,
+		MaxRequestBytes:   1 << 20,
+		ShutdownTimeout:   30 * time.Second,
+		BudgetAlert:       0.8,
+	}
+
+	if v := os.Getenv("SERVER_API_KEYS"); v != "" {
+		for i, entry := range strings.Split(v, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			name, key, ok := strings.Cut(entry, ":")
+			if !ok {
+				name, key = fmt.Sprintf("key%d", i+1), entry
+			}
+			if key == "" {
+				return Server{}, fmt.Errorf("SERVER_API_KEYS entry %q has an empty key", entry)
+			}
+			cfg.APIKeys[key] = name
+		}
+	}
+	if v := os.Getenv("SERVER_GROUPS"); v != "" {
+		for _, entry := range strings.Split(v, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			group, members, ok := strings.Cut(entry, ":")
+			if !ok || group == "" || members == "" {
+				return Server{}, fmt.Errorf("invalid SERVER_GROUPS entry %q (want group:member|member)", entry)
+			}
+			for _, member := range strings.Split(members, "|") {
+				if member = strings.TrimSpace(member); member != "" {
+					cfg.Groups[member] = append(cfg.Groups[member], group)
+				}
+			}
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_RPM"); v != "" {
+		rpm, err := strconv.Atoi(v)
+		if err != nil || rpm < 0 {
+			return Server{}, fmt.Errorf("invalid RATE_LIMIT_RPM %q", v)
+		}
+		cfg.RequestsPerMinute = rpm
+	}
+	if v := os.Getenv("TOKEN_BUDGET_DAILY"); v != "" {
+		budget, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || budget < 0 {
+			return Server{}, fmt.Errorf("invalid TOKEN_BUDGET_DAILY %q", v)
+		}
+		cfg.DailyTokenBudget = budget
+	}
+	if v := os.Getenv("MAX_REQUEST_BYTES"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			return Server{}, fmt.Errorf("invalid MAX_REQUEST_BYTES %q", v)
+		}
+		cfg.MaxRequestBytes = size
+	}
+	if v := os.Getenv("BUDGET_ALERT_THRESHOLD"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			return Server{}, fmt.Errorf("invalid BUDGET_ALERT_THRESHOLD %q (use a fraction in (0, 1])", v)
+		}
+		cfg.BudgetAlert = f
+	}
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return Server{}, fmt.Errorf("invalid SHUTDOWN_TIMEOUT %q", v)
+		}
+		cfg.ShutdownTimeout = d
+	}
+	cfg.DocumentWriters = strings.TrimSpace(os.Getenv("SERVER_DOCUMENT_WRITERS"))
+	return cfg, nil
+}
+
+// Jobs configures the queue of agent runs submitted through POST /v1/jobs.
+type Jobs struct {
+	Workers      int           // Jobs run at once; 0 disables the job API.
+	QueueSize    int           // Jobs that may wait to run before submissions are refused.
+	MaxAttempts  int           // Runs of a job that keeps failing with a retryable error.
+	RetryBackoff time.Duration // Wait before the first retry, doubled for each one after it.
+	Retention    time.Duration // How long a finished job can still be polled.
+}
+
+// LoadJobs reads JOB_WORKERS, JOB_QUEUE_SIZE, JOB_MAX_ATTEMPTS,
+// JOB_RETRY_BACKOFF and JOB_RETENTION from the environment. The job API is
+// off unless JOB_WORKERS is set; by default up to 100 jobs wait, each runs at
+// most 3 times starting 5s apart, and finished jobs are kept for an hour.
+func LoadJobs() (Jobs, error) {
+	cfg := Jobs{QueueSize: 100, MaxAttempts: 3, RetryBackoff: 5 * time.Second, Retention: time.Hour}
+	for _, setting := range []struct {
+		name string
+		min  int
+		dst  *int
+	}{
+		{"JOB_WORKERS", 0, &cfg.Workers},
+		{"JOB_QUEUE_SIZE", 1, &cfg.QueueSize},
+		{"JOB_MAX_ATTEMPTS", 1, &cfg.MaxAttempts},
+	} {
+		if v := os.Getenv(setting.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < setting.min {
+				return Jobs{}, fmt.Errorf("invalid %s %q", setting.name, v)
+			}
+			*setting.dst = n
+		}
+	}
+	for _, setting := range []struct {
+		name string
+		dst  *time.Duration
+	}{
+		{"JOB_RETRY_BACKOFF", &cfg.RetryBackoff},
+		{"JOB_RETENTION", &cfg.Retention},
+	} {
+		if v := os.Getenv(setting.name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return Jobs{}, fmt.Errorf("invalid %s %q", setting.name, v)
+			}
+			*setting.dst = d
+		}
+	}
+	return cfg, nil
+}
+
+// Text-to-speech providers for the terminal agent's /voice mode.
+const (
+	TTSAuto   = "auto"
+	TTSSay    = "say"
+	TTSEspeak = "espeak"
+	TTSGemini = "gemini"
+)
+
+// Speech-to-text providers for the terminal agent's /talk mode.
+const (
+	STTGemini = "gemini"
+	STTOpenAI = "openai"
+)
+
+// Speech configures how the terminal agent speaks its answers and
+// transcribes spoken questions.
+type Speech struct {
+	TTSProvider string // One of the TTS* constants.
+	Voice       string // Provider-specific voice name; empty uses its default.
+	STTProvider string // One of the STT* constants.
+	STTModel    string // Transcription model; empty uses the provider's default.
+	STTBaseURL  string // API endpoint of the openai provider; empty uses OpenAI's.
+}
+
+// LoadSpeech reads TTS_PROVIDER, TTS_VOICE, STT_PROVIDER, STT_MODEL and
+// STT_BASE_URL from the environment. By default answers are spoken by the
+// first local engine found and questions are transcribed by Gemini.
+func LoadSpeech() (Speech, error) {
+	cfg := Speech{
+		TTSProvider: TTSAuto,
+		Voice:       os.Getenv("TTS_VOICE"),
+		STTProvider: STTGemini,
+		STTModel:    os.Getenv("STT_MODEL"),
+		STTBaseURL:  strings.TrimSuffix(os.Getenv("STT_BASE_URL"), "/"),
+	}
+	if v := os.Getenv("TTS_PROVIDER"); v != "" {
+		cfg.TTSProvider = strings.ToLower(strings.TrimSpace(v))
+	}
+	switch cfg.TTSProvider {
+	case TTSAuto, TTSSay, TTSEspeak, TTSGemini:
+	default:
+		return Speech{}, fmt.Errorf("unknown TTS_PROVIDER %q (use auto, say, espeak or gemini)", cfg.TTSProvider)
+	}
+	if v := os.Getenv("STT_PROVIDER"); v != "" {
+		cfg.STTProvider = strings.ToLower(strings.TrimSpace(v))
+	}
+	switch cfg.STTProvider {
+	case STTGemini, STTOpenAI:
+	default:
+		return Speech{}, fmt.Errorf("unknown STT_PROVIDER %q (use gemini or openai)", cfg.STTProvider)
+	}
+	return cfg, nil
+}
+
+// Email providers for the send_email tool.
+const (
+	EmailSMTP     = "smtp"
+	EmailSendGrid = "sendgrid"
+)
+
+// DefaultSendGridBaseURL is the public SendGrid API.
+const DefaultSendGridBaseURL = "https://api.sendgrid.com"
+
+// Email configures the send_email tool. The SMTP password and the SendGrid
+// API key are secrets (SMTP_PASSWORD, SENDGRID_API_KEY).
+type Email struct {
+	Provider     string // One of the Email* constants; empty leaves send_email out.
+	From         string // Sender address, e.g. "GopherCon Bot <bot@example.com>".
+	SMTPAddr     string // host:port of the SMTP server.
+	SMTPUsername string // Empty sends without authentication.
+	BaseURL      string // API endpoint of the sendgrid provider.
+}
+
+// LoadEmail reads EMAIL_PROVIDER, EMAIL_FROM, SMTP_ADDR, SMTP_USERNAME and
+// EMAIL_BASE_URL from the environment. Email is off unless EMAIL_PROVIDER is
+// set.
+func LoadEmail() (Email, error) {
+	cfg := Email{
+		Provider:     strings.ToLower(strings.TrimSpace(os.Getenv("EMAIL_PROVIDER"))),
+		From:         os.Getenv("EMAIL_FROM"),
+		SMTPAddr:     os.Getenv("SMTP_ADDR"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		BaseURL:      DefaultSendGridBaseURL,
+	}
+	if v := os.Getenv("EMAIL_BASE_URL"); v != "" {
+		cfg.BaseURL = strings.TrimSuffix(v, "/")
+	}
+	switch cfg.Provider {
+	case "":
+		return cfg, nil
+	case EmailSMTP:
+		if cfg.SMTPAddr == "" {
+			return Email{}, errors.New("SMTP_ADDR is required when EMAIL_PROVIDER=smtp")
+		}
+	case EmailSendGrid:
+	default:
+		return Email{}, fmt.Errorf("unknown EMAIL_PROVIDER %q (use smtp or sendgrid)", cfg.Provider)
+	}
+	if cfg.From == "" {
+		return Email{}, errors.New("EMAIL_FROM is required when EMAIL_PROVIDER is set")
+	}
+	return cfg, nil
+}
+
+// Streaming configures how streamed answers are paced on their way to the
+// terminal and to web clients.
+type Streaming struct {
+	MinChunk int           // Text is held until this many bytes have arrived...
+	MaxDelay time.Duration // ...or this long has passed since the oldest of them.
+	Rate     int           // Terminal typewriter speed in runes per second; 0 prints text as it arrives.
+}
+
+// LoadStreaming reads STREAM_MIN_CHUNK, STREAM_MAX_DELAY and
+// STREAM_TYPEWRITER_RATE from the environment. By default text is sent in
+// pieces of at least 24 bytes, held no longer than 50ms, with no typewriter
+// effect.
+func LoadStreaming() (Streaming, error) {
+	cfg := Streaming{MinChunk: 24, MaxDelay: 50 * time.Millisecond}
+	if v := os.Getenv("STREAM_MIN_CHUNK"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Streaming{}, fmt.Errorf("invalid STREAM_MIN_CHUNK %q", v)
+		}
+		cfg.MinChunk = n
+	}
+	if v := os.Getenv("STREAM_MAX_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return Streaming{}, fmt.Errorf("invalid STREAM_MAX_DELAY %q", v)
+		}
+		cfg.MaxDelay = d
+	}
+	if v := os.Getenv("STREAM_TYPEWRITER_RATE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return Streaming{}, fmt.Errorf("invalid STREAM_TYPEWRITER_RATE %q", v)
+		}
+		cfg.Rate = n
+	}
+	return cfg, nil
+}
+
+// Answer post-processors, in the order ANSWER_POSTPROCESSORS may list them.
+const (
+	PostprocessStripThinking = "strip_thinking"
+	PostprocessLanguage      = "language"
+	PostprocessCitations     = "citations"
+	PostprocessLinks         = "links"
+)
+
+// Postprocess configures the post-processors the chat agent runs on each
+// finished answer before showing it and keeping it in the conversation.
+type Postprocess struct {
+	Steps    []string // Post-processors, run in this order.
+	Language string   // Language answers must be in for the language step: a code such as "en", or "question" for the question's.
+}
+
+// LoadPostprocess reads ANSWER_POSTPROCESSORS, a comma-separated list of
+// strip_thinking, language, citations and links, and ANSWER_LANGUAGE. By
+// default only strip_thinking runs, and the language step answers in the
+// language of the question.
+func LoadPostprocess() (Postprocess, error) {
+	cfg := Postprocess{Steps: []string{PostprocessStripThinking}, Language: "question"}
+	if v, ok := os.LookupEnv("ANSWER_POSTPROCESSORS"); ok {
+		cfg.Steps = nil
+		for _, step := range strings.Split(v, ",") {
+			switch step = strings.TrimSpace(step); step {
+			case "":
+			case PostprocessStripThinking, PostprocessLanguage, PostprocessCitations, PostprocessLinks:
+				cfg.Steps = append(cfg.Steps, step)
+			default:
+				return Postprocess{}, fmt.Errorf("invalid ANSWER_POSTPROCESSORS %q: unknown post-processor %q", v, step)
+			}
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("ANSWER_LANGUAGE")); v != "" {
+		cfg.Language = strings.ToLower(v)
+	}
+	return cfg, nil
+}
+
+// Heartbeat configures how long agent turns show they are still alive.
+type Heartbeat struct {
+	Interval time.Duration // How often a running turn reports its progress; 0 never.
+	Stall    time.Duration // How long a turn may go without model output or a tool call before it is stopped; 0 forever.
+}
+
+// LoadHeartbeat reads AGENT_HEARTBEAT_INTERVAL and AGENT_STALL_TIMEOUT from
+// the environment. By default a turn reports its progress every 15s and is
+// never stopped for stalling.
+func LoadHeartbeat() (Heartbeat, error) {
+	cfg := Heartbeat{Interval: 15 * time.Second}
+	if v := os.Getenv("AGENT_HEARTBEAT_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return Heartbeat{}, fmt.Errorf("invalid AGENT_HEARTBEAT_INTERVAL %q", v)
+		}
+		cfg.Interval = d
+	}
+	if v := os.Getenv("AGENT_STALL_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return Heartbeat{}, fmt.Errorf("invalid AGENT_STALL_TIMEOUT %q", v)
+		}
+		cfg.Stall = d
+	}
+	return cfg, nil
+}
+
+// Chaos configures the failures injected into model and tool calls to test
+// how the agent copes with them. Rates are probabilities from 0 to 1; all
+// are 0, injecting nothing, by default.
+type Chaos struct {
+	ModelRateLimit  float64  // Share of model calls rejected as rate limited (HTTP 429).
+	ToolTimeout     float64  // Share of tool calls that time out without running.
+	MalformedOutput float64  // Share of tool results cut off mid-way, as malformed JSON.
+	Tools           []string // Tools failures are injected into; empty for all.
+	Seed            int64    // Seeds the choice of calls, so a run can be repeated.
+}
+
+// Enabled reports whether any failure is injected.
+func (c Chaos) Enabled() bool {
+	return c.ModelRateLimit > 0 || c.ToolTimeout > 0 || c.MalformedOutput > 0
+}
+
+// LoadChaos reads CHAOS_MODEL_429_RATE, CHAOS_TOOL_TIMEOUT_RATE,
+// CHAOS_TOOL_MALFORMED_RATE, CHAOS_TOOLS and CHAOS_SEED from the
+// environment. Nothing is injected unless a rate is set; the seed defaults
+// to 1.
+func LoadChaos() (Chaos, error) {
+	cfg := Chaos{Seed: 1}
+	for _, setting := range []struct {
+		name string
+		dst  *float64
+	}{
+		{"CHAOS_MODEL_429_RATE", &cfg.ModelRateLimit},
+		{"CHAOS_TOOL_TIMEOUT_RATE", &cfg.ToolTimeout},
+		{"CHAOS_TOOL_MALFORMED_RATE", &cfg.MalformedOutput},
+	} {
+		v := os.Getenv(setting.name)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return Chaos{}, fmt.Errorf("invalid %s %q (use a rate from 0 to 1)", setting.name, v)
+		}
+		*setting.dst = f
+	}
+	for _, name := range strings.Split(os.Getenv("CHAOS_TOOLS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.Tools = append(cfg.Tools, name)
+		}
+	}
+	if v := os.Getenv("CHAOS_SEED"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return Chaos{}, fmt.Errorf("invalid CHAOS_SEED %q", v)
+		}
+		cfg.Seed = n
+	}
+	return cfg, nil
+}
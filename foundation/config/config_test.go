@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestRAGMinScore(t *testing.T) {
+	for value, want := range map[string]float64{
+		"":     DefaultRAGMinScore,
+		"0":    0,
+		"0.75": 0.75,
+		"1":    1,
+		"-0.5": -1, // Invalid.
+		"1.5":  -1,
+		"high": -1,
+	} {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("RAG_MIN_SCORE", value)
+			got, err := RAGMinScore()
+			switch {
+			case want < 0 && err == nil:
+				t.Errorf("RAG_MIN_SCORE=%q = %v, want an error", value, got)
+			case want >= 0 && (err != nil || got != want):
+				t.Errorf("RAG_MIN_SCORE=%q = %v, %v, want %v", value, got, err, want)
+			}
+		})
+	}
+}
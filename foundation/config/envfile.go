@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// EnvFile is the file `goforai init` writes the configuration to and every
+// command reads it from, the same .env the Makefile includes.
+const EnvFile = ".env"
+
+// envLine matches a KEY=value line, optionally exported or commented out.
+var envLine = regexp.MustCompile(`^\s*(#\s*)?(export\s+)?([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+// LoadEnvFile sets the variables assigned in the file at path that are not
+// already set, so the real environment always wins. A missing file sets
+// nothing.
+func LoadEnvFile(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m := envLine.FindStringSubmatch(scanner.Text())
+		if m == nil || m[1] != "" {
+			continue
+		}
+		if _, set := os.LookupEnv(m[3]); set {
+			continue
+		}
+		os.Setenv(m[3], envValue(m[4]))
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+// envValue unquotes a value, or cuts a trailing comment off an unquoted one.
+func envValue(raw string) string {
+	v := strings.TrimSpace(raw)
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') {
+		if end := strings.IndexByte(v[1:], v[0]); end >= 0 {
+			return v[1 : end+1]
+		}
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	return v
+}
+
+// UpdateEnvFile sets the variables in values in the file at path, creating
+// it if needed. A variable already assigned in the file, or commented out as
+// in .env.example, is replaced in place; the others are appended. The rest
+// of the file is kept as it is. The file is readable by its owner only,
+// since it can hold API keys.
+func UpdateEnvFile(path string, values map[string]string) error {
+	var lines []string
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	done := make(map[string]bool, len(values))
+	// Active assignments take precedence over commented-out examples.
+	for _, commented := range []bool{false, true} {
+		for i, line := range lines {
+			m := envLine.FindStringSubmatch(line)
+			if m == nil || (m[1] != "") != commented || done[m[3]] {
+				continue
+			}
+			if v, ok := values[m[3]]; ok {
+				lines[i] = m[3] + "=" + quoteEnv(v)
+				done[m[3]] = true
+			}
+		}
+	}
+	var added []string
+	for key := range values {
+		if !done[key] {
+			added = append(added, key)
+		}
+	}
+	slices.Sort(added)
+	for _, key := range added {
+		lines = append(lines, key+"="+quoteEnv(values[key]))
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".env-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// quoteEnv quotes values that would not survive unquoted.
+func quoteEnv(v string) string {
+	switch {
+	case strings.Contains(v, `"`):
+		return "'" + v + "'"
+	case v == "" || strings.ContainsAny(v, " \t#'"):
+		return `"` + v + `"`
+	}
+	return v
+}
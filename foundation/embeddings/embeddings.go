@@ -0,0 +1,98 @@
+// Package embeddings creates the embedder for the knowledge base from the
+// configured provider: Gemini, OpenAI, or a local model served by Ollama.
+// The indexing pipeline and the RAG tool both go through New, so the index
+// and the queries against it are embedded the same way.
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/ratelimit"
+	"github.com/olusolaa/goforai/foundation/wirelog"
+)
+
+// Provider creates embedders for one embedding API.
+type Provider struct {
+	// DefaultModel is used when no model is configured.
+	DefaultModel string
+	// New returns an embedder for model. baseURL is empty when the
+	// provider's default endpoint should be used.
+	New func(ctx context.Context, model, baseURL string) (embedding.Embedder, error)
+}
+
+// Providers maps the config.Embedding* names to their providers.
+var Providers = map[string]Provider{
+	config.EmbeddingGemini: {
+		DefaultModel: gemini.EmbeddingModelName,
+		New: func(ctx context.Context, model, _ string) (embedding.Embedder, error) {
+			return gemini.NewEmbedder(ctx, model)
+		},
+	},
+	config.EmbeddingOpenAI: {DefaultModel: "text-embedding-3-small", New: newOpenAI},
+	config.EmbeddingOllama: {DefaultModel: "nomic-embed-text", New: newOllama},
+}
+
+// New returns the embedder cfg selects, and the model ID to record in the
+// index manifest. Gemini models are recorded by their bare name, as they were
+// before other providers existed, so existing indexes stay valid; other
+// models are prefixed with their provider, e.g. "ollama/nomic-embed-text".
+func New(ctx context.Context, cfg config.Embeddings) (embedding.Embedder, string, error) {
+	provider, ok := Providers[cfg.Provider]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown embedding provider %q", cfg.Provider)
+	}
+	model := cfg.Model
+	if model == "" {
+		model = provider.DefaultModel
+	}
+	embedder, err := provider.New(ctx, model, cfg.BaseURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Provider == config.EmbeddingGemini {
+		return embedder, model, nil
+	}
+	return embedder, cfg.Provider + "/" + model, nil
+}
+
+// newHTTPClient returns the client an HTTP-based provider sends its
+// requests with, logged under provider when the wire log is on. Embedding a
+// large batch on a local model can take a while, hence the generous timeout.
+func newHTTPClient(provider string) (*http.Client, error) {
+	transport, err := wirelog.Transport(provider)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Timeout: 2 * time.Minute, Transport: transport}, nil
+}
+
+// batchSize bounds the number of texts sent in one embedding request.
+const batchSize = 96
+
+// embedBatches embeds texts in batches of batchSize with embed, which must
+// return one vector per text. Each batch waits for the rate limit of
+// provider.
+func embedBatches(ctx context.Context, provider string, texts []string, embed func(context.Context, []string) ([][]float64, error)) ([][]float64, error) {
+	vectors := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		batch := texts[start:min(start+batchSize, len(texts))]
+		if err := ratelimit.Wait(ctx, provider); err != nil {
+			return nil, err
+		}
+		out, err := embed(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(out) != len(batch) {
+			return nil, fmt.Errorf("embedding API returned %d vectors for %d texts", len(out), len(batch))
+		}
+		vectors = append(vectors, out...)
+	}
+	return vectors, nil
+}
@@ -0,0 +1,119 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// embedServer serves an embedding API at path. Each text is embedded as
+// {len(text), batch number}; decode extracts the texts of a request and
+// encode builds the response.
+func embedServer(t *testing.T, path string, decode func(map[string]any) []string, encode func([][]float64) any) (*httptest.Server, *[]int) {
+	t.Helper()
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["model"] != "test-model" {
+			http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+			return
+		}
+		texts := decode(req)
+		batches = append(batches, len(texts))
+		vectors := make([][]float64, len(texts))
+		for i, text := range texts {
+			vectors[i] = []float64{float64(len(text)), float64(len(batches))}
+		}
+		json.NewEncoder(w).Encode(encode(vectors))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &batches
+}
+
+func inputs(req map[string]any) []string {
+	var texts []string
+	for _, v := range req["input"].([]any) {
+		texts = append(texts, v.(string))
+	}
+	return texts
+}
+
+func TestProviders(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	openAI, openAIBatches := embedServer(t, "/v1/embeddings", inputs, func(vectors [][]float64) any {
+		// Return the data out of order; the embedder must sort by index.
+		var data []map[string]any
+		for i := len(vectors) - 1; i >= 0; i-- {
+			data = append(data, map[string]any{"index": i, "embedding": vectors[i]})
+		}
+		return map[string]any{"data": data}
+	})
+	ollama, ollamaBatches := embedServer(t, "/api/embed", inputs, func(vectors [][]float64) any {
+		return map[string]any{"embeddings": vectors}
+	})
+
+	texts := make([]string, batchSize+5)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i)
+	}
+	for _, tc := range []struct {
+		cfg     config.Embeddings
+		wantID  string
+		batches *[]int
+	}{
+		{config.Embeddings{Provider: config.EmbeddingOpenAI, Model: "test-model", BaseURL: openAI.URL + "/v1"}, "openai/test-model", openAIBatches},
+		{config.Embeddings{Provider: config.EmbeddingOllama, Model: "test-model", BaseURL: ollama.URL}, "ollama/test-model", ollamaBatches},
+	} {
+		t.Run(tc.cfg.Provider, func(t *testing.T) {
+			embedder, id, err := New(context.Background(), tc.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tc.wantID {
+				t.Errorf("model ID = %q, want %q", id, tc.wantID)
+			}
+			vectors, err := embedder.EmbedStrings(context.Background(), texts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vectors) != len(texts) {
+				t.Fatalf("got %d vectors for %d texts", len(vectors), len(texts))
+			}
+			for i, v := range vectors {
+				if wantBatch := float64(i/batchSize + 1); v[0] != float64(i) || v[1] != wantBatch {
+					t.Fatalf("vector %d = %v, want [%d %v]", i, v, i, wantBatch)
+				}
+			}
+			if fmt.Sprint(*tc.batches) != fmt.Sprint([]int{batchSize, 5}) {
+				t.Errorf("batches = %v", *tc.batches)
+			}
+
+			bad := tc.cfg
+			bad.Model = "missing-model"
+			embedder, _, _ = New(context.Background(), bad)
+			if _, err := embedder.EmbedStrings(context.Background(), []string{"x"}); err == nil || !strings.Contains(err.Error(), "bad request") {
+				t.Errorf("EmbedStrings with an unknown model = %v, want the API's message", err)
+			}
+		})
+	}
+}
+
+func TestNewUsesProviderDefaults(t *testing.T) {
+	_, id, err := New(context.Background(), config.Embeddings{Provider: config.EmbeddingOllama})
+	if err != nil || id != "ollama/nomic-embed-text" {
+		t.Errorf("New(ollama) = %q, %v", id, err)
+	}
+	if _, _, err := New(context.Background(), config.Embeddings{Provider: "cohere"}); err == nil {
+		t.Error("New accepted an unknown provider")
+	}
+}
@@ -0,0 +1,141 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/ollama"
+	"github.com/olusolaa/goforai/foundation/secrets"
+)
+
+// DefaultOpenAIBaseURL and DefaultOllamaBaseURL are the endpoints used when
+// EMBEDDING_BASE_URL is unset.
+const (
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOllamaBaseURL = ollama.DefaultBaseURL
+)
+
+// openAIEmbedder calls the OpenAI embeddings API, or any API compatible with
+// it, such as Azure OpenAI or vLLM.
+type openAIEmbedder struct {
+	model, baseURL, apiKey string
+	client                 *http.Client
+}
+
+func newOpenAI(_ context.Context, model, baseURL string) (embedding.Embedder, error) {
+	apiKey, err := secrets.Get(secrets.OpenAIAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	client, err := newHTTPClient(config.EmbeddingOpenAI)
+	if err != nil {
+		return nil, err
+	}
+	return &openAIEmbedder{model: model, baseURL: baseURL, apiKey: apiKey, client: client}, nil
+}
+
+func (e *openAIEmbedder) EmbedStrings(ctx context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	return embedBatches(ctx, config.EmbeddingOpenAI, texts, func(ctx context.Context, batch []string) ([][]float64, error) {
+		var resp struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float64 `json:"embedding"`
+			} `json:"data"`
+		}
+		req := map[string]any{"model": e.model, "input": batch}
+		if err := postJSON(ctx, e.client, e.baseURL+"/embeddings", e.apiKey, req, &resp); err != nil {
+			return nil, fmt.Errorf("OpenAI embedding request failed: %w", err)
+		}
+		vectors := make([][]float64, len(batch))
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(batch) {
+				return nil, fmt.Errorf("OpenAI embedding response has out-of-range index %d", d.Index)
+			}
+			vectors[d.Index] = d.Embedding
+		}
+		return vectors, nil
+	})
+}
+
+func (e *openAIEmbedder) GetType() string { return "OpenAI" }
+
+// ollamaEmbedder calls a local Ollama server.
+type ollamaEmbedder struct {
+	model, baseURL string
+	client         *http.Client
+}
+
+func newOllama(_ context.Context, model, baseURL string) (embedding.Embedder, error) {
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+	client, err := newHTTPClient(config.EmbeddingOllama)
+	if err != nil {
+		return nil, err
+	}
+	return &ollamaEmbedder{model: model, baseURL: baseURL, client: client}, nil
+}
+
+func (e *ollamaEmbedder) EmbedStrings(ctx context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	return embedBatches(ctx, config.EmbeddingOllama, texts, func(ctx context.Context, batch []string) ([][]float64, error) {
+		var resp struct {
+			Embeddings [][]float64 `json:"embeddings"`
+		}
+		req := map[string]any{"model": e.model, "input": batch}
+		if err := postJSON(ctx, e.client, e.baseURL+"/api/embed", "", req, &resp); err != nil {
+			return nil, fmt.Errorf("Ollama embedding request failed (is `ollama serve` running and `ollama pull %s` done?): %w", e.model, err)
+		}
+		return resp.Embeddings, nil
+	})
+}
+
+func (e *ollamaEmbedder) GetType() string { return "Ollama" }
+
+// postJSON posts body to url with client and decodes the JSON response into out. Error
+// responses are reported with the message the API returned.
+func postJSON(ctx context.Context, client *http.Client, url, apiKey string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr struct {
+			Error any `json:"error"`
+		}
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error != nil {
+			if m, ok := apiErr.Error.(map[string]any); ok && m["message"] != nil {
+				return fmt.Errorf("status %d: %v", resp.StatusCode, m["message"])
+			}
+			return fmt.Errorf("status %d: %v", resp.StatusCode, apiErr.Error)
+		}
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(raw))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
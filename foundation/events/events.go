@@ -0,0 +1,161 @@
+// Package events notifies external systems about agent activity. Webhooks
+// receive a JSON payload when a turn completes or fails, when a tool call
+// fails, and when a client crosses its token budget threshold, so alerting
+// and audit pipelines can follow the agent without scraping logs. Long turns
+// also report their progress periodically, so a watchdog can tell a slow run
+// from a stuck one.
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/logger"
+)
+
+// Event types.
+const (
+	TurnCompleted   = "turn.completed"
+	TurnFailed      = "turn.failed"
+	TurnProgress    = "turn.progress"
+	ToolFailed      = "tool.failed"
+	BudgetThreshold = "budget.threshold"
+)
+
+// Event is the JSON payload posted to webhooks. Fields that do not apply to
+// an event type are omitted.
+type Event struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	Client    string    `json:"client,omitempty"`
+	Prompt    string    `json:"prompt,omitempty"`
+	Answer    string    `json:"answer,omitempty"`
+	Tool      string    `json:"tool,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Usage     *Usage    `json:"usage,omitempty"`
+	Budget    *Budget   `json:"budget,omitempty"`
+	Progress  *Progress `json:"progress,omitempty"`
+}
+
+// Usage is the model token usage of a turn.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Budget describes a client's token budget when a threshold is crossed.
+type Budget struct {
+	Used      int64   `json:"used"`
+	Limit     int64   `json:"limit"`
+	Threshold float64 `json:"threshold"`
+}
+
+// Dispatcher posts events to the configured webhooks. A nil *Dispatcher is
+// valid and drops every event, so callers need no nil checks.
+type Dispatcher struct {
+	urls   []string
+	types  map[string]bool // Empty means every type.
+	secret string
+	client *http.Client
+}
+
+// NewDispatcher creates a dispatcher that posts the given event types (all
+// when empty) to urls. When secret is set each request carries an
+// X-Goforai-Signature header: the hex HMAC-SHA256 of the body.
+func NewDispatcher(urls []string, types []string, secret string) *Dispatcher {
+	d := &Dispatcher{
+		urls:   urls,
+		types:  make(map[string]bool),
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, t := range types {
+		d.types[t] = true
+	}
+	return d
+}
+
+// NewDispatcherFromEnv reads EVENT_WEBHOOK_URLS (comma-separated),
+// EVENT_WEBHOOK_EVENTS (comma-separated types) and EVENT_WEBHOOK_SECRET.
+// It returns nil when no URL is configured.
+func NewDispatcherFromEnv() *Dispatcher {
+	urls := splitList(os.Getenv("EVENT_WEBHOOK_URLS"))
+	if len(urls) == 0 {
+		return nil
+	}
+	return NewDispatcher(urls, splitList(os.Getenv("EVENT_WEBHOOK_EVENTS")), os.Getenv("EVENT_WEBHOOK_SECRET"))
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Emit delivers e in the background. Delivery failures are logged and never
+// slow down or fail the turn that produced the event.
+func (d *Dispatcher) Emit(ctx context.Context, e Event) {
+	if d == nil || (len(d.types) > 0 && !d.types[e.Type]) {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	log := logger.FromContext(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 15*time.Second)
+		defer cancel()
+		for _, url := range d.urls {
+			if err := d.post(ctx, url, e); err != nil {
+				log.Warn("failed to deliver event", "event", e.Type, "url", url, "error", err)
+			}
+		}
+	}()
+}
+
+func (d *Dispatcher) post(ctx context.Context, url string, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create event request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Goforai-Event", e.Type)
+	if d.secret != "" {
+		mac := hmac.New(sha256.New, []byte(d.secret))
+		mac.Write(body)
+		req.Header.Set("X-Goforai-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("event delivery failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("event delivery returned status %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
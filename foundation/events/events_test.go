@@ -0,0 +1,120 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// delivery is one request received by a webhook.
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+func webhook(t *testing.T) (string, chan delivery) {
+	t.Helper()
+	got := make(chan delivery, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read event: %v", err)
+		}
+		got <- delivery{header: r.Header, body: body}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, got
+}
+
+func receive(t *testing.T, got chan delivery) delivery {
+	t.Helper()
+	select {
+	case d := <-got:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event delivered")
+		return delivery{}
+	}
+}
+
+func decode(t *testing.T, d delivery) Event {
+	t.Helper()
+	var e Event
+	if err := json.Unmarshal(d.body, &e); err != nil {
+		t.Fatalf("invalid event %s: %v", d.body, err)
+	}
+	return e
+}
+
+func TestSignature(t *testing.T) {
+	url, got := webhook(t)
+	d := NewDispatcher([]string{url}, nil, "s3cret")
+	d.Emit(context.Background(), Event{
+		Type:      TurnCompleted,
+		Time:      time.Date(2025, 10, 6, 9, 0, 0, 0, time.UTC),
+		RequestID: "req-1",
+		Answer:    "done",
+	})
+
+	req := receive(t, got)
+	const body = `{"type":"turn.completed","time":"2025-10-06T09:00:00Z","request_id":"req-1","answer":"done"}`
+	if string(req.body) != body {
+		t.Fatalf("body = %s, want %s", req.body, body)
+	}
+	// HMAC-SHA256 of body under the key "s3cret".
+	const want = "sha256=95f8263754466536becb6dc8218e823065aa69671a6837fbb231edec666d6397"
+	if sig := req.header.Get("X-Goforai-Signature"); sig != want {
+		t.Errorf("X-Goforai-Signature = %q, want %q", sig, want)
+	}
+	if ev := req.header.Get("X-Goforai-Event"); ev != TurnCompleted {
+		t.Errorf("X-Goforai-Event = %q", ev)
+	}
+}
+
+func TestNoSignatureWithoutSecret(t *testing.T) {
+	url, got := webhook(t)
+	NewDispatcher([]string{url}, nil, "").Emit(context.Background(), Event{Type: TurnCompleted})
+	if sig := receive(t, got).header.Get("X-Goforai-Signature"); sig != "" {
+		t.Errorf("unsigned dispatcher sent X-Goforai-Signature %q", sig)
+	}
+}
+
+func TestEventTypeFilter(t *testing.T) {
+	url, got := webhook(t)
+	d := NewDispatcher([]string{url}, []string{TurnFailed, BudgetThreshold}, "")
+	d.Emit(context.Background(), Event{Type: TurnCompleted})
+	d.Emit(context.Background(), Event{Type: ToolFailed})
+	d.Emit(context.Background(), Event{Type: TurnFailed, Error: "boom"})
+
+	if e := decode(t, receive(t, got)); e.Type != TurnFailed || e.Error != "boom" {
+		t.Errorf("delivered %+v, want the turn.failed event", e)
+	}
+	select {
+	case d := <-got:
+		t.Errorf("filtered event was delivered: %s", d.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNilDispatcherDropsEvents(t *testing.T) {
+	var d *Dispatcher
+	d.Emit(context.Background(), Event{Type: TurnCompleted})
+	d.StartTurn(Event{}).End(context.Background(), "answer", nil)
+}
+
+func TestNewDispatcherFromEnv(t *testing.T) {
+	t.Setenv("EVENT_WEBHOOK_URLS", "")
+	if d := NewDispatcherFromEnv(); d != nil {
+		t.Errorf("dispatcher without URLs = %+v, want nil", d)
+	}
+	t.Setenv("EVENT_WEBHOOK_URLS", " https://a.test/hook , ,https://b.test/hook")
+	t.Setenv("EVENT_WEBHOOK_EVENTS", "turn.failed, tool.failed")
+	d := NewDispatcherFromEnv()
+	if d == nil || len(d.urls) != 2 || d.urls[1] != "https://b.test/hook" || !d.types[ToolFailed] || d.types[TurnCompleted] {
+		t.Errorf("unexpected dispatcher %+v", d)
+	}
+}
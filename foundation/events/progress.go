@@ -0,0 +1,123 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStalled is the cause a turn is cancelled with when it goes longer than
+// Heartbeat.Stall without model output or a tool call.
+var ErrStalled = errors.New("turn stalled")
+
+// Progress is how far a running turn has got. It is the payload of
+// turn.progress events.
+type Progress struct {
+	Step     int     `json:"step"`                // Model calls started, one per step of the ReAct loop.
+	LastTool string  `json:"last_tool,omitempty"` // The tool called most recently.
+	Elapsed  float64 `json:"elapsed_seconds"`     // Since the turn started.
+	Idle     float64 `json:"idle_seconds"`        // Since the last model output or tool call.
+}
+
+// String describes p for a person watching the turn.
+func (p Progress) String() string {
+	s := fmt.Sprintf("step %d, %s elapsed", p.Step, duration(p.Elapsed).Round(time.Second))
+	if p.LastTool != "" {
+		s += ", last tool " + p.LastTool
+	}
+	return s
+}
+
+func duration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Heartbeat configures Turn.Watch.
+type Heartbeat struct {
+	Interval time.Duration           // How often progress is reported; 0 never.
+	Stall    time.Duration           // How long the turn may go without model output or a tool call; 0 forever.
+	Abort    context.CancelCauseFunc // Cancels the turn; called with ErrStalled.
+}
+
+// Progress returns how far the turn has got.
+func (t *Turn) Progress() Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	return Progress{
+		Step:     t.step,
+		LastTool: t.lastTool,
+		Elapsed:  now.Sub(t.started).Seconds(),
+		Idle:     now.Sub(t.active).Seconds(),
+	}
+}
+
+// OnProgress calls fn with the turn's progress on every heartbeat, such as
+// to show it to the user the turn is for.
+func (t *Turn) OnProgress(fn func(Progress)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.listeners = append(t.listeners, fn)
+}
+
+// Watch reports the turn's progress every h.Interval, as a turn.progress
+// event and to the OnProgress listeners, so a long autonomous run shows it
+// is still alive. With h.Stall set it also aborts the turn once it has gone
+// that long without model output or a tool call. Watching stops when the
+// turn ends or ctx is done.
+func (t *Turn) Watch(ctx context.Context, h Heartbeat) {
+	tick := h.Interval
+	if h.Stall > 0 && (tick <= 0 || h.Stall/4 < tick) {
+		tick = h.Stall / 4
+	}
+	if tick <= 0 {
+		return
+	}
+	ctx, stop := context.WithCancel(ctx)
+	t.mu.Lock()
+	t.stopWatch = stop
+	t.mu.Unlock()
+
+	go func() {
+		defer stop()
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		var reported time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				p := t.Progress()
+				if idle := duration(p.Idle); h.Stall > 0 && h.Abort != nil && idle >= h.Stall {
+					h.Abort(fmt.Errorf("%w: no model output or tool call for %s", ErrStalled, idle.Round(time.Second)))
+					return
+				}
+				if h.Interval > 0 && now.Sub(reported) >= h.Interval {
+					reported = now
+					t.report(ctx, p)
+				}
+			}
+		}
+	}()
+}
+
+func (t *Turn) report(ctx context.Context, p Progress) {
+	t.mu.Lock()
+	listeners := t.listeners
+	t.mu.Unlock()
+	for _, fn := range listeners {
+		fn(p)
+	}
+	e := t.base
+	e.Type, e.Progress = TurnProgress, &p
+	t.d.Emit(ctx, e)
+}
+
+// touch records activity on the turn, for the stall watchdog.
+func (t *Turn) touch() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active = time.Now()
+}
@@ -0,0 +1,175 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Turn collects what happens during one agent turn so a single event can
+// describe it when it ends. Tool failures are emitted as they happen, and
+// progress while the turn is watched.
+type Turn struct {
+	d       *Dispatcher
+	base    Event
+	started time.Time
+
+	mu        sync.Mutex
+	usage     Usage
+	streams   sync.WaitGroup // Streamed model outputs still being counted.
+	step      int
+	lastTool  string
+	active    time.Time          // The last model output or tool call.
+	listeners []func(Progress)   // Added by OnProgress.
+	stopWatch context.CancelFunc // Set by Watch.
+}
+
+// StartTurn begins tracking a turn. base supplies the identifying fields
+// (request, session, client, prompt) copied into every event of the turn.
+// On a nil Dispatcher the turn is tracked but its events are dropped.
+func (d *Dispatcher) StartTurn(base Event) *Turn {
+	now := time.Now()
+	return &Turn{d: d, base: base, started: now, active: now}
+}
+
+// Handler returns the callback handler that feeds the turn. Pass it to the
+// graph with compose.WithCallbacks.
+func (t *Turn) Handler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			switch info.Component {
+			case components.ComponentOfChatModel:
+				t.start(func() { t.step++ })
+			case components.ComponentOfTool:
+				t.start(func() { t.lastTool = info.Name })
+			}
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			switch info.Component {
+			case components.ComponentOfChatModel:
+				t.touch()
+				t.addUsage(model.ConvCallbackOutput(output))
+			case components.ComponentOfTool:
+				t.touch()
+				// Foundation tools report failures in an "error" field rather
+				// than as Go errors.
+				if out := tool.ConvCallbackOutput(output); out != nil {
+					if msg := ToolError(out.Response); msg != "" {
+						t.toolFailed(ctx, info.Name, msg)
+					}
+				}
+			}
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			if info.Component != components.ComponentOfChatModel {
+				output.Close()
+				return ctx
+			}
+			t.streams.Add(1)
+			go func() {
+				defer t.streams.Done()
+				defer output.Close()
+				// Usage arrives on the final chunk; keep the last one seen.
+				var last *model.CallbackOutput
+				for {
+					chunk, err := output.Recv()
+					if err != nil {
+						break
+					}
+					t.touch()
+					if out := model.ConvCallbackOutput(chunk); out != nil && out.TokenUsage != nil {
+						last = out
+					}
+				}
+				t.addUsage(last)
+			}()
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			if info.Component == components.ComponentOfTool {
+				t.toolFailed(ctx, info.Name, err.Error())
+			}
+			return ctx
+		}).
+		Build()
+}
+
+// start records a model or tool call starting; update notes which.
+func (t *Turn) start(update func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	update()
+	t.active = time.Now()
+}
+
+func (t *Turn) addUsage(out *model.CallbackOutput) {
+	if out == nil || out.TokenUsage == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage.PromptTokens += out.TokenUsage.PromptTokens
+	t.usage.CompletionTokens += out.TokenUsage.CompletionTokens
+	t.usage.TotalTokens += out.TokenUsage.TotalTokens
+}
+
+func (t *Turn) toolFailed(ctx context.Context, name, msg string) {
+	e := t.base
+	e.Type, e.Tool, e.Error = ToolFailed, name, msg
+	t.d.Emit(ctx, e)
+}
+
+// Usage returns the tokens the turn's model calls used, once every streamed
+// output has been counted.
+func (t *Turn) Usage() Usage {
+	t.streams.Wait()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+// End emits turn.completed with the answer, or turn.failed when err is set,
+// and stops watching the turn.
+func (t *Turn) End(ctx context.Context, answer string, err error) {
+	t.mu.Lock()
+	if t.stopWatch != nil {
+		t.stopWatch()
+	}
+	t.mu.Unlock()
+	usage := t.Usage()
+	e := t.base
+	e.Type, e.Answer, e.Usage = TurnCompleted, answer, &usage
+	if err != nil {
+		e.Type, e.Error = TurnFailed, err.Error()
+	}
+	t.d.Emit(ctx, e)
+}
+
+// ToolError extracts the message of a failure from a tool's JSON response,
+// or returns "" when the tool succeeded. The "error" field is either the
+// message or, once tools.RetryGuide has seen the result, an object holding
+// it with the failure's code.
+func ToolError(response string) string {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal([]byte(response), &resp) != nil || len(resp.Error) == 0 {
+		return ""
+	}
+	var failure struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(resp.Error, &failure.Message) != nil {
+		json.Unmarshal(resp.Error, &failure)
+	}
+	return failure.Message
+}
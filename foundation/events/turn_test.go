@@ -0,0 +1,92 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func TestTurnEvents(t *testing.T) {
+	url, got := webhook(t)
+	d := NewDispatcher([]string{url}, nil, "")
+	turn := d.StartTurn(Event{RequestID: "req-1", Client: "alice", Prompt: "question"})
+	ctx := callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{Name: "gemini", Component: components.ComponentOfChatModel}, turn.Handler())
+
+	for i := 0; i < 2; i++ {
+		callbacks.OnEnd(ctx, &model.CallbackOutput{TokenUsage: &model.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}})
+	}
+	toolCtx := callbacks.ReuseHandlers(ctx, &callbacks.RunInfo{Name: "read_file", Component: components.ComponentOfTool})
+	callbacks.OnEnd(toolCtx, &tool.CallbackOutput{Response: `{"error":"file not found"}`})
+	callbacks.OnEnd(toolCtx, &tool.CallbackOutput{Response: `{"content":"ok"}`})
+
+	e := decode(t, receive(t, got))
+	if e.Type != ToolFailed || e.Tool != "read_file" || e.Error != "file not found" || e.RequestID != "req-1" {
+		t.Errorf("tool event = %+v", e)
+	}
+
+	turn.End(context.Background(), "", errors.New("model unavailable"))
+	e = decode(t, receive(t, got))
+	if e.Type != TurnFailed || e.Error != "model unavailable" || e.Client != "alice" || e.Prompt != "question" {
+		t.Errorf("turn event = %+v", e)
+	}
+	if e.Usage == nil || e.Usage.TotalTokens != 30 || e.Usage.PromptTokens != 20 {
+		t.Errorf("turn usage = %+v, want the sum of both model calls", e.Usage)
+	}
+}
+
+func TestTurnProgress(t *testing.T) {
+	url, got := webhook(t)
+	d := NewDispatcher([]string{url}, []string{TurnProgress}, "")
+	turn := d.StartTurn(Event{RequestID: "req-1"})
+	ctx := callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{Name: "gemini", Component: components.ComponentOfChatModel}, turn.Handler())
+	callbacks.OnStart(ctx, &model.CallbackInput{})
+	toolCtx := callbacks.ReuseHandlers(ctx, &callbacks.RunInfo{Name: "run_tests", Component: components.ComponentOfTool})
+	callbacks.OnStart(toolCtx, &tool.CallbackInput{})
+	callbacks.OnStart(ctx, &model.CallbackInput{})
+
+	listened := make(chan Progress, 10)
+	turn.OnProgress(func(p Progress) { listened <- p })
+	turn.Watch(context.Background(), Heartbeat{Interval: 10 * time.Millisecond})
+	e := decode(t, receive(t, got))
+	if e.Type != TurnProgress || e.RequestID != "req-1" || e.Progress == nil {
+		t.Fatalf("progress event = %+v", e)
+	}
+	if p := *e.Progress; p.Step != 2 || p.LastTool != "run_tests" || p.Elapsed <= 0 {
+		t.Errorf("progress = %+v, want step 2 after run_tests", p)
+	}
+	if p := <-listened; p.Step != 2 {
+		t.Errorf("listener got %+v", p)
+	}
+	turn.End(context.Background(), "answer", nil)
+}
+
+func TestTurnStalls(t *testing.T) {
+	turn := (*Dispatcher)(nil).StartTurn(Event{})
+	ctx := callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{Name: "run_tests", Component: components.ComponentOfTool}, turn.Handler())
+	turnCtx, abort := context.WithCancelCause(context.Background())
+	defer abort(nil)
+	turn.Watch(turnCtx, Heartbeat{Stall: 100 * time.Millisecond, Abort: abort})
+
+	// Activity keeps the turn alive past the stall timeout.
+	for range 5 {
+		time.Sleep(40 * time.Millisecond)
+		callbacks.OnStart(ctx, &tool.CallbackInput{})
+	}
+	if err := context.Cause(turnCtx); err != nil {
+		t.Fatalf("an active turn was stopped: %v", err)
+	}
+	select {
+	case <-turnCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("a quiet turn was not stopped")
+	}
+	if err := context.Cause(turnCtx); !errors.Is(err, ErrStalled) {
+		t.Errorf("cause = %v, want ErrStalled", err)
+	}
+}
@@ -0,0 +1,387 @@
+// Package fakeapi provides httptest-based stand-ins for the Gemini and Tavily
+// APIs. Tests script the replies, point the clients at the fakes through
+// GEMINI_BASE_URL and TAVILY_BASE_URL, and run the real agent end to end
+// without network access or API keys.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// EmbeddingDimension is the size of the vectors returned by the fake
+// embedding endpoint.
+const EmbeddingDimension = 16
+
+// Gemini fakes the generateContent, streamGenerateContent, batchEmbedContents,
+// countTokens and models.get endpoints of the Gemini API.
+type Gemini struct {
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	replies  []GeminiReply
+	requests []GeminiRequest
+	counts   int // countTokens calls received.
+}
+
+// GeminiReply is one scripted answer to a generate call.
+type GeminiReply struct {
+	Text      string           // Model text; streamed word by word.
+	ToolCalls []GeminiCall     // Function calls the model makes instead of, or with, text.
+	Status    int              // Non-zero makes the call fail with this HTTP status.
+	Message   string           // Error message when Status is set.
+	Usage     *GeminiTokens    // Reported token usage; defaults to a fixed small count.
+	Grounding *GeminiGrounding // Search grounding metadata sent with the text.
+}
+
+// GeminiGrounding is the grounding metadata of an answer written from a
+// Google Search.
+type GeminiGrounding struct {
+	Queries  []string
+	Sources  []GeminiSource
+	Supports []GeminiSupport
+}
+
+// GeminiSource is a web page a grounded answer is based on.
+type GeminiSource struct {
+	Title, URI, Domain string
+}
+
+// GeminiSupport ties a part of a grounded answer to sources, by index.
+type GeminiSupport struct {
+	Text       string
+	Sources    []int
+	Confidence []float64
+}
+
+// GeminiCall is a function call made by the fake model.
+type GeminiCall struct {
+	Name string
+	Args map[string]any
+}
+
+// GeminiTokens is the token usage reported with a reply.
+type GeminiTokens struct {
+	Prompt, Completion int
+}
+
+// GeminiRequest is a generate call received by the fake.
+type GeminiRequest struct {
+	Model    string
+	Stream   bool
+	Contents []GeminiContent
+	System   string   // Text of the system instruction.
+	Tools    []string // Names of the functions the caller declared.
+
+	GoogleSearch bool // The caller enabled the Google Search tool.
+}
+
+// GeminiContent is one message of a generate request.
+type GeminiContent struct {
+	Role  string
+	Parts []GeminiPart
+}
+
+// GeminiPart is a part of a message; only the fields the agent uses are kept.
+type GeminiPart struct {
+	Text             string          `json:"text,omitempty"`
+	FunctionCall     *functionCall   `json:"functionCall,omitempty"`
+	FunctionResponse json.RawMessage `json:"functionResponse,omitempty"`
+}
+
+type functionCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// NewGemini starts a fake Gemini API that is shut down with the test.
+func NewGemini(t testing.TB) *Gemini {
+	t.Helper()
+	g := &Gemini{}
+	g.server = httptest.NewServer(http.HandlerFunc(g.handle))
+	g.URL = g.server.URL
+	t.Cleanup(g.server.Close)
+	return g
+}
+
+// Use points the Gemini client at the fake for the rest of the test.
+func (g *Gemini) Use(t testing.TB) {
+	t.Helper()
+	t.Setenv("GEMINI_BASE_URL", g.URL)
+	t.Setenv("GEMINI_API_KEY", "fake-gemini-key")
+}
+
+// Reply queues replies for the following generate calls, in order.
+func (g *Gemini) Reply(replies ...GeminiReply) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.replies = append(g.replies, replies...)
+}
+
+// ReplyText queues a plain text answer.
+func (g *Gemini) ReplyText(text string) {
+	g.Reply(GeminiReply{Text: text})
+}
+
+// ReplyToolCall queues a single function call.
+func (g *Gemini) ReplyToolCall(name string, args map[string]any) {
+	g.Reply(GeminiReply{ToolCalls: []GeminiCall{{Name: name, Args: args}}})
+}
+
+// ReplyError queues a failed call, e.g. 429 RESOURCE_EXHAUSTED.
+func (g *Gemini) ReplyError(status int, message string) {
+	g.Reply(GeminiReply{Status: status, Message: message})
+}
+
+// Requests returns the generate calls received so far.
+func (g *Gemini) Requests() []GeminiRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]GeminiRequest(nil), g.requests...)
+}
+
+// TokenCounts reports how many countTokens calls the fake received.
+func (g *Gemini) TokenCounts() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.counts
+}
+
+// Pending reports how many scripted replies have not been used.
+func (g *Gemini) Pending() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.replies)
+}
+
+func (g *Gemini) handle(w http.ResponseWriter, r *http.Request) {
+	// Paths look like /v1beta/models/gemini-2.5-flash:generateContent.
+	_, name, ok := strings.Cut(r.URL.Path, "/models/")
+	if !ok {
+		writeGeminiError(w, http.StatusNotFound, "unknown endpoint "+r.URL.Path)
+		return
+	}
+	model, method, _ := strings.Cut(name, ":")
+
+	switch {
+	case r.Method == http.MethodGet && method == "":
+		writeJSON(w, http.StatusOK, map[string]any{"name": "models/" + model})
+	case method == "batchEmbedContents":
+		g.handleEmbed(w, r)
+	case method == "countTokens":
+		g.handleCountTokens(w, r)
+	case method == "generateContent" || method == "streamGenerateContent":
+		g.handleGenerate(w, r, model, method == "streamGenerateContent")
+	default:
+		writeGeminiError(w, http.StatusNotFound, "unknown method "+method)
+	}
+}
+
+func (g *Gemini) handleGenerate(w http.ResponseWriter, r *http.Request, model string, stream bool) {
+	var body struct {
+		Contents          []GeminiContent `json:"contents"`
+		SystemInstruction *GeminiContent  `json:"systemInstruction"`
+		Tools             []struct {
+			FunctionDeclarations []struct {
+				Name string `json:"name"`
+			} `json:"functionDeclarations"`
+			GoogleSearch *struct{} `json:"googleSearch"`
+		} `json:"tools"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeGeminiError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	req := GeminiRequest{Model: model, Stream: stream, Contents: body.Contents}
+	if body.SystemInstruction != nil {
+		for _, p := range body.SystemInstruction.Parts {
+			req.System += p.Text
+		}
+	}
+	for _, tl := range body.Tools {
+		for _, fd := range tl.FunctionDeclarations {
+			req.Tools = append(req.Tools, fd.Name)
+		}
+		req.GoogleSearch = req.GoogleSearch || tl.GoogleSearch != nil
+	}
+
+	g.mu.Lock()
+	g.requests = append(g.requests, req)
+	var reply GeminiReply
+	scripted := len(g.replies) > 0
+	if scripted {
+		reply, g.replies = g.replies[0], g.replies[1:]
+	}
+	g.mu.Unlock()
+
+	switch {
+	case !scripted:
+		writeGeminiError(w, http.StatusInternalServerError, "fakeapi: no scripted Gemini reply left")
+		return
+	case reply.Status != 0:
+		writeGeminiError(w, reply.Status, reply.Message)
+		return
+	}
+
+	if !stream {
+		writeJSON(w, http.StatusOK, reply.chunk(reply.Text, true))
+		return
+	}
+
+	// Stream text word by word, then the tool calls and usage on the last chunk.
+	w.Header().Set("Content-Type", "text/event-stream")
+	words := strings.SplitAfter(reply.Text, " ")
+	for i, word := range words {
+		last := i == len(words)-1
+		data, _ := json.Marshal(reply.chunk(word, last))
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// chunk renders a (partial) generateContent response. Tool calls, finish
+// reason and usage are only sent with the final chunk.
+func (r GeminiReply) chunk(text string, final bool) map[string]any {
+	var parts []map[string]any
+	if text != "" {
+		parts = append(parts, map[string]any{"text": text})
+	}
+	candidate := map[string]any{"index": 0}
+	resp := map[string]any{"candidates": []any{candidate}}
+	if final {
+		for _, c := range r.ToolCalls {
+			parts = append(parts, map[string]any{"functionCall": functionCall{Name: c.Name, Args: c.Args}})
+		}
+		candidate["finishReason"] = "STOP"
+		if r.Grounding != nil {
+			candidate["groundingMetadata"] = r.Grounding.metadata()
+		}
+		usage := r.Usage
+		if usage == nil {
+			usage = &GeminiTokens{Prompt: 10, Completion: 5}
+		}
+		resp["usageMetadata"] = map[string]int{
+			"promptTokenCount":     usage.Prompt,
+			"candidatesTokenCount": usage.Completion,
+			"totalTokenCount":      usage.Prompt + usage.Completion,
+		}
+	}
+	if parts == nil {
+		parts = []map[string]any{{"text": ""}}
+	}
+	candidate["content"] = map[string]any{"role": "model", "parts": parts}
+	return resp
+}
+
+// metadata renders the groundingMetadata of a candidate.
+func (g *GeminiGrounding) metadata() map[string]any {
+	chunks := make([]any, len(g.Sources))
+	for i, s := range g.Sources {
+		chunks[i] = map[string]any{"web": map[string]string{"title": s.Title, "uri": s.URI, "domain": s.Domain}}
+	}
+	supports := make([]any, len(g.Supports))
+	for i, s := range g.Supports {
+		supports[i] = map[string]any{
+			"segment":               map[string]string{"text": s.Text},
+			"groundingChunkIndices": s.Sources,
+			"confidenceScores":      s.Confidence,
+		}
+	}
+	return map[string]any{"webSearchQueries": g.Queries, "groundingChunks": chunks, "groundingSupports": supports}
+}
+
+func (g *Gemini) handleEmbed(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Requests []struct {
+			Content GeminiContent `json:"content"`
+		} `json:"requests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeGeminiError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	embeddings := make([]map[string]any, len(body.Requests))
+	for i, req := range body.Requests {
+		var text strings.Builder
+		for _, p := range req.Content.Parts {
+			text.WriteString(p.Text)
+		}
+		embeddings[i] = map[string]any{"values": Embed(text.String())}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"embeddings": embeddings})
+}
+
+// handleCountTokens counts a token per word of text, so tests can predict
+// the count.
+func (g *Gemini) handleCountTokens(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Contents []GeminiContent `json:"contents"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeGeminiError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	g.mu.Lock()
+	g.counts++
+	g.mu.Unlock()
+	total := 0
+	for _, c := range body.Contents {
+		for _, p := range c.Parts {
+			total += len(strings.Fields(p.Text))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"totalTokens": total})
+}
+
+// Embed returns the deterministic vector the fake assigns to text: a bag of
+// hashed words, so texts sharing words are similar.
+func Embed(text string) []float64 {
+	v := make([]float64, EmbeddingDimension)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		v[h.Sum32()%EmbeddingDimension]++
+	}
+	v[0] += 0.01 // Keep the empty text from being a zero vector.
+	return v
+}
+
+func writeGeminiError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{"code": status, "message": message, "status": statusName(status)},
+	})
+}
+
+// statusName maps HTTP codes onto the gRPC status names Gemini reports.
+func statusName(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	default:
+		return "INTERNAL"
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
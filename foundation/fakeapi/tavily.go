@@ -0,0 +1,114 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Tavily fakes the Tavily /search endpoint.
+type Tavily struct {
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	replies  []TavilyReply
+	requests []TavilyRequest
+}
+
+// TavilyReply is one scripted search result.
+type TavilyReply struct {
+	Answer  string
+	Results []TavilyResult
+	Status  int    // Non-zero makes the search fail with this HTTP status.
+	Message string // Error message when Status is set.
+}
+
+// TavilyResult is one search hit.
+type TavilyResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Content string `json:"content"`
+}
+
+// TavilyRequest is a search received by the fake.
+type TavilyRequest struct {
+	APIKey         string   `json:"api_key"`
+	Query          string   `json:"query"`
+	SearchDepth    string   `json:"search_depth"`
+	MaxResults     int      `json:"max_results"`
+	IncludeDomains []string `json:"include_domains"`
+	Topic          string   `json:"topic"`
+	TimeRange      string   `json:"time_range"`
+}
+
+// NewTavily starts a fake Tavily API that is shut down with the test.
+func NewTavily(t testing.TB) *Tavily {
+	t.Helper()
+	tv := &Tavily{}
+	tv.server = httptest.NewServer(http.HandlerFunc(tv.handle))
+	tv.URL = tv.server.URL
+	t.Cleanup(tv.server.Close)
+	return tv
+}
+
+// Use points the Tavily tool at the fake for the rest of the test.
+func (tv *Tavily) Use(t testing.TB) {
+	t.Helper()
+	t.Setenv("TAVILY_BASE_URL", tv.URL)
+	t.Setenv("TAVILY_API_KEY", "fake-tavily-key")
+}
+
+// Reply queues replies for the following searches, in order.
+func (tv *Tavily) Reply(replies ...TavilyReply) {
+	tv.mu.Lock()
+	defer tv.mu.Unlock()
+	tv.replies = append(tv.replies, replies...)
+}
+
+// Requests returns the searches received so far.
+func (tv *Tavily) Requests() []TavilyRequest {
+	tv.mu.Lock()
+	defer tv.mu.Unlock()
+	return append([]TavilyRequest(nil), tv.requests...)
+}
+
+func (tv *Tavily) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/search" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown endpoint " + r.URL.Path})
+		return
+	}
+	var req TavilyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+
+	tv.mu.Lock()
+	tv.requests = append(tv.requests, req)
+	var reply TavilyReply
+	scripted := len(tv.replies) > 0
+	if scripted {
+		reply, tv.replies = tv.replies[0], tv.replies[1:]
+	}
+	tv.mu.Unlock()
+
+	switch {
+	case req.APIKey == "":
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing api_key"})
+	case !scripted:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "fakeapi: no scripted Tavily reply left"})
+	case reply.Status != 0:
+		writeJSON(w, reply.Status, map[string]string{"error": reply.Message})
+	default:
+		results := reply.Results
+		if results == nil {
+			results = []TavilyResult{}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"query": req.Query, "answer": reply.Answer, "results": results})
+	}
+}
@@ -0,0 +1,55 @@
+// Package forward provides the bases of tool and chat model wrappers. A
+// wrapper that embeds one reports the type of the component it wraps and
+// whether that component emits its own callbacks, so the graph neither loses
+// nor duplicates the callbacks of a wrapped component.
+package forward
+
+import (
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Tool forwards to the tool it embeds. Wrappers embed it and override
+// InvokableRun.
+type Tool struct {
+	tool.InvokableTool
+}
+
+// GetType returns the type of the embedded tool.
+func (t Tool) GetType() string {
+	return typeOf(t.InvokableTool)
+}
+
+// IsCallbacksEnabled reports whether the embedded tool emits its callbacks.
+func (t Tool) IsCallbacksEnabled() bool {
+	return callbacksEnabled(t.InvokableTool)
+}
+
+// ChatModel forwards to the chat model it embeds. Wrappers embed it and
+// override Generate, Stream and WithTools; a WithTools that is not
+// overridden returns the inner model unwrapped.
+type ChatModel struct {
+	model.ToolCallingChatModel
+}
+
+// GetType returns the type of the embedded model.
+func (m ChatModel) GetType() string {
+	return typeOf(m.ToolCallingChatModel)
+}
+
+// IsCallbacksEnabled reports whether the embedded model emits its callbacks.
+func (m ChatModel) IsCallbacksEnabled() bool {
+	return callbacksEnabled(m.ToolCallingChatModel)
+}
+
+func typeOf(v any) string {
+	if t, ok := v.(interface{ GetType() string }); ok {
+		return t.GetType()
+	}
+	return ""
+}
+
+func callbacksEnabled(v any) bool {
+	c, ok := v.(interface{ IsCallbacksEnabled() bool })
+	return ok && c.IsCallbacksEnabled()
+}
@@ -0,0 +1,34 @@
+package forward
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+type typedTool struct{}
+
+func (typedTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "typed"}, nil
+}
+
+func (typedTool) InvokableRun(context.Context, string, ...tool.Option) (string, error) {
+	return "", nil
+}
+
+func (typedTool) GetType() string          { return "Typed" }
+func (typedTool) IsCallbacksEnabled() bool { return true }
+
+type plainModel struct{ model.ToolCallingChatModel }
+
+func TestForward(t *testing.T) {
+	if w := (Tool{typedTool{}}); w.GetType() != "Typed" || !w.IsCallbacksEnabled() {
+		t.Errorf("tool wrapper reports %q, %v", w.GetType(), w.IsCallbacksEnabled())
+	}
+	if w := (ChatModel{plainModel{}}); w.GetType() != "" || w.IsCallbacksEnabled() {
+		t.Errorf("model wrapper reports %q, %v for a model without either", w.GetType(), w.IsCallbacksEnabled())
+	}
+}
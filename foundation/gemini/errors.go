@@ -0,0 +1,127 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/forward"
+	"google.golang.org/genai"
+)
+
+var (
+	// ErrRateLimited is returned when the Gemini API rejects a call because a
+	// quota or rate limit was exceeded. Retrying later may succeed.
+	ErrRateLimited = errors.New("gemini rate limit exceeded")
+
+	// ErrContextTooLong is returned when the prompt, including conversation
+	// history and tool results, exceeds the model's context window.
+	ErrContextTooLong = errors.New("prompt exceeds the model's context window")
+
+	// ErrInvalidAPIKey is returned when the Gemini API rejects the API key as
+	// invalid, expired or not allowed to call the API. Retrying will not help.
+	ErrInvalidAPIKey = errors.New("gemini rejected the API key")
+)
+
+// classify wraps Gemini API errors with the matching sentinel so callers can
+// use errors.Is instead of inspecting messages. Other errors are returned as-is.
+func classify(err error) error {
+	var apiErr genai.APIError
+	if err == nil || !errors.As(err, &apiErr) {
+		return err
+	}
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests || apiErr.Status == "RESOURCE_EXHAUSTED":
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	case apiErr.Code == http.StatusBadRequest && isContextLengthMessage(apiErr.Message):
+		return fmt.Errorf("%w: %w", ErrContextTooLong, err)
+	case apiErr.Code == http.StatusUnauthorized || apiErr.Code == http.StatusForbidden ||
+		strings.Contains(strings.ToLower(apiErr.Message), "api key not valid"):
+		return fmt.Errorf("%w: %w", ErrInvalidAPIKey, err)
+	}
+	return err
+}
+
+// isContextLengthMessage recognises the API's "input token count (N) exceeds
+// the maximum number of tokens allowed (M)" family of messages.
+func isContextLengthMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "token count") || strings.Contains(msg, "maximum number of tokens")
+}
+
+// classifiedModel classifies the errors of the wrapped chat model, including
+// those delivered mid-stream. Callbacks are still emitted by the inner model.
+type classifiedModel struct {
+	forward.ChatModel
+}
+
+func (m *classifiedModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	msg, err := m.ToolCallingChatModel.Generate(ctx, input, opts...)
+	return msg, classify(err)
+}
+
+func (m *classifiedModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	in, err := m.ToolCallingChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, classify(err)
+	}
+	out, w := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer in.Close()
+		defer w.Close()
+		for {
+			msg, err := in.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if w.Send(msg, classify(err)) || err != nil {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (m *classifiedModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	inner, err := m.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &classifiedModel{ChatModel: forward.ChatModel{ToolCallingChatModel: inner}}, nil
+}
+
+// GetType falls back to the provider when the inner model has no type.
+func (m *classifiedModel) GetType() string {
+	if t := m.ChatModel.GetType(); t != "" {
+		return t
+	}
+	return "Gemini"
+}
+
+// classifiedEmbedder classifies the errors of the wrapped embedder.
+type classifiedEmbedder struct {
+	inner embedding.Embedder
+}
+
+func (e *classifiedEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	vectors, err := e.inner.EmbedStrings(ctx, texts, opts...)
+	return vectors, classify(err)
+}
+
+func (e *classifiedEmbedder) GetType() string {
+	if t, ok := e.inner.(interface{ GetType() string }); ok {
+		return t.GetType()
+	}
+	return "Gemini"
+}
+
+func (e *classifiedEmbedder) IsCallbacksEnabled() bool {
+	c, ok := e.inner.(interface{ IsCallbacksEnabled() bool })
+	return ok && c.IsCallbacksEnabled()
+}
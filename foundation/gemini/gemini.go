@@ -3,12 +3,19 @@ package gemini
 import (
 	"context"
 	"fmt"
-	"os"
+	"net/http"
+	"sync"
 
 	"github.com/cloudwego/eino-ext/components/embedding/gemini"
 	geminiModel "github.com/cloudwego/eino-ext/components/model/gemini"
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/model"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/forward"
+	"github.com/olusolaa/goforai/foundation/ratelimit"
+	"github.com/olusolaa/goforai/foundation/secrets"
+	"github.com/olusolaa/goforai/foundation/usage"
+	"github.com/olusolaa/goforai/foundation/wirelog"
 	"google.golang.org/genai"
 )
 
@@ -17,35 +24,94 @@ const (
 	EmbeddingModelName = "text-embedding-004"
 )
 
-// NewClient creates a new Gemini API client.
+// ChatModel returns the chat model to use: CHAT_MODEL, or ChatModelName.
+func ChatModel() string {
+	if name := config.ChatModel(); name != "" {
+		return name
+	}
+	return ChatModelName
+}
+
+// Provider names Gemini in MODEL_RATE_LIMITS and the usage quotas. The chat
+// model and the embedder share its rate limit.
+const Provider = "gemini"
+
+// NewClient creates a new Gemini API client. Most callers want the shared
+// one returned by Client.
 func NewClient(ctx context.Context) (*genai.Client, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY environment variable is required")
+	apiKey, err := secrets.Get(secrets.GeminiAPIKey)
+	if err != nil {
+		return nil, err
 	}
+	return newClient(ctx, apiKey, config.GeminiBaseURL())
+}
 
+func newClient(ctx context.Context, apiKey, baseURL string) (*genai.Client, error) {
+	transport, err := wirelog.Transport(Provider)
+	if err != nil {
+		return nil, err
+	}
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
+		APIKey:      apiKey,
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: baseURL},
+		HTTPClient:  &http.Client{Transport: transport},
 	})
 	if err != nil {
-
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
+	return client, nil
+}
+
+// clientKey identifies the clients that can be shared.
+type clientKey struct {
+	apiKey, baseURL string
+}
+
+var clients = struct {
+	sync.Mutex
+	byKey map[clientKey]*genai.Client
+}{byKey: make(map[clientKey]*genai.Client)}
+
+// Client returns the process-wide Gemini client for the configured API key
+// and endpoint, creating it on first use. Chat models, embedders and health
+// checks share it, along with its pool of connections.
+func Client(ctx context.Context) (*genai.Client, error) {
+	apiKey, err := secrets.Get(secrets.GeminiAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	key := clientKey{apiKey: apiKey, baseURL: config.GeminiBaseURL()}
 
+	clients.Lock()
+	defer clients.Unlock()
+	if client, ok := clients.byKey[key]; ok {
+		return client, nil
+	}
+	client, err := newClient(ctx, key.apiKey, key.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	clients.byKey[key] = client
 	return client, nil
 }
 
 // NewChatModel creates a new Gemini chat model.
 func NewChatModel(ctx context.Context) (model.ToolCallingChatModel, error) {
-	client, err := NewClient(ctx)
+	return NewNamedChatModel(ctx, ChatModel())
+}
+
+// NewNamedChatModel creates a Gemini chat model for the named model rather
+// than the configured one.
+func NewNamedChatModel(ctx context.Context, name string) (model.ToolCallingChatModel, error) {
+	client, err := Client(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	config := &geminiModel.Config{
 		Client: client,
-		Model:  ChatModelName,
+		Model:  name,
 	}
 
 	chatModel, err := geminiModel.NewChatModel(ctx, config)
@@ -53,19 +119,23 @@ func NewChatModel(ctx context.Context) (model.ToolCallingChatModel, error) {
 		return nil, fmt.Errorf("failed to create chat model: %w", err)
 	}
 
-	return chatModel, nil
+	return usage.ChatModel(Provider, name, ratelimit.ChatModel(Provider, &classifiedModel{ChatModel: forward.ChatModel{ToolCallingChatModel: chatModel}})), nil
 }
 
-// NewEmbedder creates a new Gemini embedder for vector operations.
-func NewEmbedder(ctx context.Context) (embedding.Embedder, error) {
-	client, err := NewClient(ctx)
+// NewEmbedder creates a new Gemini embedder for vector operations. An empty
+// model name selects EmbeddingModelName.
+func NewEmbedder(ctx context.Context, modelName string) (embedding.Embedder, error) {
+	if modelName == "" {
+		modelName = EmbeddingModelName
+	}
+	client, err := Client(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	config := &gemini.EmbeddingConfig{
 		Client: client,
-		Model:  EmbeddingModelName,
+		Model:  modelName,
 	}
 
 	embedder, err := gemini.NewEmbedder(ctx, config)
@@ -73,5 +143,18 @@ func NewEmbedder(ctx context.Context) (embedding.Embedder, error) {
 		return nil, fmt.Errorf("failed to create embedder: %w", err)
 	}
 
-	return embedder, nil
+	return ratelimit.Embedder(Provider, &classifiedEmbedder{inner: embedder}), nil
+}
+
+// Ping checks that the Gemini API is reachable with the configured key and
+// that the chat model is available.
+func Ping(ctx context.Context) error {
+	client, err := Client(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := client.Models.Get(ctx, ChatModel(), nil); err != nil {
+		return fmt.Errorf("chat model %s is unreachable: %w", ChatModel(), classify(err))
+	}
+	return nil
 }
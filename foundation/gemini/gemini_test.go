@@ -0,0 +1,51 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestClientIsShared(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "fake-gemini-key")
+	t.Setenv("GEMINI_BASE_URL", "http://127.0.0.1:1")
+	ctx := context.Background()
+
+	first, err := Client(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := Client(ctx); again != first {
+		t.Error("Client created a second client for the same configuration")
+	}
+	if fresh, _ := NewClient(ctx); fresh == first {
+		t.Error("NewClient returned the shared client")
+	}
+
+	t.Setenv("GEMINI_BASE_URL", "http://127.0.0.1:2")
+	if other, _ := Client(ctx); other == first {
+		t.Error("Client shared a client across endpoints")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		err  genai.APIError
+		want error
+	}{
+		{genai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED"}, ErrRateLimited},
+		{genai.APIError{Code: 400, Message: "The input token count (2000000) exceeds the maximum number of tokens allowed (1048576)."}, ErrContextTooLong},
+		{genai.APIError{Code: 400, Status: "INVALID_ARGUMENT", Message: "API key not valid. Please pass a valid API key."}, ErrInvalidAPIKey},
+		{genai.APIError{Code: 403, Status: "PERMISSION_DENIED"}, ErrInvalidAPIKey},
+	} {
+		if got := classify(tc.err); !errors.Is(got, tc.want) {
+			t.Errorf("classify(%d %q) = %v, want %v", tc.err.Code, tc.err.Message, got, tc.want)
+		}
+	}
+	got := classify(genai.APIError{Code: 404, Status: "NOT_FOUND"})
+	if errors.Is(got, ErrRateLimited) || errors.Is(got, ErrContextTooLong) || errors.Is(got, ErrInvalidAPIKey) {
+		t.Errorf("classify(404) = %v, want it unclassified", got)
+	}
+}
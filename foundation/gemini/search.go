@@ -0,0 +1,100 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/olusolaa/goforai/foundation/ratelimit"
+	"google.golang.org/genai"
+)
+
+// Grounded is an answer written by Gemini from a Google Search it ran itself,
+// with the grounding metadata that ties the answer to the pages it used.
+type Grounded struct {
+	Text     string            // The answer.
+	Queries  []string          // What the model searched for.
+	Sources  []GroundedSource  // Pages the answer is based on.
+	Supports []GroundedSupport // Parts of Text and the sources that back them.
+}
+
+// GroundedSource is a page Gemini's search found.
+type GroundedSource struct {
+	Title  string
+	URL    string // A Google redirect to the page.
+	Domain string
+}
+
+// GroundedSupport is a part of an answer backed by sources.
+type GroundedSupport struct {
+	Text       string    // The part of the answer, as it appears in it.
+	Sources    []int     // Indices into Grounded.Sources.
+	Confidence []float64 // Confidence in each source, from 0 to 1, when reported.
+}
+
+// Search asks the chat model to answer query with Gemini's Google Search
+// tool. Search grounding cannot be combined with function calling in a
+// request, so it runs as a call of its own rather than as a tool of the
+// agent's model.
+func Search(ctx context.Context, query string) (*Grounded, error) {
+	client, err := Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := ratelimit.Wait(ctx, Provider); err != nil {
+		return nil, err
+	}
+	resp, err := client.Models.GenerateContent(ctx, ChatModel(), genai.Text(query), &genai.GenerateContentConfig{
+		Tools: []*genai.Tool{{GoogleSearch: &genai.GoogleSearch{}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("grounded search failed: %w", classify(err))
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return nil, errors.New("grounded search returned no answer")
+	}
+	return grounded(resp.Candidates[0]), nil
+}
+
+// grounded reads the answer and grounding metadata of a candidate.
+func grounded(c *genai.Candidate) *Grounded {
+	var text strings.Builder
+	for _, p := range c.Content.Parts {
+		text.WriteString(p.Text)
+	}
+	g := &Grounded{Text: text.String()}
+	meta := c.GroundingMetadata
+	if meta == nil {
+		return g
+	}
+	g.Queries = meta.WebSearchQueries
+	for _, chunk := range meta.GroundingChunks {
+		var s GroundedSource
+		if chunk != nil && chunk.Web != nil {
+			s = GroundedSource{Title: chunk.Web.Title, URL: chunk.Web.URI, Domain: chunk.Web.Domain}
+		}
+		// Kept even when empty, so the indices of the supports stay valid.
+		g.Sources = append(g.Sources, s)
+	}
+	for _, support := range meta.GroundingSupports {
+		if support == nil || support.Segment == nil || support.Segment.Text == "" {
+			continue
+		}
+		s := GroundedSupport{Text: support.Segment.Text}
+		for i, idx := range support.GroundingChunkIndices {
+			if int(idx) < 0 || int(idx) >= len(g.Sources) {
+				continue
+			}
+			s.Sources = append(s.Sources, int(idx))
+			if i < len(support.ConfidenceScores) {
+				s.Confidence = append(s.Confidence, float64(support.ConfidenceScores[i]))
+			}
+		}
+		if len(s.Confidence) != len(s.Sources) {
+			s.Confidence = nil
+		}
+		g.Supports = append(g.Supports, s)
+	}
+	return g
+}
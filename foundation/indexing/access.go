@@ -0,0 +1,83 @@
+package indexing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+)
+
+// AccessFileName is the file in the docs directory that restricts who may
+// retrieve which documents when the agent is served to several clients.
+const AccessFileName = "access.json"
+
+// accessRules maps paths relative to the docs directory, of files or of
+// directories, to the client names and "group:<name>" entries allowed to
+// read them. The longest matching path applies; an empty list makes a
+// path public again.
+//
+//	{"teams/platform": ["group:platform"], "teams/platform/oncall.md": ["ada"]}
+type accessRules map[string][]string
+
+// loadAccessRules reads the rules at file. A missing file means every
+// document is public.
+func loadAccessRules(file string) (accessRules, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access rules: %w", err)
+	}
+	var rules accessRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid access rules in %s: %w", file, err)
+	}
+	cleaned := make(accessRules, len(rules))
+	for p, principals := range rules {
+		for _, principal := range principals {
+			if strings.TrimSpace(principal) == "" || strings.Contains(principal, ",") {
+				return nil, fmt.Errorf("invalid access rules in %s: %q is not a client name or group:<name>", file, principal)
+			}
+		}
+		cleaned[path.Clean(strings.Trim(p, "/"))] = principals
+	}
+	return cleaned, nil
+}
+
+// lookup returns the principals allowed to read source, or nil when it is
+// public.
+func (r accessRules) lookup(source string) []string {
+	for p := path.Clean(source); ; p = path.Dir(p) {
+		if principals, ok := r[p]; ok {
+			return principals
+		}
+		if p == "." || p == "/" {
+			return nil
+		}
+	}
+}
+
+// accessStamper records on each loaded file who may retrieve it, from the
+// source path the provenance stamper set. The splitter copies it to every
+// chunk.
+type accessStamper struct {
+	rules accessRules
+}
+
+func (a accessStamper) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	for _, doc := range src {
+		source, _ := doc.MetaData[chromemdb.MetaSource].(string)
+		if principals := a.rules.lookup(source); len(principals) > 0 {
+			doc.MetaData[chromemdb.MetaAccess] = strings.Join(principals, ",")
+		}
+	}
+	return src, nil
+}
@@ -0,0 +1,37 @@
+package indexing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAccessRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), AccessFileName)
+	os.WriteFile(file, []byte(`{"/teams/platform/": ["group:platform"], "teams/platform/oncall.md": ["ada"], "teams/platform/public": []}`), 0o644)
+	rules, err := loadAccessRules(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for source, want := range map[string]string{
+		"talks.md":                      "",
+		"teams/platform/roadmap.md":     "group:platform",
+		"teams/platform/oncall.md":      "ada",
+		"teams/platform/public/faq.md":  "",
+		"teams/platform-old/roadmap.md": "",
+		"teams/platform/2025/retro.md":  "group:platform",
+	} {
+		if got := strings.Join(rules.lookup(source), ","); got != want {
+			t.Errorf("lookup(%q) = %q, want %q", source, got, want)
+		}
+	}
+
+	if rules, err := loadAccessRules(filepath.Join(t.TempDir(), AccessFileName)); err != nil || rules != nil {
+		t.Errorf("missing file: rules %v, err %v", rules, err)
+	}
+	os.WriteFile(file, []byte(`{"teams": ["ada,grace"]}`), 0o644)
+	if _, err := loadAccessRules(file); err == nil {
+		t.Error("a principal with a comma was accepted")
+	}
+}
@@ -0,0 +1,271 @@
+// Package indexing builds the GopherCon knowledge base: it loads markdown
+// documents, splits them into chunks, embeds them, and exports the database.
+package indexing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/chunking"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/embeddings"
+	"github.com/olusolaa/goforai/foundation/knowledgegraph"
+	"github.com/olusolaa/goforai/foundation/language"
+
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	chromem "github.com/philippgille/chromem-go"
+)
+
+// Defaults used by `goforai index` and by the tools that query the result.
+const (
+	DefaultDocsDir = "./foundation/indexing/gophercon-docs"
+	DefaultDBPath  = "data/chromem.gob"
+	CollectionName = "gophercon-knowledge"
+)
+
+// Options configures an indexing run. Zero values fall back to the defaults.
+type Options struct {
+	DocsDir    string
+	DBPath     string
+	AccessFile string    // Access rules; defaults to access.json in DocsDir.
+	GraphPath  string    // Where the knowledge graph is saved; empty builds none.
+	Out        io.Writer // Progress output; defaults to os.Stdout.
+}
+
+// indexingPipeline bundles the compiled graph with the database it writes to.
+type indexingPipeline struct {
+	runner  compose.Runnable[document.Source, []string]
+	db      *chromem.DB
+	indexer *chromemdb.ChromemDB
+}
+
+// Run indexes every markdown file under opts.DocsDir and exports the database.
+func Run(ctx context.Context, opts Options) error {
+	if opts.DocsDir == "" {
+		opts.DocsDir = DefaultDocsDir
+	}
+	if opts.DBPath == "" {
+		opts.DBPath = DefaultDBPath
+	}
+	if opts.AccessFile == "" {
+		opts.AccessFile = filepath.Join(opts.DocsDir, AccessFileName)
+	}
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	fmt.Fprintln(out, "🚀 GopherCon Knowledge Indexing with Eino")
+	fmt.Fprintln(out, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Fprintln(out, "Using Eino's document processing pipeline:")
+	if opts.GraphPath != "" {
+		fmt.Fprintln(out, "  FileLoader → Provenance → Access → GraphExtractor → LanguageTagger → Splitter → ChromemIndexer")
+	} else {
+		fmt.Fprintln(out, "  FileLoader → Provenance → Access → LanguageTagger → Splitter → ChromemIndexer")
+	}
+	fmt.Fprintln(out, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	fmt.Fprintln(out, "\n🔧 Building indexing graph...")
+	stamp := provenanceStamper{docsDir: opts.DocsDir, indexedAt: time.Now().UTC()}
+	rules, err := loadAccessRules(opts.AccessFile)
+	if err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		fmt.Fprintf(out, "   Access rules: %d paths from %s\n", len(rules), opts.AccessFile)
+	}
+	var graph *knowledgegraph.Graph
+	if opts.GraphPath != "" {
+		graph = knowledgegraph.New()
+	}
+	pipeline, err := buildIndexingGraph(ctx, out, stamp, accessStamper{rules: rules}, graph)
+	if err != nil {
+		return fmt.Errorf("failed to build indexing graph: %w", err)
+	}
+
+	docsDir := opts.DocsDir
+	fmt.Fprintf(out, "\n📖 Processing markdown files from: %s\n\n", docsDir)
+
+	fileCount := 0
+	chunkCount := 0
+
+	err = filepath.WalkDir(docsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk dir failed: %w", err)
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		if !strings.HasSuffix(path, ".md") {
+			return nil
+		}
+
+		fileCount++
+		fmt.Fprintf(out, "  [processing] %s\n", filepath.Base(path))
+
+		ids, err := pipeline.runner.Invoke(ctx, document.Source{URI: path})
+		if err != nil {
+			return fmt.Errorf("failed to index %s: %w", path, err)
+		}
+
+		chunkCount += len(ids)
+		fmt.Fprintf(out, "  [✓ done] indexed %d chunks from %s\n\n", len(ids), filepath.Base(path))
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "💾 Persisting database to disk...")
+	dbPath := opts.DBPath
+	if err := chromemdb.ExportDB(pipeline.db, dbPath, pipeline.indexer.Manifest()); err != nil {
+		return fmt.Errorf("failed to export database: %w", err)
+	}
+	// The snapshot lets the next build ship only what changed since this one
+	// (goforai index delta).
+	snapshot, err := chromemdb.TakeSnapshot(dbPath)
+	if err != nil {
+		return err
+	}
+	if err := chromemdb.SaveSnapshot(chromemdb.SnapshotDir(dbPath), snapshot); err != nil {
+		return err
+	}
+	if graph != nil {
+		if err := graph.Save(opts.GraphPath); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(out, "\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Fprintf(out, "✅ Indexing complete!\n")
+	fmt.Fprintf(out, "   Files: %d markdown files → %d chunks\n", fileCount, chunkCount)
+	fmt.Fprintf(out, "   💾 Saved to: %s (snapshot %s)\n", dbPath, snapshot.ID)
+	if graph != nil {
+		fmt.Fprintf(out, "   🕸️  Knowledge graph: %d relations saved to %s\n", graph.Len(), opts.GraphPath)
+	}
+	fmt.Fprintln(out, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Fprintln(out, "\n🎯 Next step: Run the agent")
+	fmt.Fprintln(out, "   goforai chat")
+
+	return nil
+}
+
+func buildIndexingGraph(ctx context.Context, out io.Writer, stamp provenanceStamper, access accessStamper, graph *knowledgegraph.Graph) (*indexingPipeline, error) {
+	embeddingConfig, err := config.LoadEmbeddings()
+	if err != nil {
+		return nil, err
+	}
+	embedder, embeddingModel, err := embeddings.New(ctx, embeddingConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+	fmt.Fprintf(out, "   Embeddings: %s\n", embeddingModel)
+
+	chunkCfg, err := config.LoadChunking()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chunking config: %w", err)
+	}
+	fmt.Fprintf(out, "   Chunking: size=%d overlap=%d strategy=%s\n", chunkCfg.Size, chunkCfg.Overlap, chunkCfg.Strategy)
+
+	db := chromem.NewDB()
+
+	chromemIndexer, err := chromemdb.New(ctx, CollectionName, embedder,
+		chromemdb.WithDB(db),
+		chromemdb.WithEmbeddingModel(embeddingModel),
+		chromemdb.WithCollectionMetadata(chunkCfg.Metadata()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chromem indexer: %w", err)
+	}
+
+	g := compose.NewGraph[document.Source, []string]()
+
+	fileLoader, err := file.NewFileLoader(ctx, &file.FileLoaderConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file loader: %w", err)
+	}
+	_ = g.AddLoaderNode("FileLoader", fileLoader)
+
+	_ = g.AddDocumentTransformerNode("Provenance", stamp)
+
+	_ = g.AddDocumentTransformerNode("Access", access)
+
+	// Relations are read from whole files, with the source and access
+	// rules the nodes before stamped on them.
+	afterAccess := "Access"
+	if graph != nil {
+		_ = g.AddDocumentTransformerNode("GraphExtractor", knowledgegraph.Extractor{Graph: graph})
+		afterAccess = "GraphExtractor"
+	}
+
+	// Languages are detected on whole files, which are more reliable to go
+	// on than chunks; the splitter copies the tag to every chunk.
+	_ = g.AddDocumentTransformerNode("LanguageTagger", language.Tagger{})
+
+	splitter, err := chunking.New(chunkCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create splitter: %w", err)
+	}
+	_ = g.AddDocumentTransformerNode("Splitter", splitter)
+
+	_ = g.AddIndexerNode("ChromemIndexer", chromemIndexer)
+
+	_ = g.AddEdge(compose.START, "FileLoader")
+	_ = g.AddEdge("FileLoader", "Provenance")
+	_ = g.AddEdge("Provenance", "Access")
+	if graph != nil {
+		_ = g.AddEdge("Access", "GraphExtractor")
+	}
+	_ = g.AddEdge(afterAccess, "LanguageTagger")
+	_ = g.AddEdge("LanguageTagger", "Splitter")
+	_ = g.AddEdge("Splitter", "ChromemIndexer")
+	_ = g.AddEdge("ChromemIndexer", compose.END)
+
+	r, err := g.Compile(ctx, compose.WithGraphName("KnowledgeIndexing"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile graph: %w", err)
+	}
+
+	return &indexingPipeline{runner: r, db: db, indexer: chromemIndexer}, nil
+}
+
+// provenanceStamper records on each loaded file where it came from and when
+// it was indexed, so answers can say how current they are. The splitter
+// copies both to every chunk, and derives the chunks' IDs from the file's.
+type provenanceStamper struct {
+	docsDir   string
+	indexedAt time.Time
+}
+
+func (p provenanceStamper) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	for _, doc := range src {
+		if doc.MetaData == nil {
+			doc.MetaData = make(map[string]any)
+		}
+		source, _ := doc.MetaData[file.MetaKeySource].(string)
+		if rel, err := filepath.Rel(p.docsDir, source); err == nil && !strings.HasPrefix(rel, "..") {
+			source = filepath.ToSlash(rel)
+		}
+		doc.MetaData[chromemdb.MetaSource] = source
+		doc.MetaData[chromemdb.MetaIndexedAt] = p.indexedAt.Format(time.RFC3339)
+		// Named after the file, its chunks keep their IDs from one build to
+		// the next, so deltas between builds only hold what changed.
+		if doc.ID == "" {
+			doc.ID = source
+		}
+	}
+	return src, nil
+}
@@ -0,0 +1,139 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/chunking"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/language"
+)
+
+// ErrInvalidDocument is returned by Ingest for documents it cannot store.
+var ErrInvalidDocument = errors.New("invalid document")
+
+// ErrUnknownCollection is returned by Ingest for a collection the database
+// does not hold. Ingest writes into existing collections only, so that
+// external writers cannot add collections to the served database.
+var ErrUnknownCollection = errors.New("unknown collection")
+
+// Ingester writes documents that external systems send, such as a CMS
+// publishing a page, into a knowledge base while it is being searched, and
+// exports the database after every write so the changes survive restarts.
+// It is safe for concurrent use.
+type Ingester struct {
+	kb       *chromemdb.ChromemDB
+	path     string
+	splitter *chunking.Splitter
+	now      func() time.Time
+
+	mu          sync.Mutex
+	collections map[string]*chromemdb.ChromemDB
+}
+
+// NewIngester returns an Ingester writing into kb's collections and
+// exporting kb's database to path. Documents to split are chunked as
+// configured for `goforai index`.
+func NewIngester(kb *chromemdb.ChromemDB, path string) (*Ingester, error) {
+	cfg, err := config.LoadChunking()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chunking config: %w", err)
+	}
+	splitter, err := chunking.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create splitter: %w", err)
+	}
+	return &Ingester{kb: kb, path: path, splitter: splitter, now: time.Now, collections: make(map[string]*chromemdb.ChromemDB)}, nil
+}
+
+// Ingest deletes the documents named by deleteIDs from collection, or the
+// knowledge base's own collection when it is empty, then stores docs,
+// replacing any stored under the same ID. With split, each document is
+// chunked first; its chunks are replaced and deleted along with it. Every
+// document needs an ID, and is stamped with the time it was indexed and its
+// language. Ingest returns the IDs of what it stored and how many of
+// deleteIDs named a stored document.
+func (in *Ingester) Ingest(ctx context.Context, collection string, docs []*schema.Document, split bool, deleteIDs []string) (stored []string, deleted int, err error) {
+	for i, doc := range docs {
+		switch {
+		case strings.TrimSpace(doc.ID) == "":
+			return nil, 0, fmt.Errorf("%w: document %d has no id", ErrInvalidDocument, i+1)
+		case strings.TrimSpace(doc.Content) == "":
+			return nil, 0, fmt.Errorf("%w: document %s has no content", ErrInvalidDocument, doc.ID)
+		}
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	col, err := in.collection(ctx, collection)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if deleted, err = col.Delete(ctx, deleteIDs...); err != nil {
+		return nil, 0, err
+	}
+	replaced := make([]string, len(docs))
+	for i, doc := range docs {
+		replaced[i] = doc.ID
+	}
+	if _, err := col.Delete(ctx, replaced...); err != nil {
+		return nil, 0, err
+	}
+
+	indexedAt := in.now().UTC().Format(time.RFC3339)
+	for _, doc := range docs {
+		if doc.MetaData == nil {
+			doc.MetaData = make(map[string]any)
+		}
+		if _, ok := doc.MetaData[chromemdb.MetaSource]; !ok {
+			doc.MetaData[chromemdb.MetaSource] = doc.ID
+		}
+		doc.MetaData[chromemdb.MetaIndexedAt] = indexedAt
+		if split {
+			doc.MetaData[chromemdb.MetaDocumentID] = doc.ID
+		}
+	}
+	if docs, err = (language.Tagger{}).Transform(ctx, docs); err != nil {
+		return nil, 0, err
+	}
+	if split {
+		if docs, err = in.splitter.Transform(ctx, docs); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if stored, err = col.Store(ctx, docs); err != nil {
+		return nil, 0, err
+	}
+	if err := in.kb.Export(in.path); err != nil {
+		return nil, 0, fmt.Errorf("documents were stored but not saved: %w", err)
+	}
+	return stored, deleted, nil
+}
+
+// collection returns the named collection, the knowledge base's own when
+// name is empty.
+func (in *Ingester) collection(ctx context.Context, name string) (*chromemdb.ChromemDB, error) {
+	if name == "" {
+		return in.kb, nil
+	}
+	if col, ok := in.collections[name]; ok {
+		return col, nil
+	}
+	if !in.kb.HasCollection(name) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownCollection, name)
+	}
+	col, err := in.kb.Collection(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open collection %q: %w", name, err)
+	}
+	in.collections[name] = col
+	return col, nil
+}
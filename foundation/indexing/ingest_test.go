@@ -0,0 +1,107 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+	chromem "github.com/philippgille/chromem-go"
+)
+
+type wordEmbedder struct{}
+
+func (wordEmbedder) EmbedStrings(_ context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = fakeapi.Embed(text)
+	}
+	return vectors, nil
+}
+
+func TestIngest(t *testing.T) {
+	t.Setenv("CHUNK_SIZE", "60")
+	t.Setenv("CHUNK_OVERLAP", "0")
+	ctx := context.Background()
+	kb, err := chromemdb.New(ctx, CollectionName, wordEmbedder{}, chromemdb.WithDB(chromem.NewDB()), chromemdb.WithTopK(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "chromem.gob")
+	in, err := NewIngester(kb, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := func() []string {
+		t.Helper()
+		docs, err := kb.Retrieve(ctx, "keynote venue schedule")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, doc := range docs {
+			ids = append(ids, doc.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	page := strings.Repeat("The keynote starts at nine in the main hall. ", 3)
+	ids, _, err := in.Ingest(ctx, "", []*schema.Document{
+		{ID: "faq/venue", Content: "The venue is the conference centre.", MetaData: map[string]any{chromemdb.MetaAccess: "group:staff"}},
+		{ID: "schedule", Content: page},
+	}, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"faq/venue#0", "schedule#0", "schedule#1", "schedule#2"}; !slices.Equal(ids, want) || !slices.Equal(stored(), want) {
+		t.Fatalf("stored %q, searchable %q, want %q", ids, stored(), want)
+	}
+	// The fake embeddings rank poorly; find the document by ID instead.
+	docs, _ := kb.Retrieve(ctx, "venue")
+	i := slices.IndexFunc(docs, func(doc *schema.Document) bool { return doc.ID == "faq/venue#0" })
+	if i < 0 {
+		t.Fatalf("faq/venue#0 was not retrieved")
+	}
+	if meta := docs[i].MetaData; meta[chromemdb.MetaAccess] != "group:staff" || meta[chromemdb.MetaIndexedAt] == nil || meta[chromemdb.MetaSource] != "faq/venue" {
+		t.Errorf("unexpected metadata %v", meta)
+	}
+
+	// A shorter version replaces every chunk of the old one; deleting a
+	// document deletes its chunks.
+	if _, deleted, err := in.Ingest(ctx, "", []*schema.Document{{ID: "schedule", Content: "The keynote starts at ten."}}, true, []string{"faq/venue", "faq/unknown"}); err != nil || deleted != 1 {
+		t.Fatalf("deleted %d documents, want 1: %v", deleted, err)
+	}
+	if got := stored(); !slices.Equal(got, []string{"schedule#0"}) {
+		t.Errorf("after the update: %q", got)
+	}
+
+	// Other existing collections are kept in the same file; the export is
+	// reloadable. Writers cannot add collections.
+	if _, _, err := in.Ingest(ctx, "changelog", []*schema.Document{{ID: "v2", Content: "Release 2 adds streaming."}}, false, nil); !errors.Is(err, ErrUnknownCollection) {
+		t.Fatalf("unknown collection: err = %v", err)
+	}
+	if _, err := kb.Collection(ctx, "changelog"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := in.Ingest(ctx, "changelog", []*schema.Document{{ID: "v2", Content: "Release 2 adds streaming."}}, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	db, manifest, err := chromemdb.ImportDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest == nil || manifest.Collection != CollectionName || db.GetCollection("changelog", nil).Count() != 1 || db.GetCollection(CollectionName, nil).Count() != 1 {
+		t.Errorf("unexpected export %+v: %v", manifest, db.ListCollections())
+	}
+
+	if _, _, err := in.Ingest(ctx, "", []*schema.Document{{Content: "anonymous"}}, false, nil); !errors.Is(err, ErrInvalidDocument) {
+		t.Errorf("document without an id: err = %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package knowledgegraph extracts the entities and relations of the
+// knowledge base, such as which speaker gives which talk in which track, into
+// a small graph of subject-predicate-object relations. Vector search finds
+// passages like a question; the graph answers questions about relations
+// across documents, such as which speakers give more than one talk.
+//
+// Relations are read from the fields of Markdown sections: under a heading
+// "## Concurrency Patterns in Go", the line "**Speaker:** Sarah Johnson"
+// becomes the relation (Concurrency Patterns in Go, speaker, Sarah Johnson).
+package knowledgegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+)
+
+// ErrNotFound is returned by Load when the graph has not been built.
+var ErrNotFound = errors.New("knowledge graph not found")
+
+// Relation is one edge of the graph.
+type Relation struct {
+	Subject   string `json:"subject"`
+	Predicate string `json:"predicate"`
+	Object    string `json:"object"`
+	Source    string `json:"source,omitempty"` // Document it was read from.
+	Access    string `json:"access,omitempty"` // Who may read it, as chromemdb.MetaAccess; empty for everyone.
+}
+
+// Graph is a set of relations. It is safe for concurrent use.
+type Graph struct {
+	mu        sync.Mutex
+	relations []Relation
+	seen      map[Relation]bool
+}
+
+// New returns an empty graph.
+func New() *Graph {
+	return &Graph{seen: make(map[Relation]bool)}
+}
+
+// Add adds relations the graph does not hold yet.
+func (g *Graph) Add(relations ...Relation) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, r := range relations {
+		if !g.seen[r] {
+			g.seen[r] = true
+			g.relations = append(g.relations, r)
+		}
+	}
+}
+
+// Len returns the number of relations.
+func (g *Graph) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.relations)
+}
+
+// Predicates returns the distinct predicates, sorted.
+func (g *Graph) Predicates() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	set := make(map[string]bool)
+	for _, r := range g.relations {
+		set[r.Predicate] = true
+	}
+	predicates := make([]string, 0, len(set))
+	for p := range set {
+		predicates = append(predicates, p)
+	}
+	sort.Strings(predicates)
+	return predicates
+}
+
+// Match returns the relations whose subject, predicate and object contain
+// the given ones, ignoring case, in the order they were added. An empty
+// pattern matches anything. With an identity in ctx (see
+// chromemdb.WithIdentity), relations it may not read are left out.
+func (g *Graph) Match(ctx context.Context, subject, predicate, object string) []Relation {
+	identity, restricted := chromemdb.IdentityFrom(ctx)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var matches []Relation
+	for _, r := range g.relations {
+		if restricted && !identity.CanRead(r.Access) {
+			continue
+		}
+		if contains(r.Subject, subject) && contains(r.Predicate, predicate) && contains(r.Object, object) {
+			matches = append(matches, r)
+		}
+	}
+	return matches
+}
+
+func contains(s, pattern string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(strings.TrimSpace(pattern)))
+}
+
+var (
+	heading = regexp.MustCompile(`^#{2,6}\s+(.+?)\s*#*$`)
+	field   = regexp.MustCompile(`^\*\*([^*:]+):\*\*\s*(.+?)\s*$`)
+)
+
+// Extract reads the relations of a Markdown document: each "**Key:** value"
+// field relates the heading above it to the value.
+func Extract(content string) []Relation {
+	var relations []Relation
+	var subject string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if m := heading.FindStringSubmatch(line); m != nil {
+			subject = m[1]
+			continue
+		}
+		m := field.FindStringSubmatch(line)
+		if m == nil || subject == "" {
+			continue
+		}
+		relations = append(relations, Relation{Subject: subject, Predicate: strings.ToLower(strings.TrimSpace(m[1])), Object: m[2]})
+	}
+	return relations
+}
+
+// Extractor is a document transformer that adds the relations of each
+// document it sees to a graph, with the document's source and access, and
+// passes the documents on unchanged. Put it in an indexing pipeline before
+// the splitter, where documents are still whole files.
+type Extractor struct {
+	Graph *Graph
+}
+
+// Transform extracts the relations of src.
+func (e Extractor) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	for _, doc := range src {
+		source, _ := doc.MetaData[chromemdb.MetaSource].(string)
+		access, _ := doc.MetaData[chromemdb.MetaAccess].(string)
+		relations := Extract(doc.Content)
+		for i := range relations {
+			relations[i].Source, relations[i].Access = source, access
+		}
+		e.Graph.Add(relations...)
+	}
+	return src, nil
+}
+
+type graphFile struct {
+	Relations []Relation `json:"relations"`
+}
+
+// Load reads the graph saved at path. It fails with ErrNotFound when there
+// is none.
+func Load(path string) (*Graph, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w at %s", ErrNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read knowledge graph: %w", err)
+	}
+	var file graphFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to read knowledge graph %s: %w", path, err)
+	}
+	g := New()
+	g.Add(file.Relations...)
+	return g, nil
+}
+
+// Save writes the graph to path atomically.
+func (g *Graph) Save(path string) error {
+	g.mu.Lock()
+	data, err := json.MarshalIndent(graphFile{Relations: g.relations}, "", "  ")
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to save knowledge graph: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".graph-*")
+	if err != nil {
+		return fmt.Errorf("failed to save knowledge graph: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save knowledge graph: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save knowledge graph: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save knowledge graph: %w", err)
+	}
+	return nil
+}
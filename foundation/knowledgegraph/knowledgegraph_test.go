@@ -0,0 +1,71 @@
+package knowledgegraph
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+)
+
+const talks = `# Conference Talks
+
+## Concurrency Patterns
+
+**Speaker:** Sarah Johnson  
+**Track:** Performance
+
+Explore the latest concurrency features. **Note:** not a field.
+
+## Scheduler Internals
+
+**Speaker:** Sarah Johnson  
+**Track:** Performance
+`
+
+func TestExtractor(t *testing.T) {
+	g := New()
+	docs := []*schema.Document{
+		{Content: talks, MetaData: map[string]any{chromemdb.MetaSource: "talks.md"}},
+		{Content: "## Staff Party\n\n**Time:** 8 PM\n", MetaData: map[string]any{chromemdb.MetaSource: "staff.md", chromemdb.MetaAccess: "group:staff"}},
+	}
+	if _, err := (Extractor{Graph: g}).Transform(context.Background(), docs); err != nil {
+		t.Fatal(err)
+	}
+	want := Relation{Subject: "Concurrency Patterns", Predicate: "speaker", Object: "Sarah Johnson", Source: "talks.md"}
+	if g.Len() != 5 || !slices.Contains(g.Match(context.Background(), "", "", ""), want) {
+		t.Fatalf("graph holds %v", g.Match(context.Background(), "", "", ""))
+	}
+
+	if got := g.Match(context.Background(), "", "SPEAKER", "sarah"); len(got) != 2 {
+		t.Errorf("Sarah's talks = %v", got)
+	}
+	if got := g.Match(context.Background(), "party", "", ""); len(got) != 1 {
+		t.Errorf("without an identity every relation is visible: %v", got)
+	}
+	guest := chromemdb.WithIdentity(context.Background(), chromemdb.Identity{Name: "guest"})
+	if got := g.Match(guest, "party", "", ""); len(got) != 0 {
+		t.Errorf("a guest sees the staff party: %v", got)
+	}
+	if got := g.Predicates(); !slices.Equal(got, []string{"speaker", "time", "track"}) {
+		t.Errorf("predicates = %v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "data", "graph.json")
+	if _, err := Load(path); !errors.Is(err, ErrNotFound) {
+		t.Errorf("loading a missing graph: %v", err)
+	}
+	if err := g.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(loaded.Match(context.Background(), "", "", ""), g.Match(context.Background(), "", "", "")) {
+		t.Error("the loaded graph differs from the saved one")
+	}
+}
@@ -0,0 +1,197 @@
+// Package language detects the language of text and translates it with a
+// chat model, so that attendees can query the English knowledge base in
+// their own language.
+package language
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+)
+
+// English is the language the knowledge base is written in.
+const English = "en"
+
+// MetaLanguage is the metadata key holding a document's ISO 639-1 language
+// code.
+const MetaLanguage = "language"
+
+// names maps the codes Detect returns to the names used in prompts.
+var names = map[string]string{
+	"am": "Amharic",
+	"ar": "Arabic",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sw": "Swahili",
+	"th": "Thai",
+	"zh": "Chinese",
+}
+
+// Name returns the English name of a language code, or the code itself when
+// it is not one Detect knows.
+func Name(code string) string {
+	if name, ok := names[strings.ToLower(code)]; ok {
+		return name
+	}
+	return code
+}
+
+// scripts identifies languages written in their own script by that script.
+var scripts = []struct {
+	code  string
+	table *unicode.RangeTable
+}{
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"zh", unicode.Han},
+	{"ko", unicode.Hangul},
+	{"ar", unicode.Arabic},
+	{"ru", unicode.Cyrillic},
+	{"hi", unicode.Devanagari},
+	{"am", unicode.Ethiopic},
+	{"el", unicode.Greek},
+	{"he", unicode.Hebrew},
+	{"th", unicode.Thai},
+}
+
+// stopwords are frequent function words of the languages written in the
+// Latin script. Words shared by several languages count for each of them.
+var stopwords = map[string][]string{
+	"en": strings.Fields("the and is are was of to in what who which when where how does do with about for this that at on it be will can there their"),
+	"fr": strings.Fields("le la les des est et qui que quoi quel quelle quels où quand comment pour avec sur dans une un du de au aux pas ce cette sont il elle je vous nous"),
+	"es": strings.Fields("el la los las es y qué quién quiénes cuál cuándo dónde cómo para con sobre en una un del de al por son está hay yo usted"),
+	"pt": strings.Fields("o a os as é e que quem qual quando onde como para com sobre em uma um do da dos das no na não são está há eu você"),
+	"de": strings.Fields("der die das und ist sind wer was wann wo wie welche welcher für mit über ein eine den dem nicht von zu im ich sie es gibt"),
+	"it": strings.Fields("il lo gli le è e chi che cosa quale quando dove come per con su una un del della di non sono c'è io lei"),
+	"nl": strings.Fields("de het een en is zijn wie wat wanneer waar hoe welke voor met over van niet op ik u er"),
+	"sw": strings.Fields("na ya wa ni kwa za katika nani nini lini wapi vipi gani kuhusu hii hiyo je cha mimi wewe"),
+}
+
+// stopwordIndex maps each stopword to the languages it belongs to.
+var stopwordIndex = func() map[string][]string {
+	index := make(map[string][]string)
+	for code, words := range stopwords {
+		for _, w := range words {
+			index[w] = append(index[w], code)
+		}
+	}
+	return index
+}()
+
+// minStopwords is how many stopwords text must contain before Detect names a
+// Latin-script language; fewer is too little evidence.
+const minStopwords = 2
+
+// Detect returns the ISO 639-1 code of the language text is written in, or
+// "" when it cannot tell. Languages with their own script are recognized by
+// it; for the others Detect counts frequent function words, which is enough
+// for a question or a page of prose but not for a few words or for code.
+func Detect(text string) string {
+	var letters, latin int
+	counts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[s.code]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	// Japanese mixes kana with the Han characters Chinese is written in.
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+	// Text mostly in another script is in that script's language, even when
+	// it quotes Go identifiers.
+	if best, n := top(counts); n > 0 && latin*2 <= letters {
+		return best
+	}
+
+	clear(counts)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, w := range words {
+		for _, code := range stopwordIndex[w] {
+			counts[code]++
+		}
+	}
+	best, n := top(counts)
+	if n < minStopwords {
+		return ""
+	}
+	// A tie means the words were shared between languages; don't guess.
+	for code, c := range counts {
+		if c == n && code != best {
+			return ""
+		}
+	}
+	return best
+}
+
+// top returns the key with the highest count, preferring the smaller key on
+// ties so the result does not depend on map order.
+func top(counts map[string]int) (string, int) {
+	var best string
+	var n int
+	for code, c := range counts {
+		if c > n || (c == n && code < best) {
+			best, n = code, c
+		}
+	}
+	return best, n
+}
+
+// Tagger is a document transformer that records the detected language of
+// each document under MetaLanguage. Documents whose language cannot be
+// detected are left as they are.
+type Tagger struct{}
+
+// Transform tags docs in place and returns them.
+func (Tagger) Transform(ctx context.Context, src []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	for _, doc := range src {
+		code := Detect(doc.Content)
+		if code == "" {
+			continue
+		}
+		if doc.MetaData == nil {
+			doc.MetaData = make(map[string]any)
+		}
+		doc.MetaData[MetaLanguage] = code
+	}
+	return src, nil
+}
+
+// Of returns the language recorded on doc, or fallback when there is none.
+func Of(doc *schema.Document, fallback string) string {
+	if code, ok := doc.MetaData[MetaLanguage].(string); ok && code != "" {
+		return code
+	}
+	return fallback
+}
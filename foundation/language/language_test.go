@@ -0,0 +1,87 @@
+package language
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Who is giving the talk about Eino graphs?", "en"},
+		{"Qui présente la conférence sur les graphes Eino ?", "fr"},
+		{"¿Quién da la charla sobre los grafos de Eino?", "es"},
+		{"Quem vai falar sobre os grafos do Eino?", "pt"},
+		{"Wer hält den Vortrag über Eino und wann ist er?", "de"},
+		{"Nani anazungumza kuhusu Eino na ni lini?", "sw"},
+		{"Кто рассказывает про `chromem.DB`?", "ru"},
+		{"Eino のグラフについて話すのは誰ですか", "ja"},
+		{"谁在讲 Eino 图?", "zh"},
+		{"من يتحدث عن Eino؟", "ar"},
+		// Too little to go on.
+		{"Eino", ""},
+		{"func main() { fmt.Println(42) }", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.text); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTagger(t *testing.T) {
+	docs := []*schema.Document{
+		{Content: "The keynote is about building agents with Eino and Go."},
+		{Content: "```go\nx := 1\n```"},
+	}
+	out, err := Tagger{}.Transform(context.Background(), docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Of(out[0], ""); got != "en" {
+		t.Errorf("first document tagged %q", got)
+	}
+	if got := Of(out[1], "unknown"); got != "unknown" {
+		t.Errorf("code was tagged %q", got)
+	}
+}
+
+// echoModel replies with the system prompt and the user's text, so tests can
+// see what a translation asked for.
+type echoModel struct{ reply string }
+
+func (m echoModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if m.reply != "" {
+		return schema.AssistantMessage(m.reply, nil), nil
+	}
+	var parts []string
+	for _, msg := range input {
+		parts = append(parts, msg.Content)
+	}
+	return schema.AssistantMessage(strings.Join(parts, "|"), nil), nil
+}
+
+func (m echoModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), err
+}
+
+func TestTranslate(t *testing.T) {
+	got, err := NewTranslator(echoModel{}).Translate(context.Background(), "Who speaks?", "fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "into French.") || !strings.HasSuffix(got, "|Who speaks?") {
+		t.Errorf("Translate() sent %q", got)
+	}
+
+	if _, err := NewTranslator(echoModel{reply: " \n"}).Translate(context.Background(), "Who speaks?", "fr"); err == nil {
+		t.Error("an empty translation was accepted")
+	}
+}
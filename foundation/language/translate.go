@@ -0,0 +1,49 @@
+package language
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/prompts"
+)
+
+// Translator translates text with a chat model.
+type Translator struct {
+	model model.BaseChatModel
+}
+
+// NewTranslator returns a Translator that uses m.
+func NewTranslator(m model.BaseChatModel) *Translator {
+	return &Translator{model: m}
+}
+
+// Translate translates text into target, a language code such as "fr" or a
+// language name.
+func (t *Translator) Translate(ctx context.Context, text, target string) (string, error) {
+	// The language.translate prompt asks for a bare translation that leaves
+	// code alone.
+	prompt, err := prompts.Get("language.translate")
+	if err != nil {
+		return "", err
+	}
+	system, err := prompt.Render(map[string]any{"language": Name(target)})
+	if err != nil {
+		return "", err
+	}
+	msg, err := t.model.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(system),
+		schema.UserMessage(text),
+	})
+	if err != nil {
+		return "", fmt.Errorf("translation failed: %w", err)
+	}
+	translation := strings.TrimSpace(msg.Content)
+	if translation == "" {
+		return "", errors.New("translation failed: the model returned nothing")
+	}
+	return translation, nil
+}
@@ -0,0 +1,105 @@
+// Package logger builds the slog.Logger used across the project and carries
+// per-turn correlation data (request IDs, tool call IDs) through contexts.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/compose"
+	"github.com/google/uuid"
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// New creates a logger from the logging configuration. The returned close
+// function releases the output file, if one was opened.
+func New(cfg config.Logging) (*slog.Logger, func() error, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, nil, fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+	}
+
+	var out io.Writer
+	closeFn := func() error { return nil }
+	switch strings.ToLower(cfg.Output) {
+	case "", "stderr":
+		out = os.Stderr
+	case "stdout":
+		out = os.Stdout
+	default:
+		f, err := os.OpenFile(cfg.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log output %s: %w", cfg.Output, err)
+		}
+		out, closeFn = f, f.Close
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "", "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		closeFn()
+		return nil, nil, fmt.Errorf("invalid log format %q (use text or json)", cfg.Format)
+	}
+
+	return slog.New(handler), closeFn, nil
+}
+
+type ctxKey struct{}
+
+// WithContext returns a context carrying the logger.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or slog.Default().
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// WithRequestID tags every log line emitted during a turn with a fresh
+// request ID and returns it for the caller to surface elsewhere.
+func WithRequestID(ctx context.Context) (context.Context, string) {
+	id := uuid.NewString()
+	return WithContext(ctx, FromContext(ctx).With("request_id", id)), id
+}
+
+// NewCallbackHandler logs the start, end, and failure of every tool call,
+// correlated by the tool call ID the model assigned to it.
+func NewCallbackHandler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if info.Component != components.ComponentOfTool {
+				return ctx
+			}
+			l := FromContext(ctx).With("tool", info.Name, "tool_call_id", compose.GetToolCallID(ctx))
+			l.Debug("tool call started")
+			return WithContext(ctx, l)
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if info.Component == components.ComponentOfTool {
+				FromContext(ctx).Debug("tool call finished")
+			}
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			if info.Component == components.ComponentOfTool {
+				FromContext(ctx).Warn("tool call failed", "error", err)
+			}
+			return ctx
+		}).
+		Build()
+}
@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// records decodes the JSON log lines in buf.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r map[string]any
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+		out = append(out, r)
+	}
+	buf.Reset()
+	return out
+}
+
+func TestWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	base := WithContext(context.Background(), slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	FromContext(base).Info("before the turn")
+	if r := records(t, &buf)[0]; r["request_id"] != nil {
+		t.Errorf("a context without a request ID logged request_id %v", r["request_id"])
+	}
+
+	ctx, id := WithRequestID(base)
+	FromContext(ctx).Info("turn started")
+	if r := records(t, &buf)[0]; r["request_id"] != id {
+		t.Errorf("request_id = %v, want %s", r["request_id"], id)
+	}
+
+	// Tool calls in the turn log the request ID beside their own.
+	handler := NewCallbackHandler()
+	info := &callbacks.RunInfo{Name: "read_file", Component: components.ComponentOfTool}
+	toolCtx := handler.OnStart(ctx, info, &tool.CallbackInput{})
+	handler.OnEnd(toolCtx, info, &tool.CallbackOutput{})
+	logged := records(t, &buf)
+	if len(logged) != 2 {
+		t.Fatalf("tool call logged %d lines, want its start and end", len(logged))
+	}
+	for _, r := range logged {
+		if r["request_id"] != id || r["tool"] != "read_file" {
+			t.Errorf("tool call logged %v, want request_id %s", r, id)
+		}
+	}
+
+	if _, other := WithRequestID(base); other == id {
+		t.Error("two turns got the same request ID")
+	}
+}
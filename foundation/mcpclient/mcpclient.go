@@ -0,0 +1,252 @@
+// Package mcpclient connects to external Model Context Protocol servers and
+// wraps the tools they advertise as Eino tools, so the agent can use any MCP
+// server without Go code for each one.
+package mcpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// DefaultConfigPath is read when MCP_CONFIG is not set.
+const DefaultConfigPath = "mcp.json"
+
+// ServerConfig describes how to reach one MCP server. Either Command (a
+// subprocess speaking stdio) or URL (an SSE endpoint) must be set.
+type ServerConfig struct {
+	Command  string            `json:"command,omitempty"`
+	Args     []string          `json:"args,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	ReadOnly bool              `json:"read_only,omitempty"` // None of its tools write; safe in read-only mode.
+}
+
+// Config uses the same layout as Claude Desktop's configuration file.
+type Config struct {
+	MCPServers map[string]ServerConfig `json:"mcpServers"`
+}
+
+// LoadConfig reads an MCP configuration file. A missing file yields an empty
+// configuration, since MCP servers are optional.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MCP config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse MCP config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// LoadTools connects to every server in the file named by MCP_CONFIG (or
+// mcp.json) and returns their tools. Servers that fail to start are logged
+// and skipped so one broken server does not take the agent down. In
+// read-only mode (see tools.WithReadOnly) only the servers marked read_only
+// are started, since MCP tools can write as freely as built-in ones. The
+// returned function ends every session and stops the server subprocesses;
+// call it once the tools are no longer used.
+func LoadTools(ctx context.Context) ([]tool.BaseTool, func() error, error) {
+	path := os.Getenv("MCP_CONFIG")
+	if path == "" {
+		path = DefaultConfigPath
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log := logger.FromContext(ctx)
+	names := make([]string, 0, len(cfg.MCPServers))
+	for name, server := range cfg.MCPServers {
+		if tools.ReadOnly(ctx) && !server.ReadOnly {
+			log.Info("skipping MCP server in read-only mode", "server", name)
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		loaded  []tool.BaseTool
+		closers []io.Closer
+	)
+	for _, name := range names {
+		serverTools, closer, err := Connect(ctx, name, cfg.MCPServers[name])
+		if err != nil {
+			log.Warn("skipping MCP server", "server", name, "error", err)
+			continue
+		}
+		log.Info("connected MCP server", "server", name, "tools", len(serverTools))
+		loaded = append(loaded, serverTools...)
+		closers = append(closers, closer)
+	}
+
+	closeAll := func() error {
+		var errs []error
+		for _, c := range closers {
+			errs = append(errs, c.Close())
+		}
+		return errors.Join(errs...)
+	}
+	return loaded, closeAll, nil
+}
+
+// Connect starts a session with one server and wraps the tools it lists. The
+// returned Closer ends the session and, for command servers, stops the
+// subprocess.
+func Connect(ctx context.Context, name string, cfg ServerConfig) ([]tool.BaseTool, io.Closer, error) {
+	var (
+		cli *client.Client
+		err error
+	)
+	switch {
+	case cfg.Command != "":
+		env := os.Environ()
+		for k, v := range cfg.Env {
+			env = append(env, k+"="+v)
+		}
+		cli, err = client.NewStdioMCPClient(cfg.Command, env, cfg.Args...)
+	case cfg.URL != "":
+		cli, err = client.NewSSEMCPClient(cfg.URL)
+		if err == nil {
+			err = cli.Start(ctx)
+		}
+	default:
+		return nil, nil, errors.New("server needs either a command or a url")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start client: %w", err)
+	}
+
+	initReq := mcp.InitializeRequest{}
+	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initReq.Params.ClientInfo = mcp.Implementation{Name: "goforai", Version: "0.1.0"}
+	if _, err := cli.Initialize(ctx, initReq); err != nil {
+		cli.Close()
+		return nil, nil, fmt.Errorf("failed to initialize session: %w", err)
+	}
+
+	listed, err := cli.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		cli.Close()
+		return nil, nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	tools := make([]tool.BaseTool, 0, len(listed.Tools))
+	for _, t := range listed.Tools {
+		wrapped, err := newRemoteTool(cli, name, cfg.ReadOnly, t)
+		if err != nil {
+			cli.Close()
+			return nil, nil, err
+		}
+		tools = append(tools, wrapped)
+	}
+	return tools, cli, nil
+}
+
+// remoteTool adapts a tool living on an MCP server to tool.InvokableTool.
+type remoteTool struct {
+	cli      *client.Client
+	server   string
+	readOnly bool // The server is marked read_only.
+	info     *schema.ToolInfo
+	name     string // Name as known by the server.
+}
+
+func newRemoteTool(cli *client.Client, server string, readOnly bool, t mcp.Tool) (*remoteTool, error) {
+	raw, err := json.Marshal(t.InputSchema)
+	if t.RawInputSchema != nil {
+		raw, err = t.RawInputSchema, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema of %s/%s: %w", server, t.Name, err)
+	}
+	var js jsonschema.Schema
+	if err := json.Unmarshal(raw, &js); err != nil {
+		return nil, fmt.Errorf("failed to decode schema of %s/%s: %w", server, t.Name, err)
+	}
+
+	return &remoteTool{
+		cli:      cli,
+		server:   server,
+		readOnly: readOnly,
+		name:     t.Name,
+		info: &schema.ToolInfo{
+			// Prefix with the server name so tools from different servers can't collide.
+			Name:        server + "__" + t.Name,
+			Desc:        t.Description,
+			ParamsOneOf: schema.NewParamsOneOfByJSONSchema(&js),
+		},
+	}, nil
+}
+
+func (r *remoteTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return r.info, nil
+}
+
+// ReadOnly reports whether the tool's server was marked read_only.
+func (r *remoteTool) ReadOnly() bool {
+	return r.readOnly
+}
+
+// InvokableRun forwards the call. Failures reported by the server are returned
+// in an "error" field, like the built-in tools do, so the model can react.
+func (r *remoteTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var args map[string]any
+	if strings.TrimSpace(argumentsInJSON) != "" {
+		if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+			return errorPayload(fmt.Sprintf("invalid arguments: %v", err)), nil
+		}
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = r.name
+	req.Params.Arguments = args
+
+	result, err := r.cli.CallTool(ctx, req)
+	if err != nil {
+		return errorPayload(fmt.Sprintf("MCP server '%s' call failed: %v", r.server, err)), nil
+	}
+
+	var text []string
+	for _, content := range result.Content {
+		if tc, ok := content.(mcp.TextContent); ok {
+			text = append(text, tc.Text)
+		}
+	}
+	joined := strings.Join(text, "\n")
+	if result.IsError {
+		return errorPayload(joined), nil
+	}
+	return joined, nil
+}
+
+func errorPayload(msg string) string {
+	b, _ := json.Marshal(map[string]string{"error": msg})
+	return string(b)
+}
+
+var (
+	_ tool.InvokableTool = (*remoteTool)(nil)
+	_ tools.ReadOnlyTool = (*remoteTool)(nil)
+)
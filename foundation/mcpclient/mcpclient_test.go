@@ -0,0 +1,159 @@
+package mcpclient
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// TestMain doubles as a stdio MCP server, so the tests can start a real
+// subprocess without any external dependency.
+func TestMain(m *testing.M) {
+	if os.Getenv("MCPCLIENT_TEST_SERVER") == "1" {
+		if pidFile := os.Getenv("MCPCLIENT_TEST_PIDFILE"); pidFile != "" {
+			os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644)
+		}
+		server.NewStdioServer(newEchoServer()).Listen(context.Background(), os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// newEchoServer serves a single tool that returns its "text" argument, or an
+// error result when the text is empty.
+func newEchoServer() *server.MCPServer {
+	s := server.NewMCPServer("echo", "1.0.0", server.WithToolCapabilities(false))
+	echo := mcp.NewTool("echo", mcp.WithDescription("Echo the text back"), mcp.WithString("text", mcp.Required()))
+	s.AddTool(echo, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		text := req.GetString("text", "")
+		if text == "" {
+			return mcp.NewToolResultError("text is empty"), nil
+		}
+		return mcp.NewToolResultText(text), nil
+	})
+	return s
+}
+
+func invoke(t *testing.T, tools []tool.BaseTool, name, args string) string {
+	t.Helper()
+	for _, bt := range tools {
+		info, err := bt.Info(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Name != name {
+			continue
+		}
+		out, err := bt.(tool.InvokableTool).InvokableRun(context.Background(), args)
+		if err != nil {
+			t.Fatalf("InvokableRun: %v", err)
+		}
+		return out
+	}
+	t.Fatalf("tool %s not loaded", name)
+	return ""
+}
+
+func TestLoadToolsStartsAndStopsSubprocesses(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "server.pid")
+	config := `{"mcpServers": {
+		"echo": {"command": "` + os.Args[0] + `", "env": {"MCPCLIENT_TEST_SERVER": "1", "MCPCLIENT_TEST_PIDFILE": "` + pidFile + `"}},
+		"broken": {"command": "` + filepath.Join(dir, "missing-binary") + `"}
+	}}`
+	configPath := filepath.Join(dir, "mcp.json")
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MCP_CONFIG", configPath)
+
+	tools, closeTools, err := LoadTools(context.Background())
+	if err != nil {
+		t.Fatalf("LoadTools: %v", err)
+	}
+	if len(tools) != 1 {
+		t.Fatalf("loaded %d tools, want only echo's (the broken server is skipped)", len(tools))
+	}
+	if out := invoke(t, tools, "echo__echo", `{"text":"hello"}`); out != "hello" {
+		t.Errorf("echo returned %q", out)
+	}
+	if out := invoke(t, tools, "echo__echo", `{"text":""}`); !strings.Contains(out, `"error":"text is empty"`) {
+		t.Errorf("tool error was not reported in the error field: %q", out)
+	}
+
+	raw, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("server did not record its pid: %v", err)
+	}
+	pid, _ := strconv.Atoi(string(raw))
+
+	if err := closeTools(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if err := syscall.Kill(pid, 0); !errors.Is(err, syscall.ESRCH) {
+		t.Errorf("server subprocess %d still running after close (kill -0: %v)", pid, err)
+	}
+}
+
+func TestLoadToolsReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "writer.pid")
+	config := `{"mcpServers": {
+		"reader": {"command": "` + os.Args[0] + `", "env": {"MCPCLIENT_TEST_SERVER": "1"}, "read_only": true},
+		"writer": {"command": "` + os.Args[0] + `", "env": {"MCPCLIENT_TEST_SERVER": "1", "MCPCLIENT_TEST_PIDFILE": "` + pidFile + `"}}
+	}}`
+	configPath := filepath.Join(dir, "mcp.json")
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MCP_CONFIG", configPath)
+
+	loaded, closeTools, err := LoadTools(tools.WithReadOnly(context.Background()))
+	if err != nil {
+		t.Fatalf("LoadTools: %v", err)
+	}
+	defer closeTools()
+	if len(loaded) != 1 {
+		t.Fatalf("loaded %d tools, want only the read-only server's", len(loaded))
+	}
+	if out := invoke(t, loaded, "reader__echo", `{"text":"hello"}`); out != "hello" {
+		t.Errorf("echo returned %q", out)
+	}
+	if _, err := os.Stat(pidFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the server not marked read_only was started (pid file: %v)", err)
+	}
+}
+
+func TestConnectSSE(t *testing.T) {
+	srv := server.NewTestServer(newEchoServer())
+	defer srv.Close()
+
+	tools, closer, err := Connect(context.Background(), "remote", ServerConfig{URL: srv.URL + "/sse"})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer closer.Close()
+	if out := invoke(t, tools, "remote__echo", `{"text":"over sse"}`); out != "over sse" {
+		t.Errorf("echo returned %q", out)
+	}
+	if out := invoke(t, tools, "remote__echo", `not json`); !strings.Contains(out, "invalid arguments") {
+		t.Errorf("bad arguments were not reported: %q", out)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "mcp.json"))
+	if err != nil || len(cfg.MCPServers) != 0 {
+		t.Fatalf("LoadConfig on a missing file = %+v, %v; want an empty config", cfg, err)
+	}
+}
@@ -0,0 +1,161 @@
+// Package mcpserver exposes Eino tools over the Model Context Protocol so
+// MCP clients such as Claude Desktop or Cursor can call them directly.
+package mcpserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ServerName and ServerVersion identify this server to MCP clients.
+const (
+	ServerName    = "goforai"
+	ServerVersion = "0.1.0"
+)
+
+// New builds an MCP server that publishes every invokable tool in tools.
+func New(ctx context.Context, tools []tool.BaseTool) (*server.MCPServer, error) {
+	s := server.NewMCPServer(ServerName, ServerVersion,
+		server.WithToolCapabilities(false),
+		server.WithRecovery(),
+	)
+
+	for _, t := range tools {
+		invokable, ok := t.(tool.InvokableTool)
+		if !ok {
+			continue // Streaming-only tools have no MCP equivalent.
+		}
+
+		mcpTool, err := toMCPTool(ctx, invokable)
+		if err != nil {
+			return nil, err
+		}
+		s.AddTool(mcpTool, handlerFor(invokable))
+	}
+
+	return s, nil
+}
+
+// toMCPTool converts the Eino tool description into an MCP tool definition.
+func toMCPTool(ctx context.Context, t tool.InvokableTool) (mcp.Tool, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return mcp.Tool{}, fmt.Errorf("failed to get tool info: %w", err)
+	}
+
+	rawSchema := json.RawMessage(`{"type":"object","properties":{}}`)
+	if info.ParamsOneOf != nil {
+		js, err := info.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return mcp.Tool{}, fmt.Errorf("failed to build schema for tool '%s': %w", info.Name, err)
+		}
+		if js != nil {
+			if rawSchema, err = json.Marshal(js); err != nil {
+				return mcp.Tool{}, fmt.Errorf("failed to encode schema for tool '%s': %w", info.Name, err)
+			}
+		}
+	}
+
+	return mcp.NewToolWithRawSchema(info.Name, info.Desc, rawSchema), nil
+}
+
+// handlerFor adapts an Eino tool to the MCP call signature. Arguments arrive
+// as decoded JSON and are re-encoded for the tool, which expects a JSON string.
+func handlerFor(t tool.InvokableTool) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := req.GetRawArguments()
+		if args == nil {
+			args = map[string]any{}
+		}
+		argsJSON, err := json.Marshal(args)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid arguments", err), nil
+		}
+
+		result, err := t.InvokableRun(ctx, string(argsJSON))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultText(result), nil
+	}
+}
+
+// ServeStdio serves s over stdin/stdout until the client disconnects or ctx
+// is cancelled. Nothing else may write to stdout while it runs, including
+// logs; see the mcp command, which forces them to stderr.
+func ServeStdio(ctx context.Context, s *server.MCPServer) error {
+	return server.NewStdioServer(s).Listen(ctx, os.Stdin, os.Stdout)
+}
+
+// ServeSSE serves s over HTTP with Server-Sent Events on addr until ctx is
+// cancelled. When token is set, every request must carry it as a bearer
+// token. A token is required unless addr is a loopback address, because the
+// tools can read files and run commands.
+func ServeSSE(ctx context.Context, s *server.MCPServer, addr, token string) error {
+	if token == "" && !isLoopback(addr) {
+		return fmt.Errorf("refusing to serve MCP on non-loopback address %s without an auth token", addr)
+	}
+
+	httpServer := &http.Server{Addr: addr}
+	sse := server.NewSSEServer(s, server.WithHTTPServer(httpServer))
+	httpServer.Handler = requireToken(token, sse)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- sse.Start(addr)
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return sse.Shutdown(shutdownCtx)
+	}
+}
+
+// requireToken rejects requests without "Authorization: Bearer <token>".
+// An empty token disables the check.
+func requireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopback reports whether addr (host:port) only listens on the local machine.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,63 @@
+package mcpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestIsLoopback(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8081": true,
+		"[::1]:8081":     true,
+		"localhost:8081": true,
+		":8081":          false,
+		"0.0.0.0:8081":   false,
+		"10.0.0.5:8081":  false,
+		"example.com:80": false,
+		"8081":           false,
+	} {
+		if got := isLoopback(addr); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestServeSSERefusesOpenAddressWithoutToken(t *testing.T) {
+	s := server.NewMCPServer(ServerName, ServerVersion)
+	err := ServeSSE(context.Background(), s, ":0", "")
+	if err == nil || !strings.Contains(err.Error(), "without an auth token") {
+		t.Fatalf("ServeSSE on all interfaces without a token = %v, want a refusal", err)
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := requireToken("secret", ok)
+	for header, want := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"secret":        http.StatusUnauthorized,
+		"Bearer secret": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/sse", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Authorization %q: status %d, want %d", header, rec.Code, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	requireToken("", ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sse", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("no token configured: status %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,80 @@
+// Package models records what each chat model can do and what it costs, so
+// that context management and cost tracking look the numbers up instead of
+// assuming gemini-2.5-flash.
+package models
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Capabilities describes a chat model.
+type Capabilities struct {
+	ContextWindow int     // Input tokens the model accepts.
+	MaxOutput     int     // Tokens the model can generate in one response.
+	Tools         bool    // Supports function calling.
+	Vision        bool    // Accepts images.
+	InputPrice    float64 // USD per million input tokens.
+	OutputPrice   float64 // USD per million output tokens.
+}
+
+// Cost returns the price in USD of a call with the given token counts.
+func (c Capabilities) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*c.InputPrice + float64(outputTokens)*c.OutputPrice) / 1_000_000
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Capabilities{
+		"gemini-2.5-pro":        {ContextWindow: 1_048_576, MaxOutput: 65_536, Tools: true, Vision: true, InputPrice: 1.25, OutputPrice: 10.00},
+		"gemini-2.5-flash":      {ContextWindow: 1_048_576, MaxOutput: 65_536, Tools: true, Vision: true, InputPrice: 0.30, OutputPrice: 2.50},
+		"gemini-2.5-flash-lite": {ContextWindow: 1_048_576, MaxOutput: 65_536, Tools: true, Vision: true, InputPrice: 0.10, OutputPrice: 0.40},
+		"gemini-2.0-flash":      {ContextWindow: 1_048_576, MaxOutput: 8_192, Tools: true, Vision: true, InputPrice: 0.10, OutputPrice: 0.40},
+		"gpt-4o":                {ContextWindow: 128_000, MaxOutput: 16_384, Tools: true, Vision: true, InputPrice: 2.50, OutputPrice: 10.00},
+		"gpt-4o-mini":           {ContextWindow: 128_000, MaxOutput: 16_384, Tools: true, Vision: true, InputPrice: 0.15, OutputPrice: 0.60},
+	}
+)
+
+// Register adds or replaces the capabilities of a model, for models the
+// built-in table does not know or whose prices changed.
+func Register(name string, c Capabilities) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = c
+}
+
+// Lookup returns the capabilities of the named model. Names are matched
+// without a "models/" prefix and, failing an exact match, by the longest
+// registered name they start with, so "gemini-2.5-flash-001" resolves to
+// gemini-2.5-flash. ok is false for unknown models.
+func Lookup(name string) (c Capabilities, ok bool) {
+	name = strings.TrimPrefix(name, "models/")
+	mu.RLock()
+	defer mu.RUnlock()
+	if c, ok := registry[name]; ok {
+		return c, true
+	}
+	best := ""
+	for known := range registry {
+		if strings.HasPrefix(name, known+"-") && len(known) > len(best) {
+			best = known
+		}
+	}
+	if best == "" {
+		return Capabilities{}, false
+	}
+	return registry[best], true
+}
+
+// Names returns the registered model names in sorted order.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
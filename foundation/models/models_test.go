@@ -0,0 +1,39 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	for name, want := range map[string]float64{
+		"gemini-2.5-flash":             0.30,
+		"models/gemini-2.5-flash":      0.30,
+		"gemini-2.5-flash-001":         0.30,
+		"gemini-2.5-flash-lite":        0.10,
+		"gemini-2.5-flash-lite-001":    0.10,
+		"gemini-2.5-pro-preview-06-05": 1.25,
+	} {
+		c, ok := Lookup(name)
+		if !ok || c.InputPrice != want {
+			t.Errorf("Lookup(%q) = %+v, %v; want input price %v", name, c, ok, want)
+		}
+	}
+	for _, name := range []string{"", "gemini", "gemini-2.5-flashy", "llama3"} {
+		if c, ok := Lookup(name); ok {
+			t.Errorf("Lookup(%q) = %+v, want unknown", name, c)
+		}
+	}
+
+	Register("llama3", Capabilities{ContextWindow: 8192, Tools: true})
+	if c, ok := Lookup("llama3"); !ok || c.ContextWindow != 8192 {
+		t.Errorf("registered model not found: %+v, %v", c, ok)
+	}
+}
+
+func TestCost(t *testing.T) {
+	c, _ := Lookup("gemini-2.5-flash")
+	if got := c.Cost(1_000_000, 100_000); math.Abs(got-0.55) > 1e-9 {
+		t.Errorf("Cost = %v, want 0.55", got)
+	}
+}
@@ -0,0 +1,334 @@
+// Package ollama provides a tool-calling chat model backed by a local Ollama
+// server, so the agent can run without reaching a hosted API.
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/wirelog"
+)
+
+// Provider names Ollama in the usage quotas.
+const Provider = "ollama"
+
+// DefaultBaseURL is where Ollama listens by default.
+const DefaultBaseURL = "http://localhost:11434"
+
+// ErrUnavailable is returned when no Ollama server answers at the configured
+// address, usually because it is not running.
+var ErrUnavailable = errors.New("ollama is not running")
+
+// ChatModel talks to the /api/chat endpoint of an Ollama server.
+type ChatModel struct {
+	client  *http.Client
+	baseURL string
+	model   string
+	tools   []tool
+}
+
+// NewChatModel returns a chat model for the named model served at baseURL,
+// or DefaultBaseURL when it is empty. The model must support tool calling
+// for the agent to use its tools; most recent coding models do.
+func NewChatModel(_ context.Context, modelName, baseURL string) (*ChatModel, error) {
+	if modelName == "" {
+		return nil, errors.New("ollama: model name is required")
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	transport, err := wirelog.Transport(Provider)
+	if err != nil {
+		return nil, err
+	}
+	// Local models are slow to load and to answer on a laptop, hence no
+	// overall timeout: the caller's context bounds each call.
+	return &ChatModel{
+		client:  &http.Client{Transport: transport},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   modelName,
+	}, nil
+}
+
+// Model returns the name of the model the ChatModel calls.
+func (m *ChatModel) Model() string { return m.model }
+
+// Ping checks that the server answers and has the model pulled.
+func (m *ChatModel) Ping(ctx context.Context) error {
+	var out struct{}
+	if err := m.post(ctx, "/api/show", map[string]string{"model": m.model}, &out); err != nil {
+		return fmt.Errorf("chat model %s is unavailable: %w", m.model, err)
+	}
+	return nil
+}
+
+func (m *ChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	req, err := m.request(input, false, opts)
+	if err != nil {
+		return nil, err
+	}
+	var resp chatResponse
+	if err := m.post(ctx, "/api/chat", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.toMessage(), nil
+}
+
+func (m *ChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	req, err := m.request(input, true, opts)
+	if err != nil {
+		return nil, err
+	}
+	body, err := m.send(ctx, "/api/chat", req)
+	if err != nil {
+		return nil, err
+	}
+
+	out, w := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer body.Close()
+		defer w.Close()
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			var chunk chatResponse
+			if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+				w.Send(nil, fmt.Errorf("ollama: failed to decode stream: %w", err))
+				return
+			}
+			if chunk.Error != "" {
+				w.Send(nil, fmt.Errorf("ollama: %s", chunk.Error))
+				return
+			}
+			if w.Send(chunk.toMessage(), nil) || chunk.Done {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			w.Send(nil, fmt.Errorf("ollama: stream interrupted: %w", err))
+		}
+	}()
+	return out, nil
+}
+
+// WithTools returns a copy of the model that offers tools to the model.
+func (m *ChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	converted, err := convertTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	bound := *m
+	bound.tools = converted
+	return &bound, nil
+}
+
+func (m *ChatModel) GetType() string { return "Ollama" }
+
+// IsCallbacksEnabled is false, so Eino reports the calls to callbacks.
+func (m *ChatModel) IsCallbacksEnabled() bool { return false }
+
+// request builds the /api/chat body for input.
+func (m *ChatModel) request(input []*schema.Message, stream bool, opts []model.Option) (*chatRequest, error) {
+	common := model.GetCommonOptions(&model.Options{}, opts...)
+	req := &chatRequest{Model: m.model, Stream: stream, Tools: m.tools, Options: map[string]any{}}
+	if common.Model != nil && *common.Model != "" {
+		req.Model = *common.Model
+	}
+	if common.Tools != nil {
+		tools, err := convertTools(common.Tools)
+		if err != nil {
+			return nil, err
+		}
+		req.Tools = tools
+	}
+	if common.Temperature != nil {
+		req.Options["temperature"] = *common.Temperature
+	}
+	if common.TopP != nil {
+		req.Options["top_p"] = *common.TopP
+	}
+	if common.MaxTokens != nil {
+		req.Options["num_predict"] = *common.MaxTokens
+	}
+	if len(common.Stop) > 0 {
+		req.Options["stop"] = common.Stop
+	}
+
+	// Tool results are matched to calls by name in Ollama, by ID in Eino.
+	callNames := make(map[string]string)
+	for _, msg := range input {
+		out := message{Role: string(msg.Role), Content: msg.Content}
+		for _, part := range msg.MultiContent {
+			if part.Type == schema.ChatMessagePartTypeText {
+				out.Content += part.Text
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			callNames[call.ID] = call.Function.Name
+			args := json.RawMessage(call.Function.Arguments)
+			if !json.Valid(args) {
+				args = json.RawMessage("{}")
+			}
+			out.ToolCalls = append(out.ToolCalls, toolCall{Function: functionCall{Name: call.Function.Name, Arguments: args}})
+		}
+		if msg.Role == schema.Tool {
+			out.ToolName = msg.ToolName
+			if out.ToolName == "" {
+				out.ToolName = callNames[msg.ToolCallID]
+			}
+		}
+		req.Messages = append(req.Messages, out)
+	}
+	return req, nil
+}
+
+// post sends body to path and decodes the JSON response into out.
+func (m *ChatModel) post(ctx context.Context, path string, body, out any) error {
+	resp, err := m.send(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	if err := json.NewDecoder(resp).Decode(out); err != nil {
+		return fmt.Errorf("ollama: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send posts body to path and returns the body of a successful response.
+func (m *ChatModel) send(ctx context.Context, path string, body any) (io.ReadCloser, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w at %s: start it with 'ollama serve': %w", ErrUnavailable, m.baseURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error != "" {
+			if resp.StatusCode == http.StatusNotFound {
+				return nil, fmt.Errorf("ollama: %s: pull it with 'ollama pull %s'", apiErr.Error, m.model)
+			}
+			return nil, fmt.Errorf("ollama: status %d: %s", resp.StatusCode, apiErr.Error)
+		}
+		return nil, fmt.Errorf("ollama: status %d: %s", resp.StatusCode, bytes.TrimSpace(raw))
+	}
+	return resp.Body, nil
+}
+
+// convertTools describes tools in the function format Ollama takes.
+func convertTools(infos []*schema.ToolInfo) ([]tool, error) {
+	tools := make([]tool, 0, len(infos))
+	for _, info := range infos {
+		params := json.RawMessage(`{"type":"object","properties":{}}`)
+		if info.ParamsOneOf != nil {
+			js, err := info.ParamsOneOf.ToJSONSchema()
+			if err != nil {
+				return nil, fmt.Errorf("ollama: invalid parameters of tool %s: %w", info.Name, err)
+			}
+			if params, err = json.Marshal(js); err != nil {
+				return nil, fmt.Errorf("ollama: invalid parameters of tool %s: %w", info.Name, err)
+			}
+		}
+		tools = append(tools, tool{Type: "function", Function: function{Name: info.Name, Description: info.Desc, Parameters: params}})
+	}
+	return tools, nil
+}
+
+type chatRequest struct {
+	Model    string         `json:"model"`
+	Messages []message      `json:"messages"`
+	Tools    []tool         `json:"tools,omitempty"`
+	Stream   bool           `json:"stream"`
+	Options  map[string]any `json:"options,omitempty"`
+}
+
+type message struct {
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
+}
+
+type toolCall struct {
+	Function functionCall `json:"function"`
+}
+
+type functionCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type tool struct {
+	Type     string   `json:"type"`
+	Function function `json:"function"`
+}
+
+type function struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+type chatResponse struct {
+	Message         message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+	Error           string  `json:"error"`
+}
+
+// toMessage converts a response, or one chunk of a streamed one, to an
+// assistant message. Ollama does not number tool calls, so they get IDs and
+// indexes in the order they arrive.
+func (r *chatResponse) toMessage() *schema.Message {
+	msg := &schema.Message{Role: schema.Assistant, Content: r.Message.Content}
+	for i, call := range r.Message.ToolCalls {
+		index := i
+		msg.ToolCalls = append(msg.ToolCalls, schema.ToolCall{
+			Index:    &index,
+			ID:       fmt.Sprintf("call_%d_%d", time.Now().UnixNano(), i),
+			Type:     "function",
+			Function: schema.FunctionCall{Name: call.Function.Name, Arguments: string(call.Function.Arguments)},
+		})
+	}
+	if r.Done {
+		msg.ResponseMeta = &schema.ResponseMeta{
+			FinishReason: r.DoneReason,
+			Usage: &schema.TokenUsage{
+				PromptTokens:     r.PromptEvalCount,
+				CompletionTokens: r.EvalCount,
+				TotalTokens:      r.PromptEvalCount + r.EvalCount,
+			},
+		}
+	}
+	return msg
+}
@@ -0,0 +1,150 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeOllama answers /api/chat with replies, one per call, and records the
+// requests. Streamed replies are sent as one line per word.
+func fakeOllama(t *testing.T, replies ...string) (*httptest.Server, *[]chatRequest) {
+	t.Helper()
+	var requests []chatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		if req.Model != "qwen2.5-coder:7b" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model \"` + req.Model + `\" not found, try pulling it first"}`))
+			return
+		}
+		requests = append(requests, req)
+		reply := replies[len(requests)-1]
+		if !req.Stream {
+			w.Write([]byte(reply))
+			return
+		}
+		var resp chatResponse
+		json.Unmarshal([]byte(reply), &resp)
+		for _, word := range strings.SplitAfter(resp.Message.Content, " ") {
+			line, _ := json.Marshal(chatResponse{Message: message{Role: "assistant", Content: word}})
+			w.Write(append(line, '\n'))
+		}
+		resp.Message.Content = ""
+		line, _ := json.Marshal(resp)
+		w.Write(append(line, '\n'))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestGenerateWithTools(t *testing.T) {
+	srv, requests := fakeOllama(t,
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"read_file","arguments":{"path":"main.go"}}}]},"done":true,"done_reason":"stop","prompt_eval_count":20,"eval_count":5}`,
+		`{"message":{"role":"assistant","content":"It prints hello."},"done":true,"prompt_eval_count":40,"eval_count":4}`)
+	ctx := context.Background()
+	m, err := NewChatModel(ctx, "qwen2.5-coder:7b", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withTools, err := m.WithTools([]*schema.ToolInfo{{
+		Name: "read_file",
+		Desc: "Read a file.",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"path": {Type: schema.String, Required: true},
+		}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := []*schema.Message{schema.SystemMessage("Be brief."), schema.UserMessage("What does main.go do?")}
+	call, err := withTools.Generate(ctx, input)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(call.ToolCalls) != 1 || call.ToolCalls[0].Function.Name != "read_file" || call.ToolCalls[0].Function.Arguments != `{"path":"main.go"}` {
+		t.Fatalf("tool calls = %+v", call.ToolCalls)
+	}
+	if u := call.ResponseMeta.Usage; u.PromptTokens != 20 || u.TotalTokens != 25 {
+		t.Errorf("usage = %+v", u)
+	}
+
+	input = append(input, call, schema.ToolMessage(`{"content":"package main"}`, call.ToolCalls[0].ID))
+	answer, err := withTools.Generate(ctx, input)
+	if err != nil || answer.Content != "It prints hello." {
+		t.Fatalf("Generate = %v, %v", answer, err)
+	}
+
+	req := (*requests)[1]
+	if len(req.Tools) != 1 || req.Tools[0].Function.Name != "read_file" || !strings.Contains(string(req.Tools[0].Function.Parameters), `"path"`) {
+		t.Errorf("tools = %+v", req.Tools)
+	}
+	if len(req.Messages) != 4 || req.Messages[2].ToolCalls[0].Function.Name != "read_file" ||
+		req.Messages[3].Role != "tool" || req.Messages[3].ToolName != "read_file" {
+		t.Errorf("messages = %+v", req.Messages)
+	}
+	if len((*requests)[0].Tools) != 1 {
+		t.Error("WithTools did not bind the tools")
+	}
+	if len(m.tools) != 0 {
+		t.Error("WithTools changed the original model")
+	}
+}
+
+func TestStream(t *testing.T) {
+	srv, _ := fakeOllama(t, `{"message":{"role":"assistant","content":"Goroutines are cheap."},"done":true,"eval_count":3}`)
+	m, err := NewChatModel(context.Background(), "qwen2.5-coder:7b", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := m.Stream(context.Background(), []*schema.Message{schema.UserMessage("Goroutines?")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var chunks []*schema.Message
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	msg, err := schema.ConcatMessages(chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) < 3 || msg.Content != "Goroutines are cheap." || msg.ResponseMeta.Usage.CompletionTokens != 3 {
+		t.Errorf("%d chunks concatenated to %+v", len(chunks), msg)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv, _ := fakeOllama(t)
+	m, err := NewChatModel(context.Background(), "llama9", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")}); err == nil || !strings.Contains(err.Error(), "ollama pull llama9") {
+		t.Errorf("missing model: %v", err)
+	}
+
+	srv.Close()
+	if _, err := m.Generate(context.Background(), []*schema.Message{schema.UserMessage("hi")}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("stopped server: got %v, want ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Package pacing smooths streamed text on its way to a sink. Fast models
+// deliver answers in a flood of tiny chunks, which makes terminals flicker
+// and sends web clients a frame per token; a Pacer coalesces them into
+// larger pieces and can slow them to a steady typewriter rate.
+package pacing
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// Pacer buffers text written to it and passes it to its sink in pieces of at
+// least cfg.MinChunk bytes, or whatever has arrived once the oldest buffered
+// byte has waited cfg.MaxDelay. A zero MinChunk passes every write straight
+// through; a zero MaxDelay holds text until MinChunk is reached or Flush is
+// called.
+//
+// With cfg.Rate set, each piece is released a few runes at a time at that
+// many runes per second. Write blocks meanwhile, so the pace pushes back on
+// the stream being read.
+//
+// The sink is never called concurrently, but it can be called from a timer's
+// goroutine. It is safe to call Write and Flush from several goroutines.
+type Pacer struct {
+	ctx  context.Context
+	cfg  config.Streaming
+	emit func(string) error
+
+	mu    sync.Mutex
+	buf   strings.Builder
+	timer *time.Timer // Flushes buf after MaxDelay; nil when buf is empty.
+	err   error       // The sink's first error, returned from then on.
+}
+
+// New returns a Pacer that passes text to emit. Canceling ctx ends the
+// typewriter effect: whatever is left is passed on at once.
+func New(ctx context.Context, cfg config.Streaming, emit func(string) error) *Pacer {
+	return &Pacer{ctx: ctx, cfg: cfg, emit: emit}
+}
+
+// Write adds s to the buffer, passing the buffer on if it is full. It
+// returns the sink's error, including one from an earlier timed flush.
+func (p *Pacer) Write(s string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.buf.WriteString(s)
+	if p.buf.Len() >= p.cfg.MinChunk {
+		return p.flush()
+	}
+	if p.timer == nil && p.cfg.MaxDelay > 0 {
+		var t *time.Timer
+		t = time.AfterFunc(p.cfg.MaxDelay, func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.timer == t {
+				p.flush()
+			}
+		})
+		p.timer = t
+	}
+	return nil
+}
+
+// Flush passes on whatever is buffered. Call it when the stream ends, and
+// before writing anything else to the same sink, so the output stays in
+// order.
+func (p *Pacer) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	return p.flush()
+}
+
+func (p *Pacer) flush() error {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	text := p.buf.String()
+	p.buf.Reset()
+	if text == "" {
+		return nil
+	}
+	if err := p.release(text); err != nil {
+		p.err = err
+		return err
+	}
+	return nil
+}
+
+// release passes text to the sink at the typewriter rate. Pieces are sized
+// so that there are at most 100 a second.
+func (p *Pacer) release(text string) error {
+	if p.cfg.Rate <= 0 {
+		return p.emit(text)
+	}
+	runes := []rune(text)
+	step := max(1, p.cfg.Rate/100)
+	interval := time.Duration(step) * time.Second / time.Duration(p.cfg.Rate)
+	for len(runes) > step {
+		if err := p.emit(string(runes[:step])); err != nil {
+			return err
+		}
+		runes = runes[step:]
+		select {
+		case <-p.ctx.Done():
+			return p.emit(string(runes))
+		case <-time.After(interval):
+		}
+	}
+	return p.emit(string(runes))
+}
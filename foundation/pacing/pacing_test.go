@@ -0,0 +1,115 @@
+package pacing
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// sink records the pieces a Pacer passes on.
+type sink struct {
+	mu     sync.Mutex
+	pieces []string
+	err    error
+}
+
+func (s *sink) emit(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pieces = append(s.pieces, text)
+	return s.err
+}
+
+func (s *sink) got() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.pieces)
+}
+
+func TestCoalesce(t *testing.T) {
+	var s sink
+	p := New(context.Background(), config.Streaming{MinChunk: 8}, s.emit)
+	for _, tok := range []string{"Go", "ph", "er", "Con", " Af", "ri", "ca"} {
+		if err := p.Write(tok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"GopherCon", " Africa"}; !slices.Equal(s.got(), want) {
+		t.Errorf("pieces = %q, want %q", s.got(), want)
+	}
+
+	// Without a minimum, every write is passed straight on.
+	s = sink{}
+	p = New(context.Background(), config.Streaming{}, s.emit)
+	p.Write("a")
+	p.Write("b")
+	if want := []string{"a", "b"}; !slices.Equal(s.got(), want) {
+		t.Errorf("pieces = %q, want %q", s.got(), want)
+	}
+}
+
+func TestMaxDelay(t *testing.T) {
+	var s sink
+	p := New(context.Background(), config.Streaming{MinChunk: 1000, MaxDelay: 20 * time.Millisecond}, s.emit)
+	p.Write("Hello")
+	if len(s.got()) != 0 {
+		t.Fatal("text was passed on before it filled a chunk or waited")
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(s.got()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if want := []string{"Hello"}; !slices.Equal(s.got(), want) {
+		t.Errorf("after the delay, pieces = %q, want %q", s.got(), want)
+	}
+}
+
+func TestTypewriter(t *testing.T) {
+	var s sink
+	p := New(context.Background(), config.Streaming{Rate: 200}, s.emit)
+	start := time.Now()
+	if err := p.Write("0123456789"); err != nil {
+		t.Fatal(err)
+	}
+	// Ten runes at 200 a second go two at a time, with four 10ms pauses.
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("typed 10 runes in %s", elapsed)
+	}
+	if want := []string{"01", "23", "45", "67", "89"}; !slices.Equal(s.got(), want) {
+		t.Errorf("pieces = %q, want %q", s.got(), want)
+	}
+
+	// Canceling ends the effect and passes the rest on at once.
+	s = sink{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p = New(ctx, config.Streaming{Rate: 1}, s.emit)
+	start = time.Now()
+	p.Write("abc")
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("a canceled pacer still typed for %s", elapsed)
+	}
+	if want := []string{"a", "bc"}; !slices.Equal(s.got(), want) {
+		t.Errorf("pieces = %q, want %q", s.got(), want)
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	gone := errors.New("client disconnected")
+	s := sink{err: gone}
+	p := New(context.Background(), config.Streaming{}, s.emit)
+	if err := p.Write("a"); !errors.Is(err, gone) {
+		t.Fatalf("Write = %v", err)
+	}
+	if err := p.Write("b"); !errors.Is(err, gone) || len(s.got()) != 1 {
+		t.Errorf("after a failure, Write = %v and the sink saw %q", err, s.got())
+	}
+}
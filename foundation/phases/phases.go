@@ -0,0 +1,231 @@
+// Package phases reports which phase a turn of the agent is in: thinking,
+// acting through tools, or answering. Models deliver their reasoning either
+// in a message's ReasoningContent or between <think> tags in its content; a
+// Splitter separates it from the answer, and the callback handler from
+// NewHandler turns the model's output into lifecycle events, so user
+// interfaces can render each phase differently without parsing content.
+package phases
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Phase names a lifecycle event of a turn.
+type Phase string
+
+const (
+	// ReasoningStarted is sent when a model call starts to reason.
+	ReasoningStarted Phase = "reasoning_started"
+	// ToolPhase is sent when a model call ends by calling tools.
+	ToolPhase Phase = "tool_phase"
+	// AnswerStarted is sent when a model call starts to write text meant for
+	// the user, which is the answer unless the call goes on to call tools.
+	AnswerStarted Phase = "answer_started"
+)
+
+// Event is one phase change of a turn.
+type Event struct {
+	Phase Phase    `json:"phase"`
+	Step  int      `json:"step"`            // The model call of the turn, counting from 1.
+	Tools []string `json:"tools,omitempty"` // On ToolPhase, the tools called, in order.
+}
+
+const (
+	openTag  = "<think>"
+	closeTag = "</think>"
+)
+
+// Splitter separates the reasoning in a model's streamed output from the
+// rest of its content. Tags split across chunks are recognized; the start of
+// a possible tag is held back until the next chunk shows what it is. The
+// zero value is ready to use, for one model call.
+type Splitter struct {
+	thinking bool
+	pending  string // The end of the last chunk, which may begin a tag.
+}
+
+// Split returns the reasoning and the answer text of the next chunk.
+func (s *Splitter) Split(chunk *schema.Message) (reasoning, answer string) {
+	var r, a strings.Builder
+	r.WriteString(chunk.ReasoningContent)
+	text := s.pending + chunk.Content
+	s.pending = ""
+	for text != "" {
+		tag, out := openTag, &a
+		if s.thinking {
+			tag, out = closeTag, &r
+		}
+		if i := strings.Index(text, tag); i >= 0 {
+			out.WriteString(text[:i])
+			text = text[i+len(tag):]
+			s.thinking = !s.thinking
+			continue
+		}
+		keep := partialTag(text, tag)
+		out.WriteString(text[:len(text)-keep])
+		s.pending = text[len(text)-keep:]
+		break
+	}
+	return r.String(), a.String()
+}
+
+// Flush returns the text held back at the end of the output.
+func (s *Splitter) Flush() (reasoning, answer string) {
+	text := s.pending
+	s.pending = ""
+	if s.thinking {
+		return text, ""
+	}
+	return "", text
+}
+
+// partialTag returns the length of the longest end of text that begins tag.
+func partialTag(text, tag string) int {
+	for k := min(len(text), len(tag)-1); k > 0; k-- {
+		if strings.HasSuffix(text, tag[:k]) {
+			return k
+		}
+	}
+	return 0
+}
+
+// Handler is a callback handler that reports the phase events of a turn.
+type Handler struct {
+	callbacks.Handler
+	streams sync.WaitGroup
+
+	mu   sync.Mutex
+	step int
+}
+
+// Needed reports which callbacks the handler implements, as the embedded
+// handler would if it were used directly.
+func (h *Handler) Needed(ctx context.Context, info *callbacks.RunInfo, timing callbacks.CallbackTiming) bool {
+	return h.Handler.(callbacks.TimingChecker).Needed(ctx, info, timing)
+}
+
+// Step returns the number of model calls started so far. Once output of the
+// turn's last model call arrives, it is the step of that call's events.
+func (h *Handler) Step() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.step
+}
+
+// Wait waits until the events of every streamed model call that has ended
+// are sent.
+func (h *Handler) Wait() {
+	h.streams.Wait()
+}
+
+// NewHandler returns a callback handler that calls on with the phase events
+// of a turn, in order for each model call. Create one per turn and pass it
+// to the graph with compose.WithCallbacks. Events of streamed model calls
+// are sent from another goroutine as the stream is read; Wait for them
+// before ending the turn.
+func NewHandler(on func(ctx context.Context, e Event)) *Handler {
+	h := &Handler{}
+	h.Handler = callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if info.Component == components.ComponentOfChatModel {
+				h.mu.Lock()
+				h.step++
+				h.mu.Unlock()
+			}
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if info.Component != components.ComponentOfChatModel {
+				return ctx
+			}
+			if out := model.ConvCallbackOutput(output); out != nil && out.Message != nil {
+				c := newCall(ctx, on, h.Step())
+				c.add(out.Message)
+				c.end()
+			}
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			if info.Component != components.ComponentOfChatModel {
+				output.Close()
+				return ctx
+			}
+			c := newCall(ctx, on, h.Step())
+			h.streams.Add(1)
+			go func() {
+				defer h.streams.Done()
+				defer output.Close()
+				for {
+					chunk, err := output.Recv()
+					if err != nil {
+						break
+					}
+					if out := model.ConvCallbackOutput(chunk); out != nil && out.Message != nil {
+						c.add(out.Message)
+					}
+				}
+				c.end()
+			}()
+			return ctx
+		}).
+		Build()
+	return h
+}
+
+// call follows the output of one model call.
+type call struct {
+	ctx       context.Context
+	on        func(context.Context, Event)
+	step      int
+	split     Splitter
+	reasoning bool // ReasoningStarted was sent.
+	answering bool // AnswerStarted was sent.
+	tools     []string
+	calls     map[int]bool // Indexes of the streamed tool calls seen.
+}
+
+func newCall(ctx context.Context, on func(context.Context, Event), step int) *call {
+	return &call{ctx: ctx, on: on, step: step, calls: make(map[int]bool)}
+}
+
+func (c *call) add(msg *schema.Message) {
+	reasoning, answer := c.split.Split(msg)
+	c.text(reasoning, answer)
+	for i, tc := range msg.ToolCalls {
+		// Streamed calls arrive in pieces that share an index; only the
+		// first piece of each carries the name.
+		index := i
+		if tc.Index != nil {
+			index = *tc.Index
+		}
+		if tc.Function.Name != "" && !c.calls[index] {
+			c.calls[index] = true
+			c.tools = append(c.tools, tc.Function.Name)
+		}
+	}
+}
+
+func (c *call) text(reasoning, answer string) {
+	if !c.reasoning && strings.TrimSpace(reasoning) != "" {
+		c.reasoning = true
+		c.on(c.ctx, Event{Phase: ReasoningStarted, Step: c.step})
+	}
+	if !c.answering && strings.TrimSpace(answer) != "" {
+		c.answering = true
+		c.on(c.ctx, Event{Phase: AnswerStarted, Step: c.step})
+	}
+}
+
+func (c *call) end() {
+	c.text(c.split.Flush())
+	if len(c.tools) > 0 {
+		c.on(c.ctx, Event{Phase: ToolPhase, Step: c.step, Tools: c.tools})
+	}
+}
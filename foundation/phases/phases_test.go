@@ -0,0 +1,94 @@
+package phases
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestSplitter(t *testing.T) {
+	var s Splitter
+	var reasoning, answer string
+	for _, chunk := range []*schema.Message{
+		{Content: "<thi"},
+		{Content: "nk>Let me"},
+		{Content: " check.</"},
+		{Content: "think>The answer is <"},
+		{Content: "b>42"},
+		{Content: "</b"},
+		{ReasoningContent: " Sure."},
+	} {
+		r, a := s.Split(chunk)
+		reasoning += r
+		answer += a
+	}
+	r, a := s.Flush()
+	reasoning += r
+	answer += a
+	if want := "Let me check. Sure."; reasoning != want {
+		t.Errorf("reasoning = %q, want %q", reasoning, want)
+	}
+	if want := "The answer is <b>42</b"; answer != want {
+		t.Errorf("answer = %q, want %q", answer, want)
+	}
+}
+
+// recorder collects the events sent to it.
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) on(ctx context.Context, e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func TestHandler(t *testing.T) {
+	rec := &recorder{}
+	h := NewHandler(rec.on)
+	ctx := context.Background()
+	info := &callbacks.RunInfo{Component: components.ComponentOfChatModel}
+
+	// The first call answers without streaming.
+	h.OnStart(ctx, info, &model.CallbackInput{})
+	h.OnEnd(ctx, info, &model.CallbackOutput{Message: schema.AssistantMessage("Hello.", nil)})
+
+	// The second reasons, then streams a call to search in two pieces.
+	h.OnStart(ctx, info, &model.CallbackInput{})
+	index := 0
+	chunks := []callbacks.CallbackOutput{
+		&model.CallbackOutput{Message: &schema.Message{Role: schema.Assistant, ReasoningContent: "I should look."}},
+		&model.CallbackOutput{Message: &schema.Message{Role: schema.Assistant, ToolCalls: []schema.ToolCall{
+			{Index: &index, Function: schema.FunctionCall{Name: "search"}},
+		}}},
+		&model.CallbackOutput{Message: &schema.Message{Role: schema.Assistant, ToolCalls: []schema.ToolCall{
+			{Index: &index, Function: schema.FunctionCall{Arguments: `{"q":"go"}`}},
+		}}},
+	}
+	h.OnEndWithStreamOutput(ctx, info, schema.StreamReaderFromArray(chunks))
+	h.Wait()
+	if step := h.Step(); step != 2 {
+		t.Errorf("step = %d, want 2", step)
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	want := []Event{
+		{Phase: AnswerStarted, Step: 1},
+		{Phase: ReasoningStarted, Step: 2},
+		{Phase: ToolPhase, Step: 2, Tools: []string{"search"}},
+	}
+	if !slices.EqualFunc(rec.events, want, func(a, b Event) bool {
+		return a.Phase == b.Phase && a.Step == b.Step && slices.Equal(a.Tools, b.Tools)
+	}) {
+		t.Errorf("events = %+v, want %+v", rec.events, want)
+	}
+}
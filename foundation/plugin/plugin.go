@@ -0,0 +1,365 @@
+// Package plugin loads tools from plugin binaries: separate programs, built
+// with Serve, that the agent starts and talks to over their stdin and stdout.
+// A plugin can crash, leak or hang without taking the agent with it, and it
+// never sees the agent's secrets, so risky integrations can ship outside the
+// core binary.
+//
+// The protocol is JSON-RPC 1.0 (net/rpc/jsonrpc) with two methods:
+// Plugin.Tools lists the plugin's tools and Plugin.Invoke runs one.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	"github.com/olusolaa/goforai/foundation/logger"
+)
+
+// DefaultDir is searched for plugins when PLUGIN_DIR is not set.
+const DefaultDir = "plugins"
+
+// The agent sets handshakeKey to handshakeValue in a plugin's environment;
+// Serve refuses to run without it, and a new protocol version changes it.
+const (
+	handshakeKey   = "GOFORAI_PLUGIN"
+	handshakeValue = "v1"
+	serviceName    = "Plugin"
+)
+
+// stopTimeout is how long Close waits for a plugin to exit on its own.
+const stopTimeout = 5 * time.Second
+
+// passedEnv lists the variables of the agent's environment every plugin gets.
+// Other variables, API keys among them, are only passed to the plugin their
+// name starts with, such as JIRA_TOKEN to the plugin named jira.
+var passedEnv = []string{"PATH", "HOME", "TMPDIR", "LANG", "TZ", "SSL_CERT_FILE", "SSL_CERT_DIR", "HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"}
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ToolSpec describes a tool a plugin offers.
+type ToolSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"` // JSON schema of the arguments.
+}
+
+// InvokeArgs asks a plugin to run one of its tools.
+type InvokeArgs struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// InvokeReply is the result of a tool, or the error it returned.
+type InvokeReply struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Load starts every executable in dir as a plugin and returns their tools,
+// named <plugin>__<tool> like MCP tools. A missing dir holds no plugins, and
+// plugins that fail to start are logged and skipped. The returned function
+// stops the plugins; call it once the tools are no longer used.
+func Load(ctx context.Context, dir string) ([]tool.BaseTool, func() error, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, func() error { return nil }, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read plugin directory %s: %w", dir, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	log := logger.FromContext(ctx)
+	var (
+		tools   []tool.BaseTool
+		closers []io.Closer
+	)
+	for _, entry := range entries {
+		// Stat follows links, so a plugin can be linked in from elsewhere.
+		info, err := os.Stat(filepath.Join(dir, entry.Name()))
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		pluginTools, closer, err := Open(ctx, name, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			log.Warn("skipping plugin", "plugin", name, "error", err)
+			continue
+		}
+		log.Info("started plugin", "plugin", name, "tools", len(pluginTools))
+		tools = append(tools, pluginTools...)
+		closers = append(closers, closer)
+	}
+
+	closeAll := func() error {
+		var errs []error
+		for _, c := range closers {
+			errs = append(errs, c.Close())
+		}
+		return errors.Join(errs...)
+	}
+	return tools, closeAll, nil
+}
+
+// Open starts the plugin binary at path and wraps the tools it lists. The
+// returned Closer stops the plugin.
+func Open(ctx context.Context, name, path string) ([]tool.BaseTool, io.Closer, error) {
+	if !validName.MatchString(name) {
+		return nil, nil, fmt.Errorf("invalid plugin name %q: use up to 64 letters, digits, '_' or '-'", name)
+	}
+	p := &process{name: name, path: path, log: logger.FromContext(ctx)}
+
+	var specs []ToolSpec
+	if err := p.call(ctx, serviceName+".Tools", struct{}{}, &specs); err != nil {
+		p.Close()
+		return nil, nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+	tools := make([]tool.BaseTool, 0, len(specs))
+	for _, spec := range specs {
+		t, err := newPluginTool(p, spec)
+		if err != nil {
+			p.Close()
+			return nil, nil, err
+		}
+		tools = append(tools, t)
+	}
+	return tools, p, nil
+}
+
+// process is a running plugin. A plugin that exits is started again on the
+// next call.
+type process struct {
+	name string
+	path string
+	log  *slog.Logger
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	client *rpc.Client
+	stdin  io.Closer
+	exited chan struct{}
+	closed bool
+}
+
+// conn returns the client of the running plugin, starting it if needed.
+func (p *process) conn() (*rpc.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, errors.New("the plugin was stopped")
+	}
+	if p.client != nil {
+		return p.client, nil
+	}
+
+	cmd := exec.Command(p.path)
+	cmd.Env = pluginEnv(p.name)
+	cmd.Stderr = &stderrLog{log: p.log, plugin: p.name}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	// Unlike StdoutPipe, a pipe of our own stays open for reading until the
+	// client is closed, even after Wait returns.
+	stdout, w, err := os.Pipe()
+	if err != nil {
+		stdin.Close()
+		return nil, err
+	}
+	cmd.Stdout = w
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		stdin.Close()
+		stdout.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", p.path, err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		p.log.Debug("plugin exited", "plugin", p.name, "error", err)
+		close(exited)
+	}()
+
+	p.cmd, p.stdin, p.exited = cmd, stdin, exited
+	p.client = rpc.NewClientWithCodec(jsonrpc.NewClientCodec(pipe{ReadCloser: stdout, Writer: stdin}))
+	return p.client, nil
+}
+
+// call runs method on the plugin, giving up when ctx is done.
+func (p *process) call(ctx context.Context, method string, args, reply any) error {
+	client, err := p.conn()
+	if err != nil {
+		return err
+	}
+	call := client.Go(method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-call.Done:
+	}
+	// Errors the plugin returned are rpc.ServerErrors; any other error means
+	// the connection broke.
+	var remote rpc.ServerError
+	if call.Error != nil && !errors.As(call.Error, &remote) {
+		p.drop(client)
+		return fmt.Errorf("plugin %s stopped during the call; it is restarted on the next one", p.name)
+	}
+	return call.Error
+}
+
+// drop forgets client, whose connection broke, and stops its process, so
+// the next call starts the plugin again.
+func (p *process) drop(client *rpc.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != client {
+		return
+	}
+	p.cmd.Process.Kill()
+	p.client.Close()
+	p.cmd, p.client = nil, nil
+}
+
+// Close asks the plugin to exit by closing its input, and kills it if it
+// does not.
+func (p *process) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.cmd == nil {
+		return nil
+	}
+	p.stdin.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(stopTimeout):
+		p.cmd.Process.Kill()
+		<-p.exited
+	}
+	p.client.Close()
+	p.cmd, p.client = nil, nil
+	return nil
+}
+
+// pluginEnv returns the environment a plugin named name runs with.
+func pluginEnv(name string) []string {
+	env := []string{handshakeKey + "=" + handshakeValue}
+	for _, key := range passedEnv {
+		if v, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+v)
+		}
+	}
+	prefix := strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// pluginTool adapts a tool living in a plugin to tool.InvokableTool.
+type pluginTool struct {
+	process *process
+	info    *schema.ToolInfo
+	name    string // Name as known by the plugin.
+}
+
+func newPluginTool(p *process, spec ToolSpec) (*pluginTool, error) {
+	if !validName.MatchString(spec.Name) {
+		return nil, fmt.Errorf("plugin %s: invalid tool name %q", p.name, spec.Name)
+	}
+	js := &jsonschema.Schema{Type: "object"}
+	if len(spec.Parameters) > 0 && string(spec.Parameters) != "null" {
+		if err := json.Unmarshal(spec.Parameters, js); err != nil {
+			return nil, fmt.Errorf("failed to decode schema of %s/%s: %w", p.name, spec.Name, err)
+		}
+	}
+	return &pluginTool{
+		process: p,
+		name:    spec.Name,
+		info: &schema.ToolInfo{
+			Name:        p.name + "__" + spec.Name,
+			Desc:        spec.Description,
+			ParamsOneOf: schema.NewParamsOneOfByJSONSchema(js),
+		},
+	}, nil
+}
+
+func (t *pluginTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+// InvokableRun forwards the call. Errors of the tool or the plugin are
+// returned in an "error" field, like the built-in tools do, so the model can
+// react; a canceled call returns the context's error.
+func (t *pluginTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	var reply InvokeReply
+	err := t.process.call(ctx, serviceName+".Invoke", InvokeArgs{Name: t.name, Arguments: argumentsInJSON}, &reply)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err != nil {
+		return errorPayload(fmt.Sprintf("plugin '%s' call failed: %v", t.process.name, err)), nil
+	}
+	if reply.Error != "" {
+		return errorPayload(reply.Error), nil
+	}
+	return reply.Output, nil
+}
+
+func errorPayload(msg string) string {
+	b, _ := json.Marshal(map[string]string{"error": msg})
+	return string(b)
+}
+
+// pipe joins a plugin's stdout and stdin into one connection. Closing it
+// closes only the output; process.Close closes the plugin's input first, so
+// the plugin can exit on its own.
+type pipe struct {
+	io.ReadCloser
+	io.Writer
+}
+
+// stderrLog logs what a plugin writes to stderr, a line at a time.
+type stderrLog struct {
+	log    *slog.Logger
+	plugin string
+	buf    []byte
+}
+
+func (w *stderrLog) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.buf[:i])); line != "" {
+			w.log.Info("plugin output", "plugin", w.plugin, "line", line)
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+var _ tool.InvokableTool = (*pluginTool)(nil)
@@ -0,0 +1,143 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// TestMain turns the test binary into a plugin when the tests start it as
+// one.
+func TestMain(m *testing.M) {
+	if os.Getenv("TESTPLUGIN_MODE") == "serve" {
+		servePluginForTest()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func servePluginForTest() {
+	type noArgs struct {
+		Reason string `json:"reason,omitempty"`
+	}
+	type echoRequest struct {
+		Text string `json:"text" jsonschema:"description=Text to echo back"`
+	}
+	echo, err := utils.InferTool("echo", "Echo the text back, with the environment the plugin sees.",
+		func(ctx context.Context, req echoRequest) (map[string]string, error) {
+			fmt.Println("this goes to the agent's log, not the protocol")
+			return map[string]string{"text": req.Text, "secret": os.Getenv("AGENT_SECRET"), "own": os.Getenv("TESTPLUGIN_TOKEN")}, nil
+		})
+	if err != nil {
+		panic(err)
+	}
+	crash, err := utils.InferTool("crash", "Exit the plugin.", func(ctx context.Context, _ noArgs) (string, error) {
+		os.Exit(3)
+		return "", nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	fail, err := utils.InferTool("fail", "Return an error.", func(ctx context.Context, _ noArgs) (string, error) {
+		return "", fmt.Errorf("the remote service said no")
+	})
+	if err != nil {
+		panic(err)
+	}
+	Serve(echo, crash, fail)
+}
+
+func TestLoad(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(exe, filepath.Join(dir, "testplugin")); err != nil {
+		t.Skip("cannot link the test binary:", err)
+	}
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a plugin"), 0o644)
+	t.Setenv("TESTPLUGIN_MODE", "serve")
+	t.Setenv("TESTPLUGIN_TOKEN", "mine")
+	t.Setenv("AGENT_SECRET", "not for plugins")
+
+	ctx := context.Background()
+	loaded, closePlugins, err := Load(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closePlugins()
+	if len(loaded) != 3 {
+		t.Fatalf("loaded %d tools, want 3", len(loaded))
+	}
+	byName := make(map[string]tool.InvokableTool)
+	for _, bt := range loaded {
+		info, _ := bt.Info(ctx)
+		byName[info.Name] = bt.(tool.InvokableTool)
+	}
+	echo := byName["testplugin__echo"]
+	if echo == nil {
+		t.Fatalf("no testplugin__echo among %v", byName)
+	}
+	info, _ := echo.Info(ctx)
+	params, err := info.ParamsOneOf.ToJSONSchema()
+	if err != nil || params.Properties == nil {
+		t.Fatalf("echo parameters = %+v, %v", params, err)
+	}
+	if prop, ok := params.Properties.Get("text"); !ok || prop.Description != "Text to echo back" {
+		t.Errorf("echo text parameter = %+v", prop)
+	}
+
+	run := func(tl tool.InvokableTool, args string) map[string]string {
+		t.Helper()
+		out, err := tl.InvokableRun(ctx, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resp map[string]string
+		if err := json.Unmarshal([]byte(out), &resp); err != nil {
+			t.Fatalf("%v: %s", err, out)
+		}
+		return resp
+	}
+	want := map[string]string{"text": "hi", "secret": "", "own": "mine"}
+	if resp := run(echo, `{"text":"hi"}`); fmt.Sprint(resp) != fmt.Sprint(want) {
+		t.Errorf("echo = %v, want %v", resp, want)
+	}
+	if resp := run(byName["testplugin__fail"], `{}`); !strings.Contains(resp["error"], "the remote service said no") {
+		t.Errorf("fail = %v", resp)
+	}
+
+	// A crash is reported to the model, and the next call starts the plugin
+	// again.
+	if resp := run(byName["testplugin__crash"], `{}`); !strings.Contains(resp["error"], "stopped during the call") {
+		t.Errorf("crash = %v", resp)
+	}
+	if resp := run(echo, `{"text":"again"}`); resp["text"] != "again" {
+		t.Errorf("echo after a crash = %v", resp)
+	}
+
+	if err := closePlugins(); err != nil {
+		t.Fatal(err)
+	}
+	if resp := run(echo, `{"text":"late"}`); !strings.Contains(resp["error"], "stopped") {
+		t.Errorf("echo after close = %v", resp)
+	}
+}
+
+func TestLoadMissingDir(t *testing.T) {
+	loaded, closePlugins, err := Load(context.Background(), filepath.Join(t.TempDir(), "missing"))
+	if err != nil || loaded != nil {
+		t.Fatalf("Load = %v, %v", loaded, err)
+	}
+	if err := closePlugins(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Serve runs the current binary as a plugin offering tools, answering the
+// agent over stdin and stdout until it closes them. Call it from main.
+// Anything the tools print to os.Stdout is sent to stderr instead, which the
+// agent logs, so it cannot corrupt the protocol.
+//
+// Run by hand rather than by the agent, Serve explains that and exits.
+func Serve(tools ...tool.InvokableTool) {
+	if os.Getenv(handshakeKey) != handshakeValue {
+		fmt.Fprintln(os.Stderr, "This binary is a goforai tool plugin. Put it in the agent's plugin directory (PLUGIN_DIR) instead of running it.")
+		os.Exit(1)
+	}
+	conn := stdio{Reader: os.Stdin, Writer: os.Stdout}
+	os.Stdout = os.Stderr
+	if err := serve(conn, tools); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// serve answers requests on conn until it is closed.
+func serve(conn io.ReadWriteCloser, tools []tool.InvokableTool) error {
+	svc := &service{tools: make(map[string]tool.InvokableTool, len(tools))}
+	for _, t := range tools {
+		info, err := t.Info(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to describe a tool: %w", err)
+		}
+		params, err := info.ParamsOneOf.ToJSONSchema()
+		if err != nil {
+			return fmt.Errorf("failed to describe %s: %w", info.Name, err)
+		}
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to describe %s: %w", info.Name, err)
+		}
+		svc.specs = append(svc.specs, ToolSpec{Name: info.Name, Description: info.Desc, Parameters: raw})
+		svc.tools[info.Name] = t
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName(serviceName, svc); err != nil {
+		return err
+	}
+	server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	return nil
+}
+
+// service is the RPC receiver a plugin registers.
+type service struct {
+	specs []ToolSpec
+	tools map[string]tool.InvokableTool
+}
+
+func (s *service) Tools(_ struct{}, reply *[]ToolSpec) error {
+	*reply = s.specs
+	return nil
+}
+
+func (s *service) Invoke(args InvokeArgs, reply *InvokeReply) error {
+	t, ok := s.tools[args.Name]
+	if !ok {
+		return fmt.Errorf("no tool named %q", args.Name)
+	}
+	out, err := t.InvokableRun(context.Background(), args.Arguments)
+	if err != nil {
+		reply.Error = err.Error()
+		return nil
+	}
+	reply.Output = out
+	return nil
+}
+
+// stdio joins the process's standard input and output into one connection.
+type stdio struct {
+	io.Reader
+	io.Writer
+}
+
+func (stdio) Close() error {
+	return nil
+}
@@ -0,0 +1,250 @@
+// Package policy decides which tool calls the agent may make. Rules in a JSON
+// file allow or deny calls by tool name, path prefix, URL domain or command,
+// and are checked before every call. A denied call does not run: the model
+// receives a structured error naming the rule, so it can change its plan
+// instead of ending the turn.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/forward"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// Effects of a rule.
+const (
+	Allow = "allow"
+	Deny  = "deny"
+)
+
+// Rule matches tool calls. Every condition that is set must hold for the rule
+// to match; a rule with no condition matches every call.
+type Rule struct {
+	Effect string `json:"effect"`
+	// Tool is a tool name, or a pattern such as "github__*".
+	Tool string `json:"tool,omitempty"`
+	// Path matches calls with a path argument at or below this path, relative
+	// to the workspace.
+	Path string `json:"path,omitempty"`
+	// Domain matches calls with a URL argument on this domain or one of its
+	// subdomains.
+	Domain string `json:"domain,omitempty"`
+	// Command matches calls with a command argument starting with this text.
+	Command string `json:"command,omitempty"`
+	// Reason is shown to the model when the rule denies a call.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Policy is the content of the rules file. Rules are checked in order and the
+// first match decides; calls no rule matches get Default, allow when empty.
+type Policy struct {
+	Default string `json:"default,omitempty"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Load reads the rules file at path. A missing file yields a nil *Policy,
+// which allows every call.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy %s: %w", path, err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// Validate reports the first malformed rule.
+func (p *Policy) Validate() error {
+	if p.Default != "" && p.Default != Allow && p.Default != Deny {
+		return fmt.Errorf("default must be %q or %q, got %q", Allow, Deny, p.Default)
+	}
+	for i, r := range p.Rules {
+		if r.Effect != Allow && r.Effect != Deny {
+			return fmt.Errorf("rule %d: effect must be %q or %q, got %q", i+1, Allow, Deny, r.Effect)
+		}
+		if _, err := path.Match(r.Tool, ""); err != nil {
+			return fmt.Errorf("rule %d: invalid tool pattern %q", i+1, r.Tool)
+		}
+	}
+	return nil
+}
+
+// Decision is the outcome of checking a call against a policy.
+type Decision struct {
+	Effect string `json:"effect"`
+	Rule   int    `json:"rule,omitempty"` // 1-based index of the deciding rule; 0 for the default.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Decide checks a call of the named tool. A nil *Policy allows every call.
+func (p *Policy) Decide(ctx context.Context, toolName, argumentsInJSON string) Decision {
+	if p == nil {
+		return Decision{Effect: Allow}
+	}
+	args := parseArguments(argumentsInJSON)
+	for i, r := range p.Rules {
+		if r.matches(ctx, toolName, args) {
+			return Decision{Effect: r.Effect, Rule: i + 1, Reason: r.Reason}
+		}
+	}
+	if p.Default == Deny {
+		return Decision{Effect: Deny, Reason: "no policy rule allows this call"}
+	}
+	return Decision{Effect: Allow}
+}
+
+func (r Rule) matches(ctx context.Context, toolName string, args arguments) bool {
+	if r.Tool != "" {
+		if ok, _ := path.Match(r.Tool, toolName); !ok {
+			return false
+		}
+	}
+	if r.Path != "" && !anyOf(args.paths, func(p string) bool { return underPath(ctx, p, r.Path) }) {
+		return false
+	}
+	if r.Domain != "" && !anyOf(args.hosts, func(h string) bool { return onDomain(h, r.Domain) }) {
+		return false
+	}
+	if r.Command != "" && !anyOf(args.commands, func(c string) bool { return strings.HasPrefix(c, r.Command) }) {
+		return false
+	}
+	return true
+}
+
+func anyOf(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// underPath reports whether p is prefix or lies below it, both resolved
+// against the workspace of ctx.
+func underPath(ctx context.Context, p, prefix string) bool {
+	resolve := func(p string) string {
+		if resolved, err := tools.ResolvePath(ctx, p); err == nil {
+			p = resolved
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		return p
+	}
+	rel, err := filepath.Rel(resolve(prefix), resolve(p))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func onDomain(host, domain string) bool {
+	host, domain = strings.ToLower(host), strings.ToLower(strings.TrimPrefix(domain, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// Argument names the conditions of a rule look at.
+var (
+	pathKeys    = map[string]bool{"path": true, "file": true, "file_path": true, "filepath": true, "dir": true, "directory": true}
+	commandKeys = map[string]bool{"command": true, "cmd": true}
+	domainKeys  = map[string]bool{"site": true, "domain": true}
+)
+
+// arguments are the values of a call that rules can match.
+type arguments struct {
+	paths    []string
+	hosts    []string
+	commands []string
+}
+
+// parseArguments collects the paths, URL hosts and commands among the
+// arguments of a call, at any depth.
+func parseArguments(argumentsInJSON string) arguments {
+	var args arguments
+	var v any
+	if json.Unmarshal([]byte(argumentsInJSON), &v) != nil {
+		return args
+	}
+	var visit func(key string, v any)
+	visit = func(key string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, value := range v {
+				visit(strings.ToLower(k), value)
+			}
+		case []any:
+			for _, value := range v {
+				visit(key, value)
+			}
+		case string:
+			switch {
+			case pathKeys[key]:
+				args.paths = append(args.paths, v)
+			case commandKeys[key]:
+				args.commands = append(args.commands, strings.TrimSpace(v))
+			case domainKeys[key]:
+				args.hosts = append(args.hosts, v)
+			default:
+				if u, err := url.Parse(v); err == nil && u.Hostname() != "" {
+					args.hosts = append(args.hosts, u.Hostname())
+				}
+			}
+		}
+	}
+	visit("", v)
+	return args
+}
+
+// Wrap returns t with every call checked against p first. Tools that are not
+// invokable, and every tool when p is nil, are returned unchanged.
+func (p *Policy) Wrap(t tool.BaseTool) tool.BaseTool {
+	inner, ok := t.(tool.InvokableTool)
+	if p == nil || !ok {
+		return t
+	}
+	return &guardedTool{Tool: forward.Tool{InvokableTool: inner}, policy: p}
+}
+
+type guardedTool struct {
+	forward.Tool
+	policy *Policy
+}
+
+func (t *guardedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+	decision := t.policy.Decide(ctx, info.Name, argumentsInJSON)
+	if decision.Effect == Allow {
+		return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	}
+
+	// A denial is returned in the envelope of every other failure, so the
+	// model handles it the same way.
+	msg := "this call is not allowed by the tool policy"
+	if decision.Rule > 0 {
+		msg += fmt.Sprintf(" (rule %d)", decision.Rule)
+	}
+	if decision.Reason != "" {
+		msg += ": " + decision.Reason
+	}
+	return tools.FailureResult(info.Name, tools.NewToolError(tools.FailureDenied, msg)), nil
+}
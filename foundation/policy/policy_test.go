@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+const rules = `{
+  "rules": [
+    {"effect": "allow", "tool": "edit_go_file", "path": "repos/app/scratch"},
+    {"effect": "deny", "tool": "edit_go_file", "path": "repos/app", "reason": "repos/app is read-only"},
+    {"effect": "deny", "domain": "example.com", "reason": "no example.com"},
+    {"effect": "deny", "tool": "shell__*", "command": "rm ", "reason": "no deleting"}
+  ]
+}`
+
+func TestDecide(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := tools.WithWorkspace(context.Background(), t.TempDir())
+
+	for _, c := range []struct {
+		tool, args string
+		effect     string
+		rule       int
+	}{
+		{"edit_go_file", `{"file_path": "repos/app/main.go"}`, Deny, 2},
+		{"edit_go_file", `{"file_path": "repos/app/../app/main.go"}`, Deny, 2},
+		{"edit_go_file", `{"file_path": "repos/app/scratch/x.go"}`, Allow, 1},
+		{"edit_go_file", `{"file_path": "repos/application/main.go"}`, Allow, 0},
+		{"read_file", `{"path": "repos/app/main.go"}`, Allow, 0},
+		{"gitclone", `{"repo_url": "https://git.example.com/x/y"}`, Deny, 3},
+		{"search_internet", `{"query": "go", "site": "example.com"}`, Deny, 3},
+		{"gitclone", `{"repo_url": "https://github.com/example.com"}`, Allow, 0},
+		{"shell__run", `{"command": "  rm -rf /"}`, Deny, 4},
+		{"shell__run", `{"command": "ls"}`, Allow, 0},
+		{"other__run", `{"command": "rm -rf /"}`, Allow, 0},
+		{"edit_go_file", `not json`, Allow, 0},
+	} {
+		d := p.Decide(ctx, c.tool, c.args)
+		if d.Effect != c.effect || d.Rule != c.rule {
+			t.Errorf("Decide(%s, %s) = %+v, want %s by rule %d", c.tool, c.args, d, c.effect, c.rule)
+		}
+	}
+
+	strict := &Policy{Default: Deny, Rules: []Rule{{Effect: Allow, Tool: "read_file"}}}
+	if d := strict.Decide(ctx, "gitclone", `{}`); d.Effect != Deny || d.Reason == "" {
+		t.Errorf("default deny = %+v", d)
+	}
+	if d := (*Policy)(nil).Decide(ctx, "gitclone", `{}`); d.Effect != Allow {
+		t.Errorf("nil policy = %+v", d)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if p, err := Load(filepath.Join(dir, "missing.json")); p != nil || err != nil {
+		t.Errorf("Load(missing) = %v, %v", p, err)
+	}
+	for _, bad := range []string{`{"rules": [{"effect": "maybe"}]}`, `{"default": "ask"}`, `{"rules": [{"effect": "deny", "tool": "["}]}`, `{`} {
+		path := filepath.Join(dir, "bad.json")
+		os.WriteFile(path, []byte(bad), 0o644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load accepted %s", bad)
+		}
+	}
+}
+
+type cloneRequest struct {
+	RepoURL string `json:"repo_url"`
+}
+
+func TestWrap(t *testing.T) {
+	ran := 0
+	inner, err := utils.InferTool("gitclone", "Clone.", func(ctx context.Context, req *cloneRequest) (string, error) {
+		ran++
+		return "cloned", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Policy{Rules: []Rule{{Effect: Deny, Domain: "example.com", Reason: "no example.com"}}}
+	guarded := p.Wrap(inner).(tool.InvokableTool)
+
+	out, err := guarded.InvokableRun(context.Background(), `{"repo_url": "https://example.com/x/y"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var denied struct {
+		Error tools.ToolError `json:"error"`
+		Hint  tools.Hint      `json:"hint"`
+	}
+	if err := json.Unmarshal([]byte(out), &denied); err != nil {
+		t.Fatal(err)
+	}
+	if ran != 0 || denied.Error.Code != tools.FailureDenied || denied.Error.Retryable ||
+		!strings.Contains(denied.Error.Message, "(rule 1): no example.com") || denied.Hint.Suggestion == "" {
+		t.Errorf("denied call ran %d times and returned %s", ran, out)
+	}
+
+	if out, err := guarded.InvokableRun(context.Background(), `{"repo_url": "https://github.com/x/y"}`); err != nil || ran != 1 || !strings.Contains(out, "cloned") {
+		t.Errorf("allowed call = %s, %v", out, err)
+	}
+	if (*Policy)(nil).Wrap(inner) != inner {
+		t.Error("a nil policy wraps tools")
+	}
+}
@@ -0,0 +1,185 @@
+// Package postprocess rewrites finished answers before they are shown and
+// kept in the conversation: it strips the model's leftover reasoning, keeps
+// answers in the language asked for, cites the documents retrieved and turns
+// Markdown links into plain ones. The output policies live here, in one
+// pipeline configured by config.Postprocess, instead of in the code that
+// reads the model's stream.
+package postprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/language"
+)
+
+// Answer is a finished answer on its way through a Pipeline.
+type Answer struct {
+	Question string
+	Text     string
+	Sources  []string // Documents retrieved to write the answer, such as "talks.md".
+}
+
+// Processor rewrites an answer in place.
+type Processor interface {
+	Name() string
+	Process(ctx context.Context, answer *Answer) error
+}
+
+// Pipeline runs processors in order.
+type Pipeline []Processor
+
+// New returns the pipeline cfg configures. translator writes translations
+// for the language step; New fails when that step is configured without one.
+func New(cfg config.Postprocess, translator *language.Translator) (Pipeline, error) {
+	var p Pipeline
+	for _, step := range cfg.Steps {
+		switch step {
+		case config.PostprocessStripThinking:
+			p = append(p, StripThinking())
+		case config.PostprocessLanguage:
+			if translator == nil {
+				return nil, errors.New("the language post-processor needs a chat model to translate with")
+			}
+			p = append(p, Language(cfg.Language, translator))
+		case config.PostprocessCitations:
+			p = append(p, Citations())
+		case config.PostprocessLinks:
+			p = append(p, Links())
+		default:
+			return nil, fmt.Errorf("unknown post-processor %q", step)
+		}
+	}
+	return p, nil
+}
+
+// Process runs every processor on answer. It stops at the first that fails,
+// leaving answer as the processors before it left it.
+func (p Pipeline) Process(ctx context.Context, answer *Answer) error {
+	for _, proc := range p {
+		if err := proc.Process(ctx, answer); err != nil {
+			return fmt.Errorf("%s post-processor failed: %w", proc.Name(), err)
+		}
+	}
+	return nil
+}
+
+// processor adapts a function to Processor.
+type processor struct {
+	name    string
+	process func(ctx context.Context, answer *Answer) error
+}
+
+func (p processor) Name() string { return p.name }
+
+func (p processor) Process(ctx context.Context, answer *Answer) error {
+	return p.process(ctx, answer)
+}
+
+var (
+	// thinkingBlocks match the reasoning some models write before answering.
+	thinkingBlocks = []*regexp.Regexp{
+		regexp.MustCompile(`(?is)<think>.*?</think>`),
+		regexp.MustCompile(`(?is)<thinking>.*?</thinking>`),
+		regexp.MustCompile(`(?is)<reasoning>.*?</reasoning>`),
+	}
+	// thinkingTags match the tags of blocks left open or closed without
+	// being opened, when a stream was cut or a model slipped.
+	thinkingTags = regexp.MustCompile(`(?i)</?(think|thinking|reasoning)>`)
+	blankLines   = regexp.MustCompile(`\n{3,}`)
+)
+
+// StripThinking removes the model's reasoning blocks and stray reasoning
+// tags, so they are neither shown as the answer nor sent back to the model
+// in later turns.
+func StripThinking() Processor {
+	return processor{name: config.PostprocessStripThinking, process: func(_ context.Context, answer *Answer) error {
+		text := answer.Text
+		for _, re := range thinkingBlocks {
+			text = re.ReplaceAllString(text, "")
+		}
+		text = thinkingTags.ReplaceAllString(text, "")
+		answer.Text = strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+		return nil
+	}}
+}
+
+// Language translates answers that are not in target, a language code such
+// as "en", or with target "question" not in the language of the question.
+// Answers and questions whose language cannot be detected are left alone.
+func Language(target string, translator *language.Translator) Processor {
+	return processor{name: config.PostprocessLanguage, process: func(ctx context.Context, answer *Answer) error {
+		want := target
+		if want == "question" {
+			want = language.Detect(answer.Question)
+		}
+		got := language.Detect(answer.Text)
+		if want == "" || got == "" || got == want {
+			return nil
+		}
+		translation, err := translator.Translate(ctx, answer.Text, want)
+		if err != nil {
+			return err
+		}
+		answer.Text = translation
+		return nil
+	}}
+}
+
+// Citations appends the sources of the answer that it does not already
+// mention, one per line, under "Sources:".
+func Citations() Processor {
+	return processor{name: config.PostprocessCitations, process: func(_ context.Context, answer *Answer) error {
+		seen := make(map[string]bool)
+		var cite []string
+		for _, s := range answer.Sources {
+			if s == "" || seen[s] || strings.Contains(answer.Text, s) {
+				continue
+			}
+			seen[s] = true
+			cite = append(cite, "- "+s)
+		}
+		if len(cite) > 0 {
+			answer.Text = strings.TrimRight(answer.Text, "\n") + "\n\nSources:\n" + strings.Join(cite, "\n")
+		}
+		return nil
+	}}
+}
+
+var (
+	markdownLink = regexp.MustCompile(`\[([^\]\n]+)\]\((https?://[^)\s]+)\)`)
+	autolink     = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+)
+
+// Links rewrites Markdown links as "text (url)", and as the bare URL when
+// the text is the URL, so terminals that do not render Markdown show where
+// they point. Code blocks are left alone.
+func Links() Processor {
+	return processor{name: config.PostprocessLinks, process: func(_ context.Context, answer *Answer) error {
+		answer.Text = outsideCode(answer.Text, func(text string) string {
+			text = markdownLink.ReplaceAllStringFunc(text, func(link string) string {
+				m := markdownLink.FindStringSubmatch(link)
+				if m[1] == m[2] {
+					return m[2]
+				}
+				return m[1] + " (" + m[2] + ")"
+			})
+			return autolink.ReplaceAllString(text, "$1")
+		})
+		return nil
+	}}
+}
+
+// outsideCode applies rewrite to the parts of text outside fenced code
+// blocks.
+func outsideCode(text string, rewrite func(string) string) string {
+	parts := strings.Split(text, "```")
+	for i := 0; i < len(parts); i += 2 {
+		parts[i] = rewrite(parts[i])
+	}
+	return strings.Join(parts, "```")
+}
@@ -0,0 +1,86 @@
+package postprocess
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/language"
+)
+
+// translatorModel answers every translation request with reply.
+type translatorModel struct {
+	reply string
+	calls int
+}
+
+func (m *translatorModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.calls++
+	if m.reply == "" {
+		return nil, errors.New("quota exceeded")
+	}
+	return schema.AssistantMessage(m.reply, nil), nil
+}
+
+func (m *translatorModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+	p, err := New(config.Postprocess{Steps: []string{"strip_thinking", "citations", "links"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer := &Answer{
+		Question: "Where is the venue?",
+		Text:     "<think>The user wants the venue.</think>\n\nThe venue is the [conference centre](https://example.com/venue), see venue.md.\n\n\n\n```\n[x](https://example.com/code)\n```\n</think>",
+		Sources:  []string{"venue.md", "faq.md", "faq.md", "schedule.md"},
+	}
+	if err := p.Process(ctx, answer); err != nil {
+		t.Fatal(err)
+	}
+	want := "The venue is the conference centre (https://example.com/venue), see venue.md.\n\n```\n[x](https://example.com/code)\n```\n\nSources:\n- faq.md\n- schedule.md"
+	if answer.Text != want {
+		t.Errorf("processed answer:\n%s\nwant:\n%s", answer.Text, want)
+	}
+
+	if _, err := New(config.Postprocess{Steps: []string{"language"}}, nil); err == nil {
+		t.Error("a language step without a translator was accepted")
+	}
+	if _, err := New(config.Postprocess{Steps: []string{"shout"}}, nil); err == nil {
+		t.Error("an unknown step was accepted")
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	ctx := context.Background()
+	m := &translatorModel{reply: "La salle est au premier étage."}
+	p := Pipeline{Language("question", language.NewTranslator(m))}
+
+	answer := &Answer{Question: "Où est la salle de la conférence ?", Text: "The room is on the first floor of the building."}
+	if err := p.Process(ctx, answer); err != nil {
+		t.Fatal(err)
+	}
+	if answer.Text != m.reply {
+		t.Errorf("answer = %q, want the translation", answer.Text)
+	}
+
+	same := &Answer{Question: "Where is the room of the talk?", Text: "The room is on the first floor of the building."}
+	if err := p.Process(ctx, same); err != nil || m.calls != 1 {
+		t.Errorf("an answer in the question's language was translated: %v, %d calls", err, m.calls)
+	}
+
+	m.reply = ""
+	failed := &Answer{Question: "Où est la salle de la conférence ?", Text: "The room is on the first floor of the building."}
+	if err := p.Process(ctx, failed); err == nil || !strings.Contains(err.Error(), "language post-processor failed") {
+		t.Errorf("err = %v", err)
+	}
+	if failed.Text != "The room is on the first floor of the building." {
+		t.Errorf("a failed translation changed the answer to %q", failed.Text)
+	}
+}
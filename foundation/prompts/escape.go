@@ -0,0 +1,54 @@
+package prompts
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Escape makes s, text a user controls, safe to fill in a template variable.
+// It drops the control characters a terminal paste can carry, keeping tabs
+// and newlines, and defuses the tags, such as <question> and </question>,
+// that templates put around variables: a pasted "</question>" cannot close
+// its section and open another. Tags are matched by name and case
+// insensitively, and written as &lt;question&gt; instead.
+//
+// Braces are kept as they are. Render and Eino's FString templates both fill
+// variables in a single pass, so a pasted "map[string]int{}" or "{question}"
+// stays literal text rather than becoming a placeholder.
+func Escape(s string, tags ...string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, s)
+	if len(tags) == 0 || !strings.Contains(s, "<") {
+		return s
+	}
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = regexp.QuoteMeta(tag)
+	}
+	tag := regexp.MustCompile(`(?i)<(\s*/?\s*(?:` + strings.Join(names, "|") + `)\s*)>`)
+	return tag.ReplaceAllString(s, "&lt;$1&gt;")
+}
+
+// EscapeVars returns a copy of vars, the variables of an Eino template, with
+// every string value escaped against the tags named after the variables.
+// Other values, such as a history of messages, are kept as they are.
+func EscapeVars(vars map[string]any) map[string]any {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	out := make(map[string]any, len(vars))
+	for name, v := range vars {
+		if s, ok := v.(string); ok {
+			v = Escape(s, names...)
+		}
+		out[name] = v
+	}
+	return out
+}
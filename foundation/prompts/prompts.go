@@ -0,0 +1,317 @@
+// Package prompts keeps the prompt templates of the agent and the tutorial
+// steps in one library instead of string constants spread across packages.
+//
+// Each template is a markdown file with YAML front matter naming it, giving
+// its version and declaring its variables:
+//
+//	---
+//	name: step5.compact
+//	version: 2
+//	description: Summarizes a conversation for /compact.
+//	variables: []
+//	---
+//	Summarize the conversation below...
+//
+// Variables are written {name}, as in Eino's FString templates; {{ and }}
+// stand for literal braces. Templates that take user input put each such
+// variable between tags named after it, <question>{question}</question>,
+// and fill it with Escape so the input cannot break out of them. The built-in templates are compiled in. Files in
+// the prompts directory (config.PromptsDir) add new templates or versions,
+// or replace a built-in version of the same name and number. Get returns
+// the latest version unless config.PromptVersions pins another.
+package prompts
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/olusolaa/goforai/foundation/config"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed templates/*.md
+var builtin embed.FS
+
+// ErrNotFound is returned for a template name or version the library does
+// not have.
+var ErrNotFound = errors.New("prompt template not found")
+
+// placeholder matches a variable, or an escaped brace.
+var placeholder = regexp.MustCompile(`\{\{|\}\}|\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Template is one version of a named prompt.
+type Template struct {
+	Name        string   `yaml:"name"`
+	Version     int      `yaml:"version"`
+	Description string   `yaml:"description"`
+	Variables   []string `yaml:"variables"`
+	Text        string   `yaml:"-"` // The body, with its {variables} unfilled.
+	Source      string   `yaml:"-"` // File the template was read from.
+}
+
+// Render fills in the template's variables, each escaped as Escape does
+// against tags named after the template's variables. Every declared variable
+// must be given, and nothing else.
+func (t *Template) Render(vars map[string]any) (string, error) {
+	for _, name := range t.Variables {
+		if _, ok := vars[name]; !ok {
+			return "", fmt.Errorf("prompt %s v%d: missing variable %q", t.Name, t.Version, name)
+		}
+	}
+	for name := range vars {
+		if !slices.Contains(t.Variables, name) {
+			return "", fmt.Errorf("prompt %s v%d: unknown variable %q", t.Name, t.Version, name)
+		}
+	}
+	return placeholder.ReplaceAllStringFunc(t.Text, func(m string) string {
+		switch m {
+		case "{{":
+			return "{"
+		case "}}":
+			return "}"
+		}
+		return Escape(fmt.Sprint(vars[m[1:len(m)-1]]), t.Variables...)
+	}), nil
+}
+
+// Expect checks that the template declares exactly vars. Callers that hand
+// Text to an Eino template, which fills the variables itself, use it to
+// fail at startup rather than on the first message.
+func (t *Template) Expect(vars ...string) error {
+	declared := slices.Clone(t.Variables)
+	want := slices.Clone(vars)
+	slices.Sort(declared)
+	slices.Sort(want)
+	if !slices.Equal(declared, want) {
+		return fmt.Errorf("prompt %s v%d declares variables %v, but %v are provided", t.Name, t.Version, declared, want)
+	}
+	return nil
+}
+
+// Library holds every version of every template it was loaded with.
+type Library struct {
+	versions map[string][]*Template // Sorted by version.
+	pins     map[string]int
+}
+
+// Load returns the built-in templates together with those in dir. A missing
+// directory adds nothing.
+func Load(dir string) (*Library, error) {
+	lib := &Library{versions: make(map[string][]*Template), pins: make(map[string]int)}
+	if err := lib.addFS(builtin, "templates", "", false); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if _, err := os.Stat(dir); err == nil {
+			if err := lib.addFS(os.DirFS(dir), ".", dir, true); err != nil {
+				return nil, err
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read prompts directory %s: %w", dir, err)
+		}
+	}
+	return lib, nil
+}
+
+// FromConfig loads the library from config.PromptsDir with the versions
+// pinned by config.PromptVersions.
+func FromConfig() (*Library, error) {
+	lib, err := Load(config.PromptsDir())
+	if err != nil {
+		return nil, err
+	}
+	pins, err := config.PromptVersions()
+	if err != nil {
+		return nil, err
+	}
+	return lib.Pin(pins)
+}
+
+var defaultLibrary = sync.OnceValues(FromConfig)
+
+// Default returns the library FromConfig returned on its first call.
+func Default() (*Library, error) {
+	return defaultLibrary()
+}
+
+// Get returns the template name from the Default library.
+func Get(name string) (*Template, error) {
+	lib, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return lib.Get(name)
+}
+
+type libraryKey struct{}
+
+// WithLibrary returns a context whose prompts come from lib instead of the
+// Default library, as when an experiment compares two prompt versions.
+func WithLibrary(ctx context.Context, lib *Library) context.Context {
+	return context.WithValue(ctx, libraryKey{}, lib)
+}
+
+// FromContext returns the library set by WithLibrary, or the Default one.
+func FromContext(ctx context.Context) (*Library, error) {
+	if lib, ok := ctx.Value(libraryKey{}).(*Library); ok {
+		return lib, nil
+	}
+	return Default()
+}
+
+// Get returns the pinned version of the template name, or its latest.
+func (l *Library) Get(name string) (*Template, error) {
+	if v, ok := l.pins[name]; ok {
+		return l.Version(name, v)
+	}
+	versions := l.versions[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Version returns the given version of the template name.
+func (l *Library) Version(name string, version int) (*Template, error) {
+	for _, t := range l.versions[name] {
+		if t.Version == version {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s v%d", ErrNotFound, name, version)
+}
+
+// Versions returns every version of the template name, oldest first.
+func (l *Library) Versions(name string) []*Template {
+	return slices.Clone(l.versions[name])
+}
+
+// Names returns the names of all templates, sorted.
+func (l *Library) Names() []string {
+	names := make([]string, 0, len(l.versions))
+	for name := range l.versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pin returns a copy of the library in which Get returns the given versions
+// of the named templates, on top of the versions already pinned.
+func (l *Library) Pin(versions map[string]int) (*Library, error) {
+	pinned := &Library{versions: l.versions, pins: make(map[string]int, len(l.pins)+len(versions))}
+	for name, v := range l.pins {
+		pinned.pins[name] = v
+	}
+	for name, v := range versions {
+		if _, err := l.Version(name, v); err != nil {
+			return nil, fmt.Errorf("cannot pin prompt: %w", err)
+		}
+		pinned.pins[name] = v
+	}
+	return pinned, nil
+}
+
+// addFS adds the templates in dir of fsys, which was opened at origin, or is
+// the built-in templates when origin is empty. Templates may replace
+// existing versions only when override is set.
+func (l *Library) addFS(fsys fs.FS, dir, origin string, override bool) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.md"))
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]string)
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt %s: %w", file, err)
+		}
+		t, err := parse(data)
+		if err != nil {
+			return fmt.Errorf("invalid prompt %s: %w", file, err)
+		}
+		t.Source = "built-in"
+		if origin != "" {
+			t.Source = filepath.Join(origin, file)
+		}
+		key := fmt.Sprintf("%s v%d", t.Name, t.Version)
+		if other, ok := seen[key]; ok {
+			return fmt.Errorf("prompts %s and %s are both %s", other, file, key)
+		}
+		seen[key] = file
+		l.add(t, override)
+	}
+	return nil
+}
+
+// add inserts t among the versions of its name.
+func (l *Library) add(t *Template, override bool) {
+	versions := l.versions[t.Name]
+	i, found := slices.BinarySearchFunc(versions, t.Version, func(e *Template, v int) int { return e.Version - v })
+	switch {
+	case found && override:
+		versions[i] = t
+	case !found:
+		versions = slices.Insert(versions, i, t)
+	}
+	l.versions[t.Name] = versions
+}
+
+// parse reads a template file and checks that its body uses exactly the
+// variables it declares.
+func parse(data []byte) (*Template, error) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	rest, ok := bytes.CutPrefix(data, []byte("---\n"))
+	if !ok {
+		return nil, errors.New("missing front matter")
+	}
+	front, body, ok := bytes.Cut(rest, []byte("\n---\n"))
+	if !ok {
+		return nil, errors.New("unterminated front matter")
+	}
+
+	var t Template
+	if err := yaml.Unmarshal(front, &t); err != nil {
+		return nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+	switch {
+	case t.Name == "":
+		return nil, errors.New("name is required")
+	case t.Version < 1:
+		return nil, errors.New("version must be 1 or more")
+	}
+	t.Text = strings.TrimSuffix(string(body), "\n")
+
+	if stray := placeholder.ReplaceAllString(t.Text, ""); strings.ContainsAny(stray, "{}") {
+		return nil, errors.New("unmatched brace; write {{ and }} for literal braces")
+	}
+	used := make(map[string]bool)
+	for _, m := range placeholder.FindAllStringSubmatch(t.Text, -1) {
+		if m[1] != "" {
+			used[m[1]] = true
+		}
+	}
+	for name := range used {
+		if !slices.Contains(t.Variables, name) {
+			return nil, fmt.Errorf("undeclared variable {%s}; list it under variables or write {{%s}} for literal braces", name, name)
+		}
+	}
+	for _, name := range t.Variables {
+		if !used[name] {
+			return nil, fmt.Errorf("variable %q is declared but not used", name)
+		}
+	}
+	return &t, nil
+}
@@ -0,0 +1,131 @@
+// Package ratelimit paces outbound calls to model providers. Every chat model
+// and embedder of a provider shares one process-wide token bucket, sized from
+// MODEL_RATE_LIMITS, so a burst of concurrent server sessions queues briefly
+// instead of tripping the provider's quota and failing every session with 429s.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/forward"
+	"golang.org/x/time/rate"
+)
+
+var limiters = struct {
+	sync.Mutex
+	loaded     bool
+	err        error
+	byProvider map[string]*rate.Limiter
+}{}
+
+// limiter returns the shared limiter of provider, or nil when the provider is
+// not limited.
+func limiter(provider string) (*rate.Limiter, error) {
+	limiters.Lock()
+	defer limiters.Unlock()
+	if !limiters.loaded {
+		limiters.loaded = true
+		limiters.byProvider = make(map[string]*rate.Limiter)
+		rpms, err := config.ModelRateLimits()
+		if err != nil {
+			limiters.err = err
+		}
+		for name, rpm := range rpms {
+			// A full minute's worth of calls may go out at once, as the
+			// provider quotas are per minute.
+			limiters.byProvider[name] = rate.NewLimiter(rate.Limit(float64(rpm)/60), rpm)
+		}
+	}
+	return limiters.byProvider[provider], limiters.err
+}
+
+// Wait blocks until provider may be called, or ctx is done.
+func Wait(ctx context.Context, provider string) error {
+	l, err := limiter(provider)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return nil
+	}
+	if err := l.Wait(ctx); err != nil {
+		return fmt.Errorf("waiting for the %s rate limit: %w", provider, err)
+	}
+	return nil
+}
+
+// reset forgets the limiters so the next call reloads the configuration.
+func reset() {
+	limiters.Lock()
+	defer limiters.Unlock()
+	limiters.loaded, limiters.err, limiters.byProvider = false, nil, nil
+}
+
+// ChatModel returns m with every Generate and Stream call paced by the
+// limiter of provider.
+func ChatModel(provider string, m model.ToolCallingChatModel) model.ToolCallingChatModel {
+	return &limitedModel{ChatModel: forward.ChatModel{ToolCallingChatModel: m}, provider: provider}
+}
+
+type limitedModel struct {
+	forward.ChatModel
+	provider string
+}
+
+func (m *limitedModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if err := Wait(ctx, m.provider); err != nil {
+		return nil, err
+	}
+	return m.ToolCallingChatModel.Generate(ctx, input, opts...)
+}
+
+func (m *limitedModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if err := Wait(ctx, m.provider); err != nil {
+		return nil, err
+	}
+	return m.ToolCallingChatModel.Stream(ctx, input, opts...)
+}
+
+func (m *limitedModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	inner, err := m.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &limitedModel{ChatModel: forward.ChatModel{ToolCallingChatModel: inner}, provider: m.provider}, nil
+}
+
+// Embedder returns e with every EmbedStrings call paced by the limiter of
+// provider.
+func Embedder(provider string, e embedding.Embedder) embedding.Embedder {
+	return &limitedEmbedder{provider: provider, inner: e}
+}
+
+type limitedEmbedder struct {
+	provider string
+	inner    embedding.Embedder
+}
+
+func (e *limitedEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	if err := Wait(ctx, e.provider); err != nil {
+		return nil, err
+	}
+	return e.inner.EmbedStrings(ctx, texts, opts...)
+}
+
+func (e *limitedEmbedder) GetType() string {
+	if t, ok := e.inner.(interface{ GetType() string }); ok {
+		return t.GetType()
+	}
+	return ""
+}
+
+func (e *limitedEmbedder) IsCallbacksEnabled() bool {
+	c, ok := e.inner.(interface{ IsCallbacksEnabled() bool })
+	return ok && c.IsCallbacksEnabled()
+}
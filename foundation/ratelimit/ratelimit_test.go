@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+type countingEmbedder struct{ calls int }
+
+func (e *countingEmbedder) EmbedStrings(ctx context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	e.calls++
+	return make([][]float64, len(texts)), nil
+}
+
+func TestLimitsAreSharedPerProvider(t *testing.T) {
+	t.Setenv("MODEL_RATE_LIMITS", "fake:1")
+	reset()
+	t.Cleanup(reset)
+
+	inner := &countingEmbedder{}
+	a, b := Embedder("fake", inner), Embedder("fake", inner)
+	if _, err := a.EmbedStrings(context.Background(), []string{"x"}); err != nil {
+		t.Fatal(err)
+	}
+	// The bucket is empty for the next minute, for every embedder of the
+	// provider.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := b.EmbedStrings(ctx, []string{"x"}); err == nil || inner.calls != 1 {
+		t.Errorf("second call = %v after %d calls, want a rate limit error", err, inner.calls)
+	}
+
+	other := Embedder("other", inner)
+	for range 5 {
+		if _, err := other.EmbedStrings(context.Background(), []string{"x"}); err != nil {
+			t.Fatalf("unlimited provider: %v", err)
+		}
+	}
+}
+
+func TestInvalidLimits(t *testing.T) {
+	t.Setenv("MODEL_RATE_LIMITS", "gemini=60")
+	reset()
+	t.Cleanup(reset)
+	if err := Wait(context.Background(), "gemini"); err == nil {
+		t.Error("invalid MODEL_RATE_LIMITS accepted")
+	}
+
+	t.Setenv("MODEL_RATE_LIMITS", "")
+	reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Wait(ctx, "gemini"); err != nil {
+		t.Errorf("Wait without limits = %v, want no wait at all", err)
+	}
+}
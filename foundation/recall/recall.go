@@ -0,0 +1,305 @@
+// Package recall indexes the conversations of stored sessions, so the agent
+// can look up what was asked, answered and decided in earlier sessions.
+//
+// Each exchange (a question and the answer to it) is one document of a
+// dedicated chromemdb collection, exported to its own file next to the
+// knowledge base. Exchanges of sessions that have an owner are only
+// returned to that client (chromemdb.WithIdentity).
+package recall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/embeddings"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/olusolaa/goforai/foundation/truncate"
+	"github.com/philippgille/chromem-go"
+)
+
+// Collection is the name of the collection of past conversations.
+const Collection = "past-conversations"
+
+// Metadata keys of an indexed exchange.
+const (
+	MetaSession = "session_id"
+	MetaTurn    = "turn"       // Position of the exchange in its session, from 1.
+	MetaDate    = "updated_at" // When the session was last active, RFC 3339.
+)
+
+// maxContent bounds the text of an exchange, which is embedded whole.
+const maxContent = 4000
+
+// embedBatchSize is the most exchanges sent to the embedder in one call.
+const embedBatchSize = 100
+
+// Lister lists stored sessions; session.Store and session.Manager are both
+// Listers.
+type Lister interface {
+	List(ctx context.Context) ([]*session.Session, error)
+}
+
+// Stats describes what Sync indexed.
+type Stats struct {
+	Sessions  int // Sessions listed.
+	Exchanges int // Exchanges in the index.
+	Embedded  int // Exchanges embedded by this Sync; the others kept their vectors.
+}
+
+// Index searches the past conversations exported to a file.
+type Index struct {
+	path           string
+	embedder       embedding.Embedder
+	embeddingModel string
+	topK           int
+
+	mu      sync.Mutex
+	db      *chromemdb.ChromemDB
+	modTime time.Time // Of the file db was loaded from.
+}
+
+// New returns the index stored at path, whose exchanges are embedded by
+// embedder, a model named embeddingModel. Retrieve returns up to topK
+// exchanges.
+func New(path string, embedder embedding.Embedder, embeddingModel string, topK int) *Index {
+	return &Index{path: path, embedder: embedder, embeddingModel: embeddingModel, topK: topK}
+}
+
+// NewFromEnv returns the index at config.RecallDBPath, embedded with the
+// configured embedder like the knowledge base.
+func NewFromEnv(ctx context.Context) (*Index, error) {
+	cfg, err := config.LoadEmbeddings()
+	if err != nil {
+		return nil, err
+	}
+	embedder, model, err := embeddings.New(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder: %w", err)
+	}
+	return New(config.RecallDBPath(), embedder, model, 5), nil
+}
+
+// Path returns the file the index is stored in.
+func (x *Index) Path() string {
+	return x.path
+}
+
+// Sync rebuilds the index from the sessions sessions lists. Exchanges already
+// indexed keep their vectors, so only new ones are embedded, and those of
+// sessions that were deleted are dropped.
+func (x *Index) Sync(ctx context.Context, sessions Lister) (Stats, error) {
+	listed, err := sessions.List(ctx)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	// An index embedded by another model is rebuilt from scratch.
+	var previous *chromem.Collection
+	old, manifest, err := chromemdb.ImportDB(x.path)
+	switch {
+	case errors.Is(err, chromemdb.ErrDBNotFound):
+	case err != nil:
+		return Stats{}, err
+	case manifest == nil || manifest.EmbeddingModel == x.embeddingModel:
+		previous = old.GetCollection(Collection, nil)
+	}
+
+	stats := Stats{Sessions: len(listed)}
+	var docs []chromem.Document
+	var pending []int // Indexes in docs of the exchanges to embed.
+	for _, s := range listed {
+		for i, text := range exchanges(s.Messages) {
+			doc := chromem.Document{
+				ID:      s.ID + "/" + strconv.Itoa(i+1),
+				Content: text,
+				Metadata: map[string]string{
+					MetaSession: s.ID,
+					MetaTurn:    strconv.Itoa(i + 1),
+					MetaDate:    s.UpdatedAt.UTC().Format(time.RFC3339),
+				},
+			}
+			if s.Owner != "" {
+				doc.Metadata[chromemdb.MetaAccess] = s.Owner
+			}
+			if previous != nil {
+				if kept, err := previous.GetByID(ctx, doc.ID); err == nil && kept.Content == doc.Content {
+					doc.Embedding = kept.Embedding
+				}
+			}
+			if doc.Embedding == nil {
+				pending = append(pending, len(docs))
+			}
+			docs = append(docs, doc)
+		}
+	}
+	if err := x.embed(ctx, docs, pending); err != nil {
+		return Stats{}, err
+	}
+	stats.Exchanges, stats.Embedded = len(docs), len(pending)
+
+	metadata := map[string]string{chromemdb.MetaEmbeddingModel: x.embeddingModel}
+	db := chromem.NewDB()
+	collection, err := db.CreateCollection(Collection, metadata, x.embeddingFunc)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to create collection: %w", err)
+	}
+	if len(docs) > 0 {
+		if err := collection.AddDocuments(ctx, docs, runtime.NumCPU()); err != nil {
+			return Stats{}, fmt.Errorf("failed to add exchanges: %w", err)
+		}
+	}
+	var dimension int
+	if len(docs) > 0 {
+		dimension = len(docs[0].Embedding)
+	}
+	err = chromemdb.ExportDB(db, x.path, &chromemdb.Manifest{
+		SchemaVersion:  chromemdb.SchemaVersion,
+		EmbeddingModel: x.embeddingModel,
+		Dimension:      dimension,
+		Collection:     Collection,
+		Metadata:       metadata,
+		CreatedAt:      time.Now().UTC(),
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+	return stats, nil
+}
+
+// embed sets the vectors of the documents at the pending indexes of docs.
+func (x *Index) embed(ctx context.Context, docs []chromem.Document, pending []int) error {
+	for start := 0; start < len(pending); start += embedBatchSize {
+		batch := pending[start:min(start+embedBatchSize, len(pending))]
+		texts := make([]string, len(batch))
+		for i, d := range batch {
+			texts[i] = docs[d].Content
+		}
+		vectors, err := x.embedder.EmbedStrings(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to embed conversations: %w", err)
+		}
+		if len(vectors) != len(batch) {
+			return fmt.Errorf("failed to embed conversations: the embedder returned %d vectors for %d texts", len(vectors), len(batch))
+		}
+		for i, d := range batch {
+			if len(vectors[i]) == 0 {
+				return errors.New("failed to embed conversations: the embedder returned an empty vector")
+			}
+			docs[d].Embedding = toFloat32(vectors[i])
+		}
+	}
+	return nil
+}
+
+// embeddingFunc embeds a single text for chromem-go. Sync sets every vector
+// itself, so it is only a fallback.
+func (x *Index) embeddingFunc(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := x.embedder.EmbedStrings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 || len(vectors[0]) == 0 {
+		return nil, errors.New("embedder returned no embeddings")
+	}
+	return toFloat32(vectors[0]), nil
+}
+
+// Retrieve returns the exchanges most similar to query, most similar first.
+// The file is loaded again when it changed since the last call, so a
+// running agent sees what `goforai sessions index` added. It returns
+// chromemdb.ErrDBNotFound until the first Sync.
+func (x *Index) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	db, err := x.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return db.Retrieve(ctx, query, opts...)
+}
+
+// load returns the index, loading it again when the file changed.
+func (x *Index) load(ctx context.Context) (*chromemdb.ChromemDB, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	info, err := os.Stat(x.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w at %s: index past conversations with 'goforai sessions index'", chromemdb.ErrDBNotFound, x.path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if x.db != nil && info.ModTime().Equal(x.modTime) {
+		return x.db, nil
+	}
+	db, err := chromemdb.New(ctx, Collection, x.embedder,
+		chromemdb.WithDBPath(x.path),
+		chromemdb.WithEmbeddingModel(x.embeddingModel),
+		chromemdb.WithTopK(x.topK),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load past conversations: %w", err)
+	}
+	x.db, x.modTime = db, info.ModTime()
+	return db, nil
+}
+
+// exchanges renders a conversation as one text per question: the user's
+// message and the assistant's answers to it. Tool calls and results are
+// left out; the conclusions drawn from them are in the answers.
+func exchanges(messages []*schema.Message) []string {
+	var texts []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			texts = append(texts, truncate.Runes(current.String(), maxContent))
+			current.Reset()
+		}
+	}
+	for _, m := range messages {
+		switch {
+		case m.Role == schema.User:
+			flush()
+			fmt.Fprintf(&current, "User: %s", strings.TrimSpace(m.Content))
+		case m.Role == schema.Assistant && current.Len() > 0 && strings.TrimSpace(m.Content) != "":
+			fmt.Fprintf(&current, "\n\nAssistant: %s", strings.TrimSpace(m.Content))
+		}
+	}
+	flush()
+	return texts
+}
+
+// Format renders retrieved exchanges for the model or the terminal.
+func Format(docs []*schema.Document) string {
+	if len(docs) == 0 {
+		return "No earlier conversation matches."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d exchanges from earlier conversations:\n\n", len(docs))
+	for i, doc := range docs {
+		header := fmt.Sprintf("session %v, turn %v", doc.MetaData[MetaSession], doc.MetaData[MetaTurn])
+		if at, err := time.Parse(time.RFC3339, fmt.Sprint(doc.MetaData[MetaDate])); err == nil {
+			header += ", " + at.Format(time.DateOnly)
+		}
+		fmt.Fprintf(&b, "=== %d (%s) ===\n%s\n\n", i+1, header, doc.Content)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func toFloat32(v []float64) []float32 {
+	out := make([]float32, len(v))
+	for i, f := range v {
+		out[i] = float32(f)
+	}
+	return out
+}
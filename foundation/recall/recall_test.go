@@ -0,0 +1,151 @@
+package recall
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+	"github.com/olusolaa/goforai/foundation/session"
+)
+
+// wordEmbedder embeds texts as the fake Gemini API does, counting them.
+type wordEmbedder struct{ texts int }
+
+func (e *wordEmbedder) EmbedStrings(_ context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	e.texts += len(texts)
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = fakeapi.Embed(text)
+	}
+	return vectors, nil
+}
+
+func seed(t *testing.T, store *session.MemoryStore, id, owner string, messages ...*schema.Message) {
+	t.Helper()
+	now := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	err := store.Save(context.Background(), &session.Session{ID: id, Owner: owner, Messages: messages, CreatedAt: now, UpdatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncAndRetrieve(t *testing.T) {
+	ctx := context.Background()
+	store := session.NewMemoryStore()
+	seed(t, store, "s1", "",
+		schema.UserMessage("Which vector store should we use?"),
+		schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "search_docs"}}}),
+		schema.ToolMessage("chromem-go, qdrant", "1"),
+		schema.AssistantMessage("We decided on chromem-go because it is embedded.", nil),
+		schema.UserMessage("And the chunk size?"),
+		schema.AssistantMessage("Chunks of 512 tokens.", nil),
+	)
+	seed(t, store, "s2", "alice",
+		schema.UserMessage("Deploy the gateway to staging"),
+		schema.AssistantMessage("The gateway runs on staging now.", nil),
+	)
+
+	embedder := &wordEmbedder{}
+	index := New(filepath.Join(t.TempDir(), "conversations.gob"), embedder, "fake-embedding", 2)
+	if _, err := index.Retrieve(ctx, "vector store"); !errors.Is(err, chromemdb.ErrDBNotFound) {
+		t.Fatalf("Retrieve before Sync = %v, want ErrDBNotFound", err)
+	}
+
+	stats, err := index.Sync(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Stats{Sessions: 2, Exchanges: 3, Embedded: 3}); stats != want {
+		t.Fatalf("Sync = %+v, want %+v", stats, want)
+	}
+
+	docs, err := index.Retrieve(ctx, "which vector store did we decide on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) == 0 || docs[0].ID != "s1/1" {
+		t.Fatalf("Retrieve = %v, want s1/1 first", docs)
+	}
+	want := "User: Which vector store should we use?\n\nAssistant: We decided on chromem-go because it is embedded."
+	if docs[0].Content != want {
+		t.Errorf("exchange = %q, want %q", docs[0].Content, want)
+	}
+	if got := Format(docs[:1]); !strings.Contains(got, "=== 1 (session s1, turn 1, 2026-03-14) ===") {
+		t.Errorf("Format = %q", got)
+	}
+
+	// Another client does not see alice's session.
+	bob := chromemdb.WithIdentity(ctx, chromemdb.Identity{Name: "bob"})
+	docs, err = index.Retrieve(bob, "deploy the gateway to staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range docs {
+		if doc.MetaData[MetaSession] == "s2" {
+			t.Errorf("bob retrieved alice's exchange %s", doc.ID)
+		}
+	}
+
+	// Kept exchanges are not embedded again, and deleted sessions are dropped.
+	if err := store.Delete(ctx, "s2"); err != nil {
+		t.Fatal(err)
+	}
+	seed(t, store, "s3", "", schema.UserMessage("Rename the module"), schema.AssistantMessage("Renamed to goforai.", nil))
+	embedder.texts = 0
+	stats, err = index.Sync(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Stats{Sessions: 2, Exchanges: 3, Embedded: 1}); stats != want || embedder.texts != 1 {
+		t.Fatalf("second Sync = %+v with %d texts embedded, want %+v with 1", stats, embedder.texts, want)
+	}
+	docs, err = index.Retrieve(ctx, "deploy the gateway to staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range docs {
+		if doc.MetaData[MetaSession] == "s2" {
+			t.Errorf("the deleted session is still indexed: %s", doc.ID)
+		}
+	}
+}
+
+func TestSyncRebuildsForAnotherModel(t *testing.T) {
+	ctx := context.Background()
+	store := session.NewMemoryStore()
+	seed(t, store, "s1", "", schema.UserMessage("hello"), schema.AssistantMessage("hi", nil))
+	path := filepath.Join(t.TempDir(), "conversations.gob")
+	if _, err := New(path, &wordEmbedder{}, "model-a", 1).Sync(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := New(path, &wordEmbedder{}, "model-b", 1).Sync(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Embedded != 1 {
+		t.Errorf("Sync with another model embedded %d exchanges, want 1", stats.Embedded)
+	}
+}
+
+func TestExchanges(t *testing.T) {
+	got := exchanges([]*schema.Message{
+		schema.AssistantMessage("Welcome!", nil), // Before any question.
+		schema.UserMessage("  first  "),
+		schema.UserMessage("second"),
+		schema.AssistantMessage("answer", nil),
+	})
+	want := []string{"User: first", "User: second\n\nAssistant: answer"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("exchanges = %q, want %q", got, want)
+	}
+	if got := Format(nil); got != "No earlier conversation matches." {
+		t.Errorf("Format(nil) = %q", got)
+	}
+}
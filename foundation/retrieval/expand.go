@@ -0,0 +1,142 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/logger"
+)
+
+// minChunkOverlap is the shortest text two chunks must share for it to be
+// taken as the overlap the splitter left between them rather than chance.
+const minChunkOverlap = 16
+
+// ChunkRetriever is a retriever that can also read the chunks around one it
+// returned, such as chromemdb.ChromemDB.
+type ChunkRetriever interface {
+	retriever.Retriever
+	Neighbors(ctx context.Context, doc *schema.Document, window int) ([]*schema.Document, error)
+}
+
+// Expand returns r with the chunks it returns with a score of at least
+// cfg.MinScore merged with the cfg.Window chunks on each side of them, so
+// that an answer drawn from a section split across chunks sees all of it.
+// Neighbors the search also returned are merged in rather than repeated,
+// unless they ranked higher and were returned on their own. With a Window of
+// 0, r is returned as it is.
+func Expand(r ChunkRetriever, cfg config.Expansion) retriever.Retriever {
+	if cfg.Window <= 0 {
+		return r
+	}
+	return &expandingRetriever{ChunkRetriever: r, cfg: cfg}
+}
+
+type expandingRetriever struct {
+	ChunkRetriever
+	cfg config.Expansion
+}
+
+func (r *expandingRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	docs, err := r.ChunkRetriever.Retrieve(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool) // Chunks already in out, on their own or merged.
+	out := make([]*schema.Document, 0, len(docs))
+	for _, doc := range docs {
+		key, ok := chunkKey(doc)
+		if !ok {
+			out = append(out, doc)
+			continue
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if doc.Score() < r.cfg.MinScore {
+			out = append(out, doc)
+			continue
+		}
+		neighbors, err := r.Neighbors(ctx, doc, r.cfg.Window)
+		if err != nil {
+			logger.FromContext(ctx).Warn("failed to expand knowledge base match", "document", doc.ID, "error", err)
+			out = append(out, doc)
+			continue
+		}
+		// Chunks the search ranked higher are already in out.
+		neighbors = slices.DeleteFunc(neighbors, func(n *schema.Document) bool {
+			k, ok := chunkKey(n)
+			return !ok || seen[k]
+		})
+		for _, n := range neighbors {
+			k, _ := chunkKey(n)
+			seen[k] = true
+		}
+		out = append(out, expandChunk(doc, neighbors))
+	}
+	return out, nil
+}
+
+// MetaExpandedChunks is the metadata key Expand sets on a merged document,
+// holding the range of chunk indexes it spans, such as "3-5".
+const MetaExpandedChunks = "expanded_chunks"
+
+// expandChunk returns a copy of doc with its neighbors, which are in the
+// order of the file, merged around it.
+func expandChunk(doc *schema.Document, neighbors []*schema.Document) *schema.Document {
+	if len(neighbors) == 0 {
+		return doc
+	}
+	index, _ := chromemdb.ChunkIndex(doc)
+	first, last := index, index
+	var content string
+	placed := false
+	for _, n := range neighbors {
+		i, _ := chromemdb.ChunkIndex(n)
+		if i > index && !placed {
+			content, placed = joinChunks(content, doc.Content), true
+		}
+		content = joinChunks(content, n.Content)
+		first, last = min(first, i), max(last, i)
+	}
+	if !placed {
+		content = joinChunks(content, doc.Content)
+	}
+
+	metadata := make(map[string]any, len(doc.MetaData)+1)
+	for k, v := range doc.MetaData {
+		metadata[k] = v
+	}
+	metadata[MetaExpandedChunks] = fmt.Sprintf("%d-%d", first, last)
+	expanded := &schema.Document{ID: doc.ID, Content: content, MetaData: metadata}
+	return expanded.WithScore(doc.Score())
+}
+
+// joinChunks appends b to a, writing the text they share once.
+func joinChunks(a, b string) string {
+	if a == "" {
+		return b
+	}
+	for k := min(len(a), len(b)); k >= minChunkOverlap; k-- {
+		if strings.HasSuffix(a, b[:k]) {
+			return a + b[k:]
+		}
+	}
+	return a + "\n\n" + b
+}
+
+// chunkKey identifies a chunk by its file and position in it.
+func chunkKey(doc *schema.Document) (string, bool) {
+	source, _ := doc.MetaData[chromemdb.MetaSource].(string)
+	index, ok := chromemdb.ChunkIndex(doc)
+	if source == "" || !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s#%d", source, index), true
+}
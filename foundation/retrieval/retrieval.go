@@ -0,0 +1,110 @@
+// Package retrieval sizes what the knowledge base adds to a prompt. A fixed
+// number of documents overflows the context of a long conversation and
+// wastes it on a short one, so the number of documents, and how much of each
+// is kept, follow the context the conversation leaves free and what the turn
+// may cost. Expand widens the closest matches with the chunks around them.
+package retrieval
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/tokens"
+	"github.com/olusolaa/goforai/foundation/truncate"
+)
+
+// minDocTokens is what a search returns however tight the budget: a search
+// was asked for, and an empty one only makes the model search again.
+const minDocTokens = 100
+
+// Plan is how much one search may add to the prompt.
+type Plan struct {
+	TopK         int // Documents to retrieve.
+	MaxDocTokens int // Each document is cut to this many tokens; 0 keeps them whole.
+}
+
+// Sizer plans searches for one chat model.
+type Sizer struct {
+	caps      models.Capabilities
+	cfg       config.Retrieval
+	docTokens int
+}
+
+// NewSizer returns a Sizer for a model with caps, whose knowledge base holds
+// chunks of up to chunkChars characters.
+func NewSizer(caps models.Capabilities, cfg config.Retrieval, chunkChars int) *Sizer {
+	return &Sizer{caps: caps, cfg: cfg, docTokens: max(chunkChars/tokens.AverageBytesPerToken, 1)}
+}
+
+// Plan returns the plan for a search made when the prompt already holds
+// promptTokens. Documents get ContextShare of the context left free and no
+// more than the cost ceiling leaves; as many whole chunks as fit are
+// retrieved, up to MaxTopK, and when not even one fits it is cut to size.
+func (s *Sizer) Plan(promptTokens int) Plan {
+	budget := int(float64(s.caps.ContextWindow-promptTokens) * s.cfg.ContextShare)
+	if s.cfg.MaxTurnCost > 0 && s.caps.InputPrice > 0 {
+		affordable := int(s.cfg.MaxTurnCost*1_000_000/s.caps.InputPrice) - promptTokens
+		budget = min(budget, affordable)
+	}
+	budget = max(budget, minDocTokens)
+
+	plan := Plan{TopK: min(max(budget/s.docTokens, 1), s.cfg.MaxTopK)}
+	if plan.TopK*s.docTokens > budget {
+		plan.MaxDocTokens = budget / plan.TopK
+	}
+	return plan
+}
+
+// Fit cuts docs to the plan. The documents are copied, not modified.
+func (p Plan) Fit(docs []*schema.Document) []*schema.Document {
+	if p.TopK > 0 && len(docs) > p.TopK {
+		docs = docs[:p.TopK]
+	}
+	fitted := make([]*schema.Document, len(docs))
+	for i, doc := range docs {
+		fitted[i] = doc
+		if p.MaxDocTokens > 0 && tokens.Estimate(doc.Content) > p.MaxDocTokens {
+			cut := *doc
+			cut.Content = truncate.Bytes(doc.Content, tokens.BytesFor(doc.Content, p.MaxDocTokens))
+			fitted[i] = &cut
+		}
+	}
+	return fitted
+}
+
+type promptTokensKey struct{}
+
+// WithPromptTokens records in ctx how many tokens the prompt of the turn
+// already holds, for the searches made during the turn.
+func WithPromptTokens(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, promptTokensKey{}, n)
+}
+
+// PromptTokens returns the size recorded by WithPromptTokens, or 0.
+func PromptTokens(ctx context.Context) int {
+	n, _ := ctx.Value(promptTokensKey{}).(int)
+	return n
+}
+
+// Wrap returns r with every search sized by s for the prompt recorded in its
+// context by WithPromptTokens.
+func Wrap(r retriever.Retriever, s *Sizer) retriever.Retriever {
+	return &sizedRetriever{Retriever: r, sizer: s}
+}
+
+type sizedRetriever struct {
+	retriever.Retriever
+	sizer *Sizer
+}
+
+func (r *sizedRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	plan := r.sizer.Plan(PromptTokens(ctx))
+	docs, err := r.Retriever.Retrieve(ctx, query, append([]retriever.Option{retriever.WithTopK(plan.TopK)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Fit(docs), nil
+}
@@ -0,0 +1,161 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/tokens"
+)
+
+func TestPlan(t *testing.T) {
+	caps := models.Capabilities{ContextWindow: 128_000, InputPrice: 2.50}
+	// 1000-character chunks are 250 tokens.
+	sizer := NewSizer(caps, config.Retrieval{ContextShare: 0.05, MaxTopK: 8}, 1000)
+
+	for _, tc := range []struct {
+		name   string
+		sizer  *Sizer
+		prompt int
+		want   Plan
+	}{
+		// 6,400 tokens fit all 8 chunks.
+		{"short conversation", sizer, 0, Plan{TopK: 8}},
+		// 20% of the context is free: 1,280 tokens, 5 chunks.
+		{"long conversation", sizer, 102_400, Plan{TopK: 5}},
+		// 140 tokens: one chunk, cut.
+		{"full context", sizer, 125_200, Plan{TopK: 1, MaxDocTokens: 140}},
+		// Never less than minDocTokens.
+		{"overflowing context", sizer, 200_000, Plan{TopK: 1, MaxDocTokens: minDocTokens}},
+		// $0.005 buys 2,000 input tokens, 1,000 of them left after the prompt.
+		{"cost ceiling", NewSizer(caps, config.Retrieval{ContextShare: 0.05, MaxTopK: 8, MaxTurnCost: 0.005}, 1000), 1000, Plan{TopK: 4}},
+	} {
+		if got := tc.sizer.Plan(tc.prompt); got != tc.want {
+			t.Errorf("%s: Plan(%d) = %+v, want %+v", tc.name, tc.prompt, got, tc.want)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	docs := []*schema.Document{
+		{ID: "a", Content: strings.Repeat("é", 30)}, // 60 bytes, 10 tokens
+		{ID: "b", Content: "short"},
+		{ID: "c", Content: "dropped"},
+	}
+	got := Plan{TopK: 2, MaxDocTokens: 5}.Fit(docs)
+	if len(got) != 2 || got[0].Content != strings.Repeat("é", 15)+"…" || got[1] != docs[1] {
+		t.Errorf("Fit = %v", got)
+	}
+	if len(docs[0].Content) != 60 {
+		t.Error("Fit modified the retriever's document")
+	}
+}
+
+// countRetriever returns as many documents as it is asked for.
+type countRetriever struct{}
+
+func (countRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	defaultTopK := 3
+	topK := *retriever.GetCommonOptions(&retriever.Options{TopK: &defaultTopK}, opts...).TopK
+	docs := make([]*schema.Document, topK)
+	for i := range docs {
+		docs[i] = &schema.Document{Content: strings.Repeat("x", 1000)}
+	}
+	return docs, nil
+}
+
+func TestWrap(t *testing.T) {
+	sizer := NewSizer(models.Capabilities{ContextWindow: 128_000}, config.Retrieval{ContextShare: 0.05, MaxTopK: 8}, 1000)
+	r := Wrap(countRetriever{}, sizer)
+
+	docs, err := r.Retrieve(context.Background(), "keynote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 8 {
+		t.Errorf("short prompt: got %d documents, want 8", len(docs))
+	}
+
+	docs, err = r.Retrieve(WithPromptTokens(context.Background(), 125_200), "keynote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || tokens.Estimate(docs[0].Content) > 140+1 {
+		t.Errorf("full prompt: got %d documents, the first of %d tokens", len(docs), tokens.Estimate(docs[0].Content))
+	}
+}
+
+// chunkRetriever returns the chunks at ranked, in that order, of a file split
+// into chunks.
+type chunkRetriever struct {
+	chunks []string
+	ranked []int
+	scores []float64
+}
+
+func (r chunkRetriever) chunk(i int, score float64) *schema.Document {
+	doc := &schema.Document{ID: fmt.Sprintf("talks.md#%d", i), Content: r.chunks[i], MetaData: map[string]any{"source": "talks.md", "chunk_index": strconv.Itoa(i)}}
+	return doc.WithScore(score)
+}
+
+func (r chunkRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	docs := make([]*schema.Document, len(r.ranked))
+	for i, c := range r.ranked {
+		docs[i] = r.chunk(c, r.scores[i])
+	}
+	return docs, nil
+}
+
+func (r chunkRetriever) Neighbors(ctx context.Context, doc *schema.Document, window int) ([]*schema.Document, error) {
+	index, _ := strconv.Atoi(doc.MetaData["chunk_index"].(string))
+	var out []*schema.Document
+	for i := max(index-window, 0); i <= min(index+window, len(r.chunks)-1); i++ {
+		if i != index {
+			out = append(out, r.chunk(i, 0))
+		}
+	}
+	return out, nil
+}
+
+func TestExpand(t *testing.T) {
+	chunks := []string{
+		"## Keynote\n\nThe keynote is given by Sarah Johnson, who leads the Go team",
+		"who leads the Go team at Acme. It starts at 9:00 in Hall A",
+		"starts at 9:00 in Hall A and lasts an hour.\n\n## Lunch",
+		"## Lunch\n\nLunch is served at noon.",
+	}
+	r := chunkRetriever{chunks: chunks, ranked: []int{1, 2, 3}, scores: []float64{0.9, 0.8, 0.6}}
+	docs, err := Expand(r, config.Expansion{MinScore: 0.85, Window: 1}).Retrieve(context.Background(), "when is the keynote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want the expanded match and the unrelated one: %v", len(docs), docs)
+	}
+	want := "## Keynote\n\nThe keynote is given by Sarah Johnson, who leads the Go team at Acme. It starts at 9:00 in Hall A and lasts an hour.\n\n## Lunch"
+	if docs[0].Content != want || docs[0].Score() != 0.9 || docs[0].MetaData[MetaExpandedChunks] != "0-2" {
+		t.Errorf("expanded match = %q (score %.2f, chunks %v), want %q", docs[0].Content, docs[0].Score(), docs[0].MetaData[MetaExpandedChunks], want)
+	}
+	if docs[1].Content != chunks[3] {
+		t.Errorf("second document = %q, want the lunch chunk unchanged", docs[1].Content)
+	}
+	if r.chunks[1] != chunks[1] {
+		t.Error("Expand modified the retriever's document")
+	}
+
+	// A neighbor ranked higher stays on its own.
+	r.ranked, r.scores = []int{2, 1}, []float64{0.8, 0.9}
+	if docs, _ := Expand(r, config.Expansion{MinScore: 0.85, Window: 1}).Retrieve(context.Background(), "q"); len(docs) != 2 || docs[0].Content != chunks[2] || !strings.HasPrefix(docs[1].Content, "## Keynote") || strings.Contains(docs[1].Content, "lasts an hour") {
+		t.Errorf("got %v", docs)
+	}
+
+	if _, ok := Expand(r, config.Expansion{MinScore: 0.5}).(chunkRetriever); !ok {
+		t.Error("a window of 0 did not leave the retriever as it was")
+	}
+}
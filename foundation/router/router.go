@@ -0,0 +1,183 @@
+// Package router decides whether a user's message needs the knowledge base at
+// all, so that greetings and general Go questions skip the embedding call and
+// the retrieved context that would only distract the model.
+package router
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// DefaultThreshold is the cosine similarity to the collection centroid above
+// which a query is taken to be about the knowledge base.
+const DefaultThreshold = 0.45
+
+// maxSmallTalkWords bounds how long a message of nothing but small talk can
+// be: "thanks, that helps a lot" is small talk, a paragraph is not.
+const maxSmallTalkWords = 5
+
+// smallTalk holds the words a greeting, thanks or goodbye is made of.
+var smallTalk = set(
+	"hi", "hello", "hey", "hiya", "yo", "morning", "afternoon", "evening", "good", "day",
+	"thanks", "thank", "you", "thx", "ty", "cheers", "great", "cool", "nice", "awesome",
+	"ok", "okay", "k", "sure", "yes", "yeah", "yep", "no", "nope", "got", "it",
+	"bye", "goodbye", "later", "see", "ya", "that", "helps", "helped", "a", "lot", "so", "much",
+	"how", "are", "doing", "what's", "up", "sup",
+)
+
+// domainWords are words that only make sense about the conference; a query
+// containing one is retrieved for without asking the embedder.
+var domainWords = set(
+	"gophercon", "conference", "speaker", "speakers", "talk", "talks", "keynote", "keynotes",
+	"workshop", "workshops", "schedule", "agenda", "session", "sessions", "venue", "ticket",
+	"tickets", "sponsor", "sponsors", "organizer", "organizers", "nairobi", "lagos", "africa",
+	"attendee", "attendees", "track", "tracks", "panel", "day1", "day2",
+)
+
+// codeSignals mark a message as a programming question, which the model
+// answers from what it knows rather than from the conference knowledge base.
+var codeSignals = []string{"```", "func ", "package ", ":=", "err != nil", ".go ", "go.mod", "panic:", "goroutine "}
+
+// Decision is the router's verdict on a message.
+type Decision struct {
+	Retrieve bool   // Whether to search the knowledge base.
+	Reason   string // Why, for logs.
+}
+
+// Router decides whether to retrieve for a message. The zero Router uses the
+// keyword rules alone and retrieves whenever they do not settle the question.
+type Router struct {
+	embedder  embedding.Embedder
+	centroid  []float64
+	threshold float64
+}
+
+// Option configures a Router.
+type Option func(*Router)
+
+// WithCentroid settles the messages the keyword rules cannot by comparing
+// their embedding to centroid, the normalized mean embedding of the
+// collection (see chromemdb.ReadCentroid), and retrieving when the cosine
+// similarity reaches threshold. A threshold of zero means DefaultThreshold.
+func WithCentroid(embedder embedding.Embedder, centroid []float64, threshold float64) Option {
+	return func(r *Router) {
+		if threshold == 0 {
+			threshold = DefaultThreshold
+		}
+		r.embedder = embedder
+		r.centroid = centroid
+		r.threshold = threshold
+	}
+}
+
+// New returns a Router configured by opts.
+func New(opts ...Option) *Router {
+	r := &Router{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Route decides whether query needs the knowledge base. The rules run from
+// cheapest to dearest: empty input and small talk never retrieve, conference
+// words always do, code never does, and anything else is decided by the
+// centroid when there is one. A failed embedding call retrieves, so that the
+// router never costs an answer.
+func (r *Router) Route(ctx context.Context, query string) Decision {
+	words := tokenize(query)
+	switch {
+	case len(words) == 0:
+		return Decision{Retrieve: false, Reason: "empty message"}
+	case len(words) <= maxSmallTalkWords && allIn(words, smallTalk):
+		return Decision{Retrieve: false, Reason: "small talk"}
+	case anyIn(words, domainWords):
+		return Decision{Retrieve: true, Reason: "mentions the conference"}
+	case looksLikeCode(query):
+		return Decision{Retrieve: false, Reason: "programming question"}
+	case r.embedder == nil || len(r.centroid) == 0:
+		return Decision{Retrieve: true, Reason: "no centroid to compare with"}
+	}
+
+	vectors, err := r.embedder.EmbedStrings(ctx, []string{query})
+	if err != nil || len(vectors) != 1 {
+		return Decision{Retrieve: true, Reason: fmt.Sprintf("could not embed the message: %v", err)}
+	}
+	sim := cosine(vectors[0], r.centroid)
+	if sim >= r.threshold {
+		return Decision{Retrieve: true, Reason: fmt.Sprintf("similarity %.2f to the knowledge base", sim)}
+	}
+	return Decision{Retrieve: false, Reason: fmt.Sprintf("similarity %.2f to the knowledge base is below %.2f", sim, r.threshold)}
+}
+
+// tokenize lowercases s and splits it into words, dropping the punctuation
+// around them.
+func tokenize(s string) []string {
+	fields := strings.Fields(strings.ToLower(s))
+	words := fields[:0]
+	for _, f := range fields {
+		f = strings.TrimFunc(f, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if f != "" {
+			words = append(words, f)
+		}
+	}
+	return words
+}
+
+func looksLikeCode(s string) bool {
+	for _, signal := range codeSignals {
+		if strings.Contains(s+" ", signal) {
+			return true
+		}
+	}
+	return false
+}
+
+// cosine returns the cosine similarity of a and b, or zero when their
+// lengths differ or either is zero.
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+func allIn(words []string, m map[string]bool) bool {
+	for _, w := range words {
+		if !m[w] {
+			return false
+		}
+	}
+	return true
+}
+
+func anyIn(words []string, m map[string]bool) bool {
+	for _, w := range words {
+		if m[w] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// axisEmbedder embeds texts mentioning "eino" along the first axis, the
+// centroid's direction, and everything else along the second.
+type axisEmbedder struct {
+	err   error
+	calls int
+}
+
+func (e *axisEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	e.calls++
+	if e.err != nil {
+		return nil, e.err
+	}
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		if strings.Contains(strings.ToLower(text), "eino") {
+			vectors[i] = []float64{0.9, 0.1}
+		} else {
+			vectors[i] = []float64{0.1, 0.9}
+		}
+	}
+	return vectors, nil
+}
+
+func TestRoute(t *testing.T) {
+	embedder := &axisEmbedder{}
+	r := New(WithCentroid(embedder, []float64{1, 0}, 0))
+
+	tests := []struct {
+		query    string
+		retrieve bool
+		embeds   bool
+	}{
+		{"", false, false},
+		{"Hello!", false, false},
+		{"thanks, that helps a lot", false, false},
+		{"Who is giving the keynote?", true, false},
+		{"When does the Nairobi workshop start?", true, false},
+		{"Why does `x := f()` shadow err here?\n```go\nif err != nil {}\n```", false, false},
+		{"Is there anything on building agents with Eino?", true, true},
+		{"What is the capital of Kenya?", false, true},
+	}
+	for _, tt := range tests {
+		embedder.calls = 0
+		d := r.Route(context.Background(), tt.query)
+		if d.Retrieve != tt.retrieve || d.Reason == "" {
+			t.Errorf("Route(%q) = %+v, want Retrieve %v", tt.query, d, tt.retrieve)
+		}
+		if got := embedder.calls > 0; got != tt.embeds {
+			t.Errorf("Route(%q) called the embedder: %v, want %v", tt.query, got, tt.embeds)
+		}
+	}
+}
+
+func TestRouteWithoutCentroid(t *testing.T) {
+	// Without a centroid, or when the embedder fails, anything the keyword
+	// rules leave open is retrieved for.
+	for name, r := range map[string]*Router{
+		"no centroid":   New(),
+		"embed failure": New(WithCentroid(&axisEmbedder{err: errors.New("quota exceeded")}, []float64{1, 0}, 0)),
+	} {
+		if d := r.Route(context.Background(), "What is the capital of Kenya?"); !d.Retrieve {
+			t.Errorf("%s: %+v", name, d)
+		}
+		if d := r.Route(context.Background(), "hi"); d.Retrieve {
+			t.Errorf("%s: small talk was retrieved for: %+v", name, d)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultConfigPath is read when SCHEDULE_CONFIG is not set.
+const DefaultConfigPath = "schedules.json"
+
+// DefaultTimeout bounds a single task run when the task does not set one.
+const DefaultTimeout = 10 * time.Minute
+
+// Task is a prompt the agent answers on a schedule.
+type Task struct {
+	Name     string       `json:"name"`
+	Schedule string       `json:"schedule"` // Cron expression, e.g. "0 9 * * 1-5", or a descriptor like "@daily".
+	Prompt   string       `json:"prompt"`
+	Timeout  string       `json:"timeout,omitempty"` // Go duration; defaults to DefaultTimeout.
+	Sinks    []SinkConfig `json:"sinks"`
+	// Workspace is the directory the agent's file tools work in. The files a
+	// run changes there are reported with its result. Defaults to the
+	// current directory.
+	Workspace string `json:"workspace,omitempty"`
+}
+
+// SinkConfig names where a task's result is delivered.
+type SinkConfig struct {
+	Type string `json:"type"`           // file, webhook or slack.
+	Path string `json:"path,omitempty"` // For file sinks.
+	URL  string `json:"url,omitempty"`  // For webhook and slack sinks.
+}
+
+// Config is the layout of the schedules file.
+type Config struct {
+	Tasks []Task `json:"tasks"`
+}
+
+// LoadConfig reads and validates a schedules file. A missing file yields an
+// empty configuration.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedules %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse schedules %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range cfg.Tasks {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("invalid task %q in %s: %w", t.Name, path, err)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("duplicate task name %q in %s", t.Name, path)
+		}
+		seen[t.Name] = true
+	}
+	return &cfg, nil
+}
+
+// ConfigPath returns SCHEDULE_CONFIG or the default path.
+func ConfigPath() string {
+	if p := os.Getenv("SCHEDULE_CONFIG"); p != "" {
+		return p
+	}
+	return DefaultConfigPath
+}
+
+func (t Task) validate() error {
+	if t.Name == "" {
+		return errors.New("name is required")
+	}
+	if t.Prompt == "" {
+		return errors.New("prompt is required")
+	}
+	if _, err := cron.ParseStandard(t.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", t.Schedule, err)
+	}
+	if _, err := t.timeout(); err != nil {
+		return err
+	}
+	if t.Workspace != "" {
+		if info, err := os.Stat(t.Workspace); err != nil || !info.IsDir() {
+			return fmt.Errorf("workspace %q is not a directory", t.Workspace)
+		}
+	}
+	for _, s := range t.Sinks {
+		if _, err := newSink(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t Task) timeout() (time.Duration, error) {
+	if t.Timeout == "" {
+		return DefaultTimeout, nil
+	}
+	d, err := time.ParseDuration(t.Timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q", t.Timeout)
+	}
+	return d, nil
+}
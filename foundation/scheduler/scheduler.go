@@ -0,0 +1,193 @@
+// Package scheduler runs agent prompts on cron schedules and delivers the
+// answers to sinks such as files, webhooks, or Slack, so recurring chores
+// ("summarize new issues every morning") run without anyone at a terminal.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/snapshot"
+	"github.com/olusolaa/goforai/foundation/tools"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrTaskRunning is returned by Trigger while the task is already running.
+var ErrTaskRunning = errors.New("task is already running")
+
+// Agent answers a conversation. The step5 agent.Runner satisfies it.
+type Agent interface {
+	Generate(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (*schema.Message, error)
+}
+
+// Scheduler runs tasks on their schedules.
+type Scheduler struct {
+	agent Agent
+	tasks map[string]Task
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// New creates a scheduler for the given tasks.
+func New(agent Agent, tasks []Task) *Scheduler {
+	s := &Scheduler{agent: agent, tasks: make(map[string]Task, len(tasks)), running: make(map[string]bool)}
+	for _, t := range tasks {
+		s.tasks[t.Name] = t
+	}
+	return s
+}
+
+// Tasks returns the configured tasks sorted by name.
+func (s *Scheduler) Tasks() []Task {
+	tasks := make([]Task, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks
+}
+
+// Next returns when the task will next run after now.
+func (t Task) Next(now time.Time) time.Time {
+	sched, err := cron.ParseStandard(t.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return sched.Next(now)
+}
+
+// Run starts every task on its schedule and blocks until ctx is cancelled,
+// then lets running tasks finish within their timeouts. A run that is still
+// going when its next slot comes up, scheduled or triggered, is skipped
+// rather than stacked.
+func (s *Scheduler) Run(ctx context.Context) error {
+	log := logger.FromContext(ctx)
+	c := cron.New()
+
+	for _, t := range s.Tasks() {
+		task := t
+		job := func() {
+			if !s.start(task.Name) {
+				log.Warn("task skipped, previous run still going", "task", task.Name)
+				return
+			}
+			defer s.finish(task.Name)
+			s.run(context.WithoutCancel(ctx), task)
+		}
+		if _, err := c.AddFunc(task.Schedule, job); err != nil {
+			return fmt.Errorf("failed to schedule task %q: %w", task.Name, err)
+		}
+		log.Info("task scheduled", "task", task.Name, "schedule", task.Schedule, "next", task.Next(time.Now()))
+	}
+
+	c.Start()
+	<-ctx.Done()
+	log.Info("scheduler stopping, waiting for running tasks")
+	<-c.Stop().Done()
+	return nil
+}
+
+// Trigger runs a task immediately, outside its schedule. Like scheduled
+// runs, it does not start while this scheduler is already running the task.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (Result, error) {
+	task, ok := s.tasks[name]
+	if !ok {
+		return Result{}, fmt.Errorf("unknown task %q", name)
+	}
+	if !s.start(name) {
+		return Result{}, fmt.Errorf("%w: %q", ErrTaskRunning, name)
+	}
+	defer s.finish(name)
+	return s.run(ctx, task), nil
+}
+
+// start marks a task as running and reports false if it already was.
+func (s *Scheduler) start(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+func (s *Scheduler) finish(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, name)
+}
+
+// run executes one task and hands the result to its sinks. Failures are
+// delivered too, so a broken task is noticed where its output is expected.
+// Tasks that share a workspace and run at the same time see each other's
+// changes in their reports.
+func (s *Scheduler) run(ctx context.Context, task Task) Result {
+	ctx, _ = logger.WithRequestID(ctx)
+	log := logger.FromContext(ctx).With("task", task.Name)
+	ctx = logger.WithContext(ctx, log)
+
+	timeout, _ := task.timeout() // Validated when the config was loaded.
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// No one watches the run, so the workspace is snapshotted first and
+	// everything the agent changed is reported with the answer.
+	root := "."
+	if task.Workspace != "" {
+		root = task.Workspace
+		runCtx = tools.WithWorkspace(runCtx, root)
+	}
+	before, err := snapshot.Take(root)
+	if err != nil {
+		log.Warn("changes to the workspace will not be reported", "error", err)
+	}
+
+	log.Info("task started")
+	result := Result{Task: task.Name, Prompt: task.Prompt, StartedAt: time.Now()}
+	msg, err := s.agent.Generate(runCtx, []*schema.Message{schema.UserMessage(task.Prompt)})
+	result.Duration = time.Since(result.StartedAt)
+	if err != nil {
+		result.Error = err.Error()
+		log.Error("task failed", "error", err, "duration", result.Duration)
+	} else {
+		result.Output = msg.Content
+		log.Info("task finished", "duration", result.Duration)
+	}
+	if before != nil {
+		if result.Changes, err = before.Changes(); err != nil {
+			log.Warn("changes to the workspace will not be reported", "error", err)
+		} else if len(result.Changes) > 0 {
+			log.Info("task changed files", "files", len(result.Changes))
+		}
+	}
+
+	s.deliver(ctx, log, task, result)
+	return result
+}
+
+func (s *Scheduler) deliver(ctx context.Context, log *slog.Logger, task Task, result Result) {
+	for _, cfg := range task.Sinks {
+		sink, err := newSink(cfg)
+		if err != nil {
+			log.Error("invalid sink", "sink", cfg.Type, "error", err)
+			continue
+		}
+		// Sinks get their own deadline so a task that used its whole budget
+		// can still report.
+		sinkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		if err := sink.Deliver(sinkCtx, result); err != nil {
+			log.Error("failed to deliver task result", "sink", cfg.Type, "error", err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,287 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/snapshot"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// fakeAgent answers with reply or err. With block set, each call waits for a
+// value on it first. With write set, it writes write["path"] = "content"
+// into the workspace of the call.
+type fakeAgent struct {
+	reply   string
+	err     error
+	started chan struct{}
+	block   chan struct{}
+	write   map[string]string
+}
+
+func (a *fakeAgent) Generate(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (*schema.Message, error) {
+	if a.started != nil {
+		a.started <- struct{}{}
+	}
+	if a.block != nil {
+		<-a.block
+	}
+	for path, content := range a.write {
+		resolved, err := tools.ResolvePath(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(resolved, []byte(content), 0644); err != nil {
+			return nil, err
+		}
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	return schema.AssistantMessage(a.reply+" "+messages[0].Content, nil), nil
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(cfg.Tasks) != 0 {
+		t.Errorf("missing file = %+v, %v; want an empty config", cfg, err)
+	}
+
+	cfg, err = LoadConfig(writeConfig(t, `{"tasks":[{"name":"digest","schedule":"@daily","prompt":"Summarize","timeout":"1m",
+		"sinks":[{"type":"file","path":"out.md"},{"type":"slack","url":"https://hooks.slack.test/x"}]}]}`))
+	if err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	if len(cfg.Tasks) != 1 || cfg.Tasks[0].Name != "digest" || len(cfg.Tasks[0].Sinks) != 2 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	for name, tc := range map[string]struct{ tasks, want string }{
+		"no name":          {`{"schedule":"@daily","prompt":"p"}`, "name is required"},
+		"no prompt":        {`{"name":"a","schedule":"@daily"}`, "prompt is required"},
+		"bad schedule":     {`{"name":"a","schedule":"every day","prompt":"p"}`, "invalid schedule"},
+		"bad timeout":      {`{"name":"a","schedule":"@daily","prompt":"p","timeout":"-1s"}`, "invalid timeout"},
+		"unknown sink":     {`{"name":"a","schedule":"@daily","prompt":"p","sinks":[{"type":"email"}]}`, "unknown sink type"},
+		"file sink path":   {`{"name":"a","schedule":"@daily","prompt":"p","sinks":[{"type":"file"}]}`, "requires a path"},
+		"webhook sink url": {`{"name":"a","schedule":"@daily","prompt":"p","sinks":[{"type":"webhook"}]}`, "requires a url"},
+		"duplicate name":   {`{"name":"a","schedule":"@daily","prompt":"p"},{"name":"a","schedule":"@hourly","prompt":"q"}`, "duplicate task name"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, `{"tasks":[`+tc.tasks+`]}`))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("LoadConfig = %v, want an error containing %q", err, tc.want)
+			}
+		})
+	}
+
+	if _, err := LoadConfig(writeConfig(t, `{"tasks":`)); err == nil || !strings.Contains(err.Error(), "failed to parse") {
+		t.Errorf("invalid JSON = %v, want a parse error", err)
+	}
+}
+
+func TestTaskNext(t *testing.T) {
+	now := time.Date(2025, 10, 6, 10, 30, 0, 0, time.Local) // A Monday.
+	for schedule, want := range map[string]time.Time{
+		"0 9 * * 1-5":    time.Date(2025, 10, 7, 9, 0, 0, 0, time.Local),
+		"@hourly":        time.Date(2025, 10, 6, 11, 0, 0, 0, time.Local),
+		"*/15 * * * *":   time.Date(2025, 10, 6, 10, 45, 0, 0, time.Local),
+		"not a schedule": {},
+	} {
+		if got := (Task{Schedule: schedule}).Next(now); !got.Equal(want) {
+			t.Errorf("Next(%q) = %v, want %v", schedule, got, want)
+		}
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "digest.md")
+	sink := &FileSink{Path: path}
+	started := time.Date(2025, 10, 6, 9, 0, 0, 0, time.UTC)
+	if err := sink.Deliver(context.Background(), Result{Task: "digest", Output: "  All quiet.\n", StartedAt: started}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if err := sink.Deliver(context.Background(), Result{Task: "digest", Error: "model unavailable", StartedAt: started}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## digest — 2025-10-06T09:00:00Z\n\nAll quiet.\n\n## digest — 2025-10-06T09:00:00Z\n\n**Failed:** model unavailable\n\n"
+	if string(got) != want {
+		t.Errorf("file contents:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+// receiver records the JSON bodies posted to it and answers with status.
+func receiver(t *testing.T, status int) (*httptest.Server, chan map[string]any) {
+	t.Helper()
+	bodies := make(chan map[string]any, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		bodies <- body
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, bodies
+}
+
+func TestWebhookSink(t *testing.T) {
+	srv, bodies := receiver(t, http.StatusNoContent)
+	if err := (&WebhookSink{URL: srv.URL}).Deliver(context.Background(), Result{Task: "digest", Output: "done"}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if body := <-bodies; body["task"] != "digest" || body["output"] != "done" {
+		t.Errorf("webhook body = %v", body)
+	}
+
+	failing, _ := receiver(t, http.StatusBadGateway)
+	if err := (&WebhookSink{URL: failing.URL}).Deliver(context.Background(), Result{Task: "digest"}); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Deliver to a failing endpoint = %v, want a status error", err)
+	}
+}
+
+func TestSlackSink(t *testing.T) {
+	srv, bodies := receiver(t, http.StatusOK)
+	sink := &SlackSink{WebhookURL: srv.URL}
+	if err := sink.Deliver(context.Background(), Result{Task: "digest", Output: "All quiet."}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if body := <-bodies; body["text"] != "*digest*\nAll quiet." {
+		t.Errorf("slack text = %q", body["text"])
+	}
+	if err := sink.Deliver(context.Background(), Result{Task: "digest", Error: "timeout"}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if body := <-bodies; body["text"] != ":warning: *digest* failed: timeout" {
+		t.Errorf("slack failure text = %q", body["text"])
+	}
+	changes := []snapshot.Change{{Path: "main.go", Kind: snapshot.Modified, Diff: "--- a/main.go\n"}}
+	if err := sink.Deliver(context.Background(), Result{Task: "fix", Output: "Fixed.", Changes: changes}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if body := <-bodies; body["text"] != "*fix*\nFixed.\n• modified `main.go`" {
+		t.Errorf("slack text with changes = %q", body["text"])
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	srv, bodies := receiver(t, http.StatusOK)
+	task := Task{Name: "digest", Schedule: "@daily", Prompt: "Summarize", Sinks: []SinkConfig{{Type: "webhook", URL: srv.URL}}}
+	s := New(&fakeAgent{reply: "answer to"}, []Task{task})
+
+	result, err := s.Trigger(context.Background(), "digest")
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if result.Output != "answer to Summarize" || result.Error != "" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if body := <-bodies; body["output"] != "answer to Summarize" {
+		t.Errorf("sink got %v", body)
+	}
+
+	if _, err := s.Trigger(context.Background(), "missing"); err == nil || !strings.Contains(err.Error(), "unknown task") {
+		t.Errorf("Trigger of an unknown task = %v", err)
+	}
+}
+
+func TestTriggerDeliversFailures(t *testing.T) {
+	srv, bodies := receiver(t, http.StatusOK)
+	task := Task{Name: "digest", Schedule: "@daily", Prompt: "Summarize", Sinks: []SinkConfig{{Type: "webhook", URL: srv.URL}}}
+	s := New(&fakeAgent{err: errors.New("model unavailable")}, []Task{task})
+
+	result, err := s.Trigger(context.Background(), "digest")
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if result.Error != "model unavailable" {
+		t.Errorf("result error = %q", result.Error)
+	}
+	if body := <-bodies; body["error"] != "model unavailable" {
+		t.Errorf("sink got %v", body)
+	}
+}
+
+func TestTriggerReportsChanges(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspace, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(t.TempDir(), "report.md")
+	task := Task{Name: "fix", Schedule: "@daily", Prompt: "Fix it", Workspace: workspace, Sinks: []SinkConfig{{Type: "file", Path: report}}}
+	agent := &fakeAgent{reply: "fixed", write: map[string]string{"main.go": "package main\n\nfunc main() {}\n", "go.mod": "module fix\n"}}
+
+	result, err := New(agent, []Task{task}).Trigger(context.Background(), "fix")
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	var changed []string
+	for _, c := range result.Changes {
+		changed = append(changed, c.Kind+" "+c.Path)
+	}
+	if strings.Join(changed, ", ") != "created go.mod, modified main.go" || !strings.Contains(result.Changes[1].Diff, "+func main() {}") {
+		t.Errorf("changes = %+v", result.Changes)
+	}
+	got, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "**Changed files:**\n\n```diff\ncreated  go.mod\nmodified main.go\n") {
+		t.Errorf("file sink got:\n%s", got)
+	}
+
+	// A second run that changes nothing reports nothing.
+	agent.write = nil
+	if result, _ := New(agent, []Task{task}).Trigger(context.Background(), "fix"); len(result.Changes) != 0 {
+		t.Errorf("unchanged workspace reported %+v", result.Changes)
+	}
+}
+
+func TestTriggerSkipsRunningTask(t *testing.T) {
+	agent := &fakeAgent{reply: "ok", started: make(chan struct{}, 1), block: make(chan struct{})}
+	s := New(agent, []Task{{Name: "digest", Schedule: "@daily", Prompt: "Summarize"}})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Trigger(context.Background(), "digest")
+		done <- err
+	}()
+	<-agent.started
+
+	if _, err := s.Trigger(context.Background(), "digest"); !errors.Is(err, ErrTaskRunning) {
+		t.Errorf("Trigger while running = %v, want ErrTaskRunning", err)
+	}
+	close(agent.block)
+	if err := <-done; err != nil {
+		t.Fatalf("first Trigger: %v", err)
+	}
+	if _, err := s.Trigger(context.Background(), "digest"); err != nil {
+		t.Errorf("Trigger after the run finished = %v", err)
+	}
+}
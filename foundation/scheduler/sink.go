@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/snapshot"
+)
+
+// Result is the outcome of one task run, handed to every sink.
+type Result struct {
+	Task      string        `json:"task"`
+	Prompt    string        `json:"prompt"`
+	Output    string        `json:"output,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+	// Changes are the files the run created, modified or deleted in the
+	// task's workspace.
+	Changes []snapshot.Change `json:"changes,omitempty"`
+}
+
+// Sink delivers task results somewhere a person will see them.
+type Sink interface {
+	Deliver(ctx context.Context, r Result) error
+}
+
+func newSink(cfg SinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case "file":
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("file sink requires a path")
+		}
+		return &FileSink{Path: cfg.Path}, nil
+	case "webhook":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("webhook sink requires a url")
+		}
+		return &WebhookSink{URL: cfg.URL}, nil
+	case "slack":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("slack sink requires an incoming webhook url")
+		}
+		return &SlackSink{WebhookURL: cfg.URL}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q (use file, webhook or slack)", cfg.Type)
+	}
+}
+
+// FileSink appends each result to a markdown file.
+type FileSink struct {
+	Path string
+}
+
+func (f *FileSink) Deliver(ctx context.Context, r Result) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", f.Path, err)
+	}
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.Path, err)
+	}
+	defer file.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s — %s\n\n", r.Task, r.StartedAt.Format(time.RFC3339))
+	if r.Error != "" {
+		fmt.Fprintf(&b, "**Failed:** %s\n\n", r.Error)
+	} else {
+		fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(r.Output))
+	}
+	if len(r.Changes) > 0 {
+		fmt.Fprintf(&b, "**Changed files:**\n\n```diff\n%s```\n\n", snapshot.Report(r.Changes))
+	}
+	if _, err := file.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// WebhookSink POSTs the result as JSON.
+type WebhookSink struct {
+	URL string
+}
+
+func (w *WebhookSink) Deliver(ctx context.Context, r Result) error {
+	return postJSON(ctx, w.URL, r)
+}
+
+// SlackSink posts the result to a Slack incoming webhook.
+type SlackSink struct {
+	WebhookURL string
+}
+
+func (s *SlackSink) Deliver(ctx context.Context, r Result) error {
+	text := fmt.Sprintf("*%s*\n%s", r.Task, r.Output)
+	if r.Error != "" {
+		text = fmt.Sprintf(":warning: *%s* failed: %s", r.Task, r.Error)
+	}
+	// Diffs are too long for a message; the file and webhook sinks carry them.
+	for _, c := range r.Changes {
+		text += fmt.Sprintf("\n• %s `%s`", c.Kind, c.Path)
+	}
+	return postJSON(ctx, s.WebhookURL, map[string]string{"text": text})
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+func postJSON(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("delivery failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("delivery returned status %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Package secrets resolves API keys without requiring them to sit in plain
+// environment variables. A secret named NAME is looked up, in order, in the
+// NAME environment variable, in the file named by NAME_FILE (the Docker and
+// Kubernetes convention), and in the OS keyring under the "goforai" service.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringService is the service name secrets are stored under in the OS keyring.
+const KeyringService = "goforai"
+
+// Well-known secret names.
+const (
+	GeminiAPIKey   = "GEMINI_API_KEY"
+	TavilyAPIKey   = "TAVILY_API_KEY"
+	OpenAIAPIKey   = "OPENAI_API_KEY"
+	SMTPPassword   = "SMTP_PASSWORD"
+	SendGridAPIKey = "SENDGRID_API_KEY"
+)
+
+// Known lists the secrets the agent can use, for status output.
+var Known = []string{GeminiAPIKey, TavilyAPIKey, OpenAIAPIKey, SMTPPassword, SendGridAPIKey}
+
+// ErrNotFound is returned when no provider has the secret.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider is one place secrets can come from.
+type Provider interface {
+	// Name identifies the provider in status output.
+	Name() string
+	// Lookup returns the secret and true, or false when the provider does
+	// not have it. Errors are reserved for a secret that exists but cannot
+	// be read.
+	Lookup(name string) (string, bool, error)
+}
+
+// EnvProvider reads secrets from environment variables.
+type EnvProvider struct{}
+
+func (EnvProvider) Name() string { return "env" }
+
+func (EnvProvider) Lookup(name string) (string, bool, error) {
+	v := os.Getenv(name)
+	return v, v != "", nil
+}
+
+// FileProvider reads the secret from the file named by NAME_FILE.
+type FileProvider struct{}
+
+func (FileProvider) Name() string { return "file" }
+
+func (FileProvider) Lookup(name string) (string, bool, error) {
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s_FILE: %w", name, err)
+	}
+	v := strings.TrimSpace(string(data))
+	return v, v != "", nil
+}
+
+// KeyringProvider reads secrets from the OS keyring (macOS Keychain, Windows
+// Credential Manager, or the Secret Service on Linux desktops).
+type KeyringProvider struct{}
+
+func (KeyringProvider) Name() string { return "keyring" }
+
+func (KeyringProvider) Lookup(name string) (string, bool, error) {
+	v, err := keyring.Get(KeyringService, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		// A locked or unreachable keyring may well hold the secret, so
+		// report it instead of pretending the secret is not set.
+		return "", false, fmt.Errorf("failed to read %s from the OS keyring (set %s or %s_FILE to skip it): %w",
+			name, name, name, err)
+	}
+	return v, v != "", nil
+}
+
+// Providers is the default lookup order.
+var Providers = []Provider{EnvProvider{}, FileProvider{}, KeyringProvider{}}
+
+var cache sync.Map // name -> string; keyring lookups can prompt or be slow.
+
+// Get returns the secret, or an error naming every place it was looked for.
+func Get(name string) (string, error) {
+	if v, ok := cache.Load(name); ok {
+		return v.(string), nil
+	}
+	v, _, err := Resolve(name)
+	if err != nil {
+		return "", err
+	}
+	cache.Store(name, v)
+	return v, nil
+}
+
+// Resolve looks the secret up in every provider and also returns the name of
+// the provider that supplied it.
+func Resolve(name string) (value, source string, err error) {
+	for _, p := range Providers {
+		v, ok, err := p.Lookup(name)
+		if err != nil {
+			return "", "", err
+		}
+		if ok {
+			return v, p.Name(), nil
+		}
+	}
+	return "", "", fmt.Errorf("%w: set %s, point %s_FILE at a file containing it, or run 'goforai secrets set %s'",
+		ErrNotFound, name, name, name)
+}
+
+// Set stores the secret in the OS keyring.
+func Set(name, value string) error {
+	if err := keyring.Set(KeyringService, name, value); err != nil {
+		return fmt.Errorf("failed to store %s in the OS keyring: %w", name, err)
+	}
+	cache.Delete(name)
+	return nil
+}
+
+// Delete removes the secret from the OS keyring.
+func Delete(name string) error {
+	if err := keyring.Delete(KeyringService, name); err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("%w in the OS keyring: %s", ErrNotFound, name)
+		}
+		return fmt.Errorf("failed to delete %s from the OS keyring: %w", name, err)
+	}
+	cache.Delete(name)
+	return nil
+}
@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+const testSecret = "GOFORAI_TEST_SECRET"
+
+func TestResolveOrder(t *testing.T) {
+	keyring.MockInit()
+	if err := keyring.Set(KeyringService, testSecret, "from-keyring"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { keyring.Delete(KeyringService, testSecret) })
+
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(testSecret, "from-env")
+	t.Setenv(testSecret+"_FILE", path)
+	if v, source, err := Resolve(testSecret); err != nil || v != "from-env" || source != "env" {
+		t.Errorf("Resolve = %q, %q, %v; want the environment to win", v, source, err)
+	}
+
+	t.Setenv(testSecret, "")
+	if v, source, err := Resolve(testSecret); err != nil || v != "from-file" || source != "file" {
+		t.Errorf("Resolve = %q, %q, %v; want the trimmed file contents", v, source, err)
+	}
+
+	t.Setenv(testSecret+"_FILE", "")
+	if v, source, err := Resolve(testSecret); err != nil || v != "from-keyring" || source != "keyring" {
+		t.Errorf("Resolve = %q, %q, %v; want the keyring entry", v, source, err)
+	}
+}
+
+func TestResolveMissing(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv(testSecret, "")
+	t.Setenv(testSecret+"_FILE", "")
+	_, _, err := Resolve(testSecret)
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "goforai secrets set "+testSecret) {
+		t.Errorf("Resolve = %v, want ErrNotFound with instructions", err)
+	}
+}
+
+func TestResolveReportsFileErrors(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv(testSecret, "")
+	t.Setenv(testSecret+"_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, _, err := Resolve(testSecret); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve with an unreadable _FILE = %v, want a read error", err)
+	}
+}
+
+func TestKeyringErrorsAreNotNotFound(t *testing.T) {
+	locked := errors.New("keyring is locked")
+	keyring.MockInitWithError(locked)
+	t.Cleanup(keyring.MockInit)
+
+	_, ok, err := KeyringProvider{}.Lookup(testSecret)
+	if ok || !errors.Is(err, locked) {
+		t.Errorf("Lookup on a failing keyring = %v, %v; want the keyring error", ok, err)
+	}
+
+	t.Setenv(testSecret, "")
+	t.Setenv(testSecret+"_FILE", "")
+	if _, _, err := Resolve(testSecret); errors.Is(err, ErrNotFound) || !errors.Is(err, locked) {
+		t.Errorf("Resolve on a failing keyring = %v, want the keyring error", err)
+	}
+}
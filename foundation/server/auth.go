@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"golang.org/x/time/rate"
+)
+
+// clientKey stores the authenticated *client on the request context.
+const clientKey = "goforai.client"
+
+// errBudgetExhausted stops a turn once its client has used up the day's
+// token budget, including part way through the turn.
+var errBudgetExhausted = errors.New("daily token budget exhausted")
+
+// WithAPIKeys requires every API request to carry one of keys, either as
+// "Authorization: Bearer <key>" or "X-API-Key: <key>". keys maps each key to
+// the client name used for limits and logs. Without keys the server is open
+// and clients are identified by IP address.
+func WithAPIKeys(keys map[string]string) Option {
+	return func(c *config) {
+		c.apiKeys = keys
+	}
+}
+
+// WithGroups maps client names to the groups they belong to. Knowledge base
+// searches made for a client only return the documents that are public or
+// granted, by their access metadata, to the client's name or one of its
+// groups (see chromemdb.MetaAccess).
+func WithGroups(groups map[string][]string) Option {
+	return func(c *config) {
+		c.groups = groups
+	}
+}
+
+// WithRateLimit allows each client rpm requests per minute, with bursts of
+// up to rpm. Zero disables the limit.
+func WithRateLimit(rpm int) Option {
+	return func(c *config) {
+		c.requestsPerMinute = rpm
+	}
+}
+
+// WithTokenBudget caps the model tokens each client may use per UTC day.
+// Zero means unlimited.
+func WithTokenBudget(daily int64) Option {
+	return func(c *config) {
+		c.dailyTokenBudget = daily
+	}
+}
+
+// WithBudgetAlertThreshold sets the fraction of the daily token budget at
+// which a budget.threshold event is emitted. Defaults to 0.8.
+func WithBudgetAlertThreshold(fraction float64) Option {
+	return func(c *config) {
+		c.budgetAlertThreshold = fraction
+	}
+}
+
+// WithMaxRequestBytes rejects request bodies larger than n bytes.
+func WithMaxRequestBytes(n int) Option {
+	return func(c *config) {
+		c.maxRequestBytes = n
+	}
+}
+
+// Without API keys every source address is a client, so the state of
+// clients that stop sending requests is dropped once they have been idle
+// for clientIdleTimeout, when their rate limit has long refilled, unless
+// they used tokens today. Idle clients are looked for every
+// clientSweepInterval.
+const (
+	clientIdleTimeout   = time.Hour
+	clientSweepInterval = time.Minute
+)
+
+// client tracks the limits of one API key (or IP address).
+type client struct {
+	name     string
+	limiter  *rate.Limiter
+	lastSeen time.Time // Guarded by clients.mu.
+
+	mu      sync.Mutex
+	day     string
+	used    int64
+	alerted bool // Budget threshold event already sent today.
+}
+
+// tokensToday returns the tokens used since midnight UTC.
+func (c *client) tokensToday() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rollover()
+	return c.used
+}
+
+// addTokens records usage and reports whether it crossed threshold (a
+// fraction of budget) for the first time today.
+func (c *client) addTokens(n, budget int64, threshold float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rollover()
+	c.used += n
+	if budget <= 0 || threshold <= 0 || c.alerted || float64(c.used) < threshold*float64(budget) {
+		return false
+	}
+	c.alerted = true
+	return true
+}
+
+// rollover resets the counters at midnight UTC. The caller holds c.mu.
+func (c *client) rollover() {
+	if today := time.Now().UTC().Format(time.DateOnly); c.day != today {
+		c.day, c.used, c.alerted = today, 0, false
+	}
+}
+
+// clients lazily creates the per-client state and drops that of idle
+// clients.
+type clients struct {
+	mu    sync.Mutex
+	m     map[string]*client
+	rpm   int
+	now   func() time.Time // time.Now when nil.
+	swept time.Time
+}
+
+func (cs *clients) get(name string) *client {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	now := time.Now()
+	if cs.now != nil {
+		now = cs.now()
+	}
+	if now.Sub(cs.swept) >= clientSweepInterval {
+		cs.sweep(now)
+	}
+	if c, ok := cs.m[name]; ok {
+		c.lastSeen = now
+		return c
+	}
+	limit := rate.Inf
+	if cs.rpm > 0 {
+		limit = rate.Limit(float64(cs.rpm) / 60)
+	}
+	c := &client{name: name, limiter: rate.NewLimiter(limit, max(cs.rpm, 1)), lastSeen: now}
+	cs.m[name] = c
+	return c
+}
+
+// sweep drops the clients idle since before clientIdleTimeout that used no
+// tokens today. The caller holds cs.mu.
+func (cs *clients) sweep(now time.Time) {
+	cs.swept = now
+	for name, c := range cs.m {
+		if now.Sub(c.lastSeen) >= clientIdleTimeout && c.tokensToday() == 0 {
+			delete(cs.m, name)
+		}
+	}
+}
+
+// guard authenticates the request and enforces the rate limit and token
+// budget before any API handler runs.
+func (s *Server) guard(ctx context.Context, c *app.RequestContext) {
+	name := c.ClientIP()
+	if len(s.config.apiKeys) > 0 {
+		var ok bool
+		if name, ok = s.lookupKey(requestKey(c)); !ok {
+			writeError(c, http.StatusUnauthorized, "authentication_error", "missing or invalid API key")
+			c.Abort()
+			return
+		}
+	}
+
+	cl := s.clients.get(name)
+	if !cl.limiter.Allow() {
+		c.Header("Retry-After", strconv.Itoa(int(time.Minute.Seconds())/max(s.config.requestsPerMinute, 1)+1))
+		writeError(c, http.StatusTooManyRequests, "rate_limit_error", "rate limit exceeded, slow down")
+		c.Abort()
+		return
+	}
+	if budget := s.config.dailyTokenBudget; budget > 0 && cl.tokensToday() >= budget {
+		writeError(c, http.StatusTooManyRequests, "insufficient_quota", errBudgetExhausted.Error())
+		c.Abort()
+		return
+	}
+
+	c.Set(clientKey, cl)
+	ctx = chromemdb.WithIdentity(ctx, chromemdb.Identity{Name: name, Groups: s.config.groups[name]})
+	c.Next(logger.WithContext(ctx, logger.FromContext(ctx).With("client", name)))
+}
+
+func requestKey(c *app.RequestContext) string {
+	if auth := string(c.GetHeader("Authorization")); auth != "" {
+		if key, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(key)
+		}
+	}
+	return string(c.GetHeader("X-API-Key"))
+}
+
+// lookupKey compares against every key in constant time.
+func (s *Server) lookupKey(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	var name string
+	found := false
+	for k, n := range s.config.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			name, found = n, true
+		}
+	}
+	return name, found
+}
+
+func clientName(c *app.RequestContext) string {
+	if v, ok := c.Get(clientKey); ok {
+		return v.(*client).name
+	}
+	return ""
+}
+
+// agentOptions attaches the turn's event tracking and, in a session, its
+// trace, and charges its model usage to the request's client.
+func (s *Server) agentOptions(c *app.RequestContext, conv *conversation) []compose.Option {
+	handlers := []callbacks.Handler{conv.turn.Handler()}
+	if v, ok := c.Get(clientKey); ok {
+		handlers = append(handlers, s.usageHandler(v.(*client), conv))
+	}
+	if conv.trace != nil {
+		handlers = append(handlers, conv.trace.handler())
+	}
+	return []compose.Option{compose.WithCallbacks(handlers...)}
+}
+
+// usageHandler adds the token usage of every chat model call to cl and emits
+// budget.threshold the first time a day that cl crosses the alert threshold.
+// Once the budget is spent the rest of conv's turn is aborted, so one long
+// tool loop cannot overshoot it. Streamed calls are recorded in the
+// background and tracked in conv.usage.
+func (s *Server) usageHandler(cl *client, conv *conversation) callbacks.Handler {
+	budget, threshold := s.config.dailyTokenBudget, s.config.budgetAlertThreshold
+	record := func(ctx context.Context, output callbacks.CallbackOutput) {
+		out := model.ConvCallbackOutput(output)
+		if out == nil || out.TokenUsage == nil {
+			return
+		}
+		if cl.addTokens(int64(out.TokenUsage.TotalTokens), budget, threshold) {
+			s.config.events.Emit(ctx, events.Event{
+				Type:   events.BudgetThreshold,
+				Client: cl.name,
+				Budget: &events.Budget{Used: cl.tokensToday(), Limit: budget, Threshold: threshold},
+			})
+		}
+		if budget > 0 && cl.tokensToday() >= budget {
+			conv.stop(errBudgetExhausted)
+		}
+	}
+	return callbacks.NewHandlerBuilder().
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if info.Component == components.ComponentOfChatModel {
+				record(ctx, output)
+			}
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			if info.Component != components.ComponentOfChatModel {
+				output.Close()
+				return ctx
+			}
+			conv.usage.Add(1)
+			go func() {
+				defer conv.usage.Done()
+				defer output.Close()
+				// Usage arrives on the final chunk; keep the last one seen.
+				var last callbacks.CallbackOutput
+				for {
+					chunk, err := output.Recv()
+					if err != nil { // io.EOF or a failed stream; either way we're done.
+						break
+					}
+					if out := model.ConvCallbackOutput(chunk); out != nil && out.TokenUsage != nil {
+						last = chunk
+					}
+				}
+				if last != nil {
+					record(ctx, last)
+				}
+			}()
+			return ctx
+		}).
+		Build()
+}
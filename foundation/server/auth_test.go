@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	appconfig "github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/session"
+)
+
+var testKeys = map[string]string{"sk-alice": "alice", "sk-bob": "bob"}
+
+func TestAPIKeys(t *testing.T) {
+	_, base := startServer(t, newFakeAgent(t, &fakeModel{reply: "ok"}), WithAPIKeys(testKeys))
+	url := base + "/v1/chat/completions"
+
+	for name, tc := range map[string]struct {
+		headers []string
+		status  int
+	}{
+		"missing":        {nil, http.StatusUnauthorized},
+		"wrong":          {[]string{"Authorization", "Bearer sk-mallory"}, http.StatusUnauthorized},
+		"not bearer":     {[]string{"Authorization", "sk-alice"}, http.StatusUnauthorized},
+		"bearer":         {[]string{"Authorization", "Bearer sk-alice"}, http.StatusOK},
+		"x-api-key":      {[]string{"X-API-Key", "sk-bob"}, http.StatusOK},
+		"health is open": {nil, http.StatusOK},
+	} {
+		t.Run(name, func(t *testing.T) {
+			method, target, body := http.MethodPost, url, any(chatRequest("hi", false))
+			if name == "health is open" {
+				method, target, body = http.MethodGet, base+"/healthz", nil
+			}
+			if status, raw := do(t, method, target, body, tc.headers...); status != tc.status {
+				t.Errorf("status %d, want %d: %s", status, tc.status, raw)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	_, base := startServer(t, newFakeAgent(t, &fakeModel{reply: "ok"}), WithAPIKeys(testKeys), WithRateLimit(2))
+	url := base + "/v1/chat/completions"
+
+	for i := 0; i < 2; i++ {
+		if status, body := do(t, http.MethodPost, url, chatRequest("hi", false), "X-API-Key", "sk-alice"); status != http.StatusOK {
+			t.Fatalf("request %d within the burst: status %d: %s", i+1, status, body)
+		}
+	}
+	status, body := do(t, http.MethodPost, url, chatRequest("hi", false), "X-API-Key", "sk-alice")
+	if status != http.StatusTooManyRequests || errorType(t, body) != "rate_limit_error" {
+		t.Errorf("request past the burst: status %d: %s", status, body)
+	}
+	// Limits are per client.
+	if status, body := do(t, http.MethodPost, url, chatRequest("hi", false), "X-API-Key", "sk-bob"); status != http.StatusOK {
+		t.Errorf("another client was limited: status %d: %s", status, body)
+	}
+}
+
+func TestTokenBudget(t *testing.T) {
+	agent := newFakeAgent(t, &fakeModel{reply: "ok", usage: model.TokenUsage{TotalTokens: 30}})
+	_, base := startServer(t, agent, WithAPIKeys(testKeys), WithTokenBudget(50))
+	url := base + "/v1/chat/completions"
+
+	for i := 0; i < 2; i++ {
+		if status, body := do(t, http.MethodPost, url, chatRequest("hi", false), "X-API-Key", "sk-alice"); status != http.StatusOK {
+			t.Fatalf("request %d within the budget: status %d: %s", i+1, status, body)
+		}
+	}
+	status, body := do(t, http.MethodPost, url, chatRequest("hi", false), "X-API-Key", "sk-alice")
+	if status != http.StatusTooManyRequests || errorType(t, body) != "insufficient_quota" {
+		t.Errorf("request past the budget: status %d: %s", status, body)
+	}
+}
+
+// twoCallAgent calls m twice per turn, as a tool loop would.
+func twoCallAgent(t *testing.T, m *fakeModel) *fakeAgent {
+	t.Helper()
+	graph, err := compose.NewChain[[]*schema.Message, *schema.Message]().
+		AppendChatModel(m).
+		AppendLambda(compose.InvokableLambda(func(ctx context.Context, msg *schema.Message) ([]*schema.Message, error) {
+			return []*schema.Message{msg}, nil
+		})).
+		AppendChatModel(m).
+		Compile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeAgent{graph: graph}
+}
+
+func TestTokenBudgetStopsTurn(t *testing.T) {
+	var calls atomic.Int32
+	m := &fakeModel{reply: "ok", usage: model.TokenUsage{TotalTokens: 30}, onCall: func(context.Context) { calls.Add(1) }}
+	s, base := startServer(t, twoCallAgent(t, m), WithAPIKeys(testKeys), WithTokenBudget(20))
+
+	// The first call overruns the budget, so the turn stops before the second.
+	status, body := do(t, http.MethodPost, base+"/v1/chat/completions", chatRequest("hi", false), "X-API-Key", "sk-alice")
+	if status != http.StatusTooManyRequests || errorType(t, body) != "insufficient_quota" {
+		t.Errorf("turn that overran the budget: status %d: %s", status, body)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("model was called %d times, want 1", n)
+	}
+	if used := s.clients.get("alice").tokensToday(); used != 30 {
+		t.Errorf("client was charged %d tokens, want 30", used)
+	}
+}
+
+func TestRetrievalIdentity(t *testing.T) {
+	identities := make(chan chromemdb.Identity, 1)
+	m := &fakeModel{reply: "ok", onCall: func(ctx context.Context) {
+		identity, _ := chromemdb.IdentityFrom(ctx)
+		identities <- identity
+	}}
+	_, base := startServer(t, newFakeAgent(t, m), WithAPIKeys(testKeys), WithGroups(map[string][]string{"alice": {"platform"}}))
+
+	for key, want := range map[string]chromemdb.Identity{
+		"sk-alice": {Name: "alice", Groups: []string{"platform"}},
+		"sk-bob":   {Name: "bob"},
+	} {
+		if status, body := do(t, http.MethodPost, base+"/v1/chat/completions", chatRequest("hi", false), "X-API-Key", key); status != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", key, status, body)
+		}
+		if got := <-identities; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: the turn searched as %+v, want %+v", key, got, want)
+		}
+	}
+}
+
+func TestSessionsBelongToTheirCreator(t *testing.T) {
+	sessions := session.NewManager(session.NewMemoryStore(), appconfig.Sessions{IdleTimeout: time.Hour, WorkspaceDir: t.TempDir()})
+	_, base := startServer(t, newFakeAgent(t, &fakeModel{reply: "ok"}), WithAPIKeys(testKeys), WithSessions(sessions))
+
+	status, body := do(t, http.MethodPost, base+"/v1/sessions", nil, "X-API-Key", "sk-alice")
+	if status != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", status, body)
+	}
+	var sess session.Session
+	if err := json.Unmarshal(body, &sess); err != nil {
+		t.Fatal(err)
+	}
+	if sess.Owner != "alice" {
+		t.Errorf("session owner = %q, want alice", sess.Owner)
+	}
+	url := base + "/v1/sessions/" + sess.ID
+
+	if status, body := do(t, http.MethodGet, url, nil, "X-API-Key", "sk-bob"); status != http.StatusNotFound {
+		t.Errorf("another client read the session: status %d: %s", status, body)
+	}
+	if status, body := do(t, http.MethodPost, base+"/v1/chat/completions", chatRequest("hi", false),
+		"X-API-Key", "sk-bob", sessionHeader, sess.ID); status != http.StatusNotFound {
+		t.Errorf("another client continued the session: status %d: %s", status, body)
+	}
+	if status, body := do(t, http.MethodDelete, url, nil, "X-API-Key", "sk-bob"); status != http.StatusNotFound {
+		t.Errorf("another client deleted the session: status %d: %s", status, body)
+	}
+
+	if status, body := do(t, http.MethodPost, base+"/v1/chat/completions", chatRequest("hi", false),
+		"X-API-Key", "sk-alice", sessionHeader, sess.ID); status != http.StatusOK {
+		t.Errorf("owner continuing the session: status %d: %s", status, body)
+	}
+	if status, body := do(t, http.MethodDelete, url, nil, "X-API-Key", "sk-alice"); status != http.StatusNoContent {
+		t.Errorf("owner deleting the session: status %d: %s", status, body)
+	}
+}
+
+func TestBudgetAlertFiresOnce(t *testing.T) {
+	c := &client{name: "alice"}
+	if c.addTokens(70, 100, 0.8) {
+		t.Error("alert fired below the threshold")
+	}
+	if !c.addTokens(20, 100, 0.8) {
+		t.Error("alert did not fire when crossing the threshold")
+	}
+	if c.addTokens(20, 100, 0.8) {
+		t.Error("alert fired twice in one day")
+	}
+	if got := c.tokensToday(); got != 110 {
+		t.Errorf("tokensToday = %d, want 110", got)
+	}
+}
+
+func errorType(t *testing.T, body []byte) string {
+	t.Helper()
+	var resp errorResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("invalid error body %s: %v", body, err)
+	}
+	return resp.Error.Type
+}
+
+func TestIdleClientsAreDropped(t *testing.T) {
+	now := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	cs := &clients{m: make(map[string]*client), rpm: 60, now: func() time.Time { return now }}
+	for i := range 1000 {
+		cs.get(fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	cs.get("alice").addTokens(10, 0, 0)
+
+	// A client that keeps sending requests stays, as does one that used
+	// tokens today; the others are dropped once idle.
+	for range 2 * int(clientIdleTimeout/clientSweepInterval) {
+		now = now.Add(clientSweepInterval)
+		cs.get("bob")
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if len(cs.m) != 2 || cs.m["alice"] == nil || cs.m["bob"] == nil {
+		t.Errorf("%d clients kept, want alice and bob", len(cs.m))
+	}
+}
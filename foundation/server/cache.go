@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/olusolaa/goforai/foundation/answercache"
+	"github.com/olusolaa/goforai/foundation/logger"
+)
+
+// cacheHeader tells clients whether the answer came from the answer cache:
+// "hit" when it did, "miss" when the agent answered and the answer was
+// cached.
+const cacheHeader = "X-Answer-Cache"
+
+// WithAnswerCache answers questions from c when a near-identical question
+// was answered before from the same knowledge base, without running the
+// agent. Only the first question of a conversation is looked up, since later
+// ones are read in the light of what came before.
+func WithAnswerCache(c *answercache.Cache) Option {
+	return func(cfg *config) {
+		cfg.answers = c
+	}
+}
+
+// lookupAnswer looks conv's question up in the answer cache. On a hit it
+// sets conv.cached, which generate and stream then return instead of running
+// the agent; on a miss it keeps the question in conv so that
+// closeConversation caches the answer.
+func (s *Server) lookupAnswer(ctx context.Context, c *app.RequestContext, conv *conversation) {
+	if s.config.answers == nil {
+		return
+	}
+	var question string
+	for _, m := range conv.Messages {
+		switch m.Role {
+		case schema.User:
+			if question != "" {
+				return
+			}
+			question = m.Content
+		case schema.Assistant:
+			return
+		}
+	}
+
+	log := logger.FromContext(ctx)
+	q, err := s.config.answers.Prepare(ctx, question, s.answerScope(c))
+	if err != nil {
+		log.Warn("answer cache unavailable", "error", err)
+		return
+	}
+	hit, err := s.config.answers.Lookup(q)
+	if err != nil {
+		log.Warn("answer cache unavailable", "error", err)
+		return
+	}
+	if hit == nil {
+		conv.cacheQuery = q
+		c.Header(cacheHeader, "miss")
+		return
+	}
+	log.Info("answered from cache", "score", hit.Score, "cached_question", hit.Question)
+	c.Header(cacheHeader, "hit")
+	conv.cached = schema.AssistantMessage(hit.Answer, nil)
+}
+
+// generate answers conv with the agent, or from the answer cache.
+func (s *Server) generate(ctx context.Context, conv *conversation, opts ...compose.Option) (*schema.Message, error) {
+	if conv.cached != nil {
+		return conv.cached, nil
+	}
+	return s.agent.Generate(ctx, conv.Messages, opts...)
+}
+
+// stream streams the answer to conv from the agent, or from the answer cache
+// in one piece.
+func (s *Server) stream(ctx context.Context, conv *conversation, opts ...compose.Option) (*schema.StreamReader[*schema.Message], error) {
+	if conv.cached != nil {
+		return schema.StreamReaderFromArray([]*schema.Message{conv.cached}), nil
+	}
+	return s.agent.Stream(ctx, conv.Messages, opts...)
+}
+
+// cacheAnswer caches the agent's answer to a question lookupAnswer missed.
+func (s *Server) cacheAnswer(ctx context.Context, conv *conversation, reply *schema.Message) {
+	if conv.cacheQuery == nil {
+		return
+	}
+	if err := s.config.answers.Store(conv.cacheQuery, reply.Content); err != nil {
+		logger.FromContext(ctx).Warn("failed to cache answer", "error", err)
+	}
+}
+
+// answerScope returns who the cached answers of a request may be shared
+// with. With API keys, clients may be allowed to read different knowledge
+// base documents, so each client only gets its own answers; without them
+// every request reads the same documents.
+func (s *Server) answerScope(c *app.RequestContext) string {
+	if len(s.config.apiKeys) == 0 {
+		return ""
+	}
+	return clientName(c)
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"github.com/olusolaa/goforai/foundation/answercache"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+)
+
+type wordEmbedder struct{}
+
+func (wordEmbedder) EmbedStrings(_ context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = fakeapi.Embed(text)
+	}
+	return vectors, nil
+}
+
+func TestAnswerCache(t *testing.T) {
+	var calls atomic.Int32
+	agent := newFakeAgent(t, &fakeModel{reply: "Eino is a Go framework.", onCall: func(context.Context) { calls.Add(1) }})
+	cache := answercache.New(filepath.Join(t.TempDir(), "answers.json"), wordEmbedder{}, "fake")
+	_, base := startServer(t, agent, WithAnswerCache(cache))
+
+	ask := func(content string, stream bool) (string, string) {
+		t.Helper()
+		raw, _ := json.Marshal(chatRequest(content, stream))
+		resp, err := http.Post(base+"/v1/chat/completions", "application/json", bytes.NewReader(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status %d: %s", resp.StatusCode, body)
+		}
+		if stream {
+			var content string
+			for _, d := range sseData(body) {
+				var chunk chatCompletionResponse
+				if json.Unmarshal([]byte(d), &chunk) == nil {
+					for _, c := range chunk.Choices {
+						if c.Delta != nil {
+							content += c.Delta.Content
+						}
+					}
+				}
+			}
+			return resp.Header.Get(cacheHeader), content
+		}
+		var completion chatCompletionResponse
+		if err := json.Unmarshal(body, &completion); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Get(cacheHeader), completion.Choices[0].Message.Content
+	}
+
+	if cached, content := ask("What is Eino?", false); cached != "miss" || content != "Eino is a Go framework." {
+		t.Fatalf("first answer = %q (%s)", content, cached)
+	}
+	for _, stream := range []bool{false, true} {
+		if cached, content := ask("what is  eino?", stream); cached != "hit" || content != "Eino is a Go framework." {
+			t.Errorf("repeated question (stream %v) = %q (%s), want the cached answer", stream, content, cached)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("model was called %d times, want 1", n)
+	}
+}
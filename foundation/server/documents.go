@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/indexing"
+	"github.com/olusolaa/goforai/foundation/logger"
+)
+
+// WithDocumentIngestion serves POST /v1/documents, through which external
+// systems write documents into the knowledge base with in. writers lists the
+// clients allowed to, as client names and "group:<name>" entries, or "*" for
+// every client.
+func WithDocumentIngestion(in *indexing.Ingester, writers string) Option {
+	return func(c *config) {
+		c.ingester = in
+		c.documentWriters = writers
+	}
+}
+
+// documentsRequest is the body of POST /v1/documents.
+type documentsRequest struct {
+	Collection string            `json:"collection,omitempty"` // An existing collection; defaults to the knowledge base's.
+	Documents  []documentRequest `json:"documents"`
+	Split      bool              `json:"split,omitempty"`  // Chunk each document before storing it.
+	Delete     []string          `json:"delete,omitempty"` // IDs of documents to remove.
+}
+
+type documentRequest struct {
+	ID       string         `json:"id"`
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+type documentsResponse struct {
+	Collection string   `json:"collection,omitempty"`
+	IDs        []string `json:"ids"`
+	Deleted    int      `json:"deleted"` // Documents of the delete list that were stored.
+}
+
+func (s *Server) documentRoutes(v1 *route.RouterGroup) {
+	if s.config.ingester == nil {
+		return
+	}
+	v1.POST("/documents", s.handleDocuments)
+}
+
+func (s *Server) handleDocuments(ctx context.Context, c *app.RequestContext) {
+	if !s.canWriteDocuments(c) {
+		writeError(c, http.StatusForbidden, "permission_error", "this client may not write documents")
+		return
+	}
+	var req documentsRequest
+	if err := json.Unmarshal(c.Request.Body(), &req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid JSON body: %v", err))
+		return
+	}
+	if len(req.Documents) == 0 && len(req.Delete) == 0 {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", "documents and delete cannot both be empty")
+		return
+	}
+
+	docs := make([]*schema.Document, len(req.Documents))
+	for i, d := range req.Documents {
+		docs[i] = &schema.Document{ID: d.ID, Content: d.Content, MetaData: d.Metadata}
+	}
+	ids, deleted, err := s.config.ingester.Ingest(ctx, req.Collection, docs, req.Split, req.Delete)
+	if errors.Is(err, indexing.ErrInvalidDocument) || errors.Is(err, indexing.ErrUnknownCollection) {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	if err != nil {
+		logger.FromContext(ctx).Error("document ingestion failed", "collection", req.Collection, "error", err)
+		writeError(c, http.StatusInternalServerError, "server_error", "the documents could not be stored; see the server logs")
+		return
+	}
+	logger.FromContext(ctx).Info("documents ingested", "collection", req.Collection, "stored", len(ids), "deleted", deleted)
+	c.JSON(http.StatusOK, documentsResponse{Collection: req.Collection, IDs: ids, Deleted: deleted})
+}
+
+// canWriteDocuments reports whether the request's client is one of the
+// document writers.
+func (s *Server) canWriteDocuments(c *app.RequestContext) bool {
+	writers := strings.TrimSpace(s.config.documentWriters)
+	if writers == "*" {
+		return true
+	}
+	if writers == "" {
+		return false
+	}
+	name := clientName(c)
+	return chromemdb.Identity{Name: name, Groups: s.config.groups[name]}.CanRead(writers)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/indexing"
+	chromem "github.com/philippgille/chromem-go"
+)
+
+func TestDocumentIngestion(t *testing.T) {
+	ctx := context.Background()
+	kb, err := chromemdb.New(ctx, indexing.CollectionName, wordEmbedder{}, chromemdb.WithDB(chromem.NewDB()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := indexing.NewIngester(kb, filepath.Join(t.TempDir(), "chromem.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, base := startServer(t, newFakeAgent(t, &fakeModel{reply: "ok"}),
+		WithAPIKeys(map[string]string{"cms-key": "cms", "user-key": "ada"}),
+		WithGroups(map[string][]string{"cms": {"publishers"}}),
+		WithDocumentIngestion(in, "group:publishers"))
+
+	body := map[string]any{"documents": []map[string]any{{"id": "talks/eino", "content": "Eino is a Go framework for LLM apps."}}}
+	if status, resp := do(t, http.MethodPost, base+"/v1/documents", body, "Authorization", "Bearer user-key"); status != http.StatusForbidden {
+		t.Errorf("client outside the writers: status %d: %s", status, resp)
+	}
+	status, resp := do(t, http.MethodPost, base+"/v1/documents", body, "Authorization", "Bearer cms-key")
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %s", status, resp)
+	}
+	var got documentsResponse
+	if err := json.Unmarshal(resp, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.IDs) != 1 || got.IDs[0] != "talks/eino" {
+		t.Errorf("unexpected response %+v", got)
+	}
+	if docs, err := kb.Retrieve(ctx, "Eino framework"); err != nil || len(docs) != 1 {
+		t.Errorf("ingested document not searchable: %v, %v", docs, err)
+	}
+
+	// Only the documents that were stored count as deleted.
+	body = map[string]any{"delete": []string{"talks/eino", "talks/unknown"}}
+	status, resp = do(t, http.MethodPost, base+"/v1/documents", body, "Authorization", "Bearer cms-key")
+	if err := json.Unmarshal(resp, &got); status != http.StatusOK || err != nil || got.Deleted != 1 {
+		t.Errorf("delete: status %d: %s", status, resp)
+	}
+
+	body = map[string]any{"collection": "new", "documents": []map[string]any{{"id": "x", "content": "x"}}}
+	if status, resp := do(t, http.MethodPost, base+"/v1/documents", body, "Authorization", "Bearer cms-key"); status != http.StatusBadRequest {
+		t.Errorf("unknown collection: status %d: %s", status, resp)
+	}
+
+	body = map[string]any{"documents": []map[string]any{{"content": "no id"}}}
+	if status, resp := do(t, http.MethodPost, base+"/v1/documents", body, "Authorization", "Bearer cms-key"); status != http.StatusBadRequest {
+		t.Errorf("document without an id: status %d: %s", status, resp)
+	}
+}
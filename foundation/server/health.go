@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/olusolaa/goforai/foundation/logger"
+)
+
+// readyCacheTTL limits how often probes hit dependencies such as the model
+// API; Kubernetes probes every few seconds by default.
+const readyCacheTTL = 10 * time.Second
+
+// readyCheckTimeout bounds a single readiness check.
+const readyCheckTimeout = 5 * time.Second
+
+// Check reports whether a dependency is usable. A nil error means healthy.
+type Check func(ctx context.Context) error
+
+// WithReadinessCheck adds a dependency that must be healthy for /readyz to
+// succeed.
+func WithReadinessCheck(name string, check Check) Option {
+	return func(c *config) {
+		if c.readinessChecks == nil {
+			c.readinessChecks = make(map[string]Check)
+		}
+		c.readinessChecks[name] = check
+	}
+}
+
+// WithShutdownTimeout bounds how long Run waits for in-flight turns to finish
+// after its context is cancelled. Defaults to 30 seconds.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.shutdownTimeout = d
+	}
+}
+
+type readiness struct {
+	mu      sync.Mutex
+	checked time.Time
+	status  int
+	report  map[string]string
+}
+
+func (s *Server) healthRoutes() {
+	s.hertz.GET("/healthz", s.handleHealthz)
+	s.hertz.GET("/readyz", s.handleReadyz)
+}
+
+// handleHealthz is the liveness probe: the process is up and serving.
+func (s *Server) handleHealthz(ctx context.Context, c *app.RequestContext) {
+	c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz is the readiness probe. It fails while draining so the load
+// balancer stops routing new turns here, and when a dependency is down.
+func (s *Server) handleReadyz(ctx context.Context, c *app.RequestContext) {
+	if s.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, map[string]any{"status": "draining"})
+		return
+	}
+
+	status, report := s.checkReadiness(ctx)
+	body := map[string]any{"status": "ready", "checks": report}
+	if status != http.StatusOK {
+		body["status"] = "unavailable"
+	}
+	c.JSON(status, body)
+}
+
+// checkReadiness runs every check concurrently, reusing a recent result.
+// Failures are logged; the report only says which check failed, since the
+// probe is unauthenticated and errors can name hosts and paths.
+func (s *Server) checkReadiness(ctx context.Context) (int, map[string]string) {
+	s.ready.mu.Lock()
+	defer s.ready.mu.Unlock()
+	if s.ready.report != nil && time.Since(s.ready.checked) < readyCacheTTL {
+		return s.ready.status, s.ready.report
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readyCheckTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	status := http.StatusOK
+	report := make(map[string]string, len(s.config.readinessChecks))
+	for name, check := range s.config.readinessChecks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := "ok"
+			if err := check(ctx); err != nil {
+				logger.FromContext(ctx).Warn("readiness check failed", "check", name, "error", err)
+				result = "unavailable"
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report[name] = result
+			if result != "ok" {
+				status = http.StatusServiceUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.ready.checked, s.ready.status, s.ready.report = time.Now(), status, report
+	return status, report
+}
+
+// track counts in-flight API requests so shutdown can wait for them, and
+// turns new requests away once draining has started.
+func (s *Server) track(ctx context.Context, c *app.RequestContext) {
+	s.inflight.Add(1)
+	defer s.inflight.Add(-1)
+	// Checked after counting the request, so shutdown either sees it in
+	// flight or it sees the server draining.
+	if s.draining.Load() {
+		c.Header("Connection", "close")
+		writeError(c, http.StatusServiceUnavailable, "server_error", "the server is shutting down, retry the request")
+		c.Abort()
+		return
+	}
+	c.Next(ctx)
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadyzHidesCheckErrors(t *testing.T) {
+	_, base := startServer(t, newFakeAgent(t, &fakeModel{reply: "ok"}),
+		WithReadinessCheck("model", func(context.Context) error { return nil }),
+		WithReadinessCheck("knowledge_base", func(context.Context) error {
+			return errors.New("open /srv/data/index.gob: permission denied")
+		}))
+
+	status, body := do(t, http.MethodGet, base+"/readyz", nil)
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want 503", status)
+	}
+	var resp struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "unavailable" || resp.Checks["model"] != "ok" || resp.Checks["knowledge_base"] != "unavailable" {
+		t.Errorf("unexpected readiness report %s", body)
+	}
+	if strings.Contains(string(body), "/srv/data") {
+		t.Errorf("readiness report leaks the check error: %s", body)
+	}
+}
+
+func TestDrainingRejectsNewRequests(t *testing.T) {
+	s, base := startServer(t, newFakeAgent(t, &fakeModel{reply: "ok"}))
+	s.draining.Store(true)
+
+	for _, path := range []string{"/v1/chat/completions", "/api/chat"} {
+		status, body := do(t, http.MethodPost, base+path, chatRequest("hi", false))
+		if status != http.StatusServiceUnavailable {
+			t.Errorf("%s while draining: status %d: %s", path, status, body)
+		}
+	}
+	if status, _ := do(t, http.MethodGet, base+"/readyz", nil); status != http.StatusServiceUnavailable {
+		t.Errorf("/readyz while draining: status %d, want 503", status)
+	}
+	if status, _ := do(t, http.MethodGet, base+"/healthz", nil); status != http.StatusOK {
+		t.Errorf("/healthz while draining: status %d, want 200", status)
+	}
+	if n := s.inflight.Load(); n != 0 {
+		t.Errorf("%d requests still counted in flight", n)
+	}
+}
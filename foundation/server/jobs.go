@@ -0,0 +1,493 @@
+package server
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/google/uuid"
+	appconfig "github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// Job states. A job that failed and will be retried is queued again.
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+	jobCanceled  = "canceled"
+)
+
+// errJobCanceled stops a running job whose client canceled it.
+var errJobCanceled = errors.New("the job was canceled")
+
+// WithJobs serves POST /v1/jobs, which queues a turn for cfg.Workers
+// background workers instead of holding the request open for it, and the
+// endpoints that poll and cancel jobs. Jobs live in memory: the ones still
+// queued when the server stops are lost. Without it, or with no workers,
+// the job API is not served.
+func WithJobs(cfg appconfig.Jobs) Option {
+	return func(c *config) {
+		c.jobs = cfg
+	}
+}
+
+// job is one queued turn. The exported fields are what clients poll.
+type job struct {
+	ID         string                  `json:"id"`
+	Object     string                  `json:"object"`
+	Status     string                  `json:"status"`
+	Priority   int                     `json:"priority"`
+	Attempts   int                     `json:"attempts"`
+	SessionID  string                  `json:"session_id,omitempty"`
+	CreatedAt  time.Time               `json:"created_at"`
+	StartedAt  *time.Time              `json:"started_at,omitempty"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	RetryAt    *time.Time              `json:"retry_at,omitempty"`
+	Result     *chatCompletionResponse `json:"result,omitempty"`
+	Error      *apiError               `json:"error,omitempty"`
+
+	owner    string
+	ctx      context.Context     // The submitting request's context, detached from it.
+	request  *app.RequestContext // A copy of the submitting request, for its headers and client.
+	messages []*schema.Message
+	seq      uint64                  // Submission order, which breaks priority ties.
+	index    int                     // Position in the queue's heap; -1 when not in it.
+	cancel   context.CancelCauseFunc // Stops the running attempt.
+	retry    *time.Timer             // Requeues the job after a failed attempt.
+}
+
+// jobHeap orders jobs by priority, highest first, then by submission.
+type jobHeap []*job
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *jobHeap) Push(x any) {
+	j := x.(*job)
+	j.index = len(*h)
+	*h = append(*h, j)
+}
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	j := old[len(old)-1]
+	old[len(old)-1] = nil
+	j.index = -1
+	*h = old[:len(old)-1]
+	return j
+}
+
+// jobQueue holds the jobs of the server. Jobs of one session run one at a
+// time in the order they were submitted, so that each turn sees the answers
+// to the ones before it: only the first unfinished job of a session is ever
+// in the heap.
+type jobQueue struct {
+	cfg  appconfig.Jobs
+	wake chan struct{} // Signalled when the heap may have a job for a worker.
+
+	mu       sync.Mutex
+	jobs     map[string]*job
+	waiting  jobHeap
+	sessions map[string][]*job // Unfinished jobs by session, in submission order.
+	seq      uint64
+}
+
+func newJobQueue(cfg appconfig.Jobs) *jobQueue {
+	return &jobQueue{
+		cfg:      cfg,
+		wake:     make(chan struct{}, 1),
+		jobs:     make(map[string]*job),
+		sessions: make(map[string][]*job),
+	}
+}
+
+func (q *jobQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// submit queues j unless the queue is full.
+func (q *jobQueue) submit(j *job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune()
+	queued := 0
+	for _, other := range q.jobs {
+		if other.Status == jobQueued {
+			queued++
+		}
+	}
+	if queued >= q.cfg.QueueSize {
+		return false
+	}
+	q.seq++
+	j.seq, j.index = q.seq, -1
+	q.jobs[j.ID] = j
+	if j.SessionID != "" {
+		q.sessions[j.SessionID] = append(q.sessions[j.SessionID], j)
+		if len(q.sessions[j.SessionID]) > 1 {
+			return true
+		}
+	}
+	heap.Push(&q.waiting, j)
+	q.signal()
+	return true
+}
+
+// next marks the most urgent waiting job running and returns it with the
+// context of its attempt, or nil.
+func (q *jobQueue) next() (*job, context.Context, context.CancelCauseFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiting) == 0 {
+		return nil, nil, nil
+	}
+	j := heap.Pop(&q.waiting).(*job)
+	if len(q.waiting) > 0 {
+		q.signal() // Another worker may take the rest.
+	}
+	ctx, cancel := context.WithCancelCause(j.ctx)
+	now := time.Now()
+	j.Status, j.StartedAt, j.RetryAt, j.cancel = jobRunning, &now, nil, cancel
+	j.Attempts++
+	return j, ctx, cancel
+}
+
+// finish records the outcome of a job's attempt. A retryable failure with
+// attempts left requeues the job after the backoff.
+func (q *jobQueue) finish(j *job, result *chatCompletionResponse, failure *apiError, retry bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j.cancel = nil
+	if j.Status == jobCanceled {
+		q.done(j)
+		return
+	}
+	j.Result, j.Error = result, failure
+	if failure != nil && retry && j.Attempts < q.cfg.MaxAttempts {
+		wait := q.cfg.RetryBackoff << (j.Attempts - 1)
+		at := time.Now().Add(wait)
+		j.Status, j.RetryAt = jobQueued, &at
+		j.retry = time.AfterFunc(wait, func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			if j.Status == jobQueued && j.index < 0 {
+				heap.Push(&q.waiting, j)
+				q.signal()
+			}
+		})
+		return
+	}
+	j.Status = jobSucceeded
+	if failure != nil {
+		j.Status = jobFailed
+	}
+	q.done(j)
+}
+
+// cancel stops j, reporting false if it had already finished.
+func (q *jobQueue) cancel(j *job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	switch j.Status {
+	case jobQueued:
+		if j.index >= 0 {
+			heap.Remove(&q.waiting, j.index)
+		}
+		if j.retry != nil {
+			j.retry.Stop()
+		}
+		j.Status = jobCanceled
+		q.done(j)
+	case jobRunning:
+		// The worker records the job as done when the attempt stops.
+		j.Status = jobCanceled
+		j.cancel(errJobCanceled)
+	default:
+		return false
+	}
+	return true
+}
+
+// done stamps j finished and, in a session, starts the session's next job.
+// The caller holds q.mu.
+func (q *jobQueue) done(j *job) {
+	now := time.Now()
+	j.FinishedAt, j.RetryAt = &now, nil
+	j.ctx, j.request, j.messages = nil, nil, nil
+	if j.SessionID == "" {
+		return
+	}
+	pending := slices.DeleteFunc(q.sessions[j.SessionID], func(other *job) bool { return other == j })
+	if len(pending) == 0 {
+		delete(q.sessions, j.SessionID)
+		return
+	}
+	q.sessions[j.SessionID] = pending
+	if first := pending[0]; first.index < 0 && first.Status == jobQueued && first.retry == nil {
+		heap.Push(&q.waiting, first)
+		q.signal()
+	}
+}
+
+// prune drops the jobs that finished more than the retention ago. The caller
+// holds q.mu.
+func (q *jobQueue) prune() {
+	cutoff := time.Now().Add(-q.cfg.Retention)
+	for id, j := range q.jobs {
+		if j.FinishedAt != nil && j.FinishedAt.Before(cutoff) {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// lookup returns the job if owner owns it, or nil.
+func (q *jobQueue) lookup(id, owner string) *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune()
+	if j, ok := q.jobs[id]; ok && j.owner == owner {
+		return j
+	}
+	return nil
+}
+
+// view returns a copy of j that is safe to encode while it runs.
+func (q *jobQueue) view(j *job) job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return *j
+}
+
+// list returns copies of owner's jobs in the order they were submitted.
+func (q *jobQueue) list(owner string) []job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.prune()
+	out := []job{}
+	for _, j := range q.jobs {
+		if j.owner == owner {
+			out = append(out, *j)
+		}
+	}
+	slices.SortFunc(out, func(a, b job) int { return cmp.Compare(a.seq, b.seq) })
+	return out
+}
+
+// runJobs runs the queue's workers until ctx is cancelled. A job that is
+// running then is finished first: shutdown waits for it as for a request.
+func (s *Server) runJobs(ctx context.Context) {
+	for range s.config.jobs.Workers {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-s.jobs.wake:
+				}
+				for ctx.Err() == nil && s.runNextJob() {
+				}
+			}
+		}()
+	}
+}
+
+// runNextJob runs the most urgent waiting job and reports whether there was
+// one. It counts as in flight, like a request, so shutdown waits for it.
+func (s *Server) runNextJob() bool {
+	s.inflight.Add(1)
+	defer s.inflight.Add(-1)
+	if s.draining.Load() {
+		return false
+	}
+	j, ctx, cancel := s.jobs.next()
+	if j == nil {
+		return false
+	}
+	defer cancel(nil)
+
+	ctx, requestID := logger.WithRequestID(ctx)
+	log := logger.FromContext(ctx).With("job_id", j.ID, "attempt", j.Attempts)
+	ctx = logger.WithContext(ctx, log)
+	log.Info("job started", "priority", j.Priority, "messages", len(j.messages))
+
+	msg, err := s.runJobTurn(ctx, j.request, requestID, j.messages)
+	if err != nil {
+		failure, retry := jobError(err)
+		log.Error("job failed", "error", err, "retry", retry)
+		s.jobs.finish(j, nil, failure, retry)
+		return true
+	}
+	stop := "stop"
+	s.jobs.finish(j, &chatCompletionResponse{
+		ID:      "chatcmpl-" + requestID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   ModelName,
+		Choices: []choice{{
+			Message:      &responseMessage{Role: "assistant", Content: msg.Content},
+			FinishReason: &stop,
+		}},
+		Usage: usageOf(msg),
+	}, nil, false)
+	log.Info("job succeeded")
+	return true
+}
+
+// runJobTurn runs one turn as handleChatCompletions does without streaming.
+func (s *Server) runJobTurn(ctx context.Context, c *app.RequestContext, requestID string, messages []*schema.Message) (*schema.Message, error) {
+	ctx, conv, err := s.openConversation(ctx, c, requestID, messages)
+	if err != nil {
+		return nil, err
+	}
+	s.lookupAnswer(ctx, c, conv)
+	msg, err := s.generate(ctx, conv, s.agentOptions(c, conv)...)
+	if err != nil {
+		err = turnError(ctx, err)
+	}
+	s.closeConversation(ctx, conv, msg, err)
+	return msg, err
+}
+
+// jobError returns the description of a failed attempt clients may see, and
+// whether another attempt could succeed. Rate limits, stalls and unexpected
+// failures are retried; a spent budget, a denied tool call, an overlong
+// conversation or a missing session would only fail again.
+func jobError(err error) (*apiError, bool) {
+	switch {
+	case errors.Is(err, session.ErrNotFound):
+		return &apiError{Type: "not_found_error", Message: err.Error()}, false
+	case errors.Is(err, session.ErrClosed):
+		return &apiError{Type: "session_closed", Message: err.Error()}, false
+	}
+	_, resp := agentError(err)
+	retry := !errors.Is(err, errBudgetExhausted) &&
+		!errors.Is(err, tools.ErrToolDenied) &&
+		!errors.Is(err, gemini.ErrContextTooLong)
+	return &resp.Error, retry
+}
+
+func (s *Server) jobRoutes(v1 *route.RouterGroup) {
+	if s.config.jobs.Workers <= 0 {
+		return
+	}
+	v1.POST("/jobs", s.handleSubmitJob)
+	v1.GET("/jobs", s.handleListJobs)
+	v1.GET("/jobs/:id", s.handleGetJob)
+	v1.DELETE("/jobs/:id", s.handleCancelJob)
+}
+
+type jobRequest struct {
+	Messages []chatMessage `json:"messages"`
+	Priority int           `json:"priority"` // Higher runs first; 0 by default.
+}
+
+// handleSubmitJob queues a turn and answers 202 with the job to poll. The
+// X-Session-ID header works as for chat completions; the jobs of a session
+// run in the order they were submitted.
+func (s *Server) handleSubmitJob(ctx context.Context, c *app.RequestContext) {
+	var req jobRequest
+	if err := json.Unmarshal(c.Request.Body(), &req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid JSON body: %v", err))
+		return
+	}
+	messages, err := toSchemaMessages(req.Messages)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	j := &job{
+		ID:        "job-" + uuid.NewString(),
+		Object:    "job",
+		Status:    jobQueued,
+		Priority:  req.Priority,
+		CreatedAt: time.Now(),
+		owner:     s.sessionOwner(c),
+		ctx:       context.WithoutCancel(ctx),
+		request:   c.Copy(),
+		messages:  messages,
+	}
+	if s.config.sessions != nil {
+		j.SessionID = string(c.GetHeader(sessionHeader))
+	}
+	if j.SessionID != "" {
+		// Fail now rather than when the job runs.
+		if _, err := s.getSession(ctx, c, j.SessionID); err != nil {
+			writeSessionError(ctx, c, err)
+			return
+		}
+	}
+	view := *j
+	if !s.jobs.submit(j) {
+		c.Header("Retry-After", "60")
+		writeError(c, http.StatusServiceUnavailable, "server_error", "the job queue is full, retry later")
+		return
+	}
+	logger.FromContext(ctx).Info("job queued", "job_id", j.ID, "priority", j.Priority)
+	c.Header("Location", "/v1/jobs/"+j.ID)
+	c.JSON(http.StatusAccepted, view)
+}
+
+// handleListJobs returns the client's jobs, finished ones while they are
+// retained, in the order they were submitted.
+func (s *Server) handleListJobs(ctx context.Context, c *app.RequestContext) {
+	c.JSON(http.StatusOK, map[string]any{
+		"object": "list",
+		"data":   s.jobs.list(s.sessionOwner(c)),
+	})
+}
+
+// handleGetJob returns a job of the client; the jobs of other clients are
+// reported as missing, as sessions are.
+func (s *Server) handleGetJob(ctx context.Context, c *app.RequestContext) {
+	j := s.jobs.lookup(c.Param("id"), s.sessionOwner(c))
+	if j == nil {
+		writeError(c, http.StatusNotFound, "not_found_error", "job not found")
+		return
+	}
+	c.JSON(http.StatusOK, s.jobs.view(j))
+}
+
+// handleCancelJob cancels a queued or running job. A running job's turn is
+// stopped where it is.
+func (s *Server) handleCancelJob(ctx context.Context, c *app.RequestContext) {
+	j := s.jobs.lookup(c.Param("id"), s.sessionOwner(c))
+	if j == nil {
+		writeError(c, http.StatusNotFound, "not_found_error", "job not found")
+		return
+	}
+	if !s.jobs.cancel(j) {
+		writeError(c, http.StatusConflict, "invalid_request_error", "the job has already finished")
+		return
+	}
+	c.JSON(http.StatusOK, s.jobs.view(j))
+}
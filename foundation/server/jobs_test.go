@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	appconfig "github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/gemini"
+)
+
+// queueAgent answers each prompt once gate is closed, failing the first
+// failures calls as rate limited, and records the prompts in the order it ran
+// them.
+type queueAgent struct {
+	gate chan struct{}
+
+	mu       sync.Mutex
+	failures int
+	prompts  []string
+}
+
+func (a *queueAgent) Generate(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (*schema.Message, error) {
+	prompt := messages[len(messages)-1].Content
+	a.mu.Lock()
+	a.prompts = append(a.prompts, prompt)
+	a.mu.Unlock()
+	select {
+	case <-a.gate:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.failures > 0 {
+		a.failures--
+		return nil, gemini.ErrRateLimited
+	}
+	return schema.AssistantMessage("answer to "+prompt, nil), nil
+}
+
+func (a *queueAgent) Stream(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, errors.New("not streamed")
+}
+
+func (a *queueAgent) ran() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.prompts)
+}
+
+// submitJob queues prompt and returns the job's ID.
+func submitJob(t *testing.T, base, prompt string, priority int, headers ...string) string {
+	t.Helper()
+	body := map[string]any{"priority": priority, "messages": []map[string]string{{"role": "user", "content": prompt}}}
+	status, raw := do(t, http.MethodPost, base+"/v1/jobs", body, headers...)
+	if status != http.StatusAccepted {
+		t.Fatalf("submit %q: status %d: %s", prompt, status, raw)
+	}
+	var j job
+	if err := json.Unmarshal(raw, &j); err != nil {
+		t.Fatal(err)
+	}
+	return j.ID
+}
+
+// waitJob polls the job until it has status.
+func waitJob(t *testing.T, base, id, status string, headers ...string) job {
+	t.Helper()
+	var j job
+	for range 500 {
+		code, raw := do(t, http.MethodGet, base+"/v1/jobs/"+id, nil, headers...)
+		if code != http.StatusOK {
+			t.Fatalf("poll %s: status %d: %s", id, code, raw)
+		}
+		j = job{}
+		if err := json.Unmarshal(raw, &j); err != nil {
+			t.Fatal(err)
+		}
+		if j.Status == status {
+			return j
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s is %s, want %s", id, j.Status, status)
+	return j
+}
+
+func TestJobs(t *testing.T) {
+	agent := &queueAgent{gate: make(chan struct{}), failures: 1}
+	_, base := startServer(t, agent, WithAPIKeys(testKeys), WithRateLimit(1000), WithJobs(appconfig.Jobs{
+		Workers: 1, QueueSize: 2, MaxAttempts: 2, RetryBackoff: 20 * time.Millisecond, Retention: time.Hour,
+	}))
+	alice := []string{"Authorization", "Bearer sk-alice"}
+
+	first := submitJob(t, base, "first", 0, alice...)
+	waitJob(t, base, first, jobRunning, alice...)
+	low := submitJob(t, base, "low", 0, alice...)
+	high := submitJob(t, base, "high", 5, alice...)
+	if status, raw := do(t, http.MethodPost, base+"/v1/jobs", map[string]any{
+		"messages": []map[string]string{{"role": "user", "content": "one too many"}},
+	}, alice...); status != http.StatusServiceUnavailable {
+		t.Errorf("submit to a full queue: status %d: %s", status, raw)
+	}
+
+	// Other clients cannot see or cancel the job.
+	if status, _ := do(t, http.MethodDelete, base+"/v1/jobs/"+low, nil, "Authorization", "Bearer sk-bob"); status != http.StatusNotFound {
+		t.Errorf("bob canceled alice's job: status %d", status)
+	}
+	if status, raw := do(t, http.MethodDelete, base+"/v1/jobs/"+low, nil, alice...); status != http.StatusOK {
+		t.Fatalf("cancel: status %d: %s", status, raw)
+	}
+	waitJob(t, base, low, jobCanceled, alice...)
+
+	// The first attempt of first is rate limited; high, with the higher
+	// priority, runs while it waits to be retried.
+	close(agent.gate)
+	j := waitJob(t, base, first, jobSucceeded, alice...)
+	if j.Attempts != 2 || j.Result == nil || j.Result.Choices[0].Message.Content != "answer to first" {
+		t.Errorf("first = %+v", j)
+	}
+	waitJob(t, base, high, jobSucceeded, alice...)
+	if got, want := agent.ran(), []string{"first", "high", "first"}; !slices.Equal(got, want) {
+		t.Errorf("ran %v, want %v", got, want)
+	}
+	if status, _ := do(t, http.MethodDelete, base+"/v1/jobs/"+high, nil, alice...); status != http.StatusConflict {
+		t.Errorf("cancel a finished job: status %d", status)
+	}
+
+	_, raw := do(t, http.MethodGet, base+"/v1/jobs", nil, alice...)
+	var list struct{ Data []job }
+	if err := json.Unmarshal(raw, &list); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, j := range list.Data {
+		ids = append(ids, j.ID)
+	}
+	if want := []string{first, low, high}; !slices.Equal(ids, want) {
+		t.Errorf("listed %v, want %v", ids, want)
+	}
+	_, raw = do(t, http.MethodGet, base+"/v1/jobs", nil, "Authorization", "Bearer sk-bob")
+	if err := json.Unmarshal(raw, &list); err != nil || len(list.Data) != 0 {
+		t.Errorf("bob listed %s", raw)
+	}
+}
+
+func TestJobsAreOffByDefault(t *testing.T) {
+	_, base := startServer(t, newFakeAgent(t, &fakeModel{reply: "ok"}))
+	if status, _ := do(t, http.MethodPost, base+"/v1/jobs", chatRequest("hi", false)); status != http.StatusNotFound {
+		t.Errorf("status %d, want 404", status)
+	}
+}
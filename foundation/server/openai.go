@@ -0,0 +1,347 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/hertz-contrib/sse"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/pacing"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// --- OpenAI wire types (the subset the agent needs) ---
+
+type chatCompletionRequest struct {
+	Model         string         `json:"model"`
+	Messages      []chatMessage  `json:"messages"`
+	Stream        bool           `json:"stream"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// text returns the message content, which may be a plain string or an array
+// of content parts; only text parts are kept.
+func (m chatMessage) text() string {
+	var s string
+	if err := json.Unmarshal(m.Content, &s); err == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, p := range parts {
+		if p.Type == "text" {
+			b.WriteString(p.Text)
+		}
+	}
+	return b.String()
+}
+
+type responseMessage struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+}
+
+type choice struct {
+	Index        int              `json:"index"`
+	Message      *responseMessage `json:"message,omitempty"`
+	Delta        *responseMessage `json:"delta,omitempty"`
+	FinishReason *string          `json:"finish_reason"`
+}
+
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type chatCompletionResponse struct {
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []choice `json:"choices"`
+	Usage   *usage   `json:"usage,omitempty"`
+}
+
+type apiError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+func writeError(c *app.RequestContext, status int, errType, msg string) {
+	var resp errorResponse
+	resp.Error.Message = msg
+	resp.Error.Type = errType
+	c.JSON(status, resp)
+}
+
+// agentError maps agent failures onto the OpenAI error a client can act on:
+// back off when the model is rate limited, shorten the conversation when it
+// no longer fits, rephrase when a tool call was denied. Messages are fixed
+// strings; the underlying error can name internal paths, hosts or upstream
+// responses, so it is only logged.
+func agentError(err error) (int, errorResponse) {
+	var resp errorResponse
+	switch {
+	case errors.Is(err, errBudgetExhausted):
+		resp.Error.Type = "insufficient_quota"
+		resp.Error.Message = "daily token budget exhausted; the turn was stopped"
+		return http.StatusTooManyRequests, resp
+	case errors.Is(err, events.ErrStalled):
+		resp.Error.Type = "server_error"
+		resp.Error.Message = "the agent made no progress for too long; the turn was stopped"
+		return http.StatusGatewayTimeout, resp
+	case errors.Is(err, tools.ErrToolDenied):
+		resp.Error.Type = "permission_error"
+		resp.Error.Message = "the agent attempted an operation it is not allowed to perform; the turn was stopped"
+		return http.StatusForbidden, resp
+	case errors.Is(err, gemini.ErrRateLimited):
+		resp.Error.Type = "rate_limit_error"
+		resp.Error.Message = "the model is rate limiting requests, retry later"
+		return http.StatusTooManyRequests, resp
+	case errors.Is(err, gemini.ErrContextTooLong):
+		resp.Error.Type = "invalid_request_error"
+		resp.Error.Code = "context_length_exceeded"
+		resp.Error.Message = "the conversation no longer fits the model's context window"
+		return http.StatusBadRequest, resp
+	default:
+		resp.Error.Type = "server_error"
+		resp.Error.Message = "the agent failed to answer; see the server logs for this request ID"
+		return http.StatusInternalServerError, resp
+	}
+}
+
+// turnError attributes a failure to the reason the turn's context was
+// cancelled, since components usually only report the cancellation itself.
+func turnError(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(err, cause) && ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", cause, err)
+	}
+	return err
+}
+
+// writeAgentError logs err and sends the client its safe description.
+func writeAgentError(ctx context.Context, c *app.RequestContext, err error) {
+	err = turnError(ctx, err)
+	logger.FromContext(ctx).Error("agent turn failed", "error", err)
+	status, resp := agentError(err)
+	c.JSON(status, resp)
+}
+
+// --- Handlers ---
+
+func (s *Server) handleListModels(ctx context.Context, c *app.RequestContext) {
+	c.JSON(http.StatusOK, map[string]any{
+		"object": "list",
+		"data": []map[string]any{{
+			"id":       ModelName,
+			"object":   "model",
+			"owned_by": "goforai",
+		}},
+	})
+}
+
+func (s *Server) handleChatCompletions(ctx context.Context, c *app.RequestContext) {
+	var req chatCompletionRequest
+	if err := json.Unmarshal(c.Request.Body(), &req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid JSON body: %v", err))
+		return
+	}
+
+	messages, err := toSchemaMessages(req.Messages)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	ctx, requestID := logger.WithRequestID(ctx)
+	ctx, conv, err := s.openConversation(ctx, c, requestID, messages)
+	if err != nil {
+		writeSessionError(ctx, c, err)
+		return
+	}
+	var reply *schema.Message
+	var turnErr error
+	defer func() { s.closeConversation(ctx, conv, reply, turnErr) }()
+
+	logger.FromContext(ctx).Info("chat completion", "stream", req.Stream, "messages", len(conv.Messages))
+	s.lookupAnswer(ctx, c, conv)
+
+	id := "chatcmpl-" + requestID
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		reply, turnErr = s.streamCompletion(ctx, c, id, conv, includeUsage)
+		return
+	}
+
+	msg, err := s.generate(ctx, conv, s.agentOptions(c, conv)...)
+	if err != nil {
+		turnErr = err
+		writeAgentError(ctx, c, err)
+		return
+	}
+
+	reply = msg
+	stop := "stop"
+	c.JSON(http.StatusOK, chatCompletionResponse{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   ModelName,
+		Choices: []choice{{
+			Message:      &responseMessage{Role: "assistant", Content: msg.Content},
+			FinishReason: &stop,
+		}},
+		Usage: usageOf(msg),
+	})
+}
+
+// streamCompletion streams the answer as chat.completion.chunk events and
+// returns the full reply, including the model's token usage, or the error
+// that cut the turn short. With includeUsage the usage is also sent as a
+// final chunk without choices, as OpenAI does for stream_options.include_usage.
+func (s *Server) streamCompletion(ctx context.Context, c *app.RequestContext, id string, conv *conversation, includeUsage bool) (*schema.Message, error) {
+	reader, err := s.stream(ctx, conv, s.agentOptions(c, conv)...)
+	if err != nil {
+		writeAgentError(ctx, c, err)
+		return nil, err
+	}
+	defer reader.Close()
+
+	stream := sse.NewStream(c)
+	created := time.Now().Unix()
+	publish := func(chunk chatCompletionResponse) error {
+		chunk.ID, chunk.Object, chunk.Created, chunk.Model = id, "chat.completion.chunk", created, ModelName
+		data, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		return stream.Publish(&sse.Event{Data: data})
+	}
+	publishDelta := func(delta *responseMessage, finish *string) error {
+		return publish(chatCompletionResponse{Choices: []choice{{Delta: delta, FinishReason: finish}}})
+	}
+
+	if err := publishDelta(&responseMessage{Role: "assistant"}, nil); err != nil {
+		return nil, err
+	}
+
+	content := pacing.New(ctx, s.config.streaming, func(text string) error {
+		return publishDelta(&responseMessage{Content: text}, nil)
+	})
+
+	// Keep every chunk: the model reports token usage on the last one.
+	var chunks []*schema.Message
+	var streamErr error
+	for {
+		chunk, err := reader.Recv()
+		if errors.Is(err, io.EOF) {
+			if err := content.Flush(); err != nil {
+				return nil, fmt.Errorf("client disconnected: %w", err)
+			}
+			break
+		}
+		if err != nil {
+			content.Flush()
+			// Headers are already sent; surface the failure in-band and stop.
+			err = turnError(ctx, err)
+			logger.FromContext(ctx).Error("agent turn failed", "error", err)
+			_, resp := agentError(err)
+			publishDelta(&responseMessage{Content: "\n\n[error: " + resp.Error.Message + "]"}, nil)
+			streamErr = err
+			break
+		}
+		chunks = append(chunks, chunk)
+		if chunk.Content == "" {
+			continue
+		}
+		if err := content.Write(chunk.Content); err != nil {
+			return nil, fmt.Errorf("client disconnected: %w", err)
+		}
+	}
+
+	reply := schema.AssistantMessage("", nil)
+	if streamErr == nil && len(chunks) > 0 {
+		if reply, err = schema.ConcatMessages(chunks); err != nil {
+			streamErr = fmt.Errorf("failed to assemble the streamed reply: %w", err)
+		}
+	}
+
+	stop := "stop"
+	publishDelta(&responseMessage{}, &stop)
+	if includeUsage && streamErr == nil {
+		publish(chatCompletionResponse{Choices: []choice{}, Usage: usageOf(reply)})
+	}
+	stream.Publish(&sse.Event{Data: []byte("[DONE]")})
+	if streamErr != nil {
+		return nil, streamErr
+	}
+	return reply, nil
+}
+
+func toSchemaMessages(in []chatMessage) ([]*schema.Message, error) {
+	if len(in) == 0 {
+		return nil, errors.New("messages must not be empty")
+	}
+	out := make([]*schema.Message, 0, len(in))
+	for _, m := range in {
+		var role schema.RoleType
+		switch m.Role {
+		case "system", "developer":
+			role = schema.System
+		case "user":
+			role = schema.User
+		case "assistant":
+			role = schema.Assistant
+		default:
+			continue // Tool messages from other agents have no meaning here.
+		}
+		out = append(out, &schema.Message{Role: role, Content: m.text()})
+	}
+	if len(out) == 0 || out[len(out)-1].Role != schema.User {
+		return nil, errors.New("the last message must have role 'user'")
+	}
+	return out, nil
+}
+
+func usageOf(msg *schema.Message) *usage {
+	if msg.ResponseMeta == nil || msg.ResponseMeta.Usage == nil {
+		return nil
+	}
+	u := msg.ResponseMeta.Usage
+	return &usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
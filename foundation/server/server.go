@@ -0,0 +1,213 @@
+// Package server exposes the agent over HTTP. It speaks the OpenAI chat
+// completions protocol so existing clients can use the agent as if it were a
+// model, and serves a small embedded web UI for everyone else.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/olusolaa/goforai/foundation/answercache"
+	appconfig "github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/indexing"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/session"
+)
+
+// ModelName is the model identifier the server advertises to clients.
+const ModelName = "goforai-agent"
+
+// Agent is the conversational backend served over HTTP. The last message is
+// the user's query; earlier messages are history.
+type Agent interface {
+	Generate(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (*schema.Message, error)
+	Stream(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (*schema.StreamReader[*schema.Message], error)
+}
+
+// Server is the HTTP front end for an Agent.
+type Server struct {
+	agent   Agent
+	hertz   *server.Hertz
+	config  config
+	clients *clients
+	jobs    *jobQueue
+
+	ready    readiness
+	draining atomic.Bool
+	inflight atomic.Int64
+}
+
+type config struct {
+	addr              string
+	sessions          *session.Manager
+	apiKeys           map[string]string
+	groups            map[string][]string
+	requestsPerMinute int
+	dailyTokenBudget  int64
+	maxRequestBytes   int
+	readinessChecks   map[string]Check
+	shutdownTimeout   time.Duration
+
+	events               *events.Dispatcher
+	budgetAlertThreshold float64
+	streaming            appconfig.Streaming
+	heartbeat            appconfig.Heartbeat
+	answers              *answercache.Cache
+	ingester             *indexing.Ingester
+	documentWriters      string
+	jobs                 appconfig.Jobs
+}
+
+// WithEvents posts turn, tool failure, and budget events to d's webhooks.
+func WithEvents(d *events.Dispatcher) Option {
+	return func(c *config) {
+		c.events = d
+	}
+}
+
+// WithStreaming coalesces streamed answers into pieces of at least
+// s.MinChunk bytes, held no longer than s.MaxDelay, so clients receive
+// reasonably sized frames rather than one per token. The typewriter rate is
+// ignored: clients render at their own pace. By default every chunk is sent
+// as it arrives.
+func WithStreaming(s appconfig.Streaming) Option {
+	return func(c *config) {
+		s.Rate = 0
+		c.streaming = s
+	}
+}
+
+// WithHeartbeat reports the progress of running turns every h.Interval, as
+// turn.progress events and to web UI clients, and stops a turn that goes
+// h.Stall without model output or a tool call. By default turns report
+// nothing and are never stopped.
+func WithHeartbeat(h appconfig.Heartbeat) Option {
+	return func(c *config) {
+		c.heartbeat = h
+	}
+}
+
+// Option configures a Server.
+type Option func(*config)
+
+// WithAddr sets the listen address. Defaults to "127.0.0.1:8080". Run refuses
+// a non-loopback address unless API keys are configured.
+func WithAddr(addr string) Option {
+	return func(c *config) {
+		c.addr = addr
+	}
+}
+
+// New creates a Server and registers its routes.
+func New(agent Agent, opts ...Option) *Server {
+	cfg := config{
+		addr:                 "127.0.0.1:8080",
+		maxRequestBytes:      1 << 20,
+		shutdownTimeout:      30 * time.Second,
+		budgetAlertThreshold: 0.8,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	h := server.New(
+		server.WithHostPorts(cfg.addr),
+		server.WithDisablePrintRoute(true),
+		server.WithMaxRequestBodySize(cfg.maxRequestBytes),
+	)
+
+	s := &Server{
+		agent:   agent,
+		hertz:   h,
+		config:  cfg,
+		clients: &clients{m: make(map[string]*client), rpm: cfg.requestsPerMinute},
+		jobs:    newJobQueue(cfg.jobs),
+	}
+	s.routes()
+	return s
+}
+
+// routes registers the handlers. Everything except the probes and the static
+// UI page goes through guard for authentication and limits.
+func (s *Server) routes() {
+	s.healthRoutes()
+	v1 := s.hertz.Group("/v1", s.track, s.guard)
+	v1.GET("/models", s.handleListModels)
+	v1.POST("/chat/completions", s.handleChatCompletions)
+	s.sessionRoutes(v1)
+	s.documentRoutes(v1)
+	s.jobRoutes(v1)
+	s.webRoutes(s.hertz.Group("/api", s.track, s.guard))
+}
+
+// Run serves until ctx is cancelled, then shuts down gracefully. Idle
+// sessions are expired and queued jobs run in the background while the
+// server runs.
+func (s *Server) Run(ctx context.Context) error {
+	if len(s.config.apiKeys) == 0 && !isLoopback(s.config.addr) {
+		return fmt.Errorf("refusing to serve on %s without API keys: set SERVER_API_KEYS or listen on a loopback address", s.config.addr)
+	}
+	if s.config.sessions != nil {
+		go s.config.sessions.Run(ctx)
+	}
+	s.runJobs(ctx)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.hertz.Run() }()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("server stopped: %w", err)
+	case <-ctx.Done():
+	}
+	return s.shutdown(logger.FromContext(ctx))
+}
+
+// isLoopback reports whether addr (host:port) only listens on the local machine.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// shutdown reports not-ready, waits for in-flight requests to finish, and
+// then closes the listener, all within the shutdown timeout.
+func (s *Server) shutdown(log *slog.Logger) error {
+	s.draining.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.shutdownTimeout)
+	defer cancel()
+
+	log.Info("draining in-flight requests", "active", s.inflight.Load(), "timeout", s.config.shutdownTimeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+drain:
+	for s.inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			log.Warn("shutdown timeout reached, abandoning in-flight requests", "active", s.inflight.Load())
+			break drain
+		case <-ticker.C:
+		}
+	}
+
+	if err := s.hertz.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	log.Info("server stopped")
+	return nil
+}
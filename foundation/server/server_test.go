@@ -0,0 +1,392 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	appconfig "github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// fakeModel answers every call with reply, split into words when streamed,
+// and reports usage through callbacks the way the Gemini model does.
+type fakeModel struct {
+	reply  string
+	usage  model.TokenUsage
+	err    error
+	onCall func(ctx context.Context) // Inspects the context of every call.
+}
+
+func (m *fakeModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if m.onCall != nil {
+		m.onCall(ctx)
+	}
+	if err := ctx.Err(); err != nil { // As an aborted HTTP call would.
+		return nil, err
+	}
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input})
+	if m.err != nil {
+		callbacks.OnError(ctx, m.err)
+		return nil, m.err
+	}
+	msg := schema.AssistantMessage(m.reply, nil)
+	msg.ResponseMeta = &schema.ResponseMeta{Usage: m.schemaUsage()}
+	callbacks.OnEnd(ctx, &model.CallbackOutput{Message: msg, TokenUsage: &m.usage})
+	return msg, nil
+}
+
+func (m *fakeModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if m.onCall != nil {
+		m.onCall(ctx)
+	}
+	if err := ctx.Err(); err != nil { // As an aborted HTTP call would.
+		return nil, err
+	}
+	ctx = callbacks.OnStart(ctx, &model.CallbackInput{Messages: input})
+	if m.err != nil {
+		callbacks.OnError(ctx, m.err)
+		return nil, m.err
+	}
+
+	var outputs []*model.CallbackOutput
+	for _, word := range strings.SplitAfter(m.reply, " ") {
+		outputs = append(outputs, &model.CallbackOutput{Message: schema.AssistantMessage(word, nil)})
+	}
+	// Usage arrives on the final chunk only.
+	last := outputs[len(outputs)-1]
+	last.Message.ResponseMeta = &schema.ResponseMeta{Usage: m.schemaUsage()}
+	last.TokenUsage = &m.usage
+
+	_, out := callbacks.OnEndWithStreamOutput(ctx, schema.StreamReaderFromArray(outputs))
+	return schema.StreamReaderWithConvert(out, func(o *model.CallbackOutput) (*schema.Message, error) {
+		return o.Message, nil
+	}), nil
+}
+
+func (m *fakeModel) IsCallbacksEnabled() bool { return true }
+
+func (m *fakeModel) schemaUsage() *schema.TokenUsage {
+	return &schema.TokenUsage{
+		PromptTokens:     m.usage.PromptTokens,
+		CompletionTokens: m.usage.CompletionTokens,
+		TotalTokens:      m.usage.TotalTokens,
+	}
+}
+
+// fakeAgent runs a one-node graph around a fakeModel, so the server's
+// callbacks see real component events.
+type fakeAgent struct {
+	graph compose.Runnable[[]*schema.Message, *schema.Message]
+}
+
+func newFakeAgent(t *testing.T, m *fakeModel) *fakeAgent {
+	t.Helper()
+	graph, err := compose.NewChain[[]*schema.Message, *schema.Message]().
+		AppendChatModel(m).
+		Compile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeAgent{graph: graph}
+}
+
+func (a *fakeAgent) Generate(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (*schema.Message, error) {
+	return a.graph.Invoke(ctx, messages, opts...)
+}
+
+func (a *fakeAgent) Stream(ctx context.Context, messages []*schema.Message, opts ...compose.Option) (*schema.StreamReader[*schema.Message], error) {
+	return a.graph.Stream(ctx, messages, opts...)
+}
+
+// startServer runs a Server on a free loopback port until the test ends and
+// returns it with its base URL.
+func startServer(t *testing.T, agent Agent, opts ...Option) (*Server, string) {
+	t.Helper()
+	hlog.SetSilentMode(true)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	s := New(agent, append([]Option{WithAddr(addr), WithShutdownTimeout(time.Second)}, opts...)...)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	t.Cleanup(func() {
+		http.DefaultClient.CloseIdleConnections()
+		cancel()
+		<-done
+	})
+
+	base := "http://" + addr
+	for i := 0; i < 100; i++ {
+		if resp, err := http.Get(base + "/healthz"); err == nil {
+			resp.Body.Close()
+			return s, base
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("server did not start")
+	return nil, ""
+}
+
+// do sends a JSON request and returns the status and body.
+func do(t *testing.T, method, url string, body any, headers ...string) (int, []byte) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, raw
+}
+
+func chatRequest(content string, stream bool) map[string]any {
+	return map[string]any{
+		"model":    ModelName,
+		"stream":   stream,
+		"messages": []map[string]string{{"role": "user", "content": content}},
+	}
+}
+
+// sseData returns the data payloads of an SSE body.
+func sseData(body []byte) []string {
+	var data []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		if d, ok := strings.CutPrefix(scanner.Text(), "data:"); ok {
+			data = append(data, strings.TrimSpace(d))
+		}
+	}
+	return data
+}
+
+func TestIsLoopback(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8080": true,
+		"[::1]:8080":     true,
+		"localhost:8080": true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"192.168.1.2:80": false,
+	} {
+		if got := isLoopback(addr); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestRunRefusesOpenAddressWithoutKeys(t *testing.T) {
+	s := New(newFakeAgent(t, &fakeModel{reply: "hi"}), WithAddr(":0"))
+	err := s.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "without API keys") {
+		t.Fatalf("Run on all interfaces without keys = %v, want a refusal", err)
+	}
+}
+
+func TestChatCompletion(t *testing.T) {
+	agent := newFakeAgent(t, &fakeModel{reply: "Eino is a Go framework.", usage: model.TokenUsage{PromptTokens: 7, CompletionTokens: 5, TotalTokens: 12}})
+	_, base := startServer(t, agent)
+
+	status, body := do(t, http.MethodPost, base+"/v1/chat/completions", chatRequest("What is Eino?", false))
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %s", status, body)
+	}
+	var resp chatCompletionResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "Eino is a Go framework." {
+		t.Errorf("unexpected choices %+v", resp.Choices)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 12 {
+		t.Errorf("usage = %+v, want 12 total tokens", resp.Usage)
+	}
+}
+
+func TestAgentErrorsDoNotLeakDetails(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err    error
+		status int
+	}{
+		"internal":     {fmt.Errorf("open /srv/secret/index.gob: dial tcp 10.0.0.7:443: refused"), http.StatusInternalServerError},
+		"rate limited": {fmt.Errorf("upstream key sk-123 at 10.0.0.7: %w", gemini.ErrRateLimited), http.StatusTooManyRequests},
+		"tool denied":  {fmt.Errorf("read_file '/srv/10.0.0.7/key': %w", tools.ErrWorkspaceViolation), http.StatusForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, base := startServer(t, newFakeAgent(t, &fakeModel{err: tc.err}))
+			for _, stream := range []bool{false, true} {
+				status, body := do(t, http.MethodPost, base+"/v1/chat/completions", chatRequest("hi", stream))
+				if !stream && status != tc.status {
+					t.Errorf("status %d, want %d", status, tc.status)
+				}
+				if strings.Contains(string(body), "10.0.0.7") || strings.Contains(string(body), "sk-123") {
+					t.Errorf("stream=%v: response leaks the underlying error: %s", stream, body)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamingReportsUsage(t *testing.T) {
+	agent := newFakeAgent(t, &fakeModel{reply: "Goroutines are cheap.", usage: model.TokenUsage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30}})
+	s, base := startServer(t, agent, WithAPIKeys(map[string]string{"sk-test": "alice"}))
+
+	req := chatRequest("What is a goroutine?", true)
+	req["stream_options"] = map[string]bool{"include_usage": true}
+	status, body := do(t, http.MethodPost, base+"/v1/chat/completions", req, "Authorization", "Bearer sk-test")
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %s", status, body)
+	}
+
+	var content strings.Builder
+	var reported *usage
+	data := sseData(body)
+	if len(data) == 0 || data[len(data)-1] != "[DONE]" {
+		t.Fatalf("stream did not end with [DONE]: %q", data)
+	}
+	for _, d := range data[:len(data)-1] {
+		var chunk chatCompletionResponse
+		if err := json.Unmarshal([]byte(d), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", d, err)
+		}
+		for _, c := range chunk.Choices {
+			if c.Delta != nil {
+				content.WriteString(c.Delta.Content)
+			}
+		}
+		if chunk.Usage != nil {
+			reported = chunk.Usage
+		}
+	}
+	if content.String() != "Goroutines are cheap." {
+		t.Errorf("streamed content %q", content.String())
+	}
+	if reported == nil || reported.TotalTokens != 30 {
+		t.Errorf("usage chunk = %+v, want 30 total tokens", reported)
+	}
+	if used := s.clients.get("alice").tokensToday(); used != 30 {
+		t.Errorf("client was charged %d tokens for the streamed turn, want 30", used)
+	}
+}
+
+func TestStreamingCoalescesChunks(t *testing.T) {
+	agent := newFakeAgent(t, &fakeModel{reply: "Channels are typed conduits between goroutines."})
+	_, base := startServer(t, agent, WithStreaming(appconfig.Streaming{MinChunk: 20, MaxDelay: time.Minute}))
+
+	status, body := do(t, http.MethodPost, base+"/v1/chat/completions", chatRequest("What is a channel?", true))
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %s", status, body)
+	}
+	var deltas []string
+	for _, d := range sseData(body) {
+		var chunk chatCompletionResponse
+		if json.Unmarshal([]byte(d), &chunk) != nil {
+			continue
+		}
+		for _, c := range chunk.Choices {
+			if c.Delta != nil && c.Delta.Content != "" {
+				deltas = append(deltas, c.Delta.Content)
+			}
+		}
+	}
+	// Seven words arrive; they leave in pieces of at least 20 bytes, and the
+	// rest when the stream ends.
+	if want := []string{"Channels are typed conduits ", "between goroutines."}; !slices.Equal(deltas, want) {
+		t.Errorf("deltas = %q, want %q", deltas, want)
+	}
+}
+
+func TestStatelessRequestsGetAPrivateWorkspace(t *testing.T) {
+	var mu sync.Mutex
+	var workspaces []string
+	m := &fakeModel{reply: "ok", onCall: func(ctx context.Context) {
+		mu.Lock()
+		defer mu.Unlock()
+		workspaces = append(workspaces, tools.Workspace(ctx))
+	}}
+	_, base := startServer(t, newFakeAgent(t, m))
+
+	for i := 0; i < 2; i++ {
+		if status, body := do(t, http.MethodPost, base+"/v1/chat/completions", chatRequest("hi", false)); status != http.StatusOK {
+			t.Fatalf("status %d: %s", status, body)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(workspaces) != 2 || workspaces[0] == "" || workspaces[0] == workspaces[1] {
+		t.Fatalf("workspaces = %q, want a distinct one per request", workspaces)
+	}
+	for _, dir := range workspaces {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("request workspace %s survived the turn: %v", dir, err)
+		}
+	}
+}
+
+func TestClosedSessionKeepsItsConversation(t *testing.T) {
+	sessions := session.NewManager(session.NewMemoryStore(), appconfig.Sessions{
+		IdleTimeout: time.Hour, MaxDuration: time.Nanosecond, Retention: time.Hour, WorkspaceDir: t.TempDir(),
+	})
+	_, base := startServer(t, newFakeAgent(t, &fakeModel{reply: "ok"}), WithSessions(sessions))
+
+	status, body := do(t, http.MethodPost, base+"/v1/sessions", nil)
+	if status != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", status, body)
+	}
+	var sess session.Session
+	if err := json.Unmarshal(body, &sess); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, body := do(t, http.MethodPost, base+"/v1/chat/completions", chatRequest("hi", false), sessionHeader, sess.ID); status != http.StatusGone ||
+		!strings.Contains(string(body), "session_closed") {
+		t.Errorf("turn in a closed session: status %d: %s", status, body)
+	}
+	status, body = do(t, http.MethodGet, base+"/v1/sessions/"+sess.ID, nil)
+	if err := json.Unmarshal(body, &sess); status != http.StatusOK || err != nil || sess.CloseReason != session.CloseMaxDuration || sess.ClosedAt == nil {
+		t.Errorf("reading a closed session: status %d: %s", status, body)
+	}
+}
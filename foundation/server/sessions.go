@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/olusolaa/goforai/foundation/answercache"
+	"github.com/olusolaa/goforai/foundation/audit"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/olusolaa/goforai/foundation/tools"
+)
+
+// sessionHeader selects a server-side session. Without it the client owns the
+// history and must send the whole conversation with every request.
+const sessionHeader = "X-Session-ID"
+
+// WithSessions enables server-side sessions backed by m.
+func WithSessions(m *session.Manager) Option {
+	return func(c *config) {
+		c.sessions = m
+	}
+}
+
+func (s *Server) sessionRoutes(v1 *route.RouterGroup) {
+	if s.config.sessions == nil {
+		return
+	}
+	v1.POST("/sessions", s.handleCreateSession)
+	v1.GET("/sessions/:id", s.handleGetSession)
+	v1.DELETE("/sessions/:id", s.handleDeleteSession)
+}
+
+func (s *Server) handleCreateSession(ctx context.Context, c *app.RequestContext) {
+	sess, err := s.config.sessions.Create(ctx, s.sessionOwner(c))
+	if err != nil {
+		writeSessionError(ctx, c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, sess)
+}
+
+// handleGetSession returns closed sessions too, with closed_at and
+// close_reason set, so their conversations can still be read.
+func (s *Server) handleGetSession(ctx context.Context, c *app.RequestContext) {
+	sess, err := s.lookupSession(ctx, c, c.Param("id"))
+	if err != nil {
+		writeSessionError(ctx, c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sess)
+}
+
+func (s *Server) handleDeleteSession(ctx context.Context, c *app.RequestContext) {
+	id := c.Param("id")
+	if _, err := s.lookupSession(ctx, c, id); err != nil {
+		writeSessionError(ctx, c, err)
+		return
+	}
+	if err := s.config.sessions.Delete(ctx, id); err != nil {
+		writeSessionError(ctx, c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// sessionOwner names the client that owns the sessions this request creates.
+// Without API keys clients are only told apart by IP address, which is too
+// weak to tie a session to, so sessions are left unowned.
+func (s *Server) sessionOwner(c *app.RequestContext) string {
+	if len(s.config.apiKeys) == 0 {
+		return ""
+	}
+	return clientName(c)
+}
+
+// getSession returns the session if the request's client owns it and it is
+// still open, or session.ErrClosed if it timed out.
+func (s *Server) getSession(ctx context.Context, c *app.RequestContext, id string) (*session.Session, error) {
+	sess, err := s.lookupSession(ctx, c, id)
+	if err != nil {
+		return nil, err
+	}
+	if sess.ClosedAt != nil {
+		return nil, fmt.Errorf("%w at %s (%s); start a new session", session.ErrClosed, sess.ClosedAt.UTC().Format(time.RFC3339), sess.CloseReason)
+	}
+	return sess, nil
+}
+
+// lookupSession returns the session, open or closed, if the request's client
+// owns it. Sessions of other clients are reported as missing, so their IDs
+// cannot be probed.
+func (s *Server) lookupSession(ctx context.Context, c *app.RequestContext, id string) (*session.Session, error) {
+	sess, err := s.config.sessions.Lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sess.Owner != s.sessionOwner(c) {
+		return nil, session.ErrNotFound
+	}
+	return sess, nil
+}
+
+// writeSessionError reports a missing session as 404 and a closed one as
+// 410. Other failures are logged and answered with a generic 500, since they
+// can name files and hosts.
+func writeSessionError(ctx context.Context, c *app.RequestContext, err error) {
+	if errors.Is(err, session.ErrNotFound) {
+		writeError(c, http.StatusNotFound, "not_found_error", err.Error())
+		return
+	}
+	if errors.Is(err, session.ErrClosed) {
+		writeError(c, http.StatusGone, "session_closed", err.Error())
+		return
+	}
+	logger.FromContext(ctx).Error("session store failed", "error", err)
+	writeError(c, http.StatusInternalServerError, "server_error", "the session store or workspace is unavailable")
+}
+
+// conversation is the history for one turn, plus the session it belongs to
+// when the request named one.
+type conversation struct {
+	Messages []*schema.Message
+	session  *session.Session
+	turn     *events.Turn
+	stop     context.CancelCauseFunc // Aborts the turn, e.g. when the token budget runs out.
+	release  func()                  // Unlocks the session or removes the request's workspace.
+	usage    sync.WaitGroup          // Token usage still being recorded from model streams.
+
+	trace      *turnTrace         // Records the turn for the session; nil without one.
+	cached     *schema.Message    // The answer from the answer cache, if it had one.
+	cacheQuery *answercache.Query // The question to cache the answer to, if it had none.
+}
+
+// openConversation resolves the request's session. With a session, only the
+// last message of the request is used and the stored history is prepended;
+// the session stays locked until closeConversation. Without one, the turn
+// gets a throwaway workspace so its file tools are still sandboxed. The
+// returned context is cancelled if the turn has to be cut short.
+func (s *Server) openConversation(ctx context.Context, c *app.RequestContext, requestID string, messages []*schema.Message) (context.Context, *conversation, error) {
+	turn := s.config.events.StartTurn(events.Event{
+		RequestID: requestID,
+		SessionID: string(c.GetHeader(sessionHeader)),
+		Client:    clientName(c),
+		Prompt:    messages[len(messages)-1].Content,
+	})
+
+	ctx, stop := context.WithCancelCause(ctx)
+	turn.Watch(ctx, events.Heartbeat{Interval: s.config.heartbeat.Interval, Stall: s.config.heartbeat.Stall, Abort: stop})
+	id := string(c.GetHeader(sessionHeader))
+	if id == "" || s.config.sessions == nil {
+		dir, err := os.MkdirTemp("", "goforai-request-")
+		if err != nil {
+			stop(nil)
+			return ctx, nil, fmt.Errorf("failed to create request workspace: %w", err)
+		}
+		ctx = tools.WithWorkspace(ctx, dir)
+		release := func() {
+			if err := os.RemoveAll(dir); err != nil {
+				logger.FromContext(ctx).Warn("failed to remove request workspace", "error", err)
+			}
+		}
+		return ctx, &conversation{Messages: messages, turn: turn, stop: stop, release: release}, nil
+	}
+
+	unlock := s.config.sessions.Lock(id)
+	sess, err := s.getSession(ctx, c, id)
+	if err != nil {
+		unlock()
+		stop(nil)
+		return ctx, nil, err
+	}
+
+	ctx = logger.WithContext(ctx, logger.FromContext(ctx).With("session_id", id))
+	ctx = tools.WithWorkspace(ctx, s.config.sessions.Workspace(id))
+	ctx = audit.WithSession(ctx, id)
+
+	history := append(sess.Messages[:len(sess.Messages):len(sess.Messages)], messages[len(messages)-1])
+	trace := newTurnTrace(len(history) - 1)
+	ctx = chromemdb.WithRetrievalLog(ctx, trace.retrievals)
+	return ctx, &conversation{Messages: history, session: sess, turn: turn, stop: stop, release: unlock, trace: trace}, nil
+}
+
+// closeConversation reports the turn, records a successful reply in the
+// session, and releases it. A failed turn leaves the stored history untouched.
+func (s *Server) closeConversation(ctx context.Context, conv *conversation, reply *schema.Message, err error) {
+	defer conv.release()
+	conv.usage.Wait()
+	conv.stop(nil)
+	// The turn's context may have been cancelled to abort it; the
+	// bookkeeping below must still happen.
+	ctx = context.WithoutCancel(ctx)
+
+	var answer string
+	if reply != nil {
+		answer = reply.Content
+	}
+	conv.turn.End(ctx, answer, err)
+
+	if reply == nil || err != nil {
+		return
+	}
+	s.cacheAnswer(ctx, conv, reply)
+	if conv.session == nil {
+		return
+	}
+	conv.session.Messages = append(conv.Messages, &schema.Message{Role: schema.Assistant, Content: reply.Content})
+	conv.session.Turns = append(conv.session.Turns, conv.trace.finish())
+	if err := s.config.sessions.Save(ctx, conv.session); err != nil {
+		logger.FromContext(ctx).Error("failed to save session", "error", err)
+	}
+}
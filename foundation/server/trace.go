@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/session"
+	"github.com/olusolaa/goforai/foundation/truncate"
+)
+
+// maxTracedResult bounds what a session keeps of each tool result, in bytes.
+const maxTracedResult = 4000
+
+// turnTrace records the knowledge base searches and tool calls of a
+// session's turn, so that `goforai replay` can show how it was answered.
+type turnTrace struct {
+	mu         sync.Mutex
+	turn       session.Turn
+	retrievals *chromemdb.RetrievalLog
+}
+
+// newTurnTrace starts the trace of the turn answering the user message at
+// index message of the session.
+func newTurnTrace(message int) *turnTrace {
+	return &turnTrace{
+		turn:       session.Turn{Message: message, StartedAt: time.Now().UTC()},
+		retrievals: &chromemdb.RetrievalLog{},
+	}
+}
+
+type tracedCallKey struct{}
+
+// handler records every tool call with its arguments and its result or
+// error.
+func (t *turnTrace) handler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if info.Component != components.ComponentOfTool {
+				return ctx
+			}
+			call := session.ToolCall{Name: info.Name}
+			if in := tool.ConvCallbackInput(input); in != nil {
+				call.Arguments = in.ArgumentsInJSON
+			}
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.turn.ToolCalls = append(t.turn.ToolCalls, call)
+			return context.WithValue(ctx, tracedCallKey{}, len(t.turn.ToolCalls)-1)
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if out := tool.ConvCallbackOutput(output); out != nil && info.Component == components.ComponentOfTool {
+				t.update(ctx, func(call *session.ToolCall) { call.Result = truncate.Bytes(out.Response, maxTracedResult) })
+			}
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			if info.Component == components.ComponentOfTool {
+				t.update(ctx, func(call *session.ToolCall) { call.Error = err.Error() })
+			}
+			return ctx
+		}).
+		Build()
+}
+
+// update applies fn to the call ctx belongs to.
+func (t *turnTrace) update(ctx context.Context, fn func(*session.ToolCall)) {
+	i, ok := ctx.Value(tracedCallKey{}).(int)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(&t.turn.ToolCalls[i])
+}
+
+// finish returns the recorded turn.
+func (t *turnTrace) finish() *session.Turn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	turn := t.turn
+	turn.DurationMS = time.Since(turn.StartedAt).Milliseconds()
+	for _, r := range t.retrievals.Retrievals() {
+		turn.Retrievals = append(turn.Retrievals, session.Retrieval{Query: r.Query, Sources: r.Sources})
+	}
+	return &turn
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func TestTurnTraceRecordsToolCalls(t *testing.T) {
+	trace := newTurnTrace(2)
+	call := func(name string) context.Context {
+		ctx := callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{Name: name, Component: components.ComponentOfTool}, trace.handler())
+		return callbacks.OnStart(ctx, &tool.CallbackInput{ArgumentsInJSON: `{"path":"main.go"}`})
+	}
+	read := call("read_file")
+	failed := call("run_go")
+	callbacks.OnEnd(read, &tool.CallbackOutput{Response: strings.Repeat("x", maxTracedResult+100)})
+	callbacks.OnError(failed, errors.New("build failed"))
+
+	turn := trace.finish()
+	if turn.Message != 2 || len(turn.ToolCalls) != 2 {
+		t.Fatalf("turn = %+v", turn)
+	}
+	if c := turn.ToolCalls[0]; c.Name != "read_file" || c.Arguments != `{"path":"main.go"}` || len(c.Result) > maxTracedResult+len("…") {
+		t.Errorf("read_file call = %+v", c)
+	}
+	if c := turn.ToolCalls[1]; c.Name != "run_go" || c.Error != "build failed" {
+		t.Errorf("run_go call = %+v", c)
+	}
+}
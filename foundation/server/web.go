@@ -0,0 +1,255 @@
+package server
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/hertz-contrib/sse"
+	"github.com/olusolaa/goforai/foundation/events"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/pacing"
+	"github.com/olusolaa/goforai/foundation/phases"
+	"github.com/olusolaa/goforai/foundation/truncate"
+)
+
+//go:embed web
+var webAssets embed.FS
+
+// maxToolPreview bounds the tool arguments and results sent to the browser.
+const maxToolPreview = 2000
+
+// Web UI event types, sent as the SSE event name.
+const (
+	eventToken     = "token"
+	eventReasoning = "reasoning"
+	eventPhase     = "phase"
+	eventToolStart = "tool_start"
+	eventToolEnd   = "tool_end"
+	eventProgress  = "progress"
+	eventError     = "error"
+	eventDone      = "done"
+)
+
+// webEvent is the payload of every event streamed to the web UI.
+type webEvent struct {
+	Content   string `json:"content,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Tool      string `json:"tool,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Cached    bool   `json:"cached,omitempty"` // On done: the answer came from the answer cache.
+
+	Progress *events.Progress `json:"progress,omitempty"` // On progress: how far a long turn has got.
+	Phase    *phases.Event    `json:"phase,omitempty"`    // On phase: the phase the turn entered.
+}
+
+type webChatRequest struct {
+	Messages []chatMessage `json:"messages"`
+}
+
+// webRoutes serves the embedded single-page UI and its streaming endpoint.
+func (s *Server) webRoutes(api *route.RouterGroup) {
+	assets, err := fs.Sub(webAssets, "web")
+	if err != nil {
+		panic(fmt.Sprintf("embedded web assets are missing: %v", err))
+	}
+	index, err := fs.ReadFile(assets, "index.html")
+	if err != nil {
+		panic(fmt.Sprintf("embedded web assets are missing: %v", err))
+	}
+
+	s.hertz.GET("/", func(ctx context.Context, c *app.RequestContext) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+	api.POST("/chat", s.handleWebChat)
+}
+
+// eventStream serialises writes to the SSE stream; tool callbacks fire on
+// the graph's goroutines while tokens arrive on the handler's. Phase events
+// of a streamed model call may come after the turn ends, so the stream is
+// closed when the handler returns and later events are dropped.
+type eventStream struct {
+	mu     sync.Mutex
+	stream *sse.Stream
+	closed bool
+}
+
+var errStreamClosed = errors.New("event stream closed")
+
+func (e *eventStream) send(event string, payload webEvent) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return errStreamClosed
+	}
+	return e.stream.Publish(&sse.Event{Event: event, Data: data})
+}
+
+func (e *eventStream) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+}
+
+// handleWebChat streams the answer token by token, interleaved with tool
+// activity and phase changes so the UI can show what the agent is doing. The
+// model's reasoning is sent apart from the answer, as reasoning events.
+func (s *Server) handleWebChat(ctx context.Context, c *app.RequestContext) {
+	var req webChatRequest
+	if err := json.Unmarshal(c.Request.Body(), &req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid JSON body: %v", err))
+		return
+	}
+	messages, err := toSchemaMessages(req.Messages)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	ctx, requestID := logger.WithRequestID(ctx)
+	ctx, conv, err := s.openConversation(ctx, c, requestID, messages)
+	if err != nil {
+		writeSessionError(ctx, c, err)
+		return
+	}
+	var reply *schema.Message
+	var turnErr error
+	defer func() { s.closeConversation(ctx, conv, reply, turnErr) }()
+
+	s.lookupAnswer(ctx, c, conv)
+	stream := &eventStream{stream: sse.NewStream(c)}
+	defer stream.close()
+	conv.turn.OnProgress(func(p events.Progress) {
+		stream.send(eventProgress, webEvent{Progress: &p})
+	})
+	onPhase := phases.NewHandler(func(ctx context.Context, e phases.Event) {
+		stream.send(eventPhase, webEvent{Phase: &e})
+	})
+	opts := append(s.agentOptions(c, conv), compose.WithCallbacks(toolActivityHandler(stream), onPhase))
+	reader, err := s.stream(ctx, conv, opts...)
+	if err != nil {
+		turnErr = err
+		sendAgentError(ctx, stream, err)
+		return
+	}
+	defer reader.Close()
+
+	tokens := pacing.New(ctx, s.config.streaming, func(text string) error {
+		return stream.send(eventToken, webEvent{Content: text})
+	})
+
+	var split phases.Splitter
+	send := func(reasoning, answer string) error {
+		if reasoning != "" {
+			if err := tokens.Flush(); err != nil {
+				return err
+			}
+			if err := stream.send(eventReasoning, webEvent{Content: reasoning}); err != nil {
+				return err
+			}
+		}
+		if answer == "" {
+			return nil
+		}
+		return tokens.Write(answer)
+	}
+
+	// Keep every chunk: the model reports token usage on the last one.
+	var chunks []*schema.Message
+	for {
+		chunk, err := reader.Recv()
+		if errors.Is(err, io.EOF) {
+			onPhase.Wait()
+			if err := send(split.Flush()); err != nil {
+				turnErr = fmt.Errorf("client disconnected: %w", err)
+				return
+			}
+			if err := tokens.Flush(); err != nil {
+				turnErr = fmt.Errorf("client disconnected: %w", err)
+				return
+			}
+			break
+		}
+		if err != nil {
+			tokens.Flush()
+			turnErr = err
+			sendAgentError(ctx, stream, err)
+			return
+		}
+		chunks = append(chunks, chunk)
+		if err := send(split.Split(chunk)); err != nil {
+			turnErr = fmt.Errorf("client disconnected: %w", err)
+			return
+		}
+	}
+	reply = schema.AssistantMessage("", nil)
+	if len(chunks) > 0 {
+		if reply, err = schema.ConcatMessages(chunks); err != nil {
+			turnErr = fmt.Errorf("failed to assemble the streamed reply: %w", err)
+			sendAgentError(ctx, stream, turnErr)
+			return
+		}
+	}
+	stream.send(eventDone, webEvent{Cached: conv.cached != nil})
+}
+
+// sendAgentError logs err and tells the browser its safe description.
+func sendAgentError(ctx context.Context, events *eventStream, err error) {
+	err = turnError(ctx, err)
+	logger.FromContext(ctx).Error("agent turn failed", "error", err)
+	_, resp := agentError(err)
+	events.send(eventError, webEvent{Error: resp.Error.Message})
+}
+
+// toolActivityHandler forwards tool calls to the browser as they happen.
+func toolActivityHandler(events *eventStream) callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			if info.Component != components.ComponentOfTool {
+				return ctx
+			}
+			var args string
+			if in := tool.ConvCallbackInput(input); in != nil {
+				args = truncate.Bytes(in.ArgumentsInJSON, maxToolPreview)
+			}
+			events.send(eventToolStart, webEvent{ID: compose.GetToolCallID(ctx), Tool: info.Name, Arguments: args})
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			if info.Component != components.ComponentOfTool {
+				return ctx
+			}
+			var result string
+			if out := tool.ConvCallbackOutput(output); out != nil {
+				result = truncate.Bytes(out.Response, maxToolPreview)
+			}
+			events.send(eventToolEnd, webEvent{ID: compose.GetToolCallID(ctx), Tool: info.Name, Result: result})
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			if info.Component == components.ComponentOfTool {
+				events.send(eventToolEnd, webEvent{ID: compose.GetToolCallID(ctx), Tool: info.Name, Error: err.Error()})
+			}
+			return ctx
+		}).
+		Build()
+}
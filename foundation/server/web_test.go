@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+)
+
+func TestWebChat(t *testing.T) {
+	agent := newFakeAgent(t, &fakeModel{reply: "Channels connect goroutines.", usage: model.TokenUsage{TotalTokens: 9}})
+	s, base := startServer(t, agent, WithAPIKeys(map[string]string{"sk-test": "alice"}))
+
+	status, body := do(t, http.MethodPost, base+"/api/chat", chatRequest("What is a channel?", true), "Authorization", "Bearer sk-test")
+	if status != http.StatusOK {
+		t.Fatalf("status %d: %s", status, body)
+	}
+	if !strings.Contains(string(body), "event:done") {
+		t.Errorf("stream did not finish:\n%s", body)
+	}
+	if used := s.clients.get("alice").tokensToday(); used != 9 {
+		t.Errorf("client was charged %d tokens for the web turn, want 9", used)
+	}
+}
+
+func TestWebChatSendsReasoningApart(t *testing.T) {
+	agent := newFakeAgent(t, &fakeModel{reply: "<think>Recall the spec. </think>Channels connect goroutines."})
+	_, base := startServer(t, agent)
+
+	_, body := do(t, http.MethodPost, base+"/api/chat", chatRequest("What is a channel?", true))
+	var reasoning, answer string
+	for _, frame := range strings.Split(string(body), "\n\n") {
+		event, data, _ := strings.Cut(frame, "\ndata:")
+		var ev webEvent
+		json.Unmarshal([]byte(data), &ev)
+		switch strings.TrimPrefix(event, "event:") {
+		case eventReasoning:
+			reasoning += ev.Content
+		case eventToken:
+			answer += ev.Content
+		}
+	}
+	if reasoning != "Recall the spec. " || answer != "Channels connect goroutines." {
+		t.Errorf("reasoning %q and answer %q:\n%s", reasoning, answer, body)
+	}
+	if !strings.Contains(string(body), `"phase":"reasoning_started"`) {
+		t.Errorf("no reasoning_started phase:\n%s", body)
+	}
+}
+
+func TestWebChatErrorsDoNotLeakDetails(t *testing.T) {
+	agent := newFakeAgent(t, &fakeModel{err: errors.New("open /srv/secret/index.gob: permission denied")})
+	_, base := startServer(t, agent)
+
+	_, body := do(t, http.MethodPost, base+"/api/chat", chatRequest("hi", true))
+	if !strings.Contains(string(body), "event:error") {
+		t.Fatalf("no error event:\n%s", body)
+	}
+	if strings.Contains(string(body), "/srv/secret") {
+		t.Errorf("error event leaks the underlying error:\n%s", body)
+	}
+}
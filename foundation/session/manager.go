@@ -0,0 +1,246 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/logger"
+)
+
+// Manager hands out sessions, closes those that time out, and owns their
+// workspaces. A closed session's workspace is removed, but its conversation
+// stays readable until the retention period ends.
+type Manager struct {
+	store        Store
+	idle         time.Duration
+	maxDuration  time.Duration
+	retention    time.Duration
+	workspaceDir string
+
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+}
+
+// sessionLock is a session's turn lock, counted so it is dropped once no
+// turn holds or waits for it.
+type sessionLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewManager wraps store with the timeout and workspace settings from cfg.
+func NewManager(store Store, cfg config.Sessions) *Manager {
+	return &Manager{
+		store:        store,
+		idle:         cfg.IdleTimeout,
+		maxDuration:  cfg.MaxDuration,
+		retention:    cfg.Retention,
+		workspaceDir: cfg.WorkspaceDir,
+		locks:        make(map[string]*sessionLock),
+	}
+}
+
+// Create starts an empty session for owner with its own workspace directory.
+func (m *Manager) Create(ctx context.Context, owner string) (*Session, error) {
+	now := time.Now()
+	sess := &Session{ID: uuid.NewString(), Owner: owner, CreatedAt: now, UpdatedAt: now}
+	if err := os.MkdirAll(m.Workspace(sess.ID), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create session workspace: %w", err)
+	}
+	if err := m.store.Save(ctx, sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Get returns a live session, or ErrClosed for one that timed out, even if
+// the sweeper has not released it yet.
+func (m *Manager) Get(ctx context.Context, id string) (*Session, error) {
+	sess, err := m.Lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sess.ClosedAt != nil {
+		return nil, fmt.Errorf("%w after %s", ErrClosed, closeDescription(sess.CloseReason))
+	}
+	return sess, nil
+}
+
+// Lookup returns a session whether it is live or closed, with ClosedAt and
+// CloseReason set on a closed one. Sessions past their retention are gone.
+func (m *Manager) Lookup(ctx context.Context, id string) (*Session, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrNotFound
+	}
+	sess, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	m.markClosed(sess, time.Now())
+	if sess.ClosedAt != nil && time.Since(sess.UpdatedAt) > m.idle+m.retention {
+		m.Delete(ctx, id)
+		return nil, ErrNotFound
+	}
+	return sess, nil
+}
+
+// markClosed sets ClosedAt and CloseReason if sess has timed out by now.
+func (m *Manager) markClosed(sess *Session, now time.Time) {
+	idleEnd := sess.UpdatedAt.Add(m.idle)
+	var end time.Time
+	var reason string
+	if m.maxDuration > 0 {
+		end, reason = sess.CreatedAt.Add(m.maxDuration), CloseMaxDuration
+	}
+	if reason == "" || idleEnd.Before(end) {
+		end, reason = idleEnd, CloseIdle
+	}
+	if now.After(end) {
+		sess.ClosedAt, sess.CloseReason = &end, reason
+	}
+}
+
+func closeDescription(reason string) string {
+	if reason == CloseMaxDuration {
+		return "reaching the maximum session duration"
+	}
+	return "being idle"
+}
+
+// List returns the live sessions, most recently updated first.
+func (m *Manager) List(ctx context.Context) ([]*Session, error) {
+	all, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	live := all[:0]
+	for _, sess := range all {
+		if m.markClosed(sess, now); sess.ClosedAt == nil {
+			live = append(live, sess)
+		}
+	}
+	return live, nil
+}
+
+// Save stores the session and marks it as active.
+func (m *Manager) Save(ctx context.Context, sess *Session) error {
+	sess.UpdatedAt = time.Now()
+	return m.store.Save(ctx, sess)
+}
+
+// Delete removes the session and its workspace.
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	if err := m.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(m.Workspace(id)); err != nil {
+		return fmt.Errorf("failed to remove session workspace: %w", err)
+	}
+	return nil
+}
+
+// Workspace returns the directory the session's file tools are confined to.
+func (m *Manager) Workspace(id string) string {
+	return filepath.Join(m.workspaceDir, id)
+}
+
+// Lock serialises turns within one session so concurrent requests cannot
+// interleave their history. Call the returned function to release it.
+func (m *Manager) Lock(id string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[id]
+	if !ok {
+		l = &sessionLock{}
+		m.locks[id] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, id)
+		}
+	}
+}
+
+// busy reports whether a turn holds or waits for the session's lock.
+func (m *Manager) busy(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.locks[id]
+	return ok
+}
+
+// Run closes timed-out sessions until ctx is cancelled.
+func (m *Manager) Run(ctx context.Context) {
+	timeout := m.idle
+	if m.maxDuration > 0 {
+		timeout = min(timeout, m.maxDuration)
+	}
+	interval := max(min(timeout/2, time.Minute), time.Second)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.sweep(ctx)
+		}
+	}
+}
+
+// sweep deletes sessions past their retention from the store, then removes
+// the workspace of every session that is closed or gone (stores like Redis
+// expire keys on their own). Workspaces of sessions in the middle of a turn
+// are left for a later sweep.
+func (m *Manager) sweep(ctx context.Context) {
+	log := logger.FromContext(ctx)
+
+	ids, err := m.store.DeleteIdle(ctx, time.Now().Add(-m.idle-m.retention))
+	if err != nil {
+		log.Warn("failed to delete expired sessions", "error", err)
+	}
+	if len(ids) > 0 {
+		log.Info("deleted expired sessions", "count", len(ids))
+	}
+
+	entries, err := os.ReadDir(m.workspaceDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		id := e.Name()
+		if m.busy(id) {
+			continue
+		}
+		sess, err := m.store.Get(ctx, id)
+		if err == nil {
+			if m.markClosed(sess, time.Now()); sess.ClosedAt == nil {
+				continue
+			}
+			log.Info("closed session", "session_id", id, "reason", sess.CloseReason)
+		} else if !errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err := os.RemoveAll(m.Workspace(id)); err != nil {
+			log.Warn("failed to remove session workspace", "session_id", id, "error", err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+func newTestManager(t *testing.T, idle time.Duration) *Manager {
+	t.Helper()
+	return NewManager(NewMemoryStore(), config.Sessions{IdleTimeout: idle, WorkspaceDir: t.TempDir()})
+}
+
+func (m *Manager) lockCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.locks)
+}
+
+func TestManagerLockSerialisesTurns(t *testing.T) {
+	m := newTestManager(t, time.Hour)
+
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := m.Lock("a")
+			defer unlock()
+			mu.Lock()
+			active++
+			maxActive = max(maxActive, active)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if maxActive != 1 {
+		t.Errorf("%d turns held the session lock at once, want 1", maxActive)
+	}
+	if n := m.lockCount(); n != 0 {
+		t.Errorf("%d locks left after every turn finished, want 0", n)
+	}
+}
+
+func TestManagerDeleteKeepsHeldLock(t *testing.T) {
+	m := newTestManager(t, time.Hour)
+	ctx := context.Background()
+	sess, err := m.Create(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock := m.Lock(sess.ID)
+	if err := m.Delete(ctx, sess.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// A turn arriving after the delete must still wait for the one in flight.
+	acquired := make(chan struct{})
+	go func() {
+		defer m.Lock(sess.ID)()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("a second turn took the lock while the first still held it")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("the waiting turn never got the lock")
+	}
+}
+
+func TestManagerCreateGetDelete(t *testing.T) {
+	m := newTestManager(t, time.Hour)
+	ctx := context.Background()
+
+	sess, err := m.Create(ctx, "")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := os.Stat(m.Workspace(sess.ID)); err != nil {
+		t.Errorf("Create did not make the workspace: %v", err)
+	}
+	if _, err := m.Get(ctx, sess.ID); err != nil {
+		t.Errorf("Get: %v", err)
+	}
+	if _, err := m.Get(ctx, "../../etc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a non-UUID ID = %v, want ErrNotFound", err)
+	}
+
+	if err := m.Delete(ctx, sess.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(m.Workspace(sess.ID)); !os.IsNotExist(err) {
+		t.Errorf("Delete left the workspace behind: %v", err)
+	}
+	if _, err := m.Get(ctx, sess.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestManagerExpiresIdleSessions(t *testing.T) {
+	m := newTestManager(t, time.Hour)
+	ctx := context.Background()
+
+	sess, err := m.Create(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess.UpdatedAt = time.Now().Add(-2 * time.Hour)
+	if err := m.store.Save(ctx, sess); err != nil {
+		t.Fatal(err)
+	}
+	if list, err := m.List(ctx); err != nil || len(list) != 0 {
+		t.Errorf("List = %v, %v; want the idle session hidden", list, err)
+	}
+
+	m.sweep(ctx)
+	if _, err := m.store.Get(ctx, sess.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("sweep kept the idle session: %v", err)
+	}
+	if _, err := os.Stat(m.Workspace(sess.ID)); !os.IsNotExist(err) {
+		t.Errorf("sweep left the workspace behind: %v", err)
+	}
+}
+
+func TestManagerClosesTimedOutSessions(t *testing.T) {
+	m := NewManager(NewMemoryStore(), config.Sessions{
+		IdleTimeout: time.Hour, MaxDuration: 8 * time.Hour, Retention: 24 * time.Hour, WorkspaceDir: t.TempDir(),
+	})
+	ctx := context.Background()
+	create := func(created, updated time.Duration) *Session {
+		t.Helper()
+		sess, err := m.Create(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sess.CreatedAt, sess.UpdatedAt = time.Now().Add(-created), time.Now().Add(-updated)
+		if err := m.store.Save(ctx, sess); err != nil {
+			t.Fatal(err)
+		}
+		return sess
+	}
+	live := create(time.Hour, time.Minute)
+	idle := create(3*time.Hour, 2*time.Hour)
+	old := create(9*time.Hour, time.Minute)
+	busy := create(9*time.Hour, time.Minute)
+	gone := create(30*time.Hour, 26*time.Hour)
+
+	for _, tc := range []struct {
+		sess   *Session
+		reason string
+	}{{live, ""}, {idle, CloseIdle}, {old, CloseMaxDuration}} {
+		_, err := m.Get(ctx, tc.sess.ID)
+		if closed := errors.Is(err, ErrClosed); closed != (tc.reason != "") {
+			t.Errorf("Get(%s) = %v", tc.reason, err)
+		}
+		sess, err := m.Lookup(ctx, tc.sess.ID)
+		if err != nil || sess.CloseReason != tc.reason || (sess.ClosedAt != nil) != (tc.reason != "") {
+			t.Errorf("Lookup(%s) = %+v, %v", tc.reason, sess, err)
+		}
+	}
+	if list, err := m.List(ctx); err != nil || len(list) != 1 || list[0].ID != live.ID {
+		t.Errorf("List = %v, %v; want only the live session", list, err)
+	}
+
+	// Closed sessions lose their workspace but keep their conversation until
+	// the retention period ends; a session in the middle of a turn keeps its
+	// workspace until the turn is over.
+	unlock := m.Lock(busy.ID)
+	m.sweep(ctx)
+	for sess, keepsWorkspace := range map[*Session]bool{live: true, idle: false, old: false, busy: true, gone: false} {
+		if _, err := os.Stat(m.Workspace(sess.ID)); (err == nil) != keepsWorkspace {
+			t.Errorf("workspace of %s: %v, want kept %v", sess.ID, err, keepsWorkspace)
+		}
+	}
+	if _, err := m.store.Get(ctx, idle.ID); err != nil {
+		t.Errorf("sweep deleted a closed session within its retention: %v", err)
+	}
+	if _, err := m.store.Get(ctx, gone.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("sweep kept a session past its retention: %v", err)
+	}
+	unlock()
+	m.sweep(ctx)
+	if _, err := os.Stat(m.Workspace(busy.ID)); !os.IsNotExist(err) {
+		t.Errorf("sweep kept the workspace after the turn ended: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in process memory. They are lost on restart.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]*Session)}
+}
+
+func (m *MemoryStore) Get(ctx context.Context, id string) (*Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	// Hand out a copy so callers can append without racing other readers.
+	cp := *s
+	cp.Messages = append(s.Messages[:0:0], s.Messages...)
+	cp.Turns = append(s.Turns[:0:0], s.Turns...)
+	return &cp, nil
+}
+
+func (m *MemoryStore) Save(ctx context.Context, s *Session) error {
+	cp := *s
+	cp.Messages = append(s.Messages[:0:0], s.Messages...)
+	cp.Turns = append(s.Turns[:0:0], s.Turns...)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.ID] = &cp
+	return nil
+}
+
+func (m *MemoryStore) List(ctx context.Context) ([]*Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		cp := *s
+		cp.Messages = append(s.Messages[:0:0], s.Messages...)
+		cp.Turns = append(s.Turns[:0:0], s.Turns...)
+		sessions = append(sessions, &cp)
+	}
+	sortByActivity(sessions)
+	return sessions, nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *MemoryStore) DeleteIdle(ctx context.Context, cutoff time.Time) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ids []string
+	for id, s := range m.sessions {
+		if s.UpdatedAt.Before(cutoff) {
+			delete(m.sessions, id)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *MemoryStore) Close() error { return nil }
@@ -0,0 +1,96 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "goforai:session:"
+
+// RedisStore keeps sessions in Redis so several server replicas can share
+// them. Redis deletes expired sessions itself through key TTLs.
+type RedisStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisStore connects to the Redis instance at url (redis://host:port/db).
+// Every save refreshes the session's TTL to ttl.
+func NewRedisStore(ctx context.Context, url string, ttl time.Duration) (*RedisStore, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", opts.Addr, err)
+	}
+	return &RedisStore{client: client, ttl: ttl}, nil
+}
+
+func (r *RedisStore) Get(ctx context.Context, id string) (*Session, error) {
+	raw, err := r.client.Get(ctx, redisKeyPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", id, err)
+	}
+	var sess Session
+	if err := json.Unmarshal(raw, &sess); err != nil {
+		return nil, fmt.Errorf("failed to decode session %s: %w", id, err)
+	}
+	return &sess, nil
+}
+
+func (r *RedisStore) Save(ctx context.Context, sess *Session) error {
+	raw, err := json.Marshal(sess)
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", sess.ID, err)
+	}
+	if err := r.client.Set(ctx, redisKeyPrefix+sess.ID, raw, r.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save session %s: %w", sess.ID, err)
+	}
+	return nil
+}
+
+func (r *RedisStore) List(ctx context.Context) ([]*Session, error) {
+	var sessions []*Session
+	iter := r.client.Scan(ctx, 0, redisKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		sess, err := r.Get(ctx, strings.TrimPrefix(iter.Val(), redisKeyPrefix))
+		if errors.Is(err, ErrNotFound) {
+			continue // Expired between SCAN and GET.
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	sortByActivity(sessions)
+	return sessions, nil
+}
+
+func (r *RedisStore) Delete(ctx context.Context, id string) error {
+	if err := r.client.Del(ctx, redisKeyPrefix+id).Err(); err != nil {
+		return fmt.Errorf("failed to delete session %s: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteIdle is a no-op: key TTLs already delete expired sessions.
+func (r *RedisStore) DeleteIdle(ctx context.Context, cutoff time.Time) ([]string, error) {
+	return nil, nil
+}
+
+func (r *RedisStore) Close() error { return r.client.Close() }
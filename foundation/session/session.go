@@ -0,0 +1,106 @@
+// Package session keeps per-user conversations for server mode. Conversations
+// live in a pluggable Store; the Manager adds IDs, idle and total timeouts,
+// per-session locking, and a private tool workspace for every session.
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// ErrNotFound is returned when a session does not exist or has expired.
+var ErrNotFound = errors.New("session not found")
+
+// ErrClosed is returned for a session that timed out. Its conversation can
+// still be read until the retention period ends, but it takes no more turns.
+var ErrClosed = errors.New("session closed")
+
+// Reasons a session is closed.
+const (
+	CloseIdle        = "idle"         // No turn for longer than the idle timeout.
+	CloseMaxDuration = "max_duration" // Older than the maximum session duration.
+)
+
+// Session is one user's conversation with the agent.
+type Session struct {
+	ID        string            `json:"id"`
+	Owner     string            `json:"owner,omitempty"` // Client that created it; empty when the server is open.
+	Messages  []*schema.Message `json:"messages"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+
+	// ClosedAt and CloseReason are set by the Manager on sessions that timed
+	// out; stores do not keep them, as they follow from the timestamps.
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	CloseReason string     `json:"close_reason,omitempty"`
+
+	// Turns records how each message was answered, for `goforai replay`.
+	Turns []*Turn `json:"turns,omitempty"`
+}
+
+// Turn is how the agent answered one user message of a session: what it
+// searched the knowledge base for and which tools it called with what
+// result.
+type Turn struct {
+	Message    int         `json:"message"` // Index of the user message in Messages; the answer follows it.
+	StartedAt  time.Time   `json:"started_at"`
+	DurationMS int64       `json:"duration_ms"`
+	Retrievals []Retrieval `json:"retrievals,omitempty"`
+	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
+}
+
+// Retrieval is a knowledge base search made during a turn.
+type Retrieval struct {
+	Query   string   `json:"query"`
+	Sources []string `json:"sources"` // Where each document found came from.
+}
+
+// ToolCall is a tool call made during a turn. Long results are shortened.
+type ToolCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Store persists sessions. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the session with the given ID, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Session, error)
+	// Save creates or replaces a session.
+	Save(ctx context.Context, s *Session) error
+	// List returns every stored session, most recently updated first.
+	List(ctx context.Context) ([]*Session, error)
+	// Delete removes a session. Deleting a missing session is not an error.
+	Delete(ctx context.Context, id string) error
+	// DeleteIdle removes every session last updated before cutoff and returns
+	// their IDs.
+	DeleteIdle(ctx context.Context, cutoff time.Time) ([]string, error)
+	// Close releases the store's resources.
+	Close() error
+}
+
+// sortByActivity orders sessions most recently updated first.
+func sortByActivity(sessions []*Session) {
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt) })
+}
+
+// NewStore opens the store selected by cfg.Store.
+func NewStore(ctx context.Context, cfg config.Sessions) (Store, error) {
+	switch cfg.Store {
+	case config.SessionStoreMemory:
+		return NewMemoryStore(), nil
+	case config.SessionStoreSQLite:
+		return NewSQLiteStore(ctx, cfg.SQLitePath)
+	case config.SessionStoreRedis:
+		return NewRedisStore(ctx, cfg.RedisURL, cfg.IdleTimeout+cfg.Retention)
+	default:
+		return nil, fmt.Errorf("unknown session store %q", cfg.Store)
+	}
+}
@@ -0,0 +1,181 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure-Go driver keeps the binary cgo-free.
+)
+
+// SQLiteStore persists sessions in a local SQLite database, so conversations
+// survive restarts of a single-node deployment.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id         TEXT PRIMARY KEY,
+	owner      TEXT NOT NULL DEFAULT '',
+	messages   TEXT NOT NULL,
+	turns      TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sessions_updated_at ON sessions (updated_at);`
+
+// NewSQLiteStore opens (or creates) the database at path.
+func NewSQLiteStore(ctx context.Context, path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create session database directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session database %s: %w", path, err)
+	}
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create session schema: %w", err)
+	}
+	if err := addColumn(ctx, db, "owner", `TEXT NOT NULL DEFAULT ''`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := addColumn(ctx, db, "turns", `TEXT NOT NULL DEFAULT ''`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// addColumn upgrades databases created before sessions had the named
+// column. Sessions created before sessions had owners keep an empty owner,
+// which any client may use; those created before turns were recorded have
+// none to replay.
+func addColumn(ctx context.Context, db *sql.DB, name, definition string) error {
+	var n int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info('sessions') WHERE name = ?`, name).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("failed to inspect session schema: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, `ALTER TABLE sessions ADD COLUMN `+name+` `+definition); err != nil {
+		return fmt.Errorf("failed to add the session %s column: %w", name, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Get(ctx context.Context, id string) (*Session, error) {
+	var owner, raw, turns string
+	var created, updated int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT owner, messages, turns, created_at, updated_at FROM sessions WHERE id = ?`, id,
+	).Scan(&owner, &raw, &turns, &created, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", id, err)
+	}
+
+	sess := &Session{ID: id, Owner: owner, CreatedAt: time.UnixMilli(created), UpdatedAt: time.UnixMilli(updated)}
+	if err := decodeSession(sess, raw, turns); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+func (s *SQLiteStore) Save(ctx context.Context, sess *Session) error {
+	raw, err := json.Marshal(sess.Messages)
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", sess.ID, err)
+	}
+	turns, err := json.Marshal(sess.Turns)
+	if err != nil {
+		return fmt.Errorf("failed to encode session %s: %w", sess.ID, err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO sessions (id, owner, messages, turns, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET messages = excluded.messages, turns = excluded.turns, updated_at = excluded.updated_at`,
+		sess.ID, sess.Owner, string(raw), string(turns), sess.CreatedAt.UnixMilli(), sess.UpdatedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save session %s: %w", sess.ID, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) List(ctx context.Context) ([]*Session, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, owner, messages, turns, created_at, updated_at FROM sessions ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		var raw, turns string
+		var created, updated int64
+		sess := &Session{}
+		if err := rows.Scan(&sess.ID, &sess.Owner, &raw, &turns, &created, &updated); err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		sess.CreatedAt, sess.UpdatedAt = time.UnixMilli(created), time.UnixMilli(updated)
+		if err := decodeSession(sess, raw, turns); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+// decodeSession decodes the messages and turns columns into sess. Rows
+// written before turns were recorded have none.
+func decodeSession(sess *Session, messages, turns string) error {
+	if err := json.Unmarshal([]byte(messages), &sess.Messages); err != nil {
+		return fmt.Errorf("failed to decode session %s: %w", sess.ID, err)
+	}
+	if turns == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(turns), &sess.Turns); err != nil {
+		return fmt.Errorf("failed to decode session %s: %w", sess.ID, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete session %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeleteIdle(ctx context.Context, cutoff time.Time) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`DELETE FROM sessions WHERE updated_at < ? RETURNING id`, cutoff.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to expire sessions: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *SQLiteStore) Close() error { return s.db.Close() }
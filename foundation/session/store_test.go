@@ -0,0 +1,201 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/cloudwego/eino/schema"
+)
+
+// stores opens every Store implementation against throwaway backends.
+func stores(t *testing.T) map[string]Store {
+	t.Helper()
+	ctx := context.Background()
+
+	sqlite, err := NewSQLiteStore(ctx, filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+
+	mr := miniredis.RunT(t)
+	redis, err := NewRedisStore(ctx, "redis://"+mr.Addr()+"/0", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { redis.Close() })
+
+	return map[string]Store{"memory": NewMemoryStore(), "sqlite": sqlite, "redis": redis}
+}
+
+func testSession(id string, updated time.Time) *Session {
+	return &Session{
+		ID:        id,
+		Owner:     "alice",
+		Messages:  []*schema.Message{schema.UserMessage("What is Eino?"), schema.AssistantMessage("A Go LLM framework.", nil)},
+		CreatedAt: updated.Add(-time.Minute),
+		UpdatedAt: updated,
+		Turns: []*Turn{{
+			StartedAt:  updated,
+			Retrievals: []Retrieval{{Query: "Eino", Sources: []string{"talks.md"}}},
+			ToolCalls:  []ToolCall{{Name: "search_gophercon_knowledge", Arguments: `{"query":"Eino"}`, Result: "Eino is..."}},
+		}},
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get of a missing session = %v, want ErrNotFound", err)
+			}
+
+			now := time.Now().Truncate(time.Second)
+			want := testSession("a", now)
+			if err := store.Save(ctx, want); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			got, err := store.Get(ctx, "a")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got.Owner != "alice" || len(got.Messages) != 2 || got.Messages[1].Content != "A Go LLM framework." || !got.UpdatedAt.Equal(now) {
+				t.Errorf("Get = %+v, want %+v", got, want)
+			}
+			if len(got.Turns) != 1 || len(got.Turns[0].ToolCalls) != 1 || got.Turns[0].Retrievals[0].Sources[0] != "talks.md" {
+				t.Errorf("Get lost the turns: %+v", got.Turns)
+			}
+
+			want.Messages = append(want.Messages, schema.UserMessage("And Hertz?"))
+			if err := store.Save(ctx, want); err != nil {
+				t.Fatalf("Save over an existing session: %v", err)
+			}
+			if got, _ := store.Get(ctx, "a"); got == nil || len(got.Messages) != 3 {
+				t.Errorf("Save did not replace the session: %+v", got)
+			}
+
+			if err := store.Delete(ctx, "a"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := store.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+			}
+			if err := store.Delete(ctx, "a"); err != nil {
+				t.Errorf("Delete of a missing session = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, s := range []*Session{
+				testSession("old", now.Add(-2*time.Hour)),
+				testSession("new", now),
+				testSession("middle", now.Add(-time.Hour)),
+			} {
+				if err := store.Save(ctx, s); err != nil {
+					t.Fatal(err)
+				}
+			}
+			list, err := store.List(ctx)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			var ids []string
+			for _, s := range list {
+				ids = append(ids, s.ID)
+			}
+			if len(ids) != 3 || ids[0] != "new" || ids[1] != "middle" || ids[2] != "old" {
+				t.Errorf("List order = %v, want [new middle old]", ids)
+			}
+		})
+	}
+}
+
+func TestStoreDeleteIdle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := store.Save(ctx, testSession("idle", now.Add(-2*time.Hour))); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Save(ctx, testSession("active", now)); err != nil {
+				t.Fatal(err)
+			}
+			ids, err := store.DeleteIdle(ctx, now.Add(-time.Hour))
+			if err != nil {
+				t.Fatalf("DeleteIdle: %v", err)
+			}
+			if _, ok := store.(*RedisStore); ok {
+				// Redis expires keys through their TTL instead.
+				return
+			}
+			if len(ids) != 1 || ids[0] != "idle" {
+				t.Errorf("DeleteIdle removed %v, want [idle]", ids)
+			}
+			if _, err := store.Get(ctx, "idle"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("idle session survived: %v", err)
+			}
+			if _, err := store.Get(ctx, "active"); err != nil {
+				t.Errorf("active session was removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestSQLiteStoreUpgradesSchema(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The schema before sessions had owners.
+	_, err = db.Exec(`CREATE TABLE sessions (id TEXT PRIMARY KEY, messages TEXT NOT NULL, created_at INTEGER NOT NULL, updated_at INTEGER NOT NULL);
+		INSERT INTO sessions VALUES ('legacy', '[]', 0, 0);`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewSQLiteStore(ctx, path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore on an old database: %v", err)
+	}
+	defer store.Close()
+	if sess, err := store.Get(ctx, "legacy"); err != nil || sess.Owner != "" {
+		t.Errorf("legacy session = %+v, %v; want it unowned", sess, err)
+	}
+	if err := store.Save(ctx, testSession("new", time.Now())); err != nil {
+		t.Fatalf("Save after the upgrade: %v", err)
+	}
+}
+
+func TestRedisStoreExpiresSessions(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	store, err := NewRedisStore(ctx, "redis://"+mr.Addr()+"/0", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if err := store.Save(ctx, testSession("a", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(2 * time.Minute)
+	if _, err := store.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get past the TTL = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,183 @@
+// Package snapshot records the files of a workspace before an unattended run
+// and reports what the run created, modified and deleted, with diffs, so the
+// changes an agent made without anyone watching can be reviewed afterwards.
+package snapshot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// maxDiffBytes is the largest file whose content is kept for diffing. Larger
+// files, and binary ones, are compared by hash only.
+const maxDiffBytes = 1 << 20
+
+// skippedDirs are never recorded: version control and editor state, and
+// dependencies installed by other tools.
+var skippedDirs = map[string]bool{
+	".git": true, "node_modules": true, ".venv": true, ".idea": true, ".vscode": true,
+}
+
+// Kinds of change.
+const (
+	Created  = "created"
+	Modified = "modified"
+	Deleted  = "deleted"
+)
+
+// Change is one file that differs between two snapshots.
+type Change struct {
+	Path string `json:"path"` // Slash-separated, relative to the root.
+	Kind string `json:"kind"`
+	// Diff is a unified diff of the file, empty for binary and large files.
+	Diff string `json:"diff,omitempty"`
+}
+
+// Snapshot is the state of the files under a directory at one moment.
+type Snapshot struct {
+	root  string
+	files map[string]file
+}
+
+type file struct {
+	hash    [sha256.Size]byte
+	content []byte // nil when the file is binary or too large to diff.
+}
+
+// Take records every regular file under root.
+func Take(root string) (*Snapshot, error) {
+	s := &Snapshot{root: root, files: make(map[string]file)}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		f := file{hash: sha256.Sum256(data)}
+		if len(data) <= maxDiffBytes && utf8.Valid(data) && !bytes.ContainsRune(data, 0) {
+			f.content = data
+		}
+		s.files[filepath.ToSlash(rel)] = f
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s: %w", root, err)
+	}
+	return s, nil
+}
+
+// Changes snapshots the root again and returns what changed since s was
+// taken, sorted by path.
+func (s *Snapshot) Changes() ([]Change, error) {
+	now, err := Take(s.root)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(s, now), nil
+}
+
+// Compare returns the files that differ from before to after, sorted by path.
+func Compare(before, after *Snapshot) []Change {
+	var changes []Change
+	for path, a := range after.files {
+		b, existed := before.files[path]
+		switch {
+		case !existed:
+			changes = append(changes, Change{Path: path, Kind: Created, Diff: diff(path, nil, a)})
+		case b.hash != a.hash:
+			changes = append(changes, Change{Path: path, Kind: Modified, Diff: diff(path, &b, a)})
+		}
+	}
+	for path, b := range before.files {
+		if _, ok := after.files[path]; !ok {
+			changes = append(changes, Change{Path: path, Kind: Deleted, Diff: diff(path, &b, file{})})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// diff returns the unified diff that turns before into after, with git's
+// a/ and b/ prefixes and /dev/null for a missing side. A nil before means the
+// file was created; an after without a hash means it was deleted.
+func diff(path string, before *file, after file) string {
+	deleted := after.hash == [sha256.Size]byte{}
+	if (before != nil && before.content == nil) || (!deleted && after.content == nil) {
+		return ""
+	}
+	ud := difflib.UnifiedDiff{FromFile: "a/" + path, ToFile: "b/" + path, Context: 3}
+	if before == nil {
+		ud.FromFile = "/dev/null"
+	} else {
+		ud.A = lines(before.content)
+	}
+	if deleted {
+		ud.ToFile = "/dev/null"
+	} else {
+		ud.B = lines(after.content)
+	}
+	out, err := difflib.GetUnifiedDiffString(ud)
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// lines splits content into lines that keep their newlines. A missing final
+// newline is added, so the diff does not record it.
+func lines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	text := string(content)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	split := strings.SplitAfter(text, "\n")
+	return split[:len(split)-1] // SplitAfter ends with the empty string after the last newline.
+}
+
+// Report renders changes for a person: a summary line per file, then the
+// diffs as one patch.
+func Report(changes []Change) string {
+	if len(changes) == 0 {
+		return "No files were changed.\n"
+	}
+	var b strings.Builder
+	for _, c := range changes {
+		fmt.Fprintf(&b, "%-8s %s\n", c.Kind, c.Path)
+	}
+	for _, c := range changes {
+		if c.Diff == "" {
+			fmt.Fprintf(&b, "\nBinary or large file %s %s.\n", c.Path, c.Kind)
+			continue
+		}
+		b.WriteString("\n")
+		b.WriteString(c.Diff)
+	}
+	return b.String()
+}
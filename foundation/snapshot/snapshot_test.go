@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	root := t.TempDir()
+	write(t, filepath.Join(root, "main.go"), "package main\n\nfunc main() {}\n")
+	write(t, filepath.Join(root, "old.go"), "package main\n")
+	write(t, filepath.Join(root, "same.go"), "package main\n")
+	write(t, filepath.Join(root, "logo.png"), "\x89PNG\x00\x01")
+	write(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/main\n")
+
+	before, err := Take(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, filepath.Join(root, "main.go"), "package main\n\nfunc main() {\n\trun()\n}\n")
+	write(t, filepath.Join(root, "pkg", "run.go"), "package main\n")
+	write(t, filepath.Join(root, "logo.png"), "\x89PNG\x00\x02")
+	write(t, filepath.Join(root, ".git", "HEAD"), "ref: refs/heads/agent\n")
+	os.Remove(filepath.Join(root, "old.go"))
+
+	changes, err := before.Changes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Kind+" "+c.Path)
+	}
+	want := "modified logo.png|modified main.go|deleted old.go|created pkg/run.go"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("changes = %q, want %q", got, want)
+	}
+
+	if changes[0].Diff != "" {
+		t.Errorf("binary file has a diff: %q", changes[0].Diff)
+	}
+	if d := changes[1].Diff; !strings.HasPrefix(d, "--- a/main.go\n+++ b/main.go\n") || !strings.Contains(d, "-func main() {}\n+func main() {\n+\trun()\n+}\n") {
+		t.Errorf("main.go diff:\n%s", d)
+	}
+	if d := changes[2].Diff; !strings.Contains(d, "+++ /dev/null\n") || !strings.Contains(d, "-package main\n") {
+		t.Errorf("old.go diff:\n%s", d)
+	}
+	if d := changes[3].Diff; !strings.HasPrefix(d, "--- /dev/null\n+++ b/pkg/run.go\n") || !strings.Contains(d, "+package main\n") {
+		t.Errorf("run.go diff:\n%s", d)
+	}
+
+	report := Report(changes)
+	if !strings.HasPrefix(report, "modified logo.png\nmodified main.go\ndeleted  old.go\ncreated  pkg/run.go\n") ||
+		!strings.Contains(report, "Binary or large file logo.png modified.") {
+		t.Errorf("report:\n%s", report)
+	}
+	if got := Report(nil); got != "No files were changed.\n" {
+		t.Errorf("empty report = %q", got)
+	}
+}
@@ -0,0 +1,153 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"google.golang.org/genai"
+)
+
+const (
+	// TTSModelName is the Gemini model that turns text into speech.
+	TTSModelName = "gemini-2.5-flash-preview-tts"
+	// DefaultGeminiVoice is the prebuilt voice used when TTS_VOICE is unset.
+	DefaultGeminiVoice = "Kore"
+)
+
+// geminiSampleRate is the rate of the 16-bit mono PCM the model returns when
+// its MIME type does not say otherwise.
+const geminiSampleRate = 24000
+
+// players are programs that play a WAV file, with the arguments that precede
+// the file name.
+var players = []struct {
+	name string
+	args []string
+}{
+	{"afplay", nil},
+	{"aplay", []string{"-q"}},
+	{"paplay", nil},
+	{"ffplay", []string{"-nodisp", "-autoexit", "-loglevel", "quiet"}},
+}
+
+// geminiSpeaker synthesizes speech with Gemini and plays it with a local
+// audio player.
+type geminiSpeaker struct {
+	client *genai.Client
+	voice  string
+	player string
+	args   []string
+}
+
+func newGeminiSpeaker(ctx context.Context, voice string) (*geminiSpeaker, error) {
+	s := &geminiSpeaker{voice: voice}
+	if s.voice == "" {
+		s.voice = DefaultGeminiVoice
+	}
+	for _, p := range players {
+		if path, err := exec.LookPath(p.name); err == nil {
+			s.player, s.args = path, p.args
+			break
+		}
+	}
+	if s.player == "" {
+		return nil, errors.New("no audio player found for Gemini speech; install aplay, paplay or ffplay")
+	}
+	client, err := gemini.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.client = client
+	return s, nil
+}
+
+func (s *geminiSpeaker) Speak(ctx context.Context, text string) error {
+	resp, err := s.client.Models.GenerateContent(ctx, TTSModelName, genai.Text(Speakable(text)), &genai.GenerateContentConfig{
+		ResponseModalities: []string{string(genai.ModalityAudio)},
+		SpeechConfig: &genai.SpeechConfig{
+			VoiceConfig: &genai.VoiceConfig{PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{VoiceName: s.voice}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("speech synthesis failed: %w", err)
+	}
+	audio := inlineAudio(resp)
+	if audio == nil {
+		return errors.New("speech synthesis returned no audio")
+	}
+
+	f, err := os.CreateTemp("", "goforai-speech-*.wav")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(pcmToWAV(audio.Data, sampleRate(audio.MIMEType)))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write speech: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, s.player, append(s.args, f.Name())...)
+	if out, err := cmd.CombinedOutput(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%s failed: %w: %s", s.player, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// inlineAudio returns the first audio part of a response, or nil.
+func inlineAudio(resp *genai.GenerateContentResponse) *genai.Blob {
+	for _, c := range resp.Candidates {
+		if c.Content == nil {
+			continue
+		}
+		for _, p := range c.Content.Parts {
+			if p.InlineData != nil && strings.HasPrefix(p.InlineData.MIMEType, "audio/") {
+				return p.InlineData
+			}
+		}
+	}
+	return nil
+}
+
+// sampleRate reads the rate parameter of a MIME type such as
+// "audio/L16;codec=pcm;rate=24000".
+func sampleRate(mimeType string) int {
+	for _, param := range strings.Split(mimeType, ";") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(param), "rate="); ok {
+			if rate, err := strconv.Atoi(v); err == nil && rate > 0 {
+				return rate
+			}
+		}
+	}
+	return geminiSampleRate
+}
+
+// pcmToWAV wraps 16-bit little-endian mono PCM samples in a WAV header.
+func pcmToWAV(pcm []byte, rate int) []byte {
+	const channels, bitsPerSample = 1, 16
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(pcm)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16)) // fmt chunk size
+	binary.Write(&buf, binary.LittleEndian, uint16(1))  // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(rate))
+	binary.Write(&buf, binary.LittleEndian, uint32(rate*channels*bitsPerSample/8))
+	binary.Write(&buf, binary.LittleEndian, uint16(channels*bitsPerSample/8))
+	binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
@@ -0,0 +1,196 @@
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/gemini"
+	"github.com/olusolaa/goforai/foundation/prompts"
+	"github.com/olusolaa/goforai/foundation/secrets"
+	"github.com/olusolaa/goforai/foundation/wirelog"
+	"google.golang.org/genai"
+)
+
+// Defaults for the transcription providers.
+const (
+	DefaultOpenAIBaseURL  = "https://api.openai.com/v1"
+	DefaultOpenAISTTModel = "whisper-1"
+)
+
+// ErrNoRecorder is returned when no program to record from the microphone is
+// installed.
+var ErrNoRecorder = errors.New("no audio recorder found; install sox (for rec) or alsa-utils (for arecord)")
+
+// recorders are programs that record 16 kHz mono WAV from the default
+// microphone into the file named by their last argument until interrupted.
+var recorders = []struct {
+	name string
+	args []string
+}{
+	{"rec", []string{"-q", "-c", "1", "-r", "16000", "-b", "16"}},
+	{"arecord", []string{"-q", "-f", "S16_LE", "-c", "1", "-r", "16000"}},
+}
+
+// Recorder records from the microphone with a local program.
+type Recorder struct {
+	path string
+	args []string
+}
+
+// NewRecorder returns a Recorder using the first recording program on PATH.
+func NewRecorder() (*Recorder, error) {
+	for _, r := range recorders {
+		if path, err := exec.LookPath(r.name); err == nil {
+			return &Recorder{path: path, args: r.args}, nil
+		}
+	}
+	return nil, ErrNoRecorder
+}
+
+// Record records until ctx is done and returns the recording as WAV.
+func (r *Recorder) Record(ctx context.Context) ([]byte, error) {
+	f, err := os.CreateTemp("", "goforai-recording-*.wav")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	cmd := exec.CommandContext(ctx, r.path, append(r.args, f.Name())...)
+	// An interrupt lets the recorder finish the WAV header; a kill would
+	// leave it truncated.
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = 3 * time.Second
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", r.path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	audio, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	if len(audio) <= 44 { // A WAV header and no samples.
+		return nil, errors.New("nothing was recorded; check the microphone")
+	}
+	return audio, nil
+}
+
+// Transcriber turns a spoken WAV recording into text.
+type Transcriber interface {
+	Transcribe(ctx context.Context, wav []byte) (string, error)
+}
+
+// NewTranscriber returns the Transcriber cfg selects.
+func NewTranscriber(ctx context.Context, cfg config.Speech) (Transcriber, error) {
+	switch cfg.STTProvider {
+	case config.STTGemini, "":
+		client, err := gemini.Client(ctx)
+		if err != nil {
+			return nil, err
+		}
+		model := cfg.STTModel
+		if model == "" {
+			model = gemini.ChatModel()
+		}
+		return &geminiTranscriber{client: client, model: model}, nil
+	case config.STTOpenAI:
+		apiKey, err := secrets.Get(secrets.OpenAIAPIKey)
+		if err != nil {
+			return nil, err
+		}
+		transport, err := wirelog.Transport(config.STTOpenAI)
+		if err != nil {
+			return nil, err
+		}
+		t := &openAITranscriber{baseURL: cfg.STTBaseURL, model: cfg.STTModel, apiKey: apiKey, client: &http.Client{Transport: transport}}
+		if t.baseURL == "" {
+			t.baseURL = DefaultOpenAIBaseURL
+		}
+		if t.model == "" {
+			t.model = DefaultOpenAISTTModel
+		}
+		return t, nil
+	default:
+		return nil, fmt.Errorf("unknown speech-to-text provider %q", cfg.STTProvider)
+	}
+}
+
+// geminiTranscriber sends the recording to a multimodal Gemini model.
+type geminiTranscriber struct {
+	client *genai.Client
+	model  string
+}
+
+func (t *geminiTranscriber) Transcribe(ctx context.Context, wav []byte) (string, error) {
+	// The speech.transcribe prompt asks for a bare transcript.
+	prompt, err := prompts.Get("speech.transcribe")
+	if err != nil {
+		return "", err
+	}
+	contents := []*genai.Content{genai.NewContentFromParts([]*genai.Part{
+		genai.NewPartFromText(prompt.Text),
+		genai.NewPartFromBytes(wav, "audio/wav"),
+	}, genai.RoleUser)}
+	resp, err := t.client.Models.GenerateContent(ctx, t.model, contents, nil)
+	if err != nil {
+		return "", fmt.Errorf("transcription failed: %w", err)
+	}
+	return strings.TrimSpace(resp.Text()), nil
+}
+
+// openAITranscriber calls the OpenAI transcription API, or any API
+// compatible with it, such as a local whisper.cpp server.
+type openAITranscriber struct {
+	baseURL, model, apiKey string
+	client                 *http.Client
+}
+
+func (t *openAITranscriber) Transcribe(ctx context.Context, wav []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("model", t.model)
+	part, err := w.CreateFormFile("file", "recording.wav")
+	if err != nil {
+		return "", err
+	}
+	part.Write(wav)
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcription failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("transcription failed: status %d: %s", resp.StatusCode, bytes.TrimSpace(raw))
+	}
+	var out struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode transcription: %w", err)
+	}
+	return strings.TrimSpace(out.Text), nil
+}
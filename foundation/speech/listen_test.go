@@ -0,0 +1,90 @@
+package speech
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+	"github.com/olusolaa/goforai/foundation/prompts"
+)
+
+func TestRecorder(t *testing.T) {
+	// A shell script stands in for the recorder: it writes a header and a
+	// few samples, then waits to be interrupted like rec and arecord.
+	r := &Recorder{path: "/bin/sh", args: []string{"-c", `printf 'RIFF%0.s' $(seq 12) > "$0"; exec sleep 30`}}
+	ctx, stop := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer stop()
+	start := time.Now()
+	audio, err := r.Record(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audio) != 48 || time.Since(start) > 10*time.Second {
+		t.Errorf("recorded %d bytes in %s", len(audio), time.Since(start))
+	}
+
+	// A recorder that writes nothing reports it.
+	r = &Recorder{path: "/bin/sh", args: []string{"-c", "exec sleep 30"}}
+	ctx, stop = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer stop()
+	if _, err := r.Record(ctx); err == nil || !strings.Contains(err.Error(), "nothing was recorded") {
+		t.Errorf("empty recording = %v", err)
+	}
+}
+
+func TestGeminiTranscriber(t *testing.T) {
+	fake := fakeapi.NewGemini(t)
+	fake.Use(t)
+	fake.ReplyText("How do I cancel a context?\n")
+
+	tr, err := NewTranscriber(context.Background(), config.Speech{STTProvider: config.STTGemini})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := tr.Transcribe(context.Background(), []byte("RIFF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "How do I cancel a context?" {
+		t.Errorf("transcript = %q", text)
+	}
+	prompt, err := prompts.Get("speech.transcribe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := fake.Requests()
+	if len(reqs) != 1 || reqs[0].Contents[0].Parts[0].Text != prompt.Text {
+		t.Errorf("requests = %+v", reqs)
+	}
+}
+
+func TestOpenAITranscriber(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if r.URL.Path != "/v1/audio/transcriptions" || err != nil || r.FormValue("model") != "whisper-1" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if audio, _ := io.ReadAll(file); string(audio) != "RIFF" {
+			http.Error(w, "bad audio", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"text":" Hello there. "}`))
+	}))
+	defer srv.Close()
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+
+	tr, err := NewTranscriber(context.Background(), config.Speech{STTProvider: config.STTOpenAI, STTBaseURL: srv.URL + "/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, err := tr.Transcribe(context.Background(), []byte("RIFF")); err != nil || text != "Hello there." {
+		t.Errorf("Transcribe = %q, %v", text, err)
+	}
+}
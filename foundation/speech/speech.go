@@ -0,0 +1,95 @@
+// Package speech reads the terminal agent's answers aloud, with a local
+// engine such as macOS say or espeak, or with Gemini's text-to-speech model.
+package speech
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// ErrNoEngine is returned when no text-to-speech engine is available.
+var ErrNoEngine = errors.New("no text-to-speech engine found; install espeak-ng or set TTS_PROVIDER=gemini")
+
+// Speaker speaks text aloud. Speak returns when the speech has finished or
+// ctx is done, which stops it.
+type Speaker interface {
+	Speak(ctx context.Context, text string) error
+}
+
+// New returns the Speaker cfg selects. With config.TTSAuto it picks say when
+// it is installed, then espeak-ng or espeak.
+func New(ctx context.Context, cfg config.Speech) (Speaker, error) {
+	switch cfg.TTSProvider {
+	case config.TTSSay:
+		return newCommandSpeaker(cfg.Voice, "say")
+	case config.TTSEspeak:
+		return newCommandSpeaker(cfg.Voice, "espeak-ng", "espeak")
+	case config.TTSGemini:
+		return newGeminiSpeaker(ctx, cfg.Voice)
+	case config.TTSAuto, "":
+		return newCommandSpeaker(cfg.Voice, "say", "espeak-ng", "espeak")
+	default:
+		return nil, fmt.Errorf("unknown text-to-speech provider %q", cfg.TTSProvider)
+	}
+}
+
+// commandSpeaker speaks with a local program that reads text on stdin.
+type commandSpeaker struct {
+	path string
+	args []string
+}
+
+// newCommandSpeaker returns a speaker for the first of programs on PATH.
+func newCommandSpeaker(voice string, programs ...string) (*commandSpeaker, error) {
+	for _, name := range programs {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		s := &commandSpeaker{path: path}
+		if voice != "" {
+			s.args = append(s.args, "-v", voice)
+		}
+		// say reads "-f -" as stdin; espeak needs --stdin.
+		if name == "say" {
+			s.args = append(s.args, "-f", "-")
+		} else {
+			s.args = append(s.args, "--stdin")
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("%w (looked for %s)", ErrNoEngine, strings.Join(programs, ", "))
+}
+
+func (s *commandSpeaker) Speak(ctx context.Context, text string) error {
+	cmd := exec.CommandContext(ctx, s.path, s.args...)
+	cmd.Stdin = strings.NewReader(Speakable(text))
+	if out, err := cmd.CombinedOutput(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%s failed: %w: %s", s.path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+var (
+	codeBlock  = regexp.MustCompile("(?s)```.*?(```|$)")
+	link       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markup     = regexp.MustCompile("[*_`#>|]+")
+	blankLines = regexp.MustCompile(`\n{2,}`)
+)
+
+// Speakable turns a markdown answer into text worth reading aloud: code
+// blocks are replaced by a mention of them, links by their text, and
+// emphasis, headings and table markup are dropped.
+func Speakable(answer string) string {
+	text := codeBlock.ReplaceAllString(answer, "\n(code omitted)\n")
+	text = link.ReplaceAllString(text, "$1")
+	text = markup.ReplaceAllString(text, "")
+	text = blankLines.ReplaceAllString(text, "\n")
+	return strings.TrimSpace(text)
+}
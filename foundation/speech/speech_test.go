@@ -0,0 +1,48 @@
+package speech
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpeakable(t *testing.T) {
+	answer := "## Fix\n\nUse **`errors.Is`** as the [docs](https://pkg.go.dev/errors) say:\n\n```go\nif errors.Is(err, io.EOF) {}\n```\n\n| a | b |\n"
+	want := "Fix\nUse errors.Is as the docs say:\n(code omitted)\n a  b"
+	if got := Speakable(answer); got != want {
+		t.Errorf("Speakable() = %q, want %q", got, want)
+	}
+}
+
+func TestCommandSpeaker(t *testing.T) {
+	// A shell script stands in for the engine and records what it was given.
+	dir := t.TempDir()
+	out := filepath.Join(dir, "spoken")
+	s := &commandSpeaker{path: "/bin/sh", args: []string{"-c", "cat > " + out}}
+	if err := s.Speak(context.Background(), "Done: **3** tests pass."); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(out); string(got) != "Done: 3 tests pass." {
+		t.Errorf("spoke %q", got)
+	}
+
+	if _, err := newCommandSpeaker("", "no-such-tts-engine"); err == nil {
+		t.Error("newCommandSpeaker found a missing engine")
+	}
+}
+
+func TestPCMToWAV(t *testing.T) {
+	pcm := []byte{1, 2, 3, 4}
+	wav := pcmToWAV(pcm, sampleRate("audio/L16;codec=pcm;rate=16000"))
+	if len(wav) != 44+len(pcm) || string(wav[:4]) != "RIFF" || string(wav[36:40]) != "data" {
+		t.Fatalf("wav header = %q", wav[:44])
+	}
+	if rate := binary.LittleEndian.Uint32(wav[24:28]); rate != 16000 {
+		t.Errorf("sample rate = %d", rate)
+	}
+	if sampleRate("audio/pcm") != geminiSampleRate {
+		t.Error("a MIME type without a rate did not use the default")
+	}
+}
@@ -0,0 +1,515 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/models"
+	"github.com/olusolaa/goforai/foundation/tokens"
+)
+
+// Trace is the complete record of one conversation turn, shaped after the
+// trace/observation model used by Langfuse and LangSmith.
+type Trace struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	SessionID    string         `json:"session_id,omitempty"`
+	Input        any            `json:"input,omitempty"`
+	Output       any            `json:"output,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	StartTime    time.Time      `json:"start_time"`
+	EndTime      time.Time      `json:"end_time"`
+	Observations []*Observation `json:"observations"`
+	TotalCostUSD float64        `json:"total_cost_usd"`
+}
+
+// Observation is a single model call, tool call, or graph node within a trace.
+type Observation struct {
+	ID        string    `json:"id"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Type      string    `json:"type"` // "generation" for model calls, "span" otherwise.
+	Name      string    `json:"name"`
+	Component string    `json:"component,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Input     any       `json:"input,omitempty"`
+	Output    any       `json:"output,omitempty"`
+	Usage     *Usage    `json:"usage,omitempty"`
+	CostUSD   float64   `json:"cost_usd,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// Usage is the token accounting of a model call.
+type Usage struct {
+	Input     int  `json:"input"`
+	Output    int  `json:"output"`
+	Total     int  `json:"total"`
+	Estimated bool `json:"estimated,omitempty"` // The model reported no usage; the tokens were estimated locally.
+}
+
+// Exporter ships finished traces to an external system.
+type Exporter interface {
+	Export(ctx context.Context, trace *Trace) error
+}
+
+// estimateCost prices usage with the model's entry in the models registry;
+// unknown models cost 0.
+func estimateCost(modelName string, usage *Usage) float64 {
+	caps, ok := models.Lookup(modelName)
+	if !ok || usage == nil {
+		return 0
+	}
+	return caps.Cost(usage.Input, usage.Output)
+}
+
+// estimateUsage counts the tokens of a call whose model reported no usage
+// with the local estimator, so that its cost is not left out of the trace.
+func estimateUsage(input any, answer *schema.Message) *Usage {
+	u := &Usage{Estimated: true}
+	if in := model.ConvCallbackInput(input); in != nil {
+		u.Input = tokens.EstimateMessages(in.Messages)
+	}
+	if answer != nil {
+		u.Output = tokens.EstimateMessages([]*schema.Message{answer})
+	}
+	u.Total = u.Input + u.Output
+	return u
+}
+
+// exportTimeout bounds a single background export.
+const exportTimeout = 10 * time.Second
+
+// Recorder collects observations from Eino callbacks into per-turn traces and
+// hands them to an Exporter when the turn ends. Exports run in the background;
+// call Flush before the process exits so the last traces are not lost.
+type Recorder struct {
+	exporter     Exporter
+	defaultModel string
+	exports      sync.WaitGroup
+}
+
+// NewRecorder creates a Recorder. defaultModel is used for cost estimation
+// when the model component does not report its name.
+func NewRecorder(exporter Exporter, defaultModel string) *Recorder {
+	return &Recorder{exporter: exporter, defaultModel: defaultModel}
+}
+
+type turnKey struct{}
+type observationKey struct{}
+
+type turn struct {
+	mu      sync.Mutex
+	trace   *Trace
+	pending sync.WaitGroup // Streams still being drained.
+}
+
+// StartTurn begins a new trace for a turn and returns a context that must be
+// passed to the graph so callbacks can attach observations to it.
+func (r *Recorder) StartTurn(ctx context.Context, name, sessionID string, input any) context.Context {
+	t := &turn{trace: &Trace{
+		ID:        uuid.NewString(),
+		Name:      name,
+		SessionID: sessionID,
+		Input:     input,
+		StartTime: time.Now(),
+	}}
+	return context.WithValue(ctx, turnKey{}, t)
+}
+
+// EndTurn finalizes the trace for the turn in ctx and exports it in the
+// background, once every model stream of the turn has been drained. Export
+// failures are logged through the logger in ctx.
+func (r *Recorder) EndTurn(ctx context.Context, output any, turnErr error) {
+	t, ok := ctx.Value(turnKey{}).(*turn)
+	if !ok {
+		return
+	}
+	endTime := time.Now()
+
+	r.exports.Add(1)
+	go func() {
+		defer r.exports.Done()
+		t.pending.Wait()
+
+		t.mu.Lock()
+		t.trace.Output = output
+		t.trace.EndTime = endTime
+		if turnErr != nil {
+			t.trace.Error = turnErr.Error()
+		}
+		for _, obs := range t.trace.Observations {
+			t.trace.TotalCostUSD += obs.CostUSD
+		}
+		t.mu.Unlock()
+
+		exportCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), exportTimeout)
+		defer cancel()
+		if err := r.exporter.Export(exportCtx, t.trace); err != nil {
+			logger.FromContext(ctx).Warn("failed to export turn trace", "trace_id", t.trace.ID, "error", err)
+		}
+	}()
+}
+
+// Flush waits for the exports started by EndTurn to finish, or for ctx to be done.
+func (r *Recorder) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.exports.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("trace exports still pending: %w", ctx.Err())
+	}
+}
+
+// Handler returns the callbacks.Handler that records observations.
+func (r *Recorder) Handler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			return r.start(ctx, info, r.convertInput(info, input))
+		}).
+		OnStartWithStreamInputFn(func(ctx context.Context, info *callbacks.RunInfo, input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
+			input.Close()
+			return r.start(ctx, info, nil)
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			r.finish(ctx, info, []callbacks.CallbackOutput{output}, nil)
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			t, ok := ctx.Value(turnKey{}).(*turn)
+			if !ok {
+				output.Close()
+				return ctx
+			}
+			t.pending.Add(1)
+			go func() {
+				defer t.pending.Done()
+				defer output.Close()
+				var chunks []callbacks.CallbackOutput
+				var streamErr error
+				for {
+					chunk, err := output.Recv()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					if err != nil {
+						streamErr = err
+						break
+					}
+					chunks = append(chunks, chunk)
+				}
+				r.finish(ctx, info, chunks, streamErr)
+			}()
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			r.finish(ctx, info, nil, err)
+			return ctx
+		}).
+		Build()
+}
+
+func (r *Recorder) start(ctx context.Context, info *callbacks.RunInfo, input any) context.Context {
+	t, ok := ctx.Value(turnKey{}).(*turn)
+	if !ok {
+		return ctx
+	}
+
+	obs := &Observation{
+		ID:        uuid.NewString(),
+		Type:      "span",
+		Name:      info.Name,
+		Component: string(info.Component),
+		Input:     input,
+		StartTime: time.Now(),
+	}
+	if obs.Name == "" {
+		obs.Name = string(info.Component)
+	}
+	if parent, ok := ctx.Value(observationKey{}).(*Observation); ok {
+		obs.ParentID = parent.ID
+	}
+	if info.Component == components.ComponentOfChatModel {
+		obs.Type = "generation"
+	}
+
+	t.mu.Lock()
+	t.trace.Observations = append(t.trace.Observations, obs)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, observationKey{}, obs)
+}
+
+func (r *Recorder) finish(ctx context.Context, info *callbacks.RunInfo, outputs []callbacks.CallbackOutput, err error) {
+	t, ok := ctx.Value(turnKey{}).(*turn)
+	if !ok {
+		return
+	}
+	obs, ok := ctx.Value(observationKey{}).(*Observation)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	obs.EndTime = time.Now()
+	if err != nil {
+		obs.Error = err.Error()
+	}
+
+	switch info.Component {
+	case components.ComponentOfChatModel:
+		var msgs []*schema.Message
+		for _, o := range outputs {
+			out := model.ConvCallbackOutput(o)
+			if out == nil {
+				continue
+			}
+			if out.Message != nil {
+				msgs = append(msgs, out.Message)
+			}
+			if out.Config != nil && out.Config.Model != "" {
+				obs.Model = out.Config.Model
+			}
+			if out.TokenUsage != nil {
+				obs.Usage = &Usage{
+					Input:  out.TokenUsage.PromptTokens,
+					Output: out.TokenUsage.CompletionTokens,
+					Total:  out.TokenUsage.TotalTokens,
+				}
+			}
+		}
+		if len(msgs) > 0 {
+			if msg, err := schema.ConcatMessages(msgs); err == nil {
+				obs.Output = msg
+			}
+		}
+		if obs.Model == "" {
+			obs.Model = r.defaultModel
+		}
+		if obs.Usage == nil && err == nil {
+			answer, _ := obs.Output.(*schema.Message)
+			obs.Usage = estimateUsage(obs.Input, answer)
+		}
+		obs.CostUSD = estimateCost(obs.Model, obs.Usage)
+	case components.ComponentOfTool:
+		for _, o := range outputs {
+			if out := tool.ConvCallbackOutput(o); out != nil {
+				obs.Output = out.Response
+			}
+		}
+	}
+}
+
+func (r *Recorder) convertInput(info *callbacks.RunInfo, input callbacks.CallbackInput) any {
+	switch info.Component {
+	case components.ComponentOfChatModel:
+		if in := model.ConvCallbackInput(input); in != nil {
+			return in.Messages
+		}
+	case components.ComponentOfTool:
+		if in := tool.ConvCallbackInput(input); in != nil {
+			return json.RawMessage(in.ArgumentsInJSON)
+		}
+	}
+	return nil
+}
+
+// --- Exporters ---
+
+// NewExporterFromEnv picks an exporter from the environment: Langfuse when
+// LANGFUSE_PUBLIC_KEY and LANGFUSE_SECRET_KEY are set, a generic webhook when
+// TRACE_WEBHOOK_URL is set. It returns nil when neither is configured.
+func NewExporterFromEnv() Exporter {
+	if pk, sk := os.Getenv("LANGFUSE_PUBLIC_KEY"), os.Getenv("LANGFUSE_SECRET_KEY"); pk != "" && sk != "" {
+		host := os.Getenv("LANGFUSE_HOST")
+		if host == "" {
+			host = "https://cloud.langfuse.com"
+		}
+		return NewLangfuseExporter(host, pk, sk)
+	}
+	if url := os.Getenv("TRACE_WEBHOOK_URL"); url != "" {
+		return NewWebhookExporter(url)
+	}
+	return nil
+}
+
+// WebhookExporter POSTs each trace as JSON to a URL.
+type WebhookExporter struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookExporter creates an exporter that posts traces to url.
+func NewWebhookExporter(url string) *WebhookExporter {
+	return &WebhookExporter{url: url, httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (w *WebhookExporter) Export(ctx context.Context, trace *Trace) error {
+	return postJSON(ctx, w.httpClient, w.url, trace, nil, nil)
+}
+
+// LangfuseExporter sends traces to the Langfuse ingestion API.
+type LangfuseExporter struct {
+	host       string
+	publicKey  string
+	secretKey  string
+	httpClient *http.Client
+}
+
+// NewLangfuseExporter creates an exporter for the Langfuse instance at host.
+func NewLangfuseExporter(host, publicKey, secretKey string) *LangfuseExporter {
+	return &LangfuseExporter{
+		host:       host,
+		publicKey:  publicKey,
+		secretKey:  secretKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type langfuseEvent struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Timestamp time.Time      `json:"timestamp"`
+	Body      map[string]any `json:"body"`
+}
+
+func (l *LangfuseExporter) Export(ctx context.Context, trace *Trace) error {
+	events := []langfuseEvent{{
+		ID:        uuid.NewString(),
+		Type:      "trace-create",
+		Timestamp: trace.StartTime,
+		Body: map[string]any{
+			"id":        trace.ID,
+			"name":      trace.Name,
+			"sessionId": trace.SessionID,
+			"input":     trace.Input,
+			"output":    trace.Output,
+			"timestamp": trace.StartTime,
+			"metadata":  map[string]any{"total_cost_usd": trace.TotalCostUSD, "error": trace.Error},
+		},
+	}}
+
+	for _, obs := range trace.Observations {
+		body := map[string]any{
+			"id":        obs.ID,
+			"traceId":   trace.ID,
+			"name":      obs.Name,
+			"startTime": obs.StartTime,
+			"endTime":   obs.EndTime,
+			"input":     obs.Input,
+			"output":    obs.Output,
+			"metadata":  map[string]any{"component": obs.Component},
+		}
+		if obs.ParentID != "" {
+			body["parentObservationId"] = obs.ParentID
+		}
+		if obs.Error != "" {
+			body["level"] = "ERROR"
+			body["statusMessage"] = obs.Error
+		}
+		eventType := "span-create"
+		if obs.Type == "generation" {
+			eventType = "generation-create"
+			body["model"] = obs.Model
+			if obs.Usage != nil {
+				body["usage"] = map[string]any{
+					"input":     obs.Usage.Input,
+					"output":    obs.Usage.Output,
+					"total":     obs.Usage.Total,
+					"unit":      "TOKENS",
+					"totalCost": obs.CostUSD,
+				}
+			}
+		}
+		events = append(events, langfuseEvent{ID: uuid.NewString(), Type: eventType, Timestamp: obs.StartTime, Body: body})
+	}
+
+	auth := func(req *http.Request) { req.SetBasicAuth(l.publicKey, l.secretKey) }
+	var resp langfuseIngestionResponse
+	if err := postJSON(ctx, l.httpClient, l.host+"/api/public/ingestion", map[string]any{"batch": events}, auth, &resp); err != nil {
+		return err
+	}
+	return resp.err(len(events))
+}
+
+// langfuseIngestionResponse is the body of a 207 from the ingestion API, which
+// accepts the batch as a whole but reports the events it rejected.
+type langfuseIngestionResponse struct {
+	Errors []struct {
+		ID      string `json:"id"`
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+		Error   any    `json:"error"`
+	} `json:"errors"`
+}
+
+func (r *langfuseIngestionResponse) err(total int) error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	failed := make([]string, 0, len(r.Errors))
+	for _, e := range r.Errors {
+		reason := e.Message
+		if reason == "" && e.Error != nil {
+			reason = fmt.Sprint(e.Error)
+		}
+		failed = append(failed, fmt.Sprintf("%s (status %d: %s)", e.ID, e.Status, reason))
+	}
+	return fmt.Errorf("langfuse rejected %d of %d events: %s", len(r.Errors), total, strings.Join(failed, "; "))
+}
+
+// postJSON posts payload to url. When result is non-nil, a JSON response body
+// is decoded into it.
+func postJSON(ctx context.Context, client *http.Client, url string, payload any, decorate func(*http.Request), result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trace: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create trace export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if decorate != nil {
+		decorate(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("trace export failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("trace export returned status %d: %s", resp.StatusCode, msg)
+	}
+	if result != nil {
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(result); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to decode trace export response: %w", err)
+		}
+	}
+	return nil
+}
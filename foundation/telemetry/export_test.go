@@ -0,0 +1,225 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeExporter collects traces and, when release is set, blocks each export
+// until it is closed.
+type fakeExporter struct {
+	mu      sync.Mutex
+	traces  []*Trace
+	release chan struct{}
+}
+
+func (f *fakeExporter) Export(ctx context.Context, trace *Trace) error {
+	if f.release != nil {
+		<-f.release
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.traces = append(f.traces, trace)
+	return nil
+}
+
+func (f *fakeExporter) exported() []*Trace {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.traces
+}
+
+// runTurn simulates a graph that makes one model call, reporting both through
+// the recorder's handler the way Eino does.
+func runTurn(ctx context.Context, r *Recorder) {
+	graphCtx := callbacks.InitCallbacks(ctx, &callbacks.RunInfo{Name: "agent", Component: compose.ComponentOfGraph}, r.Handler())
+	graphCtx = callbacks.OnStart(graphCtx, "question")
+
+	modelCtx := callbacks.ReuseHandlers(graphCtx, &callbacks.RunInfo{Name: "gemini", Component: components.ComponentOfChatModel})
+	modelCtx = callbacks.OnStart(modelCtx, &model.CallbackInput{Messages: []*schema.Message{schema.UserMessage("question")}})
+	callbacks.OnEnd(modelCtx, &model.CallbackOutput{
+		Message:    schema.AssistantMessage("answer", nil),
+		TokenUsage: &model.TokenUsage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000, TotalTokens: 2_000_000},
+	})
+
+	callbacks.OnEnd(graphCtx, "answer")
+}
+
+func TestRecorderBuildsTrace(t *testing.T) {
+	exporter := &fakeExporter{}
+	r := NewRecorder(exporter, "gemini-2.5-flash")
+
+	ctx := r.StartTurn(context.Background(), "agent.turn", "session-1", "question")
+	runTurn(ctx, r)
+	r.EndTurn(ctx, "answer", nil)
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	traces := exporter.exported()
+	if len(traces) != 1 {
+		t.Fatalf("exported %d traces, want 1", len(traces))
+	}
+	trace := traces[0]
+	if trace.Name != "agent.turn" || trace.SessionID != "session-1" || trace.Output != "answer" || trace.Error != "" {
+		t.Errorf("unexpected trace %+v", trace)
+	}
+	if len(trace.Observations) != 2 {
+		t.Fatalf("got %d observations, want 2", len(trace.Observations))
+	}
+	graph, gen := trace.Observations[0], trace.Observations[1]
+	if graph.ParentID != "" || gen.ParentID != graph.ID {
+		t.Errorf("model call is not nested under the graph: graph parent %q, model parent %q", graph.ParentID, gen.ParentID)
+	}
+	if gen.Type != "generation" || gen.Model != "gemini-2.5-flash" || gen.Usage == nil || gen.Usage.Total != 2_000_000 {
+		t.Errorf("unexpected generation %+v", gen)
+	}
+	if want := 0.30 + 2.50; trace.TotalCostUSD != want {
+		t.Errorf("TotalCostUSD = %v, want %v", trace.TotalCostUSD, want)
+	}
+}
+
+func TestRecorderEstimatesMissingUsage(t *testing.T) {
+	exporter := &fakeExporter{}
+	r := NewRecorder(exporter, "gemini-2.5-flash")
+
+	ctx := r.StartTurn(context.Background(), "agent.turn", "", "question")
+	modelCtx := callbacks.InitCallbacks(ctx, &callbacks.RunInfo{Name: "gemini", Component: components.ComponentOfChatModel}, r.Handler())
+	modelCtx = callbacks.OnStart(modelCtx, &model.CallbackInput{Messages: []*schema.Message{schema.UserMessage("Who gives the keynote?")}})
+	callbacks.OnEnd(modelCtx, &model.CallbackOutput{Message: schema.AssistantMessage("Ada does.", nil)})
+	r.EndTurn(ctx, "Ada does.", nil)
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := exporter.exported()[0].Observations[0]
+	if gen.Usage == nil || !gen.Usage.Estimated || gen.Usage.Input == 0 || gen.Usage.Output == 0 ||
+		gen.Usage.Total != gen.Usage.Input+gen.Usage.Output {
+		t.Fatalf("usage = %+v", gen.Usage)
+	}
+	if gen.CostUSD == 0 {
+		t.Error("the estimated usage was not priced")
+	}
+}
+
+func TestEndTurnDoesNotBlockOnExport(t *testing.T) {
+	exporter := &fakeExporter{release: make(chan struct{})}
+	r := NewRecorder(exporter, "")
+
+	ctx := r.StartTurn(context.Background(), "agent.turn", "", "question")
+	done := make(chan struct{})
+	go func() {
+		r.EndTurn(ctx, nil, errors.New("model unavailable"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("EndTurn waited for the export")
+	}
+
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.Flush(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Flush with a pending export = %v, want a deadline error", err)
+	}
+
+	close(exporter.release)
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if traces := exporter.exported(); len(traces) != 1 || traces[0].Error != "model unavailable" {
+		t.Fatalf("unexpected exports %+v", traces)
+	}
+}
+
+// fakeLangfuse records ingestion batches and answers with status and body.
+func fakeLangfuse(t *testing.T, status int, body string) (*httptest.Server, *[]langfuseEvent) {
+	t.Helper()
+	var batch []langfuseEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "pk" || pass != "sk" {
+			t.Errorf("missing or wrong basic auth")
+		}
+		if r.URL.Path != "/api/public/ingestion" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req struct {
+			Batch []langfuseEvent `json:"batch"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid ingestion body: %v", err)
+		}
+		batch = req.Batch
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &batch
+}
+
+func testTrace() *Trace {
+	now := time.Now()
+	return &Trace{
+		ID:        "trace-1",
+		Name:      "agent.turn",
+		StartTime: now,
+		EndTime:   now,
+		Observations: []*Observation{
+			{ID: "root", Type: "span", Name: "agent", StartTime: now, EndTime: now},
+			{ID: "child", ParentID: "root", Type: "generation", Name: "gemini", Model: "gemini-2.5-flash",
+				Usage: &Usage{Input: 10, Output: 5, Total: 15}, StartTime: now, EndTime: now},
+		},
+	}
+}
+
+func TestLangfuseExport(t *testing.T) {
+	srv, batch := fakeLangfuse(t, http.StatusMultiStatus, `{"successes":[],"errors":[]}`)
+	if err := NewLangfuseExporter(srv.URL, "pk", "sk").Export(context.Background(), testTrace()); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	events := *batch
+	if len(events) != 3 {
+		t.Fatalf("sent %d events, want 3", len(events))
+	}
+	if events[0].Type != "trace-create" || events[1].Type != "span-create" || events[2].Type != "generation-create" {
+		t.Errorf("unexpected event types %s, %s, %s", events[0].Type, events[1].Type, events[2].Type)
+	}
+	if _, ok := events[1].Body["parentObservationId"]; ok {
+		t.Errorf("root observation was sent with a parentObservationId: %v", events[1].Body)
+	}
+	if got := events[2].Body["parentObservationId"]; got != "root" {
+		t.Errorf("child parentObservationId = %v, want root", got)
+	}
+}
+
+func TestLangfuseExportReportsRejectedEvents(t *testing.T) {
+	srv, _ := fakeLangfuse(t, http.StatusMultiStatus,
+		`{"successes":[{"id":"a","status":201}],"errors":[{"id":"b","status":400,"message":"Invalid request data"}]}`)
+	err := NewLangfuseExporter(srv.URL, "pk", "sk").Export(context.Background(), testTrace())
+	if err == nil || !strings.Contains(err.Error(), "rejected 1 of 3 events") || !strings.Contains(err.Error(), "Invalid request data") {
+		t.Fatalf("Export = %v, want the rejected event reported", err)
+	}
+}
+
+func TestLangfuseExportFailsOnErrorStatus(t *testing.T) {
+	srv, _ := fakeLangfuse(t, http.StatusUnauthorized, `{"message":"invalid credentials"}`)
+	err := NewLangfuseExporter(srv.URL, "pk", "sk").Export(context.Background(), testTrace())
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Export = %v, want a status error", err)
+	}
+}
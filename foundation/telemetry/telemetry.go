@@ -0,0 +1,191 @@
+// Package telemetry wires OpenTelemetry tracing into Eino graphs. Every graph
+// node, model call, and tool execution becomes a span, so a slow turn can be
+// broken down in any OTLP-compatible backend (Jaeger, Tempo, Honeycomb, ...).
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/olusolaa/goforai/foundation/telemetry"
+
+// Setup installs a global tracer provider that exports spans over OTLP/HTTP.
+// Tracing is only enabled when OTEL_EXPORTER_OTLP_ENDPOINT (or the traces
+// specific variant) is set; otherwise the returned shutdown is a no-op.
+// The exporter honours the standard OTEL_EXPORTER_OTLP_* variables.
+func Setup(ctx context.Context, serviceName string) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return noop, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return noop, fmt.Errorf("failed to build telemetry resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer used for spans created outside of Eino callbacks,
+// such as the span covering a whole conversation turn.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// NewHandler returns a callbacks.Handler that opens a span when a component
+// starts and closes it when the component ends, errors, or finishes streaming.
+func NewHandler() callbacks.Handler {
+	return callbacks.NewHandlerBuilder().
+		OnStartFn(onStart).
+		OnStartWithStreamInputFn(onStartWithStreamInput).
+		OnEndFn(onEnd).
+		OnEndWithStreamOutputFn(onEndWithStreamOutput).
+		OnErrorFn(onError).
+		Build()
+}
+
+func onStart(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+	ctx, span := startSpan(ctx, info)
+	span.SetAttributes(inputAttributes(info, input)...)
+	return ctx
+}
+
+func onStartWithStreamInput(ctx context.Context, info *callbacks.RunInfo, input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
+	input.Close()
+	ctx, _ = startSpan(ctx, info)
+	return ctx
+}
+
+func onEnd(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(outputAttributes(info, output)...)
+	span.End()
+	return ctx
+}
+
+// onEndWithStreamOutput drains the copy of the stream handed to callbacks so
+// that the span covers the whole stream and can report the final token usage.
+func onEndWithStreamOutput(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+	span := trace.SpanFromContext(ctx)
+	go func() {
+		defer output.Close()
+		defer span.End()
+
+		chunks := 0
+		for {
+			chunk, err := output.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				break
+			}
+			chunks++
+			span.SetAttributes(outputAttributes(info, chunk)...)
+		}
+		span.SetAttributes(attribute.Int("eino.stream.chunks", chunks))
+	}()
+	return ctx
+}
+
+func onError(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+	return ctx
+}
+
+func startSpan(ctx context.Context, info *callbacks.RunInfo) (context.Context, trace.Span) {
+	name := info.Name
+	if name == "" {
+		name = string(info.Component)
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(
+		attribute.String("eino.component", string(info.Component)),
+		attribute.String("eino.type", info.Type),
+		attribute.String("eino.name", info.Name),
+	))
+}
+
+func inputAttributes(info *callbacks.RunInfo, input callbacks.CallbackInput) []attribute.KeyValue {
+	switch info.Component {
+	case components.ComponentOfChatModel:
+		in := model.ConvCallbackInput(input)
+		if in == nil {
+			return nil
+		}
+		attrs := []attribute.KeyValue{
+			attribute.Int("gen_ai.request.messages", len(in.Messages)),
+			attribute.Int("gen_ai.request.tools", len(in.Tools)),
+		}
+		if in.Config != nil && in.Config.Model != "" {
+			attrs = append(attrs, attribute.String("gen_ai.request.model", in.Config.Model))
+		}
+		return attrs
+	case components.ComponentOfTool:
+		in := tool.ConvCallbackInput(input)
+		if in == nil {
+			return nil
+		}
+		return []attribute.KeyValue{attribute.Int("tool.args_size", len(in.ArgumentsInJSON))}
+	}
+	return nil
+}
+
+func outputAttributes(info *callbacks.RunInfo, output callbacks.CallbackOutput) []attribute.KeyValue {
+	switch info.Component {
+	case components.ComponentOfChatModel:
+		out := model.ConvCallbackOutput(output)
+		if out == nil || out.TokenUsage == nil {
+			return nil
+		}
+		return []attribute.KeyValue{
+			attribute.Int("gen_ai.usage.input_tokens", out.TokenUsage.PromptTokens),
+			attribute.Int("gen_ai.usage.output_tokens", out.TokenUsage.CompletionTokens),
+			attribute.Int("gen_ai.usage.total_tokens", out.TokenUsage.TotalTokens),
+		}
+	case components.ComponentOfTool:
+		out := tool.ConvCallbackOutput(output)
+		if out == nil {
+			return nil
+		}
+		return []attribute.KeyValue{attribute.Int("tool.result_size", len(out.Response))}
+	}
+	return nil
+}
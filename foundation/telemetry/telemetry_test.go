@@ -0,0 +1,159 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that records every span for the
+// rest of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	return rec
+}
+
+// endedSpan waits for the span called name to end and returns it.
+func endedSpan(t *testing.T, rec *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, span := range rec.Ended() {
+			if span.Name() == name {
+				return span
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("span %s did not end", name)
+	return nil
+}
+
+func attributeOf(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestHandlerSpans(t *testing.T) {
+	rec := recordSpans(t)
+	handler := NewHandler()
+	run := func(name string, component components.Component) context.Context {
+		return callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{Name: name, Component: component}, handler)
+	}
+
+	// A model call that returns at once.
+	ctx := callbacks.OnStart(run("gemini", components.ComponentOfChatModel), &model.CallbackInput{
+		Messages: []*schema.Message{schema.UserMessage("question")},
+		Tools:    []*schema.ToolInfo{{Name: "read_file"}},
+	})
+	callbacks.OnEnd(ctx, &model.CallbackOutput{
+		Message:    schema.AssistantMessage("answer", nil),
+		TokenUsage: &model.TokenUsage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15},
+	})
+	span := endedSpan(t, rec, "gemini")
+	if attributeOf(span, "gen_ai.request.messages").AsInt64() != 1 || attributeOf(span, "gen_ai.request.tools").AsInt64() != 1 ||
+		attributeOf(span, "gen_ai.usage.input_tokens").AsInt64() != 12 || attributeOf(span, "gen_ai.usage.total_tokens").AsInt64() != 15 {
+		t.Errorf("model span attributes = %v", span.Attributes())
+	}
+
+	// A tool call.
+	ctx = callbacks.OnStart(run("read_file", components.ComponentOfTool), &tool.CallbackInput{ArgumentsInJSON: `{"path":"go.mod"}`})
+	callbacks.OnEnd(ctx, &tool.CallbackOutput{Response: "module x"})
+	span = endedSpan(t, rec, "read_file")
+	if attributeOf(span, "tool.args_size").AsInt64() != 17 || attributeOf(span, "tool.result_size").AsInt64() != 8 {
+		t.Errorf("tool span attributes = %v", span.Attributes())
+	}
+
+	// A streamed model call: the span lasts until the stream is drained and
+	// reports the usage of the last chunk.
+	ctx = callbacks.OnStart(run("streamed", components.ComponentOfChatModel), &model.CallbackInput{})
+	_, stream := callbacks.OnEndWithStreamOutput(ctx, schema.StreamReaderFromArray([]callbacks.CallbackOutput{
+		&model.CallbackOutput{Message: schema.AssistantMessage("an", nil)},
+		&model.CallbackOutput{
+			Message:    schema.AssistantMessage("swer", nil),
+			TokenUsage: &model.TokenUsage{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7},
+		},
+	}))
+	stream.Close()
+	span = endedSpan(t, rec, "streamed")
+	if attributeOf(span, "eino.stream.chunks").AsInt64() != 2 || attributeOf(span, "gen_ai.usage.output_tokens").AsInt64() != 2 {
+		t.Errorf("stream span attributes = %v", span.Attributes())
+	}
+
+	// A stream that breaks off ends its span as failed.
+	ctx = callbacks.OnStart(run("broken", components.ComponentOfChatModel), &model.CallbackInput{})
+	reader, writer := schema.Pipe[callbacks.CallbackOutput](2)
+	writer.Send(&model.CallbackOutput{Message: schema.AssistantMessage("an", nil)}, nil)
+	writer.Send(nil, errors.New("connection reset"))
+	writer.Close()
+	_, stream = callbacks.OnEndWithStreamOutput(ctx, reader)
+	stream.Close()
+	if span = endedSpan(t, rec, "broken"); span.Status().Code != codes.Error {
+		t.Errorf("broken stream span status = %+v", span.Status())
+	}
+
+	// A call that fails.
+	ctx = callbacks.OnStart(run("failing", components.ComponentOfTool), &tool.CallbackInput{ArgumentsInJSON: "{}"})
+	callbacks.OnError(ctx, errors.New("boom"))
+	span = endedSpan(t, rec, "failing")
+	if span.Status().Code != codes.Error || span.Status().Description != "boom" || len(span.Events()) != 1 {
+		t.Errorf("failed span status = %+v, events = %v", span.Status(), span.Events())
+	}
+
+	if started, ended := len(rec.Started()), len(rec.Ended()); started != ended {
+		t.Errorf("%d spans started but %d ended", started, ended)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	previous := otel.GetTracerProvider()
+	shutdown, err := Setup(context.Background(), "goforai-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown = %v", err)
+	}
+	if otel.GetTracerProvider() != previous {
+		t.Error("Setup installed a tracer provider without an endpoint")
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://127.0.0.1:1")
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	shutdown, err = Setup(context.Background(), "goforai-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); !ok {
+		t.Errorf("tracer provider = %T, want the SDK's", otel.GetTracerProvider())
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown = %v", err)
+	}
+}
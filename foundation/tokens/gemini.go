@@ -0,0 +1,101 @@
+package tokens
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"google.golang.org/genai"
+)
+
+// countTimeout bounds one countTokens call: a count that arrives late is
+// worth less than an estimate that arrives now.
+const countTimeout = 5 * time.Second
+
+// maxCached is how many counts a Gemini counter remembers.
+const maxCached = 256
+
+// Gemini counts with the countTokens API of the Gemini model it was created
+// for. Counts are cached by content, so counting the same conversation again
+// costs no call.
+type Gemini struct {
+	client *genai.Client
+	model  string
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]int
+}
+
+// NewGemini returns a Counter for model that calls the API through client.
+func NewGemini(client *genai.Client, model string) *Gemini {
+	return &Gemini{client: client, model: model, cache: make(map[[sha256.Size]byte]int)}
+}
+
+func (g *Gemini) Count(ctx context.Context, messages []*schema.Message) (int, error) {
+	contents := toContents(messages)
+	if len(contents) == 0 {
+		return 0, nil
+	}
+	key, err := cacheKey(contents)
+	if err != nil {
+		return 0, err
+	}
+	g.mu.Lock()
+	n, ok := g.cache[key]
+	g.mu.Unlock()
+	if ok {
+		return n, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, countTimeout)
+	defer cancel()
+	resp, err := g.client.Models.CountTokens(ctx, g.model, contents, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	n = int(resp.TotalTokens)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.cache) >= maxCached {
+		clear(g.cache)
+	}
+	g.cache[key] = n
+	return n, nil
+}
+
+// toContents converts messages as the chat model sends them, with system
+// messages counted as user text since countTokens takes no system
+// instruction from the Gemini API, and tool calls and results as text.
+func toContents(messages []*schema.Message) []*genai.Content {
+	var contents []*genai.Content
+	for _, m := range messages {
+		role := genai.RoleUser
+		if m.Role == schema.Assistant {
+			role = genai.RoleModel
+		}
+		var parts []*genai.Part
+		if m.Content != "" {
+			parts = append(parts, genai.NewPartFromText(m.Content))
+		}
+		for _, tc := range m.ToolCalls {
+			parts = append(parts, genai.NewPartFromText(tc.Function.Name+tc.Function.Arguments))
+		}
+		if len(parts) > 0 {
+			contents = append(contents, genai.NewContentFromParts(parts, genai.Role(role)))
+		}
+	}
+	return contents
+}
+
+func cacheKey(contents []*genai.Content) ([sha256.Size]byte, error) {
+	data, err := json.Marshal(contents)
+	if err != nil {
+		return [sha256.Size]byte{}, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	return sha256.Sum256(data), nil
+}
@@ -0,0 +1,132 @@
+// Package tokens counts how much of a model's context text and messages take
+// up. Counts that decide whether a conversation fits come from the model's
+// own tokenizer through the Gemini countTokens API; everything else, and any
+// count the API cannot give, uses a local estimator that follows how
+// subword tokenizers split words, numbers, symbols and CJK text, so that code
+// and non-English text are not undercounted the way a flat four characters a
+// token undercounts them.
+package tokens
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// AverageBytesPerToken converts sizes known only in bytes, such as a chunk
+// size setting, into tokens. Prefer Estimate whenever the text is at hand.
+const AverageBytesPerToken = 4
+
+// messageOverhead is what the role and turn markers of a message add.
+const messageOverhead = 4
+
+// Counter counts the tokens messages take up in a model's prompt.
+type Counter interface {
+	Count(ctx context.Context, messages []*schema.Message) (int, error)
+}
+
+// Count returns c's count for messages, or their estimate when c is nil or
+// fails.
+func Count(ctx context.Context, c Counter, messages []*schema.Message) int {
+	if c != nil {
+		if n, err := c.Count(ctx, messages); err == nil {
+			return n
+		}
+	}
+	return EstimateMessages(messages)
+}
+
+// Estimator is the Counter that estimates locally. It never fails.
+type Estimator struct{}
+
+func (Estimator) Count(ctx context.Context, messages []*schema.Message) (int, error) {
+	return EstimateMessages(messages), nil
+}
+
+// EstimateMessages estimates the prompt tokens of messages: their text, the
+// arguments of their tool calls, and the markers around each message.
+func EstimateMessages(messages []*schema.Message) int {
+	n := 0
+	for _, m := range messages {
+		n += messageOverhead + Estimate(m.Content)
+		for _, tc := range m.ToolCalls {
+			n += Estimate(tc.Function.Name) + Estimate(tc.Function.Arguments)
+		}
+	}
+	return n
+}
+
+// Estimate estimates the tokens of text. Words cost a token per six ASCII
+// letters, and per three letters of other scripts; a lower-case letter
+// followed by an upper-case one starts a new word, as in "InvokableRun".
+// Digits, punctuation and symbols cost a token each, as do CJK characters
+// and line breaks; a single space before a word is free and longer runs of
+// blanks cost a token per four. The estimate errs on the high side.
+func Estimate(text string) int {
+	n := 0
+	word, ascii := 0, true // Letters of the current word.
+	blanks := 0            // Spaces and tabs since the last other rune.
+	endWord := func() {
+		if word > 0 {
+			if ascii {
+				n += (word + 5) / 6
+			} else {
+				n += (word + 2) / 3
+			}
+		}
+		word, ascii = 0, true
+	}
+	endBlanks := func() {
+		if blanks > 1 {
+			n += (blanks + 2) / 4
+		}
+		blanks = 0
+	}
+
+	prev := rune(0)
+	for _, r := range text {
+		switch {
+		case r == ' ' || r == '\t':
+			endWord()
+			blanks++
+		case r == '\n' || r == '\r':
+			endWord()
+			endBlanks()
+			n++
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			endWord()
+			endBlanks()
+			n++
+		case unicode.IsLetter(r):
+			endBlanks()
+			if word > 0 && unicode.IsUpper(r) && unicode.IsLower(prev) {
+				endWord()
+			}
+			word++
+			ascii = ascii && r < utf8.RuneSelf
+		case unicode.IsSpace(r):
+			endWord()
+			blanks++
+		default: // Digits, punctuation and symbols.
+			endWord()
+			endBlanks()
+			n++
+		}
+		prev = r
+	}
+	endWord()
+	endBlanks()
+	return n
+}
+
+// BytesFor returns about how many bytes of text hold n tokens, judging by the
+// density of text as a whole, for cutting text to a token budget.
+func BytesFor(text string, n int) int {
+	total := Estimate(text)
+	if total <= n {
+		return len(text)
+	}
+	return len(text) * n / total
+}
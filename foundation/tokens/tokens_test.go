@@ -0,0 +1,98 @@
+package tokens
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+	"github.com/olusolaa/goforai/foundation/gemini"
+)
+
+func TestEstimate(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"The keynote starts at nine.", 7},
+		{"InvokableRun", 3},
+		{"2025", 4},
+		{"func main() {}\n", 7},
+		{"\tif err != nil {", 6},
+		{"        return", 3},
+		{"日本語", 3},
+		{"Привет мир", 3},
+	} {
+		if got := Estimate(tc.text); got != tc.want {
+			t.Errorf("Estimate(%q) = %d, want %d", tc.text, got, tc.want)
+		}
+	}
+
+	// Code is denser than four bytes a token.
+	code := strings.Repeat("if err := f(x); err != nil {\n\treturn fmt.Errorf(\"f: %w\", err)\n}\n", 50)
+	if got := Estimate(code); got <= len(code)/4 {
+		t.Errorf("Estimate(code) = %d, no more than %d bytes / 4", got, len(code))
+	}
+}
+
+func TestBytesFor(t *testing.T) {
+	text := strings.Repeat("one two three ", 100) // 300 tokens, 1400 bytes.
+	if got := BytesFor(text, 30); got != 140 {
+		t.Errorf("BytesFor(30) = %d, want 140", got)
+	}
+	if got := BytesFor(text, 1000); got != len(text) {
+		t.Errorf("BytesFor(1000) = %d, want the whole text", got)
+	}
+}
+
+func TestEstimateMessages(t *testing.T) {
+	messages := []*schema.Message{
+		schema.UserMessage("Who speaks first?"),
+		schema.AssistantMessage("", []schema.ToolCall{{Function: schema.FunctionCall{Name: "search", Arguments: `{"q":"first"}`}}}),
+	}
+	// 4+4 for the text, 4+1+9 for the tool call.
+	if got := EstimateMessages(messages); got != 22 {
+		t.Errorf("EstimateMessages = %d, want 22", got)
+	}
+}
+
+func TestGemini(t *testing.T) {
+	fakeGemini := fakeapi.NewGemini(t)
+	fakeGemini.Use(t)
+	client, err := gemini.NewClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := NewGemini(client, gemini.ChatModelName)
+
+	messages := []*schema.Message{
+		schema.SystemMessage("You are helpful."),
+		schema.UserMessage("Who gives the keynote?"),
+	}
+	// The fake counts a token per word.
+	for range 2 {
+		if n, err := counter.Count(context.Background(), messages); err != nil || n != 7 {
+			t.Errorf("Count = %d, %v; want 7", n, err)
+		}
+	}
+	if calls := fakeGemini.TokenCounts(); calls != 1 {
+		t.Errorf("counting the same messages twice made %d calls", calls)
+	}
+
+	// Without the API, Count falls back to the estimate.
+	offline := NewGemini(client, gemini.ChatModelName)
+	t.Setenv("GEMINI_BASE_URL", "http://127.0.0.1:1")
+	unreachable, err := gemini.NewClient(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline.client = unreachable
+	if got, want := Count(context.Background(), offline, messages), EstimateMessages(messages); got != want {
+		t.Errorf("Count without the API = %d, want the estimate %d", got, want)
+	}
+	if got, want := Count(context.Background(), nil, messages), EstimateMessages(messages); got != want {
+		t.Errorf("Count(nil) = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/prompts"
+)
+
+// Limits of an analysis: how many repositories one call compares, how many
+// are analyzed at once by default and how much of a repository's overview
+// its sub-agent reads.
+const (
+	maxAnalyzeRepos     = 5
+	defaultAnalyzeRepos = 3
+	maxAnalyzeOverview  = 24000
+)
+
+type AnalyzeReposConfig struct {
+	// Model answers the question for each repository and merges the answers.
+	Model model.BaseChatModel
+	// Clone sets where repositories given by URL are cloned.
+	Clone *GitCloneConfig
+	// Concurrency caps how many repositories are analyzed at once; 0 means
+	// 3.
+	Concurrency int
+}
+
+type AnalyzeReposRequest struct {
+	Repos    []string `json:"repos" jsonschema:"description=The repositories to compare (2 to 5): Git URLs to clone or paths of repositories already cloned."`
+	Question string   `json:"question" jsonschema:"description=What to find out about each repository\\, such as 'Which of these HTTP routers fits a service needing middleware and path parameters?'"`
+}
+
+// RepoAnalysis is what a sub-agent found in one repository.
+type RepoAnalysis struct {
+	Repo     string `json:"repo" jsonschema:"description=The repository as given."`
+	Path     string `json:"path,omitempty" jsonschema:"description=Local path of the repository; use it with the file tools to dig deeper."`
+	Module   string `json:"module,omitempty" jsonschema:"description=Go module path from go.mod."`
+	Findings string `json:"findings,omitempty" jsonschema:"description=The answer to the question for this repository."`
+	Error    string `json:"error,omitempty" jsonschema:"description=Error message if the repository could not be analyzed."`
+}
+
+type AnalyzeReposResponse struct {
+	Question   string         `json:"question" jsonschema:"description=The question analyzed."`
+	Repos      []RepoAnalysis `json:"repos" jsonschema:"description=The findings per repository\\, in the order given."`
+	Comparison string         `json:"comparison,omitempty" jsonschema:"description=The findings merged into one answer comparing the repositories."`
+	Error      string         `json:"error,omitempty" jsonschema:"description=Error message if the repositories could not be compared."`
+}
+
+// NewAnalyzeReposTool returns the analyze_repos tool. It fans a question out
+// over several repositories: a sub-agent per repository clones it, reads its
+// overview and exported API and answers the question for it alone, with a
+// context of its own, up to config.Concurrency at a time. A last call of the
+// model merges the answers into a comparison.
+func NewAnalyzeReposTool(ctx context.Context, config *AnalyzeReposConfig) (tool.BaseTool, error) {
+	if config == nil || config.Model == nil {
+		return nil, errors.New("analyze_repos needs a chat model")
+	}
+	clone, err := cloneConfig(ctx, config.Clone)
+	if err != nil {
+		return nil, err
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultAnalyzeRepos
+	}
+	repoPrompt, err := prompts.Get("analyze.repo")
+	if err != nil {
+		return nil, err
+	}
+	mergePrompt, err := prompts.Get("analyze.merge")
+	if err != nil {
+		return nil, err
+	}
+	a := &repoAnalyst{model: config.Model, clone: clone, repoPrompt: repoPrompt, mergePrompt: mergePrompt}
+
+	return inferTool(
+		"analyze_repos",
+		"Compare several repositories on one question, such as which of three libraries fits the user's needs. "+
+			"Each repository is cloned (or read from a path given by gitclone) and analyzed in parallel from its "+
+			"layout, README and exported API, then the findings are merged into one comparison. Use it instead "+
+			"of cloning and exploring the repositories one by one; follow up with the file tools on the returned paths.",
+		func(ctx context.Context, req *AnalyzeReposRequest) (*AnalyzeReposResponse, error) {
+			question := strings.TrimSpace(req.Question)
+			switch {
+			case question == "":
+				return &AnalyzeReposResponse{Error: "question cannot be empty"}, nil
+			case len(req.Repos) == 0:
+				return &AnalyzeReposResponse{Error: "repos cannot be empty"}, nil
+			case len(req.Repos) > maxAnalyzeRepos:
+				return &AnalyzeReposResponse{Error: fmt.Sprintf("at most %d repositories can be compared at once, got %d", maxAnalyzeRepos, len(req.Repos))}, nil
+			}
+
+			resp := &AnalyzeReposResponse{Question: question, Repos: make([]RepoAnalysis, len(req.Repos))}
+			var (
+				wg     sync.WaitGroup
+				sem    = make(chan struct{}, concurrency)
+				mu     sync.Mutex
+				denied error
+			)
+			for i, repo := range req.Repos {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					select {
+					case sem <- struct{}{}:
+						defer func() { <-sem }()
+					case <-ctx.Done():
+						return
+					}
+					analysis, err := a.analyze(ctx, strings.TrimSpace(repo), question)
+					if err != nil {
+						mu.Lock()
+						denied = err
+						mu.Unlock()
+					}
+					resp.Repos[i] = analysis
+				}()
+			}
+			wg.Wait()
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if denied != nil {
+				return nil, denied
+			}
+
+			comparison, err := a.merge(ctx, question, resp.Repos)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				resp.Error = err.Error()
+				return resp, nil
+			}
+			resp.Comparison = comparison
+			return resp, nil
+		},
+	)
+}
+
+// repoAnalyst runs the sub-agents of analyze_repos and merges their findings.
+type repoAnalyst struct {
+	model       model.BaseChatModel
+	clone       *GitCloneConfig
+	repoPrompt  *prompts.Template
+	mergePrompt *prompts.Template
+}
+
+// analyze answers question for repo. Failures are reported in the analysis;
+// the error is for denied calls, which end the turn.
+func (a *repoAnalyst) analyze(ctx context.Context, repo, question string) (RepoAnalysis, error) {
+	analysis := RepoAnalysis{Repo: repo}
+	path, err := a.locate(ctx, repo)
+	if errors.Is(err, ErrToolDenied) {
+		return analysis, err
+	}
+	if err != nil {
+		analysis.Error = err.Error()
+		return analysis, nil
+	}
+	analysis.Path = path
+
+	overview, err := loadOverview(ctx, path)
+	if err != nil {
+		analysis.Error = fmt.Sprintf("failed to build overview: %v", err)
+		return analysis, nil
+	}
+	overview.Files = nil
+	analysis.Module = overview.Module
+	report := struct {
+		Overview *RepoOverview  `json:"overview"`
+		API      *ModuleSummary `json:"api,omitempty"`
+	}{Overview: overview}
+	if overview.Module != "" {
+		report.API, _ = summarizeModule(ctx, path)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		analysis.Error = err.Error()
+		return analysis, nil
+	}
+	prompt, err := a.repoPrompt.Render(map[string]any{"repo": repo, "question": question, "overview": truncateText(string(data), maxAnalyzeOverview)})
+	if err != nil {
+		analysis.Error = err.Error()
+		return analysis, nil
+	}
+	msg, err := a.model.Generate(ctx, []*schema.Message{schema.UserMessage(prompt)})
+	if err != nil {
+		analysis.Error = fmt.Sprintf("analysis failed: %v", err)
+		return analysis, nil
+	}
+	analysis.Findings = strings.TrimSpace(msg.Content)
+	return analysis, nil
+}
+
+// locate returns the local path of repo, cloning it when it is a URL. A
+// repository cloned before is analyzed as it is, without pulling.
+func (a *repoAnalyst) locate(ctx context.Context, repo string) (string, error) {
+	if repo == "" {
+		return "", errors.New("repository cannot be empty")
+	}
+	if _, err := parseAndSanitizeURL(repo); err == nil {
+		resp, err := invokeGitClone(ctx, &GitCloneRequest{Url: repo, Action: GitCloneActionClone}, a.clone)
+		if err != nil {
+			return "", err
+		}
+		if resp.Error != "" && resp.Path == "" {
+			return "", errors.New(resp.Error)
+		}
+		return resp.Path, nil
+	}
+	path, err := resolvePath(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("'%s' is neither a Git URL nor a directory", repo)
+	}
+	return path, nil
+}
+
+// merge compares the analyses in one answer to question.
+func (a *repoAnalyst) merge(ctx context.Context, question string, analyses []RepoAnalysis) (string, error) {
+	var findings strings.Builder
+	analyzed := 0
+	for _, r := range analyses {
+		fmt.Fprintf(&findings, "## %s\n", r.Repo)
+		if r.Error != "" {
+			fmt.Fprintf(&findings, "Not analyzed: %s\n\n", r.Error)
+			continue
+		}
+		analyzed++
+		findings.WriteString(r.Findings + "\n\n")
+	}
+	if analyzed == 0 {
+		return "", errors.New("no repository could be analyzed")
+	}
+	if analyzed == 1 && len(analyses) == 1 {
+		return analyses[0].Findings, nil
+	}
+
+	prompt, err := a.mergePrompt.Render(map[string]any{"question": question, "findings": strings.TrimSpace(findings.String())})
+	if err != nil {
+		return "", err
+	}
+	msg, err := a.model.Generate(ctx, []*schema.Message{schema.UserMessage(prompt)})
+	if err != nil {
+		return "", fmt.Errorf("merging the findings failed: %w", err)
+	}
+	return strings.TrimSpace(msg.Content), nil
+}
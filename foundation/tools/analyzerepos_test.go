@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// analystModel answers the analyze.repo prompt with the module it was shown
+// and the analyze.merge prompt with the findings it was given, and records
+// the prompts.
+type analystModel struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+var analyzedModule = regexp.MustCompile(`"module":"([^"]+)"`)
+
+func (m *analystModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	prompt := input[len(input)-1].Content
+	m.mu.Lock()
+	m.prompts = append(m.prompts, prompt)
+	m.mu.Unlock()
+	if _, findings, ok := strings.Cut(prompt, "Findings:\n"); ok {
+		return schema.AssistantMessage("Compared: "+strings.ReplaceAll(findings, "\n", " "), nil), nil
+	}
+	return schema.AssistantMessage(" uses "+analyzedModule.FindStringSubmatch(prompt)[1]+"\n", nil), nil
+}
+
+func (m *analystModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), err
+}
+
+func TestAnalyzeRepos(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"chi/go.mod":       "module example.com/chi\n\ngo 1.25\n",
+		"chi/mux.go":       "// Package chi routes requests.\npackage chi\n\n// Use adds middleware.\nfunc Use() {}\n",
+		"gorilla/go.mod":   "module example.com/gorilla\n\ngo 1.25\n",
+		"gorilla/route.go": "// Package mux matches routes.\npackage mux\n",
+	})
+	m := &analystModel{}
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewAnalyzeReposTool(ctx, &AnalyzeReposConfig{Model: m, Clone: &GitCloneConfig{BaseDir: filepath.Join(dir, "repos")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := callTool[AnalyzeReposRequest, AnalyzeReposResponse](t, ctx, bt, AnalyzeReposRequest{Repos: []string{"chi", "missing", "gorilla"}, Question: "Which router supports middleware?"})
+
+	if resp.Error != "" || len(resp.Repos) != 3 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	chi, missing, gorilla := resp.Repos[0], resp.Repos[1], resp.Repos[2]
+	if chi.Module != "example.com/chi" || chi.Findings != "uses example.com/chi" || chi.Path != filepath.Join(dir, "chi") {
+		t.Errorf("unexpected analysis of chi %+v", chi)
+	}
+	if gorilla.Findings != "uses example.com/gorilla" {
+		t.Errorf("unexpected analysis of gorilla %+v", gorilla)
+	}
+	if !strings.Contains(missing.Error, "neither a Git URL nor a directory") || missing.Findings != "" {
+		t.Errorf("unexpected analysis of a missing repository %+v", missing)
+	}
+	want := "Compared: ## chi uses example.com/chi  ## missing Not analyzed: 'missing' is neither a Git URL nor a directory  ## gorilla uses example.com/gorilla"
+	if resp.Comparison != want {
+		t.Errorf("comparison = %q, want %q", resp.Comparison, want)
+	}
+
+	// Each sub-agent sees its own repository and the question, including
+	// the exported API.
+	if len(m.prompts) != 3 {
+		t.Fatalf("model was called %d times, want 2 analyses and a merge", len(m.prompts))
+	}
+	for _, prompt := range m.prompts[:2] {
+		if !strings.Contains(prompt, "Question: Which router supports middleware?") {
+			t.Errorf("analysis prompt lacks the question:\n%s", prompt)
+		}
+		if strings.Contains(prompt, "example.com/chi") && strings.Contains(prompt, "example.com/gorilla") {
+			t.Errorf("analysis prompt covers both repositories:\n%s", prompt)
+		}
+		if strings.Contains(prompt, "example.com/chi") && !strings.Contains(prompt, "Use adds middleware.") {
+			t.Errorf("analysis prompt lacks the exported API:\n%s", prompt)
+		}
+	}
+
+	resp = callTool[AnalyzeReposRequest, AnalyzeReposResponse](t, ctx, bt, AnalyzeReposRequest{Repos: []string{"a", "b", "c", "d", "e", "f"}, Question: "Which?"})
+	if !strings.Contains(resp.Error, "at most 5") {
+		t.Errorf("error = %q, want at most 5 repositories", resp.Error)
+	}
+}
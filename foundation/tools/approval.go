@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+)
+
+// Approver asks a person to approve an action a tool is about to take that
+// reaches outside the agent, such as sending an email. action is a short
+// phrase ("send an email to ada@example.com") and details what exactly will
+// happen, e.g. the whole message.
+type Approver interface {
+	Approve(ctx context.Context, action, details string) (bool, error)
+}
+
+type approverKey struct{}
+
+// WithApprover lets tools called with the returned context ask a for
+// approval. The terminal UI sets it per tool call; without an approver, tools
+// that need one refuse to act.
+func WithApprover(ctx context.Context, a Approver) context.Context {
+	return context.WithValue(ctx, approverKey{}, a)
+}
+
+// approve returns nil when the approver of ctx approves the action, and an
+// error matching ErrNotApproved otherwise.
+func approve(ctx context.Context, action, details string) error {
+	a, _ := ctx.Value(approverKey{}).(Approver)
+	if a == nil {
+		return fmt.Errorf("%w: no one is available to approve it here", ErrNotApproved)
+	}
+	ok, err := a.Approve(ctx, action, details)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotApproved, err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: the user declined to %s", ErrNotApproved, action)
+	}
+	return nil
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/components/tool/utils"
 )
 
 // CalculateRequest represents calculator tool input.
@@ -24,7 +23,7 @@ type CalculateResponse struct {
 
 // NewCalculatorTool creates a calculator tool for basic arithmetic.
 func NewCalculatorTool(ctx context.Context) (tool.BaseTool, error) {
-	return utils.InferTool(
+	return inferTool(
 		"calculator",
 		"Perform basic arithmetic operations: add, subtract, multiply, or divide two numbers",
 		func(ctx context.Context, req *CalculateRequest) (*CalculateResponse, error) {
@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// callTool calls bt with req as its JSON arguments and decodes the result.
+// Pass a json.RawMessage to send arguments as written. The test fails if the
+// call returns an error or a result that does not decode.
+func callTool[Req, Resp any](t *testing.T, ctx context.Context, bt tool.BaseTool, req Req) Resp {
+	t.Helper()
+	args, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := bt.(tool.InvokableTool).InvokableRun(ctx, string(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp Resp
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	return resp
+}
@@ -0,0 +1,233 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Limits on the go commands the tools run.
+const (
+	goCommandTimeout    = 2 * time.Minute
+	maxBuildOutputBytes = 4000
+)
+
+type ChangesetRequest struct {
+	Edits []EditFileRequest `json:"edits" jsonschema:"description=Edits to apply in order, each taking the same fields as edit_go_file. Several edits may target the same file; later ones see the result of earlier ones."`
+	Build bool              `json:"build,omitempty" jsonschema:"description=After writing the files, run 'go build ./...' in their modules and roll every change back if it fails."`
+}
+
+type ChangesetResponse struct {
+	Message     string   `json:"message,omitempty" jsonschema:"description=Summary of the applied edits."`
+	Files       []string `json:"files,omitempty" jsonschema:"description=Files that were changed or created."`
+	FailedEdit  int      `json:"failed_edit,omitempty" jsonschema:"description=1-based index of the edit that failed, if any."`
+	BuildOutput string   `json:"build_output,omitempty" jsonschema:"description=Output of the failed build."`
+	Error       string   `json:"error,omitempty" jsonschema:"description=Error message if the changeset was not applied. No file is changed when this is set."`
+}
+
+// stagedFile is a file with the edits of a changeset applied in memory.
+type stagedFile struct {
+	display  string // Path as the model gave it.
+	content  []byte
+	perms    os.FileMode
+	original []byte // nil when the changeset creates the file.
+}
+
+// NewChangesetTool returns the apply_changeset tool, which applies edits to
+// several Go files all at once or not at all. With a context marked by
+// WithReadOnly every changeset is refused with ErrReadOnly.
+func NewChangesetTool(ctx context.Context) (tool.BaseTool, error) {
+	readOnly := ReadOnly(ctx)
+	return inferTool(
+		"apply_changeset",
+		"Apply edits to several Go files as one change: every edit is validated first, and if any fails (or, with build=true, the packages no longer build) no file is changed. Each edit takes the same fields as edit_go_file. Use it for refactors that span files, such as changing a function and all its callers.",
+		func(ctx context.Context, req *ChangesetRequest) (*ChangesetResponse, error) {
+			if readOnly {
+				return nil, ErrReadOnly
+			}
+			if len(req.Edits) == 0 {
+				return &ChangesetResponse{Error: "edits cannot be empty"}, nil
+			}
+
+			staged, order, failed, err := stageChangeset(ctx, req.Edits)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &ChangesetResponse{Error: err.Error() + "; no file was changed", FailedEdit: failed}, nil
+			}
+
+			var missing []string
+			for _, path := range order {
+				for _, p := range undeclaredImports(ctx, filepath.Dir(path), staged[path].content) {
+					if !slices.Contains(missing, p) {
+						missing = append(missing, p)
+					}
+				}
+			}
+
+			written, err := writeChangeset(staged, order)
+			if err != nil {
+				return &ChangesetResponse{Error: errors.Join(err, rollbackChangeset(staged, written)).Error()}, nil
+			}
+			if req.Build {
+				if output, err := buildChangeset(ctx, order); err != nil {
+					if rbErr := rollbackChangeset(staged, written); rbErr != nil {
+						err = errors.Join(err, rbErr)
+					} else {
+						err = fmt.Errorf("%w; every change was rolled back", err)
+					}
+					if len(missing) > 0 {
+						err = fmt.Errorf("%w. go.mod does not require the module of %s: apply the changeset without build, call fix_dependencies, then build with run_go", err, quoteAll(missing))
+					}
+					if cErr := canceled(ctx); cErr != nil {
+						return nil, cErr
+					}
+					return &ChangesetResponse{Error: err.Error(), BuildOutput: output}, nil
+				}
+			}
+
+			files := make([]string, len(order))
+			for i, path := range order {
+				files[i] = staged[path].display
+			}
+			return &ChangesetResponse{
+				Message: fmt.Sprintf("✅ Applied %d edits to %d files", len(req.Edits), len(files)) + dependencyHint(missing),
+				Files:   files,
+			}, nil
+		},
+	)
+}
+
+// stageChangeset applies edits in memory. It returns the staged files by
+// resolved path, the paths in the order they were first edited, and on
+// failure the 1-based index of the failing edit.
+func stageChangeset(ctx context.Context, edits []EditFileRequest) (map[string]*stagedFile, []string, int, error) {
+	staged := make(map[string]*stagedFile)
+	var order []string
+	for i := range edits {
+		req := &edits[i]
+		fail := func(err error) (map[string]*stagedFile, []string, int, error) {
+			return nil, nil, i + 1, fmt.Errorf("edit %d (%s on %s): %w", i+1, req.Operation, req.Path, err)
+		}
+		if req.Path == "" {
+			return fail(errors.New("path cannot be empty"))
+		}
+		path, err := resolvePath(ctx, req.Path)
+		if errors.Is(err, ErrToolDenied) {
+			return nil, nil, i + 1, err
+		}
+		if err != nil {
+			return fail(err)
+		}
+		display := req.Path
+		if req.Operation == "add_test_function" {
+			path, display = testFilePath(path), testFilePath(req.Path)
+		}
+
+		file, ok := staged[path]
+		if !ok {
+			file = &stagedFile{display: display}
+			if req.Operation == "add_test_function" {
+				file.content, file.perms, err = readTestFile(path)
+			} else {
+				file.content, file.perms, err = readFileWithPerms(path)
+			}
+			if err != nil {
+				return fail(err)
+			}
+			if _, statErr := os.Stat(path); statErr == nil {
+				file.original = file.content
+			}
+			staged[path] = file
+			order = append(order, path)
+		}
+
+		content, _, err := applyEdit(req, file.content, filepath.Dir(path))
+		if err != nil {
+			return fail(err)
+		}
+		file.content = content
+	}
+	return staged, order, 0, nil
+}
+
+// writeChangeset writes the staged files in order and returns the paths it
+// wrote, including on failure, for rollbackChangeset.
+func writeChangeset(staged map[string]*stagedFile, order []string) ([]string, error) {
+	var written []string
+	for _, path := range order {
+		file := staged[path]
+		if err := atomicWriteFile(path, file.content, file.perms); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", file.display, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// rollbackChangeset restores the written files to their content before the
+// changeset, removing those it created.
+func rollbackChangeset(staged map[string]*stagedFile, written []string) error {
+	var errs []error
+	for _, path := range slices.Backward(written) {
+		file := staged[path]
+		var err error
+		if file.original == nil {
+			err = os.Remove(path)
+		} else {
+			err = atomicWriteFile(path, file.original, file.perms)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to roll back %s: %w", file.display, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// buildChangeset runs 'go build ./...' in each module holding one of the
+// files at paths, so that callers in other packages are checked too, and
+// returns the output of the first build that fails.
+func buildChangeset(ctx context.Context, paths []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, goCommandTimeout)
+	defer cancel()
+
+	var roots []string
+	for _, path := range paths {
+		dir, err := filepath.Abs(filepath.Dir(path))
+		if err != nil {
+			return "", err
+		}
+		root := moduleRoot(ctx, dir)
+		if root == "" {
+			return "", fmt.Errorf("no go.mod found for %s; cannot build it", path)
+		}
+		if !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+	for _, root := range roots {
+		// Building several packages writes no binaries into the module.
+		if output, err := runCommand(ctx, root, "go", "build", "./..."); err != nil {
+			return shortenOutput(output), fmt.Errorf("go build failed in %s: %w", root, err)
+		}
+	}
+	return "", nil
+}
+
+// shortenOutput keeps the start of long command output, where the first
+// errors are.
+func shortenOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) <= maxBuildOutputBytes {
+		return output
+	}
+	return strings.ToValidUTF8(output[:maxBuildOutputBytes], "") + "\n... (output truncated)"
+}
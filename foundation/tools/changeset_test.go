@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyChangeset(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module example.com/shop\n\ngo 1.21\n",
+		"store/store.go": "package store\n\nfunc Count() int { return 0 }\n",
+		"main.go":        "package main\n\nimport \"example.com/shop/store\"\n\nfunc main() { println(store.Count()) }\n",
+	}
+	writeFiles(t, dir, files)
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewChangesetTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := func(n int) *int { return &n }
+	unchanged := func(t *testing.T) {
+		t.Helper()
+		for name, want := range files {
+			got, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil || string(got) != want {
+				t.Errorf("%s = %q, %v; want it unchanged", name, got, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "store", "store_test.go")); !os.IsNotExist(err) {
+			t.Errorf("store_test.go was left behind: %v", err)
+		}
+	}
+
+	// An invalid edit anywhere leaves every file untouched.
+	resp := callTool[ChangesetRequest, ChangesetResponse](t, ctx, bt, ChangesetRequest{Edits: []EditFileRequest{
+		{Path: "store/store.go", Operation: "add_function", Code: "func Reset() {}"},
+		{Path: "store/store.go", Operation: "add_test_function", Code: "func TestReset(t *testing.T) { Reset() }"},
+		{Path: "main.go", Operation: "replace_code_block", StartLine: line(5), EndLine: line(5), Code: "func main() {"},
+	}})
+	if resp.Error == "" || resp.FailedEdit != 3 {
+		t.Fatalf("invalid changeset = %+v, want edit 3 to fail", resp)
+	}
+	unchanged(t)
+
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	// Edits that parse but do not build are rolled back.
+	resp = callTool[ChangesetRequest, ChangesetResponse](t, ctx, bt, ChangesetRequest{Build: true, Edits: []EditFileRequest{
+		{Path: "store/store.go", Operation: "replace_code_block", StartLine: line(3), EndLine: line(3), Code: "func Count(kind string) int { return len(kind) }"},
+		{Path: "store/store.go", Operation: "add_test_function", Code: "func TestCount(t *testing.T) {}"},
+	}})
+	if resp.Error == "" || !strings.Contains(resp.BuildOutput, "not enough arguments") {
+		t.Fatalf("changeset that breaks the build = %+v", resp)
+	}
+	unchanged(t)
+
+	// A complete refactor is applied to every file.
+	resp = callTool[ChangesetRequest, ChangesetResponse](t, ctx, bt, ChangesetRequest{Build: true, Edits: []EditFileRequest{
+		{Path: "store/store.go", Operation: "replace_code_block", StartLine: line(3), EndLine: line(3), Code: "func Count(kind string) int { return len(kind) }"},
+		{Path: "main.go", Operation: "replace_code_block", StartLine: line(5), EndLine: line(5), Code: "func main() { println(store.Count(\"all\")) }"},
+	}})
+	if resp.Error != "" || strings.Join(resp.Files, ",") != "store/store.go,main.go" {
+		t.Fatalf("refactor = %+v", resp)
+	}
+	got, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	if !strings.Contains(string(got), `store.Count("all")`) {
+		t.Errorf("main.go = %s", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("the build left files in the module: %v", entries)
+	}
+}
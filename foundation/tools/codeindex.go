@@ -0,0 +1,335 @@
+package tools
+
+import (
+	"context"
+	"encoding/gob"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxIndexedFileBytes is the size above which files are left out of the code
+// index; content searches always read them.
+const maxIndexedFileBytes = 1 << 20
+
+// codeIndex is a trigram index of the files of a Git repository. Content
+// searches consult it to skip the files that cannot contain a match. It is
+// built once per commit and cached next to the repository, in
+// <repo>.index.gob.
+type codeIndex struct {
+	Commit   string
+	Files    []indexedFile
+	Postings map[uint32][]uint32 // Trigram to the ascending IDs of the files containing it.
+
+	byPath map[string]int
+}
+
+// indexedFile records the version of a file the index was built from.
+type indexedFile struct {
+	Path    string // Relative to the repository root, slash-separated.
+	Size    int64
+	ModTime int64
+}
+
+var codeIndexes = struct {
+	sync.Mutex
+	byRoot map[string]*codeIndex
+}{byRoot: make(map[string]*codeIndex)}
+
+// indexPath is where the index of the repository at root is cached. Like the
+// overview, it lives outside the worktree.
+func indexPath(root string) string {
+	return filepath.Clean(root) + ".index.gob"
+}
+
+// repoRoot returns the absolute path of the closest directory at or above dir
+// holding a .git entry. Only repositories below the workspace (or the current
+// directory), such as those gitclone creates, are indexed: it returns "" for
+// any other dir.
+func repoRoot(ctx context.Context, dir string) string {
+	top := Workspace(ctx)
+	if top == "" {
+		top = "."
+	}
+	top, err := filepath.Abs(top)
+	if err != nil {
+		return ""
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for dir != top && within(top, dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		dir = filepath.Dir(dir)
+	}
+	return ""
+}
+
+// loadCodeIndex returns the index of the repository at root, loading it from
+// disk or building it when it is missing or was built from another commit. It
+// returns nil, and searches fall back to reading every file, when the index
+// cannot be built.
+func loadCodeIndex(ctx context.Context, root string) *codeIndex {
+	commit := headCommit(root)
+	if commit == "" {
+		return nil
+	}
+
+	codeIndexes.Lock()
+	defer codeIndexes.Unlock()
+	if ix := codeIndexes.byRoot[root]; ix != nil && ix.Commit == commit {
+		return ix
+	}
+	ix := readCodeIndex(root)
+	if ix == nil || ix.Commit != commit {
+		var err error
+		if ix, err = buildCodeIndex(ctx, root, commit); err != nil {
+			return nil
+		}
+		writeCodeIndex(root, ix) // The cache is best effort.
+	}
+	ix.byPath = make(map[string]int, len(ix.Files))
+	for id, f := range ix.Files {
+		ix.byPath[f.Path] = id
+	}
+	codeIndexes.byRoot[root] = ix
+	return ix
+}
+
+func readCodeIndex(root string) *codeIndex {
+	f, err := os.Open(indexPath(root))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var ix codeIndex
+	if gob.NewDecoder(f).Decode(&ix) != nil {
+		return nil
+	}
+	return &ix
+}
+
+func writeCodeIndex(root string, ix *codeIndex) {
+	tmp := indexPath(root) + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return
+	}
+	err = gob.NewEncoder(f).Encode(ix)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return
+	}
+	os.Rename(tmp, indexPath(root))
+}
+
+// buildCodeIndex indexes the text files of the repository at root, skipping
+// the directories search_files skips. It stops early when ctx is done.
+func buildCodeIndex(ctx context.Context, root, commit string) (*codeIndex, error) {
+	ix := &codeIndex{Commit: commit, Postings: make(map[uint32][]uint32)}
+	var grams []uint32
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if _, skip := skippedSearchDirs[d.Name()]; skip {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxIndexedFileBytes {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		id := uint32(len(ix.Files))
+		ix.Files = append(ix.Files, indexedFile{Path: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime().UnixNano()})
+		// Binary files are recorded without trigrams: search_files never
+		// matches them.
+		if len(content) > 0 && !isText(content) {
+			return nil
+		}
+		grams = appendTrigrams(grams[:0], content)
+		slices.Sort(grams)
+		for _, g := range slices.Compact(grams) {
+			ix.Postings[g] = append(ix.Postings[g], id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// filterWithIndex narrows files down with the index of the repository holding
+// dir, if any.
+func filterWithIndex(ctx context.Context, dir string, files []string, re *regexp.Regexp) []string {
+	root := repoRoot(ctx, dir)
+	if root == "" {
+		return files
+	}
+	ix := loadCodeIndex(ctx, root)
+	if ix == nil {
+		return files
+	}
+	return ix.filter(root, files, re)
+}
+
+// filter drops the files that the index shows cannot match re. Files the
+// index does not know, or that changed since it was built, are kept.
+func (ix *codeIndex) filter(root string, files []string, re *regexp.Regexp) []string {
+	alternatives := regexTrigrams(re)
+	if alternatives == nil {
+		return files
+	}
+	matching := make(map[uint32]bool)
+	for _, grams := range alternatives {
+		for _, id := range ix.lookup(grams) {
+			matching[id] = true
+		}
+	}
+
+	kept := files[:0:0]
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			kept = append(kept, file)
+			continue
+		}
+		rel, err := filepath.Rel(root, abs)
+		id, known := ix.byPath[filepath.ToSlash(rel)]
+		if err != nil || !known || matching[uint32(id)] || ix.changed(file, id) {
+			kept = append(kept, file)
+		}
+	}
+	return kept
+}
+
+// lookup returns the IDs of the files containing all of grams.
+func (ix *codeIndex) lookup(grams []uint32) []uint32 {
+	ids := ix.Postings[grams[0]]
+	for _, g := range grams[1:] {
+		ids = intersect(ids, ix.Postings[g])
+		if len(ids) == 0 {
+			break
+		}
+	}
+	return ids
+}
+
+func (ix *codeIndex) changed(file string, id int) bool {
+	info, err := os.Stat(file)
+	return err != nil || info.Size() != ix.Files[id].Size || info.ModTime().UnixNano() != ix.Files[id].ModTime
+}
+
+// intersect returns the IDs in both of the ascending lists a and b.
+func intersect(a, b []uint32) []uint32 {
+	var out []uint32
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+func appendTrigrams(grams []uint32, b []byte) []uint32 {
+	for i := 0; i+3 <= len(b); i++ {
+		grams = append(grams, uint32(b[i])<<16|uint32(b[i+1])<<8|uint32(b[i+2]))
+	}
+	return grams
+}
+
+// regexTrigrams returns the trigrams a line must contain to match re, as
+// alternatives each of which lists trigrams that are all required. It returns
+// nil when re requires no literal text of three bytes or more, or only
+// case-insensitive text, so the index cannot narrow the search.
+func regexTrigrams(re *regexp.Regexp) [][]uint32 {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	return alternativeTrigrams(parsed.Simplify())
+}
+
+func alternativeTrigrams(re *syntax.Regexp) [][]uint32 {
+	switch re.Op {
+	case syntax.OpAlternate:
+		var alternatives [][]uint32
+		for _, sub := range re.Sub {
+			subAlternatives := alternativeTrigrams(sub)
+			if subAlternatives == nil {
+				return nil // This branch can match anything.
+			}
+			alternatives = append(alternatives, subAlternatives...)
+		}
+		return alternatives
+	case syntax.OpCapture:
+		return alternativeTrigrams(re.Sub[0])
+	}
+	grams := requiredTrigrams(re)
+	if len(grams) == 0 {
+		return nil
+	}
+	slices.Sort(grams)
+	return [][]uint32{slices.Compact(grams)}
+}
+
+// requiredTrigrams returns trigrams of the literal text every match of re
+// contains.
+func requiredTrigrams(re *syntax.Regexp) []uint32 {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil
+		}
+		return appendTrigrams(nil, []byte(string(re.Rune)))
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredTrigrams(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredTrigrams(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var grams []uint32
+		for _, sub := range re.Sub {
+			grams = append(grams, requiredTrigrams(sub)...)
+		}
+		return grams
+	}
+	return nil
+}
+
+// isText reports whether content sniffs as text, the files search_files
+// searches.
+func isText(content []byte) bool {
+	return strings.HasPrefix(http.DetectContentType(content), "text/")
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestRegexTrigrams(t *testing.T) {
+	for expr, want := range map[string]int{
+		`func.*Error`:      1,
+		`HandleRequest\d+`: 1,
+		`foo|barbaz`:       2,
+		`(?i)error`:        0,
+		`ab|cdef`:          0,
+		`[a-z]+`:           0,
+	} {
+		if got := len(regexTrigrams(regexp.MustCompile(expr))); got != want {
+			t.Errorf("regexTrigrams(%q) has %d alternatives, want %d", expr, got, want)
+		}
+	}
+}
+
+func TestSearchFilesWithCodeIndex(t *testing.T) {
+	workspace := t.TempDir()
+	dir := filepath.Join(workspace, "repos", "widgets")
+	writeFiles(t, dir, map[string]string{
+		"store/store.go": "package store\n\nfunc Get(id string) (Widget, error) { return lookup(id) }\n",
+		"store/index.go": "package store\n\nfunc lookup(id string) (Widget, error) { return Widget{}, nil }\n",
+		"api/api.go":     "package api\n\nfunc Serve() {}\n",
+		"README.md":      "# Widgets\n",
+	})
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	(&fakeRemotes{}).commitAll(t, repo, "initial")
+	ctx := WithWorkspace(context.Background(), workspace)
+
+	search := func(contains string) []string {
+		t.Helper()
+		bt, err := NewSearchFilesTool(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp := callTool[SearchFilesRequest, SearchFilesResponse](t, ctx, bt, SearchFilesRequest{Path: "repos/widgets", Contains: contains})
+		if resp.Error != "" {
+			t.Fatalf("search_files(%q) = %s", contains, resp.Error)
+		}
+		var files []string
+		for _, m := range resp.Matches {
+			rel, _ := filepath.Rel(dir, m.File)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return files
+	}
+
+	queries := []string{`func lookup`, `Widget, error`, `Serve|Widgets`, `(?i)widget`, `nothing here`}
+	var walked [][]string
+	for _, q := range queries {
+		walked = append(walked, search(q))
+	}
+	t.Setenv("CODE_INDEX", "true")
+	for i, q := range queries {
+		if got := search(q); !slices.Equal(got, walked[i]) {
+			t.Errorf("indexed search_files(%q) = %q, want %q", q, got, walked[i])
+		}
+	}
+	if _, err := os.Stat(indexPath(dir)); err != nil {
+		t.Errorf("index was not cached: %v", err)
+	}
+
+	root := repoRoot(ctx, filepath.Join(dir, "store"))
+	ix := loadCodeIndex(ctx, root)
+	if root != dir || ix == nil {
+		t.Fatalf("repoRoot = %q, index %v", root, ix)
+	}
+	files := []string{filepath.Join(dir, "store/store.go"), filepath.Join(dir, "store/index.go"), filepath.Join(dir, "api/api.go")}
+	if got := ix.filter(root, files, regexp.MustCompile(`func lookup`)); len(got) != 1 || got[0] != files[1] {
+		t.Errorf("filter kept %q", got)
+	}
+
+	// Files changed since the index was built are always searched.
+	writeFiles(t, dir, map[string]string{"api/api.go": "package api\n\nfunc lookup() {}\n"})
+	if got := search(`func lookup`); !slices.Equal(got, []string{"api/api.go", "store/index.go"}) {
+		t.Errorf("search after an edit = %q", got)
+	}
+	if repoRoot(ctx, workspace) != "" {
+		t.Error("the workspace itself is indexed")
+	}
+}
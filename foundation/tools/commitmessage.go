@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/go-git/go-git/v5"
+	"github.com/olusolaa/goforai/foundation/prompts"
+)
+
+// maxCommitChanges bounds the diff shown to the model, in bytes.
+const maxCommitChanges = 16000
+
+// conventionalHeader matches the first line of a Conventional Commits
+// message, such as "feat(tools): add suggest_commit_message".
+var conventionalHeader = regexp.MustCompile(`^(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(\([^()\s]+\))?!?: \S`)
+
+// CommitMessageConfig configures the suggest_commit_message tool.
+type CommitMessageConfig struct {
+	// Model writes the commit messages.
+	Model model.BaseChatModel
+}
+
+type CommitMessageRequest struct {
+	Path    string `json:"path,omitempty" jsonschema:"description=A directory in the Git repository. Defaults to the workspace."`
+	Scope   string `json:"scope,omitempty" jsonschema:"description=Which changes to describe and commit: 'staged' changes only or 'all' uncommitted changes\\, untracked files included. Defaults to the staged changes\\, or all changes when none are staged.,enum=staged,enum=all"`
+	Hint    string `json:"hint,omitempty" jsonschema:"description=What the changes are for\\, in the user's words\\, such as the issue they fix."`
+	Message string `json:"message,omitempty" jsonschema:"description=Use this commit message instead of writing one\\, e.g. a suggestion the user edited."`
+	Commit  bool   `json:"commit,omitempty" jsonschema:"description=Also commit the changes with the message\\, once the user approves it. Only when the user asked to commit."`
+}
+
+type CommitMessageResponse struct {
+	Repository string `json:"repository,omitempty" jsonschema:"description=Root of the repository."`
+	Branch     string `json:"branch,omitempty" jsonschema:"description=Checked-out branch\\, empty when HEAD is detached."`
+	Scope      string `json:"scope,omitempty" jsonschema:"description=The changes described."`
+	Summary    string `json:"summary,omitempty" jsonschema:"description=Files changed and lines added and removed."`
+	Message    string `json:"message,omitempty" jsonschema:"description=The commit message\\, in Conventional Commits form."`
+	Changelog  string `json:"changelog,omitempty" jsonschema:"description=A changelog entry for the changes; empty when users would not notice them."`
+	Commit     string `json:"commit,omitempty" jsonschema:"description=Hash of the commit made\\, when commit was set."`
+	Error      string `json:"error,omitempty" jsonschema:"description=Error message if no message could be written or the commit failed."`
+}
+
+// NewCommitMessageTool returns the suggest_commit_message tool, which reads
+// the uncommitted changes of a Git repository as review_changes does, has
+// config.Model write a Conventional Commits message and a changelog entry
+// for them, and commits them with go-git when asked to. Each commit is shown
+// to the approver of the call's context (see WithApprover) first; with a
+// context marked by WithReadOnly commits are refused with ErrReadOnly.
+func NewCommitMessageTool(ctx context.Context, config *CommitMessageConfig) (tool.BaseTool, error) {
+	if config == nil || config.Model == nil {
+		return nil, errors.New("suggest_commit_message needs a chat model")
+	}
+	prompt, err := prompts.Get("commit.message")
+	if err != nil {
+		return nil, err
+	}
+	readOnly := ReadOnly(ctx)
+	return inferTool(
+		"suggest_commit_message",
+		"Write a Conventional Commits message (type(scope): summary, then a body) and a changelog entry for the uncommitted changes of the Git repository holding the workspace. "+
+			"Use it when the user asks for a commit message or a changelog entry. With commit set, the changes are committed with the message after the user approves it; "+
+			"set it only when the user asked to commit, and pass message to commit a suggestion they edited.",
+		func(ctx context.Context, req *CommitMessageRequest) (*CommitMessageResponse, error) {
+			if req.Commit && readOnly {
+				return nil, ErrReadOnly
+			}
+			if req.Scope != "" && req.Scope != ReviewStaged && req.Scope != ReviewAll {
+				return &CommitMessageResponse{Error: fmt.Sprintf("unknown scope '%s'; use staged or all", req.Scope)}, nil
+			}
+			dir := req.Path
+			if dir == "" {
+				dir = "."
+			}
+			dir, err := resolvePath(ctx, dir)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &CommitMessageResponse{Error: err.Error()}, nil
+			}
+
+			review, err := reviewForCommit(ctx, dir, req.Scope)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &CommitMessageResponse{Error: err.Error()}, nil
+			}
+			resp := &CommitMessageResponse{Repository: review.Repository, Branch: review.Branch, Scope: review.Scope, Summary: review.Summary}
+			if len(review.Files) == 0 {
+				resp.Error = "nothing to commit: " + review.Summary
+				return resp, nil
+			}
+
+			resp.Message = strings.TrimSpace(req.Message)
+			if resp.Message == "" {
+				resp.Message, resp.Changelog, err = writeCommitMessage(ctx, config.Model, prompt, req.Hint, review)
+				if err := canceled(ctx); err != nil {
+					return nil, err
+				}
+				if err != nil {
+					resp.Error = err.Error()
+					return resp, nil
+				}
+			}
+			if !req.Commit {
+				return resp, nil
+			}
+
+			action := fmt.Sprintf("commit %s to %s", plural(len(review.Files), "file"), review.Repository)
+			if review.Branch != "" {
+				action += " on " + review.Branch
+			}
+			if err := approve(ctx, action, commitPreview(resp.Message, review)); err != nil {
+				return nil, err
+			}
+			if resp.Commit, err = commitChanges(dir, review.Scope, resp.Message); err != nil {
+				resp.Error = err.Error()
+			}
+			return resp, nil
+		},
+	)
+}
+
+// reviewForCommit reviews the changes of scope in the repository holding
+// dir; without a scope, the staged changes or, when none are, all of them.
+func reviewForCommit(ctx context.Context, dir, scope string) (*ReviewChangesResponse, error) {
+	if scope != "" {
+		return reviewChanges(ctx, dir, scope)
+	}
+	review, err := reviewChanges(ctx, dir, ReviewStaged)
+	if err != nil || len(review.Files) > 0 {
+		return review, err
+	}
+	return reviewChanges(ctx, dir, ReviewAll)
+}
+
+// writeCommitMessage has m write the commit message and changelog entry for
+// review.
+func writeCommitMessage(ctx context.Context, m model.BaseChatModel, prompt *prompts.Template, hint string, review *ReviewChangesResponse) (message, changelog string, err error) {
+	text, err := prompt.Render(map[string]any{"hint": strings.TrimSpace(hint), "changes": truncateText(formatChanges(review), maxCommitChanges)})
+	if err != nil {
+		return "", "", err
+	}
+	msg, err := m.Generate(ctx, []*schema.Message{schema.UserMessage(text)})
+	if err != nil {
+		return "", "", fmt.Errorf("writing the commit message failed: %w", err)
+	}
+	return parseCommitMessage(msg.Content)
+}
+
+// formatChanges renders review as the model reads it: a summary, then each
+// file with its status, counts, declarations touched and hunks.
+func formatChanges(review *ReviewChangesResponse) string {
+	var b strings.Builder
+	b.WriteString(review.Summary + "\n")
+	for _, f := range review.Files {
+		fmt.Fprintf(&b, "\n%s (%s, +%d -%d)\n", f.Path, f.Status, f.Added, f.Removed)
+		if len(f.Symbols) > 0 {
+			fmt.Fprintf(&b, "Declarations: %s\n", strings.Join(f.Symbols, ", "))
+		}
+		for _, h := range f.Hunks {
+			b.WriteString(h.Header + "\n" + h.Diff)
+			if !strings.HasSuffix(h.Diff, "\n") {
+				b.WriteString("\n")
+			}
+		}
+	}
+	if review.Truncated {
+		b.WriteString("\nMore files changed than are listed.\n")
+	}
+	return b.String()
+}
+
+// parseCommitMessage splits the model's reply into the commit message and
+// the changelog entry, and checks the message's first line.
+func parseCommitMessage(reply string) (message, changelog string, err error) {
+	reply = strings.TrimSpace(reply)
+	if strings.HasPrefix(reply, "```") { // A fenced reply, perhaps tagged ```text.
+		_, reply, _ = strings.Cut(reply, "\n")
+		reply = strings.TrimSuffix(strings.TrimSpace(reply), "```")
+	}
+	var lines []string
+	for _, line := range strings.Split(reply, "\n") {
+		if entry, ok := strings.CutPrefix(strings.TrimSpace(line), "CHANGELOG:"); ok {
+			if entry = strings.TrimSpace(entry); !strings.EqualFold(entry, "none") {
+				changelog = entry
+			}
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	message = strings.TrimSpace(strings.Join(lines, "\n"))
+	header, _, _ := strings.Cut(message, "\n")
+	if !conventionalHeader.MatchString(header) {
+		return "", "", fmt.Errorf("the model wrote '%s', which is not a Conventional Commits header; try again with a hint", header)
+	}
+	return message, changelog, nil
+}
+
+// commitPreview is what the approver of a commit is shown: the message and
+// the files it commits.
+func commitPreview(message string, review *ReviewChangesResponse) string {
+	var b strings.Builder
+	b.WriteString(message + "\n\n")
+	for _, f := range review.Files {
+		fmt.Fprintf(&b, "%s %s (+%d -%d)\n", f.Status, f.Path, f.Added, f.Removed)
+	}
+	if review.Truncated {
+		b.WriteString("and more files\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// commitChanges commits the changes of scope in the repository holding dir
+// with message, staging every change first for ReviewAll, and returns the
+// commit's hash. The author comes from the Git configuration.
+func commitChanges(dir, scope, message string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository: %w", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to open worktree: %w", err)
+	}
+	if scope == ReviewAll {
+		if err := wt.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+			return "", fmt.Errorf("failed to stage the changes: %w", err)
+		}
+	}
+	hash, err := wt.Commit(message+"\n", &git.CommitOptions{})
+	if errors.Is(err, git.ErrMissingAuthor) {
+		return "", errors.New("no commit author is configured; ask the user to set user.name and user.email with git config")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+	return hash.String(), nil
+}
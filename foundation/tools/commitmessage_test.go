@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/go-git/go-git/v5"
+)
+
+// committerModel answers every prompt with reply and records the prompts.
+type committerModel struct {
+	reply   string
+	prompts []string
+}
+
+func (m *committerModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.prompts = append(m.prompts, input[len(input)-1].Content)
+	return schema.AssistantMessage(m.reply, nil), nil
+}
+
+func (m *committerModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), err
+}
+
+func TestSuggestCommitMessage(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"store.go": "package store\n\nfunc Get() string {\n\treturn \"\"\n}\n"})
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	(&fakeRemotes{}).commitAll(t, repo, "initial")
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Name, cfg.User.Email = "Gopher", "gopher@example.com"
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{
+		"store.go": "package store\n\nfunc Get() string {\n\treturn \"widget\"\n}\n",
+		"notes.md": "# Notes\n",
+	})
+
+	m := &committerModel{reply: "```text\nfix(store): return the widget from Get\n\nGet returned an empty string.\n\nCHANGELOG: Get returns the stored widget.\n```"}
+	approver := &fakeApprover{approve: true}
+	ctx := WithApprover(WithWorkspace(context.Background(), dir), approver)
+	bt, err := NewCommitMessageTool(ctx, &CommitMessageConfig{Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(args string) (*CommitMessageResponse, error) {
+		t.Helper()
+		out, err := bt.(tool.InvokableTool).InvokableRun(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		var resp CommitMessageResponse
+		if err := json.Unmarshal([]byte(out), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return &resp, nil
+	}
+
+	// Nothing is staged, so every change is described.
+	resp, err := call(`{"hint":"issue 12"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMessage := "fix(store): return the widget from Get\n\nGet returned an empty string."
+	if resp.Error != "" || resp.Scope != ReviewAll || resp.Message != wantMessage || resp.Changelog != "Get returns the stored widget." || resp.Commit != "" {
+		t.Fatalf("suggestion = %+v", resp)
+	}
+	if p := m.prompts[0]; !strings.Contains(p, "<hint>issue 12</hint>") || !strings.Contains(p, `+	return "widget"`) || !strings.Contains(p, "notes.md (untracked") {
+		t.Errorf("prompt does not show the hint and the changes:\n%s", p)
+	}
+	if len(approver.actions) != 0 {
+		t.Errorf("a suggestion asked for approval: %q", approver.actions)
+	}
+
+	// A declined commit leaves the changes uncommitted.
+	approver.approve = false
+	if _, err := call(`{"commit":true}`); !errors.Is(err, ErrNotApproved) {
+		t.Fatalf("declined commit = %v, want ErrNotApproved", err)
+	}
+	approver.approve = true
+	resp, err = call(`{"commit":true,"message":"docs: add notes"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != "" || resp.Commit == "" || len(m.prompts) != 2 {
+		t.Fatalf("commit = %+v, want a commit with the given message", resp)
+	}
+	if d := approver.details[len(approver.details)-1]; !strings.HasPrefix(d, "docs: add notes\n\n") || !strings.Contains(d, "untracked notes.md") {
+		t.Errorf("approval shows %q, want the message and the files", d)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Hash.String() != resp.Commit || commit.Message != "docs: add notes\n" || commit.Author.Name != "Gopher" {
+		t.Errorf("HEAD = %s %q by %s, want the new commit", commit.Hash, commit.Message, commit.Author.Name)
+	}
+	if resp, err := call(`{}`); err != nil || !strings.HasPrefix(resp.Error, "nothing to commit") {
+		t.Errorf("after committing = %+v, %v; want nothing to commit", resp, err)
+	}
+
+	// Messages that are not Conventional Commits are rejected.
+	writeFiles(t, dir, map[string]string{"notes.md": "# Notes\n\nMore.\n"})
+	m.reply = "Updated the notes."
+	if resp, err := call(`{}`); err != nil || !strings.Contains(resp.Error, "not a Conventional Commits header") {
+		t.Errorf("free-form reply = %+v, %v; want it rejected", resp, err)
+	}
+
+	readOnly, err := NewCommitMessageTool(WithReadOnly(ctx), &CommitMessageConfig{Model: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readOnly.(tool.InvokableTool).InvokableRun(ctx, `{"commit":true}`); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("read-only commit = %v, want ErrReadOnly", err)
+	}
+}
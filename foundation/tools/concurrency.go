@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/forward"
+	"golang.org/x/sync/semaphore"
+)
+
+// Concurrency annotates how calls of a tool may overlap. The ReAct agent runs
+// the tool calls of one model response in parallel, which is what makes a
+// batch of searches fast, but two edits of the same file would interleave
+// their read-modify-write cycles and lose one of the changes. The zero value
+// lets calls run in parallel without limit.
+type Concurrency struct {
+	// Limit caps how many calls of the tool run at once; 0 is unlimited.
+	Limit int
+	// Paths returns the files or directories a call writes, from its JSON
+	// arguments. Calls writing the same path, from any tool, run one at a
+	// time.
+	Paths func(argumentsInJSON string) []string
+	// Exclusive marks a tool that may write anywhere, such as a rename
+	// across a module: its calls run while no other writing call does.
+	Exclusive bool
+}
+
+// concurrency holds the annotations of the built-in tools. Tools that are not
+// listed only read and run in parallel freely.
+var concurrency = map[string]Concurrency{
+	"edit_go_file":         {Paths: pathArgument},
+	"apply_changeset":      {Paths: changesetPaths},
+	"scaffold_project":     {Paths: pathArgument},
+	"resolve_conflict":     {Paths: pathArgument},
+	"rename_symbol":        {Exclusive: true},
+	"fix_dependencies":     {Exclusive: true},
+	"gitclone":             {Limit: 2}, // Clones are bound by the network and the disk.
+	"analyze_repos":        {Limit: 1}, // Each call already clones and analyzes in parallel.
+	"run_go":               {Limit: 2}, // Builds already use every core.
+	"profile_program":      {Limit: 1}, // Other work on the machine would skew the profile.
+	"scan_vulnerabilities": {Limit: 1}, // Each scan loads and analyzes the whole module.
+	"send_email":           {Limit: 1}, // The user approves one email at a time.
+
+	// A commit message must describe every write made before it.
+	"suggest_commit_message": {Exclusive: true},
+}
+
+// ConcurrencyOf returns the annotation of the tool called name.
+func ConcurrencyOf(name string) Concurrency {
+	return concurrency[name]
+}
+
+// ReadOnlyTool is implemented by tools declared outside this package, such
+// as custom and MCP tools, whose declaration says whether they only read.
+type ReadOnlyTool interface {
+	tool.BaseTool
+	ReadOnly() bool
+}
+
+// ExternalConcurrency returns the annotation of t, a tool declared outside
+// this package. What such a tool writes is unknown, so unless it is a
+// ReadOnlyTool that only reads, its calls run while no other writing call
+// does.
+func ExternalConcurrency(t tool.BaseTool) Concurrency {
+	if r, ok := t.(ReadOnlyTool); ok && r.ReadOnly() {
+		return Concurrency{}
+	}
+	return Concurrency{Exclusive: true}
+}
+
+// pathArgument returns the "path" argument of a call.
+func pathArgument(argumentsInJSON string) []string {
+	var args struct {
+		Path string `json:"path"`
+	}
+	if json.Unmarshal([]byte(argumentsInJSON), &args) != nil || args.Path == "" {
+		return nil
+	}
+	return []string{args.Path}
+}
+
+// changesetPaths returns the path of every edit of an apply_changeset call.
+func changesetPaths(argumentsInJSON string) []string {
+	var args struct {
+		Edits []struct {
+			Path string `json:"path"`
+		} `json:"edits"`
+	}
+	if json.Unmarshal([]byte(argumentsInJSON), &args) != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range args.Edits {
+		if e.Path != "" {
+			paths = append(paths, e.Path)
+		}
+	}
+	return paths
+}
+
+// Limiter enforces the Concurrency annotations of the tools of one toolbox.
+// Wrap every tool with the same Limiter so that file locks are shared
+// between them.
+type Limiter struct {
+	// writers is acquired with weight 1 by calls that write known files and
+	// whole by Exclusive ones. Unlike a sync.RWMutex, waiting for it can be
+	// canceled.
+	writers *semaphore.Weighted
+
+	mu    sync.Mutex
+	files map[string]chan struct{} // A one-slot semaphore per file.
+}
+
+// NewLimiter returns a Limiter with no calls running.
+func NewLimiter() *Limiter {
+	return &Limiter{writers: semaphore.NewWeighted(maxWriters), files: make(map[string]chan struct{})}
+}
+
+// maxWriters is the weight of Limiter.writers, more calls than ever run at
+// once.
+const maxWriters = 1 << 30
+
+// Wrap returns t with c enforced. Tools with the zero annotation, and tools
+// that are not invokable, are returned unchanged.
+func (l *Limiter) Wrap(t tool.BaseTool, c Concurrency) tool.BaseTool {
+	inner, ok := t.(tool.InvokableTool)
+	if !ok || (c.Limit <= 0 && c.Paths == nil && !c.Exclusive) {
+		return t
+	}
+	lt := &limitedConcurrencyTool{Tool: forward.Tool{InvokableTool: inner}, limiter: l, concurrency: c}
+	if c.Limit > 0 {
+		lt.slots = make(chan struct{}, c.Limit)
+	}
+	return lt
+}
+
+type limitedConcurrencyTool struct {
+	forward.Tool
+	limiter     *Limiter
+	concurrency Concurrency
+	slots       chan struct{} // nil when the tool has no limit.
+}
+
+func (t *limitedConcurrencyTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+			defer func() { <-t.slots }()
+		case <-ctx.Done():
+			return "", canceled(ctx)
+		}
+	}
+
+	l := t.limiter
+	switch {
+	case t.concurrency.Exclusive:
+		if l.writers.Acquire(ctx, maxWriters) != nil {
+			return "", canceled(ctx)
+		}
+		defer l.writers.Release(maxWriters)
+	case t.concurrency.Paths != nil:
+		if l.writers.Acquire(ctx, 1) != nil {
+			return "", canceled(ctx)
+		}
+		defer l.writers.Release(1)
+		unlock, err := l.lockFiles(ctx, t.concurrency.Paths(argumentsInJSON))
+		if err != nil {
+			return "", err
+		}
+		defer unlock()
+	}
+	return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+}
+
+// lockFiles takes the semaphores of paths in a fixed order, so that two calls
+// writing overlapping sets of files cannot deadlock.
+func (l *Limiter) lockFiles(ctx context.Context, paths []string) (unlock func(), err error) {
+	keys := make([]string, 0, len(paths))
+	for _, p := range paths {
+		keys = append(keys, fileKey(ctx, p))
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	var held []chan struct{}
+	unlock = func() {
+		for _, sem := range held {
+			<-sem
+		}
+	}
+	for _, key := range keys {
+		l.mu.Lock()
+		sem, ok := l.files[key]
+		if !ok {
+			sem = make(chan struct{}, 1)
+			l.files[key] = sem
+		}
+		l.mu.Unlock()
+
+		select {
+		case sem <- struct{}{}:
+			held = append(held, sem)
+		case <-ctx.Done():
+			unlock()
+			return nil, canceled(ctx)
+		}
+	}
+	return unlock, nil
+}
+
+// fileKey identifies the file at path the way the file tools resolve it. A
+// test file shares the key of the file it tests, because edit_go_file's
+// add_test_function may be given either one and writes the test file.
+func fileKey(ctx context.Context, path string) string {
+	if resolved, err := resolvePath(ctx, path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if base, ok := strings.CutSuffix(path, "_test.go"); ok {
+		path = base + ".go"
+	}
+	return path
+}
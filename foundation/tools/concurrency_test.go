@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// overlapTool records the most of its calls that ran at once.
+type overlapTool struct {
+	mu      sync.Mutex
+	running int
+	maxAll  int
+}
+
+func (t *overlapTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "overlap"}, nil
+}
+
+func (t *overlapTool) InvokableRun(context.Context, string, ...tool.Option) (string, error) {
+	t.mu.Lock()
+	t.running++
+	t.maxAll = max(t.maxAll, t.running)
+	t.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	t.mu.Lock()
+	t.running--
+	t.mu.Unlock()
+	return "{}", nil
+}
+
+// runAll calls bt once with each of args, all at the same time.
+func runAll(t *testing.T, bt tool.BaseTool, args ...string) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for _, a := range args {
+		wg.Go(func() {
+			if _, err := bt.(tool.InvokableTool).InvokableRun(context.Background(), a); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestLimiterSerializesWritesToTheSameFile(t *testing.T) {
+	inner := &overlapTool{}
+	bt := NewLimiter().Wrap(inner, ConcurrencyOf("edit_go_file"))
+
+	runAll(t, bt, `{"path":"a.go"}`, `{"path":"./a.go"}`, `{"path":"a_test.go"}`, `{"path":"b.go"}`)
+	if inner.maxAll != 2 {
+		t.Errorf("%d calls ran at once, want 2: one on a.go and one on b.go", inner.maxAll)
+	}
+}
+
+func TestLimiterSharesLocksBetweenTools(t *testing.T) {
+	inner := &overlapTool{}
+	l := NewLimiter()
+	edit := l.Wrap(inner, ConcurrencyOf("edit_go_file"))
+	changeset := l.Wrap(inner, ConcurrencyOf("apply_changeset"))
+
+	var wg sync.WaitGroup
+	wg.Go(func() { runAll(t, edit, `{"path":"b.go"}`) })
+	wg.Go(func() { runAll(t, changeset, `{"edits":[{"path":"a.go"},{"path":"b.go"}]}`) })
+	wg.Wait()
+	if inner.maxAll != 1 {
+		t.Errorf("%d calls ran at once, want 1", inner.maxAll)
+	}
+}
+
+func TestLimiterExclusive(t *testing.T) {
+	inner := &overlapTool{}
+	l := NewLimiter()
+	rename := l.Wrap(inner, ConcurrencyOf("rename_symbol"))
+	edit := l.Wrap(inner, ConcurrencyOf("edit_go_file"))
+
+	var wg sync.WaitGroup
+	wg.Go(func() { runAll(t, rename, `{"path":"a.go","old_name":"x"}`, `{"path":"b.go","old_name":"y"}`) })
+	wg.Go(func() { runAll(t, edit, `{"path":"c.go"}`) })
+	wg.Wait()
+	if inner.maxAll != 1 {
+		t.Errorf("%d calls ran at once, want 1", inner.maxAll)
+	}
+}
+
+func TestLimiterLimit(t *testing.T) {
+	inner := &overlapTool{}
+	bt := NewLimiter().Wrap(inner, Concurrency{Limit: 2})
+
+	runAll(t, bt, "1", "2", "3", "4", "5")
+	if inner.maxAll != 2 {
+		t.Errorf("%d calls ran at once, want 2", inner.maxAll)
+	}
+
+	// Read-only tools are not wrapped.
+	if search := NewLimiter().Wrap(inner, ConcurrencyOf("search_files")); search != tool.BaseTool(inner) {
+		t.Error("search_files was limited")
+	}
+}
+
+func TestLimiterWaitIsCanceled(t *testing.T) {
+	l := NewLimiter()
+	unlock, err := l.lockFiles(context.Background(), []string{"a.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bt := l.Wrap(&overlapTool{}, ConcurrencyOf("edit_go_file"))
+	if _, err := bt.(tool.InvokableTool).InvokableRun(ctx, `{"path":"a.go"}`); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestExternalConcurrency(t *testing.T) {
+	for _, readOnly := range []bool{true, false} {
+		ct, err := NewCustomTool(CustomToolSpec{Name: "lint", Description: "d", ReadOnly: readOnly, Command: []string{"true"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c := ExternalConcurrency(ct); c.Exclusive == readOnly {
+			t.Errorf("read_only %v: annotation = %+v", readOnly, c)
+		}
+	}
+	// A tool that declares nothing may write anywhere.
+	if c := ExternalConcurrency(&overlapTool{}); !c.Exclusive {
+		t.Errorf("undeclared tool: annotation = %+v", c)
+	}
+}
+
+func TestLimiterExclusiveWaitIsCanceled(t *testing.T) {
+	l := NewLimiter()
+	if err := l.writers.Acquire(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	defer l.writers.Release(1)
+
+	// A rename waiting behind a running edit gives up when its turn ends.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	bt := l.Wrap(&overlapTool{}, ConcurrencyOf("rename_symbol"))
+	if _, err := bt.(tool.InvokableTool).InvokableRun(ctx, `{}`); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,421 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// Resolutions resolve_conflict applies to a conflicted hunk.
+const (
+	ResolveOurs   = "ours"   // Keep our side.
+	ResolveTheirs = "theirs" // Keep their side.
+	ResolveBoth   = "both"   // Keep our side, then theirs.
+	ResolveBase   = "base"   // Keep the common ancestor, when the markers show it.
+	ResolveCustom = "custom" // Replace the hunk with code written for it.
+)
+
+// Limits that keep the conflicts of a worktree small enough to hand to the
+// model. Hunks past the limit are counted but not shown.
+const (
+	maxConflictLines = 1500 // Lines of all hunks shown, both sides and context.
+	conflictContext  = 3    // Lines shown above and below each hunk.
+)
+
+type ListConflictsRequest struct {
+	Path string `json:"path,omitempty" jsonschema:"description=A directory in the Git repository\\, or one conflicted file to show only its hunks. Defaults to the workspace."`
+}
+
+// ConflictHunk is one conflict of a file: the two sides between the
+// conflict markers, and the common ancestor when Git wrote it.
+type ConflictHunk struct {
+	Number      int    `json:"number" jsonschema:"description=Number of the hunk in the file\\, from 1\\, to pass to resolve_conflict."`
+	StartLine   int    `json:"start_line" jsonschema:"description=Line of the <<<<<<< marker."`
+	EndLine     int    `json:"end_line" jsonschema:"description=Line of the >>>>>>> marker."`
+	OursLabel   string `json:"ours_label,omitempty" jsonschema:"description=What Git named our side\\, such as HEAD."`
+	Ours        string `json:"ours"`
+	BaseLabel   string `json:"base_label,omitempty"`
+	Base        string `json:"base,omitempty" jsonschema:"description=The common ancestor\\, when merge.conflictStyle is diff3 or zdiff3."`
+	TheirsLabel string `json:"theirs_label,omitempty" jsonschema:"description=What Git named their side\\, such as the merged branch or the commit being replayed."`
+	Theirs      string `json:"theirs"`
+	Before      string `json:"before,omitempty" jsonschema:"description=Lines just above the hunk."`
+	After       string `json:"after,omitempty" jsonschema:"description=Lines just below the hunk."`
+}
+
+// ConflictFile is a file Git could not merge.
+type ConflictFile struct {
+	Path      string         `json:"path" jsonschema:"description=Path of the file\\, as resolve_conflict takes it."`
+	Conflicts int            `json:"conflicts" jsonschema:"description=Hunks still between conflict markers."`
+	Note      string         `json:"note,omitempty" jsonschema:"description=Why the file is conflicted without hunks\\, such as a side that deleted it."`
+	Hunks     []ConflictHunk `json:"hunks,omitempty"`
+	Truncated bool           `json:"truncated,omitempty" jsonschema:"description=Some hunks were left out to fit; list the file alone for them."`
+}
+
+type ListConflictsResponse struct {
+	Repository string         `json:"repository,omitempty" jsonschema:"description=Root of the repository."`
+	Operation  string         `json:"operation,omitempty" jsonschema:"description=What stopped on the conflicts: merge\\, rebase\\, cherry-pick or revert. During a rebase 'ours' is the branch being rebased onto and 'theirs' the commit being replayed."`
+	Summary    string         `json:"summary,omitempty"`
+	Files      []ConflictFile `json:"files,omitempty" jsonschema:"description=The conflicted files\\, sorted by path."`
+	Error      string         `json:"error,omitempty" jsonschema:"description=Error message if the conflicts could not be read."`
+}
+
+type ResolveConflictRequest struct {
+	Path       string `json:"path" jsonschema:"description=The conflicted file\\, as list_conflicts gives it."`
+	Hunk       int    `json:"hunk" jsonschema:"description=Number of the hunk to resolve\\, from list_conflicts. Hunks are numbered in the file as it is now\\, so resolving one renumbers those below it."`
+	Resolution string `json:"resolution" jsonschema:"description=How to resolve it: keep 'ours'\\, 'theirs'\\, 'both' (ours then theirs) or 'base'\\, or 'custom' to replace the hunk with code.,enum=ours,enum=theirs,enum=both,enum=base,enum=custom"`
+	Code       string `json:"code,omitempty" jsonschema:"description=For 'custom': the merged lines that replace the whole hunk\\, markers and all."`
+}
+
+type ResolveConflictResponse struct {
+	Path      string `json:"path,omitempty"`
+	Remaining int    `json:"remaining" jsonschema:"description=Conflicted hunks left in the file."`
+	Message   string `json:"message,omitempty"`
+	Error     string `json:"error,omitempty" jsonschema:"description=Error message if the hunk could not be resolved; the file is then unchanged."`
+}
+
+// NewListConflictsTool returns the list_conflicts tool, which lists the
+// files a merge, rebase or cherry-pick left conflicted in a Git worktree and
+// shows each conflicted hunk with both sides.
+func NewListConflictsTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"list_conflicts",
+		"List the merge conflicts of the Git repository holding the workspace (or path): the operation that stopped (merge, rebase, cherry-pick), "+
+			"each conflicted file, and each conflicted hunk with our side, their side, the common ancestor when available and the lines around it. "+
+			"Use it when the user is stuck on conflicts, then settle each hunk with resolve_conflict.",
+		func(ctx context.Context, req *ListConflictsRequest) (*ListConflictsResponse, error) {
+			path := req.Path
+			if path == "" {
+				path = "."
+			}
+			path, err := resolvePath(ctx, path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &ListConflictsResponse{Error: err.Error()}, nil
+			}
+			resp, err := listConflicts(ctx, path)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &ListConflictsResponse{Error: err.Error()}, nil
+			}
+			return resp, nil
+		},
+	)
+}
+
+// listConflicts lists the conflicted files of the repository holding path,
+// or only path when it is a file.
+func listConflicts(ctx context.Context, path string) (*ListConflictsResponse, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+	dir, only := abs, ""
+	if !info.IsDir() {
+		dir = filepath.Dir(abs)
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, fmt.Errorf("'%s' is not in a Git repository", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worktree: %w", err)
+	}
+	root := wt.Filesystem.Root()
+	if !info.IsDir() {
+		if only, err = filepath.Rel(root, abs); err != nil {
+			return nil, err
+		}
+		only = filepath.ToSlash(only)
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+
+	// Git keeps up to three versions of a file it could not merge in the
+	// index, one per stage, until the resolution is staged.
+	stages := map[string][]index.Stage{}
+	for _, e := range idx.Entries {
+		if e.Stage != index.Merged && (only == "" || e.Name == only) {
+			stages[e.Name] = append(stages[e.Name], e.Stage)
+		}
+	}
+	names := make([]string, 0, len(stages))
+	for name := range stages {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	resp := &ListConflictsResponse{Repository: root, Operation: gitOperation(root)}
+	budget := maxConflictLines
+	var hunks, resolved int
+	for _, name := range names {
+		if err := canceled(ctx); err != nil {
+			return nil, err
+		}
+		file := ConflictFile{Path: toolPath(ctx, filepath.Join(root, filepath.FromSlash(name)))}
+		switch s := stages[name]; {
+		case !slices.Contains(s, index.OurMode):
+			file.Note = "deleted on our side and changed on theirs; keep it or delete it"
+		case !slices.Contains(s, index.TheirMode):
+			file.Note = "changed on our side and deleted on theirs; keep it or delete it"
+		}
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err == nil {
+			found, err := parseConflicts(string(content))
+			if err != nil {
+				file.Note = err.Error()
+			}
+			file.Conflicts = len(found)
+			for _, h := range found {
+				lines := strings.Count(h.Ours+h.Base+h.Theirs+h.Before+h.After, "\n")
+				if lines > budget {
+					file.Truncated = true
+					break
+				}
+				budget -= lines
+				file.Hunks = append(file.Hunks, h.ConflictHunk)
+			}
+		}
+		if file.Conflicts == 0 && file.Note == "" {
+			file.Note = "no conflict markers left; stage the file with git add once it is right"
+			resolved++
+		}
+		hunks += file.Conflicts
+		resp.Files = append(resp.Files, file)
+	}
+	switch {
+	case len(resp.Files) == 0:
+		resp.Summary = "No conflicts."
+	case resolved == len(resp.Files):
+		resp.Summary = fmt.Sprintf("%s resolved but not staged yet.", plural(resolved, "file"))
+	default:
+		resp.Summary = fmt.Sprintf("%s conflicted, %s in all.", plural(len(resp.Files), "file"), plural(hunks, "hunk"))
+	}
+	return resp, nil
+}
+
+// gitOperation names the operation stopped in the repository at root, or
+// returns "" when none is.
+func gitOperation(root string) string {
+	gitDir := filepath.Join(root, ".git")
+	// In a linked worktree .git is a file pointing at the real directory.
+	if data, err := os.ReadFile(gitDir); err == nil {
+		if dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: "); ok {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(root, dir)
+			}
+			gitDir = dir
+		}
+	}
+	for _, op := range []struct{ file, name string }{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+	} {
+		if _, err := os.Stat(filepath.Join(gitDir, op.file)); err == nil {
+			return op.name
+		}
+	}
+	return ""
+}
+
+// toolPath returns abs as the file tools take it: relative to the
+// workspace, or to the current directory without one, when it is inside.
+func toolPath(ctx context.Context, abs string) string {
+	base := Workspace(ctx)
+	if base == "" {
+		base = "."
+	}
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return abs
+	}
+	if rel, err := filepath.Rel(base, abs); err == nil && within(base, abs) {
+		return filepath.ToSlash(rel)
+	}
+	return abs
+}
+
+// conflict is a conflicted hunk with where it sits in the file's lines.
+type conflict struct {
+	ConflictHunk
+	start, end int // Indexes of the marker lines.
+}
+
+// conflictMarker reports whether line is the conflict marker made of the
+// character c repeated seven times, alone or followed by a label.
+func conflictMarker(line string, c byte) (label string, ok bool) {
+	line = strings.TrimRight(line, "\r\n")
+	marker := strings.Repeat(string(c), 7)
+	rest, ok := strings.CutPrefix(line, marker)
+	if !ok || (rest != "" && rest[0] != ' ') {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// parseConflicts returns the conflicted hunks of content, in order.
+func parseConflicts(content string) ([]conflict, error) {
+	lines := strings.SplitAfter(content, "\n")
+	var (
+		found   []conflict
+		cur     *conflict
+		part    *string // The side being read.
+		prevEnd = -1    // Context never reaches into the previous hunk.
+	)
+	for i, line := range lines {
+		if cur == nil {
+			if label, ok := conflictMarker(line, '<'); ok {
+				cur = &conflict{ConflictHunk: ConflictHunk{Number: len(found) + 1, StartLine: i + 1, OursLabel: label}, start: i}
+				part = &cur.Ours
+			}
+			continue
+		}
+		if label, ok := conflictMarker(line, '|'); ok && part == &cur.Ours {
+			cur.BaseLabel, part = label, &cur.Base
+			continue
+		}
+		if _, ok := conflictMarker(line, '='); ok && part != &cur.Theirs {
+			part = &cur.Theirs
+			continue
+		}
+		if label, ok := conflictMarker(line, '>'); ok && part == &cur.Theirs {
+			cur.TheirsLabel, cur.EndLine, cur.end = label, i+1, i
+			cur.Before = strings.Join(lines[max(cur.start-conflictContext, prevEnd+1):cur.start], "")
+			cur.After = strings.Join(lines[i+1:min(i+1+conflictContext, len(lines))], "")
+			found = append(found, *cur)
+			cur, part, prevEnd = nil, nil, i
+			continue
+		}
+		*part += line
+	}
+	if cur != nil {
+		return found, fmt.Errorf("the conflict starting at line %d has no end marker", cur.StartLine)
+	}
+	return found, nil
+}
+
+// NewResolveConflictTool returns the resolve_conflict tool, which replaces
+// one conflicted hunk of a file with a side of it or with code written for
+// it. A Go file whose last conflict is resolved must parse, or nothing is
+// written. With a context marked by WithReadOnly every call is refused with
+// ErrReadOnly.
+func NewResolveConflictTool(ctx context.Context) (tool.BaseTool, error) {
+	readOnly := ReadOnly(ctx)
+	return inferTool(
+		"resolve_conflict",
+		"Resolve one conflicted hunk listed by list_conflicts: keep 'ours', 'theirs', 'both' or the common 'base', or pass 'custom' with code that merges the two sides. "+
+			"Prefer 'custom' when both sides made changes that must be kept. Once a Go file has no conflicts left it must parse, or nothing is written. "+
+			"After the last hunk, build and test with run_go, and ask the user to stage the files and continue the merge or rebase.",
+		func(ctx context.Context, req *ResolveConflictRequest) (*ResolveConflictResponse, error) {
+			if readOnly {
+				return nil, ErrReadOnly
+			}
+			path, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &ResolveConflictResponse{Error: err.Error()}, nil
+			}
+			resp, err := resolveConflict(path, req)
+			if err != nil {
+				return &ResolveConflictResponse{Path: req.Path, Error: err.Error()}, nil
+			}
+			return resp, nil
+		},
+	)
+}
+
+// resolveConflict applies req to the file at path.
+func resolveConflict(path string, req *ResolveConflictRequest) (*ResolveConflictResponse, error) {
+	content, perms, err := readFileWithPerms(path)
+	if err != nil {
+		return nil, err
+	}
+	found, err := parseConflicts(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("'%s' has no conflicts left", req.Path)
+	}
+	if req.Hunk < 1 || req.Hunk > len(found) {
+		return nil, fmt.Errorf("hunk %d does not exist: '%s' has %s; list_conflicts shows them", req.Hunk, req.Path, plural(len(found), "conflicted hunk"))
+	}
+	h := found[req.Hunk-1]
+
+	var replacement string
+	switch req.Resolution {
+	case ResolveOurs:
+		replacement = h.Ours
+	case ResolveTheirs:
+		replacement = h.Theirs
+	case ResolveBoth:
+		replacement = h.Ours + h.Theirs
+	case ResolveBase:
+		if h.BaseLabel == "" && h.Base == "" {
+			return nil, errors.New("the markers do not show the common ancestor of this hunk; use ours, theirs, both or custom")
+		}
+		replacement = h.Base
+	case ResolveCustom:
+		replacement = req.Code
+		if replacement != "" && !strings.HasSuffix(replacement, "\n") {
+			replacement += "\n"
+		}
+		if slices.ContainsFunc(strings.SplitAfter(replacement, "\n"), func(line string) bool {
+			_, ours := conflictMarker(line, '<')
+			_, theirs := conflictMarker(line, '>')
+			return ours || theirs
+		}) {
+			return nil, errors.New("code still holds conflict markers; pass only the merged lines")
+		}
+	default:
+		return nil, fmt.Errorf("unknown resolution '%s'; use ours, theirs, both, base or custom", req.Resolution)
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	resolved := strings.Join(lines[:h.start], "") + replacement + strings.Join(lines[h.end+1:], "")
+	remaining := len(found) - 1
+	if remaining == 0 && filepath.Ext(path) == ".go" {
+		if _, err := parser.ParseFile(token.NewFileSet(), path, resolved, parser.ParseComments); err != nil {
+			return nil, fmt.Errorf("the resolved file does not parse, so nothing was written: %w", err)
+		}
+	}
+	if err := atomicWriteFile(path, []byte(resolved), perms); err != nil {
+		return nil, err
+	}
+
+	resp := &ResolveConflictResponse{Path: req.Path, Remaining: remaining}
+	if remaining > 0 {
+		resp.Message = fmt.Sprintf("✅ Resolved hunk %d with %s; %s left, renumbered from 1.", req.Hunk, req.Resolution, plural(remaining, "hunk"))
+	} else {
+		resp.Message = fmt.Sprintf("✅ Resolved the last conflict of '%s'. Build and test with run_go, then stage it with git add.", req.Path)
+	}
+	return resp, nil
+}
@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func TestResolveConflicts(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Gopher", "-c", "user.email=gopher@example.com", "-c", "merge.conflictStyle=diff3"}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		if out, err := cmd.CombinedOutput(); err != nil && args[0] != "merge" {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	// Both branches change the limit and add a function at the end of the
+	// file, so the merge stops on two hunks.
+	base := "package shop\n\nconst limit = 10\n\nfunc Open() {}\n"
+	writeFiles(t, dir, map[string]string{"shop.go": base})
+	gitRun("init", "-q", "-b", "main")
+	gitRun("add", ".")
+	gitRun("commit", "-q", "-m", "initial")
+	gitRun("checkout", "-q", "-b", "feature")
+	writeFiles(t, dir, map[string]string{"shop.go": strings.Replace(base, "10", "20", 1) + "\nfunc Close() {}\n"})
+	gitRun("commit", "-q", "-am", "feature")
+	gitRun("checkout", "-q", "main")
+	writeFiles(t, dir, map[string]string{"shop.go": strings.Replace(base, "10", "15", 1) + "\nfunc Flush() {}\n"})
+	gitRun("commit", "-q", "-am", "main")
+	gitRun("merge", "feature")
+
+	ctx := WithWorkspace(context.Background(), dir)
+	list, err := NewListConflictsTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolve, err := NewResolveConflictTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := callTool[json.RawMessage, ListConflictsResponse](t, ctx, list, json.RawMessage(`{}`))
+	if listed.Error != "" || listed.Operation != "merge" || len(listed.Files) != 1 {
+		t.Fatalf("list_conflicts = %+v, want shop.go conflicted by a merge", listed)
+	}
+	file := listed.Files[0]
+	if file.Path != "shop.go" || file.Conflicts != 2 || len(file.Hunks) != 2 {
+		t.Fatalf("file = %+v, want two hunks", file)
+	}
+	h := file.Hunks[0]
+	if h.OursLabel != "HEAD" || h.TheirsLabel != "feature" || h.Ours != "const limit = 15\n" || h.Theirs != "const limit = 20\n" ||
+		h.Base != "const limit = 10\n" || h.Before != "package shop\n\n" {
+		t.Errorf("hunk 1 = %+v", h)
+	}
+
+	// A Go file must parse once its last conflict is resolved.
+	resolved := callTool[json.RawMessage, ResolveConflictResponse](t, ctx, resolve, json.RawMessage(`{"path":"shop.go","hunk":1,"resolution":"custom","code":"const limit = 25"}`))
+	if resolved.Error != "" || resolved.Remaining != 1 {
+		t.Fatalf("resolve_conflict = %+v, want one hunk left", resolved)
+	}
+	resolved = callTool[json.RawMessage, ResolveConflictResponse](t, ctx, resolve, json.RawMessage(`{"path":"shop.go","hunk":1,"resolution":"custom","code":"func Flush() {\n<<<<<<< HEAD\n}"}`))
+	if !strings.Contains(resolved.Error, "conflict markers") {
+		t.Errorf("code with markers = %+v, want it refused", resolved)
+	}
+	resolved = callTool[json.RawMessage, ResolveConflictResponse](t, ctx, resolve, json.RawMessage(`{"path":"shop.go","hunk":1,"resolution":"custom","code":"func Flush() {"}`))
+	if !strings.Contains(resolved.Error, "does not parse") {
+		t.Errorf("broken resolution = %+v, want it refused", resolved)
+	}
+	resolved = callTool[json.RawMessage, ResolveConflictResponse](t, ctx, resolve, json.RawMessage(`{"path":"shop.go","hunk":2,"resolution":"ours"}`))
+	if !strings.Contains(resolved.Error, "hunk 2 does not exist") {
+		t.Errorf("resolve_conflict = %+v, want an unknown hunk", resolved)
+	}
+	resolved = callTool[json.RawMessage, ResolveConflictResponse](t, ctx, resolve, json.RawMessage(`{"path":"shop.go","hunk":1,"resolution":"both"}`))
+	if resolved.Error != "" || resolved.Remaining != 0 {
+		t.Fatalf("resolve_conflict = %+v, want the file resolved", resolved)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "shop.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "package shop\n\nconst limit = 25\n\nfunc Open() {}\n\nfunc Flush() {}\n\nfunc Close() {}\n"; string(got) != want {
+		t.Errorf("shop.go =\n%s\nwant\n%s", got, want)
+	}
+	listed = callTool[json.RawMessage, ListConflictsResponse](t, ctx, list, json.RawMessage(`{"path":"shop.go"}`))
+	if len(listed.Files) != 1 || listed.Files[0].Conflicts != 0 || !strings.Contains(listed.Files[0].Note, "git add") {
+		t.Errorf("list_conflicts after resolving = %+v, want shop.go left to stage", listed)
+	}
+
+	readOnly, err := NewResolveConflictTool(WithReadOnly(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readOnly.(tool.InvokableTool).InvokableRun(ctx, `{"path":"shop.go","hunk":1,"resolution":"ours"}`); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("read-only resolve_conflict = %v, want ErrReadOnly", err)
+	}
+}
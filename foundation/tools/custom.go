@@ -0,0 +1,363 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults for custom tools that do not set their own.
+const (
+	defaultCustomTimeout = 30 * time.Second
+	maxCustomBodyBytes   = 1 << 20
+)
+
+// CustomToolsFile is the layout of the YAML file custom tools are declared
+// in; see tools.example.yaml.
+type CustomToolsFile struct {
+	Tools []CustomToolSpec `yaml:"tools"`
+}
+
+// CustomToolSpec declares one tool. Exactly one of Command and HTTP is set.
+// Every argument of Command, and the URL, headers and body of HTTP, are Go
+// templates executed with the call's arguments, such as {{.path}}, and the
+// functions json, urlquery and env.
+type CustomToolSpec struct {
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Parameters  map[string]any `yaml:"parameters"` // JSON schema of the arguments, an object.
+	ReadOnly    bool           `yaml:"read_only"`  // Safe to offer in read-only mode.
+	Timeout     time.Duration  `yaml:"timeout"`    // Defaults to 30s.
+
+	// Command is the program and its arguments. It is run directly, not
+	// through a shell, so arguments cannot inject commands; an argument that
+	// renders empty is left out.
+	Command []string        `yaml:"command"`
+	HTTP    *CustomHTTPSpec `yaml:"http"`
+}
+
+// CustomHTTPSpec is the request an HTTP custom tool sends.
+type CustomHTTPSpec struct {
+	Method  string            `yaml:"method"` // Defaults to GET.
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+}
+
+type CustomCommandResponse struct {
+	Output   string `json:"output,omitempty"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+type CustomHTTPResponse struct {
+	Status int    `json:"status,omitempty"`
+	Body   string `json:"body,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+var customToolName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// LoadCustomTools reads the custom tools declared in the YAML file at path.
+// A missing file declares none. In read-only mode (see WithReadOnly) only
+// the tools marked read_only are returned.
+func LoadCustomTools(ctx context.Context, path string) ([]tool.BaseTool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom tools %s: %w", path, err)
+	}
+	var file CustomToolsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse custom tools %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	var tools []tool.BaseTool
+	for i, spec := range file.Tools {
+		if seen[spec.Name] {
+			return nil, fmt.Errorf("custom tools %s: tool %q is declared twice", path, spec.Name)
+		}
+		seen[spec.Name] = true
+		t, err := NewCustomTool(spec)
+		if err != nil {
+			return nil, fmt.Errorf("custom tools %s: tool %d: %w", path, i+1, err)
+		}
+		if ReadOnly(ctx) && !spec.ReadOnly {
+			continue
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// NewCustomTool returns the tool spec declares.
+func NewCustomTool(spec CustomToolSpec) (tool.BaseTool, error) {
+	if !customToolName.MatchString(spec.Name) {
+		return nil, fmt.Errorf("invalid name %q: use up to 64 letters, digits, '_' or '-'", spec.Name)
+	}
+	if strings.TrimSpace(spec.Description) == "" {
+		return nil, fmt.Errorf("%s: description cannot be empty", spec.Name)
+	}
+	if (len(spec.Command) > 0) == (spec.HTTP != nil) {
+		return nil, fmt.Errorf("%s: set exactly one of command and http", spec.Name)
+	}
+	if spec.Timeout < 0 {
+		return nil, fmt.Errorf("%s: timeout cannot be negative", spec.Name)
+	}
+	if spec.Timeout == 0 {
+		spec.Timeout = defaultCustomTimeout
+	}
+
+	params := spec.Parameters
+	if params == nil {
+		params = map[string]any{"type": "object"}
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid parameters: %w", spec.Name, err)
+	}
+	var js jsonschema.Schema
+	if err := json.Unmarshal(raw, &js); err != nil {
+		return nil, fmt.Errorf("%s: invalid parameters: %w", spec.Name, err)
+	}
+	if js.Type != "object" {
+		return nil, fmt.Errorf("%s: parameters must be a schema of type object", spec.Name)
+	}
+	var declared []string
+	if js.Properties != nil {
+		for pair := js.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			declared = append(declared, pair.Key)
+		}
+	}
+
+	t := &customTool{
+		spec:     spec,
+		declared: declared,
+		info: &schema.ToolInfo{
+			Name:        spec.Name,
+			Desc:        spec.Description,
+			ParamsOneOf: schema.NewParamsOneOfByJSONSchema(&js),
+		},
+	}
+	parse := func(field, text string) (*template.Template, error) {
+		tmpl, err := template.New(field).Option("missingkey=error").Funcs(customTemplateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid template in %s: %w", spec.Name, field, err)
+		}
+		return tmpl, nil
+	}
+	if spec.HTTP != nil {
+		if spec.HTTP.URL == "" {
+			return nil, fmt.Errorf("%s: http.url cannot be empty", spec.Name)
+		}
+		if t.url, err = parse("http.url", spec.HTTP.URL); err != nil {
+			return nil, err
+		}
+		if t.body, err = parse("http.body", spec.HTTP.Body); err != nil {
+			return nil, err
+		}
+		t.headers = make(map[string]*template.Template, len(spec.HTTP.Headers))
+		for name, value := range spec.HTTP.Headers {
+			if t.headers[name], err = parse("http.headers."+name, value); err != nil {
+				return nil, err
+			}
+		}
+		t.httpClient = &http.Client{Timeout: spec.Timeout}
+		return t, nil
+	}
+	for i, arg := range spec.Command {
+		tmpl, err := parse(fmt.Sprintf("command[%d]", i), arg)
+		if err != nil {
+			return nil, err
+		}
+		t.command = append(t.command, tmpl)
+	}
+	return t, nil
+}
+
+var customTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		var b strings.Builder
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(b.String(), "\n"), nil
+	},
+	"env": os.Getenv,
+}
+
+// customTool runs a declared command or HTTP request.
+type customTool struct {
+	spec     CustomToolSpec
+	info     *schema.ToolInfo
+	declared []string // Parameters of the schema, so templates can use optional ones.
+
+	command []*template.Template
+
+	url        *template.Template
+	body       *template.Template
+	headers    map[string]*template.Template
+	httpClient *http.Client
+}
+
+func (t *customTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+// ReadOnly reports whether the tool was declared read_only.
+func (t *customTool) ReadOnly() bool {
+	return t.spec.ReadOnly
+}
+
+// InvokableRun renders the templates with the call's arguments and runs the
+// command or sends the request. Like the built-in tools, failures go to the
+// model in an "error" field.
+func (t *customTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	args := make(map[string]any)
+	if strings.TrimSpace(argumentsInJSON) != "" {
+		if err := json.Unmarshal([]byte(argumentsInJSON), &args); err != nil {
+			return marshalResponse(map[string]string{"error": fmt.Sprintf("invalid arguments: %v", err)})
+		}
+	}
+	// Optional parameters the model left out render as empty strings.
+	for _, name := range t.declared {
+		if _, ok := args[name]; !ok {
+			args[name] = ""
+		}
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, t.spec.Timeout)
+	defer cancel()
+	if t.spec.HTTP != nil {
+		resp := t.send(runCtx, args)
+		if err := canceled(ctx); err != nil {
+			return "", err
+		}
+		return marshalResponse(resp)
+	}
+	resp := t.run(runCtx, args)
+	if err := canceled(ctx); err != nil {
+		return "", err
+	}
+	return marshalResponse(resp)
+}
+
+func (t *customTool) run(ctx context.Context, args map[string]any) *CustomCommandResponse {
+	var argv []string
+	for _, tmpl := range t.command {
+		arg, err := renderCustom(tmpl, args)
+		if err != nil {
+			return &CustomCommandResponse{Error: err.Error()}
+		}
+		if arg != "" {
+			argv = append(argv, arg)
+		}
+	}
+	if len(argv) == 0 {
+		return &CustomCommandResponse{Error: "the command rendered empty"}
+	}
+	dir := Workspace(ctx)
+	if dir == "" {
+		dir = "."
+	}
+	output, err := runCommand(ctx, dir, argv[0], argv[1:]...)
+	resp := &CustomCommandResponse{Output: shortenOutput(output)}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		resp.Error = fmt.Sprintf("%s did not finish within %s", t.spec.Name, t.spec.Timeout)
+	case errors.As(err, &exitErr):
+		resp.ExitCode = exitErr.ExitCode()
+		resp.Error = fmt.Sprintf("%s exited with status %d", argv[0], resp.ExitCode)
+	case err != nil:
+		resp.Error = fmt.Sprintf("failed to run %s: %v", argv[0], err)
+	}
+	return resp
+}
+
+func (t *customTool) send(ctx context.Context, args map[string]any) *CustomHTTPResponse {
+	url, err := renderCustom(t.url, args)
+	if err != nil {
+		return &CustomHTTPResponse{Error: err.Error()}
+	}
+	body, err := renderCustom(t.body, args)
+	if err != nil {
+		return &CustomHTTPResponse{Error: err.Error()}
+	}
+	method := strings.ToUpper(t.spec.HTTP.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return &CustomHTTPResponse{Error: fmt.Sprintf("invalid request: %v", err)}
+	}
+	for name, tmpl := range t.headers {
+		value, err := renderCustom(tmpl, args)
+		if err != nil {
+			return &CustomHTTPResponse{Error: err.Error()}
+		}
+		req.Header.Set(name, value)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return &CustomHTTPResponse{Error: fmt.Sprintf("request failed: %v", err)}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCustomBodyBytes))
+	if err != nil {
+		return &CustomHTTPResponse{Status: resp.StatusCode, Error: fmt.Sprintf("failed to read the response: %v", err)}
+	}
+	out := &CustomHTTPResponse{Status: resp.StatusCode, Body: strings.ToValidUTF8(string(bytes.TrimSpace(data)), "")}
+	if resp.StatusCode/100 != 2 {
+		out.Error = fmt.Sprintf("%s %s returned status %d", method, req.URL.Redacted(), resp.StatusCode)
+	}
+	return out
+}
+
+// renderCustom executes tmpl with args.
+func renderCustom(tmpl *template.Template, args map[string]any) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, args); err != nil {
+		return "", fmt.Errorf("failed to fill in %s: %v", tmpl.Name(), err)
+	}
+	return b.String(), nil
+}
+
+func marshalResponse(resp any) (string, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+var (
+	_ tool.InvokableTool = (*customTool)(nil)
+	_ ReadOnlyTool       = (*customTool)(nil)
+)
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func TestLoadCustomTools(t *testing.T) {
+	var got struct {
+		path, auth, body string
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path = r.URL.RequestURI()
+		got.auth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		got.body = string(body)
+		if strings.Contains(got.body, "unknown") {
+			http.Error(w, "no such talk", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"room":"B"}`))
+	}))
+	defer srv.Close()
+	t.Setenv("ROOMS_TOKEN", "secret")
+
+	file := filepath.Join(t.TempDir(), "tools.yaml")
+	os.WriteFile(file, []byte(`
+tools:
+  - name: greet
+    description: Print a greeting.
+    read_only: true
+    parameters:
+      type: object
+      properties:
+        name: {type: string}
+        loud: {type: string, description: Pass "-e" to interpret escapes.}
+      required: [name]
+    command: ["echo", "{{.loud}}", "hello, {{.name}}"]
+  - name: find_room
+    description: Find the room of a talk.
+    timeout: 5s
+    parameters:
+      type: object
+      properties:
+        talk: {type: string}
+    http:
+      method: post
+      url: `+srv.URL+`/rooms?q={{urlquery .talk}}
+      headers:
+        Authorization: Bearer {{env "ROOMS_TOKEN"}}
+      body: '{"talk":{{json .talk}}}'
+`), 0o644)
+
+	loaded, err := LoadCustomTools(context.Background(), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("loaded %d tools, want 2", len(loaded))
+	}
+	run := func(bt tool.BaseTool, args string) map[string]any {
+		t.Helper()
+		return callTool[json.RawMessage, map[string]any](t, context.Background(), bt, json.RawMessage(args))
+	}
+
+	info, _ := loaded[0].Info(context.Background())
+	if info.Name != "greet" || info.Desc != "Print a greeting." {
+		t.Errorf("info = %+v", info)
+	}
+	// The optional flag renders empty and is left out; the name stays one
+	// argument, shell syntax and all.
+	if resp := run(loaded[0], `{"name":"Ada; rm -rf /"}`); resp["output"] != "hello, Ada; rm -rf /" || resp["error"] != nil {
+		t.Errorf("greet = %v", resp)
+	}
+
+	if resp := run(loaded[1], `{"talk":"Eino & graphs"}`); resp["body"] != `{"room":"B"}` || resp["status"] != 200.0 {
+		t.Errorf("find_room = %v", resp)
+	}
+	if got.path != "/rooms?q=Eino+%26+graphs" || got.auth != "Bearer secret" || got.body != `{"talk":"Eino & graphs"}` {
+		t.Errorf("server received %+v", got)
+	}
+	if resp := run(loaded[1], `{"talk":"unknown"}`); resp["status"] != 404.0 || !strings.Contains(resp["error"].(string), "status 404") {
+		t.Errorf("find_room of an unknown talk = %v", resp)
+	}
+
+	// Read-only mode keeps the tools marked read_only.
+	if loaded, err := LoadCustomTools(WithReadOnly(context.Background()), file); err != nil || len(loaded) != 1 {
+		t.Errorf("read-only mode loaded %d tools, %v", len(loaded), err)
+	}
+	if loaded, err := LoadCustomTools(context.Background(), filepath.Join(t.TempDir(), "missing.yaml")); err != nil || loaded != nil {
+		t.Errorf("missing file: %v, %v", loaded, err)
+	}
+}
+
+func TestNewCustomToolValidates(t *testing.T) {
+	for name, spec := range map[string]CustomToolSpec{
+		"bad name":       {Name: "find room", Description: "d", Command: []string{"true"}},
+		"no description": {Name: "x", Command: []string{"true"}},
+		"both":           {Name: "x", Description: "d", Command: []string{"true"}, HTTP: &CustomHTTPSpec{URL: "http://localhost"}},
+		"neither":        {Name: "x", Description: "d"},
+		"bad template":   {Name: "x", Description: "d", Command: []string{"echo", "{{.name"}},
+		"not an object":  {Name: "x", Description: "d", Command: []string{"true"}, Parameters: map[string]any{"type": "string"}},
+		"no url":         {Name: "x", Description: "d", HTTP: &CustomHTTPSpec{}},
+	} {
+		if _, err := NewCustomTool(spec); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
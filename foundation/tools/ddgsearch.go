@@ -2,80 +2,119 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/olusolaa/goforai/foundation/config"
+	"golang.org/x/net/html"
+	"golang.org/x/time/rate"
 )
 
 type DuckDuckGoSearchRequest struct {
-	Query string `json:"query" jsonschema:"description=The search query to find information on the internet"`
+	Query    string `json:"query" jsonschema:"description=The search query to find information on the internet"`
+	Page     int    `json:"page,omitempty" jsonschema:"description=Page of results to return, starting at 1. Ask for page 2 only when the first page did not help."`
+	Site     string `json:"site,omitempty" jsonschema:"description=Only return results from this domain, e.g. github.com."`
+	Recency  string `json:"recency,omitempty" jsonschema:"description=Only return results from the last 'day', 'week' or 'month'."`
+	Category string `json:"category,omitempty" jsonschema:"description=Kind of results: 'news' for news articles, 'code' for code hosting and Q&A sites. Omit for a general search."`
 }
 
 type DuckDuckGoSearchResponse struct {
-	Results string `json:"results" jsonschema:"description=Search results from the internet with sources"`
+	Results string        `json:"results" jsonschema:"description=Search results from the internet with sources"`
+	Pages   []PageContent `json:"pages,omitempty" jsonschema:"description=Text of the top result pages."`
 }
 
-func NewDuckDuckGoSearchTool(ctx context.Context) (tool.BaseTool, error) {
-	return utils.InferTool(
+// Limits of the DuckDuckGo tool.
+const (
+	ddgMaxResults = 10 // Results reported per page.
+	ddgMaxPage    = 5
+	ddgAttempts   = 3 // Tries per page when DuckDuckGo throttles us.
+)
+
+// duckDuckGo scrapes the DuckDuckGo HTML endpoint. Requests go through a
+// rate limiter, and throttled responses (202 or an anomaly page) are retried
+// with exponential backoff.
+type duckDuckGo struct {
+	baseURL string
+	client  *http.Client
+	limiter *rate.Limiter
+	backoff time.Duration // First retry delay; doubled on each retry.
+	pages   *pageFetcher  // Nil unless result pages are fetched.
+}
+
+// NewDuckDuckGoSearchTool returns the DuckDuckGo search_internet tool, which
+// needs no API key. searchConfig may be nil.
+func NewDuckDuckGoSearchTool(ctx context.Context, searchConfig *SearchConfig) (tool.BaseTool, error) {
+	ddg := &duckDuckGo{
+		baseURL: config.DuckDuckGoBaseURL(),
+		client:  &http.Client{Timeout: 20 * time.Second},
+		limiter: rate.NewLimiter(rate.Every(time.Second), 1),
+		backoff: 2 * time.Second,
+		pages:   newPageFetcher(searchConfig),
+	}
+	return ddg.tool()
+}
+
+func (d *duckDuckGo) tool() (tool.BaseTool, error) {
+	desc := "Search the internet for current information, news, GitHub repositories, and general knowledge. Use this for current events, recent news, or information not in the GopherCon knowledge base. Returns top search results with URLs. Use 'site' to search one domain, 'recency' for recent results and 'category' for news or code."
+	if d.pages != nil {
+		desc += " The text of the top result pages is included under 'pages', so there is no need to fetch them."
+	}
+	return inferTool(
 		"search_internet",
-		"Search the internet for current information, news, GitHub repositories, and general knowledge. Use this for current events, recent news, or information not in the GopherCon knowledge base. Returns top search results with URLs.",
+		desc,
 		func(ctx context.Context, req *DuckDuckGoSearchRequest) (*DuckDuckGoSearchResponse, error) {
-			return performDuckDuckGoSearch(ctx, req.Query)
+			filters, err := newSearchFilters(req.Site, req.Recency, req.Category)
+			if err != nil {
+				return nil, err
+			}
+			return d.search(ctx, req.Query, filters, req.Page)
 		},
 	)
 }
 
-func performDuckDuckGoSearch(ctx context.Context, query string) (*DuckDuckGoSearchResponse, error) {
-	// DuckDuckGo HTML search (no API key needed!)
-	searchURL := fmt.Sprintf("https://html.duckduckgo.com/html/?q=%s", url.QueryEscape(query))
+func (d *duckDuckGo) search(ctx context.Context, query string, filters searchFilters, page int) (*DuckDuckGoSearchResponse, error) {
+	page = min(max(page, 1), ddgMaxPage)
 
-	client := &http.Client{}
-	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// The first page is a GET; later pages submit the "Next" form of the
+	// page before, as a browser does.
+	form := url.Values{"q": {filters.duckDuckGoQuery(query)}}
+	if df := filters.duckDuckGoRecency(); df != "" {
+		form.Set("df", df)
 	}
-
-	// Set a user agent to avoid being blocked
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; GopherConBot/1.0)")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("search request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("search returned status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	var results []SearchResult
+	for p := 1; p <= page; p++ {
+		var next url.Values
+		var err error
+		results, next, err = d.fetch(ctx, form, p > 1)
+		if err != nil {
+			return nil, err
+		}
+		if p < page && next == nil {
+			return &DuckDuckGoSearchResponse{
+				Results: fmt.Sprintf("No more results for '%s': there are only %d page(s).", query, p),
+			}, nil
+		}
+		form = next
 	}
 
-	// Parse HTML results (simple extraction)
-	results := parseSearchResults(string(body))
-
 	if len(results) == 0 {
 		return &DuckDuckGoSearchResponse{
 			Results: fmt.Sprintf("No results found for: %s", query),
 		}, nil
 	}
 
-	// Format results
+	if len(results) > ddgMaxResults {
+		results = results[:ddgMaxResults]
+	}
 	var formattedResults strings.Builder
-	formattedResults.WriteString(fmt.Sprintf("Search results for '%s':\n\n", query))
-
+	formattedResults.WriteString(fmt.Sprintf("Search results for '%s' (page %d):\n\n", query, page))
 	for i, result := range results {
-		if i >= 5 { // Limit to top 5 results
-			break
-		}
 		formattedResults.WriteString(fmt.Sprintf("%d. %s\n", i+1, result.Title))
 		formattedResults.WriteString(fmt.Sprintf("   URL: %s\n", result.URL))
 		if result.Snippet != "" {
@@ -83,114 +122,228 @@ func performDuckDuckGoSearch(ctx context.Context, query string) (*DuckDuckGoSear
 		}
 		formattedResults.WriteString("\n")
 	}
-
-	return &DuckDuckGoSearchResponse{Results: formattedResults.String()}, nil
+	resp := &DuckDuckGoSearchResponse{Results: formattedResults.String()}
+	if d.pages != nil {
+		urls := make([]string, len(results))
+		for i, r := range results {
+			urls[i] = r.URL
+		}
+		resp.Pages = d.pages.fetch(ctx, urls)
+	}
+	return resp, nil
 }
 
-type SearchResult struct {
-	Title   string
-	URL     string
-	Snippet string
+// fetch returns the results of one page and the form that requests the next
+// one, retrying when DuckDuckGo throttles the request.
+func (d *duckDuckGo) fetch(ctx context.Context, form url.Values, post bool) ([]SearchResult, url.Values, error) {
+	delay := d.backoff
+	for attempt := 1; ; attempt++ {
+		results, next, err := d.fetchOnce(ctx, form, post)
+		if !errors.Is(err, errThrottled) || attempt == ddgAttempts {
+			if errors.Is(err, errThrottled) {
+				return nil, nil, fmt.Errorf("DuckDuckGo is rate limiting requests; try again in a minute")
+			}
+			return results, next, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
 }
 
-func parseSearchResults(html string) []SearchResult {
-	var results []SearchResult
-
-	// Extract result divs (DuckDuckGo HTML structure)
-	resultPattern := regexp.MustCompile(`<div class="result[^"]*">.*?</div>`)
-	resultDivs := resultPattern.FindAllString(html, -1)
-
-	for _, div := range resultDivs {
-		result := SearchResult{}
+// errThrottled marks a response DuckDuckGo sends instead of results when it
+// suspects automated traffic.
+var errThrottled = errors.New("throttled by DuckDuckGo")
 
-		// Extract title
-		titlePattern := regexp.MustCompile(`<a class="result__a" href="[^"]*">([^<]+)</a>`)
-		if matches := titlePattern.FindStringSubmatch(div); len(matches) > 1 {
-			result.Title = cleanText(matches[1])
-		}
+func (d *duckDuckGo) fetchOnce(ctx context.Context, form url.Values, post bool) ([]SearchResult, url.Values, error) {
+	if err := d.limiter.Wait(ctx); err != nil {
+		return nil, nil, err
+	}
 
-		// Extract URL
-		urlPattern := regexp.MustCompile(`<a class="result__url" href="([^"]+)"`)
-		if matches := urlPattern.FindStringSubmatch(div); len(matches) > 1 {
-			result.URL = cleanText(matches[1])
+	var req *http.Request
+	var err error
+	if post {
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, d.baseURL+"/html/", strings.NewReader(form.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, d.baseURL+"/html/?"+form.Encode(), nil)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Set a user agent to avoid being blocked
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; GopherConBot/1.0)")
 
-		// Extract snippet
-		snippetPattern := regexp.MustCompile(`<a class="result__snippet"[^>]*>([^<]+)</a>`)
-		if matches := snippetPattern.FindStringSubmatch(div); len(matches) > 1 {
-			result.Snippet = cleanText(matches[1])
-		}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("search request failed: %w", err)
+	}
+	defer resp.Body.Close()
 
-		// Only add if we have at least a title and URL
-		if result.Title != "" && result.URL != "" {
-			results = append(results, result)
-		}
+	switch {
+	case resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusTooManyRequests:
+		return nil, nil, errThrottled
+	case resp.StatusCode != http.StatusOK:
+		return nil, nil, fmt.Errorf("search returned status %d", resp.StatusCode)
 	}
 
-	// Fallback: try alternative parsing if no results
-	if len(results) == 0 {
-		results = parseAlternativeFormat(html)
+	doc, err := html.Parse(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse results: %w", err)
 	}
+	if findNode(doc, func(n *html.Node) bool { return hasClass(n, "anomaly-modal__modal") }) != nil {
+		return nil, nil, errThrottled
+	}
+	results, next := parseSearchResults(doc)
+	return results, next, nil
+}
 
-	return results
+type SearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
 }
 
-func parseAlternativeFormat(html string) []SearchResult {
+// parseSearchResults extracts the organic results of a DuckDuckGo HTML page,
+// skipping ads, and the form fields that request the next page (nil on the
+// last page).
+func parseSearchResults(doc *html.Node) ([]SearchResult, url.Values) {
 	var results []SearchResult
-
-	// Alternative pattern for links
-	linkPattern := regexp.MustCompile(`<a[^>]+href="([^"]+)"[^>]*>([^<]+)</a>`)
-	matches := linkPattern.FindAllStringSubmatch(html, -1)
-
 	seen := make(map[string]bool)
-	for _, match := range matches {
-		if len(match) < 3 {
-			continue
+	walk(doc, func(n *html.Node) bool {
+		if !hasClass(n, "result") {
+			return true
 		}
-
-		url := match[1]
-		title := cleanText(match[2])
-
-		// Skip internal DuckDuckGo links
-		if strings.Contains(url, "duckduckgo.com") {
-			continue
+		if hasClass(n, "result--ad") {
+			return false
 		}
-
-		// Skip duplicates
-		if seen[url] {
-			continue
+		var result SearchResult
+		if a := findNode(n, func(n *html.Node) bool { return hasClass(n, "result__a") }); a != nil {
+			result.Title = textContent(a)
+			result.URL = resolveRedirect(attr(a, "href"))
 		}
-
-		// Must be a real URL
-		if !strings.HasPrefix(url, "http") {
-			continue
+		if s := findNode(n, func(n *html.Node) bool { return hasClass(n, "result__snippet") }); s != nil {
+			result.Snippet = textContent(s)
+		}
+		if result.Title != "" && result.URL != "" && !seen[result.URL] {
+			seen[result.URL] = true
+			results = append(results, result)
 		}
+		return false
+	})
+	return results, nextPage(doc)
+}
 
-		seen[url] = true
-		results = append(results, SearchResult{
-			Title: title,
-			URL:   url,
+// nextPage returns the fields of the "Next" form of a results page.
+func nextPage(doc *html.Node) url.Values {
+	var next url.Values
+	walk(doc, func(n *html.Node) bool {
+		if next != nil {
+			return false
+		}
+		if n.Type != html.ElementNode || n.Data != "form" {
+			return true
+		}
+		isNext := findNode(n, func(n *html.Node) bool {
+			return n.Data == "input" && attr(n, "type") == "submit" && strings.EqualFold(attr(n, "value"), "Next")
+		}) != nil
+		if !isNext {
+			return false
+		}
+		fields := url.Values{}
+		walk(n, func(n *html.Node) bool {
+			if n.Type == html.ElementNode && n.Data == "input" && attr(n, "type") == "hidden" && attr(n, "name") != "" {
+				fields.Add(attr(n, "name"), attr(n, "value"))
+			}
+			return true
 		})
+		next = fields
+		return false
+	})
+	return next
+}
 
-		if len(results) >= 10 {
-			break
+// resolveRedirect turns DuckDuckGo's click-tracking links
+// (//duckduckgo.com/l/?uddg=<url>&rut=...) into the result's own URL.
+// Ad redirects and relative links resolve to "".
+func resolveRedirect(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if strings.HasSuffix(u.Host, "duckduckgo.com") || u.Host == "" {
+		if u.Path != "/l/" {
+			return ""
+		}
+		target := u.Query().Get("uddg")
+		if t, err := url.Parse(target); err != nil || (t.Scheme != "http" && t.Scheme != "https") {
+			return ""
 		}
+		return target
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
 	}
+	return href
+}
 
-	return results
+// walk visits n and its descendants in document order; visit returns false
+// to skip a node's children.
+func walk(n *html.Node, visit func(*html.Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, visit)
+	}
 }
 
-func cleanText(s string) string {
-	// Remove HTML entities
-	s = strings.ReplaceAll(s, "&amp;", "&")
-	s = strings.ReplaceAll(s, "&lt;", "<")
-	s = strings.ReplaceAll(s, "&gt;", ">")
-	s = strings.ReplaceAll(s, "&quot;", "\"")
-	s = strings.ReplaceAll(s, "&#39;", "'")
-	s = strings.ReplaceAll(s, "&nbsp;", " ")
+// findNode returns the first node under n, n included, that match accepts.
+func findNode(n *html.Node, match func(*html.Node) bool) *html.Node {
+	var found *html.Node
+	walk(n, func(n *html.Node) bool {
+		if found == nil && n.Type == html.ElementNode && match(n) {
+			found = n
+		}
+		return found == nil
+	})
+	return found
+}
 
-	// Trim whitespace
-	s = strings.TrimSpace(s)
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
 
-	return s
+func hasClass(n *html.Node, class string) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent returns the text under n with whitespace collapsed.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(n *html.Node) bool {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
 }
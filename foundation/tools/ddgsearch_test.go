@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// fakeDuckDuckGo serves the pages in testdata/ddg. The first request is
+// throttled with a 202, as DuckDuckGo does under load.
+func fakeDuckDuckGo(t *testing.T) (*duckDuckGo, *[]string) {
+	t.Helper()
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r.Method+" "+r.Form.Encode())
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		page := "testdata/ddg/page1.html"
+		if r.Method == http.MethodPost && r.Form.Get("s") == "10" && r.Form.Get("dc") == "11" {
+			page = "testdata/ddg/page2.html"
+		}
+		data, err := os.ReadFile(page)
+		if err != nil {
+			t.Error(err)
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return &duckDuckGo{
+		baseURL: srv.URL,
+		client:  srv.Client(),
+		limiter: rate.NewLimiter(rate.Inf, 1),
+		backoff: time.Millisecond,
+	}, &requests
+}
+
+func ddgSearch(t *testing.T, d *duckDuckGo, args string) string {
+	t.Helper()
+	bt, err := d.tool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callTool[json.RawMessage, DuckDuckGoSearchResponse](t, context.Background(), bt, json.RawMessage(args)).Results
+}
+
+func TestDuckDuckGoSearch(t *testing.T) {
+	d, requests := fakeDuckDuckGo(t)
+	got := ddgSearch(t, d, `{"query":"eino"}`)
+	want := `Search results for 'eino' (page 1):
+
+1. Eino: LLM application framework & tools
+   URL: https://github.com/cloudwego/eino
+   The ultimate LLM/AI application development framework in Golang.
+
+2. Eino docs
+   URL: https://www.cloudwego.io/docs/eino/
+
+`
+	if got != want {
+		t.Errorf("results\n got %q\nwant %q", got, want)
+	}
+	if len(*requests) != 2 {
+		t.Errorf("throttled request was not retried: %q", *requests)
+	}
+
+	got = ddgSearch(t, d, `{"query":"eino","page":2}`)
+	if !strings.Contains(got, "(page 2)") || !strings.Contains(got, "1. eino package\n   URL: https://pkg.go.dev/github.com/cloudwego/eino\n") {
+		t.Errorf("page 2 results:\n%s", got)
+	}
+	if last := (*requests)[len(*requests)-1]; last != "POST dc=11&q=eino&s=10" {
+		t.Errorf("page 2 was requested with %q, want the Next form", last)
+	}
+
+	if got := ddgSearch(t, d, `{"query":"eino","page":3}`); !strings.Contains(got, "there are only 2 page(s)") {
+		t.Errorf("page 3 results:\n%s", got)
+	}
+}
+
+func TestDuckDuckGoGivesUpWhenThrottled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	d := &duckDuckGo{baseURL: srv.URL, client: srv.Client(), limiter: rate.NewLimiter(rate.Inf, 1), backoff: time.Millisecond}
+	if _, err := d.search(context.Background(), "eino", searchFilters{}, 1); err == nil || !strings.Contains(err.Error(), "rate limiting") {
+		t.Errorf("search = %v, want a rate limiting error", err)
+	}
+}
+
+func TestResolveRedirect(t *testing.T) {
+	for href, want := range map[string]string{
+		"//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2Fdoc%3Fa%3D1&rut=x": "https://go.dev/doc?a=1",
+		"/l/?uddg=http%3A%2F%2Fexample.com":                                 "http://example.com",
+		"https://example.com/page":                                          "https://example.com/page",
+		"https://duckduckgo.com/y.js?ad_domain=x":                           "",
+		"//duckduckgo.com/l/?uddg=javascript%3Aalert(1)":                    "",
+		"mailto:gopher@example.com":                                         "",
+	} {
+		if got := resolveRedirect(href); got != want {
+			t.Errorf("resolveRedirect(%q) = %q, want %q", href, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/format"
@@ -14,19 +15,18 @@ import (
 	"strings"
 
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/components/tool/utils"
 	"golang.org/x/tools/go/ast/astutil"
 )
 
 type EditFileRequest struct {
-	Path        string `json:"path" jsonschema:"description=Path to the Go file to edit."`
-	Operation   string `json:"operation" jsonschema:"description=Type of edit: 'add_import', 'remove_import', 'add_var', 'add_const', 'add_function', or 'replace_code_block'."`
+	Path        string `json:"path" jsonschema:"description=Path to the Go file to edit. For 'add_test_function': the file under test or its _test.go file."`
+	Operation   string `json:"operation" jsonschema:"description=Type of edit: 'add_import', 'remove_import', 'add_var', 'add_const', 'add_function', 'add_test_function', 'replace_code_block', or 'organize_imports'."`
 	ImportPath  string `json:"import_path,omitempty" jsonschema:"description=For 'add_import'/'remove_import': the import path (e.g., 'fmt')."`
 	ImportAlias string `json:"import_alias,omitempty" jsonschema:"description=For 'add_import': optional alias for the import."`
 	VarName     string `json:"var_name,omitempty" jsonschema:"description=For 'add_var'/'add_const': the variable/constant name."`
 	VarType     string `json:"var_type,omitempty" jsonschema:"description=For 'add_var'/'add_const': the type (e.g., 'string', 'error'). Optional if value is provided."`
 	VarValue    string `json:"var_value,omitempty" jsonschema:"description=For 'add_var'/'add_const': the value expression (e.g., '\"hello\"', 'errors.New(\"not found\")'). Optional."`
-	Code        string `json:"code,omitempty" jsonschema:"description=For 'add_function' or 'replace_code_block': The complete and syntactically valid Go code for the new block. IMPORTANT: For 'replace_code_block', this MUST be the full declaration (e.g., the entire function from 'func...' to the final '}', not just the changed lines)."`
+	Code        string `json:"code,omitempty" jsonschema:"description=For 'add_function', 'add_test_function' or 'replace_code_block': The complete and syntactically valid Go code for the new block. IMPORTANT: For 'replace_code_block', this MUST be the full declaration (e.g., the entire function from 'func...' to the final '}', not just the changed lines)."`
 	StartLine   *int   `json:"start_line,omitempty" jsonschema:"description=For 'replace_code_block': the first line number of the block to replace (1-indexed)."`
 	EndLine     *int   `json:"end_line,omitempty" jsonschema:"description=For 'replace_code_block': the last line number of the block to replace (inclusive)."`
 }
@@ -36,72 +36,128 @@ type EditFileResponse struct {
 	Error   string `json:"error,omitempty" jsonschema:"description=Error message if the operation failed."`
 }
 
+// NewEditFileTool returns the edit_go_file tool. With a context marked by
+// WithReadOnly every edit is refused with ErrReadOnly.
 func NewEditFileTool(ctx context.Context) (tool.BaseTool, error) {
-	return utils.InferTool(
+	readOnly := ReadOnly(ctx)
+	return inferTool(
 		"edit_go_file",
-		"Replaces a block of Go code in a file, identified by line numbers. CRITICAL: The 'code' parameter MUST be a complete, self-contained Go declaration (e.g., a full 'func', 'type', or 'var' block). Providing incomplete snippets (like just an 'if' or 'for' loop) WILL FAIL.",
+		"Replaces a block of Go code in a file, identified by line numbers. CRITICAL: The 'code' parameter MUST be a complete, self-contained Go declaration (e.g., a full 'func', 'type', or 'var' block). Providing incomplete snippets (like just an 'if' or 'for' loop) WILL FAIL. Code edits add the imports the new code needs and remove the ones it no longer uses; 'organize_imports' also sorts and groups them. The result warns when the file imports a module go.mod does not require yet. To write a test, use 'add_test_function' with the path of the file under test: the function goes into its _test.go file, which is created with the right package name if it does not exist.",
 		func(ctx context.Context, req *EditFileRequest) (*EditFileResponse, error) {
+			if readOnly {
+				return nil, ErrReadOnly
+			}
 			if req.Path == "" {
 				return &EditFileResponse{Error: "path cannot be empty"}, nil
 			}
-
-			content, perms, err := readFileWithPerms(req.Path)
+			path, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
 			if err != nil {
 				return &EditFileResponse{Error: err.Error()}, nil
 			}
 
-			var modifiedContent []byte
-			var message string
-			var isASTOperation bool
-
-			switch req.Operation {
-			case "add_import", "remove_import", "add_var", "add_const", "add_function":
-				isASTOperation = true
-			case "replace_code_block":
-				isASTOperation = false
-				modifiedContent, message, err = replaceCodeBlock(content, req.StartLine, req.EndLine, req.Code)
-			default:
-				return &EditFileResponse{
-					Error: fmt.Sprintf("unknown operation '%s'. Use: add_import, remove_import, add_var, add_const, add_function, replace_code_block", req.Operation),
-				}, nil
-			}
-
-			if isASTOperation {
-				modifiedContent, message, err = performASTOperation(req, content)
+			displayPath := req.Path
+			var content []byte
+			var perms os.FileMode
+			if req.Operation == "add_test_function" {
+				path, displayPath = testFilePath(path), testFilePath(req.Path)
+				content, perms, err = readTestFile(path)
+			} else {
+				content, perms, err = readFileWithPerms(path)
 			}
-
 			if err != nil {
 				return &EditFileResponse{Error: err.Error()}, nil
 			}
 
-			// Final safety check: ensure the generated code is still valid Go.
-			fset := token.NewFileSet()
-			_, err = parser.ParseFile(fset, "", modifiedContent, parser.ParseComments)
-			if err != nil {
-				return &EditFileResponse{
-					Error: fmt.Sprintf("internal error or invalid edit: generated code is syntactically invalid: %v", err),
-				}, nil
-			}
-
-			// Format the source code before writing.
-			formattedContent, err := format.Source(modifiedContent)
+			formattedContent, message, err := applyEdit(req, content, filepath.Dir(path))
 			if err != nil {
-				return &EditFileResponse{
-					Error: fmt.Sprintf("failed to gofmt generated code: %v", err),
-				}, nil
+				return &EditFileResponse{Error: err.Error()}, nil
 			}
 
-			if err := atomicWriteFile(req.Path, formattedContent, perms); err != nil {
+			if err := atomicWriteFile(path, formattedContent, perms); err != nil {
 				return &EditFileResponse{Error: fmt.Sprintf("failed to write file: %v", err)}, nil
 			}
 
 			return &EditFileResponse{
-				Message: fmt.Sprintf("✅ %s in %s", message, req.Path),
+				Message: fmt.Sprintf("✅ %s in %s", message, displayPath) + dependencyHint(undeclaredImports(ctx, filepath.Dir(path), formattedContent)),
 			}, nil
 		},
 	)
 }
 
+// applyEdit performs req on content, a file in dir, and returns the gofmt'd
+// result. It never returns code that does not parse, so a failed edit cannot
+// corrupt the file. Edits that add code then fix the imports, resolving
+// packages from the other files of dir and the standard library.
+func applyEdit(req *EditFileRequest, content []byte, dir string) ([]byte, string, error) {
+	var modifiedContent []byte
+	var message string
+	var err error
+
+	switch req.Operation {
+	case "add_import", "remove_import", "add_var", "add_const", "add_function", "add_test_function":
+		modifiedContent, message, err = performASTOperation(req, content)
+	case "replace_code_block":
+		modifiedContent, message, err = replaceCodeBlock(content, req.StartLine, req.EndLine, req.Code)
+	case "organize_imports":
+		var added, removed []string
+		modifiedContent, added, removed, err = organizeImports(content, dir, true)
+		message = "Organized imports" + importChanges(added, removed)
+	default:
+		return nil, "", fmt.Errorf("unknown operation '%s'. Use: add_import, remove_import, add_var, add_const, add_function, add_test_function, replace_code_block, organize_imports", req.Operation)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch req.Operation {
+	case "add_function", "add_test_function", "replace_code_block":
+		// The edit stands even if the imports cannot be fixed.
+		if fixed, added, removed, err := organizeImports(modifiedContent, dir, false); err == nil {
+			modifiedContent = fixed
+			message += importChanges(added, removed)
+		}
+	}
+
+	// Final safety check: ensure the generated code is still valid Go.
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, "", modifiedContent, parser.ParseComments); err != nil {
+		return nil, "", fmt.Errorf("internal error or invalid edit: generated code is syntactically invalid: %v", err)
+	}
+
+	// Format the source code before writing.
+	formattedContent, err := format.Source(modifiedContent)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to gofmt generated code: %v", err)
+	}
+	return formattedContent, message, nil
+}
+
+// importChanges describes the imports organizeImports added and removed.
+func importChanges(added, removed []string) string {
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "added import "+quoteAll(added))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "removed import "+quoteAll(removed))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, "; ") + ")"
+}
+
+func quoteAll(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = "'" + p + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
 // performASTOperation handles all edits that modify the Go Abstract Syntax Tree.
 func performASTOperation(req *EditFileRequest, content []byte) ([]byte, string, error) {
 	fset := token.NewFileSet()
@@ -123,7 +179,9 @@ func performASTOperation(req *EditFileRequest, content []byte) ([]byte, string,
 	case "add_const":
 		changed, message, err = addTopLevelDecl(file, req.VarName, req.VarType, req.VarValue, true)
 	case "add_function":
-		changed, message, err = addFunctionAST(file, req.Code)
+		changed, message, err = addFunctionAST(fset, file, req.Code)
+	case "add_test_function":
+		changed, message, err = addTestFunction(fset, file, req.Code)
 	}
 
 	if err != nil {
@@ -150,6 +208,9 @@ func addImport(fset *token.FileSet, file *ast.File, importPath, alias string) (b
 	if importPath == "" {
 		return false, "", fmt.Errorf("import_path cannot be empty")
 	}
+	if alias != "" && alias != "_" && alias != "." && !token.IsIdentifier(alias) {
+		return false, "", fmt.Errorf("import_alias '%s' is not a valid Go identifier", alias)
+	}
 	var changed bool
 	if alias != "" {
 		changed = astutil.AddNamedImport(fset, file, alias, importPath)
@@ -209,9 +270,17 @@ func addTopLevelDecl(file *ast.File, name, varType, value string, isConst bool)
 		}
 	}
 
+	if !token.IsIdentifier(name) {
+		return false, "", fmt.Errorf("var_name '%s' is not a valid Go identifier", name)
+	}
+
 	valueSpec := &ast.ValueSpec{Names: []*ast.Ident{ast.NewIdent(name)}}
 	if varType != "" {
-		valueSpec.Type = ast.NewIdent(varType)
+		typ, err := parseType(varType)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid var_type: %w", err)
+		}
+		valueSpec.Type = typ
 	}
 	if value != "" {
 		expr, err := parser.ParseExpr(value)
@@ -227,14 +296,35 @@ func addTopLevelDecl(file *ast.File, name, varType, value string, isConst bool)
 	return true, fmt.Sprintf("Added %s '%s'", keyword, name), nil
 }
 
-func addFunctionAST(file *ast.File, code string) (bool, string, error) {
+// parseType parses src as a single Go type.
+func parseType(src string) (ast.Expr, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\nvar _ "+src, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Decls) == 1 {
+		if gd, ok := file.Decls[0].(*ast.GenDecl); ok && len(gd.Specs) == 1 {
+			if vs, ok := gd.Specs[0].(*ast.ValueSpec); ok && vs.Type != nil && len(vs.Values) == 0 {
+				return vs.Type, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("'%s' is not a type", src)
+}
+
+func addFunctionAST(fset *token.FileSet, file *ast.File, code string) (bool, string, error) {
 	if code == "" {
 		return false, "", fmt.Errorf("code cannot be empty for add_function")
 	}
 
-	src := "package p;\n" + code
-	fsetFrag := token.NewFileSet()
-	fileFrag, err := parser.ParseFile(fsetFrag, "fragment.go", src, 0)
+	// Parse into the file's own FileSet: the fragment then sorts after the
+	// file, and the printer keeps the fragment's own line breaks. Padding
+	// puts the function below the file's last line, so the printer leaves a
+	// blank line before it instead of aligning it with a one-line function
+	// above.
+	padding := strings.Repeat("\n", fset.File(file.Package).LineCount()+1)
+	src := "package p;" + padding + code
+	fileFrag, err := parser.ParseFile(fset, "fragment.go", src, 0)
 	if err != nil {
 		return false, "", fmt.Errorf("invalid Go code provided for function: %w", err)
 	}
@@ -258,6 +348,66 @@ func addFunctionAST(file *ast.File, code string) (bool, string, error) {
 	return true, fmt.Sprintf("Added function '%s'", funcName), nil
 }
 
+// addTestFunction adds a test, benchmark, fuzz target, example or helper to
+// a _test.go file. Like every code edit, it is followed by fixing the
+// imports, which brings in "testing".
+func addTestFunction(fset *token.FileSet, file *ast.File, code string) (bool, string, error) {
+	changed, message, err := addFunctionAST(fset, file, code)
+	if err != nil || !changed {
+		return changed, message, err
+	}
+	return true, strings.Replace(message, "Added function", "Added test function", 1), nil
+}
+
+// testFilePath returns the _test.go file holding the tests of the Go file at
+// path; a _test.go path is returned as it is.
+func testFilePath(path string) string {
+	if strings.HasSuffix(path, "_test.go") {
+		return path
+	}
+	return strings.TrimSuffix(path, ".go") + "_test.go"
+}
+
+// readTestFile reads the _test.go file at path, or returns a new one in the
+// package of the other files of its directory when it does not exist yet.
+func readTestFile(path string) ([]byte, os.FileMode, error) {
+	content, perms, err := readFileWithPerms(path)
+	if !errors.Is(err, os.ErrNotExist) {
+		return content, perms, err
+	}
+	name, err := packageName(filepath.Dir(path))
+	if err != nil {
+		return nil, 0, err
+	}
+	return []byte("package " + name + "\n"), 0o644, nil
+}
+
+// packageName returns the name of the Go package in dir, preferring the
+// package under test over an external _test package.
+func packageName(dir string) (string, error) {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no Go files in '%s' to take the package name from", dir)
+	}
+	name := ""
+	for _, m := range matches {
+		file, err := parser.ParseFile(token.NewFileSet(), m, nil, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+		if !strings.HasSuffix(m, "_test.go") {
+			return file.Name.Name, nil
+		}
+		if name == "" {
+			name = file.Name.Name
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("no parsable Go files in '%s' to take the package name from", dir)
+	}
+	return name, nil
+}
+
 func replaceCodeBlock(content []byte, startLine, endLine *int, newText string) ([]byte, string, error) {
 	if startLine == nil || endLine == nil {
 		return nil, "", fmt.Errorf("start_line and end_line are required for replace_code_block")
@@ -290,8 +440,15 @@ func replaceCodeBlock(content []byte, startLine, endLine *int, newText string) (
 		newLines = append(newLines, lines[endIdx+1:]...)
 	}
 
+	// A range that starts or ends inside a declaration leaves half of it
+	// behind; report that here rather than as a generic parse failure.
+	modified := []byte(strings.Join(newLines, "\n"))
+	if _, err := parser.ParseFile(token.NewFileSet(), "", modified, parser.ParseComments); err != nil {
+		return nil, "", fmt.Errorf("lines %d-%d do not cover whole declarations; replacing them leaves the file invalid: %w", *startLine, *endLine, err)
+	}
+
 	msg := fmt.Sprintf("Replaced code block from line %d to %d", *startLine, *endLine)
-	return []byte(strings.Join(newLines, "\n")), msg, nil
+	return modified, msg, nil
 }
 
 // --- Robust File I/O Utilities ---
@@ -0,0 +1,357 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The fuzz targets drive replaceCodeBlock and performASTOperation directly,
+// below the parse check in applyEdit, and check that an edit either fails or
+// yields a file that parses and keeps every declaration it did not touch
+// byte-for-byte. Inputs that break this are saved by `go test -fuzz` under
+// testdata/fuzz and then run as regression cases by every plain `go test`.
+
+// editSeeds returns the Go files under testdata/editfile.
+func editSeeds(t testing.TB) map[string][]byte {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "editfile", "*.go"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no seed files found: %v", err)
+	}
+	seeds := make(map[string][]byte, len(paths))
+	for _, p := range paths {
+		src, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seeds[filepath.Base(p)] = src
+	}
+	return seeds
+}
+
+// checkEditResult fails unless out parses and is stable under gofmt.
+func checkEditResult(t *testing.T, req *EditFileRequest, out []byte) {
+	t.Helper()
+	if _, err := parser.ParseFile(token.NewFileSet(), "", out, parser.ParseComments); err != nil {
+		t.Fatalf("%s produced code that does not parse: %v\n%s", req.Operation, err, out)
+	}
+	formatted, err := format.Source(out)
+	if err != nil {
+		t.Fatalf("%s produced code gofmt rejects: %v\n%s", req.Operation, err, out)
+	}
+	if !bytes.Equal(formatted, out) {
+		t.Fatalf("%s produced code that is not gofmt'd:\n%s", req.Operation, out)
+	}
+}
+
+func parses(src []byte) bool {
+	_, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	return err == nil
+}
+
+// declSources returns the source text of each top-level declaration in src
+// other than imports, without doc comments, with its line range including
+// them. ok is false if src does not parse.
+func declSources(src []byte) (texts []string, lines [][2]int, ok bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, false
+	}
+	for _, decl := range file.Decls {
+		if gd, isGen := decl.(*ast.GenDecl); isGen && gd.Tok == token.IMPORT {
+			continue
+		}
+		start, end := declLines(fset, decl)
+		texts = append(texts, string(src[fset.Position(decl.Pos()).Offset:fset.Position(decl.End()).Offset]))
+		lines = append(lines, [2]int{start, end})
+	}
+	return texts, lines, true
+}
+
+func FuzzReplaceCodeBlock(f *testing.F) {
+	for _, src := range editSeeds(f) {
+		f.Add(string(src), 3, 3, "func added() {}")
+		f.Add(string(src), 1, 2, "type T struct{ A, B int }")
+		f.Add(string(src), 5, 9, "var x = map[string]int{\"a\": 1}")
+		f.Add(string(src), 2, 1, "func bad() {}")
+	}
+	f.Add("package p\n\nfunc f() {\n}\n", 3, 4, "func f() {\n\treturn\n}")
+	f.Add("package p\n\nfunc f() {\n}\n", 3, 3, "func g() {}")
+
+	f.Fuzz(func(t *testing.T, src string, start, end int, code string) {
+		before, lines, ok := declSources([]byte(src))
+		if !ok {
+			t.Skip()
+		}
+		out, _, err := replaceCodeBlock([]byte(src), &start, &end, code)
+		if err != nil {
+			return
+		}
+		after, _, ok := declSources(out)
+		if !ok {
+			t.Fatalf("lines %d-%d: replaceCodeBlock produced code that does not parse:\n%s", start, end, out)
+		}
+		left := make(map[string]int)
+		for _, text := range after {
+			left[text]++
+		}
+		for i, text := range before {
+			if lines[i][1] >= start && lines[i][0] <= end {
+				continue // replaced
+			}
+			if left[text] == 0 {
+				t.Fatalf("lines %d-%d: declaration outside the range was changed or lost:\n%s\n--- output:\n%s", start, end, text, out)
+			}
+			left[text]--
+		}
+	})
+}
+
+func FuzzASTOperations(f *testing.F) {
+	for _, src := range editSeeds(f) {
+		f.Add(string(src), uint8(0), "os", "", "")
+		f.Add(string(src), uint8(0), "net/http", "h", "")
+		f.Add(string(src), uint8(1), "fmt", "", "")
+		f.Add(string(src), uint8(2), "limit", "int", "10")
+		f.Add(string(src), uint8(3), "Name", "", `"goforai"`)
+		f.Add(string(src), uint8(4), "func Added(x int) int { return x * 2 }", "", "")
+		f.Add(string(src), uint8(4), "func (s *Server) Stop() {}", "", "")
+	}
+
+	ops := []string{"add_import", "remove_import", "add_var", "add_const", "add_function"}
+	f.Fuzz(func(t *testing.T, src string, op uint8, a, b, c string) {
+		before, _, ok := declSources([]byte(src))
+		if !ok {
+			t.Skip()
+		}
+		req := &EditFileRequest{Operation: ops[int(op)%len(ops)]}
+		switch req.Operation {
+		case "add_import", "remove_import":
+			req.ImportPath, req.ImportAlias = a, b
+		case "add_var", "add_const":
+			req.VarName, req.VarType, req.VarValue = a, b, c
+		case "add_function":
+			req.Code = a
+		}
+		out, _, err := performASTOperation(req, []byte(src))
+		if err != nil {
+			return
+		}
+		after, _, ok := declSources(out)
+		if !ok {
+			t.Fatalf("%s produced code that does not parse:\n%s", req.Operation, out)
+		}
+		// The printer reformats what it prints, so only gofmt'd input is
+		// expected to come back unchanged.
+		if formatted, err := format.Source([]byte(src)); err != nil || !bytes.Equal(formatted, []byte(src)) {
+			return
+		}
+		if len(after) < len(before) {
+			t.Fatalf("%s dropped declarations:\n%s", req.Operation, out)
+		}
+		for i, text := range before {
+			if after[i] != text {
+				t.Fatalf("%s changed an existing declaration:\n%s\n--- became:\n%s", req.Operation, text, after[i])
+			}
+		}
+	})
+}
+
+// TestReplaceCodeBlockIdentity checks that replacing any top-level
+// declaration with its own text leaves the (gofmt'd) file unchanged.
+func TestReplaceCodeBlockIdentity(t *testing.T) {
+	for name, src := range editSeeds(t) {
+		want, err := format.Source(src)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		lines := strings.Split(string(src), "\n")
+		for _, decl := range file.Decls {
+			start, end := declLines(fset, decl)
+			block := strings.Join(lines[start-1:end], "\n")
+			req := &EditFileRequest{Operation: "replace_code_block", StartLine: &start, EndLine: &end, Code: block}
+			got, _, err := applyEdit(req, src, "")
+			if err != nil {
+				t.Errorf("%s lines %d-%d: %v", name, start, end, err)
+				continue
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s lines %d-%d: identity replacement changed the file:\n%s", name, start, end, got)
+			}
+		}
+	}
+}
+
+// TestReplaceCodeBlockRandomRanges replaces random line ranges with random
+// declarations. Whatever the range, the edit must fail or yield valid Go.
+func TestReplaceCodeBlockRandomRanges(t *testing.T) {
+	blocks := []string{
+		"func added() {}",
+		"type Pair struct{ K, V string }",
+		"var ready = make(chan struct{})",
+		"const limit = 1 << 10",
+		"// orphan comment\nfunc commented() int { return 1 }",
+	}
+	rng := rand.New(rand.NewSource(1))
+	for name, src := range editSeeds(t) {
+		n := len(strings.Split(string(src), "\n"))
+		for i := 0; i < 500; i++ {
+			start, end := rng.Intn(n+2)-1, rng.Intn(n+2)-1
+			req := &EditFileRequest{
+				Operation: "replace_code_block",
+				StartLine: &start,
+				EndLine:   &end,
+				Code:      blocks[rng.Intn(len(blocks))],
+			}
+			out, _, err := applyEdit(req, src, "")
+			outOfBounds := start < 1 || end < start || end > n
+			switch {
+			case outOfBounds && err == nil:
+				t.Fatalf("%s lines %d-%d: expected an out-of-bounds error", name, start, end)
+			case err == nil:
+				checkEditResult(t, req, out)
+			}
+		}
+	}
+}
+
+// declLines returns the 1-based line range of decl, including its doc comment.
+func declLines(fset *token.FileSet, decl ast.Decl) (int, int) {
+	pos := decl.Pos()
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			pos = d.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			pos = d.Doc.Pos()
+		}
+	}
+	return fset.Position(pos).Line, fset.Position(decl.End()).Line
+}
+
+func TestAddTestFunction(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"store/store.go": "// Package store keeps widgets.\npackage store\n\nfunc Count() int { return 0 }\n",
+	})
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewEditFileTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit := func(req EditFileRequest) EditFileResponse {
+		t.Helper()
+		return callTool[EditFileRequest, EditFileResponse](t, ctx, bt, req)
+	}
+
+	resp := edit(EditFileRequest{Path: "store/store.go", Operation: "add_test_function", Code: "func TestCount(t *testing.T) {\n\tif Count() != 0 {\n\t\tt.Fatal(\"not empty\")\n\t}\n}"})
+	if resp.Error != "" || resp.Message != "✅ Added test function 'TestCount' (added import 'testing') in store/store_test.go" {
+		t.Fatalf("first test: %+v", resp)
+	}
+	resp = edit(EditFileRequest{Path: "store/store_test.go", Operation: "add_test_function", Code: "func ExampleCount() {}"})
+	if resp.Error != "" {
+		t.Fatalf("second test: %+v", resp)
+	}
+	resp = edit(EditFileRequest{Path: "store/store.go", Operation: "add_test_function", Code: "func TestCount(t *testing.T) {}"})
+	if resp.Error != "" || !strings.Contains(resp.Message, "already exists") {
+		t.Fatalf("duplicate test: %+v", resp)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "store", "store_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package store\n\nimport \"testing\"\n\nfunc TestCount(t *testing.T) {\n\tif Count() != 0 {\n\t\tt.Fatal(\"not empty\")\n\t}\n}\n\nfunc ExampleCount() {}\n"
+	if string(got) != want {
+		t.Errorf("store_test.go =\n%s\nwant\n%s", got, want)
+	}
+
+	if resp := edit(EditFileRequest{Path: "empty/x.go", Operation: "add_test_function", Code: "func TestX(t *testing.T) {}"}); resp.Error == "" {
+		t.Errorf("test in a directory without Go files: %+v", resp)
+	}
+}
+
+func TestOrganizeImports(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"git.go": "package repo\n\nimport (\n\tgit \"github.com/go-git/go-git/v5\"\n\tstr \"strings\"\n)\n\nvar _ = git.PlainOpen\nvar _ = str.ToUpper\n",
+	})
+	src := `package repo
+
+import (
+	"github.com/example/dep" // the dependency
+	"os"
+	"github.com/go-git/go-git/v5"
+	"fmt"
+)
+
+func Open(dir string) error {
+	_, err := git.PlainOpen(dir)
+	return fmt.Errorf("opening %s: %w", str.TrimSpace(dir), errors.Join(err, dep.Err))
+}
+`
+	req := &EditFileRequest{Operation: "organize_imports"}
+	got, msg, err := applyEdit(req, []byte(src), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package repo
+
+import (
+	"errors"
+	"fmt"
+	str "strings"
+
+	"github.com/example/dep" // the dependency
+	"github.com/go-git/go-git/v5"
+)
+
+func Open(dir string) error {
+	_, err := git.PlainOpen(dir)
+	return fmt.Errorf("opening %s: %w", str.TrimSpace(dir), errors.Join(err, dep.Err))
+}
+`
+	if string(got) != want {
+		t.Errorf("organize_imports =\n%s\nwant\n%s", got, want)
+	}
+	if msg != "Organized imports (added import 'errors', 'strings'; removed import 'os')" {
+		t.Errorf("message = %q", msg)
+	}
+
+	// Organizing organized imports changes nothing.
+	again, _, err := applyEdit(req, got, dir)
+	if err != nil || !bytes.Equal(again, got) {
+		t.Errorf("second organize_imports = %s, %v", again, err)
+	}
+
+	// A code edit fixes the imports it affects without regrouping them.
+	start, end := 12, 15
+	edit := &EditFileRequest{Operation: "replace_code_block", StartLine: &start, EndLine: &end, Code: "func Open(dir string) error {\n\treturn os.Chdir(dir)\n}"}
+	got, msg, err = applyEdit(edit, got, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "package repo\n\nimport (\n\t\"os\"\n)\n\nfunc Open(dir string) error {\n\treturn os.Chdir(dir)\n}\n"; string(got) != want {
+		t.Errorf("replace_code_block =\n%s\nwant\n%s", got, want)
+	}
+	if !strings.HasSuffix(msg, "(added import 'os'; removed import 'errors', 'fmt', 'strings', 'github.com/example/dep', 'github.com/go-git/go-git/v5')") {
+		t.Errorf("message = %q", msg)
+	}
+}
@@ -0,0 +1,290 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/secrets"
+)
+
+// maxEmailRecipients limits how many people one call can reach.
+const maxEmailRecipients = 10
+
+type SendEmailRequest struct {
+	To      []string `json:"to" jsonschema:"description=Recipient addresses, such as 'ada@example.com' or 'Ada Lovelace <ada@example.com>'. To and cc together take up to 10."`
+	Cc      []string `json:"cc,omitempty" jsonschema:"description=Addresses to copy."`
+	Subject string   `json:"subject" jsonschema:"description=Subject line."`
+	Body    string   `json:"body" jsonschema:"description=Plain-text body of the email."`
+}
+
+type SendEmailResponse struct {
+	Message string `json:"message,omitempty" jsonschema:"description=Confirmation that the email was sent."`
+	Error   string `json:"error,omitempty" jsonschema:"description=Error message if the email could not be sent."`
+}
+
+// emailMessage is a validated plain-text email.
+type emailMessage struct {
+	from    *mail.Address
+	to, cc  []*mail.Address
+	subject string
+	body    string
+}
+
+// emailSender delivers messages through a provider.
+type emailSender interface {
+	send(ctx context.Context, m *emailMessage) error
+}
+
+// NewSendEmailTool returns the send_email tool, which sends a plain-text
+// email through the provider emailConfig selects. Every email is shown to
+// the approver of the call's context (see WithApprover) and only sent once
+// they approve it.
+func NewSendEmailTool(ctx context.Context, emailConfig config.Email) (tool.BaseTool, error) {
+	from, err := mail.ParseAddress(emailConfig.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", emailConfig.From, err)
+	}
+	var sender emailSender
+	switch emailConfig.Provider {
+	case config.EmailSMTP:
+		s := &smtpSender{addr: emailConfig.SMTPAddr}
+		if emailConfig.SMTPUsername != "" {
+			password, err := secrets.Get(secrets.SMTPPassword)
+			if err != nil {
+				return nil, err
+			}
+			host, _, err := net.SplitHostPort(emailConfig.SMTPAddr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SMTP address %q: %w", emailConfig.SMTPAddr, err)
+			}
+			s.auth = smtp.PlainAuth("", emailConfig.SMTPUsername, password, host)
+		}
+		sender = s
+	case config.EmailSendGrid:
+		apiKey, err := secrets.Get(secrets.SendGridAPIKey)
+		if err != nil {
+			return nil, err
+		}
+		sender = &sendGridSender{
+			baseURL:    emailConfig.BaseURL,
+			apiKey:     apiKey,
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+		}
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", emailConfig.Provider)
+	}
+	return newSendEmailTool(from, sender)
+}
+
+func newSendEmailTool(from *mail.Address, sender emailSender) (tool.BaseTool, error) {
+	return inferTool(
+		"send_email",
+		"Send a plain-text email, such as a follow-up summarizing this session. The user sees the complete email and must approve it before it is sent; if they decline, ask what to change. Write the whole message yourself: the recipients get nothing but the subject and body.",
+		func(ctx context.Context, req *SendEmailRequest) (*SendEmailResponse, error) {
+			m, err := newEmailMessage(from, req)
+			if err != nil {
+				return &SendEmailResponse{Error: err.Error()}, nil
+			}
+			if err := approve(ctx, "send an email to "+m.recipientSummary(), m.preview()); err != nil {
+				return nil, err
+			}
+			if err := sender.send(ctx, m); err != nil {
+				if err := canceled(ctx); err != nil {
+					return nil, err
+				}
+				return &SendEmailResponse{Error: fmt.Sprintf("Failed to send the email: %v", err)}, nil
+			}
+			return &SendEmailResponse{Message: "Sent to " + m.recipientSummary() + "."}, nil
+		},
+	)
+}
+
+// newEmailMessage validates req.
+func newEmailMessage(from *mail.Address, req *SendEmailRequest) (*emailMessage, error) {
+	if len(req.To) == 0 {
+		return nil, errors.New("to cannot be empty")
+	}
+	if n := len(req.To) + len(req.Cc); n > maxEmailRecipients {
+		return nil, fmt.Errorf("too many recipients (%d); the limit is %d", n, maxEmailRecipients)
+	}
+	subject := strings.TrimSpace(req.Subject)
+	if subject == "" {
+		return nil, errors.New("subject cannot be empty")
+	}
+	if strings.ContainsAny(subject, "\r\n") {
+		return nil, errors.New("subject must be a single line")
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		return nil, errors.New("body cannot be empty")
+	}
+	m := &emailMessage{from: from, subject: subject, body: req.Body}
+	for _, list := range []struct {
+		in  []string
+		out *[]*mail.Address
+	}{{req.To, &m.to}, {req.Cc, &m.cc}} {
+		for _, s := range list.in {
+			addr, err := mail.ParseAddress(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %v", s, err)
+			}
+			*list.out = append(*list.out, addr)
+		}
+	}
+	return m, nil
+}
+
+// recipients returns the envelope addresses of everyone the message goes to.
+func (m *emailMessage) recipients() []string {
+	var rcpts []string
+	for _, a := range slices.Concat(m.to, m.cc) {
+		rcpts = append(rcpts, a.Address)
+	}
+	return rcpts
+}
+
+// recipientSummary names the first recipient and counts the others.
+func (m *emailMessage) recipientSummary() string {
+	rcpts := m.recipients()
+	switch len(rcpts) {
+	case 1:
+		return rcpts[0]
+	case 2:
+		return rcpts[0] + " and " + rcpts[1]
+	default:
+		return fmt.Sprintf("%s and %d others", rcpts[0], len(rcpts)-1)
+	}
+}
+
+// preview is the message as the approver sees it.
+func (m *emailMessage) preview() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\nTo: %s\n", m.from, joinAddresses(m.to))
+	if len(m.cc) > 0 {
+		fmt.Fprintf(&b, "Cc: %s\n", joinAddresses(m.cc))
+	}
+	fmt.Fprintf(&b, "Subject: %s\n\n%s", m.subject, m.body)
+	return b.String()
+}
+
+// bytes renders the message in RFC 5322 format with a quoted-printable UTF-8
+// body.
+func (m *emailMessage) bytes(date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", joinAddresses(m.to))
+	if len(m.cc) > 0 {
+		fmt.Fprintf(&b, "Cc: %s\r\n", joinAddresses(m.cc))
+	}
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(&b)
+	// In text mode the writer ends lines with CRLF.
+	w.Write([]byte(m.body))
+	w.Close()
+	return b.Bytes()
+}
+
+func joinAddresses(addrs []*mail.Address) string {
+	parts := make([]string, len(addrs))
+	for i, a := range addrs {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// smtpSender sends through an SMTP server, upgrading to TLS when the server
+// offers STARTTLS.
+type smtpSender struct {
+	addr string
+	auth smtp.Auth // Nil sends without authentication.
+}
+
+func (s *smtpSender) send(ctx context.Context, m *emailMessage) error {
+	return smtp.SendMail(s.addr, s.auth, m.from.Address, m.recipients(), m.bytes(time.Now()))
+}
+
+// sendGridSender sends through the SendGrid v3 mail API.
+type sendGridSender struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// sendGridAddress, sendGridPersonalization, sendGridContent and
+// sendGridMail mirror the body of POST /v3/mail/send.
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+	Cc []sendGridAddress `json:"cc,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+func (s *sendGridSender) send(ctx context.Context, m *emailMessage) error {
+	var p sendGridPersonalization
+	for _, a := range m.to {
+		p.To = append(p.To, sendGridAddress{a.Address, a.Name})
+	}
+	for _, a := range m.cc {
+		p.Cc = append(p.Cc, sendGridAddress{a.Address, a.Name})
+	}
+	body := sendGridMail{
+		Personalizations: []sendGridPersonalization{p},
+		From:             sendGridAddress{m.from.Address, m.from.Name},
+		Subject:          m.subject,
+		Content:          []sendGridContent{{"text/plain", m.body}},
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/v3/mail/send", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(raw))
+	}
+	return nil
+}
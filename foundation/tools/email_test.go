@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// fakeApprover answers every request with approve and records what it was
+// shown.
+type fakeApprover struct {
+	approve bool
+	actions []string
+	details []string
+}
+
+func (a *fakeApprover) Approve(ctx context.Context, action, details string) (bool, error) {
+	a.actions = append(a.actions, action)
+	a.details = append(a.details, details)
+	return a.approve, nil
+}
+
+func TestSendEmail(t *testing.T) {
+	var got struct {
+		auth string
+		mail sendGridMail
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/mail/send" {
+			http.NotFound(w, r)
+			return
+		}
+		got.auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got.mail)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	t.Setenv("SENDGRID_API_KEY", "sg-test")
+
+	bt, err := NewSendEmailTool(context.Background(), config.Email{
+		Provider: config.EmailSendGrid,
+		From:     "GopherCon Bot <bot@example.com>",
+		BaseURL:  srv.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const args = `{"to":["Ada <ada@example.com>"],"cc":["grace@example.com"],"subject":"Session notes","body":"We covered Eino graphs."}`
+
+	approver := &fakeApprover{approve: true}
+	out, err := bt.(tool.InvokableTool).InvokableRun(WithApprover(context.Background(), approver), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != `{"message":"Sent to ada@example.com and grace@example.com."}` {
+		t.Errorf("got %s", out)
+	}
+	if len(approver.actions) != 1 || approver.actions[0] != "send an email to ada@example.com and grace@example.com" {
+		t.Errorf("approver was asked %q", approver.actions)
+	}
+	if !strings.Contains(approver.details[0], "Subject: Session notes\n\nWe covered Eino graphs.") {
+		t.Errorf("approver was shown %q", approver.details[0])
+	}
+	if got.auth != "Bearer sg-test" || got.mail.Subject != "Session notes" || got.mail.From.Name != "GopherCon Bot" ||
+		got.mail.Personalizations[0].To[0].Email != "ada@example.com" || got.mail.Personalizations[0].Cc[0].Email != "grace@example.com" ||
+		got.mail.Content[0].Value != "We covered Eino graphs." {
+		t.Errorf("SendGrid received %+v", got)
+	}
+
+	// Nothing is sent unless the user approves, and no approver means no one
+	// can.
+	got.mail = sendGridMail{}
+	for name, ctx := range map[string]context.Context{
+		"declined":    WithApprover(context.Background(), &fakeApprover{}),
+		"no approver": context.Background(),
+	} {
+		if _, err := bt.(tool.InvokableTool).InvokableRun(ctx, args); !errors.Is(err, ErrNotApproved) || !errors.Is(err, ErrToolDenied) {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+	if got.mail.Subject != "" {
+		t.Error("an email was sent without approval")
+	}
+
+	approver = &fakeApprover{approve: true}
+	for _, args := range []string{
+		`{"to":[],"subject":"Notes","body":"Hi"}`,
+		`{"to":["not an address"],"subject":"Notes","body":"Hi"}`,
+		`{"to":["ada@example.com"],"subject":"Notes\nBcc: eve@example.com","body":"Hi"}`,
+		`{"to":["ada@example.com"],"subject":"Notes","body":" "}`,
+	} {
+		out, err := bt.(tool.InvokableTool).InvokableRun(WithApprover(context.Background(), approver), args)
+		if err != nil || !strings.Contains(out, `"error"`) {
+			t.Errorf("%s: got %s, %v", args, out, err)
+		}
+	}
+	if len(approver.actions) != 0 {
+		t.Errorf("invalid emails were put to the approver: %q", approver.actions)
+	}
+}
+
+func TestEmailMessageBytes(t *testing.T) {
+	from, _ := mail.ParseAddress("bot@example.com")
+	m, err := newEmailMessage(from, &SendEmailRequest{
+		To:      []string{"José <jose@example.com>"},
+		Subject: "Résumé of the session",
+		Body:    "Merci!\nSee you in Lagos.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2025, 10, 3, 9, 0, 0, 0, time.UTC)
+	parsed, err := mail.ReadMessage(strings.NewReader(string(m.bytes(date))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(mime.WordDecoder)
+	if subject, _ := dec.DecodeHeader(parsed.Header.Get("Subject")); subject != "Résumé of the session" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if to, _ := parsed.Header.AddressList("To"); len(to) != 1 || to[0].Name != "José" {
+		t.Errorf("To = %v", to)
+	}
+	body, _ := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	if string(body) != "Merci!\r\nSee you in Lagos." {
+		t.Errorf("body = %q", body)
+	}
+}
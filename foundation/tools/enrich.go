@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/olusolaa/goforai/foundation/tokens"
+	"golang.org/x/net/html"
+)
+
+// Defaults for SearchConfig.
+const (
+	DefaultPageMaxTokens = 1500
+	pageConcurrency      = 4
+	pageTimeout          = 10 * time.Second
+	maxPageBytes         = 2 << 20
+)
+
+// SearchConfig configures the search_internet tools.
+type SearchConfig struct {
+	// FetchPages is how many of the top results to download and extract, so
+	// the model gets the pages' text along with the links in one step. 0
+	// disables fetching.
+	FetchPages int
+	// PageMaxTokens is the approximate budget for the text of one page;
+	// 0 selects DefaultPageMaxTokens.
+	PageMaxTokens int
+}
+
+// PageContent is the extracted text of a search result's page.
+type PageContent struct {
+	URL     string `json:"url" jsonschema:"description=URL of the page."`
+	Title   string `json:"title,omitempty" jsonschema:"description=Title of the page."`
+	Content string `json:"content,omitempty" jsonschema:"description=Main text of the page, possibly truncated."`
+	Error   string `json:"error,omitempty" jsonschema:"description=Why the page could not be fetched."`
+}
+
+// pageFetcher downloads search result pages and extracts their text.
+type pageFetcher struct {
+	client    *http.Client
+	count     int
+	maxTokens int
+}
+
+// newPageFetcher returns nil when config does not ask for pages.
+func newPageFetcher(config *SearchConfig) *pageFetcher {
+	if config == nil || config.FetchPages <= 0 {
+		return nil
+	}
+	maxTokens := config.PageMaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultPageMaxTokens
+	}
+	return &pageFetcher{client: &http.Client{Timeout: pageTimeout}, count: config.FetchPages, maxTokens: maxTokens}
+}
+
+// fetch returns the content of the first f.count of urls, in order, fetching
+// at most pageConcurrency pages at a time. Pages that fail carry an Error.
+func (f *pageFetcher) fetch(ctx context.Context, urls []string) []PageContent {
+	if len(urls) > f.count {
+		urls = urls[:f.count]
+	}
+	pages := make([]PageContent, len(urls))
+	sem := make(chan struct{}, pageConcurrency)
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			pages[i] = f.fetchPage(ctx, u)
+		}()
+	}
+	wg.Wait()
+	return pages
+}
+
+func (f *pageFetcher) fetchPage(ctx context.Context, pageURL string) PageContent {
+	page := PageContent{URL: pageURL}
+	if u, err := url.Parse(pageURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		page.Error = "not an http(s) URL"
+		return page
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		page.Error = err.Error()
+		return page
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; GopherConBot/1.0)")
+	req.Header.Set("Accept", "text/html,text/plain;q=0.9")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		page.Error = fmt.Sprintf("request failed: %v", err)
+		return page
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		page.Error = fmt.Sprintf("status %d", resp.StatusCode)
+		return page
+	}
+
+	body := io.LimitReader(resp.Body, maxPageBytes)
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "":
+		doc, err := html.Parse(body)
+		if err != nil {
+			page.Error = fmt.Sprintf("failed to parse page: %v", err)
+			return page
+		}
+		page.Title, page.Content = extractText(doc)
+	case "text/plain", "text/markdown":
+		data, err := io.ReadAll(body)
+		if err != nil {
+			page.Error = fmt.Sprintf("failed to read page: %v", err)
+			return page
+		}
+		page.Content = strings.TrimSpace(strings.ToValidUTF8(string(data), ""))
+	default:
+		page.Error = fmt.Sprintf("unsupported content type %s", mediaType)
+		return page
+	}
+	page.Content = truncateText(page.Content, tokens.BytesFor(page.Content, f.maxTokens))
+	return page
+}
+
+// skippedElements hold no readable content.
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true, "iframe": true,
+}
+
+// blockElements start a new line in the extracted text.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "br": true, "li": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "pre": true,
+	"blockquote": true, "tr": true, "table": true, "ul": true, "ol": true, "dd": true, "dt": true,
+}
+
+// extractText returns the title and readable text of a page: the text of
+// <main> or <article> when there is one, else of <body>, without scripts,
+// navigation and other boilerplate, one block per line.
+func extractText(doc *html.Node) (title, text string) {
+	if t := findNode(doc, func(n *html.Node) bool { return n.Data == "title" }); t != nil {
+		title = textContent(t)
+	}
+	root := findNode(doc, func(n *html.Node) bool { return n.Data == "main" || n.Data == "article" })
+	if root == nil {
+		root = findNode(doc, func(n *html.Node) bool { return n.Data == "body" })
+	}
+	if root == nil {
+		root = doc
+	}
+
+	var b strings.Builder
+	var extract func(*html.Node)
+	extract = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if skippedElements[n.Data] {
+				return
+			}
+		}
+		block := n.Type == html.ElementNode && blockElements[n.Data]
+		if block {
+			b.WriteByte('\n')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			extract(c)
+		}
+		if block {
+			b.WriteByte('\n')
+		}
+	}
+	extract(root)
+
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return title, strings.Join(lines, "\n")
+}
+
+// truncateText keeps the first limit bytes of s, on a rune boundary, and
+// marks the cut.
+func truncateText(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + " … [truncated]"
+}
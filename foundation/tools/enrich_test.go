@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olusolaa/goforai/foundation/tokens"
+)
+
+const articleHTML = `<html><head><title>Go Concurrency</title><script>var x = 1;</script></head>
+<body><nav>Home | Blog</nav>
+<main><h1>Pipelines</h1><p>Stages are connected by   channels.</p><p>Each stage is a goroutine.</p></main>
+<footer>Copyright</footer></body></html>`
+
+func TestPageFetcher(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(articleHTML))
+	})
+	mux.HandleFunc("/notes.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("gopher ", 100)))
+	})
+	mux.HandleFunc("/report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if newPageFetcher(nil) != nil || newPageFetcher(&SearchConfig{}) != nil {
+		t.Error("page fetching is enabled without FetchPages")
+	}
+	f := newPageFetcher(&SearchConfig{FetchPages: 4})
+	pages := f.fetch(context.Background(), []string{
+		srv.URL + "/article", srv.URL + "/notes.txt", srv.URL + "/report.pdf", "ftp://example.com/x", srv.URL + "/missing",
+	})
+	if len(pages) != 4 {
+		t.Fatalf("fetched %d pages, want 4", len(pages))
+	}
+
+	if p := pages[0]; p.Title != "Go Concurrency" || !strings.HasPrefix(p.Content, "Pipelines\nStages are connected by channels.") {
+		t.Errorf("article = %+v", p)
+	}
+	if strings.Contains(pages[0].Content, "Home") || strings.Contains(pages[0].Content, "var x") {
+		t.Errorf("boilerplate was extracted: %q", pages[0].Content)
+	}
+	if p := pages[1]; !strings.HasPrefix(p.Content, "gopher gopher") || strings.HasSuffix(p.Content, "[truncated]") {
+		t.Errorf("text page = %+v", p)
+	}
+	if p := pages[2]; p.Content != "" || !strings.Contains(p.Error, "unsupported content type application/pdf") {
+		t.Errorf("pdf = %+v", p)
+	}
+	if p := pages[3]; p.Error == "" {
+		t.Errorf("fetched a non-http URL: %+v", p)
+	}
+
+	small := newPageFetcher(&SearchConfig{FetchPages: 1, PageMaxTokens: 10})
+	if p := small.fetch(context.Background(), []string{srv.URL + "/notes.txt"})[0]; tokens.Estimate(strings.TrimSuffix(p.Content, " … [truncated]")) > 10 || !strings.HasSuffix(p.Content, "[truncated]") {
+		t.Errorf("text page was not truncated to the budget: %q", p.Content)
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	if got := truncateText("héllo", 2); got != "h … [truncated]" {
+		t.Errorf("truncateText cut inside a rune: %q", got)
+	}
+	if got := truncateText("short", 10); got != "short" {
+		t.Errorf("truncateText(short) = %q", got)
+	}
+}
@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// envProbeTimeout bounds all the commands env_info runs for one call.
+const envProbeTimeout = 10 * time.Second
+
+// envBinaries are the programs env_info looks for, with the arguments that
+// make each print its version without doing anything else.
+var envBinaries = []struct {
+	name        string
+	versionArgs []string
+}{
+	{"git", []string{"--version"}},
+	{"docker", []string{"--version"}},
+	{"make", []string{"--version"}},
+	{"gcc", []string{"--version"}},
+	{"gopls", []string{"version"}},
+	{"golangci-lint", []string{"--version"}},
+	{"staticcheck", []string{"-version"}},
+	{"dlv", []string{"version"}},
+}
+
+// envGoVars are the go env variables env_info reports.
+var envGoVars = []string{"GOVERSION", "GOROOT", "GOPATH", "GOMODCACHE", "GOFLAGS", "GOPROXY", "GOPRIVATE", "CGO_ENABLED", "GOWORK"}
+
+type EnvInfoRequest struct {
+	Binaries []string `json:"binaries,omitempty" jsonschema:"description=Extra programs to look for on PATH, besides git, docker, make, gcc, gopls, golangci-lint, staticcheck and dlv. Only their location is reported."`
+}
+
+// Binary is a program env_info looked for.
+type Binary struct {
+	Name    string `json:"name" jsonschema:"description=Program name."`
+	Path    string `json:"path,omitempty" jsonschema:"description=Location on PATH; empty when it is not installed."`
+	Version string `json:"version,omitempty" jsonschema:"description=First line of its version output."`
+}
+
+// EnvInfo describes the machine the tools run on.
+type EnvInfo struct {
+	OS        string            `json:"os" jsonschema:"description=Operating system, as GOOS."`
+	Arch      string            `json:"arch" jsonschema:"description=Architecture, as GOARCH."`
+	GoVersion string            `json:"go_version,omitempty" jsonschema:"description=Version of the go command on PATH; empty when Go is not installed."`
+	GoEnv     map[string]string `json:"go_env,omitempty" jsonschema:"description=Selected 'go env' variables such as GOPATH and GOMODCACHE; empty ones are left out."`
+	Binaries  []Binary          `json:"binaries" jsonschema:"description=Programs looked for on PATH."`
+	Workspace string            `json:"workspace" jsonschema:"description=Directory relative paths resolve against."`
+	Confined  bool              `json:"confined,omitempty" jsonschema:"description=Whether the file tools refuse paths outside the workspace."`
+	ReadOnly  bool              `json:"read_only,omitempty" jsonschema:"description=Whether the editing tools are disabled."`
+}
+
+type EnvInfoResponse struct {
+	Env   *EnvInfo `json:"env,omitempty" jsonschema:"description=The environment."`
+	Error string   `json:"error,omitempty" jsonschema:"description=Error message if the environment could not be inspected."`
+}
+
+// NewEnvInfoTool returns the env_info tool, which reports the Go toolchain,
+// platform and installed programs the agent's suggestions should fit.
+func NewEnvInfoTool(ctx context.Context) (tool.BaseTool, error) {
+	readOnly := ReadOnly(ctx)
+	return inferTool(
+		"env_info",
+		"Report the environment the tools run in: OS and architecture, the installed Go version and go env (GOPATH, GOMODCACHE, GOFLAGS, ...), which of git, docker, make, gcc, gopls, golangci-lint, staticcheck and dlv are installed, and the workspace root. Call it before suggesting commands or Go features that depend on the environment.",
+		func(ctx context.Context, req *EnvInfoRequest) (*EnvInfoResponse, error) {
+			for _, name := range req.Binaries {
+				if name == "" || strings.ContainsAny(name, `/\`) {
+					return &EnvInfoResponse{Error: "binaries must be program names such as 'protoc', not paths"}, nil
+				}
+			}
+			env, err := inspectEnv(ctx, req.Binaries)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &EnvInfoResponse{Error: err.Error()}, nil
+			}
+			env.ReadOnly = readOnly
+			return &EnvInfoResponse{Env: env}, nil
+		},
+	)
+}
+
+func inspectEnv(ctx context.Context, extra []string) (*EnvInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, envProbeTimeout)
+	defer cancel()
+
+	env := &EnvInfo{
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Workspace: Workspace(ctx),
+		Confined:  Workspace(ctx) != "",
+	}
+	if env.Workspace == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		env.Workspace = wd
+	} else if abs, err := filepath.Abs(env.Workspace); err == nil {
+		env.Workspace = abs
+	}
+	env.GoEnv = goEnv(ctx)
+	env.GoVersion = env.GoEnv["GOVERSION"]
+	delete(env.GoEnv, "GOVERSION")
+
+	env.Binaries = make([]Binary, 0, len(envBinaries)+len(extra))
+	for _, b := range envBinaries {
+		env.Binaries = append(env.Binaries, Binary{Name: b.name})
+	}
+	for _, name := range extra {
+		env.Binaries = append(env.Binaries, Binary{Name: name})
+	}
+	var wg sync.WaitGroup
+	for i := range env.Binaries {
+		b := &env.Binaries[i]
+		path, err := exec.LookPath(b.Name)
+		if err != nil {
+			continue
+		}
+		b.Path = path
+		if i >= len(envBinaries) {
+			continue
+		}
+		wg.Add(1)
+		go func(args []string) {
+			defer wg.Done()
+			b.Version = commandVersion(ctx, path, args)
+		}(envBinaries[i].versionArgs)
+	}
+	wg.Wait()
+	return env, nil
+}
+
+// goEnv returns the envGoVars that are set, or nil when go is not installed.
+func goEnv(ctx context.Context) map[string]string {
+	out, err := exec.CommandContext(ctx, "go", append([]string{"env", "-json"}, envGoVars...)...).Output()
+	if err != nil {
+		return nil
+	}
+	vars := make(map[string]string)
+	if err := json.Unmarshal(out, &vars); err != nil {
+		return nil
+	}
+	for k, v := range vars {
+		if v == "" || k == "GOWORK" && v == "off" {
+			delete(vars, k)
+		}
+	}
+	return vars
+}
+
+// commandVersion returns the first line a program prints for args, or ""
+// when it fails.
+func commandVersion(ctx context.Context, path string, args []string) string {
+	out, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := bytes.Cut(bytes.TrimSpace(out), []byte("\n"))
+	return strings.TrimSpace(string(line))
+}
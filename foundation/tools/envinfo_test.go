@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEnvInfo(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewEnvInfoTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(args string) *EnvInfoResponse {
+		t.Helper()
+		resp := callTool[json.RawMessage, EnvInfoResponse](t, ctx, bt, json.RawMessage(args))
+		return &resp
+	}
+
+	resp := call(`{"binaries":["go","no-such-program"]}`)
+	if resp.Error != "" {
+		t.Fatal(resp.Error)
+	}
+	env := resp.Env
+	if env.OS != runtime.GOOS || env.Arch != runtime.GOARCH || env.Workspace != dir || !env.Confined || env.ReadOnly {
+		t.Errorf("env = %+v", env)
+	}
+	found := make(map[string]Binary)
+	for _, b := range env.Binaries {
+		found[b.Name] = b
+	}
+	if _, ok := found["docker"]; !ok {
+		t.Errorf("docker was not looked for: %+v", env.Binaries)
+	}
+	if b := found["no-such-program"]; b.Path != "" {
+		t.Errorf("no-such-program = %+v, want it missing", b)
+	}
+	if path, err := exec.LookPath("go"); err == nil {
+		if found["go"].Path != path || !strings.HasPrefix(env.GoVersion, "go") || env.GoEnv["GOMODCACHE"] == "" {
+			t.Errorf("go = %+v, version %q, env %v", found["go"], env.GoVersion, env.GoEnv)
+		}
+	}
+	if path, err := exec.LookPath("git"); err == nil {
+		if b := found["git"]; b.Path != path || !strings.Contains(b.Version, "git version") {
+			t.Errorf("git = %+v", b)
+		}
+	}
+
+	if resp := call(`{"binaries":["/bin/sh"]}`); !strings.Contains(resp.Error, "not paths") {
+		t.Errorf("a path in binaries gave %+v", resp)
+	}
+}
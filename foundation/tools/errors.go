@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrToolDenied is returned when a tool refuses an operation because its
+	// policy forbids it, as opposed to the operation failing. Unlike ordinary
+	// failures, which go to the model through the Error field so it can
+	// adjust, denials are returned from InvokableRun: they end the turn and
+	// callers can match them with errors.Is.
+	ErrToolDenied = errors.New("tool call denied")
+
+	// ErrWorkspaceViolation is returned when a path resolves outside the
+	// session workspace. It wraps ErrToolDenied.
+	ErrWorkspaceViolation = fmt.Errorf("%w: path is outside the session workspace", ErrToolDenied)
+
+	// ErrReadOnly is returned when a tool would modify files while the agent
+	// runs in read-only mode. It wraps ErrToolDenied.
+	ErrReadOnly = fmt.Errorf("%w: the agent is in read-only mode", ErrToolDenied)
+
+	// ErrNotApproved is returned when a tool needs a person's approval for an
+	// action, such as sending an email, and does not get it. It wraps
+	// ErrToolDenied.
+	ErrNotApproved = fmt.Errorf("%w: the action was not approved", ErrToolDenied)
+)
+
+// canceled returns a non-nil error once ctx is done. Long-running tools check
+// it between units of work so that a canceled turn stops them promptly; like
+// a denial, the error is returned from InvokableRun rather than handed to the
+// model, and it matches context.Canceled or context.DeadlineExceeded.
+func canceled(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("tool call canceled: %w", err)
+	}
+	return nil
+}
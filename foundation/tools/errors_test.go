@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func TestWorkspaceViolationsAreReturnedAsErrors(t *testing.T) {
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+	for name, tc := range map[string]struct {
+		newTool func(context.Context) (tool.BaseTool, error)
+		args    string
+	}{
+		"read_file":            {NewReadFileTool, `{"path":"../secret.txt"}`},
+		"edit_go_file":         {NewEditFileTool, `{"path":"/tmp/escape.go","operation":"add_import","import_path":"os"}`},
+		"search_files":         {NewSearchFilesTool, `{"path":"/etc"}`},
+		"apply_changeset":      {NewChangesetTool, `{"edits":[{"path":"/tmp/escape.go","operation":"add_import","import_path":"os"}]}`},
+		"scaffold_project":     {NewScaffoldProjectTool, `{"path":"../widgets"}`},
+		"run_go":               {NewRunGoTool, `{"path":"..","command":"vet"}`},
+		"fix_dependencies":     {NewFixDependenciesTool, `{"path":".."}`},
+		"profile_program":      {NewProfileProgramTool, `{"path":".."}`},
+		"list_conflicts":       {NewListConflictsTool, `{"path":".."}`},
+		"resolve_conflict":     {NewResolveConflictTool, `{"path":"../shop.go","hunk":1,"resolution":"ours"}`},
+		"license_audit":        {func(ctx context.Context) (tool.BaseTool, error) { return NewLicenseAuditTool(ctx, nil) }, `{"path":".."}`},
+		"scan_vulnerabilities": {NewScanVulnerabilitiesTool, `{"path":".."}`},
+		"suggest_commit_message": {func(ctx context.Context) (tool.BaseTool, error) {
+			return NewCommitMessageTool(ctx, &CommitMessageConfig{Model: &committerModel{}})
+		}, `{"path":".."}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			bt, err := tc.newTool(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := bt.(tool.InvokableTool).InvokableRun(ctx, tc.args)
+			if !errors.Is(err, ErrWorkspaceViolation) || !errors.Is(err, ErrToolDenied) {
+				t.Errorf("InvokableRun = %q, %v; want an error matching ErrWorkspaceViolation and ErrToolDenied", out, err)
+			}
+		})
+	}
+}
+
+func TestReadOnlyRefusesWrites(t *testing.T) {
+	ctx := WithReadOnly(context.Background())
+	edit, err := NewEditFileTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone, err := NewGitCloneTool(ctx, &GitCloneConfig{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeset, err := NewChangesetTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rename, err := NewRenameSymbolTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scaffold, err := NewScaffoldProjectTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixDeps, err := NewFixDependenciesTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct {
+		tool tool.BaseTool
+		args string
+	}{
+		"edit_go_file":     {edit, `{"path":"main.go","operation":"add_import","import_path":"os"}`},
+		"apply_changeset":  {changeset, `{"edits":[{"path":"main.go","operation":"add_import","import_path":"os"}]}`},
+		"rename_symbol":    {rename, `{"path":"main.go","name":"main","new_name":"run"}`},
+		"scaffold_project": {scaffold, `{"path":"widgets"}`},
+		"fix_dependencies": {fixDeps, `{}`},
+		"gitclone":         {clone, `{"url":"https://github.com/cloudwego/eino","action":"pull"}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := tc.tool.(tool.InvokableTool).InvokableRun(context.Background(), tc.args)
+			if !errors.Is(err, ErrReadOnly) || !errors.Is(err, ErrToolDenied) {
+				t.Errorf("InvokableRun = %q, %v; want an error matching ErrReadOnly and ErrToolDenied", out, err)
+			}
+		})
+	}
+}
+
+func TestCanceledTurnsStopTools(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"main.go": "package main\n", "pkg/a.go": "package pkg\n"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	clone, err := NewGitCloneTool(ctx, &GitCloneConfig{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	search, err := NewSearchFilesTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overview, err := NewRepoOverviewTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summarize, err := NewSummarizeModuleTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := NewEnvInfoTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGo, err := NewRunGoTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := NewProfileProgramTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct {
+		tool tool.BaseTool
+		args string
+	}{
+		"gitclone":         {clone, `{"url":"https://github.com/cloudwego/eino","action":"clone"}`},
+		"search_files":     {search, `{"path":"` + dir + `","contains":"package"}`},
+		"repo_overview":    {overview, `{"path":"` + dir + `"}`},
+		"summarize_module": {summarize, `{"path":"` + dir + `"}`},
+		"env_info":         {env, `{}`},
+		"run_go":           {runGo, `{"path":"` + dir + `","command":"vet"}`},
+		"profile_program":  {profile, `{"path":"` + dir + `"}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := tc.tool.(tool.InvokableTool).InvokableRun(ctx, tc.args)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("InvokableRun = %q, %v; want an error matching context.Canceled", out, err)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(dir, "github_com", "cloudwego", "eino")); !os.IsNotExist(err) {
+		t.Errorf("the canceled clone left a partial checkout behind: %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+type FixDependenciesRequest struct {
+	Path string   `json:"path,omitempty" jsonschema:"description=A directory or file inside the module to fix. Defaults to the workspace root."`
+	Get  []string `json:"get,omitempty" jsonschema:"description=Modules to add or upgrade before tidying, as 'go get' takes them, e.g. 'github.com/google/uuid@latest' or 'golang.org/x/sync@v0.10.0'. Usually unnecessary: go mod tidy adds the latest version of every module the code imports."`
+}
+
+type FixDependenciesResponse struct {
+	Module  string             `json:"module,omitempty" jsonschema:"description=Path of the module whose go.mod was fixed."`
+	Message string             `json:"message,omitempty" jsonschema:"description=Summary of what changed."`
+	Changes []DependencyChange `json:"changes,omitempty" jsonschema:"description=Requirements that were added, removed or changed version."`
+	Error   string             `json:"error,omitempty" jsonschema:"description=Error message, with the go command's output, if go.mod could not be fixed. go.mod and go.sum are left unchanged then."`
+}
+
+// DependencyChange is a requirement of go.mod that fix_dependencies changed.
+type DependencyChange struct {
+	Module string `json:"module"`
+	From   string `json:"from,omitempty" jsonschema:"description=The version required before; empty when the module was added."`
+	To     string `json:"to,omitempty" jsonschema:"description=The version required now; empty when the module was removed."`
+}
+
+// NewFixDependenciesTool returns the fix_dependencies tool, which brings a
+// module's go.mod and go.sum in line with its imports by running go get and
+// go mod tidy, and reports the requirements that changed. With a context
+// marked by WithReadOnly every call is refused with ErrReadOnly.
+func NewFixDependenciesTool(ctx context.Context) (tool.BaseTool, error) {
+	readOnly := ReadOnly(ctx)
+	return inferTool(
+		"fix_dependencies",
+		"Update a Go module's go.mod and go.sum to match its imports: optionally 'go get' the given modules, then run 'go mod tidy', and report every requirement added, removed or upgraded. Call it after edits that import a package from a module go.mod does not require yet (the editing tools say so), then build with run_go.",
+		func(ctx context.Context, req *FixDependenciesRequest) (*FixDependenciesResponse, error) {
+			if readOnly {
+				return nil, ErrReadOnly
+			}
+			for _, m := range req.Get {
+				if m == "" || strings.HasPrefix(m, "-") || strings.ContainsAny(m, " \t\n") {
+					return &FixDependenciesResponse{Error: fmt.Sprintf("invalid module '%s' in get: use a module path with an optional @version", m)}, nil
+				}
+			}
+			path, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &FixDependenciesResponse{Error: err.Error()}, nil
+			}
+			dir, err := filepath.Abs(path)
+			if err != nil {
+				return &FixDependenciesResponse{Error: err.Error()}, nil
+			}
+			if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+				dir = filepath.Dir(dir)
+			}
+			root := moduleRoot(ctx, dir)
+			if root == "" {
+				return &FixDependenciesResponse{Error: fmt.Sprintf("no go.mod found at or above '%s'", req.Path)}, nil
+			}
+
+			resp, err := fixDependencies(ctx, root, req.Get)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &FixDependenciesResponse{Module: resp.Module, Error: err.Error()}, nil
+			}
+			return resp, nil
+		},
+	)
+}
+
+// fixDependencies runs go get for modules, if any, and go mod tidy in the
+// module at root. When either fails, go.mod and go.sum are restored.
+func fixDependencies(ctx context.Context, root string, modules []string) (*FixDependenciesResponse, error) {
+	gomodPath, gosumPath := filepath.Join(root, "go.mod"), filepath.Join(root, "go.sum")
+	gomod, perms, err := readFileWithPerms(gomodPath)
+	if err != nil {
+		return &FixDependenciesResponse{}, err
+	}
+	gosum, sumPerms, sumErr := readFileWithPerms(gosumPath)
+	resp := &FixDependenciesResponse{Module: modulePath(gomod)}
+	restore := func() error {
+		errs := []error{atomicWriteFile(gomodPath, gomod, perms)}
+		if sumErr == nil {
+			errs = append(errs, atomicWriteFile(gosumPath, gosum, sumPerms))
+		} else if err := os.Remove(gosumPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, runGoTimeout)
+	defer cancel()
+	commands := [][]string{{"mod", "tidy"}}
+	if len(modules) > 0 {
+		commands = slices.Insert(commands, 0, append([]string{"get"}, modules...))
+	}
+	for _, args := range commands {
+		command := "go " + strings.Join(args, " ")
+		output, err := runCommand(ctx, root, "go", args...)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("%s did not finish within %s", command, runGoTimeout)
+		} else {
+			err = fmt.Errorf("%s failed: %s", command, shortenOutput(output))
+		}
+		if rErr := restore(); rErr != nil {
+			return resp, errors.Join(err, fmt.Errorf("failed to restore go.mod and go.sum: %w", rErr))
+		}
+		return resp, fmt.Errorf("%w\ngo.mod and go.sum were left unchanged", err)
+	}
+
+	updated, err := os.ReadFile(gomodPath)
+	if err != nil {
+		return resp, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	resp.Changes = requirementChanges(gomod, updated)
+	if len(resp.Changes) == 0 {
+		resp.Message = "✅ go.mod already matches the imports; no requirement changed"
+	} else {
+		resp.Message = fmt.Sprintf("✅ Updated go.mod: %d requirement(s) changed. Build with run_go to check the code compiles.", len(resp.Changes))
+	}
+	return resp, nil
+}
+
+// requirementChanges lists the requirements that differ between two
+// versions of a go.mod file, in module order.
+func requirementChanges(before, after []byte) []DependencyChange {
+	from, to := requiredVersions(before), requiredVersions(after)
+	var changes []DependencyChange
+	for m, v := range from {
+		if to[m] != v {
+			changes = append(changes, DependencyChange{Module: m, From: v, To: to[m]})
+		}
+	}
+	for m, v := range to {
+		if _, ok := from[m]; !ok {
+			changes = append(changes, DependencyChange{Module: m, To: v})
+		}
+	}
+	slices.SortFunc(changes, func(a, b DependencyChange) int { return strings.Compare(a.Module, b.Module) })
+	return changes
+}
+
+// requiredVersions returns the version of every module a go.mod file
+// requires, indirect requirements included.
+func requiredVersions(gomod []byte) map[string]string {
+	versions := make(map[string]string)
+	for _, r := range parseRequires(gomod) {
+		versions[r.path] = r.version
+	}
+	return versions
+}
+
+// undeclaredImports returns the imports of the Go source src, a file in dir,
+// that come from a module the go.mod of dir's module does not require. They
+// do not build until fix_dependencies adds the module.
+func undeclaredImports(ctx context.Context, dir string, src []byte) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+	root := moduleRoot(ctx, dir)
+	if root == "" {
+		return nil
+	}
+	gomod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil
+	}
+	provided := []string{modulePath(gomod)}
+	for _, r := range parseRequires(gomod) {
+		provided = append(provided, r.path)
+	}
+	var missing []string
+	for _, spec := range file.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || isStdImport(p) {
+			continue
+		}
+		if !slices.ContainsFunc(provided, func(m string) bool { return m != "" && (p == m || strings.HasPrefix(p, m+"/")) }) {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// dependencyHint is added to an edit's message when the edited file imports
+// packages from modules go.mod does not require.
+func dependencyHint(missing []string) string {
+	if len(missing) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(". ⚠️ go.mod does not require the module of %s yet; call fix_dependencies before building", quoteAll(missing))
+}
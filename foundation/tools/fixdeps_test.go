@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixDependencies(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	// The dependency is replaced by a local directory, so nothing is
+	// downloaded.
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOPROXY", "off")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":         "module example.com/shop\n\ngo 1.21\n\nreplace example.com/money => ./money\n",
+		"store.go":       "package shop\n",
+		"money/go.mod":   "module example.com/money\n\ngo 1.21\n",
+		"money/money.go": "package money\n\nfunc Cents(n int) int { return n * 100 }\n",
+	})
+	ctx := WithWorkspace(context.Background(), dir)
+	edit, err := NewEditFileTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix, err := NewFixDependenciesTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	callTool[json.RawMessage, EditFileResponse](t, ctx, edit, json.RawMessage(`{"path":"store.go","operation":"add_function","code":"func Price() int { return money.Cents(3) }"}`))
+	edited := callTool[json.RawMessage, EditFileResponse](t, ctx, edit, json.RawMessage(`{"path":"store.go","operation":"add_import","import_path":"example.com/money"}`))
+	if edited.Error != "" || !strings.Contains(edited.Message, "fix_dependencies") || !strings.Contains(edited.Message, "'example.com/money'") {
+		t.Fatalf("edit = %+v, want a hint to fix the dependencies", edited)
+	}
+
+	fixed := callTool[json.RawMessage, FixDependenciesResponse](t, ctx, fix, json.RawMessage(`{}`))
+	if fixed.Error != "" || fixed.Module != "example.com/shop" || len(fixed.Changes) != 1 {
+		t.Fatalf("fix_dependencies = %+v, want example.com/money added", fixed)
+	}
+	if c := fixed.Changes[0]; c.Module != "example.com/money" || c.From != "" || c.To == "" {
+		t.Errorf("change = %+v, want example.com/money added", c)
+	}
+	edited = callTool[json.RawMessage, EditFileResponse](t, ctx, edit, json.RawMessage(`{"path":"store.go","operation":"add_const","var_name":"currency","var_value":"\"EUR\""}`))
+	if strings.Contains(edited.Message, "fix_dependencies") {
+		t.Errorf("edit after fixing = %q, still hints at missing modules", edited.Message)
+	}
+
+	// A module that cannot be fetched leaves go.mod as it was.
+	before, _ := os.ReadFile(filepath.Join(dir, "go.mod"))
+	fixed = callTool[json.RawMessage, FixDependenciesResponse](t, ctx, fix, json.RawMessage(`{"get":["example.com/missing@v1.0.0"]}`))
+	if fixed.Error == "" || !strings.Contains(fixed.Error, "left unchanged") {
+		t.Errorf("fix_dependencies = %+v, want the go get failure", fixed)
+	}
+	if after, _ := os.ReadFile(filepath.Join(dir, "go.mod")); string(after) != string(before) {
+		t.Errorf("go.mod changed by a failed call:\n%s", after)
+	}
+	fixed = callTool[json.RawMessage, FixDependenciesResponse](t, ctx, fix, json.RawMessage(`{"get":["-u"]}`))
+	if !strings.Contains(fixed.Error, "invalid module") {
+		t.Errorf("fix_dependencies with a flag = %+v", fixed)
+	}
+}
+
+func TestRequirementChanges(t *testing.T) {
+	before := []byte("module m\n\nrequire (\n\ta.com/x v1.0.0\n\tb.com/y v1.2.0 // indirect\n)\n")
+	after := []byte("module m\n\nrequire a.com/x v1.1.0\n\nrequire c.com/z v0.3.0 // indirect\n")
+	got := requirementChanges(before, after)
+	want := []DependencyChange{
+		{Module: "a.com/x", From: "v1.0.0", To: "v1.1.0"},
+		{Module: "b.com/y", From: "v1.2.0"},
+		{Module: "c.com/z", To: "v0.3.0"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("changes = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
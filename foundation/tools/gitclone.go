@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,16 +10,42 @@ import (
 	"strings"
 
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/components/tool/utils"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
 type GitCloneConfig struct {
 	BaseDir string
+	// Overview builds the repo_overview cache after every clone and pull, so
+	// the first repo_overview call is instant.
+	Overview bool
+	// ReadOnly refuses pulls, which would change files already checked out.
+	// It is also set when the tool is built with a context marked by
+	// WithReadOnly.
+	ReadOnly bool
 }
 
 func NewGitCloneTool(ctx context.Context, config *GitCloneConfig) (tool.BaseTool, error) {
+	config, err := cloneConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := "Clone or pull a Git repository into a secure, local directory. CRITICAL: The response returns a 'path' field - you MUST use this EXACT path when calling other file tools. Use action='clone' for new repos, action='pull' to update existing ones."
+	if config.ReadOnly {
+		desc = "Clone a Git repository into a secure, local directory. CRITICAL: The response returns a 'path' field - you MUST use this EXACT path when calling other file tools. Only action='clone' is available: the agent is in read-only mode, so existing checkouts cannot be pulled."
+	}
+	return inferTool(
+		"gitclone",
+		desc,
+		func(ctx context.Context, req *GitCloneRequest) (*GitCloneResponse, error) {
+			return invokeGitClone(ctx, req, config)
+		},
+	)
+}
+
+// cloneConfig fills in the defaults of config, which may be nil.
+func cloneConfig(ctx context.Context, config *GitCloneConfig) (*GitCloneConfig, error) {
 	if config == nil {
 		config = &GitCloneConfig{}
 	}
@@ -32,14 +59,10 @@ func NewGitCloneTool(ctx context.Context, config *GitCloneConfig) (tool.BaseTool
 		return nil, fmt.Errorf("could not get absolute path for base dir: %w", err)
 	}
 	config.BaseDir = absBaseDir
-
-	return utils.InferTool(
-		"gitclone",
-		"Clone or pull a Git repository into a secure, local directory. CRITICAL: The response returns a 'path' field - you MUST use this EXACT path when calling other file tools. Use action='clone' for new repos, action='pull' to update existing ones.",
-		func(ctx context.Context, req *GitCloneRequest) (*GitCloneResponse, error) {
-			return invokeGitClone(ctx, req, config)
-		},
-	)
+	if ReadOnly(ctx) {
+		config.ReadOnly = true
+	}
+	return config, nil
 }
 
 type GitCloneAction string
@@ -106,14 +129,26 @@ func invokeGitClone(ctx context.Context, req *GitCloneRequest, config *GitCloneC
 	if req.Action == "" {
 		return &GitCloneResponse{Error: "action must be 'clone' or 'pull'"}, nil
 	}
+	if req.Action == GitCloneActionPull && config.ReadOnly {
+		return nil, ErrReadOnly
+	}
 
 	parsed, err := parseAndSanitizeURL(req.Url)
 	if err != nil {
 		return &GitCloneResponse{Error: err.Error()}, nil
 	}
 
-	// Construct a safe, predictable path.
+	// Construct a safe, predictable path. Sessions clone into their own workspace.
 	repoPath := filepath.Join(config.BaseDir, parsed.Host, parsed.Org, parsed.Repo)
+	if Workspace(ctx) != "" {
+		repoPath, err = resolvePath(ctx, filepath.Join("repos", parsed.Host, parsed.Org, parsed.Repo))
+		if errors.Is(err, ErrToolDenied) {
+			return nil, err
+		}
+		if err != nil {
+			return &GitCloneResponse{Error: err.Error()}, nil
+		}
+	}
 
 	if err := os.MkdirAll(filepath.Dir(repoPath), 0755); err != nil {
 		return &GitCloneResponse{Error: fmt.Sprintf("failed to create parent directory: %v", err)}, nil
@@ -135,6 +170,9 @@ func invokeGitClone(ctx context.Context, req *GitCloneRequest, config *GitCloneC
 			ReferenceName: plumbing.HEAD,
 		})
 		if err != nil {
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
 			return &GitCloneResponse{Error: fmt.Sprintf("clone failed: %v", err)}, nil
 		}
 
@@ -163,6 +201,9 @@ func invokeGitClone(ctx context.Context, req *GitCloneRequest, config *GitCloneC
 
 		err = w.PullContext(ctx, &git.PullOptions{RemoteName: "origin"})
 		if err != nil && err != git.NoErrAlreadyUpToDate {
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
 			return &GitCloneResponse{Error: fmt.Sprintf("pull failed: %v", err)}, nil
 		}
 
@@ -170,10 +211,21 @@ func invokeGitClone(ctx context.Context, req *GitCloneRequest, config *GitCloneC
 		return &GitCloneResponse{Error: fmt.Sprintf("invalid action '%s', use 'clone' or 'pull'", req.Action)}, nil
 	}
 
+	done := "cloned"
+	if req.Action == GitCloneActionPull {
+		done = "pulled"
+	}
+	nextSteps := fmt.Sprintf("IMPORTANT: Use the EXACT path '%s' with all file tools. Examples:\n- search_files(path='%s', pattern='**/*.go')\n- read_file(path='%s/README.md')",
+		repoPath, repoPath, repoPath)
+	if config.Overview {
+		if _, err := buildOverview(ctx, repoPath); err == nil {
+			nextSteps = fmt.Sprintf("IMPORTANT: Use the EXACT path '%s' with all file tools. Start with repo_overview(path='%s') for the layout, packages and README.",
+				repoPath, repoPath)
+		}
+	}
 	return &GitCloneResponse{
-		Message: fmt.Sprintf("Successfully %sd repository to '%s'", req.Action, repoPath),
-		Path:    repoPath,
-		NextSteps: fmt.Sprintf("IMPORTANT: Use the EXACT path '%s' with all file tools. Examples:\n- search_files(path='%s', pattern='**/*.go')\n- read_file(path='%s/README.md')",
-			repoPath, repoPath, repoPath),
+		Message:   fmt.Sprintf("Successfully %s repository to '%s'", done, repoPath),
+		Path:      repoPath,
+		NextSteps: nextSteps,
 	}, nil
 }
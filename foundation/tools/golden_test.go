@@ -0,0 +1,298 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/fs"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// The golden tests replay the tool calls in testdata/golden/cases/*.json
+// against a copy of testdata/golden/fs and compare each request→response
+// pair with <case>.golden.json. After an intended behavior change, re-record
+// the expectations with:
+//
+//	go test ./foundation/tools -run TestGolden -update
+//
+// Git remotes are served from a local HTTP server, so the cases run offline.
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current tool responses")
+
+// workspaceToken replaces the temporary workspace path in recorded responses.
+const workspaceToken = "$WORKSPACE"
+
+// goldenCase is one scenario: a sequence of steps run in a fresh workspace.
+type goldenCase struct {
+	Description string       `json:"description"`
+	Steps       []goldenStep `json:"steps"`
+}
+
+// goldenStep either calls a tool or, with RemoteCommit, pushes a commit to a
+// fake remote so later gitclone pulls have something to fetch.
+type goldenStep struct {
+	Tool         string          `json:"tool,omitempty"`
+	Request      json.RawMessage `json:"request,omitempty"`
+	RemoteCommit *remoteCommit   `json:"remote_commit,omitempty"`
+}
+
+type remoteCommit struct {
+	Repo    string `json:"repo"`
+	File    string `json:"file"`
+	Content string `json:"content"`
+}
+
+// goldenRecord is what the golden file stores for each tool call: the
+// response, or the error of a call the tool denied.
+type goldenRecord struct {
+	Tool     string          `json:"tool"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Denied   string          `json:"denied,omitempty"`
+}
+
+func TestGolden(t *testing.T) {
+	cases, err := filepath.Glob(filepath.Join("testdata", "golden", "cases", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range cases {
+		if strings.HasSuffix(path, ".golden.json") {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			runGoldenCase(t, path)
+		})
+	}
+}
+
+func runGoldenCase(t *testing.T, path string) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gc goldenCase
+	if err := json.Unmarshal(raw, &gc); err != nil {
+		t.Fatalf("invalid case file: %v", err)
+	}
+
+	remotes := newFakeRemotes(t)
+	workspace := copyFixture(t, filepath.Join("testdata", "golden", "fs"))
+	ctx := WithWorkspace(context.Background(), workspace)
+	tools := goldenTools(t)
+
+	var records []goldenRecord
+	for i, step := range gc.Steps {
+		if step.RemoteCommit != nil {
+			remotes.commit(t, *step.RemoteCommit)
+			continue
+		}
+		tl, ok := tools[step.Tool]
+		if !ok {
+			t.Fatalf("step %d: unknown tool %q", i, step.Tool)
+		}
+		out, err := tl.InvokableRun(ctx, string(step.Request))
+		if errors.Is(err, ErrToolDenied) {
+			records = append(records, goldenRecord{Tool: step.Tool, Request: step.Request, Denied: strings.ReplaceAll(err.Error(), workspace, workspaceToken)})
+			continue
+		}
+		if err != nil {
+			t.Fatalf("step %d: %s returned an error instead of a response: %v", i, step.Tool, err)
+		}
+		out = strings.ReplaceAll(out, workspace, workspaceToken)
+		records = append(records, goldenRecord{Tool: step.Tool, Request: step.Request, Response: json.RawMessage(out)})
+	}
+
+	got, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	goldenPath := strings.TrimSuffix(path, ".json") + ".golden.json"
+	if *update {
+		if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("missing golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("responses differ from %s (run with -update if the change is intended)\n--- got ---\n%s", goldenPath, got)
+	}
+}
+
+func goldenTools(t *testing.T) map[string]tool.InvokableTool {
+	t.Helper()
+	ctx := context.Background()
+	constructors := map[string]func() (tool.BaseTool, error){
+		"read_file":    func() (tool.BaseTool, error) { return NewReadFileTool(ctx) },
+		"search_files": func() (tool.BaseTool, error) { return NewSearchFilesTool(ctx) },
+		"edit_go_file": func() (tool.BaseTool, error) { return NewEditFileTool(ctx) },
+		"gitclone":     func() (tool.BaseTool, error) { return NewGitCloneTool(ctx, &GitCloneConfig{BaseDir: t.TempDir()}) },
+	}
+	tools := make(map[string]tool.InvokableTool, len(constructors))
+	for name, newTool := range constructors {
+		bt, err := newTool()
+		if err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+		tools[name] = bt.(tool.InvokableTool)
+	}
+	return tools
+}
+
+// copyFixture copies the fake filesystem into a fresh workspace so cases
+// that edit files cannot affect each other.
+func copyFixture(t *testing.T, src string) string {
+	t.Helper()
+	dst := t.TempDir()
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		t.Fatalf("failed to copy fixture: %v", err)
+	}
+	return dst
+}
+
+// fakeRemotes serves the repositories in fakeRemoteRepos over a local git
+// smart-HTTP server (git http-backend), and routes https:// git traffic to
+// it. The transport is process-wide, so cases must not run in parallel.
+type fakeRemotes struct {
+	root  string
+	repos map[string]*git.Repository
+}
+
+// fakeRemoteRepos are the repositories every case starts with, keyed by the
+// URL used to clone them.
+var fakeRemoteRepos = map[string]map[string]string{
+	"https://github.com/acme/widgets": {
+		"README.md": "# widgets\n",
+		"go.mod":    "module github.com/acme/widgets\n\ngo 1.24\n",
+		"widget.go": "package widgets\n\n// Widget is a thing.\ntype Widget struct{ Name string }\n",
+	},
+}
+
+func newFakeRemotes(t *testing.T) *fakeRemotes {
+	t.Helper()
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is required to serve the fake remotes")
+	}
+
+	fr := &fakeRemotes{root: t.TempDir(), repos: make(map[string]*git.Repository)}
+	for url, files := range fakeRemoteRepos {
+		// http-backend resolves /org/repo.git to <root>/org/repo.git/.git.
+		dir := filepath.Join(fr.root, strings.TrimPrefix(url, "https://github.com/")+".git")
+		repo, err := git.PlainInit(dir, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fr.repos[url] = repo
+		for name, content := range files {
+			fr.write(t, repo, name, content)
+		}
+		fr.commitAll(t, repo, "initial commit")
+	}
+
+	srv := httptest.NewServer(&cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + fr.root, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	t.Cleanup(srv.Close)
+	target, err := neturl.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := client.Protocols["https"]
+	client.InstallProtocol("https", githttp.NewClient(&http.Client{Transport: redirectTransport{target: target}}))
+	t.Cleanup(func() { client.InstallProtocol("https", previous) })
+	return fr
+}
+
+// redirectTransport sends every request to target, keeping the path.
+type redirectTransport struct {
+	target *neturl.URL
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = rt.target.Scheme, rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (fr *fakeRemotes) commit(t *testing.T, c remoteCommit) {
+	t.Helper()
+	repo, ok := fr.repos[c.Repo]
+	if !ok {
+		t.Fatalf("unknown fake remote %q", c.Repo)
+	}
+	fr.write(t, repo, c.File, c.Content)
+	fr.commitAll(t, repo, "update "+c.File)
+}
+
+func (fr *fakeRemotes) write(t *testing.T, repo *git.Repository, name, content string) {
+	t.Helper()
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := util.WriteFile(wt.Filesystem, name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (fr *fakeRemotes) commitAll(t *testing.T, repo *git.Repository, msg string) {
+	t.Helper()
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "Gopher", Email: "gopher@example.com", When: time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)}
+	if _, err := wt.Commit(msg, &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+}
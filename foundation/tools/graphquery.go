@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/knowledgegraph"
+)
+
+// maxGraphRelations bounds the relations a graph_query response lists.
+const maxGraphRelations = 50
+
+// GraphQueryConfig configures the graph_query tool.
+type GraphQueryConfig struct {
+	// Path is the knowledge graph file `goforai index --graph` saved.
+	Path string
+}
+
+type GraphQueryRequest struct {
+	Subject   string `json:"subject,omitempty" jsonschema:"description=Only relations whose subject contains this\\, ignoring case\\, such as a talk title."`
+	Predicate string `json:"predicate,omitempty" jsonschema:"description=Only relations of this kind\\, such as speaker\\, track\\, time\\, talk\\, title or company."`
+	Object    string `json:"object,omitempty" jsonschema:"description=Only relations whose object contains this\\, ignoring case\\, such as a speaker's name."`
+	CountBy   string `json:"count_by,omitempty" jsonschema:"description=Group the matching relations by their subject or object and count each group\\, e.g. count talks per speaker with predicate speaker and count_by object.,enum=subject,enum=object"`
+	MinCount  int    `json:"min_count,omitempty" jsonschema:"description=With count_by\\, only groups with at least this many relations\\, e.g. 2 for speakers with more than one talk."`
+}
+
+type GraphRelation struct {
+	Subject   string `json:"subject" jsonschema:"description=The entity the relation is about\\, such as a talk."`
+	Predicate string `json:"predicate" jsonschema:"description=The kind of relation."`
+	Object    string `json:"object" jsonschema:"description=The related value\\, such as a speaker."`
+	Source    string `json:"source,omitempty" jsonschema:"description=Document the relation was read from."`
+}
+
+type GraphGroup struct {
+	Value   string   `json:"value" jsonschema:"description=The subject or object the group shares."`
+	Count   int      `json:"count" jsonschema:"description=How many matching relations it has."`
+	Related []string `json:"related" jsonschema:"description=The other end of each of its relations."`
+}
+
+type GraphQueryResponse struct {
+	Matches    int             `json:"matches" jsonschema:"description=How many relations matched."`
+	Relations  []GraphRelation `json:"relations,omitempty" jsonschema:"description=The matching relations\\, without count_by."`
+	Groups     []GraphGroup    `json:"groups,omitempty" jsonschema:"description=With count_by\\, the groups\\, largest first."`
+	Truncated  bool            `json:"truncated,omitempty" jsonschema:"description=More relations or groups matched than are listed; narrow the query."`
+	Predicates []string        `json:"predicates,omitempty" jsonschema:"description=The kinds of relation the graph holds\\, listed when nothing matched."`
+	Error      string          `json:"error,omitempty" jsonschema:"description=Error message if the query was invalid."`
+}
+
+// NewGraphQueryTool returns the graph_query tool, which answers questions
+// about relations across the knowledge base from the graph at config.Path,
+// such as which speakers give more than one talk. It fails with
+// knowledgegraph.ErrNotFound when the graph has not been built.
+func NewGraphQueryTool(ctx context.Context, config *GraphQueryConfig) (tool.BaseTool, error) {
+	if config == nil || config.Path == "" {
+		return nil, errors.New("graph_query needs the path of a knowledge graph")
+	}
+	graph, err := knowledgegraph.Load(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	return inferTool(
+		"graph_query",
+		"Query the knowledge graph of the conference documents: relations such as talk -speaker-> person, talk -track-> topic, talk -time-> slot and person -company-> employer. "+
+			"Use it for questions about relations across documents, such as counting or listing (which speakers give more than one talk, which talks are in a track), "+
+			"and search_gophercon_knowledge for questions about what the documents say. Filters match parts of names, ignoring case; follow a relation by querying again with its object as the subject.",
+		func(ctx context.Context, req *GraphQueryRequest) (*GraphQueryResponse, error) {
+			if req.CountBy != "" && req.CountBy != "subject" && req.CountBy != "object" {
+				return &GraphQueryResponse{Error: fmt.Sprintf("unknown count_by '%s'; use subject or object", req.CountBy)}, nil
+			}
+			matches := graph.Match(ctx, req.Subject, req.Predicate, req.Object)
+			resp := &GraphQueryResponse{Matches: len(matches)}
+			if len(matches) == 0 {
+				resp.Predicates = graph.Predicates()
+				return resp, nil
+			}
+			if req.CountBy != "" {
+				resp.Groups = groupRelations(matches, req.CountBy, req.MinCount)
+				if len(resp.Groups) > maxGraphRelations {
+					resp.Groups, resp.Truncated = resp.Groups[:maxGraphRelations], true
+				}
+				return resp, nil
+			}
+			if len(matches) > maxGraphRelations {
+				matches, resp.Truncated = matches[:maxGraphRelations], true
+			}
+			for _, r := range matches {
+				resp.Relations = append(resp.Relations, GraphRelation{Subject: r.Subject, Predicate: r.Predicate, Object: r.Object, Source: r.Source})
+			}
+			return resp, nil
+		},
+	)
+}
+
+// groupRelations groups relations by their subject or object, keeping groups
+// of at least minCount, largest first and then by value.
+func groupRelations(relations []knowledgegraph.Relation, by string, minCount int) []GraphGroup {
+	index := make(map[string]int)
+	var groups []GraphGroup
+	for _, r := range relations {
+		value, related := r.Subject, r.Object
+		if by == "object" {
+			value, related = r.Object, r.Subject
+		}
+		key := strings.ToLower(value)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, GraphGroup{Value: value})
+		}
+		groups[i].Count++
+		groups[i].Related = append(groups[i].Related, related)
+	}
+	kept := groups[:0]
+	for _, g := range groups {
+		if g.Count >= minCount {
+			kept = append(kept, g)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].Count != kept[j].Count {
+			return kept[i].Count > kept[j].Count
+		}
+		return kept[i].Value < kept[j].Value
+	})
+	return kept
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/olusolaa/goforai/foundation/knowledgegraph"
+)
+
+func TestGraphQuery(t *testing.T) {
+	ctx := context.Background()
+	g := knowledgegraph.New()
+	g.Add(
+		knowledgegraph.Relation{Subject: "Concurrency Patterns", Predicate: "speaker", Object: "Sarah Johnson", Source: "talks.md"},
+		knowledgegraph.Relation{Subject: "Scheduler Internals", Predicate: "speaker", Object: "Sarah Johnson", Source: "talks.md"},
+		knowledgegraph.Relation{Subject: "gRPC Microservices", Predicate: "speaker", Object: "David Chen", Source: "talks.md"},
+		knowledgegraph.Relation{Subject: "Concurrency Patterns", Predicate: "track", Object: "Performance", Source: "talks.md"},
+	)
+	path := filepath.Join(t.TempDir(), "graph.json")
+	if err := g.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	bt, err := NewGraphQueryTool(ctx, &GraphQueryConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := func(args string) GraphQueryResponse {
+		t.Helper()
+		return callTool[json.RawMessage, GraphQueryResponse](t, ctx, bt, json.RawMessage(args))
+	}
+
+	repeat := query(`{"predicate":"speaker","count_by":"object","min_count":2}`)
+	if len(repeat.Groups) != 1 || repeat.Groups[0].Value != "Sarah Johnson" || repeat.Groups[0].Count != 2 ||
+		!slices.Equal(repeat.Groups[0].Related, []string{"Concurrency Patterns", "Scheduler Internals"}) {
+		t.Errorf("speakers with several talks = %+v", repeat)
+	}
+
+	track := query(`{"subject":"concurrency","predicate":"track"}`)
+	if track.Matches != 1 || track.Relations[0].Object != "Performance" || track.Relations[0].Source != "talks.md" {
+		t.Errorf("track of the concurrency talk = %+v", track)
+	}
+
+	none := query(`{"predicate":"room"}`)
+	if none.Matches != 0 || !slices.Equal(none.Predicates, []string{"speaker", "track"}) {
+		t.Errorf("a query matching nothing = %+v", none)
+	}
+
+	if bad := query(`{"count_by":"speaker"}`); bad.Error == "" {
+		t.Error("an unknown count_by was accepted")
+	}
+
+	if _, err := NewGraphQueryTool(ctx, &GraphQueryConfig{Path: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("a missing graph was accepted")
+	}
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/gemini"
+)
+
+type GroundedSearchRequest struct {
+	Query string `json:"query" jsonschema:"description=The question or search query to answer from the web."`
+}
+
+type GroundedSearchResponse struct {
+	Query         string            `json:"query" jsonschema:"description=The query that was answered."`
+	Answer        string            `json:"answer,omitempty" jsonschema:"description=Answer written from the search results. A marker such as [2] follows each statement backed by a source and gives its number."`
+	Sources       []GroundedSource  `json:"sources" jsonschema:"description=The web pages the answer is based on, numbered from 1."`
+	Supports      []GroundedSupport `json:"supports,omitempty" jsonschema:"description=The statements of the answer, the sources backing each and how confident the search is in them."`
+	SearchQueries []string          `json:"search_queries,omitempty" jsonschema:"description=The Google searches run to answer the query."`
+	Error         string            `json:"error,omitempty" jsonschema:"description=Error message if the search failed."`
+}
+
+type GroundedSource struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Domain string `json:"domain,omitempty"`
+}
+
+type GroundedSupport struct {
+	Text       string    `json:"text"`
+	Sources    []int     `json:"sources"`              // Numbers of the sources.
+	Confidence []float64 `json:"confidence,omitempty"` // Per source, from 0 to 1.
+}
+
+// NewGroundedSearchTool returns the search_internet tool backed by Gemini's
+// Google Search grounding instead of Tavily or DuckDuckGo. It needs only the
+// Gemini API key, and returns an answer with its sources rather than a list
+// of results.
+func NewGroundedSearchTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"search_internet",
+		"Search the internet with Google for current information, news, and general knowledge. Returns an answer "+
+			"written from the search results, with [n] markers after the statements each numbered source backs, "+
+			"and how confident the search is in them. Always cite sources using the provided URLs, and say so "+
+			"when a statement has no source.",
+		func(ctx context.Context, req *GroundedSearchRequest) (*GroundedSearchResponse, error) {
+			query := strings.TrimSpace(req.Query)
+			if query == "" {
+				return &GroundedSearchResponse{Error: "query cannot be empty"}, nil
+			}
+			result, err := gemini.Search(ctx, query)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				// Whatever failed, it was the search service, not the query.
+				return nil, NewToolError(FailureUnavailable, err.Error())
+			}
+			return newGroundedSearchResponse(query, result), nil
+		},
+	)
+}
+
+func newGroundedSearchResponse(query string, result *gemini.Grounded) *GroundedSearchResponse {
+	resp := &GroundedSearchResponse{
+		Query:         query,
+		Sources:       make([]GroundedSource, len(result.Sources)),
+		SearchQueries: result.Queries,
+	}
+	for i, s := range result.Sources {
+		resp.Sources[i] = GroundedSource{Number: i + 1, Title: s.Title, URL: s.URL, Domain: s.Domain}
+	}
+	for _, s := range result.Supports {
+		support := GroundedSupport{Text: s.Text, Sources: make([]int, len(s.Sources)), Confidence: s.Confidence}
+		for i, idx := range s.Sources {
+			support.Sources[i] = idx + 1
+		}
+		resp.Supports = append(resp.Supports, support)
+	}
+	resp.Answer = markCitations(result.Text, resp.Supports)
+	return resp
+}
+
+// markCitations puts the numbers of its sources after each supported part of
+// answer, as in "Go 1.25 is out.[1][3]". Parts that do not occur in answer
+// are left unmarked.
+func markCitations(answer string, supports []GroundedSupport) string {
+	marks := make(map[int][]int) // End offset → source numbers.
+	for _, s := range supports {
+		start := strings.Index(answer, s.Text)
+		if start < 0 || len(s.Sources) == 0 {
+			continue
+		}
+		end := start + len(s.Text)
+		for _, n := range s.Sources {
+			if !slices.Contains(marks[end], n) {
+				marks[end] = append(marks[end], n)
+			}
+		}
+	}
+	if len(marks) == 0 {
+		return answer
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, end := range slices.Sorted(maps.Keys(marks)) {
+		b.WriteString(answer[last:end])
+		slices.Sort(marks[end])
+		for _, n := range marks[end] {
+			fmt.Fprintf(&b, "[%d]", n)
+		}
+		last = end
+	}
+	b.WriteString(answer[last:])
+	return b.String()
+}
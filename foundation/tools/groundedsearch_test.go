@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+)
+
+func TestGroundedSearchTool(t *testing.T) {
+	fakeGemini := fakeapi.NewGemini(t)
+	fakeGemini.Use(t)
+	fakeGemini.Reply(fakeapi.GeminiReply{
+		Text: "GopherCon Africa 2025 is in Nairobi. Tickets are on sale.",
+		Grounding: &fakeapi.GeminiGrounding{
+			Queries: []string{"GopherCon Africa 2025 venue"},
+			Sources: []fakeapi.GeminiSource{
+				{Title: "gophercon.africa", URI: "https://grounding.example/1", Domain: "gophercon.africa"},
+				{Title: "go.dev", URI: "https://grounding.example/2", Domain: "go.dev"},
+			},
+			Supports: []fakeapi.GeminiSupport{
+				{Text: "GopherCon Africa 2025 is in Nairobi.", Sources: []int{1, 0}, Confidence: []float64{0.5, 0.75}},
+			},
+		},
+	})
+	fakeGemini.ReplyError(http.StatusTooManyRequests, "quota exceeded")
+
+	bt, err := NewGroundedSearchTool(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	search := func(query string) GroundedSearchResponse {
+		t.Helper()
+		return callTool[GroundedSearchRequest, GroundedSearchResponse](t, context.Background(), bt, GroundedSearchRequest{Query: query})
+	}
+
+	resp := search("Where is GopherCon Africa 2025?")
+	if resp.Error != "" {
+		t.Fatal(resp.Error)
+	}
+	if want := "GopherCon Africa 2025 is in Nairobi.[1][2] Tickets are on sale."; resp.Answer != want {
+		t.Errorf("answer = %q, want %q", resp.Answer, want)
+	}
+	if len(resp.Sources) != 2 || resp.Sources[1].Number != 2 || resp.Sources[1].Domain != "go.dev" {
+		t.Errorf("sources = %+v", resp.Sources)
+	}
+	if len(resp.Supports) != 1 || resp.Supports[0].Sources[0] != 2 || resp.Supports[0].Confidence[1] != 0.75 {
+		t.Errorf("supports = %+v", resp.Supports)
+	}
+	if len(resp.SearchQueries) != 1 {
+		t.Errorf("search queries = %q", resp.SearchQueries)
+	}
+	if reqs := fakeGemini.Requests(); !reqs[0].GoogleSearch || len(reqs[0].Tools) != 0 {
+		t.Errorf("request = %+v, want the Google Search tool alone", reqs[0])
+	}
+
+	var failure *ToolError
+	_, err = bt.(tool.InvokableTool).InvokableRun(context.Background(), `{"query":"anything"}`)
+	if !errors.As(err, &failure) || failure.Code != FailureUnavailable || !failure.Retryable {
+		t.Errorf("failed search = %v, want a retryable ToolError", err)
+	}
+	if resp := search("  "); resp.Error == "" {
+		t.Error("an empty query was searched")
+	}
+}
+
+func TestMarkCitations(t *testing.T) {
+	supports := []GroundedSupport{
+		{Text: "b.", Sources: []int{3, 1}},
+		{Text: "a.", Sources: []int{2}},
+		{Text: "b.", Sources: []int{1}},
+		{Text: "not in the answer", Sources: []int{4}},
+	}
+	if got := markCitations("a. b. c.", supports); got != "a.[2] b.[1][3] c." {
+		t.Errorf("markCitations = %q", got)
+	}
+}
@@ -0,0 +1,294 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// stdPackages maps the names of commonly used standard library packages to
+// their import paths. Where two packages share a name, the more common one
+// is listed.
+var stdPackages = map[string]string{
+	"aes": "crypto/aes", "atomic": "sync/atomic", "base64": "encoding/base64",
+	"big": "math/big", "binary": "encoding/binary", "bits": "math/bits",
+	"bufio": "bufio", "build": "go/build", "bytes": "bytes", "cipher": "crypto/cipher",
+	"cmp": "cmp", "context": "context", "crc32": "hash/crc32", "csv": "encoding/csv",
+	"debug": "runtime/debug", "doc": "go/doc", "ed25519": "crypto/ed25519",
+	"errors": "errors", "exec": "os/exec", "expvar": "expvar", "filepath": "path/filepath",
+	"flag": "flag", "fmt": "fmt", "fnv": "hash/fnv", "format": "go/format", "fs": "io/fs",
+	"fstest": "testing/fstest", "gob": "encoding/gob", "gzip": "compress/gzip",
+	"hash": "hash", "heap": "container/heap", "hex": "encoding/hex", "hmac": "crypto/hmac",
+	"html": "html", "http": "net/http", "httptest": "net/http/httptest",
+	"httputil": "net/http/httputil", "io": "io", "iter": "iter", "json": "encoding/json",
+	"list": "container/list", "log": "log", "maps": "maps", "math": "math", "md5": "crypto/md5",
+	"mime": "mime", "multipart": "mime/multipart", "net": "net", "netip": "net/netip",
+	"os": "os", "parser": "go/parser", "path": "path", "pem": "encoding/pem",
+	"pprof": "runtime/pprof", "printer": "go/printer", "rand": "math/rand",
+	"reflect": "reflect", "regexp": "regexp", "ring": "container/ring", "runtime": "runtime",
+	"sha1": "crypto/sha1", "sha256": "crypto/sha256", "sha512": "crypto/sha512",
+	"signal": "os/signal", "slices": "slices", "slog": "log/slog", "sort": "sort",
+	"sql": "database/sql", "strconv": "strconv", "strings": "strings", "sync": "sync",
+	"syscall": "syscall", "tabwriter": "text/tabwriter", "tar": "archive/tar",
+	"template": "text/template", "testing": "testing", "time": "time", "tls": "crypto/tls",
+	"token": "go/token", "types": "go/types", "unicode": "unicode", "unique": "unique",
+	"unsafe": "unsafe", "url": "net/url", "utf16": "unicode/utf16", "utf8": "unicode/utf8",
+	"xml": "encoding/xml", "zip": "archive/zip", "zlib": "compress/zlib",
+}
+
+// organizeImports does for src what goimports does without a build: it adds
+// the imports that package references need, from the other Go files in dir
+// or the standard library, and removes the imports nothing refers to. With
+// group set it also sorts the imports into one block with the standard
+// library first. It returns the new source and the import paths added and
+// removed.
+func organizeImports(src []byte, dir string, group bool) (out []byte, added, removed []string, err error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+
+	// Package references are the unresolved identifiers qualifying a
+	// selector, such as fmt in fmt.Println.
+	refs := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				refs[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	imported := make(map[string]bool)
+	var unknown []*ast.ImportSpec // Imports whose package name is a guess.
+	for _, spec := range file.Imports {
+		name, p := importName(spec)
+		imported[name] = true
+		if spec.Name == nil && assumedName(p) != path.Base(p) {
+			unknown = append(unknown, spec)
+		}
+	}
+	candidates := siblingImports(dir)
+	var missing []string
+	for name := range refs {
+		if !imported[name] {
+			missing = append(missing, name)
+		}
+	}
+	slices.Sort(missing)
+
+	unresolved := false
+	for _, name := range missing {
+		p, ok := candidates[name]
+		if !ok {
+			p, ok = stdPackages[name]
+		}
+		if !ok {
+			unresolved = true
+			continue
+		}
+		if assumedName(p) == name {
+			astutil.AddImport(fset, file, p)
+		} else {
+			astutil.AddNamedImport(fset, file, name, p)
+		}
+		added = append(added, p)
+	}
+
+	for _, spec := range slices.Clone(file.Imports) {
+		name, p := importName(spec)
+		if name == "_" || name == "." || p == "C" || refs[name] {
+			continue
+		}
+		// The package of a guessed name may be one of the references no
+		// import provides.
+		if unresolved && slices.Contains(unknown, spec) {
+			continue
+		}
+		var alias string
+		if spec.Name != nil {
+			alias = spec.Name.Name
+		}
+		if astutil.DeleteNamedImport(fset, file, alias, p) {
+			removed = append(removed, p)
+			// DeleteNamedImport leaves the comments of the import behind.
+			file.Comments = slices.DeleteFunc(file.Comments, func(c *ast.CommentGroup) bool {
+				return c == spec.Doc || c == spec.Comment
+			})
+		}
+	}
+
+	out = src
+	if len(added) > 0 || len(removed) > 0 {
+		var buf bytes.Buffer
+		cfg := printer.Config{Mode: printer.TabIndent | printer.UseSpaces, Tabwidth: 8}
+		if err := cfg.Fprint(&buf, fset, file); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to print file: %w", err)
+		}
+		out = buf.Bytes()
+	}
+	if group {
+		if out, err = groupImports(out); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return out, added, removed, nil
+}
+
+// groupImports rewrites the import declarations of src as one block: the
+// standard library first, then every other package, each group sorted by
+// path. Comments between the imports, other than those ending an import's
+// line, are dropped. Files using cgo are returned unchanged.
+func groupImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	var first, last *ast.GenDecl
+	for _, decl := range file.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			if first == nil {
+				first = gd
+			}
+			last = gd
+		}
+	}
+	if first == nil {
+		return src, nil
+	}
+
+	var std, other []string
+	for _, spec := range file.Imports {
+		_, p := importName(spec)
+		if p == "C" {
+			return src, nil
+		}
+		line := spec.Path.Value
+		if spec.Name != nil {
+			line = spec.Name.Name + " " + line
+		}
+		if spec.Comment != nil {
+			line += " " + strings.TrimSpace(string(src[fset.Position(spec.Comment.Pos()).Offset:fset.Position(spec.Comment.End()).Offset]))
+		}
+		if isStdImport(p) {
+			std = append(std, line)
+		} else {
+			other = append(other, line)
+		}
+	}
+	byPath := func(a, b string) int { return strings.Compare(linePath(a), linePath(b)) }
+	slices.SortStableFunc(std, byPath)
+	slices.SortStableFunc(other, byPath)
+
+	var block bytes.Buffer
+	if len(std)+len(other) == 1 {
+		block.WriteString("import " + slices.Concat(std, other)[0])
+	} else {
+		writeImportBlock(&block, std, other)
+	}
+
+	start, end := fset.Position(first.Pos()).Offset, fset.Position(last.End()).Offset
+	var out bytes.Buffer
+	out.Write(src[:start])
+	out.Write(block.Bytes())
+	out.Write(src[end:])
+	return out.Bytes(), nil
+}
+
+// writeImportBlock writes a parenthesized import declaration with a blank
+// line between the groups.
+func writeImportBlock(block *bytes.Buffer, std, other []string) {
+	block.WriteString("import (\n")
+	for _, line := range std {
+		block.WriteString("\t" + line + "\n")
+	}
+	if len(std) > 0 && len(other) > 0 {
+		block.WriteString("\n")
+	}
+	for _, line := range other {
+		block.WriteString("\t" + line + "\n")
+	}
+	block.WriteString(")")
+}
+
+// linePath returns the import path in a line of an import block.
+func linePath(line string) string {
+	i := strings.IndexByte(line, '"')
+	if i < 0 {
+		return line
+	}
+	j := strings.IndexByte(line[i+1:], '"')
+	if j < 0 {
+		return line[i:]
+	}
+	return line[i+1 : i+1+j]
+}
+
+// isStdImport reports whether p is a standard library path: its first
+// element has no dot.
+func isStdImport(p string) bool {
+	first, _, _ := strings.Cut(p, "/")
+	return !strings.Contains(first, ".")
+}
+
+// importName returns the name spec binds in the file and its import path.
+func importName(spec *ast.ImportSpec) (name, p string) {
+	p, _ = strconv.Unquote(spec.Path.Value)
+	if spec.Name != nil {
+		return spec.Name.Name, p
+	}
+	return assumedName(p), p
+}
+
+// assumedName guesses the package name of an import path as goimports does:
+// the last element, skipping a major version suffix, without a "go-" prefix
+// and up to the first character that cannot appear in an identifier.
+func assumedName(p string) string {
+	base := path.Base(p)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" && path.Dir(p) != "." {
+		base = path.Base(path.Dir(p))
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}); i > 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// siblingImports returns the imports of the Go files in dir by the name they
+// bind, so references to the packages the rest of the package uses resolve
+// to the same imports.
+func siblingImports(dir string) map[string]string {
+	imports := make(map[string]string)
+	if dir == "" {
+		return imports
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, m := range matches {
+		file, err := parser.ParseFile(token.NewFileSet(), m, nil, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		for _, spec := range file.Imports {
+			if name, p := importName(spec); name != "_" && name != "." {
+				imports[name] = p
+			}
+		}
+	}
+	return imports
+}
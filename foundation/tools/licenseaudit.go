@@ -0,0 +1,388 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// UnknownLicense stands for a module whose license could not be identified.
+// It can be disallowed like any license ID.
+const UnknownLicense = "unknown"
+
+// Kinds of license, from the fewest obligations to the most.
+const (
+	LicensePublicDomain    = "public domain"
+	LicensePermissive      = "permissive"
+	LicenseWeakCopyleft    = "weak copyleft"
+	LicenseStrongCopyleft  = "strong copyleft"
+	LicenseNetworkCopyleft = "network copyleft"
+)
+
+// LicenseAuditConfig configures the license_audit tool.
+type LicenseAuditConfig struct {
+	// Disallowed lists SPDX license IDs, such as "GPL-3.0" or "AGPL-3.0",
+	// that dependencies may not use; UnknownLicense flags modules whose
+	// license could not be identified.
+	Disallowed []string
+}
+
+type LicenseAuditRequest struct {
+	Path       string   `json:"path,omitempty" jsonschema:"description=A directory inside the module to audit. Defaults to the workspace root."`
+	All        bool     `json:"all,omitempty" jsonschema:"description=Audit every module in the module graph, including those only needed to select versions, instead of the modules the module's packages are built from."`
+	Disallowed []string `json:"disallowed,omitempty" jsonschema:"description=SPDX IDs of licenses to flag besides the configured ones, e.g. 'GPL-3.0'; 'unknown' flags unidentified licenses."`
+}
+
+type LicenseAuditResponse struct {
+	Module       string              `json:"module,omitempty" jsonschema:"description=Path of the audited module."`
+	Licenses     []string            `json:"licenses,omitempty" jsonschema:"description=Licenses of the audited module itself."`
+	Message      string              `json:"message,omitempty" jsonschema:"description=Summary of the audit."`
+	Dependencies []DependencyLicense `json:"dependencies,omitempty" jsonschema:"description=Each dependency with its licenses, disallowed ones first."`
+	Obligations  []LicenseObligation `json:"obligations,omitempty" jsonschema:"description=What each license found asks of a program distributing the dependencies, most demanding first."`
+	Error        string              `json:"error,omitempty" jsonschema:"description=Error message if the audit could not run."`
+}
+
+// DependencyLicense is the license of one module a module depends on.
+type DependencyLicense struct {
+	Module     string   `json:"module"`
+	Version    string   `json:"version,omitempty"`
+	Licenses   []string `json:"licenses" jsonschema:"description=SPDX IDs of the licenses found in the module's license files; 'unknown' when none was identified."`
+	Files      []string `json:"files,omitempty" jsonschema:"description=The module's license files."`
+	Disallowed bool     `json:"disallowed,omitempty" jsonschema:"description=True when a license is disallowed by the policy."`
+	Note       string   `json:"note,omitempty"`
+}
+
+// LicenseObligation summarizes what a license asks of the modules using it.
+type LicenseObligation struct {
+	License     string `json:"license"`
+	Kind        string `json:"kind" jsonschema:"description=public domain, permissive, weak copyleft, strong copyleft or network copyleft."`
+	Modules     int    `json:"modules" jsonschema:"description=How many dependencies use the license."`
+	Obligations string `json:"obligations"`
+}
+
+// NewLicenseAuditTool returns the license_audit tool, which inventories the
+// licenses of a module's dependencies from their license files, flags the
+// ones config disallows and summarizes what the licenses found ask of the
+// program. It reads dependencies from the module cache and downloads
+// nothing.
+func NewLicenseAuditTool(ctx context.Context, config *LicenseAuditConfig) (tool.BaseTool, error) {
+	if config == nil {
+		config = &LicenseAuditConfig{}
+	}
+	desc := "Audit the licenses of a Go module's dependencies: list each dependency's licenses (as SPDX IDs) from its license files, flag those the policy disallows, and summarize the obligations of every license found, such as keeping notices or releasing source. Use it to vet a repository before adopting it."
+	if len(config.Disallowed) > 0 {
+		desc += fmt.Sprintf(" The policy disallows: %s.", strings.Join(config.Disallowed, ", "))
+	}
+	return inferTool(
+		"license_audit",
+		desc,
+		func(ctx context.Context, req *LicenseAuditRequest) (*LicenseAuditResponse, error) {
+			path, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &LicenseAuditResponse{Error: err.Error()}, nil
+			}
+			dir, err := filepath.Abs(path)
+			if err != nil {
+				return &LicenseAuditResponse{Error: err.Error()}, nil
+			}
+			if moduleRoot(ctx, dir) == "" {
+				return &LicenseAuditResponse{Error: fmt.Sprintf("no go.mod found at or above '%s'", req.Path)}, nil
+			}
+
+			resp, err := auditLicenses(ctx, dir, req.All, append(slices.Clone(config.Disallowed), req.Disallowed...))
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &LicenseAuditResponse{Error: err.Error()}, nil
+			}
+			return resp, nil
+		},
+	)
+}
+
+// listedModule is a module as go list -json describes it.
+type listedModule struct {
+	Path    string
+	Version string
+	Main    bool
+	Dir     string
+	Replace *listedModule
+	Error   *struct{ Err string }
+}
+
+// auditLicenses lists the modules the module in dir depends on, identifies
+// their licenses and checks them against disallowed.
+func auditLicenses(ctx context.Context, dir string, all bool, disallowed []string) (*LicenseAuditResponse, error) {
+	modules, err := listModules(ctx, dir, all)
+	if err != nil {
+		return nil, err
+	}
+	resp := &LicenseAuditResponse{}
+	counts := map[string]int{}
+	var flagged, missing int
+	for _, m := range modules {
+		licenses, files := moduleLicenses(m.Dir)
+		if m.Main {
+			resp.Module, resp.Licenses = m.Path, licenses
+			continue
+		}
+		dep := DependencyLicense{Module: m.Path, Version: m.Version, Licenses: licenses, Files: files}
+		switch {
+		case m.Replace != nil:
+			dep.Version = m.Replace.Version
+			dep.Note = "replaced by " + strings.TrimSpace(m.Replace.Path+" "+m.Replace.Version)
+		case m.Error != nil:
+			dep.Note = m.Error.Err
+		case m.Dir == "":
+			dep.Note = "not in the module cache; run 'go mod download' to audit it"
+			missing++
+		}
+		for _, l := range licenses {
+			counts[l]++
+			if slices.ContainsFunc(disallowed, func(d string) bool { return strings.EqualFold(d, l) }) {
+				dep.Disallowed = true
+			}
+		}
+		if dep.Disallowed {
+			flagged++
+		}
+		resp.Dependencies = append(resp.Dependencies, dep)
+	}
+	slices.SortStableFunc(resp.Dependencies, func(a, b DependencyLicense) int {
+		if a.Disallowed != b.Disallowed {
+			if a.Disallowed {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Module, b.Module)
+	})
+
+	for l, n := range counts {
+		kind, obligations := licenseObligations(l)
+		resp.Obligations = append(resp.Obligations, LicenseObligation{License: l, Kind: kind, Modules: n, Obligations: obligations})
+	}
+	slices.SortFunc(resp.Obligations, func(a, b LicenseObligation) int {
+		if d := licenseRank(b.Kind) - licenseRank(a.Kind); d != 0 {
+			return d
+		}
+		return strings.Compare(a.License, b.License)
+	})
+
+	if flagged > 0 {
+		resp.Message = fmt.Sprintf("⚠️ %d of %d dependencies use a disallowed license", flagged, len(resp.Dependencies))
+	} else {
+		resp.Message = fmt.Sprintf("✅ %d dependencies audited; none uses a disallowed license", len(resp.Dependencies))
+	}
+	if n := counts[UnknownLicense]; n > 0 {
+		resp.Message += fmt.Sprintf("; %d have no identified license", n)
+	}
+	if missing > 0 {
+		resp.Message += fmt.Sprintf(" (%d not downloaded)", missing)
+	}
+	return resp, nil
+}
+
+// listModules runs go list in dir and returns the main module and the
+// modules its packages are built from, or with all the whole module graph.
+func listModules(ctx context.Context, dir string, all bool) ([]listedModule, error) {
+	ctx, cancel := context.WithTimeout(ctx, goCommandTimeout)
+	defer cancel()
+	args := []string{"list", "-e", "-deps", "-json=Module", "./..."}
+	if all {
+		args = []string{"list", "-e", "-m", "-json", "all"}
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir, cmd.Stdout, cmd.Stderr = dir, &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("go %s did not finish within %s", strings.Join(args, " "), goCommandTimeout)
+		}
+		return nil, fmt.Errorf("go %s failed: %w\n%s", strings.Join(args, " "), err, shortenOutput(stderr.String()))
+	}
+
+	var modules []listedModule
+	seen := map[string]bool{}
+	dec := json.NewDecoder(&stdout)
+	for {
+		var m listedModule
+		var err error
+		if all {
+			err = dec.Decode(&m)
+		} else {
+			var pkg struct{ Module *listedModule }
+			err = dec.Decode(&pkg)
+			if pkg.Module != nil {
+				m = *pkg.Module
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the output of go list: %w", err)
+		}
+		// Standard library packages belong to no module.
+		if m.Path == "" || seen[m.Path] {
+			continue
+		}
+		seen[m.Path] = true
+		if m.Dir == "" && m.Replace != nil {
+			m.Dir = m.Replace.Dir
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+// licenseFile matches the names of the files that hold a module's license.
+var licenseFile = regexp.MustCompile(`(?i)^(licen[cs]e|copying|unlicense)([-._].*)?$`)
+
+// moduleLicenses identifies the licenses of the module in dir from its
+// license files, returning their SPDX IDs and the files' names.
+func moduleLicenses(dir string) (licenses, files []string) {
+	entries, err := os.ReadDir(dir)
+	if dir == "" || err != nil {
+		return []string{UnknownLicense}, nil
+	}
+	for _, e := range entries {
+		if e.IsDir() || !licenseFile.MatchString(e.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		files = append(files, e.Name())
+		if id := identifyLicense(data); !slices.Contains(licenses, id) {
+			licenses = append(licenses, id)
+		}
+	}
+	if len(licenses) == 0 {
+		return []string{UnknownLicense}, files
+	}
+	// A file that is not recognized next to one that is, such as an AUTHORS
+	// note named LICENSE.authors, says nothing about the license.
+	if len(licenses) > 1 {
+		licenses = slices.DeleteFunc(licenses, func(l string) bool { return l == UnknownLicense })
+	}
+	return licenses, files
+}
+
+// spdxIdentifier matches an SPDX-License-Identifier line.
+var spdxIdentifier = regexp.MustCompile(`SPDX-License-Identifier:\s*([A-Za-z0-9.+-]+)`)
+
+// identifyLicense returns the SPDX ID of the license text, from an
+// SPDX-License-Identifier line or the wording of the common open source
+// licenses, or UnknownLicense.
+func identifyLicense(text []byte) string {
+	if m := spdxIdentifier.FindSubmatch(text); m != nil {
+		return strings.TrimSuffix(strings.TrimSuffix(string(m[1]), "-only"), "-or-later")
+	}
+	t := strings.Join(strings.Fields(strings.ToLower(string(text))), " ")
+	has := func(phrases ...string) bool {
+		for _, p := range phrases {
+			if !strings.Contains(t, p) {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case has("gnu affero general public license"):
+		return "AGPL-3.0"
+	case has("gnu lesser general public license", "version 3"):
+		return "LGPL-3.0"
+	case has("gnu lesser general public license"), has("gnu library general public license"):
+		return "LGPL-2.1"
+	case has("gnu general public license", "version 3"):
+		return "GPL-3.0"
+	case has("gnu general public license"):
+		return "GPL-2.0"
+	case has("mozilla public license", "2.0"):
+		return "MPL-2.0"
+	case has("eclipse public license", "2.0"):
+		return "EPL-2.0"
+	case has("apache license", "version 2.0"):
+		return "Apache-2.0"
+	case has("boost software license"):
+		return "BSL-1.0"
+	case has("permission is hereby granted, free of charge"):
+		return "MIT"
+	case has("redistribution and use in source and binary forms"):
+		if has("endorse or promote") {
+			return "BSD-3-Clause"
+		}
+		return "BSD-2-Clause"
+	case has("permission to use, copy, modify, and"):
+		if has("provided that the above copyright notice") {
+			return "ISC"
+		}
+		return "0BSD"
+	case has("this is free and unencumbered software released into the public domain"):
+		return "Unlicense"
+	case has("cc0 1.0 universal"):
+		return "CC0-1.0"
+	case has("altered source versions must be plainly marked"):
+		return "Zlib"
+	}
+	return UnknownLicense
+}
+
+// licenseObligations returns the kind of a license and what it asks of a
+// program that distributes code under it.
+func licenseObligations(id string) (kind, obligations string) {
+	switch strings.ToUpper(id) {
+	case "UNLICENSE", "CC0-1.0", "0BSD":
+		return LicensePublicDomain, "None."
+	case "MIT", "ISC", "BSD-2-CLAUSE", "ZLIB", "BSL-1.0":
+		return LicensePermissive, "Include the copyright notice and license text with copies of the code, binaries included."
+	case "BSD-3-CLAUSE":
+		return LicensePermissive, "Include the copyright notice and license text with copies of the code, binaries included, and do not use the authors' names to promote the product."
+	case "APACHE-2.0":
+		return LicensePermissive, "Include the license and any NOTICE file with copies, and mark the files you changed. The patent license it grants ends for anyone who sues over patents in the work."
+	case "MPL-2.0", "EPL-2.0":
+		return LicenseWeakCopyleft, "Publish the source of the module's files, changes included, under the same license when distributing them; the rest of the program may use any license."
+	case "LGPL-2.1", "LGPL-3.0":
+		return LicenseWeakCopyleft, "Let users relink the program against a changed library: as Go links statically, provide the program's object files or source, and publish changes to the library under the LGPL."
+	case "GPL-2.0", "GPL-3.0":
+		return LicenseStrongCopyleft, "Distributing a program that includes the module requires releasing the whole program's source under the GPL."
+	case "AGPL-3.0":
+		return LicenseNetworkCopyleft, "As for the GPL, and users who interact with the program over a network must be offered its whole source, even if it is never distributed."
+	}
+	return UnknownLicense, "Read the license files yourself: without a license, the code may not be copied or used."
+}
+
+// licenseRank orders kinds of license by their obligations; unknown
+// licenses come first, as they need a human to read them.
+func licenseRank(kind string) int {
+	switch kind {
+	case LicensePublicDomain:
+		return 0
+	case LicensePermissive:
+		return 1
+	case LicenseWeakCopyleft:
+		return 2
+	case LicenseStrongCopyleft:
+		return 3
+	case LicenseNetworkCopyleft:
+		return 4
+	}
+	return 5
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"slices"
+	"testing"
+)
+
+const (
+	mitText    = "MIT License\n\nCopyright (c) 2024 Money Authors\n\nPermission is hereby granted, free of charge, to any person obtaining a copy\nof this software...\n"
+	gplText    = "                    GNU GENERAL PUBLIC LICENSE\n                       Version 3, 29 June 2007\n\n Copyright (C) 2007 Free Software Foundation, Inc.\n"
+	apacheText = "                                 Apache License\n                           Version 2.0, January 2004\n                        http://www.apache.org/licenses/\n"
+	bsd3Text   = "Redistribution and use in source and binary forms, with or without\nmodification, are permitted provided that the following conditions are met:\n...\nNeither the name of the copyright holder nor the names of its contributors\nmay be used to endorse or promote products derived from this software.\n"
+)
+
+func TestLicenseAudit(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	// The dependencies are replaced by local directories, so nothing is
+	// downloaded.
+	t.Setenv("GOFLAGS", "")
+	t.Setenv("GOPROXY", "off")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.21\n\n" +
+			"require (\n\texample.com/money v1.0.0\n\texample.com/copyleft v1.0.0\n\texample.com/bare v1.0.0\n)\n\n" +
+			"replace example.com/money => ./deps/money\n\nreplace example.com/copyleft => ./deps/copyleft\n\nreplace example.com/bare => ./deps/bare\n",
+		"LICENSE":                   apacheText,
+		"shop.go":                   "package shop\n\nimport (\n\t_ \"example.com/bare\"\n\t_ \"example.com/copyleft\"\n\t_ \"example.com/money\"\n)\n",
+		"deps/money/go.mod":         "module example.com/money\n\ngo 1.21\n",
+		"deps/money/LICENSE":        mitText,
+		"deps/money/money.go":       "package money\n",
+		"deps/copyleft/go.mod":      "module example.com/copyleft\n\ngo 1.21\n",
+		"deps/copyleft/COPYING":     gplText,
+		"deps/copyleft/copyleft.go": "package copyleft\n",
+		"deps/bare/go.mod":          "module example.com/bare\n\ngo 1.21\n",
+		"deps/bare/bare.go":         "package bare\n",
+	})
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewLicenseAuditTool(ctx, &LicenseAuditConfig{Disallowed: []string{"GPL-3.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := callTool[json.RawMessage, LicenseAuditResponse](t, ctx, bt, json.RawMessage(`{}`))
+	if resp.Error != "" {
+		t.Fatal(resp.Error)
+	}
+	if resp.Module != "example.com/shop" || !slices.Equal(resp.Licenses, []string{"Apache-2.0"}) {
+		t.Errorf("main module = %s %q, want example.com/shop under Apache-2.0", resp.Module, resp.Licenses)
+	}
+
+	var got []string
+	for _, d := range resp.Dependencies {
+		got = append(got, d.Module+" "+d.Licenses[0])
+	}
+	want := []string{"example.com/copyleft GPL-3.0", "example.com/bare unknown", "example.com/money MIT"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("dependencies = %q, want %q", got, want)
+	}
+	if d := resp.Dependencies[0]; !d.Disallowed || !slices.Equal(d.Files, []string{"COPYING"}) {
+		t.Errorf("copyleft = %+v, want it disallowed from COPYING", d)
+	}
+	if resp.Dependencies[1].Disallowed || resp.Dependencies[2].Disallowed {
+		t.Errorf("dependencies = %+v, want only copyleft disallowed", resp.Dependencies)
+	}
+	var kinds []string
+	for _, o := range resp.Obligations {
+		kinds = append(kinds, o.License+" "+o.Kind)
+	}
+	if want := []string{"unknown unknown", "GPL-3.0 strong copyleft", "MIT permissive"}; !slices.Equal(kinds, want) {
+		t.Errorf("obligations = %q, want %q", kinds, want)
+	}
+
+	// A license disallowed in the call adds to the configured ones.
+	resp = callTool[json.RawMessage, LicenseAuditResponse](t, ctx, bt, json.RawMessage(`{"disallowed":["unknown"]}`))
+	if n := slices.IndexFunc(resp.Dependencies, func(d DependencyLicense) bool { return !d.Disallowed }); n != 2 {
+		t.Errorf("dependencies = %+v, want copyleft and bare disallowed", resp.Dependencies)
+	}
+}
+
+func TestIdentifyLicense(t *testing.T) {
+	for text, want := range map[string]string{
+		mitText:    "MIT",
+		gplText:    "GPL-3.0",
+		apacheText: "Apache-2.0",
+		bsd3Text:   "BSD-3-Clause",
+		"GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n...GNU General Public License": "LGPL-3.0",
+		"GNU AFFERO GENERAL PUBLIC LICENSE\nVersion 3, 19 November 2007":                            "AGPL-3.0",
+		"Mozilla Public License Version 2.0\n==================================":                    "MPL-2.0",
+		"SPDX-License-Identifier: GPL-2.0-or-later\n":                                               "GPL-2.0",
+		"This is free and unencumbered software released into the public domain.":                   "Unlicense",
+		"All rights reserved.": UnknownLicense,
+	} {
+		if got := identifyLicense([]byte(text)); got != want {
+			t.Errorf("identifyLicense(%.40q) = %s, want %s", text, got, want)
+		}
+	}
+}
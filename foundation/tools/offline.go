@@ -0,0 +1,26 @@
+package tools
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrOffline is returned when a tool that needs the internet, or a hosted
+// model, is built in offline mode.
+var ErrOffline = errors.New("not available offline")
+
+type offlineKey struct{}
+
+// WithOffline marks tools built with the returned context as offline: the
+// knowledge base is only searched when its queries can be embedded locally.
+// Callers that assemble a toolbox should also leave out the tools that reach
+// the internet, such as web search.
+func WithOffline(ctx context.Context) context.Context {
+	return context.WithValue(ctx, offlineKey{}, true)
+}
+
+// Offline reports whether ctx was marked by WithOffline.
+func Offline(ctx context.Context) bool {
+	offline, _ := ctx.Value(offlineKey{}).(bool)
+	return offline
+}
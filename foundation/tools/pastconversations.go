@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/recall"
+)
+
+type PastConversationsRequest struct {
+	Query string `json:"query" jsonschema:"description=What to look for in earlier conversations, such as a decision, a file, an error or a topic"`
+}
+
+type PastConversationsResponse struct {
+	Conversations string `json:"conversations,omitempty" jsonschema:"description=The exchanges of earlier sessions that match the query, with their session, turn and date"`
+	Error         string `json:"error,omitempty" jsonschema:"description=Error message if the search failed"`
+}
+
+// NewPastConversationsTool returns the search_past_conversations tool, which
+// searches the exchanges of earlier sessions indexed by r, usually a
+// recall.Index.
+func NewPastConversationsTool(ctx context.Context, r retriever.Retriever) (tool.BaseTool, error) {
+	return inferTool(
+		"search_past_conversations",
+		"Search the conversations of earlier sessions. Use it when the user refers to something discussed or decided before (\"what did we decide about...\", \"like last time\"), or when an earlier answer would help. Returns the matching exchanges with the date of their session.",
+		func(ctx context.Context, req *PastConversationsRequest) (*PastConversationsResponse, error) {
+			if strings.TrimSpace(req.Query) == "" {
+				return &PastConversationsResponse{Error: "query cannot be empty"}, nil
+			}
+			docs, err := r.Retrieve(ctx, req.Query)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if errors.Is(err, chromemdb.ErrDBNotFound) {
+				return &PastConversationsResponse{Conversations: "No earlier conversations have been indexed."}, nil
+			}
+			if err != nil {
+				return &PastConversationsResponse{Error: fmt.Sprintf("Failed to search past conversations: %v", err)}, nil
+			}
+			return &PastConversationsResponse{Conversations: recall.Format(docs)}, nil
+		},
+	)
+}
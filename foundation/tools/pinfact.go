@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Pin limits keep pinned facts from crowding out the conversation they are
+// meant to guide.
+const (
+	maxPins      = 20
+	maxPinLength = 500
+)
+
+// pinnedHeading introduces the pinned facts to the model.
+const pinnedHeading = "Pinned facts. These were pinned for the whole session: they hold in every turn and outrank anything said in the conversation."
+
+// Pins are the facts pinned in a session, with /pin or the pin_fact tool,
+// such as "never modify files under /legacy". The agent sends them before the
+// conversation in every turn, so unlike the conversation they are never
+// summarized by /compact nor dropped to fit the context window. Pins is safe
+// for concurrent use, and a nil *Pins holds no facts.
+type Pins struct {
+	mu    sync.Mutex
+	facts []string
+}
+
+// NewPins returns an empty set of pins.
+func NewPins() *Pins {
+	return &Pins{}
+}
+
+// Add pins fact and returns its number, from 1. A fact pinned already keeps
+// its number.
+func (p *Pins) Add(fact string) (int, error) {
+	fact = strings.Join(strings.Fields(fact), " ")
+	switch {
+	case fact == "":
+		return 0, errors.New("fact cannot be empty")
+	case len(fact) > maxPinLength:
+		return 0, fmt.Errorf("fact is %d characters long; pin at most %d, stated as briefly as possible", len(fact), maxPinLength)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i := slices.Index(p.facts, fact); i >= 0 {
+		return i + 1, nil
+	}
+	if len(p.facts) >= maxPins {
+		return 0, fmt.Errorf("%d facts are pinned already, the most allowed; unpin one first", maxPins)
+	}
+	p.facts = append(p.facts, fact)
+	return len(p.facts), nil
+}
+
+// Remove unpins the fact numbered n and reports whether there was one.
+func (p *Pins) Remove(n int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n < 1 || n > len(p.facts) {
+		return false
+	}
+	p.facts = slices.Delete(p.facts, n-1, n)
+	return true
+}
+
+// Clear unpins every fact and returns how many there were.
+func (p *Pins) Clear() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := len(p.facts)
+	p.facts = nil
+	return n
+}
+
+// Facts returns the pinned facts in the order they were pinned.
+func (p *Pins) Facts() []string {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.facts)
+}
+
+// Prompt returns the pinned facts as the model reads them, numbered, or ""
+// when none are pinned.
+func (p *Pins) Prompt() string {
+	facts := p.Facts()
+	if len(facts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<pinned>\n" + pinnedHeading + "\n")
+	for i, fact := range facts {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, fact)
+	}
+	b.WriteString("</pinned>")
+	return b.String()
+}
+
+type pinsKey struct{}
+
+// WithPins lets pin_fact, called with the returned context, pin facts to p.
+// Without pins, as in one-off requests to the server, the tool refuses.
+func WithPins(ctx context.Context, p *Pins) context.Context {
+	return context.WithValue(ctx, pinsKey{}, p)
+}
+
+type PinFactRequest struct {
+	Fact string `json:"fact" jsonschema:"description=The fact or constraint to keep in mind for the rest of the session, stated in one short sentence."`
+}
+
+type PinFactResponse struct {
+	Number  int    `json:"number,omitempty" jsonschema:"description=Number of the pinned fact."`
+	Pinned  int    `json:"pinned,omitempty" jsonschema:"description=How many facts are pinned now."`
+	Message string `json:"message,omitempty" jsonschema:"description=Confirmation message."`
+	Error   string `json:"error,omitempty" jsonschema:"description=Error message if the fact was not pinned."`
+}
+
+// NewPinFactTool returns the pin_fact tool, which pins a fact to the pins of
+// the call's context (see WithPins).
+func NewPinFactTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"pin_fact",
+		"Pin a fact or constraint that must stay in mind for the rest of the session, such as a rule the user set "+
+			"(\"never modify files under /legacy\"), a decision taken or a name to use. Pinned facts are sent with "+
+			"every turn and survive compaction of the conversation. Pin only what matters for later turns, stated briefly; "+
+			"the user sees and can remove pins with /pin and /unpin.",
+		func(ctx context.Context, req *PinFactRequest) (*PinFactResponse, error) {
+			pins, _ := ctx.Value(pinsKey{}).(*Pins)
+			if pins == nil {
+				return &PinFactResponse{Error: "facts cannot be pinned in this session"}, nil
+			}
+			n, err := pins.Add(req.Fact)
+			if err != nil {
+				return &PinFactResponse{Error: err.Error()}, nil
+			}
+			return &PinFactResponse{Number: n, Pinned: len(pins.Facts()), Message: fmt.Sprintf("Pinned as fact %d.", n)}, nil
+		},
+	)
+}
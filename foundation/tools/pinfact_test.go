@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPins(t *testing.T) {
+	pins := NewPins()
+	if n, err := pins.Add("  never modify\nfiles under /legacy "); n != 1 || err != nil {
+		t.Fatalf("Add = %d, %v", n, err)
+	}
+	if n, _ := pins.Add("never modify files under /legacy"); n != 1 {
+		t.Errorf("pinning a fact again numbered it %d, want 1", n)
+	}
+	if _, err := pins.Add(strings.Repeat("x", maxPinLength+1)); err == nil {
+		t.Error("pinned a fact over the length limit")
+	}
+	pins.Add("the module is github.com/acme/shop")
+	if got := pins.Prompt(); !strings.HasPrefix(got, "<pinned>\n") ||
+		!strings.Contains(got, "\n1. never modify files under /legacy\n2. the module is github.com/acme/shop\n</pinned>") {
+		t.Errorf("Prompt = %q", got)
+	}
+	if !pins.Remove(1) || pins.Remove(5) || len(pins.Facts()) != 1 {
+		t.Errorf("Remove left %q", pins.Facts())
+	}
+	if n := pins.Clear(); n != 1 || pins.Prompt() != "" {
+		t.Errorf("Clear = %d, leaving %q", n, pins.Prompt())
+	}
+	for range maxPins {
+		pins.Add(strings.Repeat("y", len(pins.Facts())+1))
+	}
+	if _, err := pins.Add("one too many"); err == nil {
+		t.Errorf("pinned more than %d facts", maxPins)
+	}
+}
+
+func TestPinFactTool(t *testing.T) {
+	bt, err := NewPinFactTool(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pin := func(ctx context.Context, fact string) PinFactResponse {
+		t.Helper()
+		return callTool[PinFactRequest, PinFactResponse](t, ctx, bt, PinFactRequest{Fact: fact})
+	}
+
+	pins := NewPins()
+	ctx := WithPins(context.Background(), pins)
+	if resp := pin(ctx, "Use Go 1.25."); resp.Number != 1 || resp.Pinned != 1 || pins.Facts()[0] != "Use Go 1.25." {
+		t.Errorf("pin_fact = %+v", resp)
+	}
+	if resp := pin(ctx, ""); resp.Error == "" {
+		t.Error("pinned an empty fact")
+	}
+	if resp := pin(context.Background(), "Use Go 1.25."); resp.Error == "" {
+		t.Error("pinned a fact without pins in the context")
+	}
+}
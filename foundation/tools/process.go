@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+)
+
+type outputKey struct{}
+
+// WithOutput streams the output of the commands tools run, stdout and stderr
+// interleaved, to w while they run, for calls made with the returned context.
+// The terminal UI sets it per tool call to show progress; w receives the raw
+// bytes, so callers split lines themselves. The tool still returns the full
+// output to the model when the command ends.
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, w)
+}
+
+// outputOf returns the writer set by WithOutput, or nil.
+func outputOf(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(outputKey{}).(io.Writer)
+	return w
+}
+
+// runCommand runs name with args in dir and returns its combined output,
+// copying it to the WithOutput writer of ctx as it is produced.
+func runCommand(ctx context.Context, dir, name string, args ...string) (string, error) {
+	var buf bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	// With one writer for both streams, exec calls Write from one goroutine
+	// at a time.
+	var w io.Writer = &buf
+	if out := outputOf(ctx); out != nil {
+		w = io.MultiWriter(&buf, out)
+	}
+	cmd.Stdout, cmd.Stderr = w, w
+	err := cmd.Run()
+	return buf.String(), err
+}
@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Limits on profile_program's report.
+const (
+	defaultHotspots = 15
+	maxHotspots     = 50
+)
+
+type ProfileProgramRequest struct {
+	Path      string `json:"path,omitempty" jsonschema:"description=Directory of the Go package to profile, inside the module. Defaults to the workspace root. A program is profiled through a test or benchmark of its package that exercises the slow path."`
+	Bench     string `json:"bench,omitempty" jsonschema:"description=Benchmarks to run and profile, as 'go test -bench', e.g. 'BenchmarkParse' or '.'. When set, tests are not run unless run selects some."`
+	Run       string `json:"run,omitempty" jsonschema:"description=Tests to run and profile, as 'go test -run'. Defaults to every test, or none when bench is set."`
+	BenchTime string `json:"bench_time,omitempty" jsonschema:"description=How long each benchmark runs, as 'go test -benchtime': a duration such as '2s' or a count such as '500x'. Longer runs give steadier profiles."`
+	Kind      string `json:"kind,omitempty" jsonschema:"description=What to profile: 'cpu' (default), where time is spent, or 'memory', where bytes are allocated."`
+	Top       int    `json:"top,omitempty" jsonschema:"description=How many hotspots to report, 1-50. Defaults to 15."`
+}
+
+type ProfileProgramResponse struct {
+	Command    string    `json:"command,omitempty" jsonschema:"description=The go test command that was profiled."`
+	Kind       string    `json:"kind,omitempty" jsonschema:"description=cpu or memory."`
+	Total      string    `json:"total,omitempty" jsonschema:"description=Total CPU time sampled or bytes allocated."`
+	Hotspots   []Hotspot `json:"hotspots,omitempty" jsonschema:"description=The functions with the most flat time or allocation, highest first."`
+	Benchmarks []string  `json:"benchmarks,omitempty" jsonschema:"description=Result lines of the benchmarks that ran, e.g. ns/op."`
+	Output     string    `json:"output,omitempty" jsonschema:"description=Output of go test when it failed."`
+	Error      string    `json:"error,omitempty" jsonschema:"description=Error message if the program could not be profiled."`
+}
+
+// Hotspot is a function of a profile report, as a row of 'go tool pprof -top'.
+type Hotspot struct {
+	Function    string  `json:"function"`
+	Flat        string  `json:"flat" jsonschema:"description=Time or bytes in the function itself."`
+	FlatPercent float64 `json:"flat_percent"`
+	Cum         string  `json:"cum" jsonschema:"description=Time or bytes in the function and everything it calls."`
+	CumPercent  float64 `json:"cum_percent"`
+	Inlined     bool    `json:"inlined,omitempty" jsonschema:"description=The function was inlined into its callers."`
+}
+
+// NewProfileProgramTool returns the profile_program tool, which runs a
+// package's tests or benchmarks under the CPU or memory profiler and reports
+// the hottest functions, so questions about performance are answered from a
+// profile rather than a guess.
+func NewProfileProgramTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"profile_program",
+		"Profile a Go package's benchmarks or tests with pprof and report the hotspots: the functions where the most CPU time is spent or the most memory is allocated, with flat and cumulative figures, plus the benchmark results. Use it to answer 'why is this slow?' or 'what allocates?' from measurements; read the hot functions with read_file before suggesting changes.",
+		func(ctx context.Context, req *ProfileProgramRequest) (*ProfileProgramResponse, error) {
+			testArgs, pprofArgs, err := profileArgs(req)
+			if err != nil {
+				return &ProfileProgramResponse{Error: err.Error()}, nil
+			}
+			dir, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &ProfileProgramResponse{Error: err.Error()}, nil
+			}
+			if dir == "" {
+				dir = "."
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return &ProfileProgramResponse{Error: fmt.Sprintf("'%s' is not a directory", req.Path)}, nil
+			}
+
+			resp, err := profilePackage(ctx, dir, testArgs, pprofArgs)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				resp.Error = err.Error()
+			}
+			return resp, nil
+		},
+	)
+}
+
+// profileArgs returns the arguments of go test, before the profile and
+// binary paths, and of go tool pprof, before the binary and profile, that
+// req asks for.
+func profileArgs(req *ProfileProgramRequest) (testArgs, pprofArgs []string, err error) {
+	top := req.Top
+	switch {
+	case top == 0:
+		top = defaultHotspots
+	case top < 0 || top > maxHotspots:
+		return nil, nil, fmt.Errorf("top must be between 1 and %d", maxHotspots)
+	}
+	for name, pattern := range map[string]string{"bench": req.Bench, "run": req.Run} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s pattern: %w", name, err)
+		}
+	}
+	if strings.HasPrefix(req.BenchTime, "-") || strings.ContainsAny(req.BenchTime, " \t\n") {
+		return nil, nil, fmt.Errorf("invalid bench_time '%s'", req.BenchTime)
+	}
+
+	testArgs = []string{"test", "-count=1"}
+	run := req.Run
+	if run == "" && req.Bench != "" {
+		run = "^$"
+	}
+	if run != "" {
+		testArgs = append(testArgs, "-run", run)
+	}
+	if req.Bench != "" {
+		testArgs = append(testArgs, "-bench", req.Bench, "-benchmem")
+		if req.BenchTime != "" {
+			testArgs = append(testArgs, "-benchtime", req.BenchTime)
+		}
+	} else if req.BenchTime != "" {
+		return nil, nil, errors.New("bench_time only applies with bench")
+	}
+
+	pprofArgs = []string{"tool", "pprof", "-top", "-nodecount=" + strconv.Itoa(top)}
+	switch req.Kind {
+	case "", "cpu":
+		testArgs = append(testArgs, "-cpuprofile")
+	case "memory":
+		testArgs = append(testArgs, "-memprofile")
+		pprofArgs = append(pprofArgs, "-sample_index=alloc_space")
+	default:
+		return nil, nil, fmt.Errorf("unsupported kind '%s'. Use: cpu, memory", req.Kind)
+	}
+	return testArgs, pprofArgs, nil
+}
+
+// profilePackage runs go test in dir with testArgs, which end in the profile
+// flag, and reports the profile with go tool pprof and pprofArgs.
+func profilePackage(ctx context.Context, dir string, testArgs, pprofArgs []string) (*ProfileProgramResponse, error) {
+	resp := &ProfileProgramResponse{Kind: "cpu"}
+	if testArgs[len(testArgs)-1] == "-memprofile" {
+		resp.Kind = "memory"
+	}
+	tmp, err := os.MkdirTemp("", "goforai-profile-")
+	if err != nil {
+		return resp, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	profile, binary := filepath.Join(tmp, "profile.out"), filepath.Join(tmp, "pkg.test")
+
+	ctx, cancel := context.WithTimeout(ctx, runGoTimeout)
+	defer cancel()
+	args := append(testArgs, profile, "-o", binary, ".")
+	resp.Command = "go " + strings.Join(args[:len(args)-4], " ") + " <profile> ."
+	output, err := runCommand(ctx, dir, "go", args...)
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Benchmark") && strings.Contains(line, "/op") {
+			resp.Benchmarks = append(resp.Benchmarks, strings.Join(strings.Fields(line), " "))
+		}
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return resp, fmt.Errorf("go test did not finish within %s", runGoTimeout)
+	case errors.As(err, &exitErr):
+		resp.Output = shortenOutput(output)
+		return resp, errors.New("go test failed; the profile is only reported for passing runs")
+	case err != nil:
+		return resp, fmt.Errorf("failed to run go test: %w", err)
+	}
+	if _, err := os.Stat(profile); err != nil {
+		return resp, errors.New("go test wrote no profile: the package has no tests or benchmarks matching run and bench")
+	}
+
+	report, err := exec.CommandContext(ctx, "go", append(pprofArgs, binary, profile)...).Output()
+	if err != nil {
+		return resp, fmt.Errorf("failed to read the profile with go tool pprof: %w", err)
+	}
+	resp.Total, resp.Hotspots = parsePprofTop(string(report))
+	if len(resp.Hotspots) == 0 {
+		return resp, errors.New("the profile recorded no samples: the run was too short; profile a longer benchmark or raise bench_time")
+	}
+	return resp, nil
+}
+
+// pprofTotal matches the summary line of a pprof -top report, such as
+// "Showing nodes accounting for 110ms, 100% of 110ms total".
+var pprofTotal = regexp.MustCompile(`of (\S+) total`)
+
+// parsePprofTop parses the text of 'go tool pprof -top' into its total and
+// its rows.
+func parsePprofTop(report string) (total string, hotspots []Hotspot) {
+	if m := pprofTotal.FindStringSubmatch(report); m != nil {
+		total = m[1]
+	}
+	inRows := false
+	for _, line := range strings.Split(report, "\n") {
+		fields := strings.Fields(line)
+		if !inRows {
+			inRows = len(fields) == 5 && fields[0] == "flat" && fields[4] == "cum%"
+			continue
+		}
+		if len(fields) < 6 {
+			continue
+		}
+		flatPct, err1 := strconv.ParseFloat(strings.TrimSuffix(fields[1], "%"), 64)
+		cumPct, err2 := strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		function, inlined := strings.CutSuffix(strings.Join(fields[5:], " "), " (inline)")
+		hotspots = append(hotspots, Hotspot{
+			Function:    function,
+			Flat:        fields[0],
+			FlatPercent: flatPct,
+			Cum:         fields[3],
+			CumPercent:  cumPct,
+			Inlined:     inlined,
+		})
+	}
+	return total, hotspots
+}
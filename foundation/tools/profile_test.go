@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestProfileProgram(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	t.Setenv("GOFLAGS", "")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.22\n",
+		"cart.go": `package shop
+
+var sink [][]byte
+
+// Fill allocates a megabyte, in pieces, on every call.
+func Fill() {
+	for range 16 {
+		sink = append(sink, make([]byte, 64<<10))
+	}
+	sink = sink[:0]
+}
+`,
+		"cart_test.go": `package shop
+
+import "testing"
+
+func BenchmarkFill(b *testing.B) {
+	for range b.N {
+		Fill()
+	}
+}
+
+func TestBroken(t *testing.T) { t.Fatal("cart is wrong") }
+`,
+	})
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewProfileProgramTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(args string) *ProfileProgramResponse {
+		t.Helper()
+		resp := callTool[json.RawMessage, ProfileProgramResponse](t, ctx, bt, json.RawMessage(args))
+		return &resp
+	}
+
+	resp := call(`{"bench":"Fill","bench_time":"50x","kind":"memory","top":3}`)
+	if resp.Error != "" {
+		t.Fatalf("profile failed: %s\n%s", resp.Error, resp.Output)
+	}
+	if resp.Kind != "memory" || resp.Total == "" || len(resp.Hotspots) == 0 || len(resp.Hotspots) > 3 {
+		t.Fatalf("report = %+v", resp)
+	}
+	if h := resp.Hotspots[0]; h.Function != "example.com/shop.Fill" || h.FlatPercent < 50 {
+		t.Errorf("top hotspot = %+v, want Fill", h)
+	}
+	if len(resp.Benchmarks) != 1 || !strings.HasPrefix(resp.Benchmarks[0], "BenchmarkFill") {
+		t.Errorf("benchmarks = %q", resp.Benchmarks)
+	}
+
+	if resp := call(`{"run":"Broken"}`); !strings.Contains(resp.Error, "go test failed") || !strings.Contains(resp.Output, "cart is wrong") {
+		t.Errorf("failing test = %+v", resp)
+	}
+	if resp := call(`{"kind":"goroutine"}`); !strings.Contains(resp.Error, "unsupported kind") {
+		t.Errorf("unknown kind = %+v", resp)
+	}
+	if resp := call(`{"bench_time":"1s"}`); !strings.Contains(resp.Error, "only applies with bench") {
+		t.Errorf("bench_time without bench = %+v", resp)
+	}
+}
+
+func TestParsePprofTop(t *testing.T) {
+	report := `File: pp.test
+Type: cpu
+Duration: 304.69ms, Total samples = 110ms (36.10%)
+Showing nodes accounting for 110ms, 100% of 110ms total
+      flat  flat%   sum%        cum   cum%
+     110ms   100%   100%      110ms   100%  example.com/pp.fib
+         0     0%   100%      110ms   100%  testing.(*B).runN
+  583.01kB  3.29%   100%   583.01kB  3.29%  compress/flate.newDeflateFast (inline)
+`
+	total, hotspots := parsePprofTop(report)
+	if total != "110ms" || len(hotspots) != 3 {
+		t.Fatalf("parsePprofTop = %q, %+v", total, hotspots)
+	}
+	want := Hotspot{Function: "example.com/pp.fib", Flat: "110ms", FlatPercent: 100, Cum: "110ms", CumPercent: 100}
+	if hotspots[0] != want {
+		t.Errorf("first row = %+v, want %+v", hotspots[0], want)
+	}
+	if h := hotspots[2]; h.Function != "compress/flate.newDeflateFast" || !h.Inlined || h.FlatPercent != 3.29 {
+		t.Errorf("last row = %+v", hotspots[2])
+	}
+}
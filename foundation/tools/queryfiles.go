@@ -0,0 +1,504 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/tool"
+	_ "modernc.org/sqlite" // Pure-Go driver keeps the binary cgo-free.
+)
+
+// Limits that keep a query_files call quick and its result small.
+const (
+	maxQueryFiles     = 10
+	maxQueryFileBytes = 100 << 20
+	defaultQueryRows  = 50
+	maxQueryRows      = 500
+	queryTimeout      = 30 * time.Second
+)
+
+type QueryFilesRequest struct {
+	Files []string `json:"files" jsonschema:"description=Paths of the data files to query (up to 10): CSV\\, TSV\\, JSON Lines (.jsonl or .ndjson) or a JSON array of objects (.json). Each becomes a table named after the file\\, e.g. data/sales_2024.csv becomes sales_2024."`
+	Query string   `json:"query" jsonschema:"description=One SQLite SELECT (or WITH ... SELECT) statement over the tables\\, e.g. SELECT region\\, SUM(amount) AS total FROM sales_2024 GROUP BY region ORDER BY total DESC."`
+	Limit int      `json:"limit,omitempty" jsonschema:"description=Maximum rows to return (default 50\\, max 500). Aggregate in SQL rather than raising it."`
+}
+
+// QueryTable describes a file loaded as a table.
+type QueryTable struct {
+	Name    string   `json:"name" jsonschema:"description=Table name to use in the query."`
+	File    string   `json:"file" jsonschema:"description=File the table was loaded from."`
+	Columns []string `json:"columns" jsonschema:"description=Column names\\, from the CSV header or the JSON keys."`
+	Rows    int      `json:"rows" jsonschema:"description=Number of rows loaded."`
+}
+
+type QueryFilesResponse struct {
+	Tables    []QueryTable `json:"tables,omitempty" jsonschema:"description=The tables the files were loaded as."`
+	Columns   []string     `json:"columns,omitempty" jsonschema:"description=Columns of the result."`
+	Rows      [][]any      `json:"rows,omitempty" jsonschema:"description=Result rows\\, each with a value per column."`
+	Truncated bool         `json:"truncated,omitempty" jsonschema:"description=The query returned more rows than the limit."`
+	Error     string       `json:"error,omitempty" jsonschema:"description=Error message if a file could not be loaded or the query failed."`
+}
+
+// NewQueryFilesTool returns the query_files tool, which loads local data
+// files into an in-memory SQLite database and runs a read-only query over
+// them, so that questions about a dataset are answered without reading it
+// into the conversation. Parquet is not supported: the embedded engine has
+// no reader for it.
+func NewQueryFilesTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"query_files",
+		"Run a read-only SQL query (SQLite dialect) over local CSV, TSV, JSON Lines or JSON files, each loaded as a table named after the file. "+
+			"Use it to count, filter, aggregate or join tabular data instead of reading the files with read_file. "+
+			"The response lists each table's columns, so a first query such as SELECT * FROM t LIMIT 5 shows the data's shape. Numbers in the files are typed as numbers.",
+		func(ctx context.Context, req *QueryFilesRequest) (*QueryFilesResponse, error) {
+			if len(req.Files) == 0 {
+				return &QueryFilesResponse{Error: "files cannot be empty"}, nil
+			}
+			if len(req.Files) > maxQueryFiles {
+				return &QueryFilesResponse{Error: fmt.Sprintf("too many files: %d (max %d)", len(req.Files), maxQueryFiles)}, nil
+			}
+			query, err := readOnlyQuery(req.Query)
+			if err != nil {
+				return &QueryFilesResponse{Error: err.Error()}, nil
+			}
+			limit := req.Limit
+			if limit <= 0 {
+				limit = defaultQueryRows
+			}
+			limit = min(limit, maxQueryRows)
+
+			ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+			defer cancel()
+			db, err := sql.Open("sqlite", ":memory:")
+			if err != nil {
+				return nil, fmt.Errorf("failed to open in-memory database: %w", err)
+			}
+			defer db.Close()
+			// Every connection to :memory: is a database of its own.
+			db.SetMaxOpenConns(1)
+
+			resp := &QueryFilesResponse{}
+			names := make(map[string]bool)
+			for _, file := range req.Files {
+				path, err := resolvePath(ctx, file)
+				if errors.Is(err, ErrToolDenied) {
+					return nil, err
+				}
+				if err != nil {
+					return &QueryFilesResponse{Error: err.Error()}, nil
+				}
+				table, err := loadTable(ctx, db, path, tableName(file, names))
+				if err != nil {
+					return &QueryFilesResponse{Tables: resp.Tables, Error: fmt.Sprintf("failed to load '%s': %v", file, err)}, nil
+				}
+				table.File = file
+				resp.Tables = append(resp.Tables, *table)
+			}
+
+			if _, err := db.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+				return nil, fmt.Errorf("failed to make the database read-only: %w", err)
+			}
+			if err := runQuery(ctx, db, query, limit, resp); err != nil {
+				resp.Error = err.Error()
+			}
+			return resp, nil
+		},
+	)
+}
+
+// readOnlyQuery checks that query is a single SELECT statement and returns
+// it without a trailing semicolon. PRAGMA query_only stops writes in any
+// case; this also keeps out ATTACH, which would open other database files.
+func readOnlyQuery(query string) (string, error) {
+	query = strings.TrimSpace(query)
+	query = strings.TrimSpace(strings.TrimSuffix(query, ";"))
+	if query == "" {
+		return "", errors.New("query cannot be empty")
+	}
+	words := strings.FieldsFunc(query, func(r rune) bool { return !unicode.IsLetter(r) })
+	if len(words) == 0 {
+		return "", fmt.Errorf("not a SQL query: %s", query)
+	}
+	if first := strings.ToUpper(words[0]); first != "SELECT" && first != "WITH" && first != "VALUES" {
+		return "", fmt.Errorf("only SELECT queries are allowed, not %s", first)
+	}
+	if strings.Contains(stripLiterals(query), ";") {
+		return "", errors.New("only one statement is allowed")
+	}
+	return query, nil
+}
+
+// stripLiterals removes quoted strings and identifiers from query, so that
+// a semicolon inside one is not taken for the end of a statement.
+func stripLiterals(query string) string {
+	var b strings.Builder
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tableName names the table for file after its base name, made a plain SQL
+// identifier and distinct from the names already taken.
+func tableName(file string, taken map[string]bool) string {
+	base := filepath.Base(file)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, base)
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "t_" + name
+	}
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// loadTable reads the file at path into a new table called name.
+func loadTable(ctx context.Context, db *sql.DB, path, name string) (*QueryTable, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("file not found; use search_files to find the correct path")
+		}
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, errors.New("it is a directory, not a file")
+	}
+	if info.Size() > maxQueryFileBytes {
+		return nil, fmt.Errorf("file is %d MB (max %d MB)", info.Size()>>20, maxQueryFileBytes>>20)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var columns []string
+	var rows [][]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		columns, rows, err = readDelimited(f, ',')
+	case ".tsv", ".tab":
+		columns, rows, err = readDelimited(f, '\t')
+	case ".jsonl", ".ndjson":
+		columns, rows, err = readJSONLines(f)
+	case ".json":
+		columns, rows, err = readJSONArray(f)
+	case ".parquet":
+		err = errors.New("parquet files are not supported; convert the file to CSV or JSON Lines")
+	default:
+		err = fmt.Errorf("unsupported file type '%s'; use CSV, TSV, JSON Lines or JSON", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("the file holds no columns")
+	}
+	if err := createTable(ctx, db, name, columns, rows); err != nil {
+		return nil, err
+	}
+	return &QueryTable{Name: name, Columns: columns, Rows: len(rows)}, nil
+}
+
+// readDelimited reads a CSV or TSV file whose first record is the header.
+// Values are typed per column: a column whose values all parse as integers
+// or as numbers holds numbers, and empty values are NULL.
+func readDelimited(r io.Reader, comma rune) ([]string, [][]any, error) {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := columnNames(header)
+	var records [][]string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		records = append(records, record)
+	}
+
+	kinds := make([]byte, len(columns)) // 'i'nteger, 'r'eal or 't'ext.
+	for c := range columns {
+		kinds[c] = 'i'
+		for _, record := range records {
+			if c >= len(record) || record[c] == "" {
+				continue
+			}
+			if kinds[c] == 'i' {
+				if _, err := strconv.ParseInt(record[c], 10, 64); err == nil {
+					continue
+				}
+				kinds[c] = 'r'
+			}
+			if _, err := strconv.ParseFloat(record[c], 64); err != nil {
+				kinds[c] = 't'
+				break
+			}
+		}
+	}
+
+	rows := make([][]any, len(records))
+	for i, record := range records {
+		row := make([]any, len(columns))
+		for c := range columns {
+			if c >= len(record) || record[c] == "" {
+				continue
+			}
+			switch kinds[c] {
+			case 'i':
+				row[c], _ = strconv.ParseInt(record[c], 10, 64)
+			case 'r':
+				row[c], _ = strconv.ParseFloat(record[c], 64)
+			default:
+				row[c] = record[c]
+			}
+		}
+		rows[i] = row
+	}
+	return columns, rows, nil
+}
+
+// columnNames names blank header fields after their position and makes
+// repeated ones distinct.
+func columnNames(header []string) []string {
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, h := range header {
+		name := strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		if name == "" {
+			name = fmt.Sprintf("column_%d", i+1)
+		}
+		unique := name
+		for n := 2; seen[strings.ToLower(unique)]; n++ {
+			unique = fmt.Sprintf("%s_%d", name, n)
+		}
+		seen[strings.ToLower(unique)] = true
+		columns[i] = unique
+	}
+	return columns
+}
+
+// readJSONLines reads one JSON object per line, skipping blank lines.
+func readJSONLines(r io.Reader) ([]string, [][]any, error) {
+	var t objectTable
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxQueryFileBytes)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		if err := t.add(sc.Bytes()); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	return t.rows()
+}
+
+// readJSONArray reads a JSON array of objects.
+func readJSONArray(r io.Reader) ([]string, [][]any, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, nil, fmt.Errorf("expected a JSON array of objects: %w", err)
+	}
+	var t objectTable
+	for i, item := range items {
+		if err := t.add(item); err != nil {
+			return nil, nil, fmt.Errorf("element %d: %w", i+1, err)
+		}
+	}
+	return t.rows()
+}
+
+// objectTable collects JSON objects as rows. Its columns are the keys of
+// all the objects, in the order they first appear.
+type objectTable struct {
+	columns []string
+	seen    map[string]bool
+	objects []map[string]any
+}
+
+func (t *objectTable) add(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return err
+	}
+	if obj == nil {
+		return errors.New("not a JSON object")
+	}
+	// A map loses the order of the keys, so read them again in order.
+	keys := json.NewDecoder(bytes.NewReader(data))
+	keys.Token() // {
+	for keys.More() {
+		tok, err := keys.Token()
+		if err != nil {
+			return err
+		}
+		if k, _ := tok.(string); !t.seen[k] {
+			if t.seen == nil {
+				t.seen = make(map[string]bool)
+			}
+			t.seen[k] = true
+			t.columns = append(t.columns, k)
+		}
+		var skip json.RawMessage
+		if err := keys.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	t.objects = append(t.objects, obj)
+	return nil
+}
+
+// rows lays out the objects as rows. Numbers stay numbers, booleans become
+// 1 or 0, and nested arrays and objects are kept as JSON text, which
+// SQLite's JSON functions can read.
+func (t *objectTable) rows() ([]string, [][]any, error) {
+	rows := make([][]any, len(t.objects))
+	for i, obj := range t.objects {
+		row := make([]any, len(t.columns))
+		for c, col := range t.columns {
+			switch v := obj[col].(type) {
+			case nil:
+			case json.Number:
+				if n, err := v.Int64(); err == nil {
+					row[c] = n
+				} else if f, err := v.Float64(); err == nil {
+					row[c] = f
+				} else {
+					row[c] = v.String()
+				}
+			case bool:
+				row[c] = 0
+				if v {
+					row[c] = 1
+				}
+			case string:
+				row[c] = v
+			default:
+				data, err := json.Marshal(v)
+				if err != nil {
+					return nil, nil, err
+				}
+				row[c] = string(data)
+			}
+		}
+		rows[i] = row
+	}
+	return t.columns, rows, nil
+}
+
+// createTable creates the table name with columns and inserts rows. The
+// columns have no declared type, so each value keeps the type it was read
+// with.
+func createTable(ctx context.Context, db *sql.DB, name string, columns []string, rows [][]any) error {
+	quoted := make([]string, len(columns))
+	params := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdent(c)
+		params[i] = "?"
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(name), strings.Join(quoted, ", "))); err != nil {
+		return err
+	}
+	insert, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%s)", quoteIdent(name), strings.Join(params, ", ")))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, row := range rows {
+		if _, err := insert.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// runQuery runs query and adds up to limit of its rows to resp.
+func runQuery(ctx context.Context, db *sql.DB, query string, limit int, resp *QueryFilesResponse) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if resp.Columns, err = rows.Columns(); err != nil {
+		return err
+	}
+	for rows.Next() {
+		if len(resp.Rows) == limit {
+			resp.Truncated = true
+			break
+		}
+		values := make([]any, len(resp.Columns))
+		ptrs := make([]any, len(values))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		resp.Rows = append(resp.Rows, values)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func TestQueryFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"sales-2024.csv": "region,amount,rep\nnorth,10,ann\nsouth,2.5,bo\nnorth,30,\nwest,4,cy\n",
+		"reps.jsonl":     `{"name":"ann","team":"a","senior":true}` + "\n\n" + `{"name":"bo","team":"b","tags":["x"]}` + "\n",
+		"sales.parquet":  "PAR1",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewQueryFilesTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := callTool[QueryFilesRequest, QueryFilesResponse](t, ctx, bt, QueryFilesRequest{
+		Files: []string{"sales-2024.csv", "reps.jsonl"},
+		Query: `SELECT s.region, SUM(s.amount) AS total, MAX(r.senior) AS senior
+			FROM sales_2024 s LEFT JOIN reps r ON r.name = s.rep
+			GROUP BY s.region ORDER BY total DESC;`,
+		Limit: 2,
+	})
+	if resp.Error != "" {
+		t.Fatal(resp.Error)
+	}
+	if len(resp.Tables) != 2 || resp.Tables[0].Name != "sales_2024" || resp.Tables[0].Rows != 4 ||
+		strings.Join(resp.Tables[1].Columns, ",") != "name,team,senior,tags" {
+		t.Errorf("tables = %+v", resp.Tables)
+	}
+	// Amounts are summed as numbers, not compared as text.
+	rows, _ := json.Marshal(resp.Rows)
+	if string(rows) != `[["north",40,1],["west",4,null]]` || !resp.Truncated {
+		t.Errorf("rows %s, truncated %v", rows, resp.Truncated)
+	}
+
+	for name, tc := range map[string]struct {
+		req  QueryFilesRequest
+		want string
+	}{
+		"write":        {QueryFilesRequest{Files: []string{"reps.jsonl"}, Query: "DELETE FROM reps"}, "only SELECT"},
+		"attach":       {QueryFilesRequest{Files: []string{"reps.jsonl"}, Query: "ATTACH 'other.db' AS o"}, "only SELECT"},
+		"two":          {QueryFilesRequest{Files: []string{"reps.jsonl"}, Query: "SELECT 1; DROP TABLE reps"}, "one statement"},
+		"parquet":      {QueryFilesRequest{Files: []string{"sales.parquet"}, Query: "SELECT 1"}, "parquet files are not supported"},
+		"missing":      {QueryFilesRequest{Files: []string{"nope.csv"}, Query: "SELECT 1"}, "file not found"},
+		"bad column":   {QueryFilesRequest{Files: []string{"reps.jsonl"}, Query: "SELECT nope FROM reps"}, "no such column"},
+		"no files":     {QueryFilesRequest{Query: "SELECT 1"}, "files cannot be empty"},
+		"no statement": {QueryFilesRequest{Files: []string{"reps.jsonl"}, Query: " ; "}, "query cannot be empty"},
+	} {
+		if resp := callTool[QueryFilesRequest, QueryFilesResponse](t, ctx, bt, tc.req); !strings.Contains(resp.Error, tc.want) {
+			t.Errorf("%s: error %q, want %q", name, resp.Error, tc.want)
+		}
+	}
+	// A semicolon in a string literal is not a second statement.
+	if resp := callTool[QueryFilesRequest, QueryFilesResponse](t, ctx, bt, QueryFilesRequest{Files: []string{"reps.jsonl"}, Query: "SELECT 'a;b' AS s"}); resp.Error != "" || resp.Rows[0][0] != "a;b" {
+		t.Errorf("resp = %+v", resp)
+	}
+}
+
+func TestQueryFilesStaysInWorkspace(t *testing.T) {
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+	bt, err := NewQueryFilesTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = bt.(tool.InvokableTool).InvokableRun(ctx, `{"files":["../secret.csv"],"query":"SELECT 1"}`)
+	if err == nil || !strings.Contains(err.Error(), "outside the session workspace") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -1,68 +1,360 @@
 package tools
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
-	"github.com/olusolaa/goforai/foundation/gemini"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/olusolaa/goforai/foundation/chromemdb"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/embeddings"
+	"github.com/olusolaa/goforai/foundation/language"
+	"github.com/olusolaa/goforai/foundation/recall"
+	"github.com/olusolaa/goforai/foundation/retrieval"
 
+	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/schema"
+	chromem "github.com/philippgille/chromem-go"
 )
 
+// MetaTranslatedFrom is the metadata key the knowledge base tool sets on a
+// document it translated, holding the document's original language.
+const MetaTranslatedFrom = "translated_from"
+
 type RAGSearchRequest struct {
 	Query string `json:"query" jsonschema:"description=The question to search in the GopherCon Africa 2025 knowledge base"`
 }
 
 type RAGSearchResponse struct {
-	Documents string `json:"documents" jsonschema:"description=Relevant documents from the knowledge base"`
-	Error     string `json:"error,omitempty" jsonschema:"description=Error message if search failed"`
+	Documents string `json:"documents,omitempty" jsonschema:"description=Relevant documents from the knowledge base"`
+	// TranslatedQuery is set when the query was not in the knowledge base's
+	// language.
+	TranslatedQuery string `json:"translated_query,omitempty" jsonschema:"description=The query as it was searched after translation into the knowledge base's language"`
+	// Hint is set when no document matched the query closely enough.
+	Hint string `json:"hint,omitempty" jsonschema:"description=What to do when the knowledge base has no answer"`
+	// Warning is set when documents are older than RAGToolConfig.MaxAge.
+	Warning string `json:"warning,omitempty" jsonschema:"description=Set when the documents may be out of date"`
+	// Answer and Sources replace Documents in structured mode.
+	*chromemdb.Result
+	Error string `json:"error,omitempty" jsonschema:"description=Error message if search failed"`
+}
+
+// RAGToolConfig configures the knowledge base tool.
+type RAGToolConfig struct {
+	// Structured makes the tool return the documents as an answer with a list
+	// of sources (ID, snippet, score) instead of one block of text, for
+	// consumers that render citations.
+	Structured bool
+	// Translator lets attendees search in their own language: a query in
+	// another language than the knowledge base is translated before the
+	// search, and documents are translated into the query's language. Nil
+	// searches with the query as given.
+	Translator *language.Translator
+	// Language is the language of documents indexed without language
+	// metadata; "" selects language.English.
+	Language string
+	// MinScore is the similarity below which documents are left out as
+	// unrelated to the query. When none is left, the response tells the
+	// model to search the web, and to admit it does not know if that fails
+	// too. 0 keeps every document.
+	MinScore float64
+	// MaxAge is how long ago documents may have been indexed before the
+	// response warns that they may be out of date; 0 never warns.
+	MaxAge time.Duration
+	// Sizer chooses how many documents to return, and how much of each, from
+	// the prompt size recorded with retrieval.WithPromptTokens. Nil returns
+	// the retriever's fixed number of whole documents.
+	Sizer *retrieval.Sizer
+	// Sources names what one search covers, as config.RAGSources does:
+	// several sources are searched at once and their results fused. Nil
+	// searches the knowledge base alone.
+	Sources []string
+	// Expansion merges the chunks around the closest matches into them, as
+	// config.LoadExpansion configures it. The zero value returns chunks as
+	// they were retrieved.
+	Expansion config.Expansion
+}
+
+// knowledgeTopK is how many documents a search returns without a Sizer.
+const knowledgeTopK = 3
+
+func NewRAGTool(ctx context.Context, toolConfig *RAGToolConfig) (tool.BaseTool, error) {
+	kb, err := OpenKnowledgeBase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var expansion config.Expansion
+	if toolConfig != nil {
+		expansion = toolConfig.Expansion
+	}
+	r := retrieval.Expand(kb, expansion)
+	if toolConfig != nil && len(toolConfig.Sources) > 0 && !slices.Equal(toolConfig.Sources, []string{config.RAGSourceKnowledge}) {
+		if r, err = knowledgeSources(ctx, kb, toolConfig.Sources, expansion); err != nil {
+			return nil, err
+		}
+	}
+	return newRAGTool(r, toolConfig)
+}
+
+// knowledgeSources returns a retriever searching the named sources at once:
+// the knowledge base kb, past conversations, or other collections of kb's
+// file. The chunks of kb and its collections are expanded as expansion
+// configures.
+func knowledgeSources(ctx context.Context, kb *chromemdb.ChromemDB, names []string, expansion config.Expansion) (retriever.Retriever, error) {
+	sources := make([]chromemdb.KnowledgeSource, 0, len(names))
+	for _, name := range names {
+		source := chromemdb.KnowledgeSource{Name: name}
+		switch name {
+		case config.RAGSourceKnowledge:
+			source.Retriever = retrieval.Expand(kb, expansion)
+		case config.RAGSourceMemory:
+			index, err := recall.NewFromEnv(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open past conversations: %w", err)
+			}
+			source.Retriever = index
+		default:
+			col, err := kb.Collection(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open knowledge source %q: %w", name, err)
+			}
+			source.Retriever = retrieval.Expand(col, expansion)
+		}
+		sources = append(sources, source)
+	}
+	return chromemdb.NewMultiRetriever(knowledgeTopK, sources...)
+}
+
+type knowledgeBaseKey struct{}
+
+// WithKnowledgeBase makes tools built with the returned context search kb
+// instead of opening the knowledge base themselves, so that documents written
+// to kb while serving are searched at once.
+func WithKnowledgeBase(ctx context.Context, kb *chromemdb.ChromemDB) context.Context {
+	return context.WithValue(ctx, knowledgeBaseKey{}, kb)
+}
+
+// OpenKnowledgeBase opens the knowledge base the RAG tool searches, embedded
+// and stored as configured, or returns the one set by WithKnowledgeBase. It
+// returns an error wrapping chromemdb.ErrDBNotFound until the knowledge base
+// is indexed, and one wrapping ErrOffline when offline queries cannot be
+// embedded.
+func OpenKnowledgeBase(ctx context.Context) (*chromemdb.ChromemDB, error) {
+	if kb, ok := ctx.Value(knowledgeBaseKey{}).(*chromemdb.ChromemDB); ok {
+		return kb, nil
+	}
+	return openKnowledgeBase(ctx, false)
+}
+
+// OpenWritableKnowledgeBase opens the knowledge base for documents to be
+// added to it while it is searched, starting an empty one when it is not
+// indexed yet. It is never quantized, since a quantized index cannot be
+// exported again.
+func OpenWritableKnowledgeBase(ctx context.Context) (*chromemdb.ChromemDB, error) {
+	return openKnowledgeBase(ctx, true)
 }
 
-func NewRAGTool(ctx context.Context) (tool.BaseTool, error) {
-	embedder, err := gemini.NewEmbedder(ctx)
+func openKnowledgeBase(ctx context.Context, writable bool) (*chromemdb.ChromemDB, error) {
+	embeddingConfig, err := config.LoadEmbeddings()
+	if err != nil {
+		return nil, err
+	}
+	if Offline(ctx) && embeddingConfig.Provider != config.EmbeddingOllama {
+		return nil, fmt.Errorf("knowledge base search %w: queries are embedded with %s; index with EMBEDDING_PROVIDER=ollama to search offline",
+			ErrOffline, embeddingConfig.Provider)
+	}
+	embedder, embeddingModel, err := embeddings.New(ctx, embeddingConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create embedder: %w", err)
 	}
 
-	retriever, err := chromemdb.New(ctx, "gophercon-knowledge", embedder,
-		chromemdb.WithDBPath("./data/chromem.gob"),
-		chromemdb.WithTopK(3))
+	chunking, err := config.LoadChunking()
+	if err != nil {
+		return nil, err
+	}
+
+	const path = "./data/chromem.gob"
+	opts := []chromemdb.Option{
+		chromemdb.WithEmbeddingModel(embeddingModel),
+		chromemdb.WithCollectionMetadata(chunking.Metadata()),
+		chromemdb.WithTopK(knowledgeTopK),
+	}
+	if config.IndexAutoMigrate() {
+		opts = append(opts, chromemdb.WithAutoMigrate())
+	}
+	feedbackConfig, err := config.LoadFeedback()
+	if err != nil {
+		return nil, err
+	}
+	if feedbackConfig.Weight > 0 {
+		feedback, err := chromemdb.OpenFeedback(feedbackConfig.Path, feedbackConfig.Weight, feedbackConfig.MinSimilarity)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, chromemdb.WithFeedback(feedback))
+	}
+	quantization, err := chromemdb.ParseQuantization(config.IndexQuantization())
+	if err != nil {
+		return nil, fmt.Errorf("invalid INDEX_QUANTIZATION: %w", err)
+	}
+	switch {
+	case !writable:
+		opts = append(opts, chromemdb.WithQuantization(quantization))
+	case quantization != chromemdb.QuantizeNone:
+		return nil, fmt.Errorf("documents cannot be added to a quantized knowledge base; unset INDEX_QUANTIZATION (%s)", quantization)
+	}
+	if _, err := os.Stat(path); writable && errors.Is(err, os.ErrNotExist) {
+		opts = append(opts, chromemdb.WithDB(chromem.NewDB()))
+	} else {
+		opts = append(opts, chromemdb.WithDBPath(path))
+	}
+
+	retriever, err := chromemdb.New(ctx, "gophercon-knowledge", embedder, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create retriever: %w", err)
 	}
+	return retriever, nil
+}
 
-	return utils.InferTool(
+func newRAGTool(r retriever.Retriever, config *RAGToolConfig) (tool.BaseTool, error) {
+	if config == nil {
+		config = &RAGToolConfig{}
+	}
+	kbLang := config.Language
+	if kbLang == "" {
+		kbLang = language.English
+	}
+	if config.Sizer != nil {
+		r = retrieval.Wrap(r, config.Sizer)
+	}
+	return inferTool(
 		"search_gophercon_knowledge",
 		"Search the GopherCon Africa 2025 knowledge base for information about speakers, talks, schedule, and event details. Use this tool when users ask about GopherCon Africa 2025 specifics. Returns relevant documents with speaker bios, talk descriptions, and event information.",
 		func(ctx context.Context, req *RAGSearchRequest) (*RAGSearchResponse, error) {
-			docs, err := retriever.Retrieve(ctx, req.Query)
+			query, queryLang := req.Query, ""
+			if config.Translator != nil {
+				queryLang = language.Detect(req.Query)
+			}
+			if queryLang != "" && queryLang != kbLang {
+				translated, err := config.Translator.Translate(ctx, req.Query, kbLang)
+				if err := canceled(ctx); err != nil {
+					return nil, err
+				}
+				if err != nil {
+					return &RAGSearchResponse{Error: fmt.Sprintf("Failed to translate the query: %v", err)}, nil
+				}
+				query = translated
+			}
+
+			docs, err := r.Retrieve(ctx, query)
 			if err != nil {
 				return &RAGSearchResponse{
 					Error: fmt.Sprintf("Failed to retrieve documents: %v", err),
 				}, nil
 			}
+			if config.MinScore > 0 && len(docs) > 0 {
+				// Fused results from several sources are not in order of
+				// similarity.
+				best := slices.MaxFunc(docs, func(a, b *schema.Document) int { return cmp.Compare(a.Score(), b.Score()) }).Score()
+				docs = slices.DeleteFunc(slices.Clone(docs), func(doc *schema.Document) bool { return doc.Score() < config.MinScore })
+				if len(docs) == 0 {
+					return &RAGSearchResponse{
+						Documents: "No relevant information found in the knowledge base.",
+						Hint:      fmt.Sprintf("No document matches the query closely (best similarity %.2f, needed %.2f). Search the internet instead; if that finds nothing either, tell the user you don't know rather than guessing.", best, config.MinScore),
+					}, nil
+				}
+			}
+			if queryLang != "" {
+				docs, err = translateDocuments(ctx, config.Translator, docs, kbLang, queryLang)
+				if err := canceled(ctx); err != nil {
+					return nil, err
+				}
+				if err != nil {
+					return &RAGSearchResponse{Error: fmt.Sprintf("Failed to translate documents: %v", err)}, nil
+				}
+			}
+			resp := &RAGSearchResponse{Warning: staleWarning(docs, config.MaxAge, time.Now())}
+			if query != req.Query {
+				resp.TranslatedQuery = query
+			}
+
+			if config.Structured {
+				resp.Result = chromemdb.NewResult(docs)
+				return resp, nil
+			}
 
 			if len(docs) == 0 {
-				return &RAGSearchResponse{
-					Documents: "No relevant information found in the knowledge base.",
-				}, nil
+				resp.Documents = "No relevant information found in the knowledge base."
+				return resp, nil
 			}
 
 			var result strings.Builder
 			result.WriteString(fmt.Sprintf("Found %d relevant documents:\n\n", len(docs)))
 
 			for i, doc := range docs {
-				result.WriteString(fmt.Sprintf("=== Document %d ===\n", i+1))
+				if p := chromemdb.ProvenanceOf(doc).String(); p != "" {
+					result.WriteString(fmt.Sprintf("=== Document %d (%s) ===\n", i+1, p))
+				} else {
+					result.WriteString(fmt.Sprintf("=== Document %d ===\n", i+1))
+				}
 				result.WriteString(doc.Content)
 				result.WriteString("\n\n")
 			}
 
-			return &RAGSearchResponse{
-				Documents: result.String(),
-			}, nil
+			resp.Documents = result.String()
+			return resp, nil
 		},
 	)
 }
+
+// staleWarning returns a warning for the model when any of docs was indexed
+// more than maxAge before now, or "" when none was or maxAge is 0.
+func staleWarning(docs []*schema.Document, maxAge time.Duration, now time.Time) string {
+	if maxAge <= 0 {
+		return ""
+	}
+	var oldest time.Time
+	for _, doc := range docs {
+		indexed := chromemdb.ProvenanceOf(doc).IndexedAt
+		if !indexed.IsZero() && (oldest.IsZero() || indexed.Before(oldest)) {
+			oldest = indexed
+		}
+	}
+	if oldest.IsZero() || now.Sub(oldest) <= maxAge {
+		return ""
+	}
+	return fmt.Sprintf("These documents were indexed on %s, %d days ago, and may be out of date: schedules, speakers and talks can change. Tell the user which date the information is from.",
+		oldest.Format(time.DateOnly), int(now.Sub(oldest).Hours()/24))
+}
+
+// translateDocuments returns docs with the content of those not in target
+// translated into it. Documents without language metadata are taken to be in
+// fallback. The retriever's documents are left untouched.
+func translateDocuments(ctx context.Context, t *language.Translator, docs []*schema.Document, fallback, target string) ([]*schema.Document, error) {
+	out := make([]*schema.Document, len(docs))
+	for i, doc := range docs {
+		source := language.Of(doc, fallback)
+		if source == target {
+			out[i] = doc
+			continue
+		}
+		content, err := t.Translate(ctx, doc.Content, target)
+		if err != nil {
+			return nil, fmt.Errorf("document %s: %w", doc.ID, err)
+		}
+		metadata := make(map[string]any, len(doc.MetaData)+2)
+		for k, v := range doc.MetaData {
+			metadata[k] = v
+		}
+		metadata[language.MetaLanguage] = target
+		metadata[MetaTranslatedFrom] = source
+		out[i] = &schema.Document{ID: doc.ID, Content: content, MetaData: metadata}
+	}
+	return out, nil
+}
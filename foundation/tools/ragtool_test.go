@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/chromemdb"
+)
+
+// staticRetriever returns the same documents for every query.
+type staticRetriever []*schema.Document
+
+func (r staticRetriever) Retrieve(context.Context, string, ...retriever.Option) ([]*schema.Document, error) {
+	return r, nil
+}
+
+func TestRAGToolStructured(t *testing.T) {
+	docs := staticRetriever{
+		(&schema.Document{ID: "talk-1", Content: "Eino graphs in production."}).WithScore(0.9),
+		(&schema.Document{ID: "speaker-2", Content: "Ada builds agents."}).WithScore(0.7),
+	}
+	for _, structured := range []bool{false, true} {
+		bt, err := newRAGTool(docs, &RAGToolConfig{Structured: structured})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp := callTool[json.RawMessage, map[string]json.RawMessage](t, context.Background(), bt, json.RawMessage(`{"query":"eino"}`))
+		if _, ok := resp["sources"]; ok != structured {
+			t.Errorf("structured=%v: got %s", structured, resp)
+		}
+		if _, ok := resp["documents"]; ok == structured {
+			t.Errorf("structured=%v: got %s", structured, resp)
+		}
+	}
+
+	bt, _ := newRAGTool(docs, &RAGToolConfig{Structured: true})
+	out, _ := bt.(tool.InvokableTool).InvokableRun(context.Background(), `{"query":"eino"}`)
+	const want = `{"answer":"[1] talk-1\nEino graphs in production.\n\n[2] speaker-2\nAda builds agents.","sources":[{"id":"talk-1","snippet":"Eino graphs in production.","score":0.9},{"id":"speaker-2","snippet":"Ada builds agents.","score":0.7}]}`
+	if out != want {
+		t.Errorf("structured result\n got %s\nwant %s", out, want)
+	}
+}
+
+func TestRAGToolProvenance(t *testing.T) {
+	indexed := time.Now().AddDate(0, 0, -45).UTC()
+	docs := staticRetriever{
+		{ID: "talk-1", Content: "Eino graphs in production.", MetaData: map[string]any{
+			chromemdb.MetaSource:    "talks.md",
+			chromemdb.MetaIndexedAt: indexed.Format(time.RFC3339),
+		}},
+		{ID: "speaker-2", Content: "Ada builds agents."},
+	}
+	run := func(config *RAGToolConfig) RAGSearchResponse {
+		t.Helper()
+		bt, err := newRAGTool(docs, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return callTool[json.RawMessage, RAGSearchResponse](t, context.Background(), bt, json.RawMessage(`{"query":"eino"}`))
+	}
+
+	resp := run(&RAGToolConfig{MaxAge: 30 * 24 * time.Hour})
+	asOf := indexed.Format(time.DateOnly)
+	if !strings.Contains(resp.Documents, "=== Document 1 (talks.md, as of "+asOf+") ===\n") || !strings.Contains(resp.Documents, "=== Document 2 ===\n") {
+		t.Errorf("documents do not show their provenance:\n%s", resp.Documents)
+	}
+	if !strings.Contains(resp.Warning, "indexed on "+asOf+", 45 days ago") {
+		t.Errorf("warning = %q", resp.Warning)
+	}
+
+	resp = run(&RAGToolConfig{Structured: true, MaxAge: 90 * 24 * time.Hour})
+	if resp.Warning != "" {
+		t.Errorf("fresh documents were flagged: %q", resp.Warning)
+	}
+	if s := resp.Sources[0]; s.Origin != "talks.md" || s.AsOf != asOf {
+		t.Errorf("source = %+v", s)
+	}
+	if resp := run(nil); resp.Warning != "" {
+		t.Errorf("warned without a maximum age: %q", resp.Warning)
+	}
+}
+
+func TestRAGToolMinScore(t *testing.T) {
+	docs := staticRetriever{
+		(&schema.Document{ID: "talk-1", Content: "Eino graphs in production."}).WithScore(0.62),
+		(&schema.Document{ID: "speaker-2", Content: "Ada builds agents."}).WithScore(0.41),
+	}
+	search := func(minScore float64) RAGSearchResponse {
+		t.Helper()
+		bt, err := newRAGTool(docs, &RAGToolConfig{Structured: true, MinScore: minScore})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return callTool[json.RawMessage, RAGSearchResponse](t, context.Background(), bt, json.RawMessage(`{"query":"eino"}`))
+	}
+
+	if resp := search(0.5); resp.Result == nil || len(resp.Sources) != 1 || resp.Sources[0].ID != "talk-1" || resp.Hint != "" {
+		t.Errorf("min score 0.5: %+v", resp)
+	}
+	// With nothing close enough, the model is sent to the web.
+	resp := search(0.7)
+	if resp.Result != nil || !strings.Contains(resp.Hint, "best similarity 0.62, needed 0.70") || !strings.Contains(resp.Hint, "Search the internet") {
+		t.Errorf("min score 0.7: %+v", resp)
+	}
+	if len(docs) != 2 || docs[1].ID != "speaker-2" {
+		t.Error("the retriever's documents were modified")
+	}
+}
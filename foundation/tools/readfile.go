@@ -3,8 +3,8 @@ package tools
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"github.com/cloudwego/eino/components/tool/utils"
 	"io"
 	"os"
 	"strings"
@@ -31,16 +31,23 @@ type ReadFileResponse struct {
 const maxLinesToRead = 5000
 
 func NewReadFileTool(ctx context.Context) (tool.BaseTool, error) {
-	return utils.InferTool(
+	return inferTool(
 		"read_file",
 		"Read the contents of a file with line numbers. This tool is memory-efficient and can safely read slices of very large files using start_line and end_line. Returns metadata (total lines, size) to help decide which parts of a file to read.",
 		func(ctx context.Context, req *ReadFileRequest) (*ReadFileResponse, error) {
 			if req.Path == "" {
 				return &ReadFileResponse{Error: "path cannot be empty"}, nil
 			}
+			path, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &ReadFileResponse{Error: err.Error()}, nil
+			}
 
 			// 1. Perform pre-flight checks with os.Stat first.
-			fileInfo, err := os.Stat(req.Path)
+			fileInfo, err := os.Stat(path)
 			if err != nil {
 				if os.IsNotExist(err) {
 					return &ReadFileResponse{
@@ -55,7 +62,7 @@ func NewReadFileTool(ctx context.Context) (tool.BaseTool, error) {
 			}
 
 			// 2. Open the file for stream-based reading.
-			file, err := os.Open(req.Path)
+			file, err := os.Open(path)
 			if err != nil {
 				return &ReadFileResponse{Error: fmt.Sprintf("failed to open file '%s': %v", req.Path, err)}, nil
 			}
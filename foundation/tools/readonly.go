@@ -0,0 +1,18 @@
+package tools
+
+import "context"
+
+type readOnlyKey struct{}
+
+// WithReadOnly marks tools built with the returned context as read-only:
+// edit_go_file refuses every edit and gitclone refuses to pull. Callers that
+// assemble a toolbox should also leave the writing tools out entirely.
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// ReadOnly reports whether ctx was marked by WithReadOnly.
+func ReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Limits that keep a test_regex result small.
+const (
+	maxRegexInputs  = 50
+	maxRegexMatches = 20
+)
+
+type TestRegexRequest struct {
+	Pattern string   `json:"pattern" jsonschema:"description=Regular expression in Go (RE2) syntax, as the string value passed to regexp.Compile, not a Go literal."`
+	Inputs  []string `json:"inputs" jsonschema:"description=Sample strings to run the pattern against (up to 50). Include strings it should reject."`
+	Replace *string  `json:"replace,omitempty" jsonschema:"description=Replacement template; when set, each input is also shown after ReplaceAllString, with $1 or ${name} for groups."`
+}
+
+// RegexGroup is the text a capturing group matched.
+type RegexGroup struct {
+	Index   int    `json:"index" jsonschema:"description=Group number, from 1."`
+	Name    string `json:"name,omitempty" jsonschema:"description=Group name, for (?P<name>...) groups."`
+	Text    string `json:"text" jsonschema:"description=Matched text."`
+	Matched bool   `json:"matched" jsonschema:"description=Whether the group took part in the match; an optional group may not."`
+}
+
+// RegexMatch is one match of the pattern in an input.
+type RegexMatch struct {
+	Text   string       `json:"text" jsonschema:"description=Matched text."`
+	Start  int          `json:"start" jsonschema:"description=Byte offset of the match in the input."`
+	End    int          `json:"end" jsonschema:"description=Byte offset just past the match."`
+	Groups []RegexGroup `json:"groups,omitempty" jsonschema:"description=Capturing groups of the match."`
+}
+
+// RegexResult is the outcome of running the pattern on one input.
+type RegexResult struct {
+	Input    string       `json:"input"`
+	Matched  bool         `json:"matched" jsonschema:"description=Result of MatchString."`
+	Matches  []RegexMatch `json:"matches,omitempty" jsonschema:"description=Matches of FindAllStringSubmatchIndex, up to 20."`
+	Replaced *string      `json:"replaced,omitempty" jsonschema:"description=The input after ReplaceAllString, when replace is set."`
+}
+
+type TestRegexResponse struct {
+	GoLiteral string        `json:"go_literal,omitempty" jsonschema:"description=The pattern as a Go expression, ready to paste into code."`
+	Groups    int           `json:"groups,omitempty" jsonschema:"description=Number of capturing groups."`
+	Results   []RegexResult `json:"results,omitempty" jsonschema:"description=Outcome per input."`
+	Pitfalls  []string      `json:"pitfalls,omitempty" jsonschema:"description=Likely mistakes in the pattern, with fixes."`
+	Error     string        `json:"error,omitempty" jsonschema:"description=Error message if the pattern does not compile."`
+}
+
+// NewTestRegexTool returns the test_regex tool, which compiles a pattern
+// with Go's regexp package and runs it on sample inputs.
+func NewTestRegexTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"test_regex",
+		"Compile a Go regular expression and run it on sample inputs: reports whether each input matches, every match with its capturing groups, optional replacements, and common pitfalls (RE2 syntax Go does not support, missing anchors, unescaped dots, greedy wildcards). Use it to check a regexp before putting it into code.",
+		func(ctx context.Context, req *TestRegexRequest) (*TestRegexResponse, error) {
+			if req.Pattern == "" {
+				return &TestRegexResponse{Error: "pattern cannot be empty"}, nil
+			}
+			if len(req.Inputs) > maxRegexInputs {
+				return &TestRegexResponse{Error: fmt.Sprintf("too many inputs: %d (max %d)", len(req.Inputs), maxRegexInputs)}, nil
+			}
+			resp := &TestRegexResponse{Pitfalls: patternPitfalls(req.Pattern)}
+			re, err := regexp.Compile(req.Pattern)
+			if err != nil {
+				resp.Error = err.Error()
+				if hint := compileHint(err); hint != "" {
+					resp.Pitfalls = append([]string{hint}, resp.Pitfalls...)
+				}
+				return resp, nil
+			}
+			resp.GoLiteral = "regexp.MustCompile(" + goLiteral(req.Pattern) + ")"
+			resp.Groups = re.NumSubexp()
+
+			partial := false
+			for _, input := range req.Inputs {
+				result := runRegex(re, input, req.Replace)
+				for _, m := range result.Matches {
+					if m.Start > 0 || m.End < len(input) {
+						partial = true
+					}
+				}
+				resp.Results = append(resp.Results, result)
+			}
+			if partial && !anchored(req.Pattern) {
+				resp.Pitfalls = append(resp.Pitfalls, "The pattern is not anchored, so it matched part of an input and MatchString reports true for it. Wrap it in ^(?:...)$ to match whole strings.")
+			}
+			if re.MatchString("") {
+				resp.Pitfalls = append(resp.Pitfalls, "The pattern matches the empty string, so MatchString is true for every input. Use + instead of * where at least one character is required.")
+			}
+			return resp, nil
+		},
+	)
+}
+
+func runRegex(re *regexp.Regexp, input string, replace *string) RegexResult {
+	result := RegexResult{Input: input, Matched: re.MatchString(input)}
+	names := re.SubexpNames()
+	for _, loc := range re.FindAllStringSubmatchIndex(input, maxRegexMatches) {
+		m := RegexMatch{Text: input[loc[0]:loc[1]], Start: loc[0], End: loc[1]}
+		for i := 1; i < len(names); i++ {
+			g := RegexGroup{Index: i, Name: names[i]}
+			if start, end := loc[2*i], loc[2*i+1]; start >= 0 {
+				g.Text, g.Matched = input[start:end], true
+			}
+			m.Groups = append(m.Groups, g)
+		}
+		result.Matches = append(result.Matches, m)
+	}
+	if replace != nil {
+		replaced := re.ReplaceAllString(input, *replace)
+		result.Replaced = &replaced
+	}
+	return result
+}
+
+// compileHint explains compile errors caused by syntax that other regexp
+// engines accept but RE2 does not.
+func compileHint(err error) string {
+	var synErr *syntax.Error
+	if !errors.As(err, &synErr) {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(synErr.Expr, "(?=") || strings.HasPrefix(synErr.Expr, "(?!") ||
+		strings.HasPrefix(synErr.Expr, "(?<=") || strings.HasPrefix(synErr.Expr, "(?<!"):
+		return "Go's regexp (RE2) has no lookahead or lookbehind. Match the surrounding text and use a capturing group for the part you need, or check the condition in Go code."
+	case synErr.Code == syntax.ErrInvalidEscape && len(synErr.Expr) == 2 && synErr.Expr[1] >= '1' && synErr.Expr[1] <= '9':
+		return "Go's regexp (RE2) has no backreferences. Capture both parts and compare them in Go code."
+	case synErr.Code == syntax.ErrInvalidEscape && synErr.Expr == `\Z`:
+		return `Go's regexp has no \Z; use \z (end of text) or $.`
+	case synErr.Code == syntax.ErrInvalidRepeatOp && strings.HasSuffix(synErr.Expr, "+"):
+		return "Go's regexp has no possessive quantifiers (*+, ++) or atomic groups; RE2 never backtracks, so plain quantifiers are safe."
+	case synErr.Code == syntax.ErrInvalidRepeatSize:
+		return "Go's regexp allows repeat counts up to 1000, as in {0,1000}."
+	}
+	return ""
+}
+
+// patternPitfalls finds mistakes that compile but rarely do what was meant.
+func patternPitfalls(pattern string) []string {
+	var pitfalls []string
+	if strings.Contains(pattern, `\\d`) || strings.Contains(pattern, `\\w`) || strings.Contains(pattern, `\\s`) || strings.Contains(pattern, `\\b`) {
+		pitfalls = append(pitfalls, `The pattern contains a double backslash such as \\d, which matches a literal backslash. Pass the pattern as it should reach regexp.Compile; in Go code, write it in a raw string literal so \d needs no escaping.`)
+	}
+
+	inClass, dotBetweenWords, greedy := false, false, false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '.' && !inClass:
+			if i > 0 && isWordByte(pattern[i-1]) && i+1 < len(pattern) && isWordByte(pattern[i+1]) {
+				dotBetweenWords = true
+			}
+			// Closing groups and anchors after the wildcard do not count as
+			// more pattern.
+			if i+2 < len(pattern) && (pattern[i+1] == '*' || pattern[i+1] == '+') && pattern[i+2] != '?' && strings.TrimLeft(pattern[i+2:], ")$") != "" {
+				greedy = true
+			}
+		}
+	}
+	if dotBetweenWords {
+		pitfalls = append(pitfalls, `An unescaped '.' between letters or digits matches any character, so "example.com" also matches "exampleXcom". Use \. for a literal dot.`)
+	}
+	if greedy {
+		pitfalls = append(pitfalls, "A greedy .* or .+ followed by more pattern matches as much as it can, up to the last occurrence of what follows. Use .*? or a negated class such as [^\"]* to stop at the first.")
+	}
+	return pitfalls
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// anchored reports whether pattern is tied to the start and end of the text.
+func anchored(pattern string) bool {
+	pattern = strings.TrimPrefix(pattern, "(?i)")
+	return (strings.HasPrefix(pattern, "^") || strings.HasPrefix(pattern, `\A`)) &&
+		(strings.HasSuffix(pattern, "$") || strings.HasSuffix(pattern, `\z`))
+}
+
+// goLiteral quotes pattern for Go source, preferring a raw string.
+func goLiteral(pattern string) string {
+	if strconv.CanBackquote(pattern) {
+		return "`" + pattern + "`"
+	}
+	return strconv.Quote(pattern)
+}
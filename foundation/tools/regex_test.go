@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTestRegex(t *testing.T) {
+	ctx := context.Background()
+	bt, err := NewTestRegexTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replace := "$minor.$1"
+	resp := callTool[TestRegexRequest, TestRegexResponse](t, ctx, bt, TestRegexRequest{
+		Pattern: `^v(\d+)\.(?P<minor>\d+)(-rc)?$`,
+		Inputs:  []string{"v1.22", "v1.22-rc", "1.22"},
+		Replace: &replace,
+	})
+	if resp.Error != "" || len(resp.Pitfalls) != 0 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if resp.GoLiteral != "regexp.MustCompile(`^v(\\d+)\\.(?P<minor>\\d+)(-rc)?$`)" || resp.Groups != 3 {
+		t.Errorf("literal %s, %d groups", resp.GoLiteral, resp.Groups)
+	}
+	first := resp.Results[0]
+	if !first.Matched || len(first.Matches) != 1 || *first.Replaced != "22.1" {
+		t.Fatalf("v1.22 = %+v", first)
+	}
+	groups := first.Matches[0].Groups
+	if groups[1] != (RegexGroup{Index: 2, Name: "minor", Text: "22", Matched: true}) || groups[2].Matched {
+		t.Errorf("groups = %+v", groups)
+	}
+	if resp.Results[1].Matches[0].Groups[2].Text != "-rc" || resp.Results[2].Matched {
+		t.Errorf("results = %+v", resp.Results)
+	}
+
+	for name, tc := range map[string]struct {
+		req   TestRegexRequest
+		error bool
+		want  string
+	}{
+		"lookahead":      {TestRegexRequest{Pattern: `foo(?=bar)`}, true, "no lookahead or lookbehind"},
+		"lookbehind":     {TestRegexRequest{Pattern: `(?<!x)foo`}, true, "no lookahead or lookbehind"},
+		"backreference":  {TestRegexRequest{Pattern: `(a)\1`}, true, "no backreferences"},
+		"possessive":     {TestRegexRequest{Pattern: `a++`}, true, "no possessive quantifiers"},
+		"unanchored":     {TestRegexRequest{Pattern: `\d+`, Inputs: []string{"abc123"}}, false, "not anchored"},
+		"dot":            {TestRegexRequest{Pattern: `^example.com$`}, false, `Use \. for a literal dot`},
+		"greedy":         {TestRegexRequest{Pattern: `"(.*)"`}, false, "greedy"},
+		"empty match":    {TestRegexRequest{Pattern: `a*`}, false, "empty string"},
+		"double escaped": {TestRegexRequest{Pattern: `\\d+`}, false, "double backslash"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := callTool[TestRegexRequest, TestRegexResponse](t, ctx, bt, tc.req)
+			if (resp.Error != "") != tc.error || !strings.Contains(strings.Join(resp.Pitfalls, "\n"), tc.want) {
+				t.Errorf("resp = %+v, want error %v and a pitfall mentioning %q", resp, tc.error, tc.want)
+			}
+		})
+	}
+}
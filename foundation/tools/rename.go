@@ -0,0 +1,626 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+type RenameSymbolRequest struct {
+	Path    string `json:"path" jsonschema:"description=Go file that declares or uses the symbol."`
+	Name    string `json:"name" jsonschema:"description=Current name of the symbol: a function, method, type, field, variable, constant or import name."`
+	NewName string `json:"new_name" jsonschema:"description=New name of the symbol."`
+	Line    int    `json:"line,omitempty" jsonschema:"description=Optional: line in path where the symbol appears, needed when path has several different symbols with that name."`
+}
+
+type RenameSymbolResponse struct {
+	Message    string   `json:"message,omitempty" jsonschema:"description=Summary of the rename."`
+	Files      []string `json:"files,omitempty" jsonschema:"description=Files that were changed."`
+	References int      `json:"references,omitempty" jsonschema:"description=Number of identifiers that were renamed, the declaration included."`
+	Error      string   `json:"error,omitempty" jsonschema:"description=Error message if the rename was not made. No file is changed when this is set."`
+}
+
+// NewRenameSymbolTool returns the rename_symbol tool, which renames a symbol
+// and every reference to it in its module using the type checker, as gopls
+// does. With a context marked by WithReadOnly every rename is refused with
+// ErrReadOnly.
+func NewRenameSymbolTool(ctx context.Context) (tool.BaseTool, error) {
+	readOnly := ReadOnly(ctx)
+	return inferTool(
+		"rename_symbol",
+		"Rename a Go symbol (function, method, type, field, variable, constant) and every reference to it across its module, test files included, using the type checker rather than text search. Methods are renamed together with the interface methods they implement. Refuses renames that would conflict with another name. Prefer it over edit_go_file or search/replace for any rename.",
+		func(ctx context.Context, req *RenameSymbolRequest) (*RenameSymbolResponse, error) {
+			if readOnly {
+				return nil, ErrReadOnly
+			}
+			if req.Path == "" || req.Name == "" {
+				return &RenameSymbolResponse{Error: "path and name cannot be empty"}, nil
+			}
+			if !token.IsIdentifier(req.NewName) || req.NewName == "_" {
+				return &RenameSymbolResponse{Error: fmt.Sprintf("new_name '%s' is not a valid Go identifier", req.NewName)}, nil
+			}
+			if req.NewName == req.Name {
+				return &RenameSymbolResponse{Error: "new_name is the current name"}, nil
+			}
+			path, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &RenameSymbolResponse{Error: err.Error()}, nil
+			}
+			path, err = filepath.Abs(path)
+			if err != nil {
+				return &RenameSymbolResponse{Error: err.Error()}, nil
+			}
+
+			staged, order, refs, err := renameSymbol(ctx, path, req.Name, req.NewName, req.Line)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &RenameSymbolResponse{Error: err.Error()}, nil
+			}
+			written, err := writeChangeset(staged, order)
+			if err != nil {
+				return &RenameSymbolResponse{Error: errors.Join(err, rollbackChangeset(staged, written)).Error()}, nil
+			}
+			files := make([]string, len(order))
+			for i, p := range order {
+				files[i] = staged[p].display
+			}
+			return &RenameSymbolResponse{
+				Message:    fmt.Sprintf("✅ Renamed '%s' to '%s': %d references in %d files", req.Name, req.NewName, refs, len(files)),
+				Files:      files,
+				References: refs,
+			}, nil
+		},
+	)
+}
+
+// renameSymbol type-checks the module holding the file at path, renames the
+// symbol name found in that file, and returns the changed files staged for
+// writeChangeset together with the number of renamed identifiers.
+func renameSymbol(ctx context.Context, path, name, newName string, line int) (map[string]*stagedFile, []string, int, error) {
+	root := moduleRoot(ctx, filepath.Dir(path))
+	if root == "" {
+		return nil, nil, 0, fmt.Errorf("no go.mod found for '%s'", path)
+	}
+	m, err := loadModule(ctx, root)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if len(m.errs) > 0 {
+		return nil, nil, 0, fmt.Errorf("the module does not type-check, fix it before renaming: %w", m.errs[0])
+	}
+
+	target, err := m.findObject(path, name, line)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if target.Pkg() == nil || m.byPath[target.Pkg().Path()] == nil {
+		return nil, nil, 0, fmt.Errorf("'%s' is declared outside the module and cannot be renamed", name)
+	}
+	family := m.renameFamily(target)
+	idents := m.references(family)
+	if err := m.checkConflicts(family, idents, newName); err != nil {
+		return nil, nil, 0, err
+	}
+
+	// Replace the identifiers in the source text, so the rest of each file
+	// keeps its formatting, then gofmt for any realigned fields.
+	byFile := make(map[string][]*ast.Ident)
+	for _, id := range idents {
+		file := m.fset.Position(id.Pos()).Filename
+		byFile[file] = append(byFile[file], id)
+	}
+	staged := make(map[string]*stagedFile)
+	var order []string
+	for file, ids := range byFile {
+		content, perms, err := readFileWithPerms(file)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i].Pos() > ids[j].Pos() })
+		renamed := slices.Clone(content)
+		for _, id := range ids {
+			start, end := m.fset.Position(id.Pos()).Offset, m.fset.Position(id.End()).Offset
+			renamed = slices.Concat(renamed[:start], []byte(newName), renamed[end:])
+		}
+		if formatted, err := format.Source(renamed); err == nil {
+			renamed = formatted
+		}
+		staged[file] = &stagedFile{display: displayPath(ctx, file), content: renamed, perms: perms, original: content}
+		order = append(order, file)
+	}
+	slices.Sort(order)
+	return staged, order, len(idents), nil
+}
+
+// displayPath returns path relative to the workspace, or to the current
+// directory without one, as the model would give it.
+func displayPath(ctx context.Context, path string) string {
+	base := Workspace(ctx)
+	if base == "" {
+		base, _ = os.Getwd()
+	}
+	if rel, err := filepath.Rel(base, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// typedModule is a module whose packages, test files included, were all
+// type-checked together, so that a symbol is the same types.Object in every
+// package referring to it.
+type typedModule struct {
+	fset     *token.FileSet
+	listed   map[string]*listedPackage
+	byPath   map[string]*typedPackage // Packages of the module by import path.
+	packages []*typedPackage          // Including external test packages.
+	errs     []error
+
+	checking map[string]bool
+	exports  types.Importer // Packages from outside the module.
+	sources  types.Importer // Fallback when their export data cannot be read.
+}
+
+// listedPackage is the part of the output of 'go list -json' the loader uses.
+type listedPackage struct {
+	ImportPath   string
+	Name         string
+	Dir          string
+	Export       string
+	ForTest      string
+	GoFiles      []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+	Module       *struct{ Main bool }
+}
+
+type typedPackage struct {
+	pkg   *types.Package
+	files []*ast.File
+	info  *types.Info
+}
+
+// loadModule lists the packages of the module at root with their
+// dependencies and type-checks the module's own packages from source.
+func loadModule(ctx context.Context, root string) (*typedModule, error) {
+	ctx, cancel := context.WithTimeout(ctx, goCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-deps", "-test", "-export",
+		"-json=ImportPath,Name,Dir,Export,ForTest,GoFiles,TestGoFiles,XTestGoFiles,Module", "./...")
+	cmd.Dir = root
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list failed: %w: %s", err, shortenOutput(stderr.String()))
+	}
+
+	m := &typedModule{
+		fset:     token.NewFileSet(),
+		listed:   make(map[string]*listedPackage),
+		byPath:   make(map[string]*typedPackage),
+		checking: make(map[string]bool),
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p listedPackage
+		if err := dec.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read go list output: %w", err)
+		}
+		// Test variants and generated test mains duplicate the packages.
+		if p.ForTest != "" || strings.Contains(p.ImportPath, " ") || strings.HasSuffix(p.ImportPath, ".test") {
+			continue
+		}
+		m.listed[p.ImportPath] = &p
+	}
+	m.exports = importer.ForCompiler(m.fset, "gc", func(path string) (io.ReadCloser, error) {
+		if p := m.listed[path]; p != nil && p.Export != "" {
+			return os.Open(p.Export)
+		}
+		return nil, fmt.Errorf("no export data for %s", path)
+	})
+	m.sources = importer.ForCompiler(m.fset, "source", nil)
+
+	paths := make([]string, 0, len(m.listed))
+	for path, p := range m.listed {
+		if p.Module != nil && p.Module.Main {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if _, err := m.Import(path); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range paths {
+		if p := m.listed[path]; len(p.XTestGoFiles) > 0 {
+			m.check(path+"_test", p.Dir, p.XTestGoFiles)
+		}
+	}
+	return m, nil
+}
+
+// Import implements types.Importer: packages of the module are type-checked
+// from source, with their test files, and all others loaded from export data.
+func (m *typedModule) Import(path string) (*types.Package, error) {
+	p := m.listed[path]
+	if p == nil || p.Module == nil || !p.Module.Main {
+		pkg, err := m.exports.Import(path)
+		if err != nil {
+			pkg, err = m.sources.Import(path)
+		}
+		return pkg, err
+	}
+	if tp := m.byPath[path]; tp != nil {
+		return tp.pkg, nil
+	}
+	if m.checking[path] {
+		return nil, fmt.Errorf("import cycle through %s", path)
+	}
+	m.checking[path] = true
+	tp := m.check(path, p.Dir, slices.Concat(p.GoFiles, p.TestGoFiles))
+	m.byPath[path] = tp
+	return tp.pkg, nil
+}
+
+func (m *typedModule) check(path, dir string, names []string) *typedPackage {
+	tp := &typedPackage{info: &types.Info{
+		Defs:   make(map[*ast.Ident]types.Object),
+		Uses:   make(map[*ast.Ident]types.Object),
+		Types:  make(map[ast.Expr]types.TypeAndValue),
+		Scopes: make(map[ast.Node]*types.Scope),
+	}}
+	for _, name := range names {
+		file, err := parser.ParseFile(m.fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			m.errs = append(m.errs, err)
+			continue
+		}
+		tp.files = append(tp.files, file)
+	}
+	conf := types.Config{Importer: m, Error: func(err error) { m.errs = append(m.errs, err) }}
+	tp.pkg, _ = conf.Check(path, m.fset, tp.files, tp.info)
+	m.packages = append(m.packages, tp)
+	return tp
+}
+
+// findObject returns the symbol called name in the file at path, on line if
+// it is set.
+func (m *typedModule) findObject(path, name string, line int) (types.Object, error) {
+	var found []types.Object
+	var lines []int
+	for _, tp := range m.packages {
+		for _, file := range tp.files {
+			if m.fset.Position(file.Pos()).Filename != path {
+				continue
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				id, ok := n.(*ast.Ident)
+				if !ok || id.Name != name {
+					return true
+				}
+				pos := m.fset.Position(id.Pos())
+				if line != 0 && pos.Line != line {
+					return true
+				}
+				obj := tp.info.Defs[id]
+				// The name of an embedded field denotes its type.
+				if v, isVar := obj.(*types.Var); obj == nil || isVar && v.Embedded() {
+					obj = tp.info.Uses[id]
+				}
+				if obj != nil && !slices.Contains(found, origin(obj)) {
+					found = append(found, origin(obj))
+					lines = append(lines, pos.Line)
+				}
+				return true
+			})
+		}
+	}
+	switch {
+	case len(found) == 0 && line != 0:
+		return nil, fmt.Errorf("no symbol '%s' on line %d of the file", name, line)
+	case len(found) == 0:
+		return nil, fmt.Errorf("no symbol '%s' in the file", name)
+	case len(found) > 1:
+		return nil, fmt.Errorf("'%s' names %d different symbols in the file (first seen on lines %v); pass the line of the one to rename", name, len(found), lines)
+	}
+	return found[0], nil
+}
+
+// origin returns the generic declaration of an instantiated method or field.
+func origin(obj types.Object) types.Object {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return obj.Origin()
+	case *types.Var:
+		return obj.Origin()
+	}
+	return obj
+}
+
+// renameFamily returns the objects that must be renamed together with
+// target: the methods implementing, or implemented by, a method target, and
+// the fields embedding a type target.
+func (m *typedModule) renameFamily(target types.Object) []types.Object {
+	family := []types.Object{target}
+	named := m.namedTypes()
+
+	switch target := target.(type) {
+	case *types.TypeName:
+		for _, tp := range m.packages {
+			for _, obj := range tp.info.Defs {
+				if v, ok := obj.(*types.Var); ok && v.Embedded() && embeddedTypeName(v.Type()) == target {
+					family = append(family, v)
+				}
+			}
+		}
+	case *types.Func:
+		if target.Type().(*types.Signature).Recv() == nil {
+			break
+		}
+		// Grow the family until every interface method it implements and
+		// every method implementing one of its interface methods is in it.
+		for changed := true; changed; {
+			changed = false
+			for _, iface := range named {
+				it, ok := iface.Underlying().(*types.Interface)
+				if !ok {
+					continue
+				}
+				im := lookupMethod(iface, target.Name())
+				if im == nil {
+					continue
+				}
+				for _, concrete := range named {
+					if types.IsInterface(concrete) {
+						continue
+					}
+					cm := lookupMethod(concrete, target.Name())
+					if cm == nil || !(types.Implements(concrete, it) || types.Implements(types.NewPointer(concrete), it)) {
+						continue
+					}
+					inIface, inConcrete := slices.Contains(family, types.Object(im)), slices.Contains(family, types.Object(cm))
+					if inIface != inConcrete {
+						family = append(family, im, cm)
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return family
+}
+
+// namedTypes returns the defined types declared in the module.
+func (m *typedModule) namedTypes() []*types.Named {
+	var named []*types.Named
+	for _, tp := range m.packages {
+		for _, obj := range tp.info.Defs {
+			if tn, ok := obj.(*types.TypeName); ok && !tn.IsAlias() {
+				if n, ok := tn.Type().(*types.Named); ok {
+					named = append(named, n)
+				}
+			}
+		}
+	}
+	return named
+}
+
+func lookupMethod(t *types.Named, name string) *types.Func {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, t.Obj().Pkg(), name)
+	if f, ok := obj.(*types.Func); ok {
+		return f.Origin()
+	}
+	return nil
+}
+
+func embeddedTypeName(t types.Type) *types.TypeName {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		return n.Origin().Obj()
+	}
+	return nil
+}
+
+// references returns the identifiers declaring or referring to the objects,
+// each once.
+func (m *typedModule) references(objs []types.Object) []*ast.Ident {
+	seen := make(map[token.Pos]bool)
+	var idents []*ast.Ident
+	add := func(id *ast.Ident, obj types.Object) {
+		if obj != nil && slices.Contains(objs, origin(obj)) && !seen[id.Pos()] {
+			seen[id.Pos()] = true
+			idents = append(idents, id)
+		}
+	}
+	for _, tp := range m.packages {
+		for id, obj := range tp.info.Defs {
+			add(id, obj)
+		}
+		for id, obj := range tp.info.Uses {
+			add(id, obj)
+		}
+	}
+	return idents
+}
+
+// checkConflicts reports why renaming objs, referred to by idents, to
+// newName would change the meaning of the program.
+func (m *typedModule) checkConflicts(objs []types.Object, idents []*ast.Ident, newName string) error {
+	for _, obj := range objs {
+		if obj.Exported() && !token.IsExported(newName) {
+			for _, id := range idents {
+				if pkg := m.packageOf(id); pkg != nil && pkg.pkg != obj.Pkg() {
+					return fmt.Errorf("'%s' is used by package %s; an unexported name would break it", obj.Name(), pkg.pkg.Path())
+				}
+			}
+		}
+		switch {
+		case isMethod(obj):
+			recv := obj.Type().(*types.Signature).Recv().Type()
+			if other, _, _ := types.LookupFieldOrMethod(recv, true, obj.Pkg(), newName); other != nil {
+				return fmt.Errorf("%s already has a field or method '%s'", types.TypeString(recv, nil), newName)
+			}
+		case isField(obj):
+			for _, tp := range m.packages {
+				for _, tv := range tp.info.Types {
+					s, ok := tv.Type.(*types.Struct)
+					if !ok || !hasField(s, obj) {
+						continue
+					}
+					for field := range s.Fields() {
+						if field.Name() == newName {
+							return fmt.Errorf("the struct of '%s' already has a field '%s'", obj.Name(), newName)
+						}
+					}
+				}
+			}
+			for _, n := range m.namedTypes() {
+				s, ok := n.Underlying().(*types.Struct)
+				if !ok || !hasField(s, obj) {
+					continue
+				}
+				if other, _, _ := types.LookupFieldOrMethod(types.NewPointer(n), true, obj.Pkg(), newName); other != nil {
+					return fmt.Errorf("%s already has a field or method '%s'", n.Obj().Name(), newName)
+				}
+			}
+		default:
+			if err := m.checkScopeConflict(obj, idents, newName); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkScopeConflict reports a declaration of newName in the scope of obj,
+// or one that the renamed obj would hide, or that would hide it.
+func (m *typedModule) checkScopeConflict(obj types.Object, idents []*ast.Ident, newName string) error {
+	scope := obj.Parent()
+	if scope == nil {
+		return nil
+	}
+	if other := scope.Lookup(newName); other != nil {
+		return fmt.Errorf("'%s' is already declared in the scope of '%s' at %s", newName, obj.Name(), m.fset.Position(other.Pos()))
+	}
+	if scope == obj.Pkg().Scope() {
+		// Imports live in the file scopes below the package scope.
+		p := m.byPath[obj.Pkg().Path()]
+		for _, file := range p.files {
+			if fs := p.info.Scopes[file]; fs != nil && fs.Lookup(newName) != nil {
+				return fmt.Errorf("'%s' is already imported in %s", newName, m.fset.Position(file.Pos()).Filename)
+			}
+		}
+	}
+
+	// An unqualified reference to obj must not resolve to a declaration of
+	// newName in a scope between it and obj's scope.
+	qualified := make(map[*ast.Ident]bool)
+	for _, p := range m.packages {
+		for _, file := range p.files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					qualified[sel.Sel] = true
+				}
+				return true
+			})
+		}
+	}
+	for _, id := range idents {
+		p := m.packageOf(id)
+		if qualified[id] || p == nil || p.pkg != obj.Pkg() {
+			continue
+		}
+		inner := p.pkg.Scope().Innermost(id.Pos())
+		if inner == nil {
+			continue
+		}
+		if s, other := inner.LookupParent(newName, id.Pos()); other != nil && s != scope && encloses(scope, s) {
+			return fmt.Errorf("renaming '%s' at %s would make it refer to the '%s' declared at %s", obj.Name(), m.fset.Position(id.Pos()), newName, m.fset.Position(other.Pos()))
+		}
+	}
+
+	// No reference to another newName within obj's scope may resolve to
+	// obj once it is renamed.
+	for _, p := range m.packages {
+		for id, other := range p.info.Uses {
+			if id.Name != newName || qualified[id] || other.Parent() == nil || !encloses(other.Parent(), scope) {
+				continue
+			}
+			inner := p.pkg.Scope().Innermost(id.Pos())
+			if inner != nil && encloses(scope, inner) && (scope == obj.Pkg().Scope() || id.Pos() > obj.Pos()) {
+				return fmt.Errorf("renaming '%s' would hide the '%s' used at %s", obj.Name(), newName, m.fset.Position(id.Pos()))
+			}
+		}
+	}
+	return nil
+}
+
+// encloses reports whether inner is outer or nested in it.
+func encloses(outer, inner *types.Scope) bool {
+	for s := inner; s != nil; s = s.Parent() {
+		if s == outer {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *typedModule) packageOf(id *ast.Ident) *typedPackage {
+	for _, p := range m.packages {
+		for _, file := range p.files {
+			if file.FileStart <= id.Pos() && id.Pos() < file.FileEnd {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+func isMethod(obj types.Object) bool {
+	f, ok := obj.(*types.Func)
+	return ok && f.Type().(*types.Signature).Recv() != nil
+}
+
+func isField(obj types.Object) bool {
+	v, ok := obj.(*types.Var)
+	return ok && v.IsField()
+}
+
+func hasField(s *types.Struct, field types.Object) bool {
+	for f := range s.Fields() {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
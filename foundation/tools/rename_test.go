@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenameSymbol(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+// Store holds widgets.
+type Store struct {
+	items map[string]int
+}
+
+// Counter counts things.
+type Counter interface {
+	Count() int
+}
+
+func New() *Store { return &Store{items: map[string]int{}} }
+
+// Count reports the number of widgets.
+func (s *Store) Count() int { return len(s.items) }
+
+func total(stores ...*Store) int {
+	n := 0
+	for _, s := range stores {
+		n += s.Count()
+	}
+	return n
+}
+`,
+		"store/store_test.go": `package store
+
+import "testing"
+
+func TestCount(t *testing.T) {
+	if New().Count() != 0 || total() != 0 {
+		t.Fatal("not empty")
+	}
+}
+`,
+		"store/example_test.go": `package store_test
+
+import "example.com/shop/store"
+
+func ExampleNew() { _ = store.New().Count() }
+`,
+		"other/other.go": `package other
+
+// Thing is counted too.
+type Thing struct{}
+
+func (Thing) Count() int { return 1 }
+
+// Unrelated has a Count method no interface shares with Store.
+type Unrelated struct{}
+
+func (Unrelated) Count(kind string) int { return len(kind) }
+`,
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/shop/other"
+	"example.com/shop/store"
+)
+
+type wrapper struct {
+	*store.Store
+}
+
+func main() {
+	w := wrapper{store.New()}
+	var c store.Counter = w.Store
+	fmt.Println(c.Count(), w.Count(), other.Thing{}.Count(), other.Unrelated{}.Count("x"))
+}
+`,
+	})
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewRenameSymbolTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// A method is renamed with the interface method it implements and the
+	// other implementations of that interface method.
+	resp := callTool[RenameSymbolRequest, RenameSymbolResponse](t, ctx, bt, RenameSymbolRequest{Path: "store/store.go", Name: "Count", NewName: "Size", Line: 16})
+	if resp.Error != "" {
+		t.Fatalf("rename Count: %s", resp.Error)
+	}
+	if want := "main.go,other/other.go,store/example_test.go,store/store.go,store/store_test.go"; strings.Join(resp.Files, ",") != want {
+		t.Errorf("files = %v, want %s", resp.Files, want)
+	}
+	if got := read("main.go"); !strings.Contains(got, `fmt.Println(c.Size(), w.Size(), other.Thing{}.Size(), other.Unrelated{}.Count("x"))`) {
+		t.Errorf("main.go =\n%s", got)
+	}
+	if got := read("store/store.go"); strings.Contains(got, "Count()") || !strings.Contains(got, "// Count reports") {
+		t.Errorf("store.go =\n%s", got)
+	}
+
+	// Renaming a type renames the fields embedding it.
+	resp = callTool[RenameSymbolRequest, RenameSymbolResponse](t, ctx, bt, RenameSymbolRequest{Path: "main.go", Name: "Store", NewName: "Repo", Line: 11})
+	if resp.Error != "" {
+		t.Fatalf("rename Store: %s", resp.Error)
+	}
+	if got := read("main.go"); !strings.Contains(got, "*store.Repo\n") || !strings.Contains(got, "= w.Repo\n") {
+		t.Errorf("main.go =\n%s", got)
+	}
+	vet := exec.Command("go", "vet", "./...")
+	vet.Dir = dir
+	if out, err := vet.CombinedOutput(); err != nil {
+		t.Fatalf("the renamed module does not build: %v\n%s", err, out)
+	}
+
+	before := read("store/store.go")
+	for name, tc := range map[string]struct {
+		req  RenameSymbolRequest
+		want string
+	}{
+		"taken":        {RenameSymbolRequest{Path: "store/store.go", Name: "total", NewName: "New"}, "already declared"},
+		"unexported":   {RenameSymbolRequest{Path: "store/store.go", Name: "New", NewName: "newRepo"}, "used by package"},
+		"shadowed":     {RenameSymbolRequest{Path: "store/store.go", Name: "n", NewName: "s"}, "would"},
+		"field":        {RenameSymbolRequest{Path: "store/store.go", Name: "items", NewName: "Size"}, "already has a field or method"},
+		"ambiguous":    {RenameSymbolRequest{Path: "store/store.go", Name: "s", NewName: "r"}, "different symbols"},
+		"outside":      {RenameSymbolRequest{Path: "main.go", Name: "Println", NewName: "Print"}, "outside the module"},
+		"invalid name": {RenameSymbolRequest{Path: "main.go", Name: "main", NewName: "1x"}, "not a valid Go identifier"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := callTool[RenameSymbolRequest, RenameSymbolResponse](t, ctx, bt, tc.req)
+			if !strings.Contains(resp.Error, tc.want) {
+				t.Errorf("error = %q, want it to mention %q", resp.Error, tc.want)
+			}
+		})
+	}
+	if read("store/store.go") != before {
+		t.Error("a refused rename changed store.go")
+	}
+}
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/go-git/go-git/v5"
+)
+
+// Limits that keep an overview small enough to hand to the model whole.
+const (
+	maxOverviewFiles  = 1000
+	maxOverviewReadme = 4000
+)
+
+type RepoOverviewRequest struct {
+	Path         string `json:"path" jsonschema:"description=Path of the repository, as returned by gitclone."`
+	IncludeFiles bool   `json:"include_files,omitempty" jsonschema:"description=Also list the repository's files (up to 1000)."`
+}
+
+// PackageSummary describes one Go package of a repository.
+type PackageSummary struct {
+	Dir      string `json:"dir" jsonschema:"description=Directory of the package, relative to the repository root."`
+	Name     string `json:"name" jsonschema:"description=Package name."`
+	Synopsis string `json:"synopsis,omitempty" jsonschema:"description=First sentence of the package documentation."`
+	Files    int    `json:"files" jsonschema:"description=Number of non-test Go files."`
+}
+
+// RepoOverview is the cached summary of a repository.
+type RepoOverview struct {
+	Commit    string           `json:"commit,omitempty" jsonschema:"description=Commit the overview was built from."`
+	Module    string           `json:"module,omitempty" jsonschema:"description=Go module path from go.mod."`
+	TopLevel  []string         `json:"top_level" jsonschema:"description=Entries in the repository root; directories end in '/'."`
+	FileCount int              `json:"file_count" jsonschema:"description=Number of files, excluding vendored and VCS directories."`
+	Languages map[string]int   `json:"languages,omitempty" jsonschema:"description=File count per extension."`
+	Packages  []PackageSummary `json:"packages,omitempty" jsonschema:"description=Go packages with their documentation synopsis."`
+	Readme    string           `json:"readme,omitempty" jsonschema:"description=Start of the README."`
+	Files     []string         `json:"files,omitempty" jsonschema:"description=Repository files, when include_files is set."`
+}
+
+type RepoOverviewResponse struct {
+	Overview *RepoOverview `json:"overview,omitempty" jsonschema:"description=Summary of the repository."`
+	Error    string        `json:"error,omitempty" jsonschema:"description=Error message if the overview could not be built."`
+}
+
+// NewRepoOverviewTool returns the repo_overview tool. Overviews are cached
+// next to the repository, in <repo>.overview.json, and rebuilt when the
+// checked-out commit changes.
+func NewRepoOverviewTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"repo_overview",
+		"Summarize a cloned repository in one call: module path, top-level layout, file counts per language, every Go package with its doc synopsis, and the start of the README. Call it first after gitclone instead of exploring with many search_files calls.",
+		func(ctx context.Context, req *RepoOverviewRequest) (*RepoOverviewResponse, error) {
+			if req.Path == "" {
+				return &RepoOverviewResponse{Error: "path cannot be empty"}, nil
+			}
+			path, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &RepoOverviewResponse{Error: err.Error()}, nil
+			}
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				return &RepoOverviewResponse{Error: fmt.Sprintf("'%s' is not a directory. Use the path returned by gitclone.", req.Path)}, nil
+			}
+
+			overview, err := loadOverview(ctx, path)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &RepoOverviewResponse{Error: err.Error()}, nil
+			}
+			if !req.IncludeFiles {
+				overview.Files = nil
+			}
+			return &RepoOverviewResponse{Overview: overview}, nil
+		},
+	)
+}
+
+// overviewPath is where the overview of the repository at dir is cached. It
+// lives outside the worktree so that it never counts as a local change.
+func overviewPath(dir string) string {
+	return filepath.Clean(dir) + ".overview.json"
+}
+
+// loadOverview returns the cached overview of dir, rebuilding it when it is
+// missing or was built from another commit.
+func loadOverview(ctx context.Context, dir string) (*RepoOverview, error) {
+	commit := headCommit(dir)
+	if commit != "" {
+		if data, err := os.ReadFile(overviewPath(dir)); err == nil {
+			var cached RepoOverview
+			if json.Unmarshal(data, &cached) == nil && cached.Commit == commit {
+				return &cached, nil
+			}
+		}
+	}
+	return buildOverview(ctx, dir)
+}
+
+// buildOverview summarizes the repository at dir and caches the result when
+// dir is a Git checkout. It stops early when ctx is done.
+func buildOverview(ctx context.Context, dir string) (*RepoOverview, error) {
+	overview := &RepoOverview{Commit: headCommit(dir), Languages: make(map[string]int)}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", dir, err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if e.IsDir() {
+			name += "/"
+		}
+		overview.TopLevel = append(overview.TopLevel, name)
+		if !e.IsDir() && isReadme(name) && overview.Readme == "" {
+			overview.Readme = readPrefix(filepath.Join(dir, name), maxOverviewReadme)
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		overview.Module = modulePath(data)
+	}
+
+	packages := make(map[string]*PackageSummary)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "node_modules" || d.Name() == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		overview.FileCount++
+		if len(overview.Files) < maxOverviewFiles {
+			overview.Files = append(overview.Files, filepath.ToSlash(rel))
+		}
+		ext := strings.ToLower(filepath.Ext(d.Name()))
+		if ext == "" {
+			ext = "(none)"
+		}
+		overview.Languages[ext]++
+
+		if ext == ".go" && !strings.HasSuffix(d.Name(), "_test.go") {
+			summarizeGoFile(packages, filepath.ToSlash(filepath.Dir(rel)), path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk '%s': %w", dir, err)
+	}
+
+	for _, pkg := range packages {
+		overview.Packages = append(overview.Packages, *pkg)
+	}
+	sort.Slice(overview.Packages, func(i, j int) bool { return overview.Packages[i].Dir < overview.Packages[j].Dir })
+
+	if overview.Commit != "" {
+		if data, err := json.Marshal(overview); err == nil {
+			_ = os.WriteFile(overviewPath(dir), data, 0o644) // The cache is best effort.
+		}
+	}
+	return overview, nil
+}
+
+// summarizeGoFile adds the package clause and doc comment of the Go file at
+// path to the summary of the package in dir.
+func summarizeGoFile(packages map[string]*PackageSummary, dir, path string) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return
+	}
+	pkg, ok := packages[dir]
+	if !ok {
+		pkg = &PackageSummary{Dir: dir, Name: file.Name.Name}
+		packages[dir] = pkg
+	}
+	pkg.Files++
+	if pkg.Synopsis == "" && file.Doc != nil {
+		pkg.Synopsis = firstSentence(file.Doc.Text())
+	}
+}
+
+// firstSentence returns the first sentence of a doc comment, on one line.
+func firstSentence(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if i := strings.Index(text, ". "); i >= 0 {
+		return text[:i+1]
+	}
+	return text
+}
+
+// modulePath returns the module path declared in a go.mod file.
+func modulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), "\"`")
+		}
+	}
+	return ""
+}
+
+func isReadme(name string) bool {
+	base := strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+	return base == "readme"
+}
+
+func readPrefix(path string, n int) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	buf := make([]byte, n)
+	m, _ := f.Read(buf)
+	return strings.ToValidUTF8(string(buf[:m]), "")
+}
+
+// headCommit returns the commit checked out in dir, or "" if dir is not a
+// Git repository.
+func headCommit(dir string) string {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return ""
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return ""
+	}
+	return head.Hash().String()
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/go-git/go-git/v5"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRepoOverview(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "widgets")
+	writeFiles(t, dir, map[string]string{
+		"go.mod":                 "module example.com/widgets // the module\n\ngo 1.25\n",
+		"README.md":              "# Widgets\n\nA widget store.\n",
+		"main.go":                "// Command widgets serves widgets. It listens on :8080.\npackage main\n",
+		"store/store.go":         "// Package store keeps widgets in memory.\npackage store\n",
+		"store/index.go":         "package store\n",
+		"store/store_test.go":    "package store_test\n",
+		"vendor/dep/dep.go":      "package dep\n",
+		".github/workflows/x.go": "package x\n",
+	})
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	(&fakeRemotes{}).commitAll(t, repo, "initial")
+	ctx := context.Background()
+	bt, err := NewRepoOverviewTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := RepoOverviewRequest{Path: dir, IncludeFiles: true}
+
+	resp := callTool[RepoOverviewRequest, RepoOverviewResponse](t, ctx, bt, req)
+	if resp.Error != "" {
+		t.Fatalf("repo_overview failed: %s", resp.Error)
+	}
+	o := resp.Overview
+	if o.Module != "example.com/widgets" || o.Readme != "# Widgets\n\nA widget store.\n" || o.FileCount != 6 || o.Languages[".go"] != 4 {
+		t.Errorf("unexpected overview %+v", o)
+	}
+	want := []PackageSummary{
+		{Dir: ".", Name: "main", Synopsis: "Command widgets serves widgets.", Files: 1},
+		{Dir: "store", Name: "store", Synopsis: "Package store keeps widgets in memory.", Files: 2},
+	}
+	if len(o.Packages) != len(want) || o.Packages[0] != want[0] || o.Packages[1] != want[1] {
+		t.Errorf("packages = %+v, want %+v", o.Packages, want)
+	}
+	if _, err := os.Stat(overviewPath(dir)); err != nil {
+		t.Errorf("overview was not cached: %v", err)
+	}
+
+	// Uncommitted changes are served from the cache; a new commit rebuilds it.
+	writeFiles(t, dir, map[string]string{"api/api.go": "// Package api is new.\npackage api\n"})
+	if got := callTool[RepoOverviewRequest, RepoOverviewResponse](t, ctx, bt, req).Overview; len(got.Packages) != 2 {
+		t.Errorf("cached overview was rebuilt before a commit: %+v", got.Packages)
+	}
+	(&fakeRemotes{}).commitAll(t, repo, "add api")
+	if got := callTool[RepoOverviewRequest, RepoOverviewResponse](t, ctx, bt, req).Overview; len(got.Packages) != 3 || got.Packages[1].Dir != "api" {
+		t.Errorf("overview was not rebuilt after a commit: %+v", got.Packages)
+	}
+}
+
+func TestRepoOverviewRespectsWorkspace(t *testing.T) {
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+	bt, err := NewRepoOverviewTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bt.(tool.InvokableTool).InvokableRun(ctx, `{"path":"/etc"}`); err == nil {
+		t.Error("repo_overview read a directory outside the workspace")
+	}
+}
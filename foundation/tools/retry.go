@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/forward"
+)
+
+// Classes of tool failure, the codes of a ToolError.
+const (
+	FailureSyntax      = "syntax_error"      // The code given to an editing tool does not parse.
+	FailureInvalidArgs = "invalid_arguments" // A required argument is missing or malformed.
+	FailureNotFound    = "not_found"         // The file, symbol or resource does not exist.
+	FailureTimeout     = "timeout"           // The call ran out of time.
+	FailureUnavailable = "unavailable"       // A remote service failed or could not be reached.
+	FailureOther       = "tool_error"        // Anything else.
+	FailureRetryLimit  = "retry_limit"       // The tool failed too often this turn and was not run.
+	FailureDenied      = "denied"            // The tool policy does not allow the call, which was not run.
+)
+
+// defaultSuggestion is the advice for failures no other advice fits.
+const defaultSuggestion = "Read the error and change the arguments before calling the tool again; do not repeat the same call."
+
+// Hint is added to the result of a failed tool call, next to its error, so
+// the model recovers with a different call instead of repeating the failed
+// one. The system prompt tells the model to follow it.
+type Hint struct {
+	Suggestion  string `json:"suggestion"`
+	RetriesLeft *int   `json:"retries_left,omitempty"` // Failed calls of this tool still allowed this turn.
+}
+
+// suggestions holds the advice for failures that have a better alternative
+// than trying again, by tool and class.
+var suggestions = map[string]map[string]string{
+	"edit_go_file": {
+		FailureSyntax:      "A replace_code_block must replace whole declarations. Escalate: replace the entire enclosing function or type, from its doc comment to its closing brace, reading the file first for the exact lines.",
+		FailureInvalidArgs: "Check the operation's required fields (start_line and end_line for replace_code_block, import_path for imports, var_name for add_var/add_const) and call again.",
+	},
+	"apply_changeset": {
+		FailureSyntax: "Nothing was written. Fix the edit named in the error, replacing whole declarations, and send the complete changeset again.",
+	},
+	"rename_symbol": {
+		FailureNotFound: "Find the symbol's exact declaration with search_files first; rename_symbol needs the line and column of an identifier.",
+	},
+	"resolve_conflict": {
+		FailureSyntax: "Nothing was written. Resolve the hunk with 'custom' code that completes every declaration both sides touch, reading the lines around it first.",
+	},
+	"read_file": {
+		FailureNotFound: "Find the file with search_files, or list its directory, before reading it.",
+	},
+	"fix_dependencies": {
+		FailureNotFound: "A package path is not always its module's path. Check the import for typos, or pass the module with a version in get, e.g. 'github.com/owner/repo@latest'; if it does not exist, change the import instead.",
+	},
+	"profile_program": {
+		FailureTimeout: "Profile one benchmark with a shorter bench_time, such as '1s' or '100x', instead of the whole package.",
+	},
+	"scan_vulnerabilities": {
+		FailureTimeout: "Scan one package pattern, such as './cmd/server', instead of the whole module.",
+	},
+	"run_go": {
+		FailureTimeout: "Narrow the command, for example to one package or with -run for one test, instead of running it again as is.",
+	},
+	"gitclone": {
+		FailureUnavailable: "Check the repository URL; if it is right, tell the user the host could not be reached rather than cloning again.",
+	},
+}
+
+// classSuggestions is the advice for a class of failure from any tool.
+var classSuggestions = map[string]string{
+	FailureSyntax:      "Fix the syntax the error points at, or provide a larger, complete piece of code.",
+	FailureInvalidArgs: "Fix the argument the error names; the tool's schema lists what each one takes.",
+	FailureNotFound:    "Look the name or path up with a search tool before trying again.",
+	FailureTimeout:     "Ask for less work in one call, such as a narrower path or query.",
+	FailureUnavailable: "Try once more at most, then use another source or tell the user the service failed.",
+	FailureDenied:      "Do not retry this call; find another way or tell the user it is not allowed.",
+}
+
+// classifyFailure sorts a tool's error message into a class of failure.
+func classifyFailure(message string) string {
+	m := strings.ToLower(message)
+	class := FailureOther
+	switch {
+	case strings.Contains(m, "syntax") || strings.Contains(m, "expected ") || strings.Contains(m, "do not cover whole declarations"):
+		class = FailureSyntax
+	case strings.Contains(m, "timed out") || strings.Contains(m, "timeout") || strings.Contains(m, "deadline exceeded"):
+		class = FailureTimeout
+	case strings.Contains(m, "not found") || strings.Contains(m, "no such file") || strings.Contains(m, "does not exist"):
+		class = FailureNotFound
+	case strings.Contains(m, "status 50") || strings.Contains(m, "connection refused") || strings.Contains(m, "no such host") ||
+		strings.Contains(m, "unavailable") || strings.Contains(m, "status 429") || strings.Contains(m, "rate limit"):
+		class = FailureUnavailable
+	case strings.Contains(m, "cannot be empty") || strings.Contains(m, "is required") || strings.Contains(m, "are required") ||
+		strings.Contains(m, "invalid") || strings.Contains(m, "unknown operation") || strings.Contains(m, "out of file bounds"):
+		class = FailureInvalidArgs
+	}
+	return class
+}
+
+// hintFor picks the advice for a class of failure of the named tool.
+func hintFor(toolName, class string) Hint {
+	if s, ok := suggestions[toolName][class]; ok {
+		return Hint{Suggestion: s}
+	}
+	if s, ok := classSuggestions[class]; ok {
+		return Hint{Suggestion: s}
+	}
+	return Hint{Suggestion: defaultSuggestion}
+}
+
+type failureTallyKey struct{}
+
+// failureTally counts the consecutive failed calls of each tool in a turn.
+type failureTally struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// WithFailureTally starts counting the failed tool calls of one turn, so
+// that RetryGuide can stop a tool that keeps failing. Without it failures
+// still get hints, but calls are never refused.
+func WithFailureTally(ctx context.Context) context.Context {
+	return context.WithValue(ctx, failureTallyKey{}, &failureTally{counts: make(map[string]int)})
+}
+
+// RetryGuide puts every failed tool result into the ToolError envelope with
+// a Hint and, per turn, refuses to run a tool again once it has failed
+// MaxRetries times in a row.
+type RetryGuide struct {
+	// MaxRetries is how many consecutive failures of one tool a turn allows;
+	// 0 means no limit.
+	MaxRetries int
+}
+
+// Wrap returns t, named name, with its failures guided. Tools that are not
+// invokable are returned unchanged.
+func (g RetryGuide) Wrap(t tool.BaseTool, name string) tool.BaseTool {
+	inner, ok := t.(tool.InvokableTool)
+	if !ok {
+		return t
+	}
+	return &guidedTool{Tool: forward.Tool{InvokableTool: inner}, name: name, guide: g}
+}
+
+type guidedTool struct {
+	forward.Tool
+	name  string
+	guide RetryGuide
+}
+
+func (t *guidedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	tally, _ := ctx.Value(failureTallyKey{}).(*failureTally)
+	limit := t.guide.MaxRetries
+	if tally != nil && limit > 0 {
+		if failures := tally.get(t.name); failures >= limit {
+			return refusal(t.name, failures), nil
+		}
+	}
+
+	out, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	failure, partial, failed := toolFailure(out, err)
+	if !failed {
+		if err == nil && tally != nil {
+			tally.reset(t.name)
+		}
+		return out, err
+	}
+	hint := hintFor(t.name, failure.Code)
+	if tally != nil {
+		if failures := tally.add(t.name); limit > 0 {
+			left := max(limit-failures, 0)
+			hint.RetriesLeft = &left
+		}
+	}
+	return envelope(failure, hint, partial), nil
+}
+
+func (f *failureTally) get(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counts[name]
+}
+
+// add records a failure of name and returns its consecutive failures.
+func (f *failureTally) add(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[name]++
+	return f.counts[name]
+}
+
+func (f *failureTally) reset(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.counts, name)
+}
+
+// envelope renders a failed result.
+func envelope(failure *ToolError, hint Hint, partial json.RawMessage) string {
+	data, _ := json.Marshal(failureEnvelope{Error: failure, Hint: hint, Partial: partial})
+	return string(data)
+}
+
+// FailureResult renders failure of a call of the tool called name in the
+// envelope RetryGuide gives, with its hint, for failures that happen outside
+// it, such as a denial by the tool policy.
+func FailureResult(name string, failure *ToolError) string {
+	return envelope(failure, hintFor(name, failure.Code), nil)
+}
+
+// refusal is the result of a call to a tool that has failed too often.
+func refusal(name string, failures int) string {
+	zero := 0
+	return envelope(
+		NewToolError(FailureRetryLimit, fmt.Sprintf("%s failed %d times in a row this turn and was not run again.", name, failures)),
+		Hint{
+			Suggestion:  "Do not call " + name + " again this turn. Use a different tool or approach, or tell the user what failed and ask how to proceed.",
+			RetriesLeft: &zero,
+		},
+		nil,
+	)
+}
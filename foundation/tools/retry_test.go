@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/events"
+)
+
+func TestRetryGuide(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	os.WriteFile(path, []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"), 0o644)
+	edit, err := NewEditFileTool(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	guided := RetryGuide{MaxRetries: 2}.Wrap(edit, "edit_go_file").(tool.InvokableTool)
+
+	type result struct {
+		Message string     `json:"message"`
+		Error   *ToolError `json:"error"`
+		Hint    *Hint      `json:"hint"`
+	}
+	run := func(ctx context.Context, args string) result {
+		t.Helper()
+		return callTool[json.RawMessage, result](t, ctx, guided, json.RawMessage(args))
+	}
+	broken := `{"path":"` + path + `","operation":"replace_code_block","start_line":4,"end_line":4,"code":"println(\"bye\""}`
+	fixed := `{"path":"` + path + `","operation":"replace_code_block","start_line":3,"end_line":5,"code":"func main() {\n\tprintln(\"bye\")\n}"}`
+
+	// A syntax failure of a partial replacement points at replacing the
+	// whole declaration, and counts down the retries left.
+	ctx := WithWorkspace(WithFailureTally(context.Background()), dir)
+	r := run(ctx, broken)
+	if r.Error == nil || r.Error.Code != FailureSyntax || r.Error.Retryable || r.Error.Message == "" || r.Message != "" ||
+		r.Hint == nil || r.Hint.Suggestion != suggestions["edit_go_file"][FailureSyntax] || r.Hint.RetriesLeft == nil || *r.Hint.RetriesLeft != 1 {
+		t.Fatalf("first failure: %+v", r)
+	}
+	if r := run(ctx, broken); r.Hint == nil || *r.Hint.RetriesLeft != 0 {
+		t.Fatalf("second failure: %+v", r)
+	}
+	// Past the limit the tool is not run at all, even with good arguments.
+	if r := run(ctx, fixed); r.Error == nil || r.Error.Code != FailureRetryLimit || r.Hint == nil {
+		t.Fatalf("call past the limit: %+v", r)
+	}
+	if src, _ := os.ReadFile(path); string(src) != "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n" {
+		t.Fatalf("a refused call edited the file:\n%s", src)
+	}
+
+	// A new turn starts over, and a success clears the count.
+	ctx = WithWorkspace(WithFailureTally(context.Background()), dir)
+	run(ctx, broken)
+	if r := run(ctx, fixed); r.Error != nil || r.Hint != nil || r.Message == "" {
+		t.Fatalf("success: %+v", r)
+	}
+	if r := run(ctx, broken); r.Hint == nil || *r.Hint.RetriesLeft != 1 {
+		t.Errorf("failure after a success: %+v", r)
+	}
+
+	// Without a tally failures get hints but no limit.
+	if r := run(WithWorkspace(context.Background(), dir), broken); r.Hint == nil || r.Hint.RetriesLeft != nil {
+		t.Errorf("failure without a tally: %+v", r)
+	}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	for message, want := range map[string]string{
+		"path cannot be empty":                                                      FailureInvalidArgs,
+		"start_line 40 is out of file bounds (1-12)":                                FailureInvalidArgs,
+		"failed to stat file 'x.go': no such file":                                  FailureNotFound,
+		"go test timed out after 2m0s":                                              FailureTimeout,
+		"Failed to send the email: status 503: busy":                                FailureUnavailable,
+		"grounded search failed: gemini rate limit exceeded":                        FailureUnavailable,
+		"the provided replacement 'code' is not valid Go syntax: 1:8: expected ')'": FailureSyntax,
+		"exit status 1": FailureOther,
+	} {
+		if got := classifyFailure(message); got != want {
+			t.Errorf("classifyFailure(%q) = %s, want %s", message, got, want)
+		}
+	}
+}
+
+// failingTool fails every call with err, or with a response holding an
+// "error" field next to partial results when err is nil.
+type failingTool struct{ err error }
+
+func (f failingTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "search_internet"}, nil
+}
+
+func (f failingTool) InvokableRun(context.Context, string, ...tool.Option) (string, error) {
+	if f.err != nil {
+		return "", fmt.Errorf("[LocalFunc] failed to invoke tool: %w", f.err)
+	}
+	return `{"query":"eino","results":[{"url":"https://github.com/cloudwego/eino"}],"error":"fetching pages: status 502"}`, nil
+}
+
+func TestToolErrorEnvelope(t *testing.T) {
+	guide := RetryGuide{MaxRetries: 3}
+	ctx := WithFailureTally(context.Background())
+
+	out, err := guide.Wrap(failingTool{}, "search_internet").(tool.InvokableTool).InvokableRun(ctx, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got failureEnvelope
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if *got.Error != (ToolError{Code: FailureUnavailable, Message: "fetching pages: status 502", Retryable: true}) {
+		t.Errorf("error = %+v", got.Error)
+	}
+	if !strings.Contains(string(got.Partial), `"url":"https://github.com/cloudwego/eino"`) || strings.Contains(string(got.Partial), "status 502") {
+		t.Errorf("partial = %s, want the results without the error", got.Partial)
+	}
+	if msg := events.ToolError(out); msg != "fetching pages: status 502" {
+		t.Errorf("events.ToolError = %q", msg)
+	}
+
+	// A *ToolError returned by the tool keeps its code instead of being
+	// classified from its message.
+	notFound := NewToolError(FailureNotFound, "no results for the query")
+	out, err = guide.Wrap(failingTool{err: notFound}, "search_internet").(tool.InvokableTool).InvokableRun(ctx, "{}")
+	if err != nil || !strings.HasPrefix(out, `{"error":{"code":"not_found","message":"no results for the query","retryable":false},"hint":{`) {
+		t.Errorf("InvokableRun = %s, %v", out, err)
+	}
+
+	// Other errors end the turn as before.
+	if _, err := guide.Wrap(failingTool{err: ErrReadOnly}, "search_internet").(tool.InvokableTool).InvokableRun(ctx, "{}"); !errors.Is(err, ErrToolDenied) {
+		t.Errorf("a denial was turned into a result: %v", err)
+	}
+}
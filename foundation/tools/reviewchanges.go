@@ -0,0 +1,484 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Review scopes: what the changes are compared with.
+const (
+	ReviewAll      = "all"      // The worktree against HEAD, staged or not.
+	ReviewStaged   = "staged"   // The index against HEAD: what would be committed.
+	ReviewUnstaged = "unstaged" // The worktree against the index.
+)
+
+// Limits that keep a review small enough to hand to the model whole. Files
+// past the limits are still listed, with their counts but no hunks.
+const (
+	maxReviewFiles     = 100
+	maxReviewFileLines = 400  // Diff lines of one file.
+	maxReviewLines     = 2000 // Diff lines of all files.
+	reviewContext      = 3    // Unchanged lines around each change.
+)
+
+type ReviewChangesRequest struct {
+	Path  string `json:"path,omitempty" jsonschema:"description=A directory in the Git repository to review. Defaults to the workspace."`
+	Scope string `json:"scope,omitempty" jsonschema:"description=Which changes to review: 'all' uncommitted changes (the default)\\, 'staged' changes only or 'unstaged' changes only.,enum=all,enum=staged,enum=unstaged"`
+}
+
+// ReviewHunk is one change of a file with the lines around it.
+type ReviewHunk struct {
+	Header string `json:"header" jsonschema:"description=Unified diff header giving the changed line ranges\\, as in '@@ -10\\,6 +10\\,8 @@'."`
+	Diff   string `json:"diff" jsonschema:"description=The lines of the hunk\\, each starting with '+' (added)\\, '-' (removed) or ' ' (unchanged)."`
+}
+
+// ReviewFile is the summary of the changes of one file.
+type ReviewFile struct {
+	Path      string       `json:"path" jsonschema:"description=Path of the file\\, relative to the repository root."`
+	Status    string       `json:"status" jsonschema:"description=added\\, modified\\, deleted or untracked."`
+	Added     int          `json:"added" jsonschema:"description=Lines added."`
+	Removed   int          `json:"removed" jsonschema:"description=Lines removed."`
+	Binary    bool         `json:"binary,omitempty" jsonschema:"description=The file is binary and has no line diff."`
+	Symbols   []string     `json:"symbols,omitempty" jsonschema:"description=For Go files\\, the top-level declarations the changes touch."`
+	Hunks     []ReviewHunk `json:"hunks,omitempty" jsonschema:"description=The changes\\, as unified diff hunks."`
+	Truncated bool         `json:"truncated,omitempty" jsonschema:"description=Some hunks were left out to fit the review; read the file for the rest."`
+}
+
+type ReviewChangesResponse struct {
+	Repository string       `json:"repository,omitempty" jsonschema:"description=Root of the repository."`
+	Branch     string       `json:"branch,omitempty" jsonschema:"description=Checked-out branch\\, empty when HEAD is detached."`
+	Scope      string       `json:"scope,omitempty" jsonschema:"description=The changes reviewed."`
+	Summary    string       `json:"summary,omitempty" jsonschema:"description=Files changed and lines added and removed\\, over all files."`
+	Files      []ReviewFile `json:"files,omitempty" jsonschema:"description=The changed files\\, sorted by path."`
+	Truncated  bool         `json:"truncated,omitempty" jsonschema:"description=More files changed than are listed."`
+	Error      string       `json:"error,omitempty" jsonschema:"description=Error message if the changes could not be read."`
+}
+
+// NewReviewChangesTool returns the review_changes tool, which summarizes the
+// uncommitted changes of a Git repository file by file, with go-git rather
+// than the git command.
+func NewReviewChangesTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"review_changes",
+		"Review the uncommitted changes of the Git repository holding the workspace (or path): each changed file "+
+			"with its status, lines added and removed, the Go declarations touched and the diff hunks. "+
+			"Use it when asked to review what was changed in the session or the user's staged changes, "+
+			"instead of reading every file; scope 'staged' reviews only what would be committed.",
+		func(ctx context.Context, req *ReviewChangesRequest) (*ReviewChangesResponse, error) {
+			scope := req.Scope
+			if scope == "" {
+				scope = ReviewAll
+			}
+			if scope != ReviewAll && scope != ReviewStaged && scope != ReviewUnstaged {
+				return &ReviewChangesResponse{Error: fmt.Sprintf("unknown scope '%s'; use all, staged or unstaged", req.Scope)}, nil
+			}
+			dir := req.Path
+			if dir == "" {
+				dir = "."
+			}
+			dir, err := resolvePath(ctx, dir)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &ReviewChangesResponse{Error: err.Error()}, nil
+			}
+
+			resp, err := reviewChanges(ctx, dir, scope)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &ReviewChangesResponse{Error: err.Error()}, nil
+			}
+			return resp, nil
+		},
+	)
+}
+
+// reviewChanges reviews the changes of scope in the repository holding dir.
+func reviewChanges(ctx context.Context, dir, scope string) (*ReviewChangesResponse, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, fmt.Errorf("'%s' is not in a Git repository", dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worktree: %w", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status: %w", err)
+	}
+	src := &reviewSource{repo: repo, root: wt.Filesystem.Root()}
+	if head, err := repo.Head(); err == nil {
+		if head.Name().IsBranch() {
+			src.branch = head.Name().Short()
+		}
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read HEAD: %w", err)
+		}
+		if src.head, err = commit.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to read HEAD: %w", err)
+		}
+	}
+
+	paths := make([]string, 0, len(status))
+	for path, s := range status {
+		if inReviewScope(s, scope) {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+
+	resp := &ReviewChangesResponse{Repository: src.root, Branch: src.branch, Scope: scope, Files: []ReviewFile{}}
+	budget := maxReviewLines
+	var changed, added, removed int
+	for _, path := range paths {
+		if err := canceled(ctx); err != nil {
+			return nil, err
+		}
+		file, err := src.review(path, status[path], scope, budget)
+		if err != nil {
+			return nil, err
+		}
+		if file == nil { // Identical after all, as with a file whose mode alone changed.
+			continue
+		}
+		budget -= hunkLines(file.Hunks)
+		changed++
+		added += file.Added
+		removed += file.Removed
+		if len(resp.Files) < maxReviewFiles {
+			resp.Files = append(resp.Files, *file)
+		} else {
+			resp.Truncated = true
+		}
+	}
+	switch {
+	case changed > 0:
+		resp.Summary = fmt.Sprintf("%s changed, %s added, %s removed", plural(changed, "file"), plural(added, "line"), plural(removed, "line"))
+	case scope == ReviewAll:
+		resp.Summary = "No uncommitted changes."
+	default:
+		resp.Summary = "No " + scope + " changes."
+	}
+	return resp, nil
+}
+
+// plural formats n things, as in "1 file" or "3 files".
+func plural(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+// inReviewScope reports whether a file of status s has changes in scope.
+func inReviewScope(s *git.FileStatus, scope string) bool {
+	switch scope {
+	case ReviewStaged:
+		return s.Staging != git.Unmodified && s.Staging != git.Untracked
+	case ReviewUnstaged:
+		return s.Worktree != git.Unmodified
+	default:
+		return s.Staging != git.Unmodified || s.Worktree != git.Unmodified
+	}
+}
+
+// reviewSource reads the versions of a file a review compares.
+type reviewSource struct {
+	repo   *git.Repository
+	root   string
+	branch string
+	head   *object.Tree // Nil before the first commit.
+}
+
+// review compares the two versions of path that scope compares, with up to
+// budget lines of hunks. It returns nil when they are the same.
+func (s *reviewSource) review(path string, status *git.FileStatus, scope string, budget int) (*ReviewFile, error) {
+	var (
+		before, after []byte
+		hadBefore     bool
+		hasAfter      bool
+		err           error
+	)
+	switch scope {
+	case ReviewStaged:
+		if before, hadBefore, err = s.fromHead(path); err == nil {
+			after, hasAfter, err = s.fromIndex(path)
+		}
+	case ReviewUnstaged:
+		if before, hadBefore, err = s.fromIndex(path); err == nil {
+			after, hasAfter, err = s.fromWorktree(path)
+		}
+	default:
+		if before, hadBefore, err = s.fromHead(path); err == nil {
+			after, hasAfter, err = s.fromWorktree(path)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if hadBefore == hasAfter && bytes.Equal(before, after) {
+		return nil, nil
+	}
+
+	file := &ReviewFile{Path: path, Status: "modified"}
+	switch {
+	case !hadBefore && status.Worktree == git.Untracked:
+		file.Status = "untracked"
+	case !hadBefore:
+		file.Status = "added"
+	case !hasAfter:
+		file.Status = "deleted"
+	}
+	if isBinary(before) || isBinary(after) {
+		file.Binary = true
+		return file, nil
+	}
+
+	a, b := diffLines(before), diffLines(after)
+	var changed []int // Lines of the file the changes touch, from 1.
+	matcher := difflib.NewMatcher(a, b)
+	for _, group := range matcher.GetGroupedOpCodes(reviewContext) {
+		first, last := group[0], group[len(group)-1]
+		var diff strings.Builder
+		lines := 0
+		for _, op := range group {
+			switch op.Tag {
+			case 'e':
+				for _, line := range a[op.I1:op.I2] {
+					diff.WriteString(" " + line)
+				}
+				lines += op.I2 - op.I1
+			default:
+				for _, line := range a[op.I1:op.I2] {
+					diff.WriteString("-" + line)
+				}
+				for _, line := range b[op.J1:op.J2] {
+					diff.WriteString("+" + line)
+				}
+				lines += op.I2 - op.I1 + op.J2 - op.J1
+				file.Removed += op.I2 - op.I1
+				file.Added += op.J2 - op.J1
+				if hasAfter {
+					changed = append(changed, lineRange(op.J1, op.J2)...)
+				} else {
+					changed = append(changed, lineRange(op.I1, op.I2)...)
+				}
+			}
+		}
+		if lines > budget || lines > maxReviewFileLines-hunkLines(file.Hunks) {
+			file.Truncated = true
+			continue
+		}
+		budget -= lines
+		file.Hunks = append(file.Hunks, ReviewHunk{
+			Header: fmt.Sprintf("@@ -%s +%s @@", hunkRange(first.I1, last.I2), hunkRange(first.J1, last.J2)),
+			Diff:   diff.String(),
+		})
+	}
+
+	if strings.HasSuffix(path, ".go") {
+		src := after
+		if !hasAfter {
+			src = before
+		}
+		file.Symbols = touchedDecls(src, changed)
+	}
+	return file, nil
+}
+
+// fromHead returns path as committed in HEAD, and whether it is there.
+func (s *reviewSource) fromHead(path string) ([]byte, bool, error) {
+	if s.head == nil {
+		return nil, false, nil
+	}
+	f, err := s.head.File(path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	content, err := f.Contents()
+	return []byte(content), err == nil, err
+}
+
+// fromIndex returns path as staged, and whether it is staged.
+func (s *reviewSource) fromIndex(path string) ([]byte, bool, error) {
+	idx, err := s.repo.Storer.Index()
+	if err != nil {
+		return nil, false, err
+	}
+	entry, err := idx.Entry(path)
+	if err != nil { // Not in the index.
+		return nil, false, nil
+	}
+	blob, err := s.repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, false, err
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	return content, err == nil, err
+}
+
+// fromWorktree returns path as it is on disk, and whether it exists.
+func (s *reviewSource) fromWorktree(path string) ([]byte, bool, error) {
+	content, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(path)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	return content, err == nil, err
+}
+
+// isBinary reports whether content looks like something other than text.
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content)
+}
+
+// diffLines splits content into lines that each end in a newline.
+func diffLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
+
+// hunkRange formats the lines [start, stop) of a file, counted from 0, as
+// a unified diff does: "1,3" for lines 1 to 3, and "0,0" for none at the
+// start of the file.
+func hunkRange(start, stop int) string {
+	switch n := stop - start; n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprint(start + 1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
+
+// hunkLines counts the diff lines of hunks.
+func hunkLines(hunks []ReviewHunk) int {
+	n := 0
+	for _, h := range hunks {
+		n += strings.Count(h.Diff, "\n")
+	}
+	return n
+}
+
+// lineRange returns the line numbers, from 1, of the lines [start, stop)
+// counted from 0. A change that only removes lines touches the line after
+// them.
+func lineRange(start, stop int) []int {
+	if start == stop {
+		return []int{start + 1}
+	}
+	lines := make([]int, 0, stop-start)
+	for i := start; i < stop; i++ {
+		lines = append(lines, i+1)
+	}
+	return lines
+}
+
+// touchedDecls returns the top-level declarations of the Go source src that
+// span any of lines, such as "func Parse", "func (*Server).Serve" or
+// "type Config", in the order they are declared. It returns nil when src
+// does not parse.
+func touchedDecls(src []byte, lines []int) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+	touches := func(n ast.Node) bool {
+		start, end := fset.Position(n.Pos()).Line, fset.Position(n.End()).Line
+		return slices.ContainsFunc(lines, func(l int) bool { return l >= start && l <= end })
+	}
+
+	var decls []string
+	for _, decl := range f.Decls {
+		if !touches(decl) {
+			continue
+		}
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = "(" + receiverType(d.Recv.List[0].Type) + ")." + name
+			}
+			decls = append(decls, "func "+name)
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				decls = append(decls, "import")
+				continue
+			}
+			for _, spec := range d.Specs {
+				if !touches(spec) && len(d.Specs) > 1 {
+					continue
+				}
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					decls = append(decls, "type "+s.Name.Name)
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						decls = append(decls, d.Tok.String()+" "+n.Name)
+					}
+				}
+			}
+		}
+	}
+	return slices.Compact(decls)
+}
+
+// receiverType formats a method receiver's type, as in "*Server".
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return "?"
+	}
+}
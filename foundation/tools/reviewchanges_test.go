@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestReviewChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"store.go": "package store\n\ntype Store struct{}\n\nfunc (s *Store) Get(id int) string {\n\treturn \"\"\n}\n\nfunc New() *Store {\n\treturn &Store{}\n}\n",
+		"api.go":   "package store\n\nconst Version = 1\n",
+		"old.txt":  "gone\n",
+	})
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	(&fakeRemotes{}).commitAll(t, repo, "initial")
+
+	// api.go is staged, store.go changed but not staged; old.txt is deleted
+	// and notes.md untracked.
+	writeFiles(t, dir, map[string]string{
+		"api.go":   "package store\n\nconst Version = 2\n",
+		"store.go": "package store\n\ntype Store struct{}\n\nfunc (s *Store) Get(id int) string {\n\treturn \"widget\"\n}\n\nfunc New() *Store {\n\treturn &Store{}\n}\n",
+		"notes.md": "# Notes\n",
+	})
+	if err := os.Remove(filepath.Join(dir, "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add("api.go"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewReviewChangesTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := callTool[ReviewChangesRequest, ReviewChangesResponse](t, ctx, bt, ReviewChangesRequest{})
+	if resp.Error != "" {
+		t.Fatalf("review_changes failed: %s", resp.Error)
+	}
+	if resp.Branch != "master" || resp.Scope != ReviewAll || resp.Summary != "4 files changed, 3 lines added, 3 lines removed" {
+		t.Errorf("unexpected review %+v", resp)
+	}
+	var got []string
+	for _, f := range resp.Files {
+		got = append(got, f.Path+" "+f.Status)
+	}
+	if want := []string{"api.go modified", "notes.md untracked", "old.txt deleted", "store.go modified"}; !slices.Equal(got, want) {
+		t.Fatalf("files = %q, want %q", got, want)
+	}
+	store := resp.Files[3]
+	if store.Added != 1 || store.Removed != 1 || !slices.Equal(store.Symbols, []string{"func (*Store).Get"}) {
+		t.Errorf("unexpected store.go review %+v", store)
+	}
+	if len(store.Hunks) != 1 || store.Hunks[0].Header != "@@ -3,7 +3,7 @@" ||
+		!strings.Contains(store.Hunks[0].Diff, "-\treturn \"\"\n+\treturn \"widget\"\n") {
+		t.Errorf("unexpected store.go hunks %+v", store.Hunks)
+	}
+	if api := resp.Files[0]; !slices.Equal(api.Symbols, []string{"const Version"}) {
+		t.Errorf("api.go symbols = %q", api.Symbols)
+	}
+
+	staged := callTool[ReviewChangesRequest, ReviewChangesResponse](t, ctx, bt, ReviewChangesRequest{Scope: ReviewStaged})
+	if len(staged.Files) != 1 || staged.Files[0].Path != "api.go" || staged.Summary != "1 file changed, 1 line added, 1 line removed" {
+		t.Errorf("unexpected staged review %+v", staged)
+	}
+	unstaged := callTool[ReviewChangesRequest, ReviewChangesResponse](t, ctx, bt, ReviewChangesRequest{Scope: ReviewUnstaged})
+	if len(unstaged.Files) != 3 || slices.ContainsFunc(unstaged.Files, func(f ReviewFile) bool { return f.Path == "api.go" }) {
+		t.Errorf("unexpected unstaged review %+v", unstaged)
+	}
+
+	(&fakeRemotes{}).commitAll(t, repo, "second")
+	if clean := callTool[ReviewChangesRequest, ReviewChangesResponse](t, ctx, bt, ReviewChangesRequest{}); len(clean.Files) != 0 || clean.Summary != "No uncommitted changes." {
+		t.Errorf("unexpected review of a clean worktree %+v", clean)
+	}
+	if resp := callTool[ReviewChangesRequest, ReviewChangesResponse](t, WithWorkspace(context.Background(), t.TempDir()), bt, ReviewChangesRequest{}); !strings.Contains(resp.Error, "not in a Git repository") {
+		t.Errorf("error = %q, want not in a Git repository", resp.Error)
+	}
+}
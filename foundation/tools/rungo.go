@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// runGoTimeout bounds a run_go call; test suites take longer than the builds
+// goCommandTimeout is meant for.
+const runGoTimeout = 10 * time.Minute
+
+type RunGoRequest struct {
+	Path     string   `json:"path,omitempty" jsonschema:"description=Directory to run in, inside the module. Defaults to the workspace root."`
+	Command  string   `json:"command" jsonschema:"description=The go command to run: 'build' (compile without writing binaries), 'test' or 'vet'."`
+	Packages []string `json:"packages,omitempty" jsonschema:"description=Package patterns relative to path, e.g. './store' or './...'. Defaults to './...'."`
+	Run      string   `json:"run,omitempty" jsonschema:"description=For 'test', only run tests matching this regular expression, as 'go test -run'."`
+	Verbose  bool     `json:"verbose,omitempty" jsonschema:"description=For 'test', list every test as it runs ('go test -v')."`
+}
+
+type RunGoResponse struct {
+	Command string `json:"command,omitempty" jsonschema:"description=The command line that was run."`
+	Passed  bool   `json:"passed" jsonschema:"description=Whether the command succeeded."`
+	Output  string `json:"output,omitempty" jsonschema:"description=Combined stdout and stderr; long output keeps its start."`
+	Error   string `json:"error,omitempty" jsonschema:"description=Error message if the command could not be run. A failing build or test sets passed to false instead."`
+}
+
+// NewRunGoTool returns the run_go tool, which runs go build, test or vet in
+// the workspace. Their output is streamed to the WithOutput writer of the
+// call's context while they run.
+func NewRunGoTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"run_go",
+		"Run 'go build', 'go test' or 'go vet' on packages of a Go module and report whether it passed with the command's output. Use it to check that edits compile and tests pass instead of asking the user to run them.",
+		func(ctx context.Context, req *RunGoRequest) (*RunGoResponse, error) {
+			args, err := goCommandArgs(req)
+			if err != nil {
+				return &RunGoResponse{Error: err.Error()}, nil
+			}
+			dir, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &RunGoResponse{Error: err.Error()}, nil
+			}
+			if dir == "" {
+				dir = "."
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return &RunGoResponse{Error: fmt.Sprintf("'%s' is not a directory", req.Path)}, nil
+			}
+
+			runCtx, cancel := context.WithTimeout(ctx, runGoTimeout)
+			defer cancel()
+			output, err := runCommand(runCtx, dir, "go", args...)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			resp := &RunGoResponse{
+				Command: "go " + strings.Join(args, " "),
+				Passed:  err == nil,
+				Output:  shortenOutput(output),
+			}
+			var exitErr *exec.ExitError
+			switch {
+			case runCtx.Err() != nil:
+				resp.Error = fmt.Sprintf("%s did not finish within %s", resp.Command, runGoTimeout)
+			case err != nil && !errors.As(err, &exitErr):
+				resp.Error = fmt.Sprintf("failed to run %s: %v", resp.Command, err)
+			}
+			return resp, nil
+		},
+	)
+}
+
+// goCommandArgs returns the arguments of the go command req asks for.
+func goCommandArgs(req *RunGoRequest) ([]string, error) {
+	var args []string
+	switch req.Command {
+	case "build":
+		// Discarding the binaries keeps main packages from writing one into
+		// the module.
+		args = []string{"build", "-o", os.DevNull}
+	case "test":
+		args = []string{"test"}
+		if req.Verbose {
+			args = append(args, "-v")
+		}
+		if req.Run != "" {
+			args = append(args, "-run", req.Run)
+		}
+	case "vet":
+		args = []string{"vet"}
+	case "":
+		return nil, errors.New("command cannot be empty. Use: build, test, vet")
+	default:
+		return nil, fmt.Errorf("unsupported command '%s'. Use: build, test, vet", req.Command)
+	}
+	if (req.Run != "" || req.Verbose) && req.Command != "test" {
+		return nil, errors.New("run and verbose only apply to the 'test' command")
+	}
+
+	packages := req.Packages
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+	for _, p := range packages {
+		if p == "" || strings.HasPrefix(p, "-") {
+			return nil, fmt.Errorf("invalid package pattern '%s'", p)
+		}
+		if filepath.IsAbs(p) || slices.Contains(strings.Split(filepath.ToSlash(p), "/"), "..") {
+			return nil, fmt.Errorf("package pattern '%s' must stay inside path; run from another path instead", p)
+		}
+	}
+	return append(args, packages...), nil
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// lockedBuffer is a strings.Builder safe to read while a command writes it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunGo(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":  "module example.com/shop\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+		"store/store.go": `package store
+
+func Count() int { return 1 }
+`,
+		"store/store_test.go": `package store
+
+import "testing"
+
+func TestCount(t *testing.T) {}
+
+func TestBroken(t *testing.T) { t.Fatal("count is wrong") }
+`,
+	})
+	var streamed lockedBuffer
+	ctx := WithOutput(WithWorkspace(context.Background(), dir), &streamed)
+	bt, err := NewRunGoTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(req RunGoRequest) *RunGoResponse {
+		t.Helper()
+		resp := callTool[RunGoRequest, RunGoResponse](t, ctx, bt, req)
+		return &resp
+	}
+
+	// A failing test is a result, not an error, and its output is streamed.
+	resp := call(RunGoRequest{Command: "test", Packages: []string{"./store"}, Verbose: true})
+	if resp.Error != "" || resp.Passed || !strings.Contains(resp.Output, "count is wrong") {
+		t.Fatalf("failing test = %+v", resp)
+	}
+	if got := streamed.String(); !strings.Contains(got, "=== RUN   TestBroken") || !strings.Contains(got, "count is wrong") {
+		t.Errorf("streamed output = %q", got)
+	}
+
+	resp = call(RunGoRequest{Command: "test", Packages: []string{"./store"}, Run: "^TestCount$"})
+	if resp.Error != "" || !resp.Passed || resp.Command != "go test -run ^TestCount$ ./store" {
+		t.Errorf("selected test = %+v", resp)
+	}
+
+	// Building a main package writes no binary into the module.
+	if resp := call(RunGoRequest{Command: "build"}); resp.Error != "" || !resp.Passed {
+		t.Errorf("build = %+v", resp)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("the build left files in the module: %v", entries)
+	}
+
+	for name, tc := range map[string]struct {
+		req  RunGoRequest
+		want string
+	}{
+		"command":  {RunGoRequest{Command: "run"}, "unsupported command 'run'"},
+		"flag":     {RunGoRequest{Command: "vet", Packages: []string{"-vettool=x"}}, "invalid package pattern"},
+		"escape":   {RunGoRequest{Command: "vet", Packages: []string{"../..."}}, "must stay inside path"},
+		"run flag": {RunGoRequest{Command: "vet", Run: "TestCount"}, "only apply to the 'test' command"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if resp := call(tc.req); !strings.Contains(resp.Error, tc.want) {
+				t.Errorf("error = %q, want it to mention %q", resp.Error, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// scaffoldTemplates holds one directory per project template. File names and
+// contents are text/template templates, and every file ends in .tmpl so that
+// the Go tooling of this module ignores them.
+//
+//go:embed scaffold
+var scaffoldTemplates embed.FS
+
+type ScaffoldProjectRequest struct {
+	Path      string `json:"path" jsonschema:"description=Directory to create the project in. It must not exist or be empty."`
+	Module    string `json:"module,omitempty" jsonschema:"description=Module path, e.g. 'github.com/acme/widgets'. Defaults to the name of the directory."`
+	Template  string `json:"template,omitempty" jsonschema:"description=Project layout: 'cli' (main package with flag parsing, the default), 'library' (importable package), or 'service' (HTTP server under cmd/ and internal/)."`
+	GoVersion string `json:"go_version,omitempty" jsonschema:"description=Go version for go.mod, e.g. '1.25'. Defaults to the version the agent was built with."`
+}
+
+type ScaffoldProjectResponse struct {
+	Message   string   `json:"message,omitempty" jsonschema:"description=Summary of the created project."`
+	Files     []string `json:"files,omitempty" jsonschema:"description=Files that were created."`
+	NextSteps string   `json:"next_steps,omitempty" jsonschema:"description=How to build and test the project."`
+	Error     string   `json:"error,omitempty" jsonschema:"description=Error message if the project was not created."`
+}
+
+// scaffoldData is what the templates are executed with.
+type scaffoldData struct {
+	Module    string
+	Name      string // Package and command name.
+	GoVersion string
+}
+
+// NewScaffoldProjectTool returns the scaffold_project tool, which creates a
+// new Go module from the templates in scaffold/. With a context marked by
+// WithReadOnly every call is refused with ErrReadOnly.
+func NewScaffoldProjectTool(ctx context.Context) (tool.BaseTool, error) {
+	readOnly := ReadOnly(ctx)
+	return inferTool(
+		"scaffold_project",
+		"Create a new Go module in an empty directory: go.mod, a main package or package layout, a passing test and a Makefile, from the 'cli', 'library' or 'service' template. Use it to start a new project instead of writing the files out in chat, then extend it with edit_go_file.",
+		func(ctx context.Context, req *ScaffoldProjectRequest) (*ScaffoldProjectResponse, error) {
+			if readOnly {
+				return nil, ErrReadOnly
+			}
+			if req.Path == "" {
+				return &ScaffoldProjectResponse{Error: "path cannot be empty"}, nil
+			}
+			dir, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &ScaffoldProjectResponse{Error: err.Error()}, nil
+			}
+
+			data, err := newScaffoldData(dir, req)
+			if err != nil {
+				return &ScaffoldProjectResponse{Error: err.Error()}, nil
+			}
+			files, err := scaffoldProject(dir, req.Template, data)
+			if err != nil {
+				return &ScaffoldProjectResponse{Error: err.Error()}, nil
+			}
+			for i, f := range files {
+				files[i] = path.Join(filepath.ToSlash(req.Path), f)
+			}
+			return &ScaffoldProjectResponse{
+				Message:   fmt.Sprintf("✅ Created module %s from the %s template in %s", data.Module, templateName(req.Template), req.Path),
+				Files:     files,
+				NextSteps: fmt.Sprintf("Run 'make test' (or 'go test ./...') in %s. Read the created files before changing them.", req.Path),
+			}, nil
+		},
+	)
+}
+
+func templateName(name string) string {
+	if name == "" {
+		return "cli"
+	}
+	return name
+}
+
+// modulePathPattern matches the module paths go mod init accepts, a stricter
+// subset than module path syntax allows.
+var modulePathPattern = regexp.MustCompile(`^[A-Za-z0-9_~-]+(\.[A-Za-z0-9_~-]+)*(/[A-Za-z0-9_~-]+(\.[A-Za-z0-9_~-]+)*)*$`)
+
+// minScaffoldGoMinor is the oldest Go 1.x release the templates build with;
+// the service template routes by method, which needs Go 1.22.
+const minScaffoldGoMinor = 22
+
+// goVersionPattern matches the versions go.mod accepts in its go directive.
+var goVersionPattern = regexp.MustCompile(`^1\.\d+(\.\d+)?$`)
+
+func newScaffoldData(dir string, req *ScaffoldProjectRequest) (*scaffoldData, error) {
+	data := &scaffoldData{Module: req.Module, GoVersion: req.GoVersion}
+	if data.Module == "" {
+		data.Module = filepath.Base(dir)
+	}
+	if !modulePathPattern.MatchString(data.Module) {
+		return nil, fmt.Errorf("invalid module path '%s'; use slash-separated elements of letters, digits, '-', '.' and '_'", data.Module)
+	}
+	data.Name = strings.ToLower(assumedName(data.Module))
+	if !isPackageName(data.Name) {
+		return nil, fmt.Errorf("cannot derive a package name from module path '%s'; choose a module path ending in a Go identifier", data.Module)
+	}
+	if data.GoVersion == "" {
+		data.GoVersion = currentGoVersion()
+	}
+	if !goVersionPattern.MatchString(data.GoVersion) {
+		return nil, fmt.Errorf("go_version '%s' is not a Go version such as 1.25", data.GoVersion)
+	}
+	if minor, _ := strconv.Atoi(strings.Split(data.GoVersion, ".")[1]); minor < minScaffoldGoMinor {
+		return nil, fmt.Errorf("go_version '%s' is too old; the templates need Go 1.%d or later", data.GoVersion, minScaffoldGoMinor)
+	}
+	return data, nil
+}
+
+func isPackageName(name string) bool {
+	return name != "" && name != "main" && name != "_" && !strings.ContainsAny(name, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") && isIdentifier(name)
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// currentGoVersion returns the major and minor version of the Go release the
+// agent was built with, such as "1.25".
+func currentGoVersion() string {
+	v := strings.TrimPrefix(runtime.Version(), "go")
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) >= 2 && goVersionPattern.MatchString(parts[0]+"."+parts[1]) {
+		return parts[0] + "." + parts[1]
+	}
+	return "1.25"
+}
+
+// scaffoldProject renders the template called name into dir and returns the
+// created files, relative to dir. Go files are checked with gofmt before any
+// file is written.
+func scaffoldProject(dir, name string, data *scaffoldData) ([]string, error) {
+	name = templateName(name)
+	root := path.Join("scaffold", name)
+	if _, err := fs.Stat(scaffoldTemplates, root); err != nil {
+		return nil, fmt.Errorf("unknown template '%s'. Use: %s", name, strings.Join(scaffoldTemplateNames(), ", "))
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("'%s' is not empty; scaffold into a new directory", dir)
+	}
+
+	rendered := make(map[string][]byte)
+	err := fs.WalkDir(scaffoldTemplates, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := render(strings.TrimSuffix(strings.TrimPrefix(p, root+"/"), ".tmpl"), data)
+		if err != nil {
+			return err
+		}
+		src, err := scaffoldTemplates.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		content, err := render(string(src), data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		out := []byte(content)
+		if strings.HasSuffix(rel, ".go") {
+			if out, err = format.Source(out); err != nil {
+				return fmt.Errorf("template %s does not render valid Go: %w", p, err)
+			}
+		}
+		rendered[rel] = out
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(rendered))
+	for rel := range rendered {
+		files = append(files, rel)
+	}
+	slices.Sort(files)
+	for _, rel := range files {
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+		}
+		if err := os.WriteFile(target, rendered[rel], 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+	}
+	return files, nil
+}
+
+func render(text string, data *scaffoldData) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func scaffoldTemplateNames() []string {
+	entries, _ := scaffoldTemplates.ReadDir("scaffold")
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
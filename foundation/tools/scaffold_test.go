@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScaffoldProject(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewScaffoldProjectTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"cli":     "Makefile,go.mod,main.go,main_test.go",
+		"library": "Makefile,go.mod,widgets.go,widgets_test.go",
+		"service": "Makefile,cmd/widgets/main.go,go.mod,internal/server/server.go,internal/server/server_test.go",
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := ScaffoldProjectRequest{Path: name, Module: "example.com/acme/go-widgets", Template: name, GoVersion: "1.22"}
+			resp := callTool[ScaffoldProjectRequest, ScaffoldProjectResponse](t, ctx, bt, req)
+			if resp.Error != "" {
+				t.Fatal(resp.Error)
+			}
+			var got []string
+			for _, f := range resp.Files {
+				got = append(got, strings.TrimPrefix(f, req.Path+"/"))
+			}
+			if strings.Join(got, ",") != want {
+				t.Errorf("files = %v, want %s", got, want)
+			}
+			mod, _ := os.ReadFile(filepath.Join(dir, req.Path, "go.mod"))
+			if string(mod) != "module example.com/acme/go-widgets\n\ngo 1.22\n" {
+				t.Errorf("go.mod = %q", mod)
+			}
+
+			if _, err := exec.LookPath("go"); err != nil {
+				t.Skip("go is not installed")
+			}
+			for _, args := range [][]string{{"vet", "./..."}, {"test", "./..."}} {
+				cmd := exec.Command("go", args...)
+				cmd.Dir = filepath.Join(dir, req.Path)
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Errorf("go %s in the %s project: %v\n%s", args[0], name, err, out)
+				}
+			}
+		})
+	}
+
+	for name, tc := range map[string]struct {
+		req  ScaffoldProjectRequest
+		want string
+	}{
+		"not empty":    {ScaffoldProjectRequest{Path: "cli"}, "not empty"},
+		"template":     {ScaffoldProjectRequest{Path: "new", Template: "plugin"}, "unknown template 'plugin'. Use: cli, library, service"},
+		"module path":  {ScaffoldProjectRequest{Path: "new", Module: "example.com/a b"}, "invalid module path"},
+		"package name": {ScaffoldProjectRequest{Path: "new", Module: "example.com/123"}, "cannot derive a package name"},
+		"go version":   {ScaffoldProjectRequest{Path: "new", GoVersion: "latest"}, "not a Go version"},
+		"old go":       {ScaffoldProjectRequest{Path: "new", GoVersion: "1.21"}, "too old"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := callTool[ScaffoldProjectRequest, ScaffoldProjectResponse](t, ctx, bt, tc.req)
+			if !strings.Contains(resp.Error, tc.want) {
+				t.Errorf("error = %q, want it to mention %q", resp.Error, tc.want)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new")); !os.IsNotExist(err) {
+		t.Errorf("a refused scaffold created its directory: %v", err)
+	}
+}
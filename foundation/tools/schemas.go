@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+)
+
+// The parameter schemas of the tools are generated ahead of time into
+// schemas_gen.go, so that startup does not reflect over every request struct
+// and schema changes show up in code review. Regenerate them after changing a
+// request struct with:
+//
+//go:generate go test -run ^TestToolSchemas$ -update
+
+// generatedSchema is the JSON schema of a request type, recorded with the
+// hash of the type it was generated from.
+type generatedSchema struct {
+	hash   string
+	schema string
+}
+
+// inferTool is utils.InferTool with the parameter schema taken from
+// schemas_gen.go when it was generated from the current request type. Stale
+// or missing schemas fall back to reflection.
+func inferTool[T, D any](toolName, toolDesc string, i utils.InvokeFunc[T, D]) (tool.InvokableTool, error) {
+	typ := reflect.TypeFor[T]()
+	if params := generatedParams(typ); params != nil {
+		return utils.NewTool(&schema.ToolInfo{Name: toolName, Desc: toolDesc, ParamsOneOf: params}, i), nil
+	}
+	return utils.InferTool(toolName, toolDesc, i)
+}
+
+func generatedParams(typ reflect.Type) *schema.ParamsOneOf {
+	gen, ok := generatedSchemas[schemaKey(typ)]
+	if !ok || gen.hash != typeHash(typ) {
+		return nil
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal([]byte(gen.schema), &s); err != nil {
+		return nil
+	}
+	return schema.NewParamsOneOfByJSONSchema(&s)
+}
+
+// schemaKey names a request type in generatedSchemas.
+func schemaKey(typ reflect.Type) string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Name()
+}
+
+// typeHash fingerprints everything about typ that its schema depends on: the
+// names, types and tags of its fields, recursively.
+func typeHash(typ reflect.Type) string {
+	h := sha256.New()
+	seen := make(map[reflect.Type]bool)
+	var describe func(reflect.Type)
+	describe = func(t reflect.Type) {
+		fmt.Fprintf(h, "%s;", t)
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array:
+			describe(t.Elem())
+		case reflect.Map:
+			describe(t.Key())
+			describe(t.Elem())
+		case reflect.Struct:
+			for i := range t.NumField() {
+				f := t.Field(i)
+				fmt.Fprintf(h, "{%s %q}", f.Name, f.Tag)
+				describe(f.Type)
+			}
+		}
+	}
+	describe(typ)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,878 @@
+// Code generated by go test -run ^TestToolSchemas$ -update; DO NOT EDIT.
+
+package tools
+
+var generatedSchemas = map[string]generatedSchema{
+	"AnalyzeReposRequest": {
+		hash: "cc8b9b4884e572d4",
+		schema: `{
+			"properties": {
+				"repos": {
+					"items": {
+						"type": "string"
+					},
+					"description": "The repositories to compare (2 to 5): Git URLs to clone or paths of repositories already cloned.",
+					"type": "array"
+				},
+				"question": {
+					"description": "What to find out about each repository, such as 'Which of these HTTP routers fits a service needing middleware and path parameters?'",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"repos",
+				"question"
+			],
+			"type": "object"
+		}`,
+	},
+	"CalculateRequest": {
+		hash: "56c73e7337b4dac8",
+		schema: `{
+			"properties": {
+				"operation": {
+					"description": "The operation to perform: add",
+					"type": "string"
+				},
+				"a": {
+					"description": "First number",
+					"type": "number"
+				},
+				"b": {
+					"description": "Second number",
+					"type": "number"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"operation",
+				"a",
+				"b"
+			],
+			"type": "object"
+		}`,
+	},
+	"ChangesetRequest": {
+		hash: "d824d51028b21ff9",
+		schema: `{
+			"properties": {
+				"edits": {
+					"items": {
+						"properties": {
+							"path": {
+								"description": "Path to the Go file to edit. For 'add_test_function': the file under test or its _test.go file.",
+								"type": "string"
+							},
+							"operation": {
+								"description": "Type of edit: 'add_import'",
+								"type": "string"
+							},
+							"import_path": {
+								"description": "For 'add_import'/'remove_import': the import path (e.g.",
+								"type": "string"
+							},
+							"import_alias": {
+								"description": "For 'add_import': optional alias for the import.",
+								"type": "string"
+							},
+							"var_name": {
+								"description": "For 'add_var'/'add_const': the variable/constant name.",
+								"type": "string"
+							},
+							"var_type": {
+								"description": "For 'add_var'/'add_const': the type (e.g.",
+								"type": "string"
+							},
+							"var_value": {
+								"description": "For 'add_var'/'add_const': the value expression (e.g.",
+								"type": "string"
+							},
+							"code": {
+								"description": "For 'add_function'",
+								"type": "string"
+							},
+							"start_line": {
+								"description": "For 'replace_code_block': the first line number of the block to replace (1-indexed).",
+								"type": "integer"
+							},
+							"end_line": {
+								"description": "For 'replace_code_block': the last line number of the block to replace (inclusive).",
+								"type": "integer"
+							}
+						},
+						"additionalProperties": false,
+						"required": [
+							"path",
+							"operation"
+						],
+						"type": "object"
+					},
+					"description": "Edits to apply in order",
+					"type": "array"
+				},
+				"build": {
+					"description": "After writing the files",
+					"type": "boolean"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"edits"
+			],
+			"type": "object"
+		}`,
+	},
+	"CommitMessageRequest": {
+		hash: "81c83b7a36acb6b1",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "A directory in the Git repository. Defaults to the workspace.",
+					"type": "string"
+				},
+				"scope": {
+					"enum": [
+						"staged",
+						"all"
+					],
+					"description": "Which changes to describe and commit: 'staged' changes only or 'all' uncommitted changes, untracked files included. Defaults to the staged changes, or all changes when none are staged.",
+					"type": "string"
+				},
+				"hint": {
+					"description": "What the changes are for, in the user's words, such as the issue they fix.",
+					"type": "string"
+				},
+				"message": {
+					"description": "Use this commit message instead of writing one, e.g. a suggestion the user edited.",
+					"type": "string"
+				},
+				"commit": {
+					"description": "Also commit the changes with the message, once the user approves it. Only when the user asked to commit.",
+					"type": "boolean"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"DuckDuckGoSearchRequest": {
+		hash: "bc12b703299036b9",
+		schema: `{
+			"properties": {
+				"query": {
+					"description": "The search query to find information on the internet",
+					"type": "string"
+				},
+				"page": {
+					"description": "Page of results to return",
+					"type": "integer"
+				},
+				"site": {
+					"description": "Only return results from this domain",
+					"type": "string"
+				},
+				"recency": {
+					"description": "Only return results from the last 'day'",
+					"type": "string"
+				},
+				"category": {
+					"description": "Kind of results: 'news' for news articles",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"query"
+			],
+			"type": "object"
+		}`,
+	},
+	"EditFileRequest": {
+		hash: "50914db17f45f0cd",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "Path to the Go file to edit. For 'add_test_function': the file under test or its _test.go file.",
+					"type": "string"
+				},
+				"operation": {
+					"description": "Type of edit: 'add_import'",
+					"type": "string"
+				},
+				"import_path": {
+					"description": "For 'add_import'/'remove_import': the import path (e.g.",
+					"type": "string"
+				},
+				"import_alias": {
+					"description": "For 'add_import': optional alias for the import.",
+					"type": "string"
+				},
+				"var_name": {
+					"description": "For 'add_var'/'add_const': the variable/constant name.",
+					"type": "string"
+				},
+				"var_type": {
+					"description": "For 'add_var'/'add_const': the type (e.g.",
+					"type": "string"
+				},
+				"var_value": {
+					"description": "For 'add_var'/'add_const': the value expression (e.g.",
+					"type": "string"
+				},
+				"code": {
+					"description": "For 'add_function'",
+					"type": "string"
+				},
+				"start_line": {
+					"description": "For 'replace_code_block': the first line number of the block to replace (1-indexed).",
+					"type": "integer"
+				},
+				"end_line": {
+					"description": "For 'replace_code_block': the last line number of the block to replace (inclusive).",
+					"type": "integer"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"path",
+				"operation"
+			],
+			"type": "object"
+		}`,
+	},
+	"EnvInfoRequest": {
+		hash: "37889426b0d5c020",
+		schema: `{
+			"properties": {
+				"binaries": {
+					"items": {
+						"type": "string"
+					},
+					"description": "Extra programs to look for on PATH",
+					"type": "array"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"FixDependenciesRequest": {
+		hash: "6c944a00e3e69f14",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "A directory or file inside the module to fix. Defaults to the workspace root.",
+					"type": "string"
+				},
+				"get": {
+					"items": {
+						"type": "string"
+					},
+					"description": "Modules to add or upgrade before tidying",
+					"type": "array"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"GitCloneRequest": {
+		hash: "67c73d84a445bf5e",
+		schema: `{
+			"properties": {
+				"url": {
+					"description": "The URL of the repository to clone (HTTPS or SSH format).",
+					"type": "string"
+				},
+				"action": {
+					"description": "The action to perform: 'clone' or 'pull'.",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"url",
+				"action"
+			],
+			"type": "object"
+		}`,
+	},
+	"GraphQueryRequest": {
+		hash: "041f5cc5761d7f4a",
+		schema: `{
+			"properties": {
+				"subject": {
+					"description": "Only relations whose subject contains this, ignoring case, such as a talk title.",
+					"type": "string"
+				},
+				"predicate": {
+					"description": "Only relations of this kind, such as speaker, track, time, talk, title or company.",
+					"type": "string"
+				},
+				"object": {
+					"description": "Only relations whose object contains this, ignoring case, such as a speaker's name.",
+					"type": "string"
+				},
+				"count_by": {
+					"enum": [
+						"subject",
+						"object"
+					],
+					"description": "Group the matching relations by their subject or object and count each group, e.g. count talks per speaker with predicate speaker and count_by object.",
+					"type": "string"
+				},
+				"min_count": {
+					"description": "With count_by, only groups with at least this many relations, e.g. 2 for speakers with more than one talk.",
+					"type": "integer"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"GroundedSearchRequest": {
+		hash: "26f4c8a5d4e0ba69",
+		schema: `{
+			"properties": {
+				"query": {
+					"description": "The question or search query to answer from the web.",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"query"
+			],
+			"type": "object"
+		}`,
+	},
+	"LicenseAuditRequest": {
+		hash: "7f83d96632053063",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "A directory inside the module to audit. Defaults to the workspace root.",
+					"type": "string"
+				},
+				"all": {
+					"description": "Audit every module in the module graph",
+					"type": "boolean"
+				},
+				"disallowed": {
+					"items": {
+						"type": "string"
+					},
+					"description": "SPDX IDs of licenses to flag besides the configured ones",
+					"type": "array"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"ListConflictsRequest": {
+		hash: "96024cd486efa2b0",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "A directory in the Git repository, or one conflicted file to show only its hunks. Defaults to the workspace.",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"PastConversationsRequest": {
+		hash: "72f14749c878bd67",
+		schema: `{
+			"properties": {
+				"query": {
+					"description": "What to look for in earlier conversations",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"query"
+			],
+			"type": "object"
+		}`,
+	},
+	"PinFactRequest": {
+		hash: "7b7d481d9c498a73",
+		schema: `{
+			"properties": {
+				"fact": {
+					"description": "The fact or constraint to keep in mind for the rest of the session",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"fact"
+			],
+			"type": "object"
+		}`,
+	},
+	"ProfileProgramRequest": {
+		hash: "ed21db28e0647339",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "Directory of the Go package to profile",
+					"type": "string"
+				},
+				"bench": {
+					"description": "Benchmarks to run and profile",
+					"type": "string"
+				},
+				"run": {
+					"description": "Tests to run and profile",
+					"type": "string"
+				},
+				"bench_time": {
+					"description": "How long each benchmark runs",
+					"type": "string"
+				},
+				"kind": {
+					"description": "What to profile: 'cpu' (default)",
+					"type": "string"
+				},
+				"top": {
+					"description": "How many hotspots to report",
+					"type": "integer"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"QueryFilesRequest": {
+		hash: "b54c821dce63658f",
+		schema: `{
+			"properties": {
+				"files": {
+					"items": {
+						"type": "string"
+					},
+					"description": "Paths of the data files to query (up to 10): CSV, TSV, JSON Lines (.jsonl or .ndjson) or a JSON array of objects (.json). Each becomes a table named after the file, e.g. data/sales_2024.csv becomes sales_2024.",
+					"type": "array"
+				},
+				"query": {
+					"description": "One SQLite SELECT (or WITH ... SELECT) statement over the tables, e.g. SELECT region, SUM(amount) AS total FROM sales_2024 GROUP BY region ORDER BY total DESC.",
+					"type": "string"
+				},
+				"limit": {
+					"description": "Maximum rows to return (default 50, max 500). Aggregate in SQL rather than raising it.",
+					"type": "integer"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"files",
+				"query"
+			],
+			"type": "object"
+		}`,
+	},
+	"RAGSearchRequest": {
+		hash: "b15e05671d957e03",
+		schema: `{
+			"properties": {
+				"query": {
+					"description": "The question to search in the GopherCon Africa 2025 knowledge base",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"query"
+			],
+			"type": "object"
+		}`,
+	},
+	"ReadFileRequest": {
+		hash: "170b1beb5f13f9b0",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "The relative path of the file to read (e.g. 'main.go' or 'pkg/handler/handler.go')",
+					"type": "string"
+				},
+				"start_line": {
+					"description": "Optional: line number to start reading from (1-indexed). Efficient for large files.",
+					"type": "integer"
+				},
+				"end_line": {
+					"description": "Optional: line number to stop reading at (inclusive). Efficient for large files.",
+					"type": "integer"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"path"
+			],
+			"type": "object"
+		}`,
+	},
+	"RenameSymbolRequest": {
+		hash: "f18bd6237ae9d1b1",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "Go file that declares or uses the symbol.",
+					"type": "string"
+				},
+				"name": {
+					"description": "Current name of the symbol: a function",
+					"type": "string"
+				},
+				"new_name": {
+					"description": "New name of the symbol.",
+					"type": "string"
+				},
+				"line": {
+					"description": "Optional: line in path where the symbol appears",
+					"type": "integer"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"path",
+				"name",
+				"new_name"
+			],
+			"type": "object"
+		}`,
+	},
+	"RepoOverviewRequest": {
+		hash: "536e32fc3dacf56e",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "Path of the repository",
+					"type": "string"
+				},
+				"include_files": {
+					"description": "Also list the repository's files (up to 1000).",
+					"type": "boolean"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"path"
+			],
+			"type": "object"
+		}`,
+	},
+	"ResolveConflictRequest": {
+		hash: "19e23460c33a488c",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "The conflicted file, as list_conflicts gives it.",
+					"type": "string"
+				},
+				"hunk": {
+					"description": "Number of the hunk to resolve, from list_conflicts. Hunks are numbered in the file as it is now, so resolving one renumbers those below it.",
+					"type": "integer"
+				},
+				"resolution": {
+					"enum": [
+						"ours",
+						"theirs",
+						"both",
+						"base",
+						"custom"
+					],
+					"description": "How to resolve it: keep 'ours', 'theirs', 'both' (ours then theirs) or 'base', or 'custom' to replace the hunk with code.",
+					"type": "string"
+				},
+				"code": {
+					"description": "For 'custom': the merged lines that replace the whole hunk, markers and all.",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"path",
+				"hunk",
+				"resolution"
+			],
+			"type": "object"
+		}`,
+	},
+	"ReviewChangesRequest": {
+		hash: "85aded419a0df872",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "A directory in the Git repository to review. Defaults to the workspace.",
+					"type": "string"
+				},
+				"scope": {
+					"enum": [
+						"all",
+						"staged",
+						"unstaged"
+					],
+					"description": "Which changes to review: 'all' uncommitted changes (the default), 'staged' changes only or 'unstaged' changes only.",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"RunGoRequest": {
+		hash: "c1bae23213e7b02e",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "Directory to run in",
+					"type": "string"
+				},
+				"command": {
+					"description": "The go command to run: 'build' (compile without writing binaries)",
+					"type": "string"
+				},
+				"packages": {
+					"items": {
+						"type": "string"
+					},
+					"description": "Package patterns relative to path",
+					"type": "array"
+				},
+				"run": {
+					"description": "For 'test'",
+					"type": "string"
+				},
+				"verbose": {
+					"description": "For 'test'",
+					"type": "boolean"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"command"
+			],
+			"type": "object"
+		}`,
+	},
+	"ScaffoldProjectRequest": {
+		hash: "6a3eaff6a5c1607a",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "Directory to create the project in. It must not exist or be empty.",
+					"type": "string"
+				},
+				"module": {
+					"description": "Module path",
+					"type": "string"
+				},
+				"template": {
+					"description": "Project layout: 'cli' (main package with flag parsing",
+					"type": "string"
+				},
+				"go_version": {
+					"description": "Go version for go.mod",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"path"
+			],
+			"type": "object"
+		}`,
+	},
+	"ScanVulnerabilitiesRequest": {
+		hash: "cba70ef78f623315",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "Directory to scan from",
+					"type": "string"
+				},
+				"packages": {
+					"items": {
+						"type": "string"
+					},
+					"description": "Package patterns relative to path",
+					"type": "array"
+				},
+				"test": {
+					"description": "Also scan the code of test files.",
+					"type": "boolean"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"SearchFilesRequest": {
+		hash: "5d2ef0bd9285e2d6",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "Directory path to search in (default: current directory '.').",
+					"type": "string"
+				},
+				"pattern": {
+					"description": "Glob pattern for files (e.g.",
+					"type": "string"
+				},
+				"filter": {
+					"description": "Regex pattern to filter file paths. Use this for complex matching not possible with glob patterns.",
+					"type": "string"
+				},
+				"contains": {
+					"description": "Regex pattern to search inside file contents. Returns line numbers and snippets.",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"type": "object"
+		}`,
+	},
+	"SendEmailRequest": {
+		hash: "0ab5b7ab7cde1090",
+		schema: `{
+			"properties": {
+				"to": {
+					"items": {
+						"type": "string"
+					},
+					"description": "Recipient addresses",
+					"type": "array"
+				},
+				"cc": {
+					"items": {
+						"type": "string"
+					},
+					"description": "Addresses to copy.",
+					"type": "array"
+				},
+				"subject": {
+					"description": "Subject line.",
+					"type": "string"
+				},
+				"body": {
+					"description": "Plain-text body of the email.",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"to",
+				"subject",
+				"body"
+			],
+			"type": "object"
+		}`,
+	},
+	"SummarizeModuleRequest": {
+		hash: "c870bbf07a190c8f",
+		schema: `{
+			"properties": {
+				"path": {
+					"description": "A directory of the module",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"path"
+			],
+			"type": "object"
+		}`,
+	},
+	"TavilySearchRequest": {
+		hash: "530f53544581ad46",
+		schema: `{
+			"properties": {
+				"query": {
+					"description": "The search query to find information on the internet.",
+					"type": "string"
+				},
+				"search_depth": {
+					"description": "The depth of the search. Can be 'basic' or 'advanced'. Defaults to 'basic'.",
+					"type": "string"
+				},
+				"max_results": {
+					"description": "The maximum number of results to return. Defaults to 5.",
+					"type": "integer"
+				},
+				"site": {
+					"description": "Only return results from this domain",
+					"type": "string"
+				},
+				"recency": {
+					"description": "Only return results from the last 'day'",
+					"type": "string"
+				},
+				"category": {
+					"description": "Kind of results: 'news' for news articles",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"query"
+			],
+			"type": "object"
+		}`,
+	},
+	"TestRegexRequest": {
+		hash: "ea6f2e6bc548cc32",
+		schema: `{
+			"properties": {
+				"pattern": {
+					"description": "Regular expression in Go (RE2) syntax",
+					"type": "string"
+				},
+				"inputs": {
+					"items": {
+						"type": "string"
+					},
+					"description": "Sample strings to run the pattern against (up to 50). Include strings it should reject.",
+					"type": "array"
+				},
+				"replace": {
+					"description": "Replacement template; when set",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"pattern",
+				"inputs"
+			],
+			"type": "object"
+		}`,
+	},
+	"TranslateRequest": {
+		hash: "848f3831f56e241f",
+		schema: `{
+			"properties": {
+				"text": {
+					"description": "The text to translate.",
+					"type": "string"
+				},
+				"target": {
+					"description": "Language to translate into",
+					"type": "string"
+				}
+			},
+			"additionalProperties": false,
+			"required": [
+				"text",
+				"target"
+			],
+			"type": "object"
+		}`,
+	},
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/schema"
+)
+
+// toolRequest is a request type whose schema is generated into schemas_gen.go.
+type toolRequest struct {
+	typ    reflect.Type
+	params func() (*schema.ParamsOneOf, error)
+}
+
+func requestOf[T any]() toolRequest {
+	return toolRequest{typ: reflect.TypeFor[T](), params: func() (*schema.ParamsOneOf, error) {
+		return utils.GoStruct2ParamsOneOf[T]()
+	}}
+}
+
+var toolRequests = []toolRequest{
+	requestOf[*AnalyzeReposRequest](),
+	requestOf[*CalculateRequest](),
+	requestOf[*ChangesetRequest](),
+	requestOf[*CommitMessageRequest](),
+	requestOf[*DuckDuckGoSearchRequest](),
+	requestOf[*EditFileRequest](),
+	requestOf[*EnvInfoRequest](),
+	requestOf[*FixDependenciesRequest](),
+	requestOf[*GitCloneRequest](),
+	requestOf[*GraphQueryRequest](),
+	requestOf[*GroundedSearchRequest](),
+	requestOf[*LicenseAuditRequest](),
+	requestOf[*ListConflictsRequest](),
+	requestOf[*PastConversationsRequest](),
+	requestOf[*PinFactRequest](),
+	requestOf[*ProfileProgramRequest](),
+	requestOf[*QueryFilesRequest](),
+	requestOf[*RAGSearchRequest](),
+	requestOf[*ReadFileRequest](),
+	requestOf[*RenameSymbolRequest](),
+	requestOf[*RepoOverviewRequest](),
+	requestOf[*ResolveConflictRequest](),
+	requestOf[*ReviewChangesRequest](),
+	requestOf[*RunGoRequest](),
+	requestOf[*ScaffoldProjectRequest](),
+	requestOf[*ScanVulnerabilitiesRequest](),
+	requestOf[*SearchFilesRequest](),
+	requestOf[*SendEmailRequest](),
+	requestOf[*SummarizeModuleRequest](),
+	requestOf[*TavilySearchRequest](),
+	requestOf[*TestRegexRequest](),
+	requestOf[*TranslateRequest](),
+}
+
+// TestToolSchemas checks that schemas_gen.go matches the request types, and
+// rewrites it with -update.
+func TestToolSchemas(t *testing.T) {
+	var src bytes.Buffer
+	src.WriteString("// Code generated by go test -run ^TestToolSchemas$ -update; DO NOT EDIT.\n\n")
+	src.WriteString("package tools\n\nvar generatedSchemas = map[string]generatedSchema{\n")
+	for _, req := range toolRequests {
+		params, err := req.params()
+		if err != nil {
+			t.Fatalf("%s: %v", req.typ, err)
+		}
+		js, err := params.ToJSONSchema()
+		if err != nil {
+			t.Fatalf("%s: %v", req.typ, err)
+		}
+		data, err := json.MarshalIndent(js, "\t\t", "\t")
+		if err != nil {
+			t.Fatalf("%s: %v", req.typ, err)
+		}
+		fmt.Fprintf(&src, "\t%q: {\n\t\thash: %q,\n\t\tschema: %s,\n\t},\n", schemaKey(req.typ), typeHash(req.typ), quote(string(data)))
+	}
+	src.WriteString("}\n")
+	want, err := format.Source(src.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		if err := os.WriteFile("schemas_gen.go", want, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	got, err := os.ReadFile("schemas_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("schemas_gen.go is stale; run go generate ./foundation/tools")
+	}
+}
+
+// quote returns s as a raw string literal, or an interpreted one when s holds
+// a backquote.
+func quote(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+func TestInferToolUsesGeneratedSchema(t *testing.T) {
+	type unknownRequest struct {
+		Query string `json:"query" jsonschema:"description=Query"`
+	}
+	noop := func(ctx context.Context, req *ReadFileRequest) (*ReadFileResponse, error) { return nil, nil }
+
+	generated, err := inferTool("read_file", "Read a file", noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inferred, err := utils.InferTool("read_file", "Read a file", noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := toolInfoJSON(t, generated), toolInfoJSON(t, inferred); got != want {
+		t.Errorf("generated tool info differs from the inferred one:\n got %s\nwant %s", got, want)
+	}
+
+	if generatedParams(reflect.TypeFor[*ReadFileRequest]()) == nil {
+		t.Error("no generated schema for *ReadFileRequest")
+	}
+	if generatedParams(reflect.TypeFor[*unknownRequest]()) != nil {
+		t.Error("generated schema for a type that has none")
+	}
+	if slices.ContainsFunc(toolRequests, func(r toolRequest) bool { return typeHash(r.typ) == typeHash(reflect.TypeFor[*unknownRequest]()) }) {
+		t.Error("distinct types share a hash")
+	}
+}
+
+func toolInfoJSON(t *testing.T, tl interface {
+	Info(context.Context) (*schema.ToolInfo, error)
+}) string {
+	t.Helper()
+	info, err := tl.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := info.ParamsOneOf.ToJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(struct {
+		Name, Desc string
+		Params     any
+	}{info.Name, info.Desc, js})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -2,21 +2,22 @@ package tools
 
 import (
 	"bufio"
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
-	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/olusolaa/goforai/foundation/config"
 )
 
 type SearchFilesRequest struct {
@@ -30,7 +31,9 @@ type FileMatch struct {
 	File       string   `json:"file" jsonschema:"description=Path to the file."`
 	Lines      []int    `json:"lines,omitempty" jsonschema:"description=Line numbers where matches were found (content search only)."`
 	Snippets   []string `json:"snippets,omitempty" jsonschema:"description=Code snippets of the matches with surrounding context and line numbers."`
+	Offsets    []int64  `json:"offsets,omitempty" jsonschema:"description=Byte offset of the start of each matched line (content search only)."`
 	TotalLines int      `json:"total_lines,omitempty" jsonschema:"description=Total lines in the file (content search only)."`
+	Truncated  bool     `json:"truncated,omitempty" jsonschema:"description=More lines match than are reported (content search only)."`
 }
 
 type SearchFilesResponse struct {
@@ -38,8 +41,12 @@ type SearchFilesResponse struct {
 	Error   string      `json:"error,omitempty" jsonschema:"description=Error message if search failed."`
 }
 
+// NewSearchFilesTool returns the search_files tool. When CODE_INDEX is set,
+// content searches inside a Git repository consult its trigram index and only
+// read the files that can match.
 func NewSearchFilesTool(ctx context.Context) (tool.BaseTool, error) {
-	return utils.InferTool(
+	useIndex := config.CodeIndex()
+	return inferTool(
 		"search_files",
 		"Recursively search for files by glob pattern, regex filter, and content. Returns full file paths for use with other tools. Content searches ('contains') are parallelized for speed and return exact line numbers and code snippets. Example: search_files(path='repos/myrepo', pattern='**/*.go', contains='func.*Error') finds all Go files containing functions with 'Error' in their signature.",
 		func(ctx context.Context, req *SearchFilesRequest) (*SearchFilesResponse, error) {
@@ -48,13 +55,19 @@ func NewSearchFilesTool(ctx context.Context) (tool.BaseTool, error) {
 			if dir == "" {
 				dir = "."
 			}
+			dir, err := resolvePath(ctx, dir)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &SearchFilesResponse{Error: err.Error()}, nil
+			}
 
 			if _, err := os.Stat(dir); os.IsNotExist(err) {
 				return &SearchFilesResponse{Error: fmt.Sprintf("directory '%s' does not exist", dir)}, nil
 			}
 
 			var filterRe, containsRe *regexp.Regexp
-			var err error
 			if req.Filter != "" {
 				filterRe, err = regexp.Compile(req.Filter)
 				if err != nil {
@@ -69,7 +82,10 @@ func NewSearchFilesTool(ctx context.Context) (tool.BaseTool, error) {
 			}
 
 			// 2. Gather all candidate file paths
-			candidateFiles, err := collectFiles(dir, req.Pattern)
+			candidateFiles, err := collectFiles(ctx, dir, req.Pattern)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
 			if err != nil {
 				return &SearchFilesResponse{Error: err.Error()}, nil
 			}
@@ -93,8 +109,14 @@ func NewSearchFilesTool(ctx context.Context) (tool.BaseTool, error) {
 					matches = append(matches, FileMatch{File: file})
 				}
 			} else {
+				if useIndex {
+					filteredFiles = filterWithIndex(ctx, dir, filteredFiles, containsRe)
+				}
 				// Concurrent content search
-				matches = searchContentsConcurrently(filteredFiles, containsRe)
+				matches = searchContentsConcurrently(ctx, filteredFiles, containsRe)
+				if err := canceled(ctx); err != nil {
+					return nil, err
+				}
 			}
 
 			return &SearchFilesResponse{Matches: matches}, nil
@@ -102,12 +124,16 @@ func NewSearchFilesTool(ctx context.Context) (tool.BaseTool, error) {
 	)
 }
 
-// collectFiles gathers all files, prioritizing glob pattern if available.
-func collectFiles(dir, pattern string) ([]string, error) {
+// skippedSearchDirs are the directories search_files never looks into.
+var skippedSearchDirs = map[string]struct{}{
+	"vendor": {}, ".git": {}, "node_modules": {}, ".venv": {}, ".idea": {}, ".vscode": {},
+}
+
+// collectFiles gathers all files, prioritizing glob pattern if available. It
+// stops early when ctx is done.
+func collectFiles(ctx context.Context, dir, pattern string) ([]string, error) {
 	var files []string
-	skipDirs := map[string]struct{}{
-		"vendor": {}, ".git": {}, "node_modules": {}, ".venv": {}, ".idea": {}, ".vscode": {},
-	}
+	skipDirs := skippedSearchDirs
 
 	if pattern != "" {
 		// Use fast doublestar globbing
@@ -118,6 +144,9 @@ func collectFiles(dir, pattern string) ([]string, error) {
 		}
 		// Post-filter the glob results for skipped directories and ensure they are files
 		for _, match := range globMatches {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			info, err := os.Stat(match)
 			if err != nil || info.IsDir() {
 				continue // Skip directories or files that disappeared
@@ -132,6 +161,9 @@ func collectFiles(dir, pattern string) ([]string, error) {
 			if err != nil {
 				return err
 			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			if d.IsDir() {
 				if _, shouldSkip := skipDirs[d.Name()]; shouldSkip {
 					return filepath.SkipDir
@@ -159,7 +191,8 @@ func isPathInSkippedDir(path string, skipDirs map[string]struct{}) bool {
 }
 
 // searchContentsConcurrently uses a worker pool to search files in parallel.
-func searchContentsConcurrently(files []string, containsRe *regexp.Regexp) []FileMatch {
+// Once ctx is done the workers skip the remaining files.
+func searchContentsConcurrently(ctx context.Context, files []string, containsRe *regexp.Regexp) []FileMatch {
 	numWorkers := runtime.NumCPU()
 	jobs := make(chan string, len(files))
 	results := make(chan FileMatch, len(files))
@@ -170,6 +203,9 @@ func searchContentsConcurrently(files []string, containsRe *regexp.Regexp) []Fil
 		go func() {
 			defer wg.Done()
 			for filePath := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
 				if match := searchFileContent(filePath, containsRe); match != nil {
 					results <- *match
 				}
@@ -189,64 +225,120 @@ func searchContentsConcurrently(files []string, containsRe *regexp.Regexp) []Fil
 	for match := range results {
 		matches = append(matches, match)
 	}
+	// Workers finish in any order; report matches in a stable, path order.
+	sort.Slice(matches, func(i, j int) bool { return matches[i].File < matches[j].File })
 	return matches
 }
 
-// searchFileContent searches a single file for a regex pattern.
+// Limits of content searches, which keep memory flat however large the
+// searched files are.
+const (
+	maxSearchFileBytes  = 32 << 20 // Larger files are skipped.
+	maxSearchLineBytes  = 1 << 20  // A longer line ends the scan of its file.
+	maxMatchesPerFile   = 50
+	snippetContextLines = 2
+)
+
+// scanBuffers recycles the line buffers of content searches across files.
+var scanBuffers = sync.Pool{New: func() any {
+	buf := make([]byte, 64<<10)
+	return &buf
+}}
+
+// snippet is a match with the lines around it.
+type snippet struct {
+	lines []string
+	after int // Lines after the match still to add.
+}
+
+func (s *snippet) add(prefix string, n int, line []byte) {
+	s.lines = append(s.lines, fmt.Sprintf("%s%4d| %s", prefix, n, line))
+}
+
+// searchFileContent streams a single file, line by line, looking for re.
+// Binary files and files over maxSearchFileBytes are skipped, and only the
+// first maxMatchesPerFile matches are reported.
 func searchFileContent(filePath string, re *regexp.Regexp) *FileMatch {
-	content, err := os.ReadFile(filePath)
+	f, err := os.Open(filePath)
 	if err != nil {
 		return nil // Can't read file
 	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || info.Size() > maxSearchFileBytes {
+		return nil
+	}
 
 	// Skip binary files
-	if len(content) > 0 {
-		contentType := http.DetectContentType(content)
-		if !strings.HasPrefix(contentType, "text/") {
-			return nil
-		}
+	var head [512]byte
+	if n, _ := io.ReadFull(f, head[:]); n > 0 && !isText(head[:n]) {
+		return nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil
 	}
 
-	scanner := bufio.NewScanner(bytes.NewReader(content))
-	var matchedLines []int
-	var snippets []string
-	var lines []string
+	var offset, lineOffset int64
+	buf := scanBuffers.Get().(*[]byte)
+	defer scanBuffers.Put(buf)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(*buf, maxSearchLineBytes)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		lineOffset = offset
+		offset += int64(advance)
+		return advance, token, err
+	})
 
-	// First pass: read all lines into memory. This is necessary for context snippets.
+	match := FileMatch{File: filePath}
+	before := make([][]byte, 0, snippetContextLines) // The lines preceding the current one, for context.
+	var open []*snippet                              // Snippets still collecting the lines after their match.
+	var snippets []*snippet
 	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-
-	for i, line := range lines {
-		if re.MatchString(line) {
-			lineNum := i + 1
-			matchedLines = append(matchedLines, lineNum)
+		match.TotalLines++
+		n, line := match.TotalLines, scanner.Bytes()
 
-			start := max(0, i-2)
-			end := min(len(lines), i+3)
+		for _, s := range open {
+			s.add("  ", n, line)
+			s.after--
+		}
+		for len(open) > 0 && open[0].after == 0 {
+			snippets, open = append(snippets, open[0]), open[1:]
+		}
 
-			var snippetLines []string
-			for j := start; j < end; j++ {
-				prefix := "  "
-				if j == i {
-					prefix = "→ " // Mark the matched line
-				}
-				snippetLines = append(snippetLines, fmt.Sprintf("%s%4d| %s", prefix, j+1, lines[j]))
+		switch {
+		case !re.Match(line):
+		case len(match.Lines) == maxMatchesPerFile:
+			match.Truncated = true
+		default:
+			match.Lines = append(match.Lines, n)
+			match.Offsets = append(match.Offsets, lineOffset)
+			s := &snippet{after: snippetContextLines}
+			for i, b := range before {
+				s.add("  ", n-len(before)+i, b)
 			}
-			snippets = append(snippets, strings.Join(snippetLines, "\n"))
+			s.add("→ ", n, line) // Mark the matched line
+			open = append(open, s)
+		}
+
+		// Keep a copy of the line, reusing the buffer of the oldest one.
+		if len(before) < snippetContextLines {
+			before = append(before, nil)
+		} else {
+			oldest := before[0]
+			copy(before, before[1:])
+			before[len(before)-1] = oldest
 		}
+		before[len(before)-1] = append(before[len(before)-1][:0], line...)
 	}
+	snippets = append(snippets, open...)
 
-	if len(matchedLines) == 0 {
+	if len(match.Lines) == 0 {
 		return nil
 	}
-
-	return &FileMatch{
-		File:       filePath,
-		Lines:      matchedLines,
-		Snippets:   snippets,
-		TotalLines: len(lines),
+	for _, s := range snippets {
+		match.Snippets = append(match.Snippets, strings.Join(s.lines, "\n"))
 	}
+	return &match
 }
 
 // Standard library `min` and `max` for Go < 1.21
@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Run with memory profiles:
+//
+//	go test ./foundation/tools -run '^$' -bench SearchFiles -benchmem -memprofile mem.pprof
+//
+// The synthetic repositories are generated once per size and reused. The
+// 10k-file repository is skipped with -short.
+
+var benchRepoSizes = []int{1_000, 10_000}
+
+var (
+	benchReposMu sync.Mutex
+	benchRepos   = map[int]string{}
+)
+
+// benchRepo returns a directory holding the given number of ~300-line Go files
+// in nested packages, a twentieth of them under vendor/ which the tool skips.
+func benchRepo(b *testing.B, files int) string {
+	b.Helper()
+	benchReposMu.Lock()
+	defer benchReposMu.Unlock()
+	if dir, ok := benchRepos[files]; ok {
+		return dir
+	}
+
+	dir, err := os.MkdirTemp("", "goforai-bench-repo")
+	if err != nil {
+		b.Fatal(err)
+	}
+	var body strings.Builder
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&body, "\n// step%d does one unit of work.\nfunc step%d(ctx context.Context, n int) (int, error) {\n\tif n < 0 {\n\t\treturn 0, errNegative\n\t}\n\treturn n + %d, nil\n}\n", i, i, i)
+	}
+	for i := 0; i < files; i++ {
+		pkg := fmt.Sprintf("pkg%d", i%100)
+		sub := filepath.Join(dir, "internal", fmt.Sprintf("area%d", i%10), pkg)
+		if i%20 == 0 {
+			sub = filepath.Join(dir, "vendor", "example.com", pkg)
+		}
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		var src strings.Builder
+		fmt.Fprintf(&src, "package %s\n\nimport \"context\"\n", pkg)
+		if i%50 == 0 {
+			fmt.Fprintf(&src, "\nfunc HandleRequest%d(ctx context.Context) error { return nil }\n", i)
+		}
+		src.WriteString(body.String())
+		if err := os.WriteFile(filepath.Join(sub, fmt.Sprintf("file%d.go", i)), []byte(src.String()), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	benchRepos[files] = dir
+	return dir
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	for _, dir := range benchRepos {
+		os.RemoveAll(dir)
+	}
+	os.Exit(code)
+}
+
+func BenchmarkSearchFiles(b *testing.B) {
+	bt, err := NewSearchFilesTool(context.Background())
+	if err != nil {
+		b.Fatal(err)
+	}
+	search := bt.(tool.InvokableTool)
+
+	queries := []struct {
+		name string
+		req  string
+	}{
+		{"glob", `{"pattern": "**/*.go"}`},
+		{"walk+filter", `{"filter": "area3/.*\\.go$"}`},
+		{"glob+contains", `{"pattern": "**/*.go", "contains": "func HandleRequest\\d+"}`},
+		{"contains-common", `{"contains": "errNegative"}`},
+	}
+	for _, files := range benchRepoSizes {
+		for _, q := range queries {
+			b.Run(fmt.Sprintf("files=%d/%s", files, q.name), func(b *testing.B) {
+				if testing.Short() && files > 1_000 {
+					b.Skipf("skipping %d files in -short mode", files)
+				}
+				ctx := WithWorkspace(context.Background(), benchRepo(b, files))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					out, err := search.InvokableRun(ctx, q.req)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if strings.Contains(out, `"error"`) {
+						b.Fatalf("search failed: %s", out)
+					}
+				}
+			})
+		}
+	}
+}
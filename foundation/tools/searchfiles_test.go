@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSearchFileContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.txt")
+	content := "first\r\nmatch one\r\n" + strings.Repeat("match again\n", maxMatchesPerFile+10) + "last"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := searchFileContent(path, regexp.MustCompile(`^match`))
+	if m == nil {
+		t.Fatal("no match")
+	}
+	if len(m.Lines) != maxMatchesPerFile || len(m.Snippets) != maxMatchesPerFile || !m.Truncated {
+		t.Errorf("got %d lines, %d snippets, truncated %v; want %d and truncated", len(m.Lines), len(m.Snippets), m.Truncated, maxMatchesPerFile)
+	}
+	if m.TotalLines != maxMatchesPerFile+13 {
+		t.Errorf("TotalLines = %d", m.TotalLines)
+	}
+	if m.Lines[0] != 2 || m.Offsets[0] != 7 || m.Offsets[1] != 18 || !strings.HasPrefix(content[m.Offsets[2]:], "match again") {
+		t.Errorf("lines %v, offsets %v", m.Lines[:3], m.Offsets[:3])
+	}
+	if want := "     1| first\n→    2| match one\n     3| match again\n     4| match again"; m.Snippets[0] != want {
+		t.Errorf("snippet\n got %q\nwant %q", m.Snippets[0], want)
+	}
+
+	huge := filepath.Join(dir, "huge.txt")
+	if err := os.WriteFile(huge, []byte("match\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(huge, maxSearchFileBytes+1); err != nil {
+		t.Fatal(err)
+	}
+	if m := searchFileContent(huge, regexp.MustCompile(`match`)); m != nil {
+		t.Errorf("searched a file over the size limit: %+v", m.Lines)
+	}
+}
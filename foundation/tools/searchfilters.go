@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// codeSites are the domains a "code" search is restricted to when no site is
+// given.
+var codeSites = []string{"github.com", "gitlab.com", "stackoverflow.com", "pkg.go.dev"}
+
+// searchFilters narrow a search_internet query. Each provider maps them onto
+// its own parameters.
+type searchFilters struct {
+	site     string // Bare domain, e.g. "github.com".
+	recency  string // "", "day", "week" or "month".
+	category string // "", "news" or "code".
+}
+
+// newSearchFilters validates the filters of a search request. site may be a
+// domain or a URL; only its host is kept.
+func newSearchFilters(site, recency, category string) (searchFilters, error) {
+	f := searchFilters{
+		recency:  strings.ToLower(strings.TrimSpace(recency)),
+		category: strings.ToLower(strings.TrimSpace(category)),
+	}
+	switch f.recency {
+	case "", "day", "week", "month":
+	default:
+		return searchFilters{}, fmt.Errorf("invalid recency %q: use day, week or month", recency)
+	}
+	switch f.category {
+	case "", "news", "code":
+	default:
+		return searchFilters{}, fmt.Errorf("invalid category %q: use news or code", category)
+	}
+
+	site = strings.TrimSpace(site)
+	if site == "" {
+		return f, nil
+	}
+	if !strings.Contains(site, "://") {
+		site = "https://" + site
+	}
+	u, err := url.Parse(site)
+	if err != nil || u.Hostname() == "" || strings.ContainsAny(u.Hostname(), " :") {
+		return searchFilters{}, fmt.Errorf("invalid site %q: use a domain such as github.com", site)
+	}
+	f.site = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return f, nil
+}
+
+// domains returns the domains results must come from, if any.
+func (f searchFilters) domains() []string {
+	switch {
+	case f.site != "":
+		return []string{f.site}
+	case f.category == "code":
+		return codeSites
+	}
+	return nil
+}
+
+// duckDuckGoQuery adds the domain restriction to query using site: operators.
+func (f searchFilters) duckDuckGoQuery(query string) string {
+	domains := f.domains()
+	if len(domains) == 0 {
+		return query
+	}
+	sites := make([]string, len(domains))
+	for i, d := range domains {
+		sites[i] = "site:" + d
+	}
+	if len(sites) == 1 {
+		return query + " " + sites[0]
+	}
+	return query + " (" + strings.Join(sites, " OR ") + ")"
+}
+
+// duckDuckGoRecency returns the df parameter of the DuckDuckGo HTML endpoint.
+// DuckDuckGo has no news vertical there, so news searches are limited to the
+// last week unless a recency is given.
+func (f searchFilters) duckDuckGoRecency() string {
+	recency := f.recency
+	if recency == "" && f.category == "news" {
+		recency = "week"
+	}
+	switch recency {
+	case "day":
+		return "d"
+	case "week":
+		return "w"
+	case "month":
+		return "m"
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/fakeapi"
+)
+
+func TestSearchFilters(t *testing.T) {
+	f, err := newSearchFilters("https://www.GitHub.com/cloudwego/eino", "Week", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.site != "github.com" || f.recency != "week" {
+		t.Errorf("filters = %+v", f)
+	}
+	if got := f.duckDuckGoQuery("eino issues"); got != "eino issues site:github.com" {
+		t.Errorf("duckDuckGoQuery = %q", got)
+	}
+
+	code, _ := newSearchFilters("", "", "code")
+	if got := code.duckDuckGoQuery("errgroup"); got != "errgroup (site:github.com OR site:gitlab.com OR site:stackoverflow.com OR site:pkg.go.dev)" {
+		t.Errorf("code duckDuckGoQuery = %q", got)
+	}
+	news, _ := newSearchFilters("", "", "news")
+	if got := news.duckDuckGoRecency(); got != "w" {
+		t.Errorf("news recency = %q, want w", got)
+	}
+
+	for _, bad := range [][3]string{{"", "year", ""}, {"", "", "images"}, {"not a domain", "", ""}} {
+		if _, err := newSearchFilters(bad[0], bad[1], bad[2]); err == nil {
+			t.Errorf("newSearchFilters(%q) accepted invalid filters", bad)
+		}
+	}
+}
+
+func TestDuckDuckGoSearchFilters(t *testing.T) {
+	d, requests := fakeDuckDuckGo(t)
+	ddgSearch(t, d, `{"query":"eino","site":"github.com","recency":"day"}`)
+	if last := (*requests)[len(*requests)-1]; last != "GET df=d&q=eino+site%3Agithub.com" {
+		t.Errorf("filtered search was requested with %q", last)
+	}
+}
+
+func TestTavilySearchFilters(t *testing.T) {
+	tavily := fakeapi.NewTavily(t)
+	tavily.Use(t)
+	tavily.Reply(fakeapi.TavilyReply{}, fakeapi.TavilyReply{})
+	bt, err := NewTavilySearchTool(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(args string) string {
+		out, err := bt.(tool.InvokableTool).InvokableRun(context.Background(), args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	run(`{"query":"eino","category":"news","recency":"month"}`)
+	run(`{"query":"eino","category":"code"}`)
+	reqs := tavily.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("%d searches, want 2", len(reqs))
+	}
+	if reqs[0].Topic != "news" || reqs[0].TimeRange != "month" || reqs[0].IncludeDomains != nil {
+		t.Errorf("news search = %+v", reqs[0])
+	}
+	if reqs[1].Topic != "" || !slices.Equal(reqs[1].IncludeDomains, codeSites) {
+		t.Errorf("code search = %+v", reqs[1])
+	}
+
+	if out := run(`{"query":"eino","recency":"decade"}`); !strings.Contains(out, "invalid recency") {
+		t.Errorf("invalid recency = %s", out)
+	}
+}
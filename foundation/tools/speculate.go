@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/forward"
+)
+
+// speculation predicts the calls the model is likely to make after a tool's
+// result, such as reading the files a search found.
+type speculation struct {
+	// Tool is the tool the predicted calls go to. It must only read, so that
+	// running a call the model never makes does no harm.
+	Tool string
+	// Predict returns the JSON arguments of the likely calls, most likely
+	// first.
+	Predict func(result string) []string
+}
+
+// speculations holds the predictions of the built-in tools, by the tool whose
+// result they are made from.
+var speculations = map[string]speculation{
+	"search_files": {Tool: "read_file", Predict: searchedFiles},
+}
+
+// searchedFiles predicts that the files a search found are read next, in the
+// order they were found.
+func searchedFiles(result string) []string {
+	var resp SearchFilesResponse
+	if json.Unmarshal([]byte(result), &resp) != nil || resp.Error != "" {
+		return nil
+	}
+	var calls []string
+	for _, m := range resp.Matches {
+		if data, err := json.Marshal(ReadFileRequest{Path: m.File}); err == nil {
+			calls = append(calls, string(data))
+		}
+	}
+	return calls
+}
+
+// Speculator prefetches the results of cheap, read-only tool calls the model
+// is likely to make next. When a search returns, the model still has to read
+// the result and write its next call; the calls the result predicts run
+// meanwhile, so by the time the model asks for them their results are ready
+// or on their way. A prefetch is only used for a call with the same
+// arguments in the same turn (see WithSpeculation), and every prefetch of
+// the turn is dropped when a tool that is not known to only read runs, so a
+// result is never older than the last write.
+//
+// The Speculator keeps count of how often the model goes on to make each
+// predicted call, by the tool that led to it and its rank among the
+// predictions, and stops prefetching those it rarely makes.
+type Speculator struct {
+	cfg config.Speculation
+
+	mu    sync.Mutex
+	tools map[string]tool.InvokableTool // Wrapped tools by name, unwrapped.
+	stats map[predictionKey]*hitCount
+}
+
+type predictionKey struct {
+	trigger string // The tool whose result the prediction was made from.
+	rank    int
+}
+
+type hitCount struct {
+	tries, hits int
+}
+
+// NewSpeculator returns a Speculator configured by cfg. When cfg is not
+// Enabled, Wrap returns tools unchanged.
+func NewSpeculator(cfg config.Speculation) *Speculator {
+	return &Speculator{cfg: cfg, tools: make(map[string]tool.InvokableTool), stats: make(map[predictionKey]*hitCount)}
+}
+
+// Wrap returns t, named name, with its calls served from prefetches and its
+// results used to prefetch the calls they predict. readOnly tells whether t
+// only reads; calls of any other tool drop the prefetches of the turn, and
+// only read-only tools are prefetched. Every tool of the agent should be
+// wrapped, so that writes drop stale prefetches. Tools that are not
+// invokable are returned unchanged.
+func (s *Speculator) Wrap(t tool.BaseTool, name string, readOnly bool) tool.BaseTool {
+	inner, ok := t.(tool.InvokableTool)
+	if !ok || !s.cfg.Enabled {
+		return t
+	}
+	if readOnly {
+		s.mu.Lock()
+		s.tools[name] = inner
+		s.mu.Unlock()
+	}
+	return &speculativeTool{Tool: forward.Tool{InvokableTool: inner}, name: name, readOnly: readOnly, speculator: s}
+}
+
+// HitRate returns how many of the prefetches made after a result of trigger
+// the model used, and how many were made.
+func (s *Speculator) HitRate(trigger string) (hits, tries int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, c := range s.stats {
+		if key.trigger == trigger {
+			hits, tries = hits+c.hits, tries+c.tries
+		}
+	}
+	return hits, tries
+}
+
+// likely reports whether the prediction of rank after trigger is used often
+// enough to keep making. Until it has been tried a few times it is.
+func (s *Speculator) likely(key predictionKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.stats[key]
+	if c == nil {
+		return true
+	}
+	// One hit and one miss assumed up front keep a few early misses from
+	// ending a prediction for good.
+	return float64(c.hits+1)/float64(c.tries+2) >= s.cfg.MinHitRate
+}
+
+// count records a prefetch of the prediction key, or with hit, that the
+// model used one.
+func (s *Speculator) count(key predictionKey, hit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.stats[key]
+	if c == nil {
+		c = &hitCount{}
+		s.stats[key] = c
+	}
+	if hit {
+		c.hits++
+	} else {
+		c.tries++
+	}
+}
+
+// speculate starts the calls result predicts.
+func (s *Speculator) speculate(ctx context.Context, turn *prefetches, trigger, result string) {
+	spec, ok := speculations[trigger]
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	target := s.tools[spec.Tool]
+	s.mu.Unlock()
+	if target == nil {
+		return
+	}
+	for rank, args := range spec.Predict(result) {
+		if rank >= s.cfg.MaxCalls {
+			break
+		}
+		key := predictionKey{trigger: trigger, rank: rank}
+		if !s.likely(key) {
+			continue
+		}
+		p := &prefetch{done: make(chan struct{}), prediction: key}
+		if !turn.add(callKey(spec.Tool, args), p) {
+			continue
+		}
+		s.count(key, false)
+		go func() {
+			defer close(p.done)
+			p.result, p.err = target.InvokableRun(ctx, args)
+		}()
+	}
+}
+
+type prefetchesKey struct{}
+
+// prefetches holds the prefetched calls of one turn by callKey.
+type prefetches struct {
+	mu    sync.Mutex
+	calls map[string]*prefetch
+}
+
+type prefetch struct {
+	done       chan struct{} // Closed when result and err are set.
+	result     string
+	err        error
+	prediction predictionKey
+}
+
+// WithSpeculation starts the prefetches of one turn. Without it tools wrapped
+// by a Speculator run every call as it comes.
+func WithSpeculation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, prefetchesKey{}, &prefetches{calls: make(map[string]*prefetch)})
+}
+
+// add records p unless the call is already prefetched.
+func (t *prefetches) add(key string, p *prefetch) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.calls[key]; ok {
+		return false
+	}
+	t.calls[key] = p
+	return true
+}
+
+// take removes and returns the prefetch of a call, or nil.
+func (t *prefetches) take(key string) *prefetch {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := t.calls[key]
+	delete(t.calls, key)
+	return p
+}
+
+// clear drops every prefetch; those still running finish unseen.
+func (t *prefetches) clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	clear(t.calls)
+}
+
+// callKey identifies a call by its tool and arguments, whatever the order of
+// their keys or their spacing.
+func callKey(name, argumentsInJSON string) string {
+	var v any
+	if json.Unmarshal([]byte(argumentsInJSON), &v) == nil {
+		if data, err := json.Marshal(v); err == nil {
+			argumentsInJSON = string(data)
+		}
+	}
+	return name + " " + argumentsInJSON
+}
+
+type speculativeTool struct {
+	forward.Tool
+	name       string
+	readOnly   bool
+	speculator *Speculator
+}
+
+func (t *speculativeTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	turn, _ := ctx.Value(prefetchesKey{}).(*prefetches)
+	if turn == nil {
+		return t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	}
+
+	if p := turn.take(callKey(t.name, argumentsInJSON)); p != nil {
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return "", canceled(ctx)
+		}
+		// A prefetch that failed is run again, as the model asked for it.
+		if p.err == nil {
+			t.speculator.count(p.prediction, true)
+			t.speculator.speculate(ctx, turn, t.name, p.result)
+			return p.result, nil
+		}
+	}
+
+	if !t.readOnly {
+		turn.clear()
+	}
+	out, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	if !t.readOnly {
+		turn.clear()
+	}
+	if err == nil {
+		t.speculator.speculate(ctx, turn, t.name, out)
+	}
+	return out, err
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// recordingTool answers every call with reply and records the arguments.
+type recordingTool struct {
+	name  string
+	reply string
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (t *recordingTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: t.name}, nil
+}
+
+func (t *recordingTool) InvokableRun(_ context.Context, args string, _ ...tool.Option) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls = append(t.calls, args)
+	return t.reply, nil
+}
+
+func (t *recordingTool) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.calls)
+}
+
+func TestSpeculatorPrefetchesPredictedCalls(t *testing.T) {
+	found, _ := json.Marshal(SearchFilesResponse{Matches: []FileMatch{{File: "a.go"}, {File: "b.go"}, {File: "c.go"}}})
+	search := &recordingTool{name: "search_files", reply: string(found)}
+	read := &recordingTool{name: "read_file", reply: `{"content":"package a"}`}
+	edit := &recordingTool{name: "edit_go_file", reply: `{}`}
+	lint := &recordingTool{name: "lint_fix", reply: `{}`} // A custom tool not declared read-only.
+
+	s := NewSpeculator(config.Speculation{Enabled: true, MaxCalls: 2, MinHitRate: 0.25})
+	wrapped := map[string]tool.InvokableTool{}
+	for _, rt := range []*recordingTool{search, read, edit, lint} {
+		readOnly := rt == search || rt == read
+		wrapped[rt.name] = s.Wrap(rt, rt.name, readOnly).(tool.InvokableTool)
+	}
+	run := func(ctx context.Context, name, args string) string {
+		t.Helper()
+		out, err := wrapped[name].InvokableRun(ctx, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	// Without a turn nothing is prefetched.
+	run(context.Background(), "search_files", `{"pattern":"*.go"}`)
+	if n := read.count(); n != 0 {
+		t.Fatalf("read_file ran %d times outside a turn", n)
+	}
+
+	ctx := WithSpeculation(context.Background())
+	run(ctx, "search_files", `{"pattern":"*.go"}`)
+	// The model's call, with its keys spaced differently, gets the prefetch.
+	if out := run(ctx, "read_file", `{ "path": "a.go" }`); out != read.reply {
+		t.Errorf("read_file = %q", out)
+	}
+	if out := run(ctx, "read_file", `{"path":"c.go"}`); out != read.reply {
+		t.Errorf("read_file = %q", out)
+	}
+	// a.go and b.go were prefetched, and c.go, beyond MaxCalls, was read
+	// when asked for.
+	turn := ctx.Value(prefetchesKey{}).(*prefetches)
+	turn.mu.Lock()
+	pending := turn.calls[callKey("read_file", `{"path":"b.go"}`)]
+	turn.mu.Unlock()
+	if pending == nil {
+		t.Fatal("b.go was not prefetched")
+	}
+	<-pending.done
+	read.mu.Lock()
+	calls := append([]string(nil), read.calls...)
+	read.mu.Unlock()
+	if len(calls) != 3 {
+		t.Fatalf("read_file ran %d times, want 3: %v", len(calls), calls)
+	}
+	if hits, tries := s.HitRate("search_files"); hits != 1 || tries != 2 {
+		t.Errorf("hit rate = %d/%d, want 1/2", hits, tries)
+	}
+
+	// A write drops the prefetches made before it.
+	run(ctx, "edit_go_file", `{"path":"b.go"}`)
+	before := read.count()
+	run(ctx, "read_file", `{"path":"b.go"}`)
+	if read.count() != before+1 {
+		t.Error("a prefetch made before a write was used after it")
+	}
+
+	// So does a tool that is not known to only read.
+	run(ctx, "search_files", `{"pattern":"*.go"}`)
+	waitPrefetch(t, ctx, `{"path":"a.go"}`)
+	run(ctx, "lint_fix", `{}`)
+	before = read.count()
+	run(ctx, "read_file", `{"path":"a.go"}`)
+	if read.count() != before+1 {
+		t.Error("a prefetch made before a call of lint_fix was used after it")
+	}
+}
+
+// waitPrefetch waits for the prefetch of the read_file call with args.
+func waitPrefetch(t *testing.T, ctx context.Context, args string) {
+	t.Helper()
+	turn := ctx.Value(prefetchesKey{}).(*prefetches)
+	turn.mu.Lock()
+	p := turn.calls[callKey("read_file", args)]
+	turn.mu.Unlock()
+	if p == nil {
+		t.Fatalf("%s was not prefetched", args)
+	}
+	<-p.done
+}
+
+func TestSpeculatorStopsUnusedPredictions(t *testing.T) {
+	found, _ := json.Marshal(SearchFilesResponse{Matches: []FileMatch{{File: "a.go"}}})
+	search := &recordingTool{name: "search_files", reply: string(found)}
+	read := &recordingTool{name: "read_file", reply: `{}`}
+	s := NewSpeculator(config.Speculation{Enabled: true, MaxCalls: 2, MinHitRate: 0.25})
+	s.Wrap(read, read.name, true)
+	wrappedSearch := s.Wrap(search, search.name, true).(tool.InvokableTool)
+
+	for range 10 {
+		ctx := WithSpeculation(context.Background())
+		if _, err := wrappedSearch.InvokableRun(ctx, `{}`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// (0+1)/(n+2) falls below 0.25 after three unused prefetches.
+	if _, tries := s.HitRate("search_files"); tries != 3 {
+		t.Errorf("%d prefetches made, want 3", tries)
+	}
+
+	if disabled := NewSpeculator(config.Speculation{}); disabled.Wrap(read, read.name, true) != tool.BaseTool(read) {
+		t.Error("a disabled speculator wrapped a tool")
+	}
+}
@@ -0,0 +1,327 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// Limits that keep a module summary small enough to hand to the model whole.
+const (
+	maxSummaryPackages = 100
+	maxSummarySymbols  = 25
+)
+
+type SummarizeModuleRequest struct {
+	Path string `json:"path" jsonschema:"description=A directory of the module, such as a repository returned by gitclone or one of its packages. Only packages at or below it are summarized."`
+}
+
+// ModuleSummary describes a Go module and the packages below a directory of
+// it.
+type ModuleSummary struct {
+	Module      string          `json:"module,omitempty" jsonschema:"description=Go module path from go.mod."`
+	GoVersion   string          `json:"go_version,omitempty" jsonschema:"description=Go version from go.mod."`
+	Requires    []string        `json:"requires,omitempty" jsonschema:"description=Direct dependencies with their versions."`
+	EntryPoints []string        `json:"entry_points,omitempty" jsonschema:"description=Directories of the main packages, relative to the module root."`
+	Packages    []PackageDetail `json:"packages" jsonschema:"description=Packages with their documentation and exported API."`
+	Truncated   bool            `json:"truncated,omitempty" jsonschema:"description=True when packages were left out; summarize a subdirectory to see them."`
+}
+
+// PackageDetail describes the exported API of one Go package.
+type PackageDetail struct {
+	Dir        string   `json:"dir" jsonschema:"description=Directory of the package, relative to the module root."`
+	ImportPath string   `json:"import_path,omitempty" jsonschema:"description=Import path of the package."`
+	Name       string   `json:"name" jsonschema:"description=Package name."`
+	Synopsis   string   `json:"synopsis,omitempty" jsonschema:"description=First sentence of the package documentation."`
+	Types      []Symbol `json:"types,omitempty" jsonschema:"description=Exported types."`
+	Funcs      []Symbol `json:"funcs,omitempty" jsonschema:"description=Exported functions, constructors included."`
+	Truncated  bool     `json:"truncated,omitempty" jsonschema:"description=True when exported identifiers were left out."`
+}
+
+// Symbol is an exported identifier of a package.
+type Symbol struct {
+	Decl string `json:"decl" jsonschema:"description=Declaration without the body, e.g. 'type Server struct' or 'func New(addr string) *Server'."`
+	Doc  string `json:"doc,omitempty" jsonschema:"description=First sentence of its documentation."`
+}
+
+type SummarizeModuleResponse struct {
+	Summary *ModuleSummary `json:"summary,omitempty" jsonschema:"description=Summary of the module."`
+	Error   string         `json:"error,omitempty" jsonschema:"description=Error message if the summary could not be built."`
+}
+
+// NewSummarizeModuleTool returns the summarize_module tool, which describes a
+// Go module from its go.mod, package documentation and exported
+// identifiers.
+func NewSummarizeModuleTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"summarize_module",
+		"Explain a Go module's API in one call: module path, Go version, direct dependencies, main packages (entry points), and for every package below 'path' its doc synopsis, exported types and exported function signatures. Use it when asked to explain a codebase or before reading the files of an unfamiliar package.",
+		func(ctx context.Context, req *SummarizeModuleRequest) (*SummarizeModuleResponse, error) {
+			if req.Path == "" {
+				return &SummarizeModuleResponse{Error: "path cannot be empty"}, nil
+			}
+			dir, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &SummarizeModuleResponse{Error: err.Error()}, nil
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return &SummarizeModuleResponse{Error: fmt.Sprintf("'%s' is not a directory", req.Path)}, nil
+			}
+
+			summary, err := summarizeModule(ctx, dir)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &SummarizeModuleResponse{Error: err.Error()}, nil
+			}
+			return &SummarizeModuleResponse{Summary: summary}, nil
+		},
+	)
+}
+
+// summarizeModule summarizes the packages at or below dir, in the module
+// whose go.mod is closest above it. It stops early when ctx is done.
+func summarizeModule(ctx context.Context, dir string) (*ModuleSummary, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	root := moduleRoot(ctx, dir)
+	if root == "" {
+		return nil, fmt.Errorf("no go.mod found at or above '%s'", dir)
+	}
+	gomod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	summary := &ModuleSummary{Module: modulePath(gomod)}
+	summary.GoVersion, summary.Requires = moduleRequirements(gomod)
+
+	var dirs []string
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != dir {
+			if name := d.Name(); strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata" || name == "node_modules" {
+				return filepath.SkipDir
+			}
+			// Nested modules are summarized on their own.
+			if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		dirs = append(dirs, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk '%s': %w", dir, err)
+	}
+
+	for _, d := range dirs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		rel, _ := filepath.Rel(root, d)
+		rel = filepath.ToSlash(rel)
+		pkg := summarizePackage(d, rel)
+		if pkg == nil {
+			continue
+		}
+		if len(summary.Packages) == maxSummaryPackages {
+			summary.Truncated = true
+			break
+		}
+		if summary.Module != "" {
+			pkg.ImportPath = path.Join(summary.Module, rel)
+		}
+		if pkg.Name == "main" {
+			summary.EntryPoints = append(summary.EntryPoints, rel)
+		}
+		summary.Packages = append(summary.Packages, *pkg)
+	}
+	return summary, nil
+}
+
+// moduleRoot returns the closest directory at or above dir holding a go.mod,
+// without leaving the workspace, or "" if there is none.
+func moduleRoot(ctx context.Context, dir string) string {
+	top := Workspace(ctx)
+	if top != "" {
+		if abs, err := filepath.Abs(top); err == nil {
+			top = abs
+		}
+	}
+	for top == "" || within(top, dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
+}
+
+// moduleRequirements returns the go version and the direct requirements
+// declared in a go.mod file.
+func moduleRequirements(gomod []byte) (goVersion string, requires []string) {
+	for _, r := range parseRequires(gomod) {
+		if !r.indirect {
+			requires = append(requires, r.path+" "+r.version)
+		}
+	}
+	for _, line := range strings.Split(string(gomod), "\n") {
+		line, _, _ := strings.Cut(line, "//")
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "go" {
+			goVersion = fields[1]
+		}
+	}
+	return goVersion, requires
+}
+
+// requirement is a require directive of a go.mod file.
+type requirement struct {
+	path, version string
+	indirect      bool
+}
+
+// parseRequires returns the requirements declared in a go.mod file, from
+// single-line directives and require blocks alike.
+func parseRequires(gomod []byte) []requirement {
+	var requires []requirement
+	inRequire := false
+	for _, line := range strings.Split(string(gomod), "\n") {
+		line, comment, _ := strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		indirect := strings.TrimSpace(comment) == "indirect"
+		switch {
+		case inRequire && len(fields) == 1 && fields[0] == ")":
+			inRequire = false
+		case inRequire && len(fields) == 2:
+			requires = append(requires, requirement{fields[0], fields[1], indirect})
+		case len(fields) == 2 && fields[0] == "require" && fields[1] == "(":
+			inRequire = true
+		case len(fields) == 3 && fields[0] == "require":
+			requires = append(requires, requirement{fields[1], fields[2], indirect})
+		}
+	}
+	return requires
+}
+
+// summarizePackage describes the non-test Go package in dir, or returns nil
+// if dir holds none.
+func summarizePackage(dir, rel string) *PackageDetail {
+	fset := token.NewFileSet()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []*ast.File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		// Files of another package, such as generators behind a build tag,
+		// would make doc.NewFromFiles fail.
+		if len(files) > 0 && file.Name.Name != files[0].Name.Name {
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	p, err := doc.NewFromFiles(fset, files, rel)
+	if err != nil {
+		return nil
+	}
+
+	pkg := &PackageDetail{Dir: rel, Name: p.Name, Synopsis: firstSentence(p.Doc)}
+	add := func(list *[]Symbol, decl ast.Decl, docText string) {
+		if len(pkg.Types)+len(pkg.Funcs) == maxSummarySymbols {
+			pkg.Truncated = true
+			return
+		}
+		*list = append(*list, Symbol{Decl: declString(fset, decl), Doc: firstSentence(docText)})
+	}
+	for _, t := range p.Types {
+		add(&pkg.Types, t.Decl, t.Doc)
+	}
+	funcs := slices.Clone(p.Funcs)
+	for _, t := range p.Types {
+		funcs = append(funcs, t.Funcs...)
+	}
+	slices.SortFunc(funcs, func(a, b *doc.Func) int { return strings.Compare(a.Name, b.Name) })
+	for _, f := range funcs {
+		add(&pkg.Funcs, f.Decl, f.Doc)
+	}
+	return pkg
+}
+
+// declString prints decl without its body: the signature of a function, or
+// the name and kind of a type.
+func declString(fset *token.FileSet, decl ast.Decl) string {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, fset, &ast.FuncDecl{Recv: decl.Recv, Name: decl.Name, Type: decl.Type}); err != nil {
+			return "func " + decl.Name.Name
+		}
+		return strings.Join(strings.Fields(buf.String()), " ")
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.IsExported() {
+				return "type " + ts.Name.Name + " " + typeKind(fset, ts.Type)
+			}
+		}
+	}
+	return ""
+}
+
+func typeKind(fset *token.FileSet, expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		return ""
+	}
+	return buf.String()
+}
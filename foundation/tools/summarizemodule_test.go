@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeModule(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "widgets")
+	writeFiles(t, dir, map[string]string{
+		"go.mod": `module example.com/widgets
+
+go 1.25
+
+require github.com/a/b v1.2.0
+
+require (
+	github.com/c/d v0.3.0
+	github.com/e/f v1.0.0 // indirect
+)
+`,
+		"cmd/widgets/main.go": "// Command widgets serves widgets.\npackage main\n\nfunc main() {}\n",
+		"store/store.go": `// Package store keeps widgets in memory.
+package store
+
+// Store holds widgets. It is safe for concurrent use.
+type Store struct{ items map[string]int }
+
+// ID identifies a widget.
+type ID string
+
+type hidden int
+
+// New returns an empty store.
+func New(capacity int) *Store { return &Store{} }
+
+// Get returns a widget.
+func (s *Store) Get(id ID) (int, bool) { return 0, false }
+
+// Count reports the number of widgets in s.
+func Count(s *Store) int { return len(s.items) }
+
+func helper() {}
+`,
+		"store/store_test.go": "package store\n\nfunc TestX() {}\n",
+		"internal/doc.go":     "// Package internal has no API.\npackage internal\n",
+		"testdata/x/x.go":     "package x\n\nfunc X() {}\n",
+		"tools/go.mod":        "module example.com/widgets/tools\n",
+		"tools/tools.go":      "package tools\n",
+		"docs/notes.md":       "Not Go.\n",
+	})
+	ctx := context.Background()
+	bt, err := NewSummarizeModuleTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := callTool[SummarizeModuleRequest, SummarizeModuleResponse](t, ctx, bt, SummarizeModuleRequest{Path: dir})
+	if resp.Error != "" {
+		t.Fatalf("summarize_module failed: %s", resp.Error)
+	}
+	s := resp.Summary
+	if s.Module != "example.com/widgets" || s.GoVersion != "1.25" {
+		t.Errorf("module = %q, go = %q", s.Module, s.GoVersion)
+	}
+	if want := []string{"github.com/a/b v1.2.0", "github.com/c/d v0.3.0"}; !reflect.DeepEqual(s.Requires, want) {
+		t.Errorf("requires = %q, want %q", s.Requires, want)
+	}
+	if want := []string{"cmd/widgets"}; !reflect.DeepEqual(s.EntryPoints, want) {
+		t.Errorf("entry points = %q, want %q", s.EntryPoints, want)
+	}
+	want := []PackageDetail{
+		{Dir: "cmd/widgets", ImportPath: "example.com/widgets/cmd/widgets", Name: "main", Synopsis: "Command widgets serves widgets."},
+		{Dir: "internal", ImportPath: "example.com/widgets/internal", Name: "internal", Synopsis: "Package internal has no API."},
+		{
+			Dir: "store", ImportPath: "example.com/widgets/store", Name: "store", Synopsis: "Package store keeps widgets in memory.",
+			Types: []Symbol{
+				{Decl: "type ID string", Doc: "ID identifies a widget."},
+				{Decl: "type Store struct", Doc: "Store holds widgets."},
+			},
+			Funcs: []Symbol{
+				{Decl: "func Count(s *Store) int", Doc: "Count reports the number of widgets in s."},
+				{Decl: "func New(capacity int) *Store", Doc: "New returns an empty store."},
+			},
+		},
+	}
+	if !reflect.DeepEqual(s.Packages, want) {
+		t.Errorf("packages = %+v\nwant %+v", s.Packages, want)
+	}
+
+	// A package directory is summarized within its module.
+	resp = callTool[SummarizeModuleRequest, SummarizeModuleResponse](t, ctx, bt, SummarizeModuleRequest{Path: filepath.Join(dir, "store")})
+	if resp.Error != "" || len(resp.Summary.Packages) != 1 || resp.Summary.Packages[0].ImportPath != "example.com/widgets/store" || resp.Summary.Module != "example.com/widgets" {
+		t.Errorf("summary of store = %+v", resp)
+	}
+
+	// Without a go.mod inside the workspace there is nothing to summarize.
+	ws := WithWorkspace(ctx, filepath.Join(dir, "docs"))
+	if resp := callTool[SummarizeModuleRequest, SummarizeModuleResponse](t, ws, bt, SummarizeModuleRequest{Path: "."}); resp.Error == "" {
+		t.Errorf("summary outside a module = %+v, want an error", resp.Summary)
+	}
+}
@@ -7,11 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/cloudwego/eino/components/tool"
-	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/secrets"
 )
 
 // --- User-Facing Request/Response Structs ---
@@ -20,6 +20,9 @@ type TavilySearchRequest struct {
 	Query       string `json:"query" jsonschema:"description=The search query to find information on the internet."`
 	SearchDepth string `json:"search_depth,omitempty" jsonschema:"description=The depth of the search. Can be 'basic' or 'advanced'. Defaults to 'basic'."`
 	MaxResults  *int   `json:"max_results,omitempty" jsonschema:"description=The maximum number of results to return. Defaults to 5."`
+	Site        string `json:"site,omitempty" jsonschema:"description=Only return results from this domain, e.g. github.com."`
+	Recency     string `json:"recency,omitempty" jsonschema:"description=Only return results from the last 'day', 'week' or 'month'."`
+	Category    string `json:"category,omitempty" jsonschema:"description=Kind of results: 'news' for news articles, 'code' for code hosting and Q&A sites. Omit for a general search."`
 }
 
 type TavilySearchResponse struct {
@@ -27,6 +30,7 @@ type TavilySearchResponse struct {
 	Answer      string         `json:"answer,omitempty" jsonschema:"description=AI-generated summary answer, if available."`
 	Results     []TavilyResult `json:"results" jsonschema:"description=Array of search results with structured data."`
 	ResultCount int            `json:"result_count" jsonschema:"description=Number of results returned."`
+	Pages       []PageContent  `json:"pages,omitempty" jsonschema:"description=Text of the top result pages."`
 	Error       string         `json:"error,omitempty" jsonschema:"description=Error message if the search failed."`
 }
 
@@ -40,11 +44,14 @@ type TavilyResult struct {
 
 // tavilyAPIBody mirrors the structure of the JSON request sent to the Tavily API.
 type tavilyAPIBody struct {
-	APIKey        string `json:"api_key"`
-	Query         string `json:"query"`
-	SearchDepth   string `json:"search_depth,omitempty"`
-	IncludeAnswer bool   `json:"include_answer"`
-	MaxResults    int    `json:"max_results,omitempty"`
+	APIKey         string   `json:"api_key"`
+	Query          string   `json:"query"`
+	SearchDepth    string   `json:"search_depth,omitempty"`
+	IncludeAnswer  bool     `json:"include_answer"`
+	MaxResults     int      `json:"max_results,omitempty"`
+	IncludeDomains []string `json:"include_domains,omitempty"`
+	Topic          string   `json:"topic,omitempty"`
+	TimeRange      string   `json:"time_range,omitempty"`
 }
 
 // tavilyAPIResponse mirrors the successful JSON response from the Tavily API.
@@ -62,13 +69,17 @@ type tavilyErrorResponse struct {
 // TavilyTool holds the persistent state for the tool, like the API key and HTTP client.
 type TavilyTool struct {
 	apiKey     string
+	baseURL    string
 	httpClient *http.Client
+	pages      *pageFetcher // Nil unless result pages are fetched.
 }
 
-func NewTavilySearchTool(ctx context.Context) (tool.BaseTool, error) {
-	apiKey := os.Getenv("TAVILY_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("TAVILY_API_KEY environment variable is required")
+// NewTavilySearchTool returns the Tavily search_internet tool. searchConfig
+// may be nil.
+func NewTavilySearchTool(ctx context.Context, searchConfig *SearchConfig) (tool.BaseTool, error) {
+	apiKey, err := secrets.Get(secrets.TavilyAPIKey)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create a single, reusable HTTP client.
@@ -78,16 +89,19 @@ func NewTavilySearchTool(ctx context.Context) (tool.BaseTool, error) {
 
 	impl := &TavilyTool{
 		apiKey:     apiKey,
+		baseURL:    config.TavilyBaseURL(),
 		httpClient: client,
+		pages:      newPageFetcher(searchConfig),
 	}
 
-	return utils.InferTool(
-		"search_internet",
-		"Search the internet for current information, news, and general knowledge. Returns an AI-generated answer "+
-			"plus structured search results. Allows for 'basic' or 'advanced' search depth. Always cite sources using the provided URLs."+
-			"Use github.com to search for GitHub repositories and LinkedIn to search for peoples profiles.",
-		impl.PerformSearch,
-	)
+	desc := "Search the internet for current information, news, and general knowledge. Returns an AI-generated answer " +
+		"plus structured search results. Allows for 'basic' or 'advanced' search depth. Always cite sources using the provided URLs." +
+		" Use 'site' to search one domain (github.com for GitHub repositories, linkedin.com for people's profiles), " +
+		"'recency' for recent results and 'category' for news or code."
+	if impl.pages != nil {
+		desc += " The text of the top result pages is included under 'pages', so there is no need to fetch them."
+	}
+	return inferTool("search_internet", desc, impl.PerformSearch)
 }
 
 func (t *TavilyTool) PerformSearch(ctx context.Context, req *TavilySearchRequest) (*TavilySearchResponse, error) {
@@ -99,13 +113,24 @@ func (t *TavilyTool) PerformSearch(ctx context.Context, req *TavilySearchRequest
 	if req.MaxResults != nil {
 		maxResults = *req.MaxResults
 	}
+	filters, err := newSearchFilters(req.Site, req.Recency, req.Category)
+	if err != nil {
+		return &TavilySearchResponse{Error: err.Error()}, nil
+	}
+	topic := ""
+	if filters.category == "news" {
+		topic = "news"
+	}
 
 	apiReqBody := tavilyAPIBody{
-		APIKey:        t.apiKey,
-		Query:         req.Query,
-		SearchDepth:   searchDepth,
-		IncludeAnswer: true,
-		MaxResults:    maxResults,
+		APIKey:         t.apiKey,
+		Query:          req.Query,
+		SearchDepth:    searchDepth,
+		IncludeAnswer:  true,
+		MaxResults:     maxResults,
+		IncludeDomains: filters.domains(),
+		Topic:          topic,
+		TimeRange:      filters.recency,
 	}
 
 	jsonData, err := json.Marshal(apiReqBody)
@@ -113,7 +138,7 @@ func (t *TavilyTool) PerformSearch(ctx context.Context, req *TavilySearchRequest
 		return &TavilySearchResponse{Error: fmt.Sprintf("failed to marshal request: %v", err)}, nil
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.tavily.com/search", bytes.NewBuffer(jsonData))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/search", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return &TavilySearchResponse{Error: fmt.Sprintf("failed to create request: %v", err)}, nil
 	}
@@ -140,10 +165,18 @@ func (t *TavilyTool) PerformSearch(ctx context.Context, req *TavilySearchRequest
 		return &TavilySearchResponse{Error: fmt.Sprintf("failed to decode successful response: %v", err)}, nil
 	}
 
-	return &TavilySearchResponse{
+	result := &TavilySearchResponse{
 		Query:       apiResp.Query,
 		Answer:      apiResp.Answer,
 		Results:     apiResp.Results,
 		ResultCount: len(apiResp.Results),
-	}, nil
+	}
+	if t.pages != nil {
+		urls := make([]string, len(apiResp.Results))
+		for i, r := range apiResp.Results {
+			urls[i] = r.URL
+		}
+		result.Pages = t.pages.fetch(ctx, urls)
+	}
+	return result, nil
 }
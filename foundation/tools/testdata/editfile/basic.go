@@ -0,0 +1,23 @@
+package basic
+
+import "fmt"
+
+// Greeting is the default greeting.
+const Greeting = "hello"
+
+type Server struct {
+	Addr string
+}
+
+// Start prints the address.
+func (s *Server) Start() error {
+	fmt.Println("listening on", s.Addr)
+	return nil
+}
+
+func helper(n int) int {
+	if n < 2 {
+		return n
+	}
+	return helper(n-1) + helper(n-2)
+}
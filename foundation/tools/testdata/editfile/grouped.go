@@ -0,0 +1,28 @@
+// Package grouped has grouped declarations and comments in awkward places.
+package grouped
+
+import (
+	"errors"
+	"fmt"
+	str "strings"
+)
+
+var (
+	ErrEmpty = errors.New("empty") // trailing comment
+	count    int
+)
+
+const (
+	a = iota
+	b
+)
+
+/* block comment before a function */
+func Join(parts ...string) (string, error) {
+	if len(parts) == 0 {
+		return "", ErrEmpty
+	}
+	return str.Join(parts, ","), nil
+}
+
+func init() { fmt.Sprint(count) }
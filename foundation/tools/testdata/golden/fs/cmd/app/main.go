@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"example.com/widgets/internal/store"
+)
+
+func main() {
+	s := store.New()
+	if err := s.Put("gopher", 42); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(s.Get("gopher"))
+}
@@ -0,0 +1,27 @@
+// Package store keeps widgets in memory.
+package store
+
+import "errors"
+
+// ErrEmptyKey is returned when a widget has no name.
+var ErrEmptyKey = errors.New("empty key")
+
+type Store struct {
+	items map[string]int
+}
+
+func New() *Store {
+	return &Store{items: make(map[string]int)}
+}
+
+func (s *Store) Put(key string, value int) error {
+	if key == "" {
+		return ErrEmptyKey
+	}
+	s.items[key] = value
+	return nil
+}
+
+func (s *Store) Get(key string) int {
+	return s.items[key]
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ToolError is a failed tool call as the model receives it. RetryGuide
+// turns every failure, whether the tool reports it in the "error" field of
+// its response or returns a *ToolError, into the same envelope:
+//
+//	{"error": {"code": "not_found", "message": "…", "retryable": false},
+//	 "hint": {"suggestion": "…", "retries_left": 1},
+//	 "partial": {…the rest of the response…}}
+//
+// so a failure cannot be mistaken for a result, and the system prompt tells
+// the model how to handle it. Tools return a *ToolError when they know the
+// class of a failure better than its message tells; other failures are
+// classified from the message.
+type ToolError struct {
+	Code      string `json:"code"` // One of the Failure classes.
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"` // The same call may succeed if made again.
+}
+
+// NewToolError returns a failure of class code. Timeouts and unavailable
+// services are retryable; the other classes need a different call.
+func NewToolError(code, message string) *ToolError {
+	return &ToolError{Code: code, Message: message, Retryable: code == FailureTimeout || code == FailureUnavailable}
+}
+
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+// failureEnvelope is the result of a failed call.
+type failureEnvelope struct {
+	Error   *ToolError      `json:"error"`
+	Hint    Hint            `json:"hint"`
+	Partial json.RawMessage `json:"partial,omitempty"`
+}
+
+// toolFailure returns the failure of a call that returned out and err, and
+// what else its response holds. It returns false for a successful call and
+// for errors that are not a *ToolError, such as denials and cancellation,
+// which end the turn instead.
+func toolFailure(out string, err error) (failure *ToolError, partial json.RawMessage, failed bool) {
+	if err != nil {
+		return failure, nil, errors.As(err, &failure)
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal([]byte(out), &fields) != nil {
+		return nil, nil, false
+	}
+	raw, ok := fields["error"]
+	if !ok {
+		return nil, nil, false
+	}
+	var message string
+	if json.Unmarshal(raw, &message) == nil {
+		if message == "" {
+			return nil, nil, false
+		}
+		failure = NewToolError(classifyFailure(message), message)
+	} else if json.Unmarshal(raw, &failure) != nil || failure == nil || failure.Message == "" {
+		return nil, nil, false
+	}
+
+	delete(fields, "error")
+	delete(fields, "hint")
+	if len(fields) > 0 {
+		partial, _ = json.Marshal(fields)
+	}
+	return failure, partial, true
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/language"
+)
+
+type TranslateRequest struct {
+	Text   string `json:"text" jsonschema:"description=The text to translate."`
+	Target string `json:"target" jsonschema:"description=Language to translate into, as an ISO 639-1 code such as 'fr' or 'sw', or as a language name."`
+}
+
+type TranslateResponse struct {
+	Translation string `json:"translation,omitempty" jsonschema:"description=The translated text."`
+	Source      string `json:"source,omitempty" jsonschema:"description=ISO 639-1 code of the language the text was detected to be in, when it could be detected."`
+	Error       string `json:"error,omitempty" jsonschema:"description=Error message if the text could not be translated."`
+}
+
+// NewTranslateTool returns the translate tool, which translates text with
+// translator. Code and identifiers in the text are kept as they are.
+func NewTranslateTool(ctx context.Context, translator *language.Translator) (tool.BaseTool, error) {
+	return inferTool(
+		"translate",
+		"Translate text into another language, keeping code, identifiers and names as they are. Use it to answer an attendee in their language, or to read a document or error message written in a language the user does not read. The knowledge base search already translates on its own.",
+		func(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error) {
+			if strings.TrimSpace(req.Text) == "" {
+				return &TranslateResponse{Error: "text cannot be empty"}, nil
+			}
+			if req.Target == "" {
+				return &TranslateResponse{Error: "target language cannot be empty"}, nil
+			}
+			source := language.Detect(req.Text)
+			if source != "" && strings.EqualFold(language.Name(source), language.Name(req.Target)) {
+				return &TranslateResponse{Translation: req.Text, Source: source}, nil
+			}
+			translation, err := translator.Translate(ctx, req.Text, req.Target)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &TranslateResponse{Source: source, Error: err.Error()}, nil
+			}
+			return &TranslateResponse{Translation: translation, Source: source}, nil
+		},
+	)
+}
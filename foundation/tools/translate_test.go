@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/language"
+)
+
+// dictionaryModel translates the texts it knows, keyed by the language named
+// in the prompt and the text, and records what it was asked.
+type dictionaryModel struct {
+	entries map[string]string // "French|text" → translation
+	asked   []string
+}
+
+func (m *dictionaryModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	var target string
+	for _, name := range []string{"English", "French", "Swahili"} {
+		if strings.Contains(input[0].Content, "into "+name+".") {
+			target = name
+		}
+	}
+	key := target + "|" + input[len(input)-1].Content
+	m.asked = append(m.asked, key)
+	if translation, ok := m.entries[key]; ok {
+		return schema.AssistantMessage(translation, nil), nil
+	}
+	return nil, fmt.Errorf("no translation for %q", key)
+}
+
+func (m *dictionaryModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), err
+}
+
+func TestTranslateTool(t *testing.T) {
+	m := &dictionaryModel{entries: map[string]string{
+		"French|The keynote starts at nine.": "La keynote commence à neuf heures.",
+	}}
+	bt, err := NewTranslateTool(context.Background(), language.NewTranslator(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(req TranslateRequest) TranslateResponse {
+		t.Helper()
+		return callTool[TranslateRequest, TranslateResponse](t, context.Background(), bt, req)
+	}
+
+	resp := run(TranslateRequest{Text: "The keynote starts at nine.", Target: "fr"})
+	if resp.Translation != "La keynote commence à neuf heures." || resp.Source != "en" || resp.Error != "" {
+		t.Errorf("translate to fr = %+v", resp)
+	}
+
+	// Text already in the target language is returned without a call.
+	m.asked = nil
+	resp = run(TranslateRequest{Text: "Who is the first speaker of the day?", Target: "English"})
+	if resp.Translation != "Who is the first speaker of the day?" || len(m.asked) != 0 {
+		t.Errorf("translate to English = %+v, asked %v", resp, m.asked)
+	}
+
+	if resp := run(TranslateRequest{Text: "Where is the venue?", Target: "sw"}); resp.Error == "" {
+		t.Errorf("a failed translation was not reported: %+v", resp)
+	}
+	if resp := run(TranslateRequest{Text: " ", Target: "fr"}); resp.Error == "" {
+		t.Error("empty text was accepted")
+	}
+}
+
+func TestRAGToolTranslates(t *testing.T) {
+	docs := staticRetriever{
+		(&schema.Document{ID: "talk-1", Content: "Eino graphs in production.", MetaData: map[string]any{language.MetaLanguage: "en"}}).WithScore(0.9),
+		(&schema.Document{ID: "talk-2", Content: "Les agents en Go."}).WithScore(0.7),
+		(&schema.Document{ID: "talk-3", Content: "Déployer avec Docker.", MetaData: map[string]any{language.MetaLanguage: "fr"}}).WithScore(0.5),
+	}
+	m := &dictionaryModel{entries: map[string]string{
+		"English|Qui parle de la production avec Eino ?": "Who talks about production with Eino?",
+		"French|Eino graphs in production.":              "Les graphes Eino en production.",
+		"French|Les agents en Go.":                       "Les agents en Go.",
+	}}
+	bt, err := newRAGTool(docs, &RAGToolConfig{Structured: true, Translator: language.NewTranslator(m)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := callTool[json.RawMessage, RAGSearchResponse](t, context.Background(), bt, json.RawMessage(`{"query":"Qui parle de la production avec Eino ?"}`))
+	if resp.TranslatedQuery != "Who talks about production with Eino?" || resp.Result == nil {
+		t.Fatalf("got %+v", resp)
+	}
+	// Untagged documents are taken to be English; those already in French
+	// are not translated again.
+	var snippets []string
+	for _, s := range resp.Sources {
+		snippets = append(snippets, s.Snippet)
+	}
+	if got, want := strings.Join(snippets, "|"), "Les graphes Eino en production.|Les agents en Go.|Déployer avec Docker."; got != want {
+		t.Errorf("snippets = %q, want %q", got, want)
+	}
+	if len(m.asked) != 3 {
+		t.Errorf("asked for %d translations: %v", len(m.asked), m.asked)
+	}
+	if _, ok := docs[0].MetaData[MetaTranslatedFrom]; ok || docs[0].Content != "Eino graphs in production." {
+		t.Error("the retriever's documents were modified")
+	}
+
+	// English queries against the English knowledge base cost no calls.
+	m.asked = nil
+	docs = docs[:1]
+	bt, _ = newRAGTool(docs, &RAGToolConfig{Translator: language.NewTranslator(m)})
+	out, err := bt.(tool.InvokableTool).InvokableRun(context.Background(), `{"query":"Who talks about Eino in production?"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.asked) != 0 || strings.Contains(out, "translated_query") {
+		t.Errorf("English query asked %v and returned %s", m.asked, out)
+	}
+}
@@ -0,0 +1,292 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/olusolaa/goforai/foundation/forward"
+	"github.com/olusolaa/goforai/foundation/tokens"
+)
+
+// truncatedHint is added to every truncated result so the model narrows its
+// next call instead of repeating this one.
+const truncatedHint = "Result truncated to fit the context window. Refine the query (a narrower path, pattern or line range) to see the omitted parts."
+
+// OutputPolicy bounds the size of the tool results fed back to the model, so
+// one full-file read or broad search cannot overflow the context window in the
+// middle of a ReAct loop.
+type OutputPolicy struct {
+	// MaxTokens is the approximate budget for one result; 0 disables the limit.
+	MaxTokens int
+}
+
+// LimitOutput wraps t so that results over the policy's budget are shortened.
+// In a JSON object the longest strings are cut in the middle, keeping their
+// head and tail, or once no string is worth cutting, trailing array elements
+// and object members are dropped; a "truncated" field explains what
+// happened. Any other result is cut the same way as a whole. Tools that are
+// not invokable are returned unchanged.
+func LimitOutput(t tool.BaseTool, policy OutputPolicy) tool.BaseTool {
+	inner, ok := t.(tool.InvokableTool)
+	if !ok || policy.MaxTokens <= 0 {
+		return t
+	}
+	return &limitedTool{Tool: forward.Tool{InvokableTool: inner}, policy: policy}
+}
+
+type limitedTool struct {
+	forward.Tool
+	policy OutputPolicy
+}
+
+func (t *limitedTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	out, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	if err != nil {
+		return out, err
+	}
+	return t.policy.apply(out), nil
+}
+
+// apply shortens out to the policy's budget.
+func (p OutputPolicy) apply(out string) string {
+	if tokens.Estimate(out) <= p.MaxTokens {
+		return out
+	}
+	limit := tokens.BytesFor(out, p.MaxTokens)
+	if shortened, ok := shortenJSON(out, limit); ok {
+		return shortened
+	}
+	keep := limit - len(truncatedHint) - 2
+	return headTail(out, max(keep, 0)) + "\n\n" + truncatedHint
+}
+
+// shortenJSON cuts the longest strings of a JSON object until its encoding
+// fits in limit bytes, and drops trailing elements when the strings are too
+// short to cut, as in a long list of names. ok is false when out is not a
+// JSON object or not even the truncation marker fits.
+func shortenJSON(out string, limit int) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(out)))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil || obj == nil {
+		return "", false
+	}
+	obj["truncated"] = truncatedHint
+
+	for range 32 {
+		encoded, err := encodeJSON(obj)
+		if err != nil {
+			return "", false
+		}
+		excess := len(encoded) - limit
+		if excess <= 0 {
+			return encoded, true
+		}
+		leaves := stringLeaves(obj, "")
+		sort.Slice(leaves, func(i, j int) bool {
+			if li, lj := len(leaves[i].get()), len(leaves[j].get()); li != lj {
+				return li > lj
+			}
+			return leaves[i].path < leaves[j].path
+		})
+		if len(leaves) == 0 || len(leaves[0].get()) < 64 {
+			if !dropTrailing(obj, excess) {
+				return "", false
+			}
+			continue
+		}
+		longest := leaves[0]
+		s := longest.get()
+		// Escaping makes a string longer in JSON than in memory; scale the
+		// cut by that ratio, and halve the string at least each round.
+		quoted, err := encodeJSON(s)
+		if err != nil {
+			return "", false
+		}
+		keep := (len(quoted) - excess) * len(s) / len(quoted)
+		keep = max(min(keep, len(s)/2), 64)
+		longest.set(headTail(s, keep))
+	}
+	return "", false
+}
+
+// dropTrailing removes about excess bytes of trailing elements from the
+// largest array in obj, or from the largest nested object when there is no
+// array, or else from obj itself. Object members go in reverse key order,
+// the order they are encoded in. It reports whether anything was dropped.
+func dropTrailing(obj map[string]any, excess int) bool {
+	var arrays, objects []container
+	collectContainers(obj, "", nil, &arrays, &objects)
+	var target container
+	switch {
+	case len(arrays) > 0:
+		target = largest(arrays)
+	case len(objects) > 0:
+		target = largest(objects)
+	default:
+		target = objectContainer(obj, "")
+		if target.len() == 0 {
+			return false
+		}
+	}
+	for dropped := 0; dropped < excess && target.len() > 0; {
+		dropped += target.dropLast()
+	}
+	return true
+}
+
+// container is an array or object inside a decoded JSON document that
+// elements can be dropped from.
+type container struct {
+	path     string
+	len      func() int
+	dropLast func() int // Returns the encoded size of what was dropped.
+	size     int
+}
+
+// collectContainers finds the non-empty arrays and nested objects in v,
+// which set replaces in its parent.
+func collectContainers(v any, path string, set func(any), arrays, objects *[]container) {
+	switch v := v.(type) {
+	case map[string]any:
+		if path != "" {
+			if c := objectContainer(v, path); c.len() > 0 {
+				*objects = append(*objects, c)
+			}
+		}
+		for k, child := range v {
+			collectContainers(child, path+"/"+k, func(x any) { v[k] = x }, arrays, objects)
+		}
+	case []any:
+		if len(v) > 0 {
+			*arrays = append(*arrays, arrayContainer(v, path, set))
+		}
+		for i, child := range v {
+			collectContainers(child, fmt.Sprintf("%s/%d", path, i), func(x any) { v[i] = x }, arrays, objects)
+		}
+	}
+}
+
+// arrayContainer drops elements of a, replacing it in its parent with set.
+func arrayContainer(a []any, path string, set func(any)) container {
+	size, _ := encodeJSON(a)
+	c := container{path: path, size: len(size), len: func() int { return len(a) }}
+	c.dropLast = func() int {
+		last, _ := encodeJSON(a[len(a)-1])
+		a = a[:len(a)-1]
+		set(a)
+		return len(last) + 1 // And its comma.
+	}
+	return c
+}
+
+// objectContainer drops members of m other than the truncation marker.
+func objectContainer(m map[string]any, path string) container {
+	size, _ := encodeJSON(m)
+	var keys []string
+	for k := range m {
+		if k != "truncated" || path != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return container{
+		path: path,
+		size: len(size),
+		len:  func() int { return len(keys) },
+		dropLast: func() int {
+			k := keys[len(keys)-1]
+			keys = keys[:len(keys)-1]
+			member, _ := encodeJSON(map[string]any{k: m[k]})
+			delete(m, k)
+			return len(member) - 1 // Its braces, less its comma.
+		},
+	}
+}
+
+// largest returns the container with the longest encoding, the first by
+// path among equals.
+func largest(cs []container) container {
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].size != cs[j].size {
+			return cs[i].size > cs[j].size
+		}
+		return cs[i].path < cs[j].path
+	})
+	return cs[0]
+}
+
+// leaf gives access to one string value inside a decoded JSON document.
+type leaf struct {
+	path string // Orders leaves of equal length deterministically.
+	get  func() string
+	set  func(string)
+}
+
+func stringLeaves(v any, path string) []leaf {
+	var leaves []leaf
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if _, ok := child.(string); ok {
+				if k == "truncated" {
+					continue
+				}
+				leaves = append(leaves, leaf{
+					path: path + "/" + k,
+					get:  func() string { s, _ := v[k].(string); return s },
+					set:  func(s string) { v[k] = s },
+				})
+				continue
+			}
+			leaves = append(leaves, stringLeaves(child, path+"/"+k)...)
+		}
+	case []any:
+		for i, child := range v {
+			if _, ok := child.(string); ok {
+				leaves = append(leaves, leaf{
+					path: fmt.Sprintf("%s/%d", path, i),
+					get:  func() string { s, _ := v[i].(string); return s },
+					set:  func(s string) { v[i] = s },
+				})
+				continue
+			}
+			leaves = append(leaves, stringLeaves(child, fmt.Sprintf("%s/%d", path, i))...)
+		}
+	}
+	return leaves
+}
+
+func encodeJSON(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+// headTail shortens s to at most keep bytes, keeping two thirds from the
+// start and one third from the end, on rune boundaries, with a marker in
+// between.
+func headTail(s string, keep int) string {
+	if len(s) <= keep {
+		return s
+	}
+	keep = max(keep-40, 0) // Room for the marker.
+	head, tail := keep*2/3, keep/3
+	for head > 0 && !utf8.RuneStart(s[head]) {
+		head--
+	}
+	start := len(s) - tail
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return fmt.Sprintf("%s\n… [%d bytes omitted] …\n%s", s[:head], start-head, s[start:])
+}
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// staticTool returns the same result on every call.
+type staticTool struct {
+	out string
+	err error
+}
+
+func (t staticTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: "static"}, nil
+}
+
+func (t staticTool) InvokableRun(context.Context, string, ...tool.Option) (string, error) {
+	return t.out, t.err
+}
+
+func runLimited(t *testing.T, out string, maxTokens int) string {
+	t.Helper()
+	limited := LimitOutput(staticTool{out: out}, OutputPolicy{MaxTokens: maxTokens})
+	got, err := limited.(tool.InvokableTool).InvokableRun(context.Background(), "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestLimitOutputKeepsSmallResults(t *testing.T) {
+	const out = `{"content":"package main","total_lines":1}`
+	if got := runLimited(t, out, 100); got != out {
+		t.Errorf("small result changed to %s", got)
+	}
+}
+
+func TestLimitOutputShortensJSONStrings(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 5000; i++ {
+		content.WriteString("line of <code> & more\n")
+	}
+	in, _ := json.Marshal(map[string]any{
+		"content":     "HEAD " + content.String() + " TAIL",
+		"total_lines": 9007199254740993, // Beyond float64 precision.
+		"path":        "main.go",
+	})
+
+	got := runLimited(t, string(in), 1000)
+	if len(got) > 4000 {
+		t.Errorf("result is %d bytes, want at most 4000", len(got))
+	}
+	var resp map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(got), &resp); err != nil {
+		t.Fatalf("truncated result is not valid JSON: %v\n%s", err, got)
+	}
+	if string(resp["total_lines"]) != "9007199254740993" || string(resp["path"]) != `"main.go"` {
+		t.Errorf("short fields changed: %s", got)
+	}
+	var text string
+	json.Unmarshal(resp["content"], &text)
+	if !strings.HasPrefix(text, "HEAD line of <code>") || !strings.HasSuffix(text, " TAIL") || !strings.Contains(text, "bytes omitted") {
+		t.Errorf("content does not keep its head and tail:\n%s", text)
+	}
+	if !strings.Contains(string(resp["truncated"]), "Refine the query") {
+		t.Errorf("no truncation hint in %s", got)
+	}
+}
+
+func TestLimitOutputDropsTrailingElements(t *testing.T) {
+	files := make([]string, 5000)
+	for i := range files {
+		files[i] = fmt.Sprintf("pkg/file%04d.go", i)
+	}
+	in, _ := json.Marshal(map[string]any{"files": files, "total": len(files), "error": ""})
+
+	got := runLimited(t, string(in), 500)
+	if len(got) > 2000 {
+		t.Errorf("result is %d bytes, want at most 2000", len(got))
+	}
+	var resp struct {
+		Files     []string `json:"files"`
+		Total     int      `json:"total"`
+		Truncated string   `json:"truncated"`
+	}
+	if err := json.Unmarshal([]byte(got), &resp); err != nil {
+		t.Fatalf("truncated result is not valid JSON: %v\n%s", err, got)
+	}
+	if len(resp.Files) == 0 || len(resp.Files) >= len(files) || resp.Files[0] != files[0] || resp.Total != len(files) {
+		t.Errorf("want the first files and the total, got %d files: %s", len(resp.Files), got)
+	}
+	if resp.Truncated != truncatedHint {
+		t.Errorf("no truncation hint in %s", got)
+	}
+
+	// Without arrays, trailing object members go instead.
+	counts := make(map[string]int, len(files))
+	for i, f := range files {
+		counts[f] = i
+	}
+	in, _ = json.Marshal(map[string]any{"counts": counts})
+	got = runLimited(t, string(in), 500)
+	var byFile struct {
+		Counts    map[string]int `json:"counts"`
+		Truncated string         `json:"truncated"`
+	}
+	if err := json.Unmarshal([]byte(got), &byFile); err != nil || len(got) > 2000 {
+		t.Fatalf("truncated result (%d bytes) is not valid JSON: %v\n%s", len(got), err, got)
+	}
+	if _, ok := byFile.Counts[files[0]]; !ok || len(byFile.Counts) >= len(files) || byFile.Truncated != truncatedHint {
+		t.Errorf("want the first members and the hint, got %s", got)
+	}
+}
+
+func TestLimitOutputShortensText(t *testing.T) {
+	got := runLimited(t, "start "+strings.Repeat("日本語 ", 1000)+"end", 200)
+	if len(got) > 800 || !strings.HasPrefix(got, "start ") || !strings.Contains(got, "end\n\n"+truncatedHint) {
+		t.Errorf("unexpected truncation (%d bytes):\n%s", len(got), got)
+	}
+	if !strings.Contains(got, "bytes omitted") || strings.ContainsRune(got, '�') {
+		t.Errorf("text was not cut cleanly on rune boundaries:\n%s", got)
+	}
+}
+
+func TestLimitOutputPassesErrorsThrough(t *testing.T) {
+	limited := LimitOutput(staticTool{err: ErrToolDenied}, OutputPolicy{MaxTokens: 1})
+	if _, err := limited.(tool.InvokableTool).InvokableRun(context.Background(), "{}"); !errors.Is(err, ErrToolDenied) {
+		t.Errorf("InvokableRun = %v, want ErrToolDenied", err)
+	}
+	unlimited := staticTool{out: "x"}
+	if got := LimitOutput(unlimited, OutputPolicy{}); got != tool.BaseTool(unlimited) {
+		t.Errorf("a zero policy wrapped the tool")
+	}
+}
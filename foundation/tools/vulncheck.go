@@ -0,0 +1,251 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// maxCallStacks bounds the call stacks reported for one vulnerability; the
+// first few show how the code reaches it.
+const maxCallStacks = 3
+
+type ScanVulnerabilitiesRequest struct {
+	Path     string   `json:"path,omitempty" jsonschema:"description=Directory to scan from, inside the module. Defaults to the workspace root."`
+	Packages []string `json:"packages,omitempty" jsonschema:"description=Package patterns relative to path, e.g. './cmd/...'. Defaults to './...'."`
+	Test     bool     `json:"test,omitempty" jsonschema:"description=Also scan the code of test files."`
+}
+
+type ScanVulnerabilitiesResponse struct {
+	Module          string          `json:"module,omitempty" jsonschema:"description=Path of the scanned module."`
+	Message         string          `json:"message,omitempty" jsonschema:"description=Summary of the scan."`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty" jsonschema:"description=Known vulnerabilities the code calls into, most urgent to fix."`
+	NotCalled       []Vulnerability `json:"not_called,omitempty" jsonschema:"description=Vulnerabilities in required modules or imported packages whose vulnerable symbols the code never calls; upgrading is still advisable. Listed without symbols or call stacks."`
+	Error           string          `json:"error,omitempty" jsonschema:"description=Error message if the scan could not run."`
+}
+
+// Vulnerability is a Go vulnerability database entry that affects the
+// scanned code.
+type Vulnerability struct {
+	ID           string     `json:"id" jsonschema:"description=Go vulnerability ID, e.g. GO-2024-2687."`
+	Aliases      []string   `json:"aliases,omitempty" jsonschema:"description=Other IDs, such as CVEs and GHSAs."`
+	Summary      string     `json:"summary,omitempty"`
+	URL          string     `json:"url,omitempty" jsonschema:"description=Page with the full advisory."`
+	Module       string     `json:"module" jsonschema:"description=The affected module; 'stdlib' for the standard library, fixed by upgrading Go."`
+	FoundVersion string     `json:"found_version,omitempty" jsonschema:"description=The version in use."`
+	FixedVersion string     `json:"fixed_version,omitempty" jsonschema:"description=The first version with the fix; empty when none is released."`
+	Symbols      []string   `json:"symbols,omitempty" jsonschema:"description=Vulnerable functions the code calls."`
+	CallStacks   [][]string `json:"call_stacks,omitempty" jsonschema:"description=How the code reaches the vulnerable functions: each stack goes from the module's own code to the vulnerable call, one 'function (file:line)' per frame."`
+}
+
+// NewScanVulnerabilitiesTool returns the scan_vulnerabilities tool, which
+// runs govulncheck on a module and reports the known vulnerabilities its
+// code calls, with call stacks and the versions that fix them. govulncheck
+// must be on PATH.
+func NewScanVulnerabilitiesTool(ctx context.Context) (tool.BaseTool, error) {
+	return inferTool(
+		"scan_vulnerabilities",
+		"Scan a Go module for known vulnerabilities with govulncheck: report each vulnerability the code actually calls, with the vulnerable functions, the call stacks reaching them from the module's code, and the version that fixes it, plus those in dependencies it does not call. Use it to audit a repository; patch with fix_dependencies (get 'module@fixed_version') and check with run_go.",
+		func(ctx context.Context, req *ScanVulnerabilitiesRequest) (*ScanVulnerabilitiesResponse, error) {
+			packages := req.Packages
+			if len(packages) == 0 {
+				packages = []string{"./..."}
+			}
+			for _, p := range packages {
+				if p == "" || strings.HasPrefix(p, "-") || filepath.IsAbs(p) || slices.Contains(strings.Split(filepath.ToSlash(p), "/"), "..") {
+					return &ScanVulnerabilitiesResponse{Error: fmt.Sprintf("invalid package pattern '%s': patterns must stay inside path", p)}, nil
+				}
+			}
+			dir, err := resolvePath(ctx, req.Path)
+			if errors.Is(err, ErrToolDenied) {
+				return nil, err
+			}
+			if err != nil {
+				return &ScanVulnerabilitiesResponse{Error: err.Error()}, nil
+			}
+			if dir == "" {
+				dir = "."
+			}
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				return &ScanVulnerabilitiesResponse{Error: fmt.Sprintf("'%s' is not a directory", req.Path)}, nil
+			}
+			if _, err := exec.LookPath("govulncheck"); err != nil {
+				return &ScanVulnerabilitiesResponse{Error: "govulncheck is not installed; ask the user to run 'go install golang.org/x/vuln/cmd/govulncheck@latest'"}, nil
+			}
+
+			resp, err := scanVulnerabilities(ctx, dir, req.Test, packages)
+			if err := canceled(ctx); err != nil {
+				return nil, err
+			}
+			if err != nil {
+				return &ScanVulnerabilitiesResponse{Error: err.Error()}, nil
+			}
+			if abs, err := filepath.Abs(dir); err == nil {
+				if root := moduleRoot(ctx, abs); root != "" {
+					if gomod, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+						resp.Module = modulePath(gomod)
+					}
+				}
+			}
+			return resp, nil
+		},
+	)
+}
+
+// scanVulnerabilities runs govulncheck on packages in dir and sorts its
+// findings into the vulnerabilities the code calls and the rest.
+func scanVulnerabilities(ctx context.Context, dir string, test bool, packages []string) (*ScanVulnerabilitiesResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, runGoTimeout)
+	defer cancel()
+	args := []string{"-json"}
+	if test {
+		args = append(args, "-test")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "govulncheck", append(args, packages...)...)
+	cmd.Dir, cmd.Stdout, cmd.Stderr = dir, &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("govulncheck did not finish within %s", runGoTimeout)
+		}
+		return nil, fmt.Errorf("govulncheck failed: %w\n%s", err, shortenOutput(stderr.String()))
+	}
+	resp, err := parseGovulncheck(&stdout)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(resp.Vulnerabilities) > 0:
+		resp.Message = fmt.Sprintf("⚠️ The code calls %d known vulnerabilit(ies); %d more are in dependencies it does not call", len(resp.Vulnerabilities), len(resp.NotCalled))
+	case len(resp.NotCalled) > 0:
+		resp.Message = fmt.Sprintf("✅ The code calls no known vulnerability; %d are in dependencies it does not call", len(resp.NotCalled))
+	default:
+		resp.Message = "✅ No known vulnerabilities"
+	}
+	return resp, nil
+}
+
+// govulncheckMessage is one of the JSON messages govulncheck -json writes.
+type govulncheckMessage struct {
+	OSV *struct {
+		ID               string   `json:"id"`
+		Aliases          []string `json:"aliases"`
+		Summary          string   `json:"summary"`
+		Details          string   `json:"details"`
+		DatabaseSpecific struct {
+			URL string `json:"url"`
+		} `json:"database_specific"`
+	} `json:"osv"`
+	Finding *struct {
+		OSV          string             `json:"osv"`
+		FixedVersion string             `json:"fixed_version"`
+		Trace        []govulncheckFrame `json:"trace"`
+	} `json:"finding"`
+}
+
+// govulncheckFrame is a frame of a finding's trace. The first frame is the
+// vulnerable symbol; the trace ends in the scanned module's code.
+type govulncheckFrame struct {
+	Module   string `json:"module"`
+	Version  string `json:"version"`
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	Receiver string `json:"receiver"`
+	Position *struct {
+		Filename string `json:"filename"`
+		Line     int    `json:"line"`
+	} `json:"position"`
+}
+
+func (f govulncheckFrame) symbol() string {
+	if f.Receiver != "" {
+		return f.Package + "." + f.Receiver + "." + f.Function
+	}
+	return f.Package + "." + f.Function
+}
+
+func (f govulncheckFrame) String() string {
+	if f.Position == nil || f.Position.Filename == "" {
+		return f.symbol()
+	}
+	return fmt.Sprintf("%s (%s:%d)", f.symbol(), f.Position.Filename, f.Position.Line)
+}
+
+// parseGovulncheck reads the stream of JSON messages of govulncheck -json.
+// A vulnerability is called when one of its findings has a trace down to a
+// function.
+func parseGovulncheck(r io.Reader) (*ScanVulnerabilitiesResponse, error) {
+	byID := make(map[string]*Vulnerability)
+	var order []string
+	called := make(map[string]bool)
+	dec := json.NewDecoder(r)
+	for {
+		var msg govulncheckMessage
+		if err := dec.Decode(&msg); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read govulncheck output: %w", err)
+		}
+		switch {
+		case msg.OSV != nil:
+			v := vulnerability(byID, &order, msg.OSV.ID)
+			v.Aliases, v.URL = msg.OSV.Aliases, msg.OSV.DatabaseSpecific.URL
+			if v.Summary = msg.OSV.Summary; v.Summary == "" {
+				v.Summary = firstSentence(msg.OSV.Details)
+			}
+		case msg.Finding != nil && len(msg.Finding.Trace) > 0:
+			v := vulnerability(byID, &order, msg.Finding.OSV)
+			trace := msg.Finding.Trace
+			v.Module, v.FoundVersion, v.FixedVersion = trace[0].Module, trace[0].Version, msg.Finding.FixedVersion
+			if trace[0].Function == "" {
+				continue
+			}
+			called[v.ID] = true
+			if symbol := trace[0].symbol(); !slices.Contains(v.Symbols, symbol) {
+				v.Symbols = append(v.Symbols, symbol)
+			}
+			if len(v.CallStacks) < maxCallStacks {
+				stack := make([]string, len(trace))
+				for i, frame := range slices.Backward(trace) {
+					stack[len(trace)-1-i] = frame.String()
+				}
+				v.CallStacks = append(v.CallStacks, stack)
+			}
+		}
+	}
+
+	resp := &ScanVulnerabilitiesResponse{}
+	for _, id := range order {
+		v := byID[id]
+		switch {
+		case v.Module == "":
+			// The database entry was loaded but nothing in the module is affected.
+		case called[id]:
+			resp.Vulnerabilities = append(resp.Vulnerabilities, *v)
+		default:
+			resp.NotCalled = append(resp.NotCalled, *v)
+		}
+	}
+	return resp, nil
+}
+
+// vulnerability returns the entry for id, adding it in order when it is new.
+func vulnerability(byID map[string]*Vulnerability, order *[]string, id string) *Vulnerability {
+	v, ok := byID[id]
+	if !ok {
+		v = &Vulnerability{ID: id}
+		byID[id] = v
+		*order = append(*order, id)
+	}
+	return v
+}
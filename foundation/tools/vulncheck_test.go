@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestScanVulnerabilities(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake govulncheck is a shell script")
+	}
+	// A fake govulncheck prints the output of a real scan and records its
+	// arguments.
+	bin := t.TempDir()
+	fixture, err := filepath.Abs("testdata/govulncheck/output.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(bin, "args") + "\ncat " + fixture + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "govulncheck"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"go.mod": "module example.com/app\n\ngo 1.22\n", "main.go": "package main\n"})
+	ctx := WithWorkspace(context.Background(), dir)
+	bt, err := NewScanVulnerabilitiesTool(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := callTool[json.RawMessage, ScanVulnerabilitiesResponse](t, ctx, bt, json.RawMessage(`{"test":true}`))
+	if resp.Error != "" {
+		t.Fatal(resp.Error)
+	}
+	if args, _ := os.ReadFile(filepath.Join(bin, "args")); strings.TrimSpace(string(args)) != "-json -test ./..." {
+		t.Errorf("govulncheck ran with %q", args)
+	}
+
+	if resp.Module != "example.com/app" || len(resp.Vulnerabilities) != 2 || len(resp.NotCalled) != 1 {
+		t.Fatalf("scan = %+v, want two called vulnerabilities and one not called", resp)
+	}
+	text := resp.Vulnerabilities[0]
+	if text.ID != "GO-2021-0113" || text.Module != "golang.org/x/text" || text.FoundVersion != "v0.3.5" || text.FixedVersion != "v0.3.7" ||
+		!slices.Equal(text.Symbols, []string{"golang.org/x/text/language.Parse"}) || !slices.Contains(text.Aliases, "CVE-2021-38561") {
+		t.Errorf("first vulnerability = %+v", text)
+	}
+	wantStack := []string{
+		"example.com/app.main (main.go:10)",
+		"example.com/app/lang.Detect (lang/detect.go:21)",
+		"golang.org/x/text/language.Parse (language/parse.go:33)",
+	}
+	if len(text.CallStacks) != 1 || !slices.Equal(text.CallStacks[0], wantStack) {
+		t.Errorf("call stacks = %q, want %q", text.CallStacks, wantStack)
+	}
+	if http := resp.Vulnerabilities[1]; http.Module != "stdlib" || !slices.Equal(http.Symbols, []string{"net/http.*Client.Do"}) {
+		t.Errorf("second vulnerability = %+v", http)
+	}
+	if nc := resp.NotCalled[0]; nc.ID != "GO-2022-1059" || nc.FixedVersion != "v0.3.8" || len(nc.Symbols) != 0 ||
+		nc.Summary != "An attacker may cause a denial of service by crafting an Accept-Language header which ParseAcceptLanguage will take significant time to parse." {
+		t.Errorf("not called = %+v", nc)
+	}
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+type workspaceKey struct{}
+
+// WithWorkspace confines the file tools to dir for calls made with the
+// returned context. Server mode uses it to give every session its own sandbox.
+func WithWorkspace(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, dir)
+}
+
+// Workspace returns the workspace set by WithWorkspace, or "" when the tools
+// operate on the current directory.
+func Workspace(ctx context.Context) string {
+	dir, _ := ctx.Value(workspaceKey{}).(string)
+	return dir
+}
+
+// ResolvePath maps path onto the workspace of ctx exactly as the file tools
+// do, for callers outside this package that read files on the model's or
+// user's behalf. Paths that escape the workspace fail with
+// ErrWorkspaceViolation.
+func ResolvePath(ctx context.Context, path string) (string, error) {
+	return resolvePath(ctx, path)
+}
+
+// resolvePath maps a path given by the model onto the session workspace.
+// Without a workspace the path is returned unchanged. Inside one, relative
+// paths are joined to it, symlinks are followed, and anything that would
+// escape it is rejected with ErrWorkspaceViolation.
+func resolvePath(ctx context.Context, path string) (string, error) {
+	root := Workspace(ctx)
+	if root == "" {
+		return path, nil
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid workspace: %w", err)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid workspace: %w", err)
+	}
+
+	resolved := filepath.Clean(path)
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(root, resolved)
+	}
+	if !within(root, resolved) {
+		return "", fmt.Errorf("%w: '%s'", ErrWorkspaceViolation, path)
+	}
+
+	// A symlink inside the workspace may still point outside it, so check
+	// where the path really lands.
+	real, err := evalExisting(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s': %w", path, err)
+	}
+	if !within(realRoot, real) {
+		return "", fmt.Errorf("%w: '%s'", ErrWorkspaceViolation, path)
+	}
+	return resolved, nil
+}
+
+// evalExisting follows the symlinks in path. Trailing elements that do not
+// exist yet, such as a file about to be created, are kept as they are.
+func evalExisting(path string) (string, error) {
+	var missing []string
+	for {
+		real, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(append([]string{real}, missing...)...), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
+
+// within reports whether path is root or lies below it.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePath(t *testing.T) {
+	workspace := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(workspace, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(workspace, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(workspace, "src"), filepath.Join(workspace, "alias")); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithWorkspace(context.Background(), workspace)
+
+	for _, path := range []string{
+		"src",
+		"src/new/file.go", // not created yet
+		"alias/main.go",   // symlink that stays inside
+		filepath.Join(workspace, "src", "main.go"),
+	} {
+		if _, err := resolvePath(ctx, path); err != nil {
+			t.Errorf("resolvePath(%q) = %v, want it allowed", path, err)
+		}
+	}
+
+	for _, path := range []string{
+		"../secret.txt",
+		filepath.Join(outside, "secret.txt"),
+		"escape/secret.txt",
+		"escape/new.txt",
+		"escape",
+	} {
+		if _, err := resolvePath(ctx, path); !errors.Is(err, ErrWorkspaceViolation) {
+			t.Errorf("resolvePath(%q) = %v, want ErrWorkspaceViolation", path, err)
+		}
+	}
+}
+
+func TestResolvePathWithoutWorkspace(t *testing.T) {
+	if got, err := resolvePath(context.Background(), "../anything"); err != nil || got != "../anything" {
+		t.Errorf("resolvePath without a workspace = %q, %v; want the path unchanged", got, err)
+	}
+}
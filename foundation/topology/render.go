@@ -0,0 +1,163 @@
+package topology
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+// A node with a subgraph is drawn as a box around the subgraph's own nodes;
+// edges into it enter at the subgraph's START and edges out of it leave from
+// its END. Nodes are identified by their key path, such as
+// "ReactAgent/ChatModel".
+
+// DOT renders g in the Graphviz DOT language, for `dot -Tsvg`.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(g.Name))
+	b.WriteString("  rankdir=TB;\n  labelloc=t;\n")
+	fmt.Fprintf(&b, "  label=%s;\n", dotQuote(joinLines(g.Name, signature(g.InputType, g.OutputType))))
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+	g.writeDOT(&b, "", "  ")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (g *Graph) writeDOT(b *strings.Builder, prefix, indent string) {
+	fmt.Fprintf(b, "%s%s [label=%s, shape=circle];\n", indent, dotQuote(prefix+compose.START), dotQuote(compose.START))
+	fmt.Fprintf(b, "%s%s [label=%s, shape=doublecircle];\n", indent, dotQuote(prefix+compose.END), dotQuote(compose.END))
+	for _, n := range g.Nodes {
+		if n.Subgraph != nil {
+			fmt.Fprintf(b, "%ssubgraph %s {\n", indent, dotQuote("cluster_"+prefix+n.Key))
+			fmt.Fprintf(b, "%s  label=%s;\n  %sstyle=rounded;\n", indent, dotQuote(n.label()), indent)
+			n.Subgraph.writeDOT(b, prefix+n.Key+"/", indent+"  ")
+			fmt.Fprintf(b, "%s}\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s%s [label=%s];\n", indent, dotQuote(prefix+n.Key), dotQuote(n.label()))
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Branch {
+			attrs = append(attrs, "style=dashed", "label=\"branch\"")
+		}
+		if e.NoData {
+			attrs = append(attrs, "style=dotted")
+		}
+		fmt.Fprintf(b, "%s%s -> %s", indent, dotQuote(g.from(prefix, e.From)), dotQuote(g.to(prefix, e.To)))
+		if len(attrs) > 0 {
+			fmt.Fprintf(b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+}
+
+// Mermaid renders g as a Mermaid flowchart, which GitHub and most Markdown
+// viewers draw from a ```mermaid block.
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	title := g.Name
+	if sig := signature(g.InputType, g.OutputType); sig != "" {
+		title = strings.TrimSpace(title + " (" + sig + ")")
+	}
+	fmt.Fprintf(&b, "---\ntitle: %s\n---\nflowchart TD\n", mermaidEscape(title))
+	ids := map[string]string{}
+	id := func(path string) string {
+		if _, ok := ids[path]; !ok {
+			ids[path] = fmt.Sprintf("n%d", len(ids))
+		}
+		return ids[path]
+	}
+	g.writeMermaid(&b, "", "  ", id)
+	return b.String()
+}
+
+func (g *Graph) writeMermaid(b *strings.Builder, prefix, indent string, id func(string) string) {
+	fmt.Fprintf(b, "%s%s([\"%s\"])\n", indent, id(prefix+compose.START), compose.START)
+	fmt.Fprintf(b, "%s%s([\"%s\"])\n", indent, id(prefix+compose.END), compose.END)
+	for _, n := range g.Nodes {
+		if n.Subgraph != nil {
+			fmt.Fprintf(b, "%ssubgraph %s [\"%s\"]\n", indent, id("cluster_"+prefix+n.Key), mermaidEscape(n.label()))
+			n.Subgraph.writeMermaid(b, prefix+n.Key+"/", indent+"  ", id)
+			fmt.Fprintf(b, "%send\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s%s[\"%s\"]\n", indent, id(prefix+n.Key), mermaidEscape(n.label()))
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		switch {
+		case e.Branch:
+			arrow = "-. branch .->"
+		case e.NoData:
+			arrow = "-.->"
+		}
+		fmt.Fprintf(b, "%s%s %s %s\n", indent, id(g.from(prefix, e.From)), arrow, id(g.to(prefix, e.To)))
+	}
+}
+
+// from returns the path an edge leaving key starts at.
+func (g *Graph) from(prefix, key string) string {
+	if n := g.node(key); n != nil && n.Subgraph != nil {
+		return n.Subgraph.from(prefix+key+"/", compose.END)
+	}
+	return prefix + key
+}
+
+// to returns the path an edge entering key ends at.
+func (g *Graph) to(prefix, key string) string {
+	if n := g.node(key); n != nil && n.Subgraph != nil {
+		return n.Subgraph.to(prefix+key+"/", compose.START)
+	}
+	return prefix + key
+}
+
+func (g *Graph) node(key string) *Node {
+	for i := range g.Nodes {
+		if g.Nodes[i].Key == key {
+			return &g.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// label names the node, its component and the types it maps between.
+func (n *Node) label() string {
+	component := n.Component
+	if component == "" {
+		component = "Node"
+	}
+	return joinLines(n.Key, component, signature(n.InputType, n.OutputType))
+}
+
+func signature(in, out string) string {
+	if in == "" && out == "" {
+		return ""
+	}
+	return in + " → " + out
+}
+
+// joinLines joins the non-empty lines with newlines.
+func joinLines(lines ...string) string {
+	var kept []string
+	for _, l := range lines {
+		if l != "" {
+			kept = append(kept, l)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// dotQuote quotes s as a DOT string, in which \n breaks a label's line.
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// mermaidEscape makes s safe inside a quoted Mermaid label.
+func mermaidEscape(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", "<br/>")
+	return r.Replace(s)
+}
@@ -0,0 +1,176 @@
+// Package topology describes compiled Eino graphs — their nodes, edges and
+// the types flowing between them — and renders them as Graphviz DOT or
+// Mermaid, so an orchestration can be documented and debugged as a picture
+// rather than read out of the code that builds it.
+package topology
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+// Graph is the topology of a compiled graph.
+type Graph struct {
+	Name       string
+	InputType  string
+	OutputType string
+	Nodes      []Node // In breadth-first order from compose.START, which is left out along with compose.END.
+	Edges      []Edge
+}
+
+// Node is a node of a Graph.
+type Node struct {
+	Key        string
+	Component  string // Such as "ChatTemplate", "Lambda" or "Graph".
+	InputType  string
+	OutputType string
+	Subgraph   *Graph // The nested graph of a graph node, when it was compiled with a Recorder.
+}
+
+// Edge connects two nodes of a Graph, either of which can be compose.START or
+// compose.END.
+type Edge struct {
+	From, To string
+	Branch   bool // Taken only when the branch leaving From chooses To.
+	NoData   bool // Orders the nodes without passing From's output to To.
+}
+
+// Recorder is a compose.GraphCompileCallback that keeps the topology of the
+// graph it is compiled into:
+//
+//	var rec topology.Recorder
+//	g.Compile(ctx, compose.WithGraphCompileCallbacks(&rec))
+//	fmt.Print(rec.Graph().Mermaid())
+type Recorder struct {
+	mu    sync.Mutex
+	graph *Graph
+}
+
+// OnFinish implements compose.GraphCompileCallback.
+func (r *Recorder) OnFinish(ctx context.Context, info *compose.GraphInfo) {
+	g := FromGraphInfo(info)
+	r.mu.Lock()
+	r.graph = g
+	r.mu.Unlock()
+}
+
+// Graph returns the topology of the last graph compiled with r, or nil if
+// none has been.
+func (r *Recorder) Graph() *Graph {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.graph
+}
+
+// FromGraphInfo returns the topology info describes.
+func FromGraphInfo(info *compose.GraphInfo) *Graph {
+	g := &Graph{
+		Name:       info.Name,
+		InputType:  typeName(info.InputType),
+		OutputType: typeName(info.OutputType),
+	}
+
+	type pair struct{ from, to string }
+	control, data := map[pair]bool{}, map[pair]bool{}
+	for from, tos := range info.Edges {
+		for _, to := range tos {
+			control[pair{from, to}] = true
+		}
+	}
+	for from, tos := range info.DataEdges {
+		for _, to := range tos {
+			data[pair{from, to}] = true
+		}
+	}
+	branch := map[pair]bool{}
+	for from, branches := range info.Branches {
+		for _, b := range branches {
+			for to := range b.GetEndNode() {
+				branch[pair{from, to}] = true
+			}
+		}
+	}
+
+	next := map[string][]string{}
+	for _, edges := range []map[pair]bool{control, data, branch} {
+		for p := range edges {
+			if !slices.Contains(next[p.from], p.to) {
+				next[p.from] = append(next[p.from], p.to)
+			}
+		}
+	}
+	for from := range next {
+		slices.Sort(next[from])
+	}
+
+	// Visit the nodes breadth first from START, so that the listing reads in
+	// the order data flows; nodes START cannot reach follow in key order.
+	var order []string
+	seen := map[string]bool{compose.START: true, compose.END: true}
+	queue := []string{compose.START}
+	visit := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			order = append(order, key)
+			queue = append(queue, key)
+		}
+	}
+	for {
+		for len(queue) > 0 {
+			key := queue[0]
+			queue = queue[1:]
+			for _, to := range next[key] {
+				visit(to)
+			}
+		}
+		var rest []string
+		for key := range info.Nodes {
+			if !seen[key] {
+				rest = append(rest, key)
+			}
+		}
+		if len(rest) == 0 {
+			break
+		}
+		slices.Sort(rest)
+		visit(rest[0])
+	}
+
+	for _, key := range order {
+		n := info.Nodes[key]
+		node := Node{
+			Key:        key,
+			Component:  string(n.Component),
+			InputType:  typeName(n.InputType),
+			OutputType: typeName(n.OutputType),
+		}
+		if n.GraphInfo != nil {
+			node.Subgraph = FromGraphInfo(n.GraphInfo)
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	for _, from := range append([]string{compose.START}, order...) {
+		for _, to := range next[from] {
+			p := pair{from, to}
+			g.Edges = append(g.Edges, Edge{
+				From:   from,
+				To:     to,
+				Branch: branch[p],
+				NoData: control[p] && !data[p],
+			})
+		}
+	}
+	return g
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
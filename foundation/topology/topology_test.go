@@ -0,0 +1,113 @@
+package topology
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+func upper(_ context.Context, s string) (string, error) { return strings.ToUpper(s), nil }
+
+// compileTestGraph compiles a graph that routes its input through a branch
+// and a nested graph.
+func compileTestGraph(t *testing.T) *Graph {
+	t.Helper()
+	ctx := context.Background()
+	inner := compose.NewGraph[string, string]()
+	inner.AddLambdaNode("Shout", compose.InvokableLambda(upper))
+	inner.AddEdge(compose.START, "Shout")
+	inner.AddEdge("Shout", compose.END)
+
+	g := compose.NewGraph[string, string]()
+	g.AddLambdaNode("Trim", compose.InvokableLambda(func(_ context.Context, s string) (string, error) {
+		return strings.TrimSpace(s), nil
+	}))
+	g.AddGraphNode("Inner", inner)
+	g.AddLambdaNode("Echo", compose.InvokableLambda(func(_ context.Context, s string) (string, error) { return s, nil }))
+	g.AddEdge(compose.START, "Trim")
+	g.AddBranch("Trim", compose.NewGraphBranch(func(_ context.Context, s string) (string, error) {
+		if s == "" {
+			return "Echo", nil
+		}
+		return "Inner", nil
+	}, map[string]bool{"Inner": true, "Echo": true}))
+	g.AddEdge("Inner", compose.END)
+	g.AddEdge("Echo", compose.END)
+
+	var rec Recorder
+	if _, err := g.Compile(ctx, compose.WithGraphName("Test"), compose.WithGraphCompileCallbacks(&rec)); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Graph() == nil {
+		t.Fatal("the recorder saw no graph")
+	}
+	return rec.Graph()
+}
+
+func TestFromGraphInfo(t *testing.T) {
+	g := compileTestGraph(t)
+	if g.Name != "Test" || g.InputType != "string" || g.OutputType != "string" {
+		t.Errorf("graph = %q %s → %s", g.Name, g.InputType, g.OutputType)
+	}
+	var keys []string
+	for _, n := range g.Nodes {
+		keys = append(keys, n.Key)
+	}
+	if got := strings.Join(keys, ","); got != "Trim,Echo,Inner" {
+		t.Errorf("nodes = %s, want Trim,Echo,Inner", got)
+	}
+	if n := g.node("Trim"); n.Component != "Lambda" || n.InputType != "string" {
+		t.Errorf("Trim = %+v", n)
+	}
+	if n := g.node("Inner"); n.Subgraph == nil || len(n.Subgraph.Nodes) != 1 || n.Subgraph.Nodes[0].Key != "Shout" {
+		t.Errorf("Inner = %+v", n)
+	}
+	var branches int
+	for _, e := range g.Edges {
+		if e.Branch {
+			branches++
+			if e.From != "Trim" {
+				t.Errorf("branch edge %+v", e)
+			}
+		}
+	}
+	if branches != 2 {
+		t.Errorf("%d branch edges, want 2: %+v", branches, g.Edges)
+	}
+}
+
+func TestRender(t *testing.T) {
+	g := compileTestGraph(t)
+
+	dot := g.DOT()
+	for _, want := range []string{
+		`digraph "Test" {`,
+		`"Trim" [label="Trim\nLambda\nstring → string"];`,
+		`subgraph "cluster_Inner" {`,
+		`"start" -> "Trim";`,
+		`"Trim" -> "Inner/start" [style=dashed, label="branch"];`,
+		`"Inner/Shout" -> "Inner/end";`,
+		`"Inner/end" -> "end";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT is missing %s:\n%s", want, dot)
+		}
+	}
+
+	mermaid := g.Mermaid()
+	for _, want := range []string{
+		"title: Test (string → string)",
+		"flowchart TD",
+		`n2["Trim<br/>Lambda<br/>string → string"]`,
+		"subgraph n4 [\"Inner<br/>Graph<br/>string → string\"]",
+		"n0 --> n2",
+		"n2 -. branch .-> n5",
+		"n6 --> n1",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid is missing %s:\n%s", want, mermaid)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Package truncate shortens strings for logs, previews, prompts and tables
+// without splitting a UTF-8 sequence.
+package truncate
+
+import "unicode/utf8"
+
+// Bytes shortens s to at most n bytes without splitting a UTF-8
+// sequence, marking the cut with an ellipsis. Use it for size budgets.
+func Bytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	n = max(n, 0)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
+
+// Runes shortens s to at most n runes, the ellipsis marking the cut
+// included. Use it for text shown in columns.
+func Runes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,42 @@
+package truncate
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBytes(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"truncated", 5, "trunc…"},
+		{"héllo", 2, "h…"}, // é is two bytes; cutting at 2 would split it
+		{"日本語", 4, "日…"},
+		{"日本語", 2, "…"},
+		{"any", 0, "…"},
+	} {
+		got := Bytes(tc.in, tc.n)
+		if got != tc.want {
+			t.Errorf("Bytes(%q, %d) = %q, want %q", tc.in, tc.n, got, tc.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("Bytes(%q, %d) = %q is not valid UTF-8", tc.in, tc.n, got)
+		}
+	}
+}
+
+func TestRunes(t *testing.T) {
+	if got := Runes("Lit un fichier ou répertoire", 16); got != "Lit un fichier …" {
+		t.Errorf("Runes = %q", got)
+	}
+	if got := Runes("réponse", 7); got != "réponse" {
+		t.Errorf("Runes of a string that fits = %q", got)
+	}
+	if got := Runes("réponse", 0); got != "" {
+		t.Errorf("Runes to 0 runes = %q", got)
+	}
+}
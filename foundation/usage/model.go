@@ -0,0 +1,97 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/forward"
+	"github.com/olusolaa/goforai/foundation/logger"
+)
+
+// ChatModel returns m, the model named modelName at provider, with the usage
+// of every call recorded in the current ledger and calls refused once the
+// provider's quota is spent. Without Setup it only forwards to m.
+func ChatModel(provider, modelName string, m model.ToolCallingChatModel) model.ToolCallingChatModel {
+	return &trackedModel{ChatModel: forward.ChatModel{ToolCallingChatModel: m}, provider: provider, model: modelName}
+}
+
+type trackedModel struct {
+	forward.ChatModel
+	provider string
+	model    string
+}
+
+func (m *trackedModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	l := Current()
+	if l == nil {
+		return m.ToolCallingChatModel.Generate(ctx, input, opts...)
+	}
+	if err := l.Check(m.provider); err != nil {
+		return nil, err
+	}
+	msg, err := m.ToolCallingChatModel.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var u *schema.TokenUsage
+	if msg.ResponseMeta != nil {
+		u = msg.ResponseMeta.Usage
+	}
+	m.record(ctx, l, u)
+	return msg, nil
+}
+
+// Stream records the usage once the stream ends, from the last chunk that
+// reported any, as providers report it on the final chunk.
+func (m *trackedModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	l := Current()
+	if l == nil {
+		return m.ToolCallingChatModel.Stream(ctx, input, opts...)
+	}
+	if err := l.Check(m.provider); err != nil {
+		return nil, err
+	}
+	in, err := m.ToolCallingChatModel.Stream(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	out, w := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer in.Close()
+		defer w.Close()
+		var u *schema.TokenUsage
+		defer func() { m.record(ctx, l, u) }()
+		for {
+			chunk, err := in.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if chunk != nil && chunk.ResponseMeta != nil && chunk.ResponseMeta.Usage != nil {
+				u = chunk.ResponseMeta.Usage
+			}
+			if closed := w.Send(chunk, err); closed || err != nil {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// record adds a call to l; a ledger that cannot be written does not fail the
+// call that was already paid for.
+func (m *trackedModel) record(ctx context.Context, l *Ledger, u *schema.TokenUsage) {
+	if err := l.Add(ctx, m.provider, m.model, u); err != nil {
+		logger.FromContext(ctx).Warn("failed to record token usage", "provider", m.provider, "error", err)
+	}
+}
+
+func (m *trackedModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	inner, err := m.ToolCallingChatModel.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &trackedModel{ChatModel: forward.ChatModel{ToolCallingChatModel: inner}, provider: m.provider, model: m.model}, nil
+}
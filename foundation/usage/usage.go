@@ -0,0 +1,346 @@
+// Package usage keeps a ledger of the tokens spent with each model provider,
+// per UTC day and model, in a JSON file, and enforces the daily and monthly
+// quotas of USAGE_QUOTA_DAILY and USAGE_QUOTA_MONTHLY: a warning is logged
+// when a provider crosses USAGE_ALERT_THRESHOLD of a quota, and once a quota
+// is spent further calls to the provider fail until the period ends.
+//
+// Tracking starts with Setup, which goforai runs for every command, so that
+// library users and tests do not write a ledger unasked.
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/logger"
+	"github.com/olusolaa/goforai/foundation/models"
+)
+
+// ErrQuotaExceeded is returned for calls to a provider whose quota is spent.
+var ErrQuotaExceeded = errors.New("token quota exceeded")
+
+// How long an update waits for another process's lock on the ledger, and
+// how old a lock must be to have been left by a process that died holding
+// it. Updates take milliseconds.
+const (
+	lockTimeout = 5 * time.Second
+	staleLock   = 30 * time.Second
+)
+
+// Record is the usage of one model on one UTC day.
+type Record struct {
+	Date             string  `json:"date"` // YYYY-MM-DD.
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost_usd"` // 0 for models without a known price.
+}
+
+// file is the layout of the ledger file.
+type file struct {
+	Records []*Record `json:"records"`
+}
+
+// Ledger records token usage and enforces quotas on it. The file is read
+// again for every check and update, so processes sharing it, such as chat
+// and serve, see each other's spend, and updates hold a lock file next to
+// it, so that none is lost when two processes record a call at once. It is
+// safe for concurrent use.
+type Ledger struct {
+	path      string
+	daily     map[string]int64
+	monthly   map[string]int64
+	threshold float64
+	now       func() time.Time
+
+	mu sync.Mutex
+}
+
+// New returns the ledger cfg describes.
+func New(cfg config.Usage) *Ledger {
+	return &Ledger{
+		path:      cfg.Path,
+		daily:     cfg.DailyQuotas,
+		monthly:   cfg.MonthlyQuotas,
+		threshold: cfg.AlertThreshold,
+		now:       time.Now,
+	}
+}
+
+var current atomic.Pointer[Ledger]
+
+// Setup starts tracking the usage of every chat model wrapped by ChatModel in
+// the ledger config.LoadUsage describes.
+func Setup() error {
+	cfg, err := config.LoadUsage()
+	if err != nil {
+		return err
+	}
+	current.Store(New(cfg))
+	return nil
+}
+
+// Current returns the ledger Setup opened, or nil when usage is not tracked.
+func Current() *Ledger {
+	return current.Load()
+}
+
+// Path returns the file the ledger is kept in.
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Quota is a provider's quota for a period and what it spent of it.
+type Quota struct {
+	Provider string
+	Period   string // "day" or "month".
+	Used     int64
+	Limit    int64
+}
+
+// Quotas returns the configured quotas with their current spend, sorted by
+// provider, the daily one first.
+func (l *Ledger) Quotas() ([]Quota, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	now := l.now().UTC()
+	var quotas []Quota
+	for _, period := range []struct {
+		name   string
+		limits map[string]int64
+	}{{"day", l.daily}, {"month", l.monthly}} {
+		for provider, limit := range period.limits {
+			daily, monthly := spent(records, provider, now)
+			used := daily
+			if period.name == "month" {
+				used = monthly
+			}
+			quotas = append(quotas, Quota{Provider: provider, Period: period.name, Used: used, Limit: limit})
+		}
+	}
+	sort.SliceStable(quotas, func(i, j int) bool {
+		if quotas[i].Provider != quotas[j].Provider {
+			return quotas[i].Provider < quotas[j].Provider
+		}
+		return quotas[i].Period == "day" && quotas[j].Period == "month"
+	})
+	return quotas, nil
+}
+
+// Check returns an error wrapping ErrQuotaExceeded when provider has spent
+// its daily or monthly quota.
+func (l *Ledger) Check(provider string) error {
+	daily, monthly := l.daily[provider], l.monthly[provider]
+	if daily <= 0 && monthly <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records, err := l.read()
+	if err != nil {
+		return err
+	}
+	usedToday, usedMonth := spent(records, provider, l.now().UTC())
+	switch {
+	case daily > 0 && usedToday >= daily:
+		return fmt.Errorf("%w: %s has used %d of its %d tokens today (resets at midnight UTC)", ErrQuotaExceeded, provider, usedToday, daily)
+	case monthly > 0 && usedMonth >= monthly:
+		return fmt.Errorf("%w: %s has used %d of its %d tokens this month", ErrQuotaExceeded, provider, usedMonth, monthly)
+	}
+	return nil
+}
+
+// Add records one call of model at provider that used u, and logs a warning
+// when it takes the provider past the alert threshold or the end of a quota.
+func (l *Ledger) Add(ctx context.Context, provider, model string, u *schema.TokenUsage) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := l.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	records, err := l.read()
+	if err != nil {
+		return err
+	}
+	now := l.now().UTC()
+	beforeToday, beforeMonth := spent(records, provider, now)
+
+	date := now.Format(time.DateOnly)
+	var rec *Record
+	for _, r := range records {
+		if r.Date == date && r.Provider == provider && r.Model == model {
+			rec = r
+			break
+		}
+	}
+	if rec == nil {
+		rec = &Record{Date: date, Provider: provider, Model: model}
+		records = append(records, rec)
+	}
+	rec.Calls++
+	if u != nil {
+		rec.PromptTokens += int64(u.PromptTokens)
+		rec.CompletionTokens += int64(u.CompletionTokens)
+		rec.TotalTokens += int64(u.TotalTokens)
+		if caps, ok := models.Lookup(model); ok {
+			rec.Cost += caps.Cost(u.PromptTokens, u.CompletionTokens)
+		}
+	}
+	if err := l.write(records); err != nil {
+		return err
+	}
+
+	afterToday, afterMonth := spent(records, provider, now)
+	l.alert(ctx, provider, "daily", beforeToday, afterToday, l.daily[provider])
+	l.alert(ctx, provider, "monthly", beforeMonth, afterMonth, l.monthly[provider])
+	return nil
+}
+
+// alert logs a warning when spend went from before to after across the alert
+// threshold or the end of limit.
+func (l *Ledger) alert(ctx context.Context, provider, period string, before, after, limit int64) {
+	if limit <= 0 {
+		return
+	}
+	log := logger.FromContext(ctx)
+	switch warn := int64(l.threshold * float64(limit)); {
+	case before < limit && after >= limit:
+		log.Warn("token quota exhausted; further calls are refused", "provider", provider, "period", period, "used", after, "quota", limit)
+	case before < warn && after >= warn:
+		log.Warn("token quota nearly spent", "provider", provider, "period", period, "used", after, "quota", limit)
+	}
+}
+
+// Records returns the ledger from since's day on, oldest first.
+func (l *Ledger) Records(since time.Time) ([]Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	records, err := l.read()
+	if err != nil {
+		return nil, err
+	}
+	from := since.UTC().Format(time.DateOnly)
+	var out []Record
+	for _, r := range records {
+		if r.Date >= from {
+			out = append(out, *r)
+		}
+	}
+	return out, nil
+}
+
+// spent returns the tokens provider used on now's day and in its month.
+func spent(records []*Record, provider string, now time.Time) (day, month int64) {
+	date := now.Format(time.DateOnly)
+	for _, r := range records {
+		if r.Provider != provider || !strings.HasPrefix(r.Date, date[:len("2006-01")]) {
+			continue
+		}
+		month += r.TotalTokens
+		if r.Date == date {
+			day += r.TotalTokens
+		}
+	}
+	return day, month
+}
+
+// lock takes the lock file of the ledger, so that processes sharing it
+// update it one at a time, and returns the function that releases it.
+func (l *Ledger) lock() (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to lock usage ledger: %w", err)
+	}
+	path := l.path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock usage ledger: %w", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock usage ledger: %s is held by another process; remove it if none is running", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (l *Ledger) read() ([]*Record, error) {
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger %s: %w", l.path, err)
+	}
+	return f.Records, nil
+}
+
+// write replaces the ledger file, sorted by date, provider and model.
+func (l *Ledger) write(records []*Record) error {
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Model < b.Model
+	})
+	data, err := json.MarshalIndent(file{Records: records}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(l.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".usage-*")
+	if err != nil {
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to save usage ledger: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+// tokenModel answers every call with a message that used 100 tokens, in two
+// chunks when streamed with the usage on the last.
+type tokenModel struct{}
+
+func (tokenModel) Generate(context.Context, []*schema.Message, ...model.Option) (*schema.Message, error) {
+	msg := schema.AssistantMessage("ok", nil)
+	msg.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 80, CompletionTokens: 20, TotalTokens: 100}}
+	return msg, nil
+}
+
+func (m tokenModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	last, _ := m.Generate(ctx, input, opts...)
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("o", nil), last}), nil
+}
+
+func (m tokenModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) { return m, nil }
+
+func useLedger(t *testing.T, cfg config.Usage, now time.Time) *Ledger {
+	t.Helper()
+	cfg.Path = filepath.Join(t.TempDir(), "usage.json")
+	l := New(cfg)
+	l.now = func() time.Time { return now }
+	current.Store(l)
+	t.Cleanup(func() { current.Store(nil) })
+	return l
+}
+
+func TestQuotas(t *testing.T) {
+	now := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	l := useLedger(t, config.Usage{DailyQuotas: map[string]int64{"gemini": 250}, MonthlyQuotas: map[string]int64{"gemini": 400}, AlertThreshold: 0.8}, now)
+	m := ChatModel("gemini", "gemini-2.5-flash", tokenModel{})
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := m.Generate(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream, err := m.Stream(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := stream.Recv(); errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	stream.Close()
+
+	// The stream is recorded once it ends; wait for it.
+	var records []Record
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if records, err = l.Records(now); err != nil {
+			t.Fatal(err)
+		}
+		if len(records) == 1 && records[0].Calls == 3 {
+			break
+		}
+	}
+	want := Record{Date: "2026-03-14", Provider: "gemini", Model: "gemini-2.5-flash", Calls: 3, PromptTokens: 240, CompletionTokens: 60, TotalTokens: 300}
+	if len(records) != 1 || records[0].Cost <= 0 {
+		t.Fatalf("records = %+v", records)
+	}
+	if records[0].Cost = 0; records[0] != want {
+		t.Errorf("record = %+v, want %+v", records[0], want)
+	}
+
+	// 300 tokens spent the daily quota of 250.
+	if _, err := m.Generate(ctx, nil); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("call over the daily quota: err = %v", err)
+	}
+	if err := l.Check("ollama"); err != nil {
+		t.Errorf("provider without a quota: %v", err)
+	}
+
+	// The next day only the monthly quota of 400 is left, for one call.
+	l.now = func() time.Time { return now.AddDate(0, 0, 1) }
+	if _, err := m.Generate(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Generate(ctx, nil); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("call over the monthly quota: err = %v", err)
+	}
+	quotas, err := l.Quotas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotas) != 2 || quotas[0] != (Quota{Provider: "gemini", Period: "day", Used: 100, Limit: 250}) ||
+		quotas[1] != (Quota{Provider: "gemini", Period: "month", Used: 400, Limit: 400}) {
+		t.Errorf("quotas = %+v", quotas)
+	}
+}
+
+func TestUntracked(t *testing.T) {
+	current.Store(nil)
+	if _, err := ChatModel("gemini", "gemini-2.5-flash", tokenModel{}).Generate(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSharedLedger(t *testing.T) {
+	// Two ledgers on one file stand for two processes sharing it.
+	path := filepath.Join(t.TempDir(), "usage.json")
+	chat, serve := New(config.Usage{Path: path}), New(config.Usage{Path: path})
+	var wg sync.WaitGroup
+	for _, l := range []*Ledger{chat, serve} {
+		for range 20 {
+			wg.Go(func() {
+				if err := l.Add(context.Background(), "gemini", "gemini-2.5-flash", &schema.TokenUsage{TotalTokens: 10}); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+	wg.Wait()
+
+	records, err := chat.Records(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Calls != 40 || records[0].TotalTokens != 400 {
+		t.Errorf("records = %+v, want 40 calls of 400 tokens", records)
+	}
+}
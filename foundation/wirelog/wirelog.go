@@ -0,0 +1,392 @@
+// Package wirelog records the HTTP requests sent to model and embedding
+// providers, and their responses, to debug prompt construction and provider
+// quirks without a proxy. It is off unless WIRE_LOG_DIR is set.
+//
+// Each provider logs to <dir>/<provider>.jsonl, one JSON object per call,
+// rotated by size. Credentials in headers, URLs and JSON bodies are replaced
+// with [REDACTED], as are the values of the agent's known secrets wherever
+// they appear, and bodies are cut to a maximum size.
+package wirelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/olusolaa/goforai/foundation/config"
+	"github.com/olusolaa/goforai/foundation/secrets"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are logged as [REDACTED].
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "X-Goog-Api-Key", "Api-Key", "X-Api-Key", "Cookie", "Set-Cookie"}
+
+// sensitiveParam matches URL query parameters whose values are not logged.
+var sensitiveParam = regexp.MustCompile(`(?i)^(key|api_?key|token|access_token|secret)$`)
+
+// sensitiveField matches a JSON string field whose name ends in a word for a
+// credential, such as "apiKey" or "refresh_token", up to its value. Names
+// like "totalTokenCount" do not match. It works on cut and streamed bodies,
+// which do not parse as JSON.
+var sensitiveField = regexp.MustCompile(`("(?i:[a-z0-9_]*(?:api_?key|secret|password|token|authorization|credentials?))"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// Entry is one logged call.
+type Entry struct {
+	Time            time.Time         `json:"time"`
+	Provider        string            `json:"provider"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	Status          int               `json:"status,omitempty"`
+	DurationMS      int64             `json:"duration_ms"` // Until the response body was read or closed.
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	RequestBytes    int64             `json:"request_bytes"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+	ResponseBytes   int64             `json:"response_bytes"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// Logger writes the wire log. A nil *Logger logs nothing.
+type Logger struct {
+	cfg     config.WireLog
+	secrets []string
+
+	mu    sync.Mutex
+	files map[string]*rotatingFile
+}
+
+// New returns a logger configured by cfg that also redacts every value in
+// secretValues, or nil when cfg.Dir is empty.
+func New(cfg config.WireLog, secretValues ...string) (*Logger, error) {
+	if cfg.Dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create wire log directory %s: %w", cfg.Dir, err)
+	}
+	l := &Logger{cfg: cfg, files: make(map[string]*rotatingFile)}
+	for _, v := range secretValues {
+		// Short values would redact ordinary text.
+		if len(v) >= 8 {
+			l.secrets = append(l.secrets, v)
+		}
+	}
+	return l, nil
+}
+
+var shared struct {
+	once   sync.Once
+	logger *Logger
+	err    error
+}
+
+// Default returns the process-wide logger configured from the environment,
+// redacting the values of secrets.Known. It is nil when the log is off.
+func Default() (*Logger, error) {
+	shared.once.Do(func() {
+		cfg, err := config.LoadWireLog()
+		if err != nil {
+			shared.err = err
+			return
+		}
+		if cfg.Dir == "" {
+			return
+		}
+		var values []string
+		for _, name := range secrets.Known {
+			if v, err := secrets.Get(name); err == nil {
+				values = append(values, v)
+			}
+		}
+		shared.logger, shared.err = New(cfg, values...)
+	})
+	return shared.logger, shared.err
+}
+
+// Transport returns a transport that sends requests with
+// http.DefaultTransport and logs them to the default logger under provider.
+// When the log is off it returns http.DefaultTransport itself.
+func Transport(provider string) (http.RoundTripper, error) {
+	l, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return l.Transport(provider, http.DefaultTransport), nil
+}
+
+// Transport wraps next so that its calls are logged under provider. On a nil
+// Logger it returns next.
+func (l *Logger) Transport(provider string, next http.RoundTripper) http.RoundTripper {
+	if l == nil {
+		return next
+	}
+	return &transport{log: l, provider: provider, next: next}
+}
+
+// Close closes the log files.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var errs []error
+	for _, f := range l.files {
+		errs = append(errs, f.close())
+	}
+	l.files = make(map[string]*rotatingFile)
+	return errors.Join(errs...)
+}
+
+type transport struct {
+	log      *Logger
+	provider string
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	e := &Entry{
+		Time:           start.UTC(),
+		Provider:       t.provider,
+		Method:         req.Method,
+		URL:            t.log.redactURL(req.URL),
+		RequestHeaders: t.log.redactHeaders(req.Header),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		e.RequestBody, e.RequestBytes = t.log.redactBody(req.Header.Get("Content-Type"), body, int64(len(body))), int64(len(body))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		e.DurationMS = time.Since(start).Milliseconds()
+		e.Error = t.log.redactText(err.Error())
+		t.log.write(e)
+		return nil, err
+	}
+	e.Status = resp.StatusCode
+	e.ResponseHeaders = t.log.redactHeaders(resp.Header)
+	// The entry is written once the caller has read or closed the body, so
+	// streamed responses are logged whole without being buffered.
+	resp.Body = &capture{ReadCloser: resp.Body, contentType: resp.Header.Get("Content-Type"), entry: e, start: start, log: t.log}
+	return resp, nil
+}
+
+// capture keeps the start of a response body as the caller reads it.
+type capture struct {
+	io.ReadCloser
+	contentType string
+	entry       *Entry
+	start       time.Time
+	log         *Logger
+	buf         bytes.Buffer
+	n           int64
+	once        sync.Once
+}
+
+func (c *capture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	if room := c.log.cfg.MaxBodyBytes + 1 - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(n, room)])
+	}
+	if err == io.EOF {
+		c.finish(nil)
+	} else if err != nil {
+		c.finish(err)
+	}
+	return n, err
+}
+
+func (c *capture) Close() error {
+	err := c.ReadCloser.Close()
+	c.finish(nil)
+	return err
+}
+
+func (c *capture) finish(err error) {
+	c.once.Do(func() {
+		c.entry.DurationMS = time.Since(c.start).Milliseconds()
+		c.entry.ResponseBody = c.log.redactBody(c.contentType, c.buf.Bytes(), c.n)
+		c.entry.ResponseBytes = c.n
+		if err != nil {
+			c.entry.Error = c.log.redactText(err.Error())
+		}
+		c.log.write(c.entry)
+	})
+}
+
+// write appends e to its provider's file. Failures to log never fail the
+// call being logged.
+func (l *Logger) write(e *Entry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.files[e.Provider]
+	if !ok {
+		f = &rotatingFile{path: filepath.Join(l.cfg.Dir, fileName(e.Provider)), maxBytes: l.cfg.MaxFileBytes, maxFiles: l.cfg.MaxFiles}
+		l.files[e.Provider] = f
+	}
+	f.write(data)
+}
+
+// fileName returns the log file of provider, keeping the name safe for the
+// file system.
+func fileName(provider string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, provider)
+	return name + ".jsonl"
+}
+
+func (l *Logger) redactHeaders(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		out[name] = l.redactText(strings.Join(values, ", "))
+	}
+	for _, name := range sensitiveHeaders {
+		if _, ok := out[http.CanonicalHeaderKey(name)]; ok {
+			out[http.CanonicalHeaderKey(name)] = redacted
+		}
+	}
+	return out
+}
+
+func (l *Logger) redactURL(u *url.URL) string {
+	u2 := *u
+	u2.User = nil
+	query := u2.Query()
+	for name := range query {
+		if sensitiveParam.MatchString(name) {
+			query.Set(name, redacted)
+		}
+	}
+	u2.RawQuery = query.Encode()
+	return l.redactText(u2.String())
+}
+
+// redactBody redacts the start of a body of total bytes and cuts it to the
+// configured size. Bodies that are not text, such as audio, are only
+// described.
+func (l *Logger) redactBody(contentType string, body []byte, total int64) string {
+	if !textual(contentType) {
+		return fmt.Sprintf("[%d bytes of %s]", total, contentType)
+	}
+	s := strings.ToValidUTF8(string(body), "�")
+	s = sensitiveField.ReplaceAllString(s, `${1}"`+redacted+`"`)
+	s = l.redactText(s)
+	if limit := l.cfg.MaxBodyBytes; len(s) > limit || total > int64(len(body)) {
+		cut := min(limit, len(s))
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = fmt.Sprintf("%s… (%d bytes)", s[:cut], total)
+	}
+	return s
+}
+
+// textual reports whether a body of contentType is worth logging as text.
+// Bodies without a type are assumed to be.
+func textual(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	mediaType = strings.TrimSpace(mediaType)
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") ||
+		mediaType == "application/x-www-form-urlencoded" || mediaType == "application/x-ndjson"
+}
+
+// redactText replaces the values of known secrets in s.
+func (l *Logger) redactText(s string) string {
+	for _, v := range l.secrets {
+		s = strings.ReplaceAll(s, v, redacted)
+	}
+	return s
+}
+
+// rotatingFile appends to a file and, once it would grow past maxBytes,
+// renames it to .1, shifting older files up to .maxFiles.
+type rotatingFile struct {
+	path     string
+	maxBytes int64
+	maxFiles int
+	f        *os.File
+	size     int64
+}
+
+func (r *rotatingFile) write(data []byte) {
+	if r.f != nil && r.size > 0 && r.size+int64(len(data)) > r.maxBytes {
+		r.rotate()
+	}
+	if r.f == nil {
+		f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return
+		}
+		r.f, r.size = f, info.Size()
+		if r.size > 0 && r.size+int64(len(data)) > r.maxBytes {
+			r.rotate()
+			r.write(data)
+			return
+		}
+	}
+	n, _ := r.f.Write(data)
+	r.size += int64(n)
+}
+
+func (r *rotatingFile) rotate() {
+	r.close()
+	base := strings.TrimSuffix(r.path, ".jsonl")
+	os.Remove(fmt.Sprintf("%s.%d.jsonl", base, r.maxFiles))
+	for i := r.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d.jsonl", base, i), fmt.Sprintf("%s.%d.jsonl", base, i+1))
+	}
+	os.Rename(r.path, base+".1.jsonl")
+}
+
+func (r *rotatingFile) close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f, r.size = nil, 0
+	return err
+}
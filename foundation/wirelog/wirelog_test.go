@@ -0,0 +1,143 @@
+package wirelog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olusolaa/goforai/foundation/config"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("%v: %s", err, scanner.Text())
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestTransportRedacts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "sk-live-0123456789") {
+			t.Errorf("the server did not receive the original body: %s", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"issued-abc","usage":{"totalTokenCount":42},"text":"` + strings.Repeat("x", 200) + `"}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	l, err := New(config.WireLog{Dir: dir, MaxBodyBytes: 120, MaxFileBytes: 1 << 20, MaxFiles: 2}, "sk-live-0123456789", "short")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	client := &http.Client{Transport: l.Transport("openai", http.DefaultTransport)}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/embeddings?key=AIzaSecret&alt=sse",
+		strings.NewReader(`{"input":"my key is sk-live-0123456789","apiKey":"hunter2","short":"short"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer sk-live-0123456789")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "issued-abc") {
+		t.Errorf("the caller did not receive the original response: %s", body)
+	}
+
+	entries := readEntries(t, filepath.Join(dir, "openai.jsonl"))
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Status != 200 || e.Method != "POST" || e.ResponseBytes != int64(len(body)) {
+		t.Errorf("entry = %+v", e)
+	}
+	if strings.Contains(e.URL, "AIzaSecret") || !strings.Contains(e.URL, "alt=sse") {
+		t.Errorf("url = %s", e.URL)
+	}
+	if e.RequestHeaders["Authorization"] != redacted {
+		t.Errorf("authorization = %q", e.RequestHeaders["Authorization"])
+	}
+	want := `{"input":"my key is [REDACTED]","apiKey":"[REDACTED]","short":"short"}`
+	if e.RequestBody != want {
+		t.Errorf("request body = %s, want %s", e.RequestBody, want)
+	}
+	if strings.Contains(e.ResponseBody, "issued-abc") || !strings.Contains(e.ResponseBody, `"totalTokenCount":42`) ||
+		!strings.HasSuffix(e.ResponseBody, "bytes)") {
+		t.Errorf("response body = %s", e.ResponseBody)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Write(make([]byte, 500))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	l, err := New(config.WireLog{Dir: dir, MaxBodyBytes: 100, MaxFileBytes: 600, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	client := &http.Client{Transport: l.Transport("gemini", http.DefaultTransport)}
+	for range 8 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	names, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(names) != 3 {
+		t.Fatalf("files = %v, want the current one and two rotated", names)
+	}
+	for _, name := range names {
+		info, _ := os.Stat(name)
+		if info.Size() > 600 {
+			t.Errorf("%s has %d bytes", name, info.Size())
+		}
+	}
+	if e := readEntries(t, filepath.Join(dir, "gemini.jsonl")); len(e) == 0 || e[0].ResponseBody != "[500 bytes of audio/wav]" {
+		t.Errorf("entries = %+v", e)
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	l, err := New(config.WireLog{})
+	if err != nil || l != nil {
+		t.Fatalf("New without a directory = %v, %v", l, err)
+	}
+	if got := l.Transport("gemini", http.DefaultTransport); got != http.DefaultTransport {
+		t.Errorf("a nil logger wrapped the transport")
+	}
+	if err := l.Close(); err != nil {
+		t.Error(err)
+	}
+}